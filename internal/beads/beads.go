@@ -1,4 +1,10 @@
 // Package beads provides a wrapper for the bd (beads) CLI.
+//
+// Reads against a Dolt server-mode database (List, Show, ShowMultiple) are
+// served in-process through the beads storage library over a shared
+// connection, falling back to the bd CLI whenever the store is unavailable or
+// cannot answer. Writes always go through bd. Set GT_BEADS_INPROCESS=0 to
+// force every call through the CLI.
 package beads
 
 import (
@@ -66,6 +72,7 @@ type Issue struct {
 	CreatedBy   string   `json:"created_by,omitempty"`
 	UpdatedAt   string   `json:"updated_at"`
 	ClosedAt    string   `json:"closed_at,omitempty"`
+	CloseReason string   `json:"close_reason,omitempty"`
 	Parent      string   `json:"parent,omitempty"`
 	Assignee    string   `json:"assignee,omitempty"`
 	Children    []string `json:"children,omitempty"`
@@ -502,6 +509,10 @@ func (b *Beads) List(opts ListOptions) ([]*Issue, error) {
 		args = append(args, "--limit=0")
 	}
 
+	if issues, ok := b.listInProcess(opts); ok {
+		return issues, nil
+	}
+
 	out, err := b.run(args...)
 	if err != nil {
 		return nil, err
@@ -628,6 +639,10 @@ func (b *Beads) Show(id string) (*Issue, error) {
 		return target.Show(id)
 	}
 
+	if issue, ok := b.showInProcess(id); ok {
+		return issue, nil
+	}
+
 	out, err := b.run("show", id, "--json")
 	if err != nil {
 		return nil, err
@@ -646,13 +661,18 @@ func (b *Beads) Show(id string) (*Issue, error) {
 	return issues[0], nil
 }
 
-// ShowMultiple fetches multiple issues by ID in a single bd call.
+// ShowMultiple fetches multiple issues by ID in a single bd call, or with a
+// fixed number of batched queries when served in-process.
 // Returns a map of ID to Issue. Missing IDs are not included in the map.
 func (b *Beads) ShowMultiple(ids []string) (map[string]*Issue, error) {
 	if len(ids) == 0 {
 		return make(map[string]*Issue), nil
 	}
 
+	if issues, ok := b.showMultipleInProcess(ids); ok {
+		return issues, nil
+	}
+
 	// bd show supports multiple IDs
 	args := append([]string{"show", "--json"}, ids...)
 	out, err := b.run(args...)
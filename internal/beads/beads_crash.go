@@ -0,0 +1,215 @@
+// Package beads provides crash report bead management.
+package beads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CrashReportLabel marks beads created by the daemon to record an agent death.
+const CrashReportLabel = "gt:crash-report"
+
+// crashPaneMarker separates the structured fields from the captured pane tail
+// in a crash report description. Lines after it are never parsed as fields.
+const crashPaneMarker = "--- pane tail ---"
+
+// CrashReportFields holds structured fields for crash report beads.
+// These are stored as "key: value" lines in the description.
+type CrashReportFields struct {
+	Agent        string // Agent identity (e.g., "deacon", "gastown/Toast")
+	Session      string // Tmux session name
+	ExitStatus   int    // Process exit status (-1 if unknown)
+	Signature    string // Cluster signature for grouping similar crashes
+	CapturedAt   string // ISO 8601 timestamp
+	RestartCount int    // Restart count from the daemon's restart tracker
+	CrashLoop    bool   // Whether the agent was in a crash loop at capture time
+	TrackedPID   int    // PID from the session's PID tracking file (0 if none)
+	PIDAlive     bool   // Whether the tracked PID was still running
+	DoltHealth   string // One-line Dolt server health summary
+	SuggestedFix string // Heuristic remediation hint
+	Occurrences  int    // Times this crash has hit the session while the report was open
+}
+
+// FormatCrashReportDescription creates a description string from crash report
+// fields, followed by the captured pane tail.
+func FormatCrashReportDescription(title string, fields *CrashReportFields, paneTail []string) string {
+	if fields == nil {
+		return title
+	}
+
+	orNull := func(s string) string {
+		if s == "" {
+			return "null"
+		}
+		return s
+	}
+
+	var lines []string
+	lines = append(lines, title)
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("agent: %s", fields.Agent))
+	lines = append(lines, fmt.Sprintf("session: %s", orNull(fields.Session)))
+	lines = append(lines, fmt.Sprintf("exit_status: %d", fields.ExitStatus))
+	lines = append(lines, fmt.Sprintf("signature: %s", fields.Signature))
+	lines = append(lines, fmt.Sprintf("captured_at: %s", fields.CapturedAt))
+	lines = append(lines, fmt.Sprintf("restart_count: %d", fields.RestartCount))
+	lines = append(lines, fmt.Sprintf("crash_loop: %t", fields.CrashLoop))
+	lines = append(lines, fmt.Sprintf("tracked_pid: %d", fields.TrackedPID))
+	lines = append(lines, fmt.Sprintf("pid_alive: %t", fields.PIDAlive))
+	lines = append(lines, fmt.Sprintf("dolt_health: %s", orNull(fields.DoltHealth)))
+	lines = append(lines, fmt.Sprintf("suggested_fix: %s", orNull(fields.SuggestedFix)))
+	lines = append(lines, fmt.Sprintf("occurrences: %d", max(fields.Occurrences, 1)))
+
+	if len(paneTail) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, crashPaneMarker)
+		lines = append(lines, paneTail...)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ParseCrashReportFields extracts crash report fields from an issue's description.
+// The pane tail section is ignored.
+func ParseCrashReportFields(description string) *CrashReportFields {
+	fields := &CrashReportFields{ExitStatus: -1, Occurrences: 1}
+
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimSpace(line)
+		if line == crashPaneMarker {
+			break
+		}
+		if line == "" {
+			continue
+		}
+
+		colonIdx := strings.Index(line, ":")
+		if colonIdx == -1 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:colonIdx])
+		value := strings.TrimSpace(line[colonIdx+1:])
+		if value == "null" {
+			value = ""
+		}
+
+		switch strings.ToLower(key) {
+		case "agent":
+			fields.Agent = value
+		case "session":
+			fields.Session = value
+		case "exit_status":
+			if n, err := strconv.Atoi(value); err == nil {
+				fields.ExitStatus = n
+			}
+		case "signature":
+			fields.Signature = value
+		case "captured_at":
+			fields.CapturedAt = value
+		case "restart_count":
+			if n, err := strconv.Atoi(value); err == nil {
+				fields.RestartCount = n
+			}
+		case "crash_loop":
+			fields.CrashLoop = value == "true"
+		case "tracked_pid":
+			if n, err := strconv.Atoi(value); err == nil {
+				fields.TrackedPID = n
+			}
+		case "pid_alive":
+			fields.PIDAlive = value == "true"
+		case "dolt_health":
+			fields.DoltHealth = value
+		case "suggested_fix":
+			fields.SuggestedFix = value
+		case "occurrences":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				fields.Occurrences = n
+			}
+		}
+	}
+
+	return fields
+}
+
+// CreateCrashReportBead creates a crash report bead. The signature is also
+// added as a "crash-sig:<signature>" label so similar crashes can be queried.
+func (b *Beads) CreateCrashReportBead(title string, fields *CrashReportFields, paneTail []string) (*Issue, error) {
+	// Guard against flag-like titles (gt-e0kx5: --help garbage beads)
+	if IsFlagLikeTitle(title) {
+		return nil, fmt.Errorf("refusing to create crash report bead: %w (got %q)", ErrFlagTitle, title)
+	}
+
+	description := FormatCrashReportDescription(title, fields, paneTail)
+
+	args := []string{"create", "--json",
+		"--title=" + title,
+		"--description=" + description,
+		"--type=bug",
+		"--labels=" + CrashReportLabel,
+	}
+	if fields != nil && fields.Signature != "" {
+		args = append(args, "--labels=crash-sig:"+fields.Signature)
+	}
+
+	if actor := b.getActor(); actor != "" {
+		args = append(args, "--actor="+actor)
+	}
+
+	out, err := b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var issue Issue
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return nil, fmt.Errorf("parsing bd create output: %w", err)
+	}
+
+	return &issue, nil
+}
+
+// FindOpenCrashReport returns the open crash report bead for the same crash
+// (signature) on the same session, or nil if there is none.
+func (b *Beads) FindOpenCrashReport(session, signature string) (*Issue, error) {
+	out, err := b.run("list", "--label=crash-sig:"+signature, "--status=open", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd list output: %w", err)
+	}
+	for _, issue := range issues {
+		if ParseCrashReportFields(issue.Description).Session == session {
+			return issue, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateCrashReportBead rewrites an open crash report bead with the latest
+// capture of a recurring crash.
+func (b *Beads) UpdateCrashReportBead(id, title string, fields *CrashReportFields, paneTail []string) error {
+	description := FormatCrashReportDescription(title, fields, paneTail)
+	return b.Update(id, UpdateOptions{Title: &title, Description: &description})
+}
+
+// ListCrashReports returns all open crash report beads.
+func (b *Beads) ListCrashReports() ([]*Issue, error) {
+	out, err := b.run("list", "--label="+CrashReportLabel, "--status=open", "--json")
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*Issue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parsing bd list output: %w", err)
+	}
+
+	return issues, nil
+}
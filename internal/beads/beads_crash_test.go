@@ -0,0 +1,48 @@
+package beads
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCrashReportDescription_RoundTrip(t *testing.T) {
+	fields := &CrashReportFields{
+		Agent:        "deacon",
+		Session:      "hq-deacon",
+		ExitStatus:   137,
+		Signature:    "a1b2c3d4e5f6",
+		CapturedAt:   "2026-01-15T03:00:00Z",
+		RestartCount: 5,
+		CrashLoop:    true,
+		TrackedPID:   4242,
+		PIDAlive:     false,
+		DoltHealth:   "healthy latency=3ms connections=4/1000",
+		SuggestedFix: "Process was killed (OOM?)",
+		Occurrences:  3,
+	}
+	// Pane lines that look like fields must not leak into the parsed result.
+	pane := []string{"agent: impostor", "Error: out of memory"}
+
+	desc := FormatCrashReportDescription("Crash: deacon", fields, pane)
+	if !strings.Contains(desc, crashPaneMarker) || !strings.Contains(desc, "Error: out of memory") {
+		t.Fatalf("description missing pane tail:\n%s", desc)
+	}
+
+	got := ParseCrashReportFields(desc)
+	if *got != *fields {
+		t.Errorf("round trip mismatch:\n got  %+v\n want %+v", *got, *fields)
+	}
+}
+
+func TestParseCrashReportFields_Defaults(t *testing.T) {
+	got := ParseCrashReportFields("Crash: x\n\nagent: x\nsession: null")
+	if got.ExitStatus != -1 {
+		t.Errorf("ExitStatus = %d, want -1 when absent", got.ExitStatus)
+	}
+	if got.Session != "" {
+		t.Errorf("Session = %q, want empty for null", got.Session)
+	}
+	if got.Occurrences != 1 {
+		t.Errorf("Occurrences = %d, want 1 when absent", got.Occurrences)
+	}
+}
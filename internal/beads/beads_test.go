@@ -596,6 +596,12 @@ func TestMRFieldsRoundTrip(t *testing.T) {
 		Rig:         "gastown",
 		MergeCommit: "abc123def789",
 		CloseReason: "merged",
+
+		ConflictResolutions: "go.sum (go_sum), main.go (imports)",
+		ParentMR:            "gt-mr-parent",
+		ParentHead:          "0123456789abcdef",
+		Reverts:             "aaaa..bbbb",
+		RevertedBy:          "gt-mr-rev",
 	}
 
 	// Format to string
@@ -0,0 +1,214 @@
+// Package beads provides external tracker mapping for synced beads.
+package beads
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TrackerLabel marks beads imported from an external issue tracker.
+const TrackerLabel = "gt:tracker"
+
+// TrackerSourceLabel returns the label identifying beads from one sync source.
+func TrackerSourceLabel(source string) string {
+	return "tracker:" + source
+}
+
+// trackerBodyMarker separates the tracker fields from the imported issue
+// body. The body is untrusted external text: lines after the marker are
+// never parsed as fields.
+const trackerBodyMarker = "--- tracker body ---"
+
+// TrackerFields maps a bead to its external issue. They are stored as
+// "key: value" lines at the top of the description, followed by
+// trackerBodyMarker and the imported issue body.
+//
+// State, Assignee and MR hold the values both sides agreed on at the last
+// sync. They are the merge base: a side that differs from them has changed
+// since, which is how gt issue sync decides which direction to propagate.
+type TrackerFields struct {
+	Source   string // Sync source name from settings
+	Ref      string // Stable external key ("owner/repo#12", "PROJ-12")
+	URL      string // Link to the external issue
+	State    string // Last synced state: "open" or "closed"
+	Assignee string // Last synced external assignee
+	MR       string // Last merge request note pushed ("<mr-id>:<status>")
+	BodyHash string // TrackerBodyHash of the body imported at the last sync
+	SyncedAt string // ISO 8601 time of the last sync
+}
+
+// trackerKeys are the description keys owned by TrackerFields.
+var trackerKeys = map[string]bool{
+	"tracker":           true,
+	"tracker_ref":       true,
+	"tracker_url":       true,
+	"tracker_state":     true,
+	"tracker_assignee":  true,
+	"tracker_mr":        true,
+	"tracker_body_hash": true,
+	"tracker_synced_at": true,
+}
+
+// FormatTrackerFields formats TrackerFields as description lines.
+// Only non-empty fields are included.
+func FormatTrackerFields(fields *TrackerFields) string {
+	if fields == nil {
+		return ""
+	}
+	var lines []string
+	add := func(key, value string) {
+		if value != "" {
+			lines = append(lines, key+": "+value)
+		}
+	}
+	add("tracker", fields.Source)
+	add("tracker_ref", fields.Ref)
+	add("tracker_url", fields.URL)
+	add("tracker_state", fields.State)
+	add("tracker_assignee", fields.Assignee)
+	add("tracker_mr", fields.MR)
+	add("tracker_body_hash", fields.BodyHash)
+	add("tracker_synced_at", fields.SyncedAt)
+	return strings.Join(lines, "\n")
+}
+
+// ParseTrackerFields extracts tracker fields from the metadata block of an
+// issue's description; the imported body is ignored. Returns nil if the bead
+// is not mapped to an external issue.
+func ParseTrackerFields(issue *Issue) *TrackerFields {
+	if issue == nil || issue.Description == "" {
+		return nil
+	}
+	header, _ := splitTrackerDescription(issue.Description)
+	fields := &TrackerFields{}
+	for _, line := range header {
+		key, value, ok := trackerLine(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "tracker":
+			fields.Source = value
+		case "tracker_ref":
+			fields.Ref = value
+		case "tracker_url":
+			fields.URL = value
+		case "tracker_state":
+			fields.State = value
+		case "tracker_assignee":
+			fields.Assignee = value
+		case "tracker_mr":
+			fields.MR = value
+		case "tracker_body_hash":
+			fields.BodyHash = value
+		case "tracker_synced_at":
+			fields.SyncedAt = value
+		}
+	}
+	if fields.Source == "" || fields.Ref == "" {
+		return nil
+	}
+	return fields
+}
+
+// TrackerBody returns the imported external issue body: everything after
+// the metadata block.
+func TrackerBody(description string) string {
+	_, body := splitTrackerDescription(description)
+	return body
+}
+
+// TrackerBodyHash fingerprints an external issue body, so a sync can tell
+// whether it changed without comparing against the stored description.
+func TrackerBodyHash(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(body)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// FormatTrackerDescription builds a tracked bead description: the tracker
+// fields, a blank line, trackerBodyMarker, then the external issue body.
+func FormatTrackerDescription(fields *TrackerFields, body string) string {
+	formatted := FormatTrackerFields(fields)
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return formatted
+	}
+	return formatted + "\n\n" + trackerBodyMarker + "\n" + body
+}
+
+// splitTrackerDescription splits a tracked bead description into its
+// metadata lines and the imported body. Descriptions written before the
+// marker existed keep their fields in the leading block, up to the first
+// blank line.
+func splitTrackerDescription(description string) ([]string, string) {
+	lines := strings.Split(description, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) == trackerBodyMarker {
+			return lines[:i], strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		}
+	}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			return lines[:i], strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+		}
+	}
+	return lines, ""
+}
+
+// trackerLine splits a description line into a tracker key and value.
+func trackerLine(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	colonIdx := strings.Index(line, ":")
+	if colonIdx == -1 {
+		return "", "", false
+	}
+	key := strings.ToLower(strings.TrimSpace(line[:colonIdx]))
+	if !trackerKeys[key] {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(line[colonIdx+1:]), true
+}
+
+// CreateTrackedBead creates a task bead mirroring an external issue.
+func (b *Beads) CreateTrackedBead(title string, fields *TrackerFields, body string, labels []string) (*Issue, error) {
+	if IsFlagLikeTitle(title) {
+		return nil, fmt.Errorf("refusing to create tracked bead: %w (got %q)", ErrFlagTitle, title)
+	}
+
+	args := []string{"create", "--json",
+		"--title=" + title,
+		"--description=" + FormatTrackerDescription(fields, body),
+		"--type=task",
+		"--labels=" + TrackerLabel,
+		"--labels=" + TrackerSourceLabel(fields.Source),
+	}
+	for _, l := range labels {
+		args = append(args, "--labels="+l)
+	}
+	if actor := b.getActor(); actor != "" {
+		args = append(args, "--actor="+actor)
+	}
+
+	out, err := b.run(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var issue Issue
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return nil, fmt.Errorf("parsing bd create output: %w", err)
+	}
+	return &issue, nil
+}
+
+// ListTrackedBeads returns all beads, open or closed, imported from source.
+func (b *Beads) ListTrackedBeads(source string) ([]*Issue, error) {
+	return b.List(ListOptions{
+		Status:   "all",
+		Label:    TrackerSourceLabel(source),
+		Priority: -1,
+	})
+}
@@ -0,0 +1,47 @@
+package beads
+
+import "testing"
+
+func TestTrackerFields_RoundTrip(t *testing.T) {
+	fields := &TrackerFields{
+		Source:   "github",
+		Ref:      "acme/widgets#412",
+		URL:      "https://github.com/acme/widgets/issues/412",
+		State:    "open",
+		Assignee: "octocat",
+		BodyHash: TrackerBodyHash("body"),
+		SyncedAt: "2026-10-18T12:00:00Z",
+	}
+	// The body is untrusted: field-like lines in it must not be parsed.
+	body := "tracker_state: closed\ntracker_ref: evil/repo#1\n\nSteps to reproduce:\n1. rename main"
+	issue := &Issue{Description: FormatTrackerDescription(fields, body)}
+
+	got := ParseTrackerFields(issue)
+	if got == nil || *got != *fields {
+		t.Errorf("ParseTrackerFields() = %+v, want %+v", got, fields)
+	}
+	if b := TrackerBody(issue.Description); b != body {
+		t.Errorf("TrackerBody() = %q, want %q", b, body)
+	}
+}
+
+func TestParseTrackerFields_Untracked(t *testing.T) {
+	if f := ParseTrackerFields(&Issue{Description: "tracker_url: https://x\nplain bead"}); f != nil {
+		t.Errorf("ParseTrackerFields() = %+v, want nil without source and ref", f)
+	}
+	if f := ParseTrackerFields(nil); f != nil {
+		t.Errorf("ParseTrackerFields(nil) = %+v, want nil", f)
+	}
+}
+
+func TestParseTrackerFields_LegacyDescription(t *testing.T) {
+	// Written before the body marker: fields end at the first blank line.
+	desc := "tracker: github\ntracker_ref: acme/w#1\ntracker_state: open\n\ntracker_state: closed\nbody"
+	got := ParseTrackerFields(&Issue{Description: desc})
+	if got == nil || got.State != "open" {
+		t.Errorf("ParseTrackerFields() = %+v, want state from the field block", got)
+	}
+	if b := TrackerBody(desc); b != "tracker_state: closed\nbody" {
+		t.Errorf("TrackerBody() = %q", b)
+	}
+}
@@ -234,6 +234,19 @@ type MRFields struct {
 	LastConflictSHA string // SHA of main when conflict occurred
 	ConflictTaskID  string // Link to conflict-resolution task (if any)
 
+	// ConflictResolutions records conflicts the refinery resolved
+	// automatically, as "path (strategy)" entries joined by ", ".
+	ConflictResolutions string
+
+	// Stacking: an MR built on another open MR's branch
+	ParentMR   string // MR this one is stacked on (cleared when the parent lands)
+	ParentHead string // Parent branch commit this branch was built on
+
+	// Post-merge verification: a merged commit that broke the target is
+	// undone by a revert MR
+	Reverts    string // On a revert MR: the range it undoes ("base..head")
+	RevertedBy string // On a merged MR: the revert MR that undoes it
+
 	// Convoy tracking (for priority scoring - convoy starvation prevention)
 	ConvoyID        string // Parent convoy ID if part of a convoy
 	ConvoyCreatedAt string // Convoy creation time (ISO 8601) for starvation prevention
@@ -305,6 +318,21 @@ func ParseMRFields(issue *Issue) *MRFields {
 		case "conflict_task_id", "conflict-task-id", "conflicttaskid":
 			fields.ConflictTaskID = value
 			hasFields = true
+		case "conflict_resolutions", "conflict-resolutions", "conflictresolutions":
+			fields.ConflictResolutions = value
+			hasFields = true
+		case "parent_mr", "parent-mr", "parentmr":
+			fields.ParentMR = value
+			hasFields = true
+		case "parent_head", "parent-head", "parenthead":
+			fields.ParentHead = value
+			hasFields = true
+		case "reverts":
+			fields.Reverts = value
+			hasFields = true
+		case "reverted_by", "reverted-by", "revertedby":
+			fields.RevertedBy = value
+			hasFields = true
 		case "convoy_id", "convoy-id", "convoyid", "convoy":
 			fields.ConvoyID = value
 			hasFields = true
@@ -369,6 +397,21 @@ func FormatMRFields(fields *MRFields) string {
 	if fields.ConflictTaskID != "" {
 		lines = append(lines, "conflict_task_id: "+fields.ConflictTaskID)
 	}
+	if fields.ConflictResolutions != "" {
+		lines = append(lines, "conflict_resolutions: "+fields.ConflictResolutions)
+	}
+	if fields.ParentMR != "" {
+		lines = append(lines, "parent_mr: "+fields.ParentMR)
+	}
+	if fields.ParentHead != "" {
+		lines = append(lines, "parent_head: "+fields.ParentHead)
+	}
+	if fields.Reverts != "" {
+		lines = append(lines, "reverts: "+fields.Reverts)
+	}
+	if fields.RevertedBy != "" {
+		lines = append(lines, "reverted_by: "+fields.RevertedBy)
+	}
 	if fields.ConvoyID != "" {
 		lines = append(lines, "convoy_id: "+fields.ConvoyID)
 	}
@@ -389,38 +432,51 @@ func SetMRFields(issue *Issue, fields *MRFields) string {
 
 	// Known MR field keys (lowercase)
 	mrKeys := map[string]bool{
-		"branch":             true,
-		"target":             true,
-		"source_issue":       true,
-		"source-issue":       true,
-		"sourceissue":        true,
-		"worker":             true,
-		"rig":                true,
-		"merge_commit":       true,
-		"merge-commit":       true,
-		"mergecommit":        true,
-		"close_reason":       true,
-		"close-reason":       true,
-		"closereason":        true,
-		"agent_bead":         true,
-		"agent-bead":         true,
-		"agentbead":          true,
-		"retry_count":        true,
-		"retry-count":        true,
-		"retrycount":         true,
-		"last_conflict_sha":  true,
-		"last-conflict-sha":  true,
-		"lastconflictsha":    true,
-		"conflict_task_id":   true,
-		"conflict-task-id":   true,
-		"conflicttaskid":     true,
-		"convoy_id":          true,
-		"convoy-id":          true,
-		"convoyid":           true,
-		"convoy":             true,
-		"convoy_created_at":  true,
-		"convoy-created-at":  true,
-		"convoycreatedat":    true,
+		"branch":               true,
+		"target":               true,
+		"source_issue":         true,
+		"source-issue":         true,
+		"sourceissue":          true,
+		"worker":               true,
+		"rig":                  true,
+		"merge_commit":         true,
+		"merge-commit":         true,
+		"mergecommit":          true,
+		"close_reason":         true,
+		"close-reason":         true,
+		"closereason":          true,
+		"agent_bead":           true,
+		"agent-bead":           true,
+		"agentbead":            true,
+		"retry_count":          true,
+		"retry-count":          true,
+		"retrycount":           true,
+		"last_conflict_sha":    true,
+		"last-conflict-sha":    true,
+		"lastconflictsha":      true,
+		"conflict_task_id":     true,
+		"conflict-task-id":     true,
+		"conflicttaskid":       true,
+		"conflict_resolutions": true,
+		"conflict-resolutions": true,
+		"conflictresolutions":  true,
+		"parent_mr":            true,
+		"parent-mr":            true,
+		"parentmr":             true,
+		"parent_head":          true,
+		"parent-head":          true,
+		"parenthead":           true,
+		"reverts":              true,
+		"reverted_by":          true,
+		"reverted-by":          true,
+		"revertedby":           true,
+		"convoy_id":            true,
+		"convoy-id":            true,
+		"convoyid":             true,
+		"convoy":               true,
+		"convoy_created_at":    true,
+		"convoy-created-at":    true,
+		"convoycreatedat":      true,
 	}
 
 	// Collect non-MR lines from existing description
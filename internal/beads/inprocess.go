@@ -0,0 +1,451 @@
+package beads
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+	"github.com/steveyegge/gastown/internal/telemetry"
+)
+
+// InProcessEnv disables in-process reads when set to "0" or "false",
+// forcing every operation through the bd CLI.
+const InProcessEnv = "GT_BEADS_INPROCESS"
+
+// storeOpenTimeout bounds connecting to the Dolt server for a new store.
+const storeOpenTimeout = 5 * time.Second
+
+// storeRetryAfter is how long a beads dir whose store failed to open is left
+// on the CLI before another open is attempted, so a down server costs one
+// connection attempt per interval rather than one per call.
+const storeRetryAfter = 30 * time.Second
+
+// storeCall bounds a single in-process query. On timeout the caller falls
+// back to the CLI, which has its own retry and stale handling.
+const storeCall = 10 * time.Second
+
+// storeCache holds one open storage connection per beads dir, shared by every
+// Beads wrapper in the process.
+var storeCache = struct {
+	sync.Mutex
+	stores map[string]beadsdk.Storage
+	failed map[string]time.Time
+}{
+	stores: make(map[string]beadsdk.Storage),
+	failed: make(map[string]time.Time),
+}
+
+// openStore is the store constructor, replaceable in tests.
+var openStore = beadsdk.OpenFromConfig
+
+// labelBatcher and dependencyBatcher are implemented by the Dolt store but
+// not part of beadsdk.Storage. ShowMultiple uses them to fetch labels and
+// dependencies for all IDs in one query each.
+type labelBatcher interface {
+	GetLabelsForIssues(ctx context.Context, issueIDs []string) (map[string][]string, error)
+}
+
+type dependencyBatcher interface {
+	GetDependencyRecordsForIssues(ctx context.Context, issueIDs []string) (map[string][]*beadsdk.Dependency, error)
+}
+
+// inProcessEnabled reports whether in-process reads are allowed by the environment.
+func inProcessEnabled() bool {
+	switch strings.ToLower(os.Getenv(InProcessEnv)) {
+	case "0", "false", "no", "off":
+		return false
+	}
+	return true
+}
+
+// isServerMode reports whether beadsDir's metadata.json selects Dolt server
+// mode. Only server-mode databases are opened in-process: an embedded
+// database is single-writer and must stay owned by bd.
+func isServerMode(beadsDir string) bool {
+	data, err := os.ReadFile(filepath.Join(beadsDir, "metadata.json")) //nolint:gosec // G304: path is the resolved beads dir
+	if err != nil {
+		return false
+	}
+	var meta struct {
+		DoltMode string `json:"dolt_mode"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return false
+	}
+	return meta.DoltMode == "server"
+}
+
+// store returns a shared in-process storage connection for this wrapper's
+// beads dir, or nil when the operation should go through the bd CLI:
+// isolated (test) wrappers, explicit server ports, non-server databases,
+// InProcessEnv disabled, or a recent failure to connect.
+func (b *Beads) store() beadsdk.Storage {
+	if b.isolated || b.serverPort > 0 || !inProcessEnabled() {
+		return nil
+	}
+	beadsDir := b.storeKey()
+	if beadsDir == "" {
+		return nil
+	}
+
+	storeCache.Lock()
+	defer storeCache.Unlock()
+	if s, ok := storeCache.stores[beadsDir]; ok {
+		return s
+	}
+	if t, ok := storeCache.failed[beadsDir]; ok && time.Since(t) < storeRetryAfter {
+		return nil
+	}
+	if !isServerMode(beadsDir) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeOpenTimeout)
+	defer cancel()
+	s, err := openStore(ctx, beadsDir)
+	if err != nil {
+		storeCache.failed[beadsDir] = time.Now()
+		return nil
+	}
+	delete(storeCache.failed, beadsDir)
+	storeCache.stores[beadsDir] = s
+	return s
+}
+
+// storeKey returns the absolute beads dir the store cache is keyed by.
+func (b *Beads) storeKey() string {
+	beadsDir := b.getResolvedBeadsDir()
+	if beadsDir == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(beadsDir); err == nil {
+		beadsDir = abs
+	}
+	return beadsDir
+}
+
+// dropStore closes and forgets the cached store for beadsDir if it is still
+// s, so the next call reopens against whatever metadata.json points at now
+// (after a failover, say) instead of retrying a dead connection.
+func dropStore(beadsDir string, s beadsdk.Storage) {
+	storeCache.Lock()
+	defer storeCache.Unlock()
+	if cached, ok := storeCache.stores[beadsDir]; ok && cached == s {
+		_ = s.Close()
+		delete(storeCache.stores, beadsDir)
+	}
+}
+
+// isConnectionError reports whether err means the store's server could not
+// be reached, as opposed to a query the server rejected.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.As(err, &netErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") ||
+		strings.Contains(msg, "invalid connection") ||
+		strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "bad connection")
+}
+
+// CloseStores closes every cached in-process storage connection. Long-running
+// processes call it on shutdown and after the Dolt server is switched (see
+// doltserver.Promote); later operations reopen on demand.
+func CloseStores() {
+	storeCache.Lock()
+	defer storeCache.Unlock()
+	for dir, s := range storeCache.stores {
+		_ = s.Close()
+		delete(storeCache.stores, dir)
+	}
+	for dir := range storeCache.failed {
+		delete(storeCache.failed, dir)
+	}
+}
+
+// errStoreMiss means the in-process store could not fully answer a query
+// (e.g. an ID it does not hold) and the caller should ask bd instead, which
+// also reproduces bd's own error for the case.
+var errStoreMiss = errors.New("in-process store miss")
+
+// withStore runs fn against the in-process store and records it as a bd call
+// with mode=inprocess. It returns false when no store is available or fn
+// failed, in which case the caller falls back to the CLI.
+func (b *Beads) withStore(op string, fn func(ctx context.Context, s beadsdk.Storage) error) bool {
+	s := b.store()
+	if s == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), storeCall)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx, s)
+	if errors.Is(err, errStoreMiss) {
+		// Not a failure of the store; the CLI call that follows is recorded.
+		return false
+	}
+	if isConnectionError(err) {
+		dropStore(b.storeKey(), s)
+	}
+	telemetry.RecordBDStoreCall(ctx, op, float64(time.Since(start).Milliseconds()), err)
+	return err == nil
+}
+
+// decodeIssues converts SDK values into gastown Issues via the same JSON
+// encoding bd uses for --json output, so in-process and CLI results decode
+// identically.
+func decodeIssues(v any) ([]*Issue, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var issues []*Issue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// listFilter maps ListOptions to the filter bd list builds for the equivalent
+// flags.
+func listFilter(opts ListOptions) beadsdk.IssueFilter {
+	filter := beadsdk.IssueFilter{Limit: opts.Limit}
+
+	switch opts.Status {
+	case "":
+		// bd list hides closed issues unless asked.
+		filter.ExcludeStatus = []beadsdk.Status{beadsdk.StatusClosed}
+	case "all":
+	default:
+		s := beadsdk.Status(opts.Status)
+		filter.Status = &s
+	}
+	if opts.Label != "" {
+		filter.Labels = []string{opts.Label}
+	} else if opts.Type != "" {
+		filter.Labels = []string{"gt:" + opts.Type}
+	}
+	if opts.Priority >= 0 {
+		p := opts.Priority
+		filter.Priority = &p
+	}
+	if opts.Parent != "" {
+		parent := opts.Parent
+		filter.ParentID = &parent
+	}
+	if opts.Assignee != "" {
+		assignee := opts.Assignee
+		filter.Assignee = &assignee
+	}
+	filter.NoAssignee = opts.NoAssignee
+
+	isTemplate := false
+	filter.IsTemplate = &isTemplate
+	if opts.Type != "gate" {
+		filter.ExcludeTypes = []beadsdk.IssueType{"gate"}
+	}
+	return filter
+}
+
+// listInProcess answers List from the store, shaped like bd list --json.
+func (b *Beads) listInProcess(opts ListOptions) ([]*Issue, bool) {
+	var issues []*Issue
+	ok := b.withStore("list", func(ctx context.Context, s beadsdk.Storage) error {
+		found, err := s.SearchIssues(ctx, "", listFilter(opts))
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(found))
+		for i, issue := range found {
+			ids[i] = issue.ID
+		}
+		labels, deps, err := batchLabelsAndDeps(ctx, s, ids)
+		if err != nil {
+			return err
+		}
+
+		type listed struct {
+			*beadsdk.Issue
+			Parent *string `json:"parent,omitempty"`
+		}
+		out := make([]listed, len(found))
+		for i, issue := range found {
+			issue.Labels = labels[issue.ID]
+			issue.Dependencies = deps[issue.ID]
+			out[i] = listed{Issue: issue, Parent: parentOf(deps[issue.ID])}
+		}
+		issues, err = decodeIssues(out)
+		return err
+	})
+	return issues, ok
+}
+
+// showInProcess answers Show for a single ID from the store, shaped like
+// bd show --json.
+func (b *Beads) showInProcess(id string) (*Issue, bool) {
+	var issue *Issue
+	ok := b.withStore("show", func(ctx context.Context, s beadsdk.Storage) error {
+		found, err := s.GetIssue(ctx, id)
+		if err != nil || found == nil {
+			return errStoreMiss
+		}
+		details := &issueDetails{Issue: *found, Labels: found.Labels}
+		if details.Dependencies, err = s.GetDependenciesWithMetadata(ctx, id); err != nil {
+			return err
+		}
+		if details.Dependents, err = s.GetDependentsWithMetadata(ctx, id); err != nil {
+			return err
+		}
+		for _, dep := range details.Dependencies {
+			if dep.DependencyType == beadsdk.DepParentChild {
+				parent := dep.ID
+				details.Parent = &parent
+				break
+			}
+		}
+		issues, err := decodeIssues([]*issueDetails{details})
+		if err != nil {
+			return err
+		}
+		issue = issues[0]
+		return nil
+	})
+	return issue, ok
+}
+
+// showMultipleInProcess answers ShowMultiple with a fixed number of batched
+// queries regardless of len(ids). Dependents are not populated: no batched
+// query exists for them and no ShowMultiple caller reads them.
+func (b *Beads) showMultipleInProcess(ids []string) (map[string]*Issue, bool) {
+	var result map[string]*Issue
+	ok := b.withStore("show", func(ctx context.Context, s beadsdk.Storage) error {
+		ids = uniqueStrings(ids)
+		found, err := s.GetIssuesByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		if len(found) != len(ids) {
+			// Partial IDs, wisps or routed IDs: let bd resolve them.
+			return errStoreMiss
+		}
+		labels, deps, err := batchLabelsAndDeps(ctx, s, ids)
+		if err != nil {
+			return err
+		}
+
+		var targetIDs []string
+		for _, records := range deps {
+			for _, d := range records {
+				targetIDs = append(targetIDs, d.DependsOnID)
+			}
+		}
+		targets := make(map[string]*beadsdk.Issue)
+		if len(targetIDs) > 0 {
+			issues, err := s.GetIssuesByIDs(ctx, uniqueStrings(targetIDs))
+			if err != nil {
+				return err
+			}
+			for _, t := range issues {
+				targets[t.ID] = t
+			}
+		}
+
+		details := make([]*issueDetails, len(found))
+		for i, issue := range found {
+			d := &issueDetails{Issue: *issue, Labels: labels[issue.ID]}
+			for _, rec := range deps[issue.ID] {
+				t, ok := targets[rec.DependsOnID]
+				if !ok {
+					// External or cross-database dependency; bd resolves these via routing.
+					return errStoreMiss
+				}
+				d.Dependencies = append(d.Dependencies, &beadsdk.IssueWithDependencyMetadata{
+					Issue:          *t,
+					DependencyType: rec.Type,
+				})
+			}
+			d.Parent = parentOf(deps[issue.ID])
+			details[i] = d
+		}
+
+		issues, err := decodeIssues(details)
+		if err != nil {
+			return err
+		}
+		result = make(map[string]*Issue, len(issues))
+		for _, issue := range issues {
+			result[issue.ID] = issue
+		}
+		return nil
+	})
+	return result, ok
+}
+
+// issueDetails mirrors the JSON shape of bd show --json.
+type issueDetails struct {
+	beadsdk.Issue
+	Labels       []string                               `json:"labels,omitempty"`
+	Dependencies []*beadsdk.IssueWithDependencyMetadata `json:"dependencies,omitempty"`
+	Dependents   []*beadsdk.IssueWithDependencyMetadata `json:"dependents,omitempty"`
+	Parent       *string                                `json:"parent,omitempty"`
+}
+
+// batchLabelsAndDeps fetches labels and dependency records for ids, in one
+// query each when the store supports batching and per issue otherwise.
+func batchLabelsAndDeps(ctx context.Context, s beadsdk.Storage, ids []string) (map[string][]string, map[string][]*beadsdk.Dependency, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil
+	}
+	lb, okL := s.(labelBatcher)
+	db, okD := s.(dependencyBatcher)
+	if !okL || !okD {
+		return nil, nil, errStoreMiss
+	}
+	labels, err := lb.GetLabelsForIssues(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	deps, err := db.GetDependencyRecordsForIssues(ctx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	return labels, deps, nil
+}
+
+// parentOf returns the target of the first parent-child dependency, matching
+// how bd computes the parent field.
+func parentOf(deps []*beadsdk.Dependency) *string {
+	for _, d := range deps {
+		if d.Type == beadsdk.DepParentChild {
+			parent := d.DependsOnID
+			return &parent
+		}
+	}
+	return nil
+}
+
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
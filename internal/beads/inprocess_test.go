@@ -0,0 +1,282 @@
+package beads
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	beadsdk "github.com/steveyegge/beads"
+)
+
+// fakeStore answers the handful of storage calls the in-process reads make.
+// The embedded interface is nil; any other call panics.
+type fakeStore struct {
+	beadsdk.Storage
+	issues map[string]*beadsdk.Issue
+	labels map[string][]string
+	deps   map[string][]*beadsdk.Dependency
+}
+
+func (f *fakeStore) GetIssue(_ context.Context, id string) (*beadsdk.Issue, error) {
+	issue, ok := f.issues[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	cp := *issue
+	cp.Labels = f.labels[id]
+	return &cp, nil
+}
+
+func (f *fakeStore) GetIssuesByIDs(_ context.Context, ids []string) ([]*beadsdk.Issue, error) {
+	var out []*beadsdk.Issue
+	for _, id := range ids {
+		if issue, ok := f.issues[id]; ok {
+			cp := *issue
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetLabelsForIssues(_ context.Context, ids []string) (map[string][]string, error) {
+	out := make(map[string][]string)
+	for _, id := range ids {
+		out[id] = f.labels[id]
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetDependencyRecordsForIssues(_ context.Context, ids []string) (map[string][]*beadsdk.Dependency, error) {
+	out := make(map[string][]*beadsdk.Dependency)
+	for _, id := range ids {
+		out[id] = f.deps[id]
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetDependenciesWithMetadata(_ context.Context, id string) ([]*beadsdk.IssueWithDependencyMetadata, error) {
+	var out []*beadsdk.IssueWithDependencyMetadata
+	for _, d := range f.deps[id] {
+		out = append(out, &beadsdk.IssueWithDependencyMetadata{Issue: *f.issues[d.DependsOnID], DependencyType: d.Type})
+	}
+	return out, nil
+}
+
+func (f *fakeStore) GetDependentsWithMetadata(_ context.Context, id string) ([]*beadsdk.IssueWithDependencyMetadata, error) {
+	var out []*beadsdk.IssueWithDependencyMetadata
+	for from, records := range f.deps {
+		for _, d := range records {
+			if d.DependsOnID == id {
+				out = append(out, &beadsdk.IssueWithDependencyMetadata{Issue: *f.issues[from], DependencyType: d.Type})
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeStore) Close() error { return nil }
+
+// withFakeStore points a server-mode beads dir at store and returns a wrapper for it.
+func withFakeStore(t *testing.T, store beadsdk.Storage) *Beads {
+	t.Helper()
+	t.Setenv(InProcessEnv, "")
+	beadsDir := filepath.Join(t.TempDir(), ".beads")
+	if err := os.MkdirAll(beadsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(beadsDir, "metadata.json"), []byte(`{"dolt_mode":"server"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := openStore
+	openStore = func(context.Context, string) (beadsdk.Storage, error) { return store, nil }
+	t.Cleanup(func() {
+		openStore = orig
+		CloseStores()
+	})
+	return NewWithBeadsDir(filepath.Dir(beadsDir), beadsDir)
+}
+
+func moleculeStore() *fakeStore {
+	return &fakeStore{
+		issues: map[string]*beadsdk.Issue{
+			"gt-mol":  {ID: "gt-mol", Title: "Molecule", Status: beadsdk.StatusOpen, IssueType: beadsdk.TypeEpic},
+			"gt-s1":   {ID: "gt-s1", Title: "Step 1", Status: beadsdk.StatusClosed, IssueType: beadsdk.TypeTask},
+			"gt-s2":   {ID: "gt-s2", Title: "Step 2", Status: beadsdk.StatusOpen, IssueType: beadsdk.TypeTask, Priority: 1},
+			"gt-root": {ID: "gt-root", Title: "Root", Status: beadsdk.StatusOpen, IssueType: beadsdk.TypeEpic},
+		},
+		labels: map[string][]string{"gt-s2": {"gt:task"}},
+		deps: map[string][]*beadsdk.Dependency{
+			"gt-s1": {{IssueID: "gt-s1", DependsOnID: "gt-mol", Type: beadsdk.DepParentChild}},
+			"gt-s2": {
+				{IssueID: "gt-s2", DependsOnID: "gt-mol", Type: beadsdk.DepParentChild},
+				{IssueID: "gt-s2", DependsOnID: "gt-s1", Type: beadsdk.DepBlocks},
+			},
+		},
+	}
+}
+
+func TestShowInProcess(t *testing.T) {
+	b := withFakeStore(t, moleculeStore())
+
+	issue, ok := b.showInProcess("gt-s2")
+	if !ok {
+		t.Fatal("showInProcess() fell back to CLI")
+	}
+	if issue.Title != "Step 2" || issue.Type != "task" || issue.Priority != 1 {
+		t.Errorf("issue = %+v", issue)
+	}
+	if issue.Parent != "gt-mol" {
+		t.Errorf("Parent = %q, want gt-mol", issue.Parent)
+	}
+	if !HasLabel(issue, "gt:task") {
+		t.Errorf("Labels = %v, want gt:task", issue.Labels)
+	}
+	if len(issue.Dependencies) != 2 {
+		t.Fatalf("Dependencies = %+v, want 2", issue.Dependencies)
+	}
+	if d := issue.Dependencies[1]; d.ID != "gt-s1" || d.Status != "closed" || d.DependencyType != "blocks" {
+		t.Errorf("Dependencies[1] = %+v", d)
+	}
+
+	root, ok := b.showInProcess("gt-mol")
+	if !ok {
+		t.Fatal("showInProcess(gt-mol) fell back to CLI")
+	}
+	if len(root.Dependents) != 2 {
+		t.Errorf("Dependents = %+v, want 2", root.Dependents)
+	}
+}
+
+func TestShowInProcess_MissFallsBack(t *testing.T) {
+	b := withFakeStore(t, moleculeStore())
+	if _, ok := b.showInProcess("gt-nope"); ok {
+		t.Error("showInProcess() answered for an unknown ID")
+	}
+}
+
+func TestShowMultipleInProcess(t *testing.T) {
+	b := withFakeStore(t, moleculeStore())
+
+	got, ok := b.showMultipleInProcess([]string{"gt-s1", "gt-s2", "gt-s2"})
+	if !ok {
+		t.Fatal("showMultipleInProcess() fell back to CLI")
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d issues, want 2", len(got))
+	}
+	s2 := got["gt-s2"]
+	if s2.Parent != "gt-mol" || !HasLabel(s2, "gt:task") {
+		t.Errorf("gt-s2 = %+v", s2)
+	}
+	if len(s2.Dependencies) != 2 || s2.Dependencies[1].Status != "closed" {
+		t.Errorf("gt-s2 Dependencies = %+v", s2.Dependencies)
+	}
+
+	if _, ok := b.showMultipleInProcess([]string{"gt-s1", "gt-nope"}); ok {
+		t.Error("showMultipleInProcess() answered with a missing ID")
+	}
+}
+
+func TestStore_Gating(t *testing.T) {
+	b := withFakeStore(t, moleculeStore())
+	if b.store() == nil {
+		t.Fatal("store() = nil for server-mode beads dir")
+	}
+
+	t.Setenv(InProcessEnv, "0")
+	if b.store() != nil {
+		t.Error("store() returned a store with in-process reads disabled")
+	}
+	t.Setenv(InProcessEnv, "")
+
+	isolated := NewIsolated(b.workDir)
+	isolated.beadsDir = b.beadsDir
+	if isolated.store() != nil {
+		t.Error("store() returned a store for an isolated wrapper")
+	}
+
+	embedded := filepath.Join(t.TempDir(), ".beads")
+	if err := os.MkdirAll(embedded, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if NewWithBeadsDir(filepath.Dir(embedded), embedded).store() != nil {
+		t.Error("store() returned a store for a non-server beads dir")
+	}
+}
+
+func TestStore_OpenFailureBacksOff(t *testing.T) {
+	b := withFakeStore(t, nil)
+	calls := 0
+	openStore = func(context.Context, string) (beadsdk.Storage, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	}
+
+	for i := 0; i < 3; i++ {
+		if b.store() != nil {
+			t.Fatal("store() returned a store after open failed")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("open attempts = %d, want 1 within the retry interval", calls)
+	}
+}
+
+func TestWithStore_ConnectionErrorDropsStore(t *testing.T) {
+	b := withFakeStore(t, moleculeStore())
+	opens := 0
+	openStore = func(context.Context, string) (beadsdk.Storage, error) {
+		opens++
+		return moleculeStore(), nil
+	}
+
+	b.withStore("test", func(context.Context, beadsdk.Storage) error { return errors.New("not found") })
+	b.withStore("test", func(context.Context, beadsdk.Storage) error { return nil })
+	if opens != 1 {
+		t.Fatalf("opens after a query error = %d, want the store kept (1)", opens)
+	}
+
+	b.withStore("test", func(context.Context, beadsdk.Storage) error {
+		return errors.New("dial tcp 127.0.0.1:3307: connect: connection refused")
+	})
+	b.withStore("test", func(context.Context, beadsdk.Storage) error { return nil })
+	if opens != 2 {
+		t.Errorf("opens after a connection error = %d, want the store reopened (2)", opens)
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	f := listFilter(ListOptions{Label: "gt:agent", Priority: -1, Assignee: "gastown/Toast"})
+	if len(f.ExcludeStatus) != 1 || f.ExcludeStatus[0] != beadsdk.StatusClosed || f.Status != nil {
+		t.Errorf("default status: Status=%v ExcludeStatus=%v, want closed excluded", f.Status, f.ExcludeStatus)
+	}
+	if len(f.Labels) != 1 || f.Labels[0] != "gt:agent" {
+		t.Errorf("Labels = %v", f.Labels)
+	}
+	if f.Priority != nil {
+		t.Errorf("Priority = %v, want nil", *f.Priority)
+	}
+	if f.Assignee == nil || *f.Assignee != "gastown/Toast" {
+		t.Errorf("Assignee = %v", f.Assignee)
+	}
+	if f.IsTemplate == nil || *f.IsTemplate {
+		t.Error("templates should be excluded")
+	}
+
+	f = listFilter(ListOptions{Status: "all", Type: "molecule", Priority: 0, Parent: "gt-mol"})
+	if f.Status != nil || len(f.ExcludeStatus) != 0 {
+		t.Errorf("status all: Status=%v ExcludeStatus=%v", f.Status, f.ExcludeStatus)
+	}
+	if len(f.Labels) != 1 || f.Labels[0] != "gt:molecule" {
+		t.Errorf("Labels = %v, want gt:molecule", f.Labels)
+	}
+	if f.Priority == nil || *f.Priority != 0 {
+		t.Error("Priority 0 should filter P0")
+	}
+	if f.ParentID == nil || *f.ParentID != "gt-mol" {
+		t.Errorf("ParentID = %v", f.ParentID)
+	}
+}
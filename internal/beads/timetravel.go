@@ -0,0 +1,655 @@
+package beads
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	beadsdk "github.com/steveyegge/beads"
+)
+
+// Time-travel reads.
+//
+// Beads live in Dolt, so every bd write is a commit and past states can be
+// read with AS OF. These helpers resolve an --as-of spec (a commit hash or a
+// time) to a commit of the wrapper's database and read issues, labels and
+// dependencies as of that commit. Queries go through the in-process store's
+// SQL connection when available and through `bd sql --json` otherwise.
+
+// commitRefPattern matches a Dolt commit hash (32 base32 characters).
+var commitRefPattern = regexp.MustCompile(`^[0-9a-v]{32}$`)
+
+// readyExcludedTypes are the types bd ready leaves out: workflow and identity
+// beads rather than claimable work.
+var readyExcludedTypes = map[string]bool{
+	"merge-request": true, "gate": true, "molecule": true, "message": true,
+	"agent": true, "role": true, "rig": true,
+}
+
+// historyIgnoredColumns are issue columns that change with every write and
+// carry no information of their own in a field-level diff.
+var historyIgnoredColumns = map[string]bool{
+	"updated_at":   true,
+	"content_hash": true,
+}
+
+// Revision is a commit of a beads database.
+type Revision struct {
+	Commit string    `json:"commit"`
+	Date   time.Time `json:"date"`
+}
+
+// TimeSpec returns the revision's date as an --as-of spec, for resolving the
+// same moment in another database (commit hashes are per database).
+func (r *Revision) TimeSpec() string {
+	return r.Date.UTC().Format(time.RFC3339Nano)
+}
+
+// FieldChange is one field's change in one commit.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// HistoryEntry is a commit that changed a bead, with the fields it changed.
+type HistoryEntry struct {
+	Commit  string        `json:"commit"`
+	Date    time.Time     `json:"date"`
+	Author  string        `json:"author"`
+	Created bool          `json:"created,omitempty"`
+	Deleted bool          `json:"deleted,omitempty"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// IsCommitRef reports whether spec is a Dolt commit hash rather than a time.
+func IsCommitRef(spec string) bool {
+	return commitRefPattern.MatchString(strings.TrimSpace(spec))
+}
+
+// ParseAsOfTime parses the time form of an --as-of spec: a duration meaning
+// "that long ago" (90m, 6h), RFC 3339, or local "2006-01-02 15:04[:05]" /
+// "2006-01-02".
+func ParseAsOfTime(spec string, now time.Time) (time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %q must not be negative", spec)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, spec); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, spec, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized --as-of %q (use a commit hash, a duration like 2h, 2026-01-02 15:04, or RFC 3339)", spec)
+}
+
+// ResolveAsOf resolves an --as-of spec to a commit of this wrapper's
+// database: a commit hash is looked up as-is, a time selects the newest commit
+// at or before it.
+func (b *Beads) ResolveAsOf(spec string) (*Revision, error) {
+	spec = strings.TrimSpace(spec)
+	query := "SELECT commit_hash, date FROM dolt_log WHERE commit_hash = ?"
+	arg := spec
+	if !IsCommitRef(spec) {
+		at, err := ParseAsOfTime(spec, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		query = "SELECT commit_hash, date FROM dolt_log WHERE date <= ? ORDER BY date DESC LIMIT 1"
+		arg = at.UTC().Format("2006-01-02 15:04:05.999999")
+	}
+
+	rows, err := b.querySQL(query, arg)
+	if err != nil {
+		return nil, fmt.Errorf("resolving --as-of %s: %w", spec, err)
+	}
+	if len(rows) == 0 {
+		if IsCommitRef(spec) {
+			return nil, fmt.Errorf("commit %s not found in %s", spec, b.getResolvedBeadsDir())
+		}
+		return nil, fmt.Errorf("no beads history at or before %s", spec)
+	}
+	return &Revision{Commit: rows[0]["commit_hash"], Date: parseSQLTime(rows[0]["date"])}, nil
+}
+
+// ListAsOf is List as of an --as-of spec. It returns the resolved revision so
+// callers can show which commit they are looking at.
+func (b *Beads) ListAsOf(opts ListOptions, spec string) ([]*Issue, *Revision, error) {
+	rev, err := b.ResolveAsOf(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	snap, err := b.loadSnapshot(rev.Commit, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Same filter the in-process List hands the store, so both agree with
+	// bd list on what each option means.
+	filter := listFilter(opts)
+	var issues []*Issue
+	for _, issue := range snap.issues() {
+		if matchesFilter(filter, issue, snap.rows[issue.ID]) {
+			issues = append(issues, issue)
+		}
+	}
+	if opts.Limit > 0 && len(issues) > opts.Limit {
+		issues = issues[:opts.Limit]
+	}
+	return issues, rev, nil
+}
+
+// matchesFilter reports whether a snapshot issue passes the parts of an
+// IssueFilter that listFilter sets. row is the issue's raw AS OF row, for
+// columns Issue does not carry.
+func matchesFilter(f beadsdk.IssueFilter, issue *Issue, row map[string]string) bool {
+	if f.Status != nil && issue.Status != string(*f.Status) {
+		return false
+	}
+	for _, st := range f.ExcludeStatus {
+		if issue.Status == string(st) {
+			return false
+		}
+	}
+	for _, label := range f.Labels {
+		if !HasLabel(issue, label) {
+			return false
+		}
+	}
+	if f.Priority != nil && issue.Priority != *f.Priority {
+		return false
+	}
+	if f.ParentID != nil && issue.Parent != *f.ParentID {
+		return false
+	}
+	if f.Assignee != nil && issue.Assignee != *f.Assignee {
+		return false
+	}
+	if f.NoAssignee && issue.Assignee != "" {
+		return false
+	}
+	if f.IsTemplate != nil && sqlBool(row["is_template"]) != *f.IsTemplate {
+		return false
+	}
+	for _, t := range f.ExcludeTypes {
+		if issue.Type == string(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadyAsOf is Ready as of an --as-of spec: open or in-progress work that was
+// not blocked, pinned, ephemeral or a workflow/identity bead at that commit.
+func (b *Beads) ReadyAsOf(spec string) ([]*Issue, *Revision, error) {
+	rev, err := b.ResolveAsOf(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	snap, err := b.loadSnapshot(rev.Commit, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var issues []*Issue
+	for _, issue := range snap.issues() {
+		row := snap.rows[issue.ID]
+		if issue.Status != "open" && issue.Status != "in_progress" {
+			continue
+		}
+		if sqlBool(row["pinned"]) || sqlBool(row["ephemeral"]) || sqlBool(row["is_template"]) {
+			continue
+		}
+		if readyExcludedTypes[issue.Type] {
+			continue
+		}
+		if len(issue.BlockedBy) > 0 {
+			continue
+		}
+		// Children of blocked parents are not ready either.
+		if parent := snap.byID[issue.Parent]; parent != nil && len(parent.BlockedBy) > 0 {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Priority != issues[j].Priority {
+			return issues[i].Priority < issues[j].Priority
+		}
+		return issues[i].CreatedAt > issues[j].CreatedAt
+	})
+	return issues, rev, nil
+}
+
+// ShowAsOf is Show as of an --as-of spec. Like Show it routes rig-level IDs
+// to their own database; a commit hash is translated to its date first, since
+// the other database has its own commits.
+func (b *Beads) ShowAsOf(id, spec string) (*Issue, *Revision, error) {
+	targetDir := ResolveRoutingTarget(b.getTownRoot(), id, b.getResolvedBeadsDir())
+	if targetDir != b.getResolvedBeadsDir() {
+		if IsCommitRef(spec) {
+			if rev, err := b.ResolveAsOf(spec); err == nil {
+				spec = rev.TimeSpec()
+			}
+		}
+		target := NewWithBeadsDir(filepath.Dir(targetDir), targetDir)
+		return target.ShowAsOf(id, spec)
+	}
+
+	rev, err := b.ResolveAsOf(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	snap, err := b.loadSnapshot(rev.Commit, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	issue := snap.byID[id]
+	if issue == nil {
+		return nil, rev, ErrNotFound
+	}
+	return issue, rev, nil
+}
+
+// History returns the commits that changed a bead, oldest first, with the
+// fields each one changed. Label changes are reported as a "labels" field.
+// The author is the actor recorded in the bead's event log for that commit,
+// falling back to the Dolt committer.
+func (b *Beads) History(id string) ([]HistoryEntry, error) {
+	targetDir := ResolveRoutingTarget(b.getTownRoot(), id, b.getResolvedBeadsDir())
+	if targetDir != b.getResolvedBeadsDir() {
+		target := NewWithBeadsDir(filepath.Dir(targetDir), targetDir)
+		return target.History(id)
+	}
+
+	issueRows, err := b.querySQL(
+		"SELECT d.*, l.committer AS log_committer FROM dolt_diff_issues d LEFT JOIN dolt_log l ON l.commit_hash = d.to_commit WHERE d.to_id = ? OR d.from_id = ?", id, id)
+	if err != nil {
+		return nil, fmt.Errorf("reading history of %s: %w", id, err)
+	}
+	if len(issueRows) == 0 {
+		return nil, ErrNotFound
+	}
+	labelRows, err := b.querySQL(
+		"SELECT to_commit, to_commit_date, from_label, to_label, diff_type FROM dolt_diff_labels WHERE to_issue_id = ? OR from_issue_id = ?", id, id)
+	if err != nil {
+		labelRows = nil // labels history is best-effort
+	}
+	actors := make(map[string]string)
+	if eventRows, err := b.querySQL(
+		"SELECT to_commit, to_actor FROM dolt_diff_events WHERE to_issue_id = ? AND diff_type = 'added'", id); err == nil {
+		for _, r := range eventRows {
+			if r["to_actor"] != "" {
+				actors[r["to_commit"]] = r["to_actor"]
+			}
+		}
+	}
+
+	return buildHistory(issueRows, labelRows, actors), nil
+}
+
+// buildHistory turns dolt_diff_issues and dolt_diff_labels rows into history
+// entries, one per commit, oldest first.
+func buildHistory(issueRows, labelRows []map[string]string, actors map[string]string) []HistoryEntry {
+	byCommit := make(map[string]*HistoryEntry)
+	entry := func(commit, date string) *HistoryEntry {
+		e := byCommit[commit]
+		if e == nil {
+			e = &HistoryEntry{Commit: commit, Date: parseSQLTime(date)}
+			byCommit[commit] = e
+		}
+		return e
+	}
+
+	for _, row := range issueRows {
+		e := entry(row["to_commit"], row["to_commit_date"])
+		e.Author = row["log_committer"]
+		switch row["diff_type"] {
+		case "added":
+			e.Created = true
+		case "removed":
+			e.Deleted = true
+		}
+		e.Changes = append(e.Changes, diffIssueRow(row)...)
+	}
+	for _, row := range labelRows {
+		e := entry(row["to_commit"], row["to_commit_date"])
+		switch row["diff_type"] {
+		case "added":
+			e.Changes = append(e.Changes, FieldChange{Field: "labels", New: "+" + row["to_label"]})
+		case "removed":
+			e.Changes = append(e.Changes, FieldChange{Field: "labels", Old: "-" + row["from_label"]})
+		}
+	}
+
+	entries := make([]HistoryEntry, 0, len(byCommit))
+	for _, e := range byCommit {
+		if len(e.Changes) == 0 && !e.Created && !e.Deleted {
+			continue
+		}
+		if actor := actors[e.Commit]; actor != "" {
+			e.Author = actor
+		}
+		entries = append(entries, *e)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+	return entries
+}
+
+// diffIssueRow returns the fields that differ between the from_ and to_
+// columns of a dolt_diff_issues row, sorted by field name.
+func diffIssueRow(row map[string]string) []FieldChange {
+	var changes []FieldChange
+	for key, newVal := range row {
+		field, ok := strings.CutPrefix(key, "to_")
+		if !ok || field == "commit" || field == "commit_date" || historyIgnoredColumns[field] {
+			continue
+		}
+		oldVal, ok := row["from_"+field]
+		if !ok || oldVal == newVal {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, Old: oldVal, New: newVal})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// asOfSnapshot is a database's issues, labels and dependencies at a commit.
+type asOfSnapshot struct {
+	rows  map[string]map[string]string
+	byID  map[string]*Issue
+	order []string
+}
+
+// issues returns the snapshot's issues in query order.
+func (s *asOfSnapshot) issues() []*Issue {
+	out := make([]*Issue, 0, len(s.order))
+	for _, id := range s.order {
+		out = append(out, s.byID[id])
+	}
+	return out
+}
+
+// loadSnapshot reads issues, labels and dependencies AS OF commit. When onlyID
+// is set, only that issue and the issues on either end of its dependencies
+// are read (the latter for titles and status).
+func (b *Beads) loadSnapshot(commit, onlyID string) (*asOfSnapshot, error) {
+	// commit comes from dolt_log via ResolveAsOf, not from the caller.
+	asOf := "AS OF " + sqlQuote(commit)
+	issueQuery := fmt.Sprintf("SELECT * FROM issues %s", asOf)
+	labelQuery := fmt.Sprintf("SELECT issue_id, label FROM labels %s", asOf)
+	depQuery := fmt.Sprintf("SELECT issue_id, depends_on_id, type FROM dependencies %s", asOf)
+	var issueArgs, labelArgs, depArgs []any
+	if onlyID != "" {
+		issueQuery += fmt.Sprintf(" WHERE id = ?"+
+			" OR id IN (SELECT depends_on_id FROM dependencies %s WHERE issue_id = ?)"+
+			" OR id IN (SELECT issue_id FROM dependencies %s WHERE depends_on_id = ?)", asOf, asOf)
+		issueArgs = []any{onlyID, onlyID, onlyID}
+		labelQuery += " WHERE issue_id = ?"
+		labelArgs = []any{onlyID}
+		depQuery += " WHERE issue_id = ? OR depends_on_id = ?"
+		depArgs = []any{onlyID, onlyID}
+	}
+	issueQuery += " ORDER BY priority, created_at DESC"
+
+	issueRows, err := b.querySQL(issueQuery, issueArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("reading issues as of %s: %w", commit, err)
+	}
+	labelRows, err := b.querySQL(labelQuery, labelArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("reading labels as of %s: %w", commit, err)
+	}
+	depRows, err := b.querySQL(depQuery, depArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("reading dependencies as of %s: %w", commit, err)
+	}
+	return buildSnapshot(issueRows, labelRows, depRows, onlyID), nil
+}
+
+// buildSnapshot assembles Issues from raw AS OF rows, filling labels, parent,
+// dependency lists and BlockedBy the way bd list/show --json would.
+func buildSnapshot(issueRows, labelRows, depRows []map[string]string, onlyID string) *asOfSnapshot {
+	s := &asOfSnapshot{
+		rows: make(map[string]map[string]string, len(issueRows)),
+		byID: make(map[string]*Issue, len(issueRows)),
+	}
+	all := make(map[string]*Issue, len(issueRows))
+	for _, row := range issueRows {
+		issue := issueFromRow(row)
+		all[issue.ID] = issue
+		if onlyID != "" && issue.ID != onlyID {
+			continue
+		}
+		s.rows[issue.ID] = row
+		s.byID[issue.ID] = issue
+		s.order = append(s.order, issue.ID)
+	}
+
+	for _, row := range labelRows {
+		if issue := s.byID[row["issue_id"]]; issue != nil {
+			issue.Labels = append(issue.Labels, row["label"])
+		}
+	}
+
+	for _, row := range depRows {
+		from, to, depType := row["issue_id"], row["depends_on_id"], row["type"]
+		target := all[ExtractIssueID(to)]
+		if issue := s.byID[from]; issue != nil {
+			issue.DependsOn = append(issue.DependsOn, to)
+			issue.DependencyCount++
+			dep := IssueDep{ID: to, DependencyType: depType}
+			if target != nil {
+				dep.Title, dep.Status, dep.Priority, dep.Type = target.Title, target.Status, target.Priority, target.Type
+			}
+			issue.Dependencies = append(issue.Dependencies, dep)
+			switch depType {
+			case "parent-child":
+				issue.Parent = to
+			case "blocks":
+				if target != nil && target.Status != "closed" {
+					issue.BlockedBy = append(issue.BlockedBy, to)
+					issue.BlockedByCount++
+				}
+			}
+		}
+		if issue := s.byID[to]; issue != nil {
+			issue.DependentCount++
+			dep := IssueDep{ID: from, DependencyType: depType}
+			if source := all[from]; source != nil {
+				dep.Title, dep.Status, dep.Priority, dep.Type = source.Title, source.Status, source.Priority, source.Type
+			}
+			issue.Dependents = append(issue.Dependents, dep)
+			switch depType {
+			case "parent-child":
+				issue.Children = append(issue.Children, from)
+			case "blocks":
+				issue.Blocks = append(issue.Blocks, from)
+			}
+		}
+	}
+	return s
+}
+
+// issueFromRow converts an issues row to an Issue. Columns missing from older
+// schemas are simply left empty.
+func issueFromRow(row map[string]string) *Issue {
+	priority, _ := strconv.Atoi(row["priority"])
+	return &Issue{
+		ID:          row["id"],
+		Title:       row["title"],
+		Description: row["description"],
+		Status:      row["status"],
+		Priority:    priority,
+		Type:        row["issue_type"],
+		CreatedAt:   sqlTimestamp(row["created_at"]),
+		CreatedBy:   row["created_by"],
+		UpdatedAt:   sqlTimestamp(row["updated_at"]),
+		ClosedAt:    sqlTimestamp(row["closed_at"]),
+		CloseReason: row["close_reason"],
+		Assignee:    row["assignee"],
+		Ephemeral:   sqlBool(row["ephemeral"]),
+		HookBead:    row["hook_bead"],
+		AgentState:  row["agent_state"],
+	}
+}
+
+// underlyingDB is implemented by the Dolt store; it exposes the SQL
+// connection for queries the Storage interface has no method for.
+type underlyingDB interface {
+	UnderlyingDB() *sql.DB
+}
+
+// querySQL runs a read query with ? placeholders bound to args and returns
+// each row as column -> string value (NULL as ""). It uses the in-process
+// store's connection when available and `bd sql --json` otherwise; bd sql
+// takes no parameters, so there the args are inlined as quoted literals.
+func (b *Beads) querySQL(query string, args ...any) ([]map[string]string, error) {
+	var rows []map[string]string
+	ok := b.withStore("sql", func(ctx context.Context, s beadsdk.Storage) error {
+		u, ok := s.(underlyingDB)
+		if !ok || u.UnderlyingDB() == nil {
+			return errStoreMiss
+		}
+		var err error
+		rows, err = scanSQLRows(ctx, u.UnderlyingDB(), query, args...)
+		return err
+	})
+	if ok {
+		return rows, nil
+	}
+
+	out, err := b.run("sql", "--json", inlineSQLArgs(query, args))
+	if err != nil {
+		return nil, err
+	}
+	var raw []map[string]any
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parsing bd sql output: %w", err)
+	}
+	rows = make([]map[string]string, len(raw))
+	for i, r := range raw {
+		rows[i] = make(map[string]string, len(r))
+		for k, v := range r {
+			rows[i][k] = sqlString(v)
+		}
+	}
+	return rows, nil
+}
+
+// scanSQLRows runs query on db and converts every row with sqlString.
+func scanSQLRows(ctx context.Context, db *sql.DB, query string, args ...any) ([]map[string]string, error) {
+	rs, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rs.Close()
+
+	cols, err := rs.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]string
+	for rs.Next() {
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rs.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(cols))
+		for i, c := range cols {
+			row[c] = sqlString(vals[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, rs.Err()
+}
+
+// sqlString renders a scanned or JSON-decoded SQL value as text.
+func sqlString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sqlBool interprets a tinyint/boolean column rendered by sqlString.
+func sqlBool(s string) bool {
+	return s == "1" || strings.EqualFold(s, "true")
+}
+
+// sqlQuote quotes s as a SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), "'", "''") + "'"
+}
+
+// inlineSQLArgs replaces each ? placeholder in query with the next arg as a
+// quoted literal. Placeholders never appear inside literals in our queries.
+func inlineSQLArgs(query string, args []any) string {
+	if len(args) == 0 {
+		return query
+	}
+	parts := strings.Split(query, "?")
+	var b strings.Builder
+	for i, part := range parts {
+		b.WriteString(part)
+		if i < len(parts)-1 && i < len(args) {
+			b.WriteString(sqlQuote(fmt.Sprint(args[i])))
+		}
+	}
+	return b.String()
+}
+
+// sqlTimestamp renders a DATETIME column as RFC 3339, the form bd's --json
+// output uses, whichever way the driver returned it.
+func sqlTimestamp(s string) string {
+	if t := parseSQLTime(s); !t.IsZero() {
+		return t.Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+// parseSQLTime parses a DATETIME column as rendered by the driver or bd sql.
+// Dolt stores commit dates in UTC.
+func parseSQLTime(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,201 @@
+package beads
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsCommitRef(t *testing.T) {
+	if !IsCommitRef("8l8oeh1lr4ckiv5etqd8fvsbvt4dt0ef") {
+		t.Error("32-char base32 hash should be a commit ref")
+	}
+	for _, s := range []string{"2h", "2026-10-17", "main", "8l8oeh1lr4ckiv5etqd8fvsbvt4dt0e", "8L8OEH1LR4CKIV5ETQD8FVSBVT4DT0EF"} {
+		if IsCommitRef(s) {
+			t.Errorf("IsCommitRef(%q) = true", s)
+		}
+	}
+}
+
+func TestParseAsOfTime(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"90m", now.Add(-90 * time.Minute)},
+		{"2026-10-16T08:00:00Z", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{"2026-10-16 08:00:30", time.Date(2026, 10, 16, 8, 0, 30, 0, time.Local)},
+		{"2026-10-16 08:00", time.Date(2026, 10, 16, 8, 0, 0, 0, time.Local)},
+		{"2026-10-16", time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseAsOfTime(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseAsOfTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseAsOfTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "yesterday", "-1h"} {
+		if _, err := ParseAsOfTime(bad, now); err == nil {
+			t.Errorf("ParseAsOfTime(%q): expected error", bad)
+		}
+	}
+}
+
+func TestDiffIssueRow(t *testing.T) {
+	row := map[string]string{
+		"to_id": "gt-1", "from_id": "gt-1",
+		"to_assignee": "gastown/polecats/nux", "from_assignee": "",
+		"to_status": "hooked", "from_status": "open",
+		"to_title": "Fix", "from_title": "Fix",
+		"to_updated_at": "b", "from_updated_at": "a",
+		"to_commit": "c2", "from_commit": "c1",
+		"to_commit_date": "2026-10-17 12:00:00", "from_commit_date": "2026-10-17 11:00:00",
+		"diff_type": "modified",
+	}
+	got := diffIssueRow(row)
+	want := []FieldChange{
+		{Field: "assignee", Old: "", New: "gastown/polecats/nux"},
+		{Field: "status", Old: "open", New: "hooked"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("diffIssueRow = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildHistory(t *testing.T) {
+	issueRows := []map[string]string{
+		{
+			"to_commit": "c2", "to_commit_date": "2026-10-17 12:00:00", "diff_type": "modified",
+			"log_committer": "root", "to_assignee": "gastown/polecats/nux", "from_assignee": "",
+		},
+		{
+			"to_commit": "c1", "to_commit_date": "2026-10-17 11:00:00", "diff_type": "added",
+			"log_committer": "root", "to_title": "Fix", "from_title": "",
+		},
+		{
+			// Only bookkeeping columns changed: not reported.
+			"to_commit": "c3", "to_commit_date": "2026-10-17 13:00:00", "diff_type": "modified",
+			"log_committer": "root", "to_updated_at": "b", "from_updated_at": "a",
+		},
+	}
+	labelRows := []map[string]string{
+		{"to_commit": "c2", "to_commit_date": "2026-10-17 12:00:00", "to_label": "urgent", "diff_type": "added"},
+	}
+	actors := map[string]string{"c2": "mayor"}
+
+	entries := buildHistory(issueRows, labelRows, actors)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if entries[0].Commit != "c1" || !entries[0].Created || entries[0].Author != "root" {
+		t.Errorf("first entry = %+v, want creation by committer", entries[0])
+	}
+	second := entries[1]
+	if second.Commit != "c2" || second.Author != "mayor" {
+		t.Errorf("second entry = %+v, want c2 attributed to the event actor", second)
+	}
+	if len(second.Changes) != 2 || second.Changes[0].Field != "assignee" || second.Changes[1].New != "+urgent" {
+		t.Errorf("second entry changes = %+v", second.Changes)
+	}
+}
+
+func TestBuildSnapshot(t *testing.T) {
+	issueRows := []map[string]string{
+		{"id": "gt-1", "status": "open", "priority": "1", "issue_type": "task"},
+		{"id": "gt-2", "status": "open", "priority": "2", "issue_type": "task"},
+		{"id": "gt-3", "status": "closed", "priority": "2", "issue_type": "task"},
+		{"id": "gt-epic", "status": "open", "priority": "0", "issue_type": "epic"},
+	}
+	labelRows := []map[string]string{{"issue_id": "gt-1", "label": "gt:merge-request"}}
+	depRows := []map[string]string{
+		{"issue_id": "gt-1", "depends_on_id": "gt-2", "type": "blocks"},
+		{"issue_id": "gt-2", "depends_on_id": "gt-3", "type": "blocks"},
+		{"issue_id": "gt-2", "depends_on_id": "gt-epic", "type": "parent-child"},
+	}
+
+	s := buildSnapshot(issueRows, labelRows, depRows, "")
+	one, two := s.byID["gt-1"], s.byID["gt-2"]
+	if !HasLabel(one, "gt:merge-request") || one.Priority != 1 {
+		t.Errorf("gt-1 = %+v", one)
+	}
+	if len(one.BlockedBy) != 1 || one.BlockedBy[0] != "gt-2" {
+		t.Errorf("gt-1 should be blocked by open gt-2, got %v", one.BlockedBy)
+	}
+	if len(two.BlockedBy) != 0 {
+		t.Errorf("gt-2 is only blocked by closed gt-3, got %v", two.BlockedBy)
+	}
+	if two.Parent != "gt-epic" || len(s.byID["gt-epic"].Children) != 1 {
+		t.Errorf("parent-child not linked: parent=%q children=%v", two.Parent, s.byID["gt-epic"].Children)
+	}
+
+	only := buildSnapshot(issueRows, labelRows, depRows, "gt-2")
+	if len(only.issues()) != 1 || only.byID["gt-2"].Dependencies[0].Status != "closed" {
+		t.Errorf("single-issue snapshot = %+v", only.issues())
+	}
+}
+
+func TestSQLQuote(t *testing.T) {
+	if got := sqlQuote(`it's \`); got != `'it''s \\'` {
+		t.Errorf("sqlQuote = %s", got)
+	}
+}
+
+func TestInlineSQLArgs(t *testing.T) {
+	got := inlineSQLArgs("SELECT * FROM issues WHERE id = ? OR id = ?", []any{"gt-1", "x' OR '1'='1"})
+	want := `SELECT * FROM issues WHERE id = 'gt-1' OR id = 'x'' OR ''1''=''1'`
+	if got != want {
+		t.Errorf("inlineSQLArgs = %s, want %s", got, want)
+	}
+	if got := inlineSQLArgs("SELECT 1", nil); got != "SELECT 1" {
+		t.Errorf("no args = %s", got)
+	}
+}
+
+// TestMatchesListFilter checks that as-of listing applies each ListOptions
+// field the way bd list does.
+func TestMatchesListFilter(t *testing.T) {
+	issue := &Issue{ID: "gt-1", Status: "open", Priority: 2, Type: "task",
+		Labels: []string{"gt:task", "urgent"}, Parent: "gt-epic", Assignee: "gastown/Toast"}
+	gate := &Issue{ID: "gt-g", Status: "open", Priority: 2, Type: "gate", Labels: []string{"gt:gate"}}
+	closed := &Issue{ID: "gt-c", Status: "closed", Priority: 2, Type: "task"}
+	template := &Issue{ID: "gt-t", Status: "open", Priority: 2, Type: "task"}
+	rows := map[string]map[string]string{"gt-t": {"is_template": "1"}}
+
+	tests := []struct {
+		name  string
+		opts  ListOptions
+		issue *Issue
+		want  bool
+	}{
+		{"default lists open", ListOptions{Priority: -1}, issue, true},
+		{"default hides closed", ListOptions{Priority: -1}, closed, false},
+		{"all shows closed", ListOptions{Status: "all", Priority: -1}, closed, true},
+		{"status must match", ListOptions{Status: "in_progress", Priority: -1}, issue, false},
+		{"label match", ListOptions{Label: "urgent", Priority: -1}, issue, true},
+		{"label miss", ListOptions{Label: "gt:bug", Priority: -1}, issue, false},
+		{"type maps to label", ListOptions{Type: "task", Priority: -1}, issue, true},
+		{"priority 0 filters", ListOptions{Priority: 0}, issue, false},
+		{"priority match", ListOptions{Priority: 2}, issue, true},
+		{"parent match", ListOptions{Parent: "gt-epic", Priority: -1}, issue, true},
+		{"parent miss", ListOptions{Parent: "gt-other", Priority: -1}, issue, false},
+		{"assignee miss", ListOptions{Assignee: "gastown/Nux", Priority: -1}, issue, false},
+		{"no-assignee", ListOptions{NoAssignee: true, Priority: -1}, issue, false},
+		{"gates hidden", ListOptions{Priority: -1}, gate, false},
+		{"gates listed by type", ListOptions{Type: "gate", Priority: -1}, gate, true},
+		{"templates hidden", ListOptions{Status: "all", Priority: -1}, template, false},
+	}
+	for _, tt := range tests {
+		if got := matchesFilter(listFilter(tt.opts), tt.issue, rows[tt.issue.ID]); got != tt.want {
+			t.Errorf("%s: matchesFilter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/config"
@@ -19,6 +21,7 @@ var (
 	accountJSON        bool
 	accountEmail       string
 	accountDescription string
+	accountProvider    string
 )
 
 var accountCmd = &cobra.Command{
@@ -63,7 +66,8 @@ that directory to complete the login.
 Examples:
   gt account add work
   gt account add work --email steve@company.com
-  gt account add work --email steve@company.com --desc "Work account"`,
+  gt account add work --email steve@company.com --desc "Work account"
+  gt account add gem-team --provider gemini`,
 	Args: cobra.ExactArgs(1),
 	RunE: runAccountAdd,
 }
@@ -173,10 +177,13 @@ func runAccountAdd(cmd *cobra.Command, args []string) error {
 
 	accountsPath := constants.MayorAccountsPath(townRoot)
 
-	// Load existing config or create new
+	// Load existing config or create new. Don't overwrite a config that
+	// exists but fails validation.
 	cfg, err := config.LoadAccountsConfig(accountsPath)
-	if err != nil {
+	if errors.Is(err, config.ErrNotFound) {
 		cfg = config.NewAccountsConfig()
+	} else if err != nil {
+		return fmt.Errorf("loading accounts config: %w", err)
 	}
 
 	// Check if account already exists
@@ -184,6 +191,18 @@ func runAccountAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("account '%s' already exists", handle)
 	}
 
+	if accountProvider == string(config.AgentClaude) {
+		accountProvider = ""
+	}
+	if accountProvider != "" {
+		if config.GetAgentPresetByName(accountProvider) == nil {
+			return fmt.Errorf("unknown provider '%s' (available: %s)", accountProvider, strings.Join(config.ListAgentPresets(), ", "))
+		}
+		if err := config.ValidateAccountProvider(accountProvider); err != nil {
+			return fmt.Errorf("%w; set config_dir_env for it in settings/agents.json first", err)
+		}
+	}
+
 	// Build config directory path
 	baseDir, err := config.DefaultAccountsConfigDir()
 	if err != nil {
@@ -198,8 +217,10 @@ func runAccountAdd(cmd *cobra.Command, args []string) error {
 
 	// Symlink global commands (e.g., SuperClaude) so they're available
 	// when CLAUDE_CONFIG_DIR points at this account directory.
-	if err := ensureSharedCommandsSymlink(configDir); err != nil {
-		style.PrintWarning("could not symlink global commands: %v", err)
+	if accountProvider == "" {
+		if err := ensureSharedCommandsSymlink(configDir); err != nil {
+			style.PrintWarning("could not symlink global commands: %v", err)
+		}
 	}
 
 	// Add account
@@ -207,6 +228,7 @@ func runAccountAdd(cmd *cobra.Command, args []string) error {
 		Email:       accountEmail,
 		Description: accountDescription,
 		ConfigDir:   configDir,
+		Provider:    accountProvider,
 	}
 
 	// If this is the first account, make it default
@@ -222,6 +244,12 @@ func runAccountAdd(cmd *cobra.Command, args []string) error {
 	fmt.Printf("Added account '%s'\n", handle)
 	fmt.Printf("Config directory: %s\n", configDir)
 	fmt.Println()
+	if accountProvider != "" {
+		preset := config.GetAgentPresetByName(accountProvider)
+		fmt.Println("To complete login, run:")
+		fmt.Printf("  %s=%s %s\n", preset.ConfigDirEnv, configDir, preset.Command)
+		return nil
+	}
 	fmt.Println("To complete login, run:")
 	fmt.Printf("  CLAUDE_CONFIG_DIR=%s claude\n", configDir)
 	fmt.Println("Then use /login to authenticate.")
@@ -509,6 +537,7 @@ func init() {
 
 	accountAddCmd.Flags().StringVar(&accountEmail, "email", "", "Account email address")
 	accountAddCmd.Flags().StringVar(&accountDescription, "desc", "", "Account description")
+	accountAddCmd.Flags().StringVar(&accountProvider, "provider", "", "Agent preset this account is for (default: claude)")
 
 	// Add subcommands
 	accountCmd.AddCommand(accountListCmd)
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/base32"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -65,6 +66,7 @@ var (
 	convoyOwned        bool
 	convoyMerge        string
 	convoyStatusJSON   bool
+	convoyStatusAsOf   string
 	convoyListJSON     bool
 	convoyListStatus   string
 	convoyListAll      bool
@@ -224,7 +226,15 @@ var convoyStatusCmd = &cobra.Command{
 	Long: `Show detailed status for a convoy.
 
 Displays convoy metadata, tracked issues, and completion progress.
-Without an ID, shows status of all active convoys.`,
+Without an ID, shows status of all active convoys.
+
+--as-of shows the convoy as it was at a time or at a commit of the town
+beads database. Tracked issues in rig databases are read at the same time.
+Worker info is live tmux state and is omitted.
+
+Examples:
+  gt convoy status hq-cv-abc
+  gt convoy status hq-cv-abc --as-of 6h`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runConvoyStatus,
 }
@@ -353,6 +363,7 @@ func init() {
 
 	// Status flags
 	convoyStatusCmd.Flags().BoolVar(&convoyStatusJSON, "json", false, "Output as JSON")
+	convoyStatusCmd.Flags().StringVar(&convoyStatusAsOf, "as-of", "", "Show status as of a time (2h, \"2006-01-02 15:04\") or Dolt commit")
 
 	// List flags
 	convoyListCmd.Flags().BoolVar(&convoyListJSON, "json", false, "Output as JSON")
@@ -1541,6 +1552,11 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 		return showAllConvoyStatus(townBeads)
 	}
 
+	var asOfBeads *beads.Beads
+	if convoyStatusAsOf != "" {
+		asOfBeads = beads.NewWithBeadsDir(filepath.Dir(townBeads), townBeads)
+	}
+
 	convoyID := args[0]
 
 	// Check if it's a numeric shortcut (e.g., "1" instead of "hq-cv-xyz")
@@ -1553,14 +1569,38 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get convoy details
-	showArgs := []string{"show", convoyID, "--json"}
-	showCmd := exec.Command("bd", showArgs...)
-	showCmd.Dir = townBeads
 	var stdout bytes.Buffer
-	showCmd.Stdout = &stdout
+	var asOfConvoy *beads.Issue
+	if asOfBeads != nil {
+		issue, rev, err := asOfBeads.ShowAsOf(convoyID, convoyStatusAsOf)
+		if err != nil {
+			if errors.Is(err, beads.ErrNotFound) {
+				return fmt.Errorf("convoy '%s' not found as of %s", convoyID, convoyStatusAsOf)
+			}
+			return err
+		}
+		asOfConvoy = issue
+		// Read tracked issues at the convoy's commit time, so rig databases
+		// line up with the town database.
+		convoyStatusAsOf = rev.TimeSpec()
+		if !convoyStatusJSON {
+			fmt.Printf("%s\n", style.Dim.Render(fmt.Sprintf("As of %s (%s)", rev.Date.Local().Format("2006-01-02 15:04:05"), shortCommit(rev.Commit))))
+		}
+		// Shaped like bd show --json so both paths parse the same.
+		data, err := json.Marshal([]*beads.Issue{issue})
+		if err != nil {
+			return err
+		}
+		stdout.Write(data)
+	} else {
+		showArgs := []string{"show", convoyID, "--json"}
+		showCmd := exec.Command("bd", showArgs...)
+		showCmd.Dir = townBeads
+		showCmd.Stdout = &stdout
 
-	if err := showCmd.Run(); err != nil {
-		return fmt.Errorf("convoy '%s' not found", convoyID)
+		if err := showCmd.Run(); err != nil {
+			return fmt.Errorf("convoy '%s' not found", convoyID)
+		}
 	}
 
 	// Parse convoy data
@@ -1587,9 +1627,14 @@ func runConvoyStatus(cmd *cobra.Command, args []string) error {
 	// Check if convoy is owned (caller-managed lifecycle)
 	isOwned := hasLabel(convoy.Labels, "gt:owned")
 
-	tracked, err := getTrackedIssues(townBeads, convoyID)
-	if err != nil {
-		return fmt.Errorf("getting tracked issues for %s: %w", convoyID, err)
+	var tracked []trackedIssueInfo
+	if asOfConvoy != nil {
+		tracked = getTrackedIssuesAsOf(asOfBeads, asOfConvoy, convoyStatusAsOf)
+	} else {
+		tracked, err = getTrackedIssues(townBeads, convoyID)
+		if err != nil {
+			return fmt.Errorf("getting tracked issues for %s: %w", convoyID, err)
+		}
 	}
 
 	// Count completed
@@ -2070,6 +2115,31 @@ func getTrackedIssues(townBeads, convoyID string) ([]trackedIssueInfo, error) {
 	return tracked, nil
 }
 
+// getTrackedIssuesAsOf returns the issues a convoy tracked at an --as-of
+// time, with their state at that time. Issues that did not exist yet are
+// listed with status "unknown". Worker info is omitted: it comes from live
+// sessions, not beads.
+func getTrackedIssuesAsOf(b *beads.Beads, convoy *beads.Issue, spec string) []trackedIssueInfo {
+	var tracked []trackedIssueInfo
+	for _, dep := range convoy.Dependencies {
+		if dep.DependencyType != "tracks" {
+			continue
+		}
+		id := beads.ExtractIssueID(dep.ID)
+		info := trackedIssueInfo{ID: id, Type: dep.DependencyType, Status: "unknown"}
+		if issue, _, err := b.ShowAsOf(id, spec); err == nil {
+			info.Title = issue.Title
+			info.Status = issue.Status
+			info.IssueType = issue.Type
+			info.Blocked = len(issue.BlockedBy) > 0
+			info.Assignee = issue.Assignee
+			info.Labels = issue.Labels
+		}
+		tracked = append(tracked, info)
+	}
+	return tracked
+}
+
 type issueDependency struct {
 	Status         string `json:"status"`
 	DependencyType string `json:"dependency_type"`
@@ -1,11 +1,13 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -106,9 +108,34 @@ Examples:
 	RunE: runDaemonEnableSupervisor,
 }
 
+var daemonCrashesCmd = &cobra.Command{
+	Use:   "crashes",
+	Short: "List captured agent crash reports grouped by signature",
+	Long: `List crash reports captured by the daemon when agents die.
+
+On each detected death the daemon records the last pane lines, exit status,
+tracked PID and Dolt health into a crash report (daemon/crashes.jsonl, mirrored
+as a gt:crash-report bead). Reports with the same signature - the exit status
+plus the normalized tail of the pane - are grouped so repeated failures stand
+out, each with a suggested fix.
+
+Examples:
+  gt daemon crashes                  # All crash clusters, most frequent first
+  gt daemon crashes --agent deacon   # Only Deacon crashes
+  gt daemon crashes --since 6h       # Crashes in the last 6 hours
+  gt daemon crashes -v               # Include the latest pane tail per cluster
+  gt daemon crashes --json`,
+	RunE: runDaemonCrashes,
+}
+
 var (
 	daemonLogLines int
 	daemonLogFollow bool
+
+	daemonCrashesAgent   string
+	daemonCrashesSince   string
+	daemonCrashesVerbose bool
+	daemonCrashesJSON    bool
 )
 
 func init() {
@@ -118,10 +145,16 @@ func init() {
 	daemonCmd.AddCommand(daemonLogsCmd)
 	daemonCmd.AddCommand(daemonRunCmd)
 	daemonCmd.AddCommand(daemonEnableSupervisorCmd)
+	daemonCmd.AddCommand(daemonCrashesCmd)
 
 	daemonLogsCmd.Flags().IntVarP(&daemonLogLines, "lines", "n", 50, "Number of lines to show")
 	daemonLogsCmd.Flags().BoolVarP(&daemonLogFollow, "follow", "f", false, "Follow log output")
 
+	daemonCrashesCmd.Flags().StringVar(&daemonCrashesAgent, "agent", "", "Only show crashes for this agent (e.g., deacon, gastown/Toast)")
+	daemonCrashesCmd.Flags().StringVar(&daemonCrashesSince, "since", "", "Only show crashes within this duration (e.g., 1h, 24h)")
+	daemonCrashesCmd.Flags().BoolVarP(&daemonCrashesVerbose, "verbose", "v", false, "Show the latest pane tail for each cluster")
+	daemonCrashesCmd.Flags().BoolVar(&daemonCrashesJSON, "json", false, "Output as JSON")
+
 	rootCmd.AddCommand(daemonCmd)
 }
 
@@ -293,6 +326,80 @@ func runDaemonLogs(cmd *cobra.Command, args []string) error {
 	return tailCmd.Run()
 }
 
+func runDaemonCrashes(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	reports, err := daemon.LoadCrashReports(townRoot)
+	if err != nil {
+		return err
+	}
+
+	var cutoff time.Time
+	if daemonCrashesSince != "" {
+		d, err := time.ParseDuration(daemonCrashesSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since duration: %w", err)
+		}
+		cutoff = time.Now().Add(-d)
+	}
+
+	var filtered []*daemon.CrashReport
+	for _, r := range reports {
+		if daemonCrashesAgent != "" && r.Agent != daemonCrashesAgent {
+			continue
+		}
+		if !cutoff.IsZero() && r.CapturedAt.Before(cutoff) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	clusters := daemon.ClusterCrashReports(filtered)
+
+	if daemonCrashesJSON {
+		out, _ := json.MarshalIndent(clusters, "", "  ")
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(clusters) == 0 {
+		fmt.Printf("%s No crash reports\n", style.Dim.Render("○"))
+		return nil
+	}
+
+	fmt.Printf("Crash clusters (%d reports, %d signatures):\n\n", len(filtered), len(clusters))
+	for _, c := range clusters {
+		latest := c.Latest
+		fmt.Printf("  %s %s  %dx  exit %d  last %s\n",
+			style.Bold.Render("●"), c.Signature, c.Count, latest.ExitStatus,
+			latest.CapturedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("     Agents: %s\n", strings.Join(c.Agents, ", "))
+		if latest.CrashLoop {
+			fmt.Printf("     %s crash loop (restart #%d)\n", style.Bold.Render("⚠"), latest.RestartCount)
+		}
+		if latest.DoltHealth != "" {
+			fmt.Printf("     Dolt: %s\n", latest.DoltHealth)
+		}
+		if latest.BeadID != "" {
+			fmt.Printf("     Bead: %s\n", latest.BeadID)
+		}
+		if c.SuggestedFix != "" {
+			fmt.Printf("     Fix: %s\n", c.SuggestedFix)
+		}
+		if daemonCrashesVerbose && len(latest.PaneTail) > 0 {
+			fmt.Printf("     %s\n", style.Dim.Render("--- pane tail ---"))
+			for _, line := range latest.PaneTail {
+				fmt.Printf("     %s\n", style.Dim.Render(line))
+			}
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
 func runDaemonRun(cmd *cobra.Command, args []string) error {
 	townRoot, err := workspace.FindFromCwdOrError()
 	if err != nil {
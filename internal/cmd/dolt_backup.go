@@ -0,0 +1,267 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	doltBackupListJSON   bool
+	doltBackupRestoreTo  string
+	doltBackupRestoreDry bool
+)
+
+var doltBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "List, verify and restore scheduled Dolt backups",
+	Long: `Manage point-in-time backups of the town's Dolt databases.
+
+The daemon takes backups when the dolt_backup patrol is enabled in
+mayor/daemon.json:
+
+  "dolt_backup": {
+    "enabled": true,
+    "interval": 3600000000000,
+    "dir": "/mnt/backups/gastown",
+    "retention": {"hourly": 24, "daily": 7, "weekly": 4}
+  }
+
+Each backup is a snapshot of every database (a Dolt backup per database)
+under the backup dir (default <town>/.dolt-backups). Old snapshots are pruned
+by tier: the newest snapshot of each of the last N hours, days and weeks is
+kept.`,
+	RunE: requireSubcommand,
+}
+
+var doltBackupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available backups",
+	Args:  cobra.NoArgs,
+	RunE:  runDoltBackupList,
+}
+
+var doltBackupVerifyCmd = &cobra.Command{
+	Use:   "verify [backup-id]",
+	Short: "Restore a backup into a scratch directory and validate it",
+	Long: `Check that a backup can actually be restored.
+
+The backup (default: the most recent) is restored into a scratch directory,
+each database's commit log and tables are read, and the scratch copy is
+discarded. The live .dolt-data/ is not touched and the server keeps running.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDoltBackupVerify,
+}
+
+var doltBackupRestoreCmd = &cobra.Command{
+	Use:   "restore [backup-id]",
+	Short: "Restore .dolt-data/ from a backup",
+	Long: `Restore the town's Dolt databases from a backup.
+
+Pick the backup by ID or with --to, which selects the newest backup taken at
+or before the given time. --to accepts a duration meaning "that long ago"
+(90m, 6h), a backup ID, RFC 3339, or local "2006-01-02 15:04".
+
+This command will:
+1. Restore the backup into a staging directory and validate it
+   (the server keeps running meanwhile)
+2. Stop the Dolt server if running
+3. Move the current .dolt-data/ aside to .dolt-data.pre-restore-<time>/
+4. Swap the staging directory in as .dolt-data/
+5. Restart the Dolt server if it was running
+
+Nothing is changed if the staged copy fails validation.
+
+Examples:
+  gt dolt backup restore --to 2h
+  gt dolt backup restore --to "2026-10-17 09:00"
+  gt dolt backup restore 20261017-080000 --dry-run`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDoltBackupRestore,
+}
+
+func init() {
+	doltBackupListCmd.Flags().BoolVar(&doltBackupListJSON, "json", false, "Output as JSON")
+	doltBackupRestoreCmd.Flags().StringVar(&doltBackupRestoreTo, "to", "", "Restore the newest backup at or before this time")
+	doltBackupRestoreCmd.Flags().BoolVar(&doltBackupRestoreDry, "dry-run", false, "Show which backup would be restored without changing anything")
+
+	doltBackupCmd.AddCommand(doltBackupListCmd)
+	doltBackupCmd.AddCommand(doltBackupVerifyCmd)
+	doltBackupCmd.AddCommand(doltBackupRestoreCmd)
+	doltCmd.AddCommand(doltBackupCmd)
+}
+
+// loadDoltBackups returns the town root, backup dir and its snapshots.
+func loadDoltBackups() (string, string, []doltserver.Snapshot, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	dir := daemon.DoltBackupDir(townRoot, daemon.LoadPatrolConfig(townRoot))
+	snaps, err := doltserver.ListSnapshots(dir)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return townRoot, dir, snaps, nil
+}
+
+func runDoltBackupList(cmd *cobra.Command, args []string) error {
+	_, dir, snaps, err := loadDoltBackups()
+	if err != nil {
+		return err
+	}
+
+	if doltBackupListJSON {
+		if snaps == nil {
+			snaps = []doltserver.Snapshot{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(snaps)
+	}
+
+	if len(snaps) == 0 {
+		fmt.Printf("No backups in %s\n", dir)
+		fmt.Printf("%s\n", style.Dim.Render("Enable the dolt_backup patrol in mayor/daemon.json to take scheduled backups."))
+		return nil
+	}
+
+	fmt.Printf("Backups in %s:\n\n", dir)
+	for i, s := range snaps {
+		label := ""
+		if i == 0 {
+			label = " (most recent)"
+		}
+		fmt.Printf("  %s  %s%s\n", s.ID, s.CreatedAt.Local().Format("2006-01-02 15:04:05"), label)
+		fmt.Printf("    %s\n", style.Dim.Render(strings.Join(s.Databases, ", ")))
+	}
+	return nil
+}
+
+func runDoltBackupVerify(cmd *cobra.Command, args []string) error {
+	townRoot, _, snaps, err := loadDoltBackups()
+	if err != nil {
+		return err
+	}
+	snap, err := pickDoltBackup(snaps, args, "")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Verifying backup %s (%d database(s))...\n", snap.ID, len(snap.Databases))
+	if err := doltserver.VerifySnapshot(townRoot, snap); err != nil {
+		return fmt.Errorf("backup %s failed verification: %w", snap.ID, err)
+	}
+	fmt.Printf("%s Backup %s restores cleanly\n", style.Bold.Render("✓"), snap.ID)
+	return nil
+}
+
+func runDoltBackupRestore(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && doltBackupRestoreTo == "" {
+		return fmt.Errorf("specify a backup ID or --to <time>\nUse 'gt dolt backup list' to see available backups")
+	}
+	if len(args) > 0 && doltBackupRestoreTo != "" {
+		return fmt.Errorf("specify either a backup ID or --to, not both")
+	}
+
+	townRoot, _, snaps, err := loadDoltBackups()
+	if err != nil {
+		return err
+	}
+	config := doltserver.DefaultConfig(townRoot)
+	if config.IsRemote() {
+		return fmt.Errorf("Dolt server is remote (%s) — restore requires local server access", config.HostPort())
+	}
+
+	snap, err := pickDoltBackup(snaps, args, doltBackupRestoreTo)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup: %s (taken %s)\n", snap.ID, snap.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Databases: %s\n", strings.Join(snap.Databases, ", "))
+
+	if doltBackupRestoreDry {
+		fmt.Printf("\n%s Dry run - no changes will be made\n", style.Bold.Render("!"))
+		return nil
+	}
+
+	// Stage and validate before touching the server, so a bad backup costs
+	// no downtime.
+	fmt.Println("\nStaging and validating backup...")
+	staging, err := doltserver.StageSnapshot(townRoot, snap)
+	if err != nil {
+		return fmt.Errorf("backup %s failed validation, nothing changed: %w", snap.ID, err)
+	}
+	fmt.Printf("%s Backup validated\n", style.Bold.Render("✓"))
+
+	wasRunning, _, _ := doltserver.IsRunning(townRoot)
+	if wasRunning {
+		fmt.Println("Stopping Dolt server...")
+		if err := doltserver.Stop(townRoot); err != nil {
+			_ = os.RemoveAll(staging)
+			return fmt.Errorf("stopping Dolt server: %w", err)
+		}
+		fmt.Printf("%s Dolt server stopped\n", style.Bold.Render("✓"))
+	}
+
+	fmt.Println("Swapping in restored data...")
+	result, restoreErr := doltserver.RestoreSnapshot(townRoot, snap, staging, time.Now())
+	if restoreErr != nil {
+		_ = os.RemoveAll(staging)
+	}
+
+	if wasRunning {
+		fmt.Println("Restarting Dolt server...")
+		if err := doltserver.Start(townRoot); err != nil {
+			fmt.Printf("  %s Failed to restart Dolt server: %v\n", style.Dim.Render("⚠"), err)
+		} else {
+			fmt.Printf("%s Dolt server restarted\n", style.Bold.Render("✓"))
+		}
+	}
+
+	if restoreErr != nil {
+		return fmt.Errorf("restore failed: %w", restoreErr)
+	}
+
+	fmt.Println()
+	for _, db := range result.Databases {
+		fmt.Printf("  %s Restored %s\n", style.Bold.Render("✓"), db)
+	}
+	for _, db := range result.Dropped {
+		fmt.Printf("  %s %s is not in this backup (kept in the previous data dir)\n", style.Dim.Render("⚠"), db)
+	}
+	if result.PreviousDataDir != "" {
+		fmt.Printf("\n  Previous data kept in %s\n", result.PreviousDataDir)
+	}
+	fmt.Printf("\n%s Restore complete from %s\n", style.Bold.Render("✓"), snap.ID)
+	return nil
+}
+
+// pickDoltBackup selects a snapshot by ID argument, by --to time, or the most
+// recent one.
+func pickDoltBackup(snaps []doltserver.Snapshot, args []string, to string) (*doltserver.Snapshot, error) {
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("no backups found\nEnable the dolt_backup patrol in mayor/daemon.json to take scheduled backups")
+	}
+	switch {
+	case len(args) > 0:
+		return doltserver.FindSnapshot(snaps, args[0])
+	case to != "":
+		at, err := doltserver.ParseRestoreTime(to, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return doltserver.SelectSnapshot(snaps, at)
+	default:
+		return &snaps[0], nil
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/daemon"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	doltFailoverReason     string
+	doltFailoverStatusJSON bool
+)
+
+var doltFailoverCmd = &cobra.Command{
+	Use:   "failover",
+	Short: "Promote the hot standby Dolt server",
+	Long: `Promote the hot standby to primary and re-point beads consumers at it.
+
+The standby is configured under dolt_server in mayor/daemon.json:
+
+  "dolt_server": {
+    "enabled": true,
+    "standby": {
+      "enabled": true,
+      "port": 3308,
+      "failover_after": 3
+    }
+  }
+
+With a standby configured, the daemon starts the primary and a local
+standby (or expects a remote one at standby.host) as a Dolt cluster, and
+every commit on the primary is replicated to the standby. When the primary
+fails failover_after consecutive health checks, the daemon promotes the
+standby on its own; this command does the same by hand.
+
+Failover makes the standby primary at a higher epoch, records it in
+daemon/dolt-active.json (which gt's connection strings follow), and
+re-points every beads metadata.json at it. The old primary is demoted to
+standby when it answers, and rejoins as a standby when restarted.
+
+Examples:
+  gt dolt failover --reason "disk full on primary"
+  gt dolt failover status
+  gt dolt failover back`,
+	Args: cobra.NoArgs,
+	RunE: runDoltFailover,
+}
+
+var doltFailoverStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which Dolt server is active and replication state",
+	Args:  cobra.NoArgs,
+	RunE:  runDoltFailoverStatus,
+}
+
+var doltFailoverBackCmd = &cobra.Command{
+	Use:   "back",
+	Short: "Hand the primary role back to the original server",
+	Long: `Fail back to the original primary after a failover.
+
+The original primary must be running and back in the standby role. The
+active standby is demoted first, which waits until the original primary
+has replicated every commit, then the original primary is promoted and the
+beads metadata.json files are restored. No writes are lost.`,
+	Args: cobra.NoArgs,
+	RunE: runDoltFailoverBack,
+}
+
+func init() {
+	doltFailoverCmd.Flags().StringVar(&doltFailoverReason, "reason", "manual failover", "Why the failover was done (recorded in dolt-active.json)")
+	doltFailoverStatusCmd.Flags().BoolVar(&doltFailoverStatusJSON, "json", false, "Output as JSON")
+
+	doltFailoverCmd.AddCommand(doltFailoverStatusCmd)
+	doltFailoverCmd.AddCommand(doltFailoverBackCmd)
+	doltCmd.AddCommand(doltFailoverCmd)
+}
+
+// loadDoltStandby returns the town root and the configured primary and standby.
+func loadDoltStandby() (string, doltserver.Endpoint, doltserver.StandbyConfig, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", doltserver.Endpoint{}, doltserver.StandbyConfig{}, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	primary, standby, ok := daemon.DoltStandbyConfig(townRoot, daemon.LoadPatrolConfig(townRoot))
+	if !ok {
+		return "", primary, standby, fmt.Errorf("no Dolt standby configured (set dolt_server.standby in mayor/daemon.json)")
+	}
+	return townRoot, primary, standby, nil
+}
+
+func runDoltFailover(cmd *cobra.Command, args []string) error {
+	townRoot, primary, standby, err := loadDoltStandby()
+	if err != nil {
+		return err
+	}
+
+	active, err := doltserver.Promote(townRoot, primary, standby.Endpoint(), doltFailoverReason, time.Now())
+	if err != nil && active == nil {
+		return fmt.Errorf("failover failed: %w", err)
+	}
+	fmt.Printf("%s Standby %s:%d is now primary (epoch %d)\n",
+		style.Bold.Render("✓"), active.Host, active.Port, active.Epoch)
+	fmt.Printf("  Re-pointed %d beads database(s)\n", len(active.Metadata))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  Fail back with: %s\n", style.Dim.Render("gt dolt failover back"))
+	return nil
+}
+
+// failoverStatus is the JSON form of gt dolt failover status.
+type failoverStatus struct {
+	Active      doltserver.Endpoint            `json:"active"`
+	FailedOver  bool                           `json:"failed_over"`
+	Since       *time.Time                     `json:"since,omitempty"`
+	Reason      string                         `json:"reason,omitempty"`
+	Primary     nodeStatus                     `json:"primary"`
+	Standby     nodeStatus                     `json:"standby"`
+	Replication []doltserver.ReplicationStatus `json:"replication,omitempty"`
+}
+
+type nodeStatus struct {
+	Address string `json:"address"`
+	Role    string `json:"role,omitempty"`
+	Epoch   int    `json:"epoch,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func probeNode(ep doltserver.Endpoint) nodeStatus {
+	s := nodeStatus{Address: ep.HostPort()}
+	role, epoch, err := doltserver.ClusterRole(ep)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Role, s.Epoch = role, epoch
+	return s
+}
+
+func runDoltFailoverStatus(cmd *cobra.Command, args []string) error {
+	townRoot, primary, standby, err := loadDoltStandby()
+	if err != nil {
+		return err
+	}
+	active, err := doltserver.LoadActiveServer(townRoot)
+	if err != nil {
+		return err
+	}
+
+	st := failoverStatus{
+		Active:  primary,
+		Primary: probeNode(primary),
+		Standby: probeNode(standby.Endpoint()),
+	}
+	current := primary
+	if active != nil {
+		current = standby.Endpoint()
+		st.Active = doltserver.Endpoint{Host: active.Host, Port: active.Port}
+		st.FailedOver = true
+		st.Since = &active.Since
+		st.Reason = active.Reason
+	}
+	if repl, err := doltserver.ClusterStatus(current); err == nil {
+		st.Replication = repl
+	}
+
+	if doltFailoverStatusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+
+	if st.FailedOver {
+		fmt.Printf("%s Failed over to %s since %s\n", style.Warning.Render("⚠"),
+			st.Active.HostPort(), st.Since.Local().Format("2006-01-02 15:04:05"))
+		if st.Reason != "" {
+			fmt.Printf("  Reason: %s\n", st.Reason)
+		}
+	} else {
+		fmt.Printf("%s Primary %s is active\n", style.Bold.Render("✓"), st.Active.HostPort())
+	}
+	fmt.Println()
+	for _, n := range []struct {
+		label string
+		node  nodeStatus
+	}{{"Primary", st.Primary}, {"Standby", st.Standby}} {
+		if n.node.Error != "" {
+			fmt.Printf("  %-8s %s  %s\n", n.label, n.node.Address, style.Error.Render("unavailable"))
+			continue
+		}
+		fmt.Printf("  %-8s %s  %s (epoch %d)\n", n.label, n.node.Address, n.node.Role, n.node.Epoch)
+	}
+	if len(st.Replication) > 0 {
+		fmt.Printf("\n  %s\n", style.Bold.Render("Replication"))
+		for _, r := range st.Replication {
+			lag := fmt.Sprintf("%dms behind", r.LagMillis)
+			if r.LagMillis < 0 {
+				lag = "lag unknown"
+			}
+			line := fmt.Sprintf("    %-24s → %s  %s", r.Database, r.StandbyRemote, lag)
+			if r.Error != "" {
+				line += "  " + style.Error.Render(r.Error)
+			}
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
+func runDoltFailoverBack(cmd *cobra.Command, args []string) error {
+	townRoot, primary, standby, err := loadDoltStandby()
+	if err != nil {
+		return err
+	}
+	if err := doltserver.Failback(townRoot, primary, standby.Endpoint()); err != nil {
+		if errors.Is(err, doltserver.ErrNotFailedOver) {
+			fmt.Printf("%s %v\n", style.Bold.Render("✓"), err)
+			return nil
+		}
+		return fmt.Errorf("fail-back failed: %w", err)
+	}
+	fmt.Printf("%s Primary %s is active again\n", style.Bold.Render("✓"), primary.HostPort())
+	return nil
+}
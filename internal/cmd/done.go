@@ -48,6 +48,7 @@ Exit statuses:
 Examples:
   gt done                              # Submit branch, notify COMPLETED, exit session
   gt done --issue gt-abc               # Explicit issue ID
+  gt done --parent gt-mr-xyz           # Stack on another polecat's unmerged MR
   gt done --status ESCALATED           # Signal blocker, skip MR
   gt done --status DEFERRED            # Pause work, skip MR`,
 	RunE:         runDone,
@@ -60,6 +61,7 @@ var (
 	doneStatus        string
 	doneCleanupStatus string
 	doneResume        bool
+	doneParent        string
 )
 
 // Valid exit types for gt done
@@ -75,6 +77,7 @@ func init() {
 	doneCmd.Flags().StringVar(&doneStatus, "status", ExitCompleted, "Exit status: COMPLETED, ESCALATED, or DEFERRED")
 	doneCmd.Flags().StringVar(&doneCleanupStatus, "cleanup-status", "", "Git cleanup status: clean, uncommitted, unpushed, stash, unknown (ZFC: agent-observed)")
 	doneCmd.Flags().BoolVar(&doneResume, "resume", false, "Resume from last checkpoint (auto-detected, for Witness recovery)")
+	doneCmd.Flags().StringVar(&doneParent, "parent", "", "Stack on an unmerged parent MR (MR ID or branch; see 'gt mq submit --help')")
 
 	rootCmd.AddCommand(doneCmd)
 }
@@ -710,15 +713,26 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 			}
 		}
 
+		// Resolve the parent MR for stacked work; the child lands where the parent lands
+		var parent *stackParent
+		if doneParent != "" {
+			parent, err = resolveStackParent(bd, g, branch, doneParent)
+			if err != nil {
+				return err
+			}
+		}
+
 		// Determine target branch (auto-detect integration branch if applicable)
 		// Only if refinery integration branch auto-targeting is enabled
 		target := defaultBranch
-		refineryEnabled := true
+		refineryEnabled := parent == nil
 		settingsPath := filepath.Join(townRoot, rigName, "settings", "config.json")
 		if settings, err := config.LoadRigSettings(settingsPath); err == nil && settings.MergeQueue != nil {
-			refineryEnabled = settings.MergeQueue.IsRefineryIntegrationEnabled()
+			refineryEnabled = refineryEnabled && settings.MergeQueue.IsRefineryIntegrationEnabled()
 		}
-		if refineryEnabled {
+		if parent != nil {
+			target = parent.Target
+		} else if refineryEnabled {
 			autoTarget, err := beads.DetectIntegrationBranch(bd, g, issueID)
 			if err == nil && autoTarget != "" {
 				target = autoTarget
@@ -761,6 +775,9 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 			if agentBeadID != "" {
 				description += fmt.Sprintf("\nagent_bead: %s", agentBeadID)
 			}
+			if parent != nil {
+				description += fmt.Sprintf("\nparent_mr: %s\nparent_head: %s", parent.ID, parent.Head)
+			}
 
 			// Add conflict resolution tracking fields (initialized, updated by Refinery)
 			description += "\nretry_count: 0"
@@ -786,6 +803,13 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 			}
 			mrID = mrIssue.ID
 
+			// Block the child on its parent so the stack merges in order
+			if parent != nil {
+				if err := bd.AddDependency(mrID, parent.ID); err != nil {
+					style.PrintWarning("could not block %s on parent %s: %v", mrID, parent.ID, err)
+				}
+			}
+
 			// GH#1945: Verify MR bead is readable before considering it confirmed.
 			// bd.Create() succeeds when the bead is written locally, but if the write
 			// didn't persist (Dolt failure, corrupt state), we'd nuke the worktree
@@ -805,6 +829,8 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 				}
 			}
 
+			recordMRQueued(townRoot, rigName, mrID, worker, target)
+
 			// Success output
 			fmt.Printf("%s Work submitted to merge queue (verified)\n", style.Bold.Render("✓"))
 			fmt.Printf("  MR ID: %s\n", style.Bold.Render(mrID))
@@ -823,6 +849,9 @@ func runDone(cmd *cobra.Command, args []string) (retErr error) {
 
 		fmt.Printf("  Source: %s\n", branch)
 		fmt.Printf("  Target: %s\n", target)
+		if parent != nil {
+			fmt.Printf("  Stacked on: %s (%s @ %s)\n", parent.ID, parent.Branch, shortCommit(parent.Head))
+		}
 		fmt.Printf("  Issue: %s\n", issueID)
 		if worker != "" {
 			fmt.Printf("  Worker: %s\n", worker)
@@ -1367,6 +1396,8 @@ func isPolecatActor(actor string) bool {
 // - GT_RIG: the rig name
 // - GT_POLECAT: the polecat name
 // Session name format: gt-<rig>-<polecat>
+//
+// In an isolated sandbox it leaves a done marker for the daemon instead.
 func selfKillSession(townRoot string, roleInfo RoleInfo) error {
 	// Get session info from environment (set at session startup)
 	rigName := os.Getenv("GT_RIG")
@@ -1397,6 +1428,18 @@ func selfKillSession(townRoot string, roleInfo RoleInfo) error {
 	_ = events.LogFeed(events.TypeSessionDeath, agentID,
 		events.SessionDeathPayload(sessionName, agentID, "self-clean: done means idle", "gt done"))
 
+	// Inside an isolated sandbox tmux is out of reach; ask the host instead.
+	if os.Getenv(session.SandboxEnv) != "" {
+		if townRoot == "" {
+			return fmt.Errorf("cannot leave done marker: town root unknown")
+		}
+		if err := session.WriteDoneMarker(filepath.Join(townRoot, rigName), polecatName, sessionName); err != nil {
+			return fmt.Errorf("leaving done marker for %s: %w", sessionName, err)
+		}
+		fmt.Printf("%s Sandboxed: the daemon will end session %s\n", style.Bold.Render("→"), sessionName)
+		return nil
+	}
+
 	// Kill our own tmux session with proper process cleanup
 	// This will terminate Claude and all child processes, completing the self-cleaning cycle.
 	// We use KillSessionWithProcessesExcluding to ensure no orphaned processes are left behind,
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	historyJSON  bool
+	historyField string
+	historyFull  bool
+)
+
+var historyCmd = &cobra.Command{
+	Use:     "history <bead-id>",
+	GroupID: GroupWork,
+	Short:   "Show field-level changes to a bead across Dolt commits",
+	Long: `Show how a bead changed over time.
+
+Every bd write is a Dolt commit, so a bead's full history is kept. This
+lists each commit that changed the bead, oldest first, with the fields it
+changed and who changed them. The author is the actor recorded in the
+bead's event log for that commit, or the Dolt committer when no event was
+recorded.
+
+Examples:
+  gt history gt-abc123                    # Full history
+  gt history gt-abc123 --field assignee   # Who changed the assignee, and when
+  gt history gt-abc123 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output as JSON")
+	historyCmd.Flags().StringVar(&historyField, "field", "", "Only show changes to this field (e.g. assignee, status, labels)")
+	historyCmd.Flags().BoolVar(&historyFull, "full", false, "Show long values in full instead of truncated")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	beadID := args[0]
+
+	entries, err := beads.New(resolveBeadDir(beadID)).History(beadID)
+	if err != nil {
+		if err == beads.ErrNotFound {
+			return fmt.Errorf("no history for %s", beadID)
+		}
+		return err
+	}
+	if historyField != "" {
+		entries = filterHistoryField(entries, historyField)
+	}
+
+	if historyJSON {
+		if entries == nil {
+			entries = []beads.HistoryEntry{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("No changes to %s of %s\n", historyField, beadID)
+		return nil
+	}
+
+	fmt.Printf("%s History of %s\n", style.Bold.Render("📜"), beadID)
+	for _, e := range entries {
+		fmt.Printf("\n  %s  %s  %s\n",
+			e.Date.Local().Format("2006-01-02 15:04:05"),
+			style.Bold.Render(e.Author),
+			style.Dim.Render(shortCommit(e.Commit)))
+		switch {
+		case e.Created:
+			fmt.Printf("    %s\n", style.Success.Render("created"))
+		case e.Deleted:
+			fmt.Printf("    %s\n", style.Warning.Render("deleted"))
+		}
+		if e.Created || e.Deleted {
+			continue
+		}
+		for _, c := range e.Changes {
+			fmt.Printf("    %s\n", formatFieldChange(c, historyFull))
+		}
+	}
+	return nil
+}
+
+// filterHistoryField keeps only the changes to field, dropping entries left
+// with none.
+func filterHistoryField(entries []beads.HistoryEntry, field string) []beads.HistoryEntry {
+	var out []beads.HistoryEntry
+	for _, e := range entries {
+		var kept []beads.FieldChange
+		for _, c := range e.Changes {
+			if c.Field == field {
+				kept = append(kept, c)
+			}
+		}
+		if len(kept) == 0 {
+			continue
+		}
+		e.Changes = kept
+		e.Created, e.Deleted = false, false
+		out = append(out, e)
+	}
+	return out
+}
+
+// formatFieldChange renders one change as "field: old → new". Label changes
+// are already "+label" / "-label".
+func formatFieldChange(c beads.FieldChange, full bool) string {
+	if c.Field == "labels" {
+		return fmt.Sprintf("labels: %s%s", c.Old, c.New)
+	}
+	value := func(s string) string {
+		if s == "" {
+			return style.Dim.Render("(empty)")
+		}
+		s = strings.ReplaceAll(s, "\n", " ")
+		if !full {
+			s = truncateWithEllipsis(s, 60)
+		}
+		return s
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Field, value(c.Old), value(c.New))
+}
+
+// shortCommit abbreviates a Dolt commit hash for display.
+func shortCommit(commit string) string {
+	if len(commit) > 8 {
+		return commit[:8]
+	}
+	return commit
+}
+
+// asOfTimeSpec prepares an --as-of spec for commands that read several
+// databases. Commit hashes belong to a single database, so a hash is looked
+// up in dirs and replaced by its commit date; times are validated and passed
+// through.
+func asOfTimeSpec(spec string, dirs ...string) (string, error) {
+	if !beads.IsCommitRef(spec) {
+		if _, err := beads.ParseAsOfTime(spec, time.Now()); err != nil {
+			return "", err
+		}
+		return spec, nil
+	}
+	var lastErr error
+	for _, dir := range dirs {
+		rev, err := beads.New(dir).ResolveAsOf(spec)
+		if err == nil {
+			return rev.TimeSpec(), nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("resolving --as-of %s: %w", spec, lastErr)
+}
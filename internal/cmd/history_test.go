@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+func TestFilterHistoryField(t *testing.T) {
+	entries := []beads.HistoryEntry{
+		{Commit: "c1", Created: true, Changes: []beads.FieldChange{{Field: "title", New: "Fix"}}},
+		{Commit: "c2", Changes: []beads.FieldChange{
+			{Field: "assignee", New: "gastown/polecats/nux"},
+			{Field: "status", Old: "open", New: "hooked"},
+		}},
+		{Commit: "c3", Changes: []beads.FieldChange{{Field: "priority", Old: "2", New: "1"}}},
+	}
+
+	got := filterHistoryField(entries, "assignee")
+	if len(got) != 1 || got[0].Commit != "c2" {
+		t.Fatalf("filterHistoryField = %+v, want only c2", got)
+	}
+	if len(got[0].Changes) != 1 || got[0].Changes[0].Field != "assignee" {
+		t.Errorf("changes = %+v, want only the assignee change", got[0].Changes)
+	}
+	if len(entries[1].Changes) != 2 {
+		t.Error("filterHistoryField must not modify its input")
+	}
+}
+
+func TestResolveShowAsOfPassThrough(t *testing.T) {
+	// Values bd show understands itself are passed through without a lookup.
+	tests := [][]string{
+		{"gt-abc", "--json"},
+		{"gt-abc", "--as-of", "8l8oeh1lr4ckiv5etqd8fvsbvt4dt0ef"},
+		{"gt-abc", "--as-of=main"},
+	}
+	for _, args := range tests {
+		got, err := resolveShowAsOf(args)
+		if err != nil {
+			t.Errorf("resolveShowAsOf(%v): %v", args, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("resolveShowAsOf(%v) = %v, want unchanged", args, got)
+		}
+	}
+
+	if _, err := resolveShowAsOf([]string{"--as-of", "2h"}); err == nil {
+		t.Error("expected error for --as-of without a bead ID")
+	}
+}
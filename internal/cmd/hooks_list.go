@@ -67,7 +67,7 @@ func runHooksListTargets(cmd *cobra.Command, args []string) error {
 
 	var infos []listTargetInfo
 	for _, target := range uniqueTargets {
-		info := buildTargetInfo(target)
+		info := buildTargetInfo(townRoot, target)
 		infos = append(infos, info)
 	}
 
@@ -78,7 +78,7 @@ func runHooksListTargets(cmd *cobra.Command, args []string) error {
 	return outputListHuman(infos)
 }
 
-func buildTargetInfo(target hooks.Target) listTargetInfo {
+func buildTargetInfo(townRoot string, target hooks.Target) listTargetInfo {
 	overrides := hooks.GetApplicableOverrides(target.Key)
 
 	// Filter to only overrides that actually exist on disk
@@ -96,7 +96,7 @@ func buildTargetInfo(target hooks.Target) listTargetInfo {
 	// Determine sync status
 	status := "missing"
 	if exists {
-		expected, err := hooks.ComputeExpected(target.Key)
+		expected, err := hooks.ComputeExpectedForTarget(townRoot, target)
 		if err != nil {
 			status = "error"
 		} else {
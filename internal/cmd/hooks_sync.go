@@ -60,7 +60,7 @@ func runHooksSync(cmd *cobra.Command, args []string) error {
 	errors := 0
 
 	for _, target := range targets {
-		result, err := syncTarget(target, hooksSyncDryRun)
+		result, err := syncTarget(townRoot, target, hooksSyncDryRun)
 		if err != nil {
 			fmt.Printf("  %s %s: %v\n", style.Error.Render("✖"), target.DisplayKey(), err)
 			errors++
@@ -121,9 +121,9 @@ const (
 
 // syncTarget syncs a single target's .claude/settings.json.
 // Uses MarshalSettings/UnmarshalSettings to preserve unknown fields.
-func syncTarget(target hooks.Target, dryRun bool) (syncResult, error) {
+func syncTarget(townRoot string, target hooks.Target, dryRun bool) (syncResult, error) {
 	// Compute expected hooks for this target
-	expected, err := hooks.ComputeExpected(target.Key)
+	expected, err := hooks.ComputeExpectedForTarget(townRoot, target)
 	if err != nil {
 		return 0, fmt.Errorf("computing expected config: %w", err)
 	}
@@ -30,7 +30,7 @@ func TestSyncTargetCreatesNew(t *testing.T) {
 		Role: "crew",
 	}
 
-	result, err := syncTarget(target, false)
+	result, err := syncTarget(tmpDir, target, false)
 	if err != nil {
 		t.Fatalf("syncTarget failed: %v", err)
 	}
@@ -100,7 +100,7 @@ func TestSyncTargetUpdatesExisting(t *testing.T) {
 		Role: "crew",
 	}
 
-	result, err := syncTarget(target, false)
+	result, err := syncTarget(tmpDir, target, false)
 	if err != nil {
 		t.Fatalf("syncTarget failed: %v", err)
 	}
@@ -165,7 +165,7 @@ func TestSyncTargetUnchanged(t *testing.T) {
 		Role: "crew",
 	}
 
-	result, err := syncTarget(target, false)
+	result, err := syncTarget(tmpDir, target, false)
 	if err != nil {
 		t.Fatalf("syncTarget failed: %v", err)
 	}
@@ -197,7 +197,7 @@ func TestSyncTargetDryRun(t *testing.T) {
 	}
 
 	// Dry run should not create the file
-	result, err := syncTarget(target, true)
+	result, err := syncTarget(tmpDir, target, true)
 	if err != nil {
 		t.Fatalf("syncTarget dry-run failed: %v", err)
 	}
@@ -232,7 +232,7 @@ func TestSyncTargetSetsEnabledPlugins(t *testing.T) {
 		Role: "crew",
 	}
 
-	if _, err := syncTarget(target, false); err != nil {
+	if _, err := syncTarget(tmpDir, target, false); err != nil {
 		t.Fatalf("syncTarget failed: %v", err)
 	}
 
@@ -12,11 +12,13 @@ import (
 var issueCmd = &cobra.Command{
 	Use:     "issue",
 	GroupID: GroupConfig,
-	Short:   "Manage current issue for status line display",
+	Short:   "Manage current issue and external tracker sync",
 	Long: `Manage the current issue displayed in the tmux status line.
 
 Sets, clears, or shows the active issue ID stored in the tmux session
-environment. The status line uses this to display what you're working on.`,
+environment. The status line uses this to display what you're working on.
+
+Use 'gt issue sync' to sync beads with external trackers (GitHub, Jira).`,
 }
 
 var issueSetCmd = &cobra.Command{
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/issuesync"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	issueSyncSource  string
+	issueSyncDryRun  bool
+	issueSyncJSON    bool
+	issueSyncTimeout time.Duration
+)
+
+var issueSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync beads with external issue trackers (GitHub, Jira)",
+	Long: `Sync beads with the external issue trackers configured in settings.
+
+Each source in the issue_sync list of settings/config.json is synced in turn:
+
+  - Open external issues carrying the source's label are imported as beads.
+    The external key is stored in the bead's tracker fields, so an issue is
+    only ever imported once.
+  - Status, assignee and close reasons flow in both directions. A change on
+    one side since the last sync wins; when both sides changed, closed wins
+    for status and the bead wins for assignee.
+  - Merge request progress for a tracked bead is posted as a comment.
+  - Title and body are owned by the tracker and refreshed into the bead.
+
+Example settings:
+
+  "issue_sync": [
+    {"provider": "github", "repo": "acme/widgets", "label": "gastown", "rig": "widgets"},
+    {"provider": "jira", "url": "https://acme.atlassian.net", "project": "WID",
+     "label": "gastown", "user_env": "JIRA_USER", "token_env": "JIRA_TOKEN",
+     "assignees": {"widgets/crew/mia": "5b10ac8d82e05b22cc7d4ef5"}}
+  ]
+
+GitHub sources use the gh CLI's authentication. This command runs
+periodically as the issue-sync Deacon plugin.`,
+	RunE: runIssueSync,
+}
+
+func init() {
+	issueCmd.AddCommand(issueSyncCmd)
+	issueSyncCmd.Flags().StringVar(&issueSyncSource, "source", "", "Only sync this source (by name)")
+	issueSyncCmd.Flags().BoolVar(&issueSyncDryRun, "dry-run", false, "Show planned changes without applying them")
+	issueSyncCmd.Flags().BoolVar(&issueSyncJSON, "json", false, "Output reports as JSON")
+	issueSyncCmd.Flags().DurationVar(&issueSyncTimeout, "timeout", 2*time.Minute, "Maximum time per source")
+}
+
+func runIssueSync(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot))
+	if err != nil {
+		return fmt.Errorf("loading town settings: %w", err)
+	}
+
+	var sources []*config.IssueSyncConfig
+	for _, src := range settings.IssueSync {
+		if src == nil || src.Disabled {
+			continue
+		}
+		if issueSyncSource != "" && src.SourceName() != issueSyncSource {
+			continue
+		}
+		sources = append(sources, src)
+	}
+	if len(sources) == 0 {
+		if issueSyncSource != "" {
+			return fmt.Errorf("no enabled issue sync source named %q", issueSyncSource)
+		}
+		if !issueSyncJSON {
+			fmt.Printf("%s No issue sync sources configured\n", style.Dim.Render("○"))
+		} else {
+			fmt.Println("[]")
+		}
+		return nil
+	}
+
+	var reports []*issuesync.Report
+	failed := 0
+	for _, src := range sources {
+		report, err := syncIssueSource(townRoot, src)
+		if err != nil {
+			failed++
+			report = &issuesync.Report{Source: src.SourceName(), Errors: []string{err.Error()}}
+		}
+		reports = append(reports, report)
+	}
+
+	if issueSyncJSON {
+		out, _ := json.MarshalIndent(reports, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		for _, r := range reports {
+			printIssueSyncReport(r)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d issue sync sources failed", failed, len(sources))
+	}
+	return nil
+}
+
+func syncIssueSource(townRoot string, src *config.IssueSyncConfig) (*issuesync.Report, error) {
+	syncer, err := issuesync.NewSyncer(townRoot, src)
+	if err != nil {
+		return nil, err
+	}
+	syncer.DryRun = issueSyncDryRun
+
+	ctx, cancel := context.WithTimeout(context.Background(), issueSyncTimeout)
+	defer cancel()
+	return syncer.Sync(ctx)
+}
+
+func printIssueSyncReport(r *issuesync.Report) {
+	header := r.Source
+	if issueSyncDryRun {
+		header += " (dry run)"
+	}
+	fmt.Printf("%s\n", style.Bold.Render(header))
+
+	if len(r.Actions) == 0 && len(r.Conflicts) == 0 && len(r.Errors) == 0 {
+		fmt.Printf("  %s in sync\n", style.Dim.Render("○"))
+	}
+	for _, a := range r.Actions {
+		target := a.Ref
+		if a.BeadID != "" {
+			target += " ↔ " + a.BeadID
+		}
+		line := fmt.Sprintf("  %s %-13s %s", style.Success.Render("✓"), a.Kind, target)
+		if a.Detail != "" {
+			line += "  " + style.Dim.Render(a.Detail)
+		}
+		fmt.Println(line)
+	}
+	for _, c := range r.Conflicts {
+		fmt.Printf("  %s conflict on %s %s: bead=%q external=%q → %q\n",
+			style.Bold.Render("⚠"), c.Ref, c.Field, c.Bead, c.External, c.Resolution)
+	}
+	for _, e := range r.Errors {
+		fmt.Printf("  %s %s\n", style.Error.Render("✗"), e)
+	}
+}
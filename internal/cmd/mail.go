@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/spf13/cobra"
 )
 
@@ -30,7 +32,13 @@ var (
 	mailThreadJSON    bool
 	mailReplySubject  string
 	mailReplyMessage  string
-	mailStdin         bool // Read message body from stdin
+	mailStdin         bool          // Read message body from stdin
+	mailSendAt        string        // Deliver no earlier than this time
+	mailSendTTL       time.Duration // Expire and auto-archive after this long
+	mailSendWaitAck   time.Duration // Block until the recipient acks or reads
+	mailSendAttach    []string      // Files to attach
+	mailSendPayload   string        // Typed JSON payload (inline or @file)
+	mailSendSchema    string        // Schema name for --payload
 
 	// Search flags
 	mailSearchFrom    string
@@ -134,6 +142,27 @@ Examples:
   gt mail send greenplace/Toast -s "Update" -m "Progress report" --cc overseer
   gt mail send list:oncall -s "Alert" -m "System down"
 
+  # Scheduling, expiry and receipts:
+  gt mail send greenplace/witness -s "Standup" -m "..." --at 09:00
+  gt mail send mayor/ -s "Heads up" -m "..." --at 2h --ttl 4h
+  gt mail send greenplace/Toast -s "Task" -m "..." --wait-ack 10m
+
+--at holds the message out of the inbox until the given time (HH:MM, a
+duration from now, or RFC3339); the recipient is notified when it is due.
+--ttl hides the message once it is older than the TTL and lets
+'gt mail sweep' archive it. --wait-ack blocks until each direct recipient
+acknowledges delivery or reads the message, and exits non-zero on timeout.
+
+  # Attachments and typed payloads:
+  gt mail send mayor/ -s "Build log" -m "See attached" --attach build.log
+  gt mail send gastown/refinery -s "Review" --attach fix.diff --attach notes.md
+  gt mail send deacon/ -s "Stats" --payload @stats.json --payload-schema stats/v1
+
+--attach stores each file in the town blob store (content-addressed, up to
+25 MiB) and references it from the message; read it back with
+'gt mail attachment'. --payload attaches a JSON document (inline, or @file)
+under a schema name so tools can consume it without parsing the body.
+
   # Read body from stdin (avoids shell quoting issues):
   gt mail send mayor/ -s "Update" --stdin <<'BODY'
   Message with 'quotes' and "quotes" and $variables.
@@ -287,7 +316,8 @@ var mailThreadCmd = &cobra.Command{
 	Short: "View a message thread",
 	Long: `View all messages in a conversation thread.
 
-Shows messages in chronological order (oldest first).
+Shows messages in chronological order (oldest first), with scheduling,
+expiry, delivery ack and read receipt status.
 
 Examples:
   gt mail thread thread-abc123`,
@@ -469,6 +499,12 @@ func init() {
 	mailSendCmd.Flags().BoolVar(&mailPermanent, "permanent", false, "Send as permanent (not ephemeral, synced to remote)")
 	mailSendCmd.Flags().BoolVar(&mailSendSelf, "self", false, "Send to self (auto-detect from cwd)")
 	mailSendCmd.Flags().StringArrayVar(&mailCC, "cc", nil, "CC recipients (can be used multiple times)")
+	mailSendCmd.Flags().StringVar(&mailSendAt, "at", "", "Deliver at a later time (HH:MM, duration like 2h, or RFC3339)")
+	mailSendCmd.Flags().DurationVar(&mailSendTTL, "ttl", 0, "Expire the message after this long (e.g. 4h); pinned messages never expire")
+	mailSendCmd.Flags().DurationVar(&mailSendWaitAck, "wait-ack", 0, "Wait up to this long for a delivery ack or read receipt")
+	mailSendCmd.Flags().StringArrayVar(&mailSendAttach, "attach", nil, "Attach a file (can be used multiple times)")
+	mailSendCmd.Flags().StringVar(&mailSendPayload, "payload", "", "Typed JSON payload (inline JSON or @file)")
+	mailSendCmd.Flags().StringVar(&mailSendSchema, "payload-schema", "", "Schema name for --payload (e.g. stats/v1)")
 	_ = mailSendCmd.MarkFlagRequired("subject") // cobra flags: error only at runtime if missing
 
 	// Inbox flags
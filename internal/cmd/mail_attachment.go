@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var mailAttachmentOutput string
+
+var mailAttachmentCmd = &cobra.Command{
+	Use:   "attachment <message-id> [name|digest]",
+	Short: "Save or print a message attachment",
+	Long: `Write an attachment of a message to stdout or a file.
+
+Attachments are stored content-addressed in the town blob store
+(<town>/mail/blobs) and verified against their digest as they are read.
+The attachment may be named by file name or digest; it can be omitted
+when the message has exactly one attachment.
+
+Examples:
+  gt mail attachment hq-abc12                   # Print the only attachment
+  gt mail attachment hq-abc12 build.log -o /tmp/build.log
+  gt mail attachment hq-abc12 fix.diff | git apply`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runMailAttachment,
+}
+
+func init() {
+	mailAttachmentCmd.Flags().StringVarP(&mailAttachmentOutput, "output", "o", "", "Write to file instead of stdout")
+
+	mailCmd.AddCommand(mailAttachmentCmd)
+}
+
+func runMailAttachment(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	router := mail.NewRouter(workDir)
+	mailbox, err := router.GetMailbox(detectSender())
+	if err != nil {
+		return fmt.Errorf("getting mailbox: %w", err)
+	}
+	msg, err := mailbox.Get(args[0])
+	if err != nil {
+		return fmt.Errorf("getting message: %w", err)
+	}
+	if len(msg.Attachments) == 0 {
+		return fmt.Errorf("message %s has no attachments", msg.ID)
+	}
+
+	var ref string
+	if len(args) > 1 {
+		ref = args[1]
+	}
+	att, err := mail.FindAttachment(msg, ref)
+	if err != nil {
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(os.Stderr, "  📎 %s %s\n", a.Name, style.Dim.Render(fmt.Sprintf("(%s, %s)", formatBytes(a.Size), a.Digest)))
+		}
+		return err
+	}
+
+	blob, err := mail.OpenAttachment(router.TownRoot(), att)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	if mailAttachmentOutput == "" {
+		_, err = io.Copy(os.Stdout, blob)
+		return err
+	}
+
+	f, err := os.Create(mailAttachmentOutput)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, blob); err != nil {
+		f.Close()
+		os.Remove(mailAttachmentOutput)
+		return fmt.Errorf("writing %s: %w", mailAttachmentOutput, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("%s Saved %s to %s (%s)\n", style.Success.Render("✓"), att.Name, mailAttachmentOutput, formatBytes(att.Size))
+	return nil
+}
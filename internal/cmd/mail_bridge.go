@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mailbridge"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	mailBridgeIMAP    string
+	mailBridgeSMTP    string
+	mailBridgeDomain  string
+	mailBridgeVerbose bool
+)
+
+var mailBridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Serve town mail over IMAP and SMTP for regular mail clients",
+	Long: `Run a local IMAP/SMTP bridge so a human can read and send Gas Town mail
+with an ordinary mail client (Thunderbird, mutt, Apple Mail, ...).
+
+Each identity appears as an address in the bridge domain:
+
+  mayor/                     mayor@town.local
+  gastown/witness            gastown/witness@town.local
+  gastown/Toast (polecat)    gastown/Toast@town.local
+  overseer                   overseer@town.local
+
+IMAP serves the identity's INBOX. \Seen maps to read, \Flagged to pinned,
+and expunging a \Deleted message archives it. SMTP submissions are routed
+like 'gt mail send'; the sender must be the logged-in identity, and replies
+(In-Reply-To) are threaded with the original.
+
+The bridge only listens on loopback addresses. Every login needs a bridge
+password, created with 'gt mail bridge user add <identity>'. Use the
+identity or its address as the username.
+
+Examples:
+  gt mail bridge user add overseer      # Create a password for the overseer
+  gt mail bridge                        # IMAP on 127.0.0.1:1143, SMTP on 127.0.0.1:1025
+  gt mail bridge --imap 127.0.0.1:9143 --smtp ""   # IMAP only`,
+	RunE: runMailBridge,
+}
+
+var mailBridgeUserCmd = &cobra.Command{
+	Use:   "user",
+	Short: "Manage mail bridge passwords",
+	RunE:  requireSubcommand,
+}
+
+var mailBridgeUserAddCmd = &cobra.Command{
+	Use:     "add <identity>",
+	Aliases: []string{"reset"},
+	Short:   "Create or reset the bridge password for an identity",
+	Long: `Generate a new bridge password for an identity and print it once.
+Any previous password for the identity stops working.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMailBridgeUserAdd,
+}
+
+var mailBridgeUserRemoveCmd = &cobra.Command{
+	Use:   "remove <identity>",
+	Short: "Remove the bridge password for an identity",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMailBridgeUserRemove,
+}
+
+var mailBridgeUserListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List identities with a bridge password",
+	Args:  cobra.NoArgs,
+	RunE:  runMailBridgeUserList,
+}
+
+func init() {
+	mailBridgeCmd.Flags().StringVar(&mailBridgeIMAP, "imap", mailbridge.DefaultIMAPAddr, "IMAP listen address (empty disables IMAP)")
+	mailBridgeCmd.Flags().StringVar(&mailBridgeSMTP, "smtp", mailbridge.DefaultSMTPAddr, "SMTP listen address (empty disables SMTP)")
+	mailBridgeCmd.Flags().StringVar(&mailBridgeDomain, "domain", mailbridge.DefaultDomain, "Mail address domain")
+	mailBridgeCmd.Flags().BoolVarP(&mailBridgeVerbose, "verbose", "v", false, "Log logins and deliveries")
+
+	mailBridgeUserCmd.AddCommand(mailBridgeUserAddCmd)
+	mailBridgeUserCmd.AddCommand(mailBridgeUserRemoveCmd)
+	mailBridgeUserCmd.AddCommand(mailBridgeUserListCmd)
+	mailBridgeCmd.AddCommand(mailBridgeUserCmd)
+	mailCmd.AddCommand(mailBridgeCmd)
+}
+
+// loadMailBridgeUsers loads the bridge credential file of the current town.
+func loadMailBridgeUsers() (*mailbridge.Users, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	return mailbridge.LoadUsers(mailbridge.UsersPath(townRoot))
+}
+
+func runMailBridge(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	users, err := loadMailBridgeUsers()
+	if err != nil {
+		return err
+	}
+	if len(users.Identities()) == 0 {
+		style.PrintWarning("no bridge users yet; create one with 'gt mail bridge user add <identity>'")
+	}
+
+	backend := mailbridge.NewRouterBackend(workDir)
+	defer backend.WaitPendingNotifications()
+	cfg := mailbridge.Config{
+		IMAPAddr: mailBridgeIMAP,
+		SMTPAddr: mailBridgeSMTP,
+		Domain:   mailBridgeDomain,
+		Backend:  backend,
+		Users:    users,
+	}
+	if mailBridgeVerbose {
+		cfg.Logger = log.New(os.Stderr, "mail-bridge: ", log.LstdFlags)
+	}
+	server, err := mailbridge.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+	if err := server.Start(); err != nil {
+		return err
+	}
+	defer server.Close()
+
+	fmt.Printf("%s Mail bridge running for %s\n", style.Success.Render("✓"), mailBridgeDomain)
+	if addr := server.IMAPAddr(); addr != "" {
+		fmt.Printf("  IMAP: %s\n", addr)
+	}
+	if addr := server.SMTPAddr(); addr != "" {
+		fmt.Printf("  SMTP: %s\n", addr)
+	}
+	fmt.Printf("  %s\n", style.Dim.Render("Press Ctrl+C to stop"))
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	<-sigCh
+	fmt.Println("Stopping mail bridge...")
+	return nil
+}
+
+func runMailBridgeUserAdd(cmd *cobra.Command, args []string) error {
+	users, err := loadMailBridgeUsers()
+	if err != nil {
+		return err
+	}
+	password, err := users.Reset(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s Bridge password for %s\n", style.Success.Render("✓"), mailbridge.EmailAddress(args[0], mailbridge.DefaultDomain))
+	fmt.Printf("  %s\n", password)
+	fmt.Printf("  %s\n", style.Dim.Render("Shown once; run 'gt mail bridge user reset' to replace it"))
+	return nil
+}
+
+func runMailBridgeUserRemove(cmd *cobra.Command, args []string) error {
+	users, err := loadMailBridgeUsers()
+	if err != nil {
+		return err
+	}
+	removed, err := users.Remove(args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("no bridge password for %s", args[0])
+	}
+	fmt.Printf("%s Removed bridge password for %s\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+func runMailBridgeUserList(cmd *cobra.Command, args []string) error {
+	users, err := loadMailBridgeUsers()
+	if err != nil {
+		return err
+	}
+	ids := users.Identities()
+	if len(ids) == 0 {
+		fmt.Printf("%s No bridge users\n", style.Dim.Render("○"))
+		return nil
+	}
+	for _, id := range ids {
+		fmt.Printf("  %s  %s\n", id, style.Dim.Render(mailbridge.EmailAddress(id, mailbridge.DefaultDomain)))
+	}
+	return nil
+}
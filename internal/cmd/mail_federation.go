@@ -0,0 +1,301 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	mailFederationSpool    string
+	mailFederationSecret   string
+	mailFederationJSON     bool
+	mailFederationSyncJSON bool
+)
+
+var mailFederationCmd = &cobra.Command{
+	Use:   "federation",
+	Short: "Exchange mail with other towns",
+	Long: `Show the cross-town mail setup and messages waiting for a peer.
+
+Towns exchange mail through spool directories. Each town has an inbound
+spool its peers can write to (a shared mount or synced folder). Mail to
+@<town>/<address> is signed with the secret shared with that peer and
+written into its spool; the peer delivers it on its next sync and writes a
+signed acknowledgement back. Unacknowledged envelopes are retried, retries
+are never delivered twice, and undeliverable mail bounces to the sender.
+
+Setup (on each side):
+  gt mail federation init alpha --spool /shared/gt/alpha
+  gt mail federation peer add beta --spool /shared/gt/beta --secret <hex>
+
+Then:
+  gt mail send @beta/mayor/ -s "Convoy handoff" -m "..."
+  gt mail federation sync        # Deliver incoming mail, process acks
+
+Sync runs periodically as the mail-federation Deacon plugin. The config,
+including the secrets, lives in mail/federation.json (mode 0600).`,
+	RunE: runMailFederationStatus,
+}
+
+var mailFederationInitCmd = &cobra.Command{
+	Use:   "init <town-name>",
+	Short: "Name this town for federation",
+	Long: `Set the name peers use to address this town (@<town-name>/...) and,
+optionally, the inbound spool directory peers write into.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMailFederationInit,
+}
+
+var mailFederationPeerCmd = &cobra.Command{
+	Use:   "peer",
+	Short: "Manage federation peers",
+	RunE:  requireSubcommand,
+}
+
+var mailFederationPeerAddCmd = &cobra.Command{
+	Use:   "add <town>",
+	Short: "Add or update a peer town",
+	Long: `Add a peer town. --spool is the peer's inbound spool directory, as seen
+from this machine. Without --secret a new shared secret is generated and
+printed; configure the same secret on the peer.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMailFederationPeerAdd,
+}
+
+var mailFederationPeerRemoveCmd = &cobra.Command{
+	Use:   "remove <town>",
+	Short: "Remove a peer town",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMailFederationPeerRemove,
+}
+
+var mailFederationSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Deliver incoming federated mail and process acknowledgements",
+	Args:  cobra.NoArgs,
+	RunE:  runMailFederationSync,
+}
+
+func init() {
+	mailFederationCmd.Flags().BoolVar(&mailFederationJSON, "json", false, "Output as JSON")
+	mailFederationInitCmd.Flags().StringVar(&mailFederationSpool, "spool", "", "Inbound spool directory (default <town>/mail/federation/spool)")
+	mailFederationPeerAddCmd.Flags().StringVar(&mailFederationSpool, "spool", "", "The peer's inbound spool directory (required)")
+	mailFederationPeerAddCmd.Flags().StringVar(&mailFederationSecret, "secret", "", "Shared secret (hex); generated if omitted")
+	_ = mailFederationPeerAddCmd.MarkFlagRequired("spool")
+	mailFederationSyncCmd.Flags().BoolVar(&mailFederationSyncJSON, "json", false, "Output as JSON")
+
+	mailFederationPeerCmd.AddCommand(mailFederationPeerAddCmd)
+	mailFederationPeerCmd.AddCommand(mailFederationPeerRemoveCmd)
+	mailFederationCmd.AddCommand(mailFederationInitCmd)
+	mailFederationCmd.AddCommand(mailFederationPeerCmd)
+	mailFederationCmd.AddCommand(mailFederationSyncCmd)
+	mailCmd.AddCommand(mailFederationCmd)
+}
+
+// loadMailFederationConfig returns the town root, config path and config.
+func loadMailFederationConfig() (string, string, *mail.FederationConfig, error) {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return "", "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	path := mail.FederationConfigPath(townRoot)
+	cfg, err := mail.LoadFederationConfig(path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return townRoot, path, cfg, nil
+}
+
+func runMailFederationStatus(cmd *cobra.Command, args []string) error {
+	townRoot, _, _, err := loadMailFederationConfig()
+	if err != nil {
+		return err
+	}
+	fed, err := mail.LoadFederation(townRoot)
+	if err != nil {
+		return err
+	}
+	outbox, err := fed.Outbox()
+	if err != nil {
+		return err
+	}
+	cfg := fed.Config()
+
+	if mailFederationJSON {
+		peers := make([]map[string]string, 0, len(cfg.Peers))
+		for name, peer := range cfg.Peers {
+			peers = append(peers, map[string]string{"town": name, "spool": peer.Spool})
+		}
+		sort.Slice(peers, func(i, j int) bool { return peers[i]["town"] < peers[j]["town"] })
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"town":   cfg.Town,
+			"spool":  fed.SpoolDir(),
+			"peers":  peers,
+			"outbox": outbox,
+		})
+	}
+
+	if cfg.Town == "" {
+		fmt.Printf("%s Federation not configured (run 'gt mail federation init <town-name>')\n", style.Dim.Render("○"))
+		return nil
+	}
+	fmt.Printf("%s Town %s\n", style.Bold.Render("🌐"), cfg.Town)
+	fmt.Printf("  Spool: %s\n", fed.SpoolDir())
+	if len(cfg.Peers) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("No peers"))
+	}
+	names := make([]string, 0, len(cfg.Peers))
+	for name := range cfg.Peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  Peer %-16s %s\n", name, style.Dim.Render(cfg.Peers[name].Spool))
+	}
+
+	if len(outbox) == 0 {
+		return nil
+	}
+	fmt.Printf("\n%s Awaiting acknowledgement (%d)\n", style.Bold.Render("📤"), len(outbox))
+	for _, e := range outbox {
+		line := fmt.Sprintf("  %s → %s: %s", style.Dim.Render(e.ID), mail.RemoteAddress(e.Peer, e.To), e.Subject)
+		line += style.Dim.Render(fmt.Sprintf(" (%d attempts, %s ago)", e.Attempts, time.Since(e.Created).Round(time.Second)))
+		fmt.Println(line)
+		if e.LastError != "" {
+			fmt.Printf("    %s\n", style.Warning.Render(e.LastError))
+		}
+	}
+	return nil
+}
+
+func runMailFederationInit(cmd *cobra.Command, args []string) error {
+	townRoot, path, cfg, err := loadMailFederationConfig()
+	if err != nil {
+		return err
+	}
+	if err := mail.ValidateTownName(args[0]); err != nil {
+		return err
+	}
+	cfg.Town = args[0]
+	if mailFederationSpool != "" {
+		cfg.Spool = mailFederationSpool
+	}
+	if err := mail.SaveFederationConfig(path, cfg); err != nil {
+		return err
+	}
+	fed, err := mail.LoadFederation(townRoot)
+	if err != nil {
+		return err
+	}
+	// Peers refuse to write into a spool that does not exist.
+	if err := os.MkdirAll(fed.SpoolDir(), 0755); err != nil {
+		return fmt.Errorf("creating spool: %w", err)
+	}
+	fmt.Printf("%s This town is %s; peers can reach it as @%s/<address>\n", style.Success.Render("✓"), cfg.Town, cfg.Town)
+	fmt.Printf("  Spool: %s\n", fed.SpoolDir())
+	return nil
+}
+
+func runMailFederationPeerAdd(cmd *cobra.Command, args []string) error {
+	_, path, cfg, err := loadMailFederationConfig()
+	if err != nil {
+		return err
+	}
+	secret := mailFederationSecret
+	generated := secret == ""
+	if generated {
+		if secret, err = mail.GenerateFederationSecret(); err != nil {
+			return err
+		}
+	}
+	if cfg.Peers == nil {
+		cfg.Peers = make(map[string]*mail.FederationPeer)
+	}
+	cfg.Peers[args[0]] = &mail.FederationPeer{Spool: mailFederationSpool, Secret: secret}
+	if err := mail.SaveFederationConfig(path, cfg); err != nil {
+		return err
+	}
+	fmt.Printf("%s Peer %s added (spool %s)\n", style.Success.Render("✓"), args[0], mailFederationSpool)
+	if generated {
+		fmt.Printf("  Shared secret: %s\n", secret)
+		fmt.Printf("  %s\n", style.Dim.Render("Configure the same secret on "+args[0]+" with --secret"))
+	}
+	return nil
+}
+
+func runMailFederationPeerRemove(cmd *cobra.Command, args []string) error {
+	_, path, cfg, err := loadMailFederationConfig()
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Peers[args[0]]; !ok {
+		return fmt.Errorf("%w: %s", mail.ErrUnknownPeer, args[0])
+	}
+	delete(cfg.Peers, args[0])
+	if err := mail.SaveFederationConfig(path, cfg); err != nil {
+		return err
+	}
+	fmt.Printf("%s Peer %s removed\n", style.Success.Render("✓"), args[0])
+	return nil
+}
+
+func runMailFederationSync(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	router := mail.NewRouter(workDir)
+	defer router.WaitPendingNotifications()
+
+	result, err := router.SyncFederation()
+	if err != nil {
+		return err
+	}
+
+	if mailFederationSyncJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			return NewSilentExit(1)
+		}
+		return nil
+	}
+
+	for _, msg := range result.Received {
+		fmt.Printf("  Received %s %s → %s: %s\n", style.Dim.Render(msg.ID), msg.From, msg.To, msg.Subject)
+	}
+	for _, id := range result.Acked {
+		fmt.Printf("  Acknowledged %s\n", style.Dim.Render(id))
+	}
+	for _, id := range result.Bounced {
+		fmt.Printf("  Bounced %s\n", style.Dim.Render(id))
+	}
+	for _, id := range result.Refused {
+		fmt.Printf("  Refused %s\n", style.Dim.Render(id))
+	}
+	for _, id := range result.Retried {
+		fmt.Printf("  Retried %s\n", style.Dim.Render(id))
+	}
+	for _, e := range result.Errors {
+		style.PrintWarning("%s", e)
+	}
+	fmt.Printf("%s Federation sync: %d received, %d acknowledged, %d pending\n",
+		style.Success.Render("✓"), len(result.Received), len(result.Acked), result.Pending)
+	if len(result.Errors) > 0 {
+		return NewSilentExit(1)
+	}
+	return nil
+}
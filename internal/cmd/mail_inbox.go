@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -218,6 +219,7 @@ func runMailRead(cmd *cobra.Command, args []string) error {
 	if msg.Body != "" {
 		fmt.Printf("\n%s\n", msg.Body)
 	}
+	printMailReadContent(msg)
 
 	// Ack after output (non-fatal).
 	if ackErr := mailbox.AcknowledgeDeliveries(address, []*mail.Message{msg}); ackErr != nil {
@@ -227,6 +229,27 @@ func runMailRead(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printMailReadContent prints a message's typed payload and attachments
+// after its body.
+func printMailReadContent(msg *mail.Message) {
+	if msg.Payload != nil {
+		fmt.Printf("\n%s %s\n", style.Bold.Render("Payload:"), msg.Payload.Schema)
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, msg.Payload.Data, "", "  "); err == nil {
+			fmt.Println(pretty.String())
+		} else {
+			fmt.Println(string(msg.Payload.Data))
+		}
+	}
+	if len(msg.Attachments) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("Attachments:"))
+		for _, a := range msg.Attachments {
+			fmt.Printf("  📎 %s %s\n", a.Name, style.Dim.Render(fmt.Sprintf("(%s, %s)", formatBytes(a.Size), a.MediaType)))
+		}
+		fmt.Printf("%s\n", style.Dim.Render(fmt.Sprintf("  Save with: gt mail attachment %s <name> -o <file>", msg.ID)))
+	}
+}
+
 func runMailPeek(cmd *cobra.Command, args []string) error {
 	// Determine which inbox
 	address := detectSender()
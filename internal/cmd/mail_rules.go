@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	mailRulesIdentity string
+	mailRulesJSON     bool
+)
+
+var mailRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Show the mail rules for a mailbox",
+	Long: `Show the mail rules applied to a mailbox at delivery time.
+
+Rules live under "rules" in config/messaging.json, keyed by mailbox address.
+A key may use '*' segments ("gastown/polecats/*"), and "*" applies to every
+mailbox. Rules for "*" run first, then pattern keys, then the exact mailbox.
+
+  {
+    "rules": {
+      "mayor/": [
+        {"name": "quiet-convoys", "match": {"subject": "Convoy progress*"}, "action": "archive"},
+        {"name": "ci", "match": {"from": "*/refinery"}, "action": "label", "label": "ci"},
+        {"name": "patrol-pings", "match": {"subject": "Patrol*", "type": "notification"}, "action": "nudge"},
+        {"name": "witness-urgent", "match": {"from": "*/witness", "type": "task"},
+         "action": "escalate", "priority": "urgent"},
+        {"name": "copy-overseer", "match": {"priority": "urgent"}, "action": "forward", "forward_to": "overseer"}
+      ]
+    }
+  }
+
+Match fields (all set fields must match): from and subject are globs ('*'
+matches anything; subject ignores case), type, priority and thread are exact.
+
+Actions:
+  archive   Store the message already archived, without notification
+  label     Add "label" to the message
+  forward   Deliver a copy to "forward_to" (copies are not filtered again)
+  nudge     Deliver as a nudge instead of mail; falls back to mail when the
+            recipient has no live session
+  escalate  Raise priority to "priority" (high or urgent, default high)
+
+Archive and nudge end rule processing; "stop": true ends it after any rule.
+
+Examples:
+  gt mail rules                       # Rules for your mailbox
+  gt mail rules --identity mayor/     # Rules for another mailbox
+  gt mail rules test                  # Dry-run rules against your inbox
+  gt mail rules test quiet-convoys    # Dry-run one rule`,
+	RunE: runMailRules,
+}
+
+var mailRulesTestCmd = &cobra.Command{
+	Use:   "test [rule-name]",
+	Short: "Dry-run mail rules against messages already in a mailbox",
+	Long: `Evaluate the mailbox's rules against the messages currently in it and
+show what each rule would have done at delivery time. Nothing is changed.
+
+With a rule name, only that rule is evaluated.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMailRulesTest,
+}
+
+func init() {
+	for _, c := range []*cobra.Command{mailRulesCmd, mailRulesTestCmd} {
+		c.Flags().StringVar(&mailRulesIdentity, "identity", "", "Mailbox address (default: your own)")
+		c.Flags().BoolVar(&mailRulesJSON, "json", false, "Output as JSON")
+	}
+
+	mailRulesCmd.AddCommand(mailRulesTestCmd)
+	mailCmd.AddCommand(mailRulesCmd)
+}
+
+// loadMailRules returns the router, mailbox address and rules for --identity.
+func loadMailRules() (*mail.Router, string, []config.MailRule, error) {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	address := mailRulesIdentity
+	if address == "" {
+		address = detectSender()
+	}
+	router := mail.NewRouter(workDir)
+	rules, err := router.RulesFor(address)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return router, address, rules, nil
+}
+
+func runMailRules(cmd *cobra.Command, args []string) error {
+	_, address, rules, err := loadMailRules()
+	if err != nil {
+		return err
+	}
+
+	if mailRulesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rules)
+	}
+
+	if len(rules) == 0 {
+		fmt.Printf("%s No mail rules for %s\n", style.Dim.Render("○"), address)
+		return nil
+	}
+	fmt.Printf("%s Mail rules for %s (%d, evaluated in order)\n", style.Bold.Render("📏"), address, len(rules))
+	for i, rule := range rules {
+		line := fmt.Sprintf("  %d. %-20s %-9s %s", i+1, rule.Name, rule.Action, describeMailRuleMatch(rule.Match))
+		if arg := mailRuleArgument(rule); arg != "" {
+			line += style.Dim.Render(" → " + arg)
+		}
+		if rule.Stop {
+			line += style.Dim.Render(" (stop)")
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// mailRuleTestResult is one message's outcome in gt mail rules test.
+type mailRuleTestResult struct {
+	ID      string           `json:"id"`
+	From    string           `json:"from"`
+	Subject string           `json:"subject"`
+	Outcome mail.RuleOutcome `json:"outcome"`
+}
+
+func runMailRulesTest(cmd *cobra.Command, args []string) error {
+	router, address, rules, err := loadMailRules()
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		var only []config.MailRule
+		for _, rule := range rules {
+			if rule.Name == args[0] {
+				only = append(only, rule)
+			}
+		}
+		if len(only) == 0 {
+			return fmt.Errorf("no rule %q applies to %s", args[0], address)
+		}
+		rules = only
+	}
+
+	mailbox, err := router.GetMailbox(address)
+	if err != nil {
+		return fmt.Errorf("getting mailbox: %w", err)
+	}
+	messages, err := mailbox.List()
+	if err != nil {
+		return fmt.Errorf("listing messages: %w", err)
+	}
+
+	var results []mailRuleTestResult
+	for _, msg := range messages {
+		outcome := mail.EvaluateRules(rules, msg)
+		if len(outcome.Matched) == 0 {
+			continue
+		}
+		results = append(results, mailRuleTestResult{ID: msg.ID, From: msg.From, Subject: msg.Subject, Outcome: outcome})
+	}
+
+	if mailRulesJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	fmt.Printf("%s Testing %d rule(s) against %d message(s) in %s (dry run)\n\n",
+		style.Bold.Render("📏"), len(rules), len(messages), address)
+	for _, r := range results {
+		fmt.Printf("  %s %s %q\n", style.Dim.Render(r.ID), r.From, r.Subject)
+		fmt.Printf("    → %s %s\n", describeRuleOutcome(r.Outcome), style.Dim.Render("("+strings.Join(r.Outcome.Matched, ", ")+")"))
+	}
+	if len(results) > 0 {
+		fmt.Println()
+	}
+	fmt.Printf("%s %d of %d message(s) matched\n", style.Success.Render("✓"), len(results), len(messages))
+	return nil
+}
+
+// describeMailRuleMatch renders a rule's match conditions.
+func describeMailRuleMatch(m config.MailRuleMatch) string {
+	var parts []string
+	for _, f := range []struct{ key, value string }{
+		{"from", m.From}, {"subject", m.Subject}, {"type", m.Type}, {"priority", m.Priority}, {"thread", m.Thread},
+	} {
+		if f.value != "" {
+			parts = append(parts, fmt.Sprintf("%s=%q", f.key, f.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// mailRuleArgument returns the action argument of a rule, if any.
+func mailRuleArgument(rule config.MailRule) string {
+	switch rule.Action {
+	case config.MailRuleLabel:
+		return rule.Label
+	case config.MailRuleForward:
+		return rule.ForwardTo
+	case config.MailRuleEscalate:
+		if rule.Priority == "" {
+			return string(mail.PriorityHigh)
+		}
+		return rule.Priority
+	}
+	return ""
+}
+
+// describeRuleOutcome summarizes what the matching rules would do.
+func describeRuleOutcome(o mail.RuleOutcome) string {
+	var parts []string
+	if o.Priority != "" {
+		parts = append(parts, "escalate to "+string(o.Priority))
+	}
+	for _, l := range o.Labels {
+		parts = append(parts, "label "+l)
+	}
+	for _, f := range o.Forward {
+		parts = append(parts, "forward to "+f)
+	}
+	switch {
+	case o.Archive:
+		parts = append(parts, "archive")
+	case o.NudgeRule != "":
+		parts = append(parts, "deliver as nudge")
+	case len(parts) == 0:
+		parts = append(parts, "deliver")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+func TestDescribeRuleOutcome(t *testing.T) {
+	tests := []struct {
+		outcome mail.RuleOutcome
+		want    string
+	}{
+		{mail.RuleOutcome{Matched: []string{"q"}, Archive: true}, "archive"},
+		{mail.RuleOutcome{Matched: []string{"n"}, NudgeRule: "n"}, "deliver as nudge"},
+		{mail.RuleOutcome{Priority: mail.PriorityUrgent, Labels: []string{"ci"}, Forward: []string{"overseer"}}, "escalate to urgent, label ci, forward to overseer"},
+		{mail.RuleOutcome{Matched: []string{"noop"}}, "deliver"},
+	}
+	for _, tt := range tests {
+		if got := describeRuleOutcome(tt.outcome); got != tt.want {
+			t.Errorf("describeRuleOutcome(%+v) = %q, want %q", tt.outcome, got, tt.want)
+		}
+	}
+}
+
+func TestDescribeMailRuleMatch(t *testing.T) {
+	got := describeMailRuleMatch(config.MailRuleMatch{From: "*/witness", Type: "task"})
+	if got != `from="*/witness" type="task"` {
+		t.Errorf("describeMailRuleMatch = %s", got)
+	}
+}
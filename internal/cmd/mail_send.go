@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/events"
@@ -95,6 +99,32 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 	// Set CC recipients
 	msg.CC = mailCC
 
+	// Scheduling and expiry: the TTL runs from the scheduled delivery time.
+	now := time.Now()
+	if mailSendAt != "" {
+		at, err := parseMailSendAt(mailSendAt, now)
+		if err != nil {
+			return err
+		}
+		if at.After(now) {
+			msg.NotBefore = &at
+		}
+	}
+	if mailSendTTL < 0 {
+		return fmt.Errorf("--ttl must be positive")
+	}
+	if mailSendTTL > 0 {
+		expires := now.Add(mailSendTTL)
+		if msg.NotBefore != nil {
+			expires = msg.NotBefore.Add(mailSendTTL)
+		}
+		msg.ExpiresAt = &expires
+	}
+
+	if err := setMailSendContent(msg, workDir); err != nil {
+		return err
+	}
+
 	// Suppress router-side notification when --no-notify is passed.
 	// Otherwise the router handles idle-aware notification per-recipient,
 	// which also works correctly for fan-out (groups, lists, channels).
@@ -147,6 +177,11 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 		_ = events.LogFeed(events.TypeMail, from, events.MailPayload(to, mailSubject))
 		fmt.Printf("%s Message sent to %s\n", style.Bold.Render("✓"), to)
 		fmt.Printf("  Subject: %s\n", mailSubject)
+		printMailSchedule(msg)
+		printMailContent(msg)
+		if mailSendWaitAck > 0 {
+			return waitForMailReceipts(router, []mailReceiptTarget{{id: msg.ID, to: to}}, mailSendWaitAck)
+		}
 		return nil
 	}
 
@@ -155,6 +190,7 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 	defer router.WaitPendingNotifications()
 	var recipientAddrs []string
 	var sendErrs []string
+	var receiptTargets []mailReceiptTarget
 
 	for _, rec := range recipients {
 		switch rec.Type {
@@ -186,6 +222,7 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 				continue
 			}
 			recipientAddrs = append(recipientAddrs, rec.Address)
+			receiptTargets = append(receiptTargets, mailReceiptTarget{id: msgCopy.ID, to: rec.Address})
 		}
 	}
 
@@ -213,7 +250,123 @@ func runMailSend(cmd *cobra.Command, args []string) error {
 	if msg.Type != mail.TypeNotification {
 		fmt.Printf("  Type: %s\n", msg.Type)
 	}
+	printMailSchedule(msg)
+	printMailContent(msg)
+
+	if mailSendWaitAck > 0 {
+		return waitForMailReceipts(router, receiptTargets, mailSendWaitAck)
+	}
+	return nil
+}
+
+// parseMailSendAt parses --at: a clock time (HH:MM, today or tomorrow if
+// already past), a duration from now (2h), or an RFC3339 timestamp.
+func parseMailSendAt(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("--at %q is in the past", value)
+		}
+		return now.Add(d), nil
+	}
+	if clock, err := time.ParseInLocation("15:04", value, now.Location()); err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, now.Location()); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --at %q: use HH:MM, a duration like 2h, or RFC3339", value)
+}
+
+// printMailSchedule prints the scheduling and expiry lines of a sent message.
+func printMailSchedule(msg *mail.Message) {
+	if msg.NotBefore != nil {
+		fmt.Printf("  Deliver at: %s\n", msg.NotBefore.Local().Format("2006-01-02 15:04"))
+	}
+	if msg.ExpiresAt != nil {
+		fmt.Printf("  Expires: %s\n", msg.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+}
 
+// setMailSendContent applies --payload and --attach to msg. Attachments are
+// stored before the message is sent so a delivered message never references
+// a missing blob.
+func setMailSendContent(msg *mail.Message, workDir string) error {
+	if mailSendPayload != "" || mailSendSchema != "" {
+		if mailSendPayload == "" || mailSendSchema == "" {
+			return fmt.Errorf("--payload and --payload-schema must be used together")
+		}
+		data := []byte(mailSendPayload)
+		if path, ok := strings.CutPrefix(mailSendPayload, "@"); ok {
+			var err error
+			if data, err = os.ReadFile(path); err != nil {
+				return fmt.Errorf("reading payload: %w", err)
+			}
+		}
+		msg.Payload = &mail.Payload{Schema: mailSendSchema, Data: json.RawMessage(bytes.TrimSpace(data))}
+		if err := msg.Payload.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(mailSendAttach) == 0 {
+		return nil
+	}
+	townRoot := mail.NewRouter(workDir).TownRoot()
+	for _, path := range mailSendAttach {
+		att, err := mail.AttachFile(townRoot, path)
+		if err != nil {
+			return fmt.Errorf("attaching %s: %w", path, err)
+		}
+		msg.Attachments = append(msg.Attachments, att)
+	}
+	return nil
+}
+
+// printMailContent prints the attachments and payload schema of a message.
+func printMailContent(msg *mail.Message) {
+	if msg.Payload != nil {
+		fmt.Printf("  Payload: %s\n", msg.Payload.Schema)
+	}
+	for _, a := range msg.Attachments {
+		fmt.Printf("  Attachment: %s (%s, %s)\n", a.Name, formatBytes(a.Size), a.MediaType)
+	}
+}
+
+// mailReceiptTarget is a sent message a --wait-ack caller waits on.
+type mailReceiptTarget struct {
+	id string
+	to string
+}
+
+// waitForMailReceipts blocks until every target has a delivery ack or read
+// receipt, sharing one deadline across all of them.
+func waitForMailReceipts(router *mail.Router, targets []mailReceiptTarget, timeout time.Duration) error {
+	if len(targets) == 0 {
+		style.PrintWarning("--wait-ack: no direct recipients to wait on (queue and channel messages have no receipts)")
+		return nil
+	}
+	fmt.Printf("  Waiting up to %s for receipts...\n", timeout)
+
+	deadline := time.Now().Add(timeout)
+	var missing []string
+	for _, t := range targets {
+		got, err := router.WaitForReceipt(t.id, mail.ReceiptAck, time.Until(deadline))
+		if err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%v)", t.to, err))
+			continue
+		}
+		fmt.Printf("%s %s: %s\n", style.Success.Render("✓"), t.to, strings.Join(mailReceiptStatus(got, time.Now()), ", "))
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no receipt from %s", strings.Join(missing, ", "))
+	}
 	return nil
 }
 
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+func TestParseMailSendAt(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"11:00", time.Date(2026, 3, 1, 11, 0, 0, 0, time.UTC)},
+		{"09:00", time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}, // already past: tomorrow
+		{"2h", now.Add(2 * time.Hour)},
+		{"2026-03-05T08:00:00Z", time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC)},
+		{"2026-03-05 08:15", time.Date(2026, 3, 5, 8, 15, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseMailSendAt(tt.value, now)
+		if err != nil {
+			t.Errorf("parseMailSendAt(%q) error: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseMailSendAt(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"tomorrow", "-5m", "25:00"} {
+		if _, err := parseMailSendAt(bad, now); err == nil {
+			t.Errorf("parseMailSendAt(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestMailReceiptStatus(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	scheduled := &mail.Message{NotBefore: &later, ExpiresAt: &later, DeliveryState: mail.DeliveryStatePending}
+	got := strings.Join(mailReceiptStatus(scheduled, now), "; ")
+	for _, want := range []string{"scheduled for", "expires", "delivery pending"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("status %q missing %q", got, want)
+		}
+	}
+
+	done := &mail.Message{
+		NotBefore:       &earlier,
+		DeliveryState:   mail.DeliveryStateAcked,
+		DeliveryAckedBy: "gastown/witness",
+		ReadBy:          "gastown/witness",
+		ReadAt:          &now,
+	}
+	got = strings.Join(mailReceiptStatus(done, now), "; ")
+	if strings.Contains(got, "scheduled") || !strings.Contains(got, "acked by gastown/witness") || !strings.Contains(got, "read by gastown/witness") {
+		t.Errorf("status = %q", got)
+	}
+}
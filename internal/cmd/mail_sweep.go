@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	mailSweepDryRun bool
+	mailSweepJSON   bool
+)
+
+var mailSweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "Release scheduled mail and archive expired mail",
+	Long: `Process time-based mail across the town.
+
+Scheduled messages (sent with --at) stay out of the recipient's inbox until
+they are due. Sweep notifies the recipients of messages that have become due.
+
+Messages sent with --ttl are hidden from the inbox once they expire. Sweep
+archives them so they stop counting against the mailbox. Pinned messages
+never expire.
+
+Sweep runs periodically as the mail-sweep Deacon plugin.
+
+Examples:
+  gt mail sweep             # Release due mail, archive expired mail
+  gt mail sweep --dry-run   # Show what would change`,
+	RunE: runMailSweep,
+}
+
+func init() {
+	mailSweepCmd.Flags().BoolVarP(&mailSweepDryRun, "dry-run", "n", false, "Show what would be done")
+	mailSweepCmd.Flags().BoolVar(&mailSweepJSON, "json", false, "Output as JSON")
+
+	mailCmd.AddCommand(mailSweepCmd)
+}
+
+func runMailSweep(cmd *cobra.Command, args []string) error {
+	workDir, err := findMailWorkDir()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+
+	router := mail.NewRouter(workDir)
+	defer router.WaitPendingNotifications()
+
+	result, err := router.Sweep(time.Now(), mailSweepDryRun)
+	if err != nil {
+		return err
+	}
+
+	if mailSweepJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			return NewSilentExit(1)
+		}
+		return nil
+	}
+
+	release, archive := "Released", "Archived"
+	if mailSweepDryRun {
+		release, archive = "Would release", "Would archive"
+	}
+	for _, msg := range result.Released {
+		fmt.Printf("  %s %s → %s: %s\n", release, style.Dim.Render(msg.ID), msg.To, msg.Subject)
+	}
+	for _, msg := range result.Expired {
+		fmt.Printf("  %s %s → %s: %s\n", archive, style.Dim.Render(msg.ID), msg.To, msg.Subject)
+	}
+	for _, e := range result.Errors {
+		style.PrintWarning("%s", e)
+	}
+
+	fmt.Printf("%s Mail sweep: %d released, %d expired\n",
+		style.Success.Render("✓"), len(result.Released), len(result.Expired))
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("%d message(s) could not be processed", len(result.Errors))
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/mail"
@@ -70,6 +71,9 @@ func runMailThread(cmd *cobra.Command, args []string) error {
 			msg.From, msg.To)
 		fmt.Printf("    %s\n",
 			style.Dim.Render(msg.Timestamp.Format("2006-01-02 15:04")))
+		if status := mailReceiptStatus(msg, time.Now()); len(status) > 0 {
+			fmt.Printf("    %s\n", style.Dim.Render(strings.Join(status, " · ")))
+		}
 
 		if msg.Body != "" {
 			fmt.Printf("    %s\n", msg.Body)
@@ -79,6 +83,43 @@ func runMailThread(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// mailReceiptStatus describes a message's schedule, expiry and receipts.
+func mailReceiptStatus(msg *mail.Message, now time.Time) []string {
+	const layout = "2006-01-02 15:04"
+	var status []string
+	if msg.IsScheduled(now) {
+		status = append(status, "scheduled for "+msg.NotBefore.Local().Format(layout))
+	}
+	if msg.ExpiresAt != nil {
+		if msg.IsExpired(now) {
+			status = append(status, "expired "+msg.ExpiresAt.Local().Format(layout))
+		} else {
+			status = append(status, "expires "+msg.ExpiresAt.Local().Format(layout))
+		}
+	}
+	switch msg.DeliveryState {
+	case mail.DeliveryStateAcked:
+		s := "acked"
+		if msg.DeliveryAckedBy != "" {
+			s += " by " + msg.DeliveryAckedBy
+		}
+		if msg.DeliveryAckedAt != nil {
+			s += " at " + msg.DeliveryAckedAt.Local().Format(layout)
+		}
+		status = append(status, s)
+	case mail.DeliveryStatePending:
+		status = append(status, "delivery pending")
+	}
+	if msg.ReadAt != nil {
+		s := "read"
+		if msg.ReadBy != "" {
+			s += " by " + msg.ReadBy
+		}
+		status = append(status, s+" at "+msg.ReadAt.Local().Format(layout))
+	}
+	return status
+}
+
 func runMailReply(cmd *cobra.Command, args []string) error {
 	msgID := args[0]
 
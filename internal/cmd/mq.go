@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
@@ -23,6 +25,7 @@ var (
 	mqSubmitEpic      string
 	mqSubmitPriority  int
 	mqSubmitNoCleanup bool
+	mqSubmitParent    string
 
 	// Retry flags
 	mqRetryNow bool
@@ -39,10 +42,19 @@ var (
 	mqListEpic    string
 	mqListJSON    bool
 	mqListVerify  bool
+	mqListAsOf    string
 
 	// Status command flags
 	mqStatusJSON bool
 
+	// Restack command flags
+	mqRestackJSON bool
+
+	// Verify command flags
+	mqVerifyCommit string
+	mqVerifyBase   string
+	mqVerifyJSON   bool
+
 	// Integration land flags
 	mqIntegrationLandForce     bool
 	mqIntegrationLandSkipTests bool
@@ -93,6 +105,12 @@ Target branch auto-detection:
 
 This ensures batch work on epics automatically flows to integration branches.
 
+Stacked MRs:
+  Use --parent when the branch was cut from another polecat's unmerged
+  branch. The MR takes the parent's target, records the parent commit it
+  builds on, and is blocked until the parent lands. The refinery rebases it
+  when the parent branch changes or lands (see 'gt mq restack').
+
 Polecat auto-cleanup:
   When run from a polecat work branch (polecat/<worker>/<issue>), this command
   automatically triggers polecat shutdown after submitting the MR. The polecat
@@ -106,6 +124,7 @@ Examples:
   gt mq submit --issue gp-abc            # Explicit issue
   gt mq submit --epic gt-xyz             # Target integration branch explicitly
   gt mq submit --priority 0              # Override priority (P0)
+  gt mq submit --parent gt-mr-abc        # Stack on an unmerged MR
   gt mq submit --no-cleanup              # Submit without auto-cleanup`,
 	RunE: runMqSubmit,
 }
@@ -143,7 +162,8 @@ Examples:
   gt mq list greenplace
   gt mq list greenplace --ready
   gt mq list greenplace --status=open
-  gt mq list greenplace --worker=Nux`,
+  gt mq list greenplace --worker=Nux
+  gt mq list greenplace --as-of 2h   # The queue two hours ago`,
 	Args: cobra.ExactArgs(1),
 	RunE: runMQList,
 }
@@ -177,6 +197,70 @@ Example:
 	RunE: runMqStatus,
 }
 
+var mqRestackCmd = &cobra.Command{
+	Use:   "restack <rig>",
+	Short: "Rebase stacked MRs onto their parents",
+	Long: `Rebase stacked merge requests (submitted with --parent) onto their parents.
+
+For each open stacked MR:
+  - Parent branch moved: the child's own commits are replayed onto the
+    parent's new head and force-pushed (with lease).
+  - Parent merged: the child is replayed onto the target and unstacked,
+    so it enters the queue as an ordinary MR. If the replay conflicts, a
+    conflict-resolution task is created and the MR is blocked on it.
+  - Parent closed without merging: reported as orphaned for re-targeting.
+
+The refinery runs this after every merge; run it by hand after pushing
+to a parent branch.
+
+Examples:
+  gt mq restack greenplace
+  gt mq restack greenplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMQRestack,
+}
+
+var mqVerifyCmd = &cobra.Command{
+	Use:   "verify <rig> <mr-id>",
+	Short: "Verify a merged commit on the target branch",
+	Long: `Run the quality gates on the commit that merged an MR.
+
+Post-merge verification (merge_queue.post_merge_verify) catches breakage
+that pre-merge gates miss: races between merge paths and environment
+drift. When the gates fail on the merged commit, the refinery:
+  - Opens a P0 revert MR for the merged range
+  - Mails the original polecat or crew member
+  - Pauses the rig's merge queue (only revert MRs stay ready)
+  - Records a main.broken event
+
+A later passing verification of the target resumes the queue and records
+a main.green event. Does nothing when post_merge_verify is off.
+
+The merged commit defaults to the MR's merge_commit field. For ff-only
+merges of several commits, pass --base with the target head before the
+merge so the whole range is reverted.
+
+Examples:
+  gt mq verify greenplace gp-mr-abc123
+  gt mq verify greenplace gp-mr-abc123 --commit HEAD --base ORIG_HEAD`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMQVerify,
+}
+
+var mqResumeCmd = &cobra.Command{
+	Use:   "resume <rig>",
+	Short: "Resume a merge queue paused by post-merge verification",
+	Long: `Clear a merge queue pause left by a failed post-merge verification.
+
+The queue resumes by itself once the target verifies green; use this after
+fixing the target by hand.
+
+Examples:
+  gt mq resume greenplace`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMQResume,
+}
+
 var mqIntegrationCmd = &cobra.Command{
 	Use:   "integration",
 	Short: "Manage integration branches for epics",
@@ -285,6 +369,7 @@ func init() {
 	mqSubmitCmd.Flags().StringVar(&mqSubmitEpic, "epic", "", "Target epic's integration branch instead of main")
 	mqSubmitCmd.Flags().IntVarP(&mqSubmitPriority, "priority", "p", -1, "Override priority (0-4, default: inherit from issue)")
 	mqSubmitCmd.Flags().BoolVar(&mqSubmitNoCleanup, "no-cleanup", false, "Don't auto-cleanup after submit (for polecats)")
+	mqSubmitCmd.Flags().StringVar(&mqSubmitParent, "parent", "", "Stack on an unmerged parent MR (MR ID or branch)")
 
 	// Retry flags
 	mqRetryCmd.Flags().BoolVar(&mqRetryNow, "now", false, "Immediately process instead of waiting for refinery loop")
@@ -296,6 +381,7 @@ func init() {
 	mqListCmd.Flags().StringVar(&mqListEpic, "epic", "", "Show MRs targeting integration/<epic>")
 	mqListCmd.Flags().BoolVar(&mqListJSON, "json", false, "Output as JSON")
 	mqListCmd.Flags().BoolVar(&mqListVerify, "verify", false, "Verify branches exist in git (shows MISSING for deleted branches)")
+	mqListCmd.Flags().StringVar(&mqListAsOf, "as-of", "", "Show the queue as of a time (2h, \"2006-01-02 15:04\") or Dolt commit")
 
 	// Reject flags
 	mqRejectCmd.Flags().StringVarP(&mqRejectReason, "reason", "r", "", "Reason for rejection (required unless --stdin)")
@@ -305,12 +391,23 @@ func init() {
 	// Status flags
 	mqStatusCmd.Flags().BoolVar(&mqStatusJSON, "json", false, "Output as JSON")
 
+	// Restack flags
+	mqRestackCmd.Flags().BoolVar(&mqRestackJSON, "json", false, "Output as JSON")
+
+	// Verify flags
+	mqVerifyCmd.Flags().StringVar(&mqVerifyCommit, "commit", "", "Merged commit to verify (default: the MR's merge_commit)")
+	mqVerifyCmd.Flags().StringVar(&mqVerifyBase, "base", "", "Target head before the merge (default: <commit>^)")
+	mqVerifyCmd.Flags().BoolVar(&mqVerifyJSON, "json", false, "Output as JSON")
+
 	// Add subcommands
 	mqCmd.AddCommand(mqSubmitCmd)
 	mqCmd.AddCommand(mqRetryCmd)
 	mqCmd.AddCommand(mqListCmd)
 	mqCmd.AddCommand(mqRejectCmd)
 	mqCmd.AddCommand(mqStatusCmd)
+	mqCmd.AddCommand(mqRestackCmd)
+	mqCmd.AddCommand(mqVerifyCmd)
+	mqCmd.AddCommand(mqResumeCmd)
 
 	// Integration branch subcommands
 	mqIntegrationCreateCmd.Flags().StringVar(&mqIntegrationCreateBranch, "branch", "", "Override branch name template (supports {title}, {epic}, {prefix}, {user})")
@@ -424,6 +521,138 @@ func runMQRetry(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runMQRestack(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		style.PrintWarning("could not load merge queue config: %v", err)
+	}
+	if mqRestackJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	results, err := eng.Restack()
+	if err != nil {
+		return fmt.Errorf("restacking: %w", err)
+	}
+
+	if mqRestackJSON {
+		if results == nil {
+			results = []refinery.StackResult{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No stacked merge requests in '%s'\n", rigName)
+		return nil
+	}
+	for _, res := range results {
+		icon := style.Bold.Render("✓")
+		switch res.Action {
+		case refinery.StackOrphaned, refinery.StackFailed:
+			icon = style.Bold.Render("✗")
+		case refinery.StackCurrent:
+			icon = style.Dim.Render("·")
+		}
+		fmt.Printf("%s %s %s on %s: %s\n", icon, res.MR, res.Branch, res.Parent, res.Action)
+		if res.Head != "" {
+			fmt.Printf("    %s\n", style.Dim.Render("head "+shortCommit(res.Head)))
+		}
+		if res.Error != "" {
+			fmt.Printf("    %s\n", style.Dim.Render(res.Error))
+		}
+	}
+	return nil
+}
+
+func runMQVerify(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+	mrID := args[1]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	eng := newMQEngineer(r)
+	if !eng.Config().PostMergeVerify {
+		if mqVerifyJSON {
+			return outputJSON(refinery.VerifyResult{Commit: mqVerifyCommit, Passed: true, Skipped: true})
+		}
+		fmt.Printf("%s Post-merge verification is off for '%s' (merge_queue.post_merge_verify)\n",
+			style.Dim.Render("·"), rigName)
+		return nil
+	}
+	if mqVerifyJSON {
+		eng.SetOutput(io.Discard)
+	}
+
+	result, err := eng.VerifyMergedMR(cmd.Context(), mrID, mqVerifyCommit, mqVerifyBase)
+	if err != nil {
+		return err
+	}
+
+	if mqVerifyJSON {
+		return outputJSON(result)
+	}
+	switch {
+	case result.Skipped:
+		fmt.Printf("%s No gates or test command configured; nothing to verify\n", style.Dim.Render("·"))
+	case result.Passed:
+		fmt.Printf("%s %s verified green at %s\n", style.Bold.Render("✓"), mrID, shortCommit(result.Commit))
+		if result.Resumed {
+			fmt.Printf("  %s\n", style.Dim.Render("Merge queue resumed"))
+		}
+	default:
+		fmt.Printf("%s %s broke the target at %s\n", style.Bold.Render("✗"), mrID, shortCommit(result.Commit))
+		fmt.Printf("  Error: %s\n", result.Error)
+		if result.RevertMR != "" {
+			fmt.Printf("  Revert MR: %s\n", style.Bold.Render(result.RevertMR))
+		}
+		if result.Paused {
+			fmt.Printf("  %s\n", style.Dim.Render("Merge queue paused until the target is green"))
+		}
+	}
+	return nil
+}
+
+func runMQResume(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	pause, err := refinery.LoadQueuePause(r.Path)
+	if err != nil {
+		return err
+	}
+	if pause == nil {
+		fmt.Printf("Merge queue for '%s' is not paused\n", rigName)
+		return nil
+	}
+	if _, err := refinery.ClearQueuePause(r.Path); err != nil {
+		return err
+	}
+	_ = events.LogFeed(events.TypeMainGreen, detectSender(), map[string]interface{}{
+		"rig":    rigName,
+		"target": pause.Target,
+		"manual": true,
+	})
+	fmt.Printf("%s Merge queue for '%s' resumed\n", style.Bold.Render("✓"), rigName)
+	return nil
+}
+
 func runMQReject(cmd *cobra.Command, args []string) error {
 	// Handle --stdin: read reason from stdin (avoids shell quoting issues)
 	if mqRejectStdin {
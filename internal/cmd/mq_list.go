@@ -50,6 +50,30 @@ func runMQList(cmd *cobra.Command, args []string) error {
 		opts.Status = "open"
 	}
 
+	// --as-of reads the queue as it was at a past commit; ages and scores are
+	// computed relative to that commit's time.
+	now := time.Now()
+	var asOf *beads.Revision
+	list := b.List
+	if mqListAsOf != "" {
+		list = func(opts beads.ListOptions) ([]*beads.Issue, error) {
+			issues, rev, err := b.ListAsOf(opts, mqListAsOf)
+			if rev != nil {
+				asOf = rev
+				now = rev.Date
+			}
+			return issues, err
+		}
+	}
+
+	// A failed post-merge verification pauses the queue (live view only)
+	var pause *refinery.QueuePause
+	if mqListAsOf == "" {
+		if pause, err = refinery.LoadQueuePause(r.Path); err != nil {
+			style.PrintWarning("%v", err)
+		}
+	}
+
 	var issues []*beads.Issue
 
 	if mqListReady {
@@ -57,7 +81,7 @@ func runMQList(cmd *cobra.Command, args []string) error {
 		// Cannot use b.Ready() because it excludes ephemeral beads,
 		// and MRs are ephemeral by design (see gt-t5t6y).
 		opts.Status = "open"
-		allOpen, err := b.List(opts)
+		allOpen, err := list(opts)
 		if err != nil {
 			return fmt.Errorf("querying ready MRs: %w", err)
 		}
@@ -65,17 +89,21 @@ func runMQList(cmd *cobra.Command, args []string) error {
 			if len(issue.BlockedBy) > 0 || issue.BlockedByCount > 0 {
 				continue // Skip blocked issues
 			}
+			if pause != nil {
+				if fields := beads.ParseMRFields(issue); fields == nil || fields.Reverts == "" {
+					continue // Only revert MRs are ready while paused
+				}
+			}
 			issues = append(issues, issue)
 		}
 	} else {
-		issues, err = b.List(opts)
+		issues, err = list(opts)
 		if err != nil {
 			return fmt.Errorf("querying merge queue: %w", err)
 		}
 	}
 
 	// Apply additional filters and calculate scores
-	now := time.Now()
 	type scoredIssue struct {
 		issue           *beads.Issue
 		fields          *beads.MRFields
@@ -141,6 +169,24 @@ func runMQList(cmd *cobra.Command, args []string) error {
 		return scored[i].score > scored[j].score
 	})
 
+	// Stacked MRs follow their parent so the stack reads top to bottom
+	ids := make([]string, len(scored))
+	parents := make([]string, len(scored))
+	for i, s := range scored {
+		ids[i] = s.issue.ID
+		if s.fields != nil {
+			parents[i] = s.fields.ParentMR
+		}
+	}
+	order, depths := stackOrder(ids, parents)
+	stacked := make([]scoredIssue, len(scored))
+	depth := make(map[string]int, len(scored))
+	for i, idx := range order {
+		stacked[i] = scored[idx]
+		depth[scored[idx].issue.ID] = depths[i]
+	}
+	scored = stacked
+
 	// Extract filtered issues for JSON output compatibility
 	var filtered []*beads.Issue
 	for _, s := range scored {
@@ -175,7 +221,22 @@ func runMQList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Human-readable output
-	fmt.Printf("%s Merge queue for '%s':\n\n", style.Bold.Render("📋"), rigName)
+	if asOf != nil {
+		fmt.Printf("%s Merge queue for '%s' as of %s (%s):\n\n", style.Bold.Render("📋"), rigName,
+			asOf.Date.Local().Format("2006-01-02 15:04:05"), shortCommit(asOf.Commit))
+	} else {
+		fmt.Printf("%s Merge queue for '%s':\n\n", style.Bold.Render("📋"), rigName)
+	}
+	if pause != nil {
+		fmt.Printf("  %s %s\n", style.Error.Render("⛔ PAUSED:"),
+			fmt.Sprintf("%s broken at %s by %s", pause.Target, shortCommit(pause.Commit), pause.MR))
+		if pause.RevertMR != "" {
+			fmt.Printf("  %s\n", style.Dim.Render(fmt.Sprintf("Only revert MRs merge until %s is green (revert: %s)", pause.Target, pause.RevertMR)))
+		} else {
+			fmt.Printf("  %s\n", style.Dim.Render("No revert MR could be opened; fix the target, then 'gt mq resume "+rigName+"'"))
+		}
+		fmt.Println()
+	}
 
 	if len(filtered) == 0 {
 		fmt.Printf("  %s\n", style.Dim.Render("(empty)"))
@@ -222,6 +283,9 @@ func runMQList(cmd *cobra.Command, args []string) error {
 			target = fields.Target
 			convoyID = fields.ConvoyID
 		}
+		if d := depth[issue.ID]; d > 0 {
+			branch = strings.Repeat("  ", d-1) + "└─ " + branch
+		}
 		if target == "" {
 			target = style.Dim.Render("(unset)")
 		}
@@ -296,15 +360,20 @@ func runMQList(cmd *cobra.Command, args []string) error {
 	// Show blocking details below table
 	for _, item := range scored {
 		issue := item.issue
+		displayID := issue.ID
+		if len(displayID) > 12 {
+			displayID = displayID[:12]
+		}
+		if item.fields != nil && item.fields.ParentMR != "" {
+			fmt.Printf("  %s %s\n", style.Dim.Render(displayID+":"),
+				style.Dim.Render(fmt.Sprintf("stacked on %s", item.fields.ParentMR)))
+			continue
+		}
 		displayStatus := issue.Status
 		if issue.Status == "open" && (len(issue.BlockedBy) > 0 || issue.BlockedByCount > 0) {
 			displayStatus = "blocked"
 		}
 		if displayStatus == "blocked" && len(issue.BlockedBy) > 0 {
-			displayID := issue.ID
-			if len(displayID) > 12 {
-				displayID = displayID[:12]
-			}
 			fmt.Printf("  %s %s\n", style.Dim.Render(displayID+":"),
 				style.Dim.Render(fmt.Sprintf("waiting on %s", issue.BlockedBy[0])))
 		}
@@ -313,6 +382,49 @@ func runMQList(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// stackOrder orders MRs so each stacked MR follows its parent, depth-first.
+// ids and parents are parallel slices (parents[i] is ids[i]'s parent_mr, or
+// ""). MRs whose parent is not in the list are roots; relative order among
+// roots and among siblings is preserved. Returns the indices into ids in
+// display order and each entry's stack depth (0 for roots).
+func stackOrder(ids, parents []string) (order []int, depths []int) {
+	index := make(map[string]int, len(ids))
+	for i, id := range ids {
+		index[id] = i
+	}
+	children := make(map[int][]int)
+	var roots []int
+	for i, parent := range parents {
+		if p, ok := index[parent]; ok && parent != "" && p != i {
+			children[p] = append(children[p], i)
+		} else {
+			roots = append(roots, i)
+		}
+	}
+
+	visited := make([]bool, len(ids))
+	var walk func(i, depth int)
+	walk = func(i, depth int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		order = append(order, i)
+		depths = append(depths, depth)
+		for _, c := range children[i] {
+			walk(c, depth+1)
+		}
+	}
+	for _, i := range roots {
+		walk(i, 0)
+	}
+	// Parent cycles have no root; list them flat rather than dropping them
+	for i := range ids {
+		walk(i, 0)
+	}
+	return order, depths
+}
+
 // formatMRAge formats the age of an MR from its created_at timestamp.
 func formatMRAge(createdAt string) string {
 	t, err := time.Parse(time.RFC3339, createdAt)
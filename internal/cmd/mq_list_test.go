@@ -1,6 +1,9 @@
 package cmd
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestBuildMQListColumns_IncludesTarget(t *testing.T) {
 	tests := []struct {
@@ -38,3 +41,51 @@ func TestBuildMQListColumns_IncludesTarget(t *testing.T) {
 		})
 	}
 }
+
+func TestStackOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		ids        []string
+		parents    []string
+		wantOrder  []int
+		wantDepths []int
+	}{
+		{
+			name:       "no stacks keeps order",
+			ids:        []string{"a", "b", "c"},
+			parents:    []string{"", "", ""},
+			wantOrder:  []int{0, 1, 2},
+			wantDepths: []int{0, 0, 0},
+		},
+		{
+			name:       "children follow parent",
+			ids:        []string{"child", "other", "parent", "grandchild"},
+			parents:    []string{"parent", "", "", "child"},
+			wantOrder:  []int{1, 2, 0, 3},
+			wantDepths: []int{0, 0, 1, 2},
+		},
+		{
+			name:       "missing parent is a root",
+			ids:        []string{"child", "a"},
+			parents:    []string{"merged-parent", ""},
+			wantOrder:  []int{0, 1},
+			wantDepths: []int{0, 0},
+		},
+		{
+			name:       "cycle is listed flat",
+			ids:        []string{"a", "b"},
+			parents:    []string{"b", "a"},
+			wantOrder:  []int{0, 1},
+			wantDepths: []int{0, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, depths := stackOrder(tt.ids, tt.parents)
+			if !slices.Equal(order, tt.wantOrder) || !slices.Equal(depths, tt.wantDepths) {
+				t.Errorf("stackOrder = %v %v, want %v %v", order, depths, tt.wantOrder, tt.wantDepths)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/refinery"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+// MQ phase/stats/sla command flags
+var (
+	mqPhaseReason string
+	mqPhaseWorker string
+	mqPhaseTarget string
+
+	mqStatsSince string
+	mqStatsJSON  bool
+
+	mqSLADryRun bool
+	mqSLAJSON   bool
+)
+
+var mqPhaseCmd = &cobra.Command{
+	Use:   "phase <rig> <mr-id> <phase>",
+	Short: "Record a merge request phase transition",
+	Long: `Record that a merge request moved to a new phase.
+
+Phases: ready, claimed, preparing, prepared, merging, merged, rejected, failed.
+Transitions must follow the MR state machine (e.g. claimed → preparing);
+an MR's first recorded phase may be any of them. Recording the current
+phase again is a no-op.
+
+The refinery patrol records each step, so 'gt mq stats' can report how long
+MRs wait and 'gt mq sla' can escalate MRs stuck in a phase.
+
+Examples:
+  gt mq phase greenplace gp-mr-abc123 claimed
+  gt mq phase greenplace gp-mr-abc123 failed --reason "tests failed"`,
+	Args: cobra.ExactArgs(3),
+	RunE: runMQPhase,
+}
+
+var mqStatsCmd = &cobra.Command{
+	Use:   "stats <rig>",
+	Short: "Show merge queue wait times and rework loops",
+	Long: `Show queue-time analytics from the rig's recorded MR phases.
+
+Reports, for the rig and for each polecat:
+  - Wait: p50/p90 of the total time an MR spent ready before being claimed
+  - Gates: p50/p90 of each gate run (time spent preparing), and failures
+  - Rework loops: returns to the queue after failing or being bounced
+
+Only MRs with activity in the --since window are counted. Open MRs over
+their phase SLA are listed at the end.
+
+Examples:
+  gt mq stats greenplace
+  gt mq stats greenplace --since 7d
+  gt mq stats greenplace --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMQStats,
+}
+
+var mqSLACmd = &cobra.Command{
+	Use:   "sla <rig>",
+	Short: "Escalate merge requests stuck in a phase",
+	Long: `Find open merge requests that have stayed in one phase longer than its SLA
+and escalate each to the Deacon (once per stay in a phase).
+
+Default SLAs: ready 4h, claimed 30m, preparing 1h, prepared 30m,
+merging 15m, failed 1h. Override them per rig with merge_queue.phase_sla
+in settings/config.json, e.g. {"ready": "2h", "failed": "off"}.
+
+Each run also drops MRs merged or rejected more than 30 days ago from the
+phase log (not with --dry-run).
+
+Examples:
+  gt mq sla greenplace
+  gt mq sla greenplace --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: runMQSLA,
+}
+
+func init() {
+	mqPhaseCmd.Flags().StringVar(&mqPhaseReason, "reason", "", "Why the MR moved (e.g. the failure)")
+	mqPhaseCmd.Flags().StringVar(&mqPhaseWorker, "worker", "", "Polecat or crew member that submitted the MR")
+	mqPhaseCmd.Flags().StringVar(&mqPhaseTarget, "target", "", "Target branch")
+
+	mqStatsCmd.Flags().StringVar(&mqStatsSince, "since", "", "Only MRs active within this window (e.g. 24h, 7d)")
+	mqStatsCmd.Flags().BoolVar(&mqStatsJSON, "json", false, "Output as JSON")
+
+	mqSLACmd.Flags().BoolVar(&mqSLADryRun, "dry-run", false, "List breaches without escalating")
+	mqSLACmd.Flags().BoolVar(&mqSLAJSON, "json", false, "Output as JSON")
+
+	mqCmd.AddCommand(mqPhaseCmd)
+	mqCmd.AddCommand(mqStatsCmd)
+	mqCmd.AddCommand(mqSLACmd)
+}
+
+// newMQEngineer creates an engineer for r with the rig's merge queue config
+// and settings/config.json overrides applied.
+func newMQEngineer(r *rig.Rig) *refinery.Engineer {
+	eng := refinery.NewEngineer(r)
+	if err := eng.LoadConfig(); err != nil {
+		style.PrintWarning("could not load merge queue config: %v", err)
+	}
+	if settings, err := config.LoadRigSettings(filepath.Join(r.Path, "settings", "config.json")); err == nil {
+		eng.ApplyRigSettings(settings.MergeQueue)
+	}
+	return eng
+}
+
+// recordMRQueued records a new MR's ready phase, so its queue wait is
+// measured from submission.
+func recordMRQueued(townRoot, rigName, mrID, worker, target string) {
+	ev := refinery.PhaseEvent{MR: mrID, Phase: refinery.MRPhaseReady, Worker: worker, Target: target}
+	if err := refinery.RecordPhase(filepath.Join(townRoot, rigName), ev); err != nil {
+		style.PrintWarning("could not record MR phase: %v", err)
+	}
+}
+
+func runMQPhase(cmd *cobra.Command, args []string) error {
+	rigName, mrID, phase := args[0], args[1], refinery.MRPhase(args[2])
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(refinery.AllPhases, phase) {
+		return fmt.Errorf("unknown phase %q", phase)
+	}
+
+	ev := refinery.PhaseEvent{
+		MR:     mrID,
+		Phase:  phase,
+		Worker: mqPhaseWorker,
+		Target: mqPhaseTarget,
+		Reason: mqPhaseReason,
+	}
+	if err := refinery.RecordPhase(r.Path, ev); err != nil {
+		return err
+	}
+	fmt.Printf("%s %s → %s\n", style.Bold.Render("✓"), mrID, phase)
+	return nil
+}
+
+// mqStatsOutput is the JSON output of gt mq stats.
+type mqStatsOutput struct {
+	Rig string `json:"rig"`
+	*refinery.QueueStats
+	SLABreaches []refinery.SLABreach `json:"sla_breaches"`
+}
+
+func runMQStats(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	if mqStatsSince != "" {
+		window, err := parseDuration(mqStatsSince)
+		if err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+		since = time.Now().Add(-window)
+	}
+
+	eng := newMQEngineer(r)
+	eng.SetOutput(io.Discard)
+	stats, err := eng.QueueStats(since)
+	if err != nil {
+		return err
+	}
+	breaches, err := eng.CheckSLA(time.Now())
+	if err != nil {
+		style.PrintWarning("could not check SLAs: %v", err)
+	}
+
+	if mqStatsJSON {
+		return outputJSON(mqStatsOutput{Rig: rigName, QueueStats: stats, SLABreaches: breaches})
+	}
+
+	window := "all recorded"
+	if mqStatsSince != "" {
+		window = "last " + mqStatsSince
+	}
+	fmt.Printf("%s Merge queue stats: %s %s\n\n", style.Bold.Render("📊"), rigName, style.Dim.Render("("+window+")"))
+	total := stats.Total
+	if total.MRs == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("No MR phases recorded"))
+		return nil
+	}
+	fmt.Printf("  MRs:          %d (%d merged, %d rejected, %d open)\n", total.MRs, total.Merged, total.Rejected, total.Open)
+	fmt.Printf("  Wait:         p50 %s  p90 %s  %s\n", formatDuration(total.Wait.P50), formatDuration(total.Wait.P90),
+		style.Dim.Render(fmt.Sprintf("(%d MRs)", total.Wait.Count)))
+	fmt.Printf("  Gates:        p50 %s  p90 %s  %s\n", formatDuration(total.Gate.P50), formatDuration(total.Gate.P90),
+		style.Dim.Render(fmt.Sprintf("(%d runs, %d failed)", total.Gate.Count, total.GateFailures)))
+	fmt.Printf("  Rework loops: %d\n", total.ReworkLoops)
+
+	workers := make([]string, 0, len(stats.Workers))
+	for w := range stats.Workers {
+		workers = append(workers, w)
+	}
+	sort.Strings(workers)
+	fmt.Printf("\n%s\n", style.Bold.Render("By polecat"))
+	table := style.NewTable(
+		style.Column{Name: "WORKER", Width: 16},
+		style.Column{Name: "MRS", Width: 4, Align: style.AlignRight},
+		style.Column{Name: "MERGED", Width: 6, Align: style.AlignRight},
+		style.Column{Name: "WAIT P50", Width: 10, Align: style.AlignRight},
+		style.Column{Name: "WAIT P90", Width: 10, Align: style.AlignRight},
+		style.Column{Name: "GATE P50", Width: 10, Align: style.AlignRight},
+		style.Column{Name: "GATE FAILS", Width: 10, Align: style.AlignRight},
+		style.Column{Name: "REWORK", Width: 6, Align: style.AlignRight},
+	).SetIndent("  ")
+	for _, w := range workers {
+		ws := stats.Workers[w]
+		table.AddRow(w,
+			fmt.Sprint(ws.MRs),
+			fmt.Sprint(ws.Merged),
+			formatDuration(ws.Wait.P50),
+			formatDuration(ws.Wait.P90),
+			formatDuration(ws.Gate.P50),
+			fmt.Sprint(ws.GateFailures),
+			fmt.Sprint(ws.ReworkLoops))
+	}
+	fmt.Print(table.Render())
+
+	if len(breaches) > 0 {
+		fmt.Printf("\n%s\n", style.Bold.Render("⚠ Over SLA"))
+		printSLABreaches(breaches)
+	}
+	return nil
+}
+
+func runMQSLA(cmd *cobra.Command, args []string) error {
+	rigName := args[0]
+
+	_, r, _, err := getRefineryManager(rigName)
+	if err != nil {
+		return err
+	}
+
+	if !mqSLADryRun {
+		if _, err := refinery.PrunePhaseLog(r.Path, time.Now().Add(-refinery.PhaseLogRetention)); err != nil {
+			style.PrintWarning("could not prune MR phase log: %v", err)
+		}
+	}
+
+	eng := newMQEngineer(r)
+	if mqSLAJSON {
+		eng.SetOutput(io.Discard)
+	}
+	breaches, err := eng.CheckSLA(time.Now())
+	if err != nil {
+		return err
+	}
+	escalated := breaches
+	if !mqSLADryRun {
+		if escalated, err = eng.EscalateSLABreaches(breaches); err != nil {
+			return err
+		}
+	}
+
+	if mqSLAJSON {
+		return outputJSON(map[string]interface{}{
+			"rig":       rigName,
+			"breaches":  breaches,
+			"escalated": escalated,
+			"dry_run":   mqSLADryRun,
+		})
+	}
+	if len(breaches) == 0 {
+		fmt.Printf("%s All open MRs in '%s' are within their phase SLAs\n", style.Bold.Render("✓"), rigName)
+		return nil
+	}
+	printSLABreaches(breaches)
+	switch {
+	case mqSLADryRun:
+		fmt.Printf("\n%s\n", style.Dim.Render("Dry run: nothing escalated"))
+	case len(escalated) == 0:
+		fmt.Printf("\n%s\n", style.Dim.Render("All breaches were already escalated"))
+	default:
+		fmt.Printf("\n%s Escalated %d MR(s) to the Deacon\n", style.Bold.Render("⚠"), len(escalated))
+	}
+	return nil
+}
+
+func printSLABreaches(breaches []refinery.SLABreach) {
+	for _, b := range breaches {
+		worker := ""
+		if b.Worker != "" {
+			worker = style.Dim.Render(" (" + b.Worker + ")")
+		}
+		fmt.Printf("  %s%s %s for %s %s\n", b.MR, worker, b.Phase, formatDuration(b.Age),
+			style.Dim.Render("(SLA "+formatDuration(b.Limit)+")"))
+	}
+}
@@ -161,9 +161,21 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	// Initialize beads for looking up source issue
 	bd := beads.New(cwd)
 
+	// Resolve the parent MR for a stacked submission
+	var parent *stackParent
+	if mqSubmitParent != "" {
+		parent, err = resolveStackParent(bd, g, branch, mqSubmitParent)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Determine target branch
 	target := defaultBranch
-	if mqSubmitEpic != "" {
+	if parent != nil {
+		// A stacked MR lands wherever its parent lands
+		target = parent.Target
+	} else if mqSubmitEpic != "" {
 		// Explicit --epic flag: read stored branch name, fall back to template
 		rigPath := filepath.Join(townRoot, rigName)
 		target = resolveIntegrationBranchName(bd, rigPath, mqSubmitEpic)
@@ -209,6 +221,9 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	if worker != "" {
 		description += fmt.Sprintf("\nworker: %s", worker)
 	}
+	if parent != nil {
+		description += fmt.Sprintf("\nparent_mr: %s\nparent_head: %s", parent.ID, parent.Head)
+	}
 
 	// Check if MR bead already exists for this branch (idempotency)
 	var mrIssue *beads.Issue
@@ -234,6 +249,15 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("creating merge request bead: %w", err)
 		}
 
+		// Block the child on its parent so the stack merges in order
+		if parent != nil {
+			if err := bd.AddDependency(mrIssue.ID, parent.ID); err != nil {
+				style.PrintWarning("could not block %s on parent %s: %v", mrIssue.ID, parent.ID, err)
+			}
+		}
+
+		recordMRQueued(townRoot, rigName, mrIssue.ID, worker, target)
+
 		// Nudge refinery to pick up the new MR
 		nudgeRefinery(rigName, "MERGE_READY received - check inbox for pending work")
 	}
@@ -243,6 +267,9 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  MR ID: %s\n", style.Bold.Render(mrIssue.ID))
 	fmt.Printf("  Source: %s\n", branch)
 	fmt.Printf("  Target: %s\n", target)
+	if parent != nil {
+		fmt.Printf("  Stacked on: %s (%s @ %s)\n", parent.ID, parent.Branch, shortCommit(parent.Head))
+	}
 	fmt.Printf("  Issue: %s\n", issueID)
 	if worker != "" {
 		fmt.Printf("  Worker: %s\n", worker)
@@ -266,6 +293,55 @@ func runMqSubmit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// stackParent is the parent MR a stacked submission builds on.
+type stackParent struct {
+	ID     string // Parent MR bead ID
+	Branch string // Parent source branch
+	Target string // Parent target branch (inherited by the child)
+	Head   string // Parent commit the child branched from
+}
+
+// resolveStackParent looks up the parent MR named by ref (an MR ID or a
+// branch with an open MR) and finds the parent commit branch was cut from.
+func resolveStackParent(bd *beads.Beads, g *git.Git, branch, ref string) (*stackParent, error) {
+	issue, err := bd.Show(ref)
+	if err != nil || issue == nil || !beads.HasLabel(issue, "gt:merge-request") {
+		issue, err = bd.FindMRForBranch(ref)
+		if err != nil {
+			return nil, fmt.Errorf("looking up parent MR %s: %w", ref, err)
+		}
+		if issue == nil {
+			return nil, fmt.Errorf("parent %s is not an open merge request", ref)
+		}
+	}
+	if issue.Status == "closed" {
+		return nil, fmt.Errorf("parent %s is already closed; submit against its target instead", issue.ID)
+	}
+
+	fields := beads.ParseMRFields(issue)
+	if fields == nil || fields.Branch == "" {
+		return nil, fmt.Errorf("parent %s has no branch", issue.ID)
+	}
+	if fields.Branch == branch {
+		return nil, fmt.Errorf("cannot stack %s on itself", branch)
+	}
+
+	parentRef := fields.Branch
+	if exists, _ := g.BranchExists(parentRef); !exists {
+		parentRef = "origin/" + fields.Branch
+	}
+	head, err := g.MergeBase(branch, parentRef)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not branch from parent %s (%s): %w", branch, issue.ID, fields.Branch, err)
+	}
+
+	target := fields.Target
+	if target == "" {
+		target = "main"
+	}
+	return &stackParent{ID: issue.ID, Branch: fields.Branch, Target: target, Head: head}, nil
+}
+
 // polecatCleanup sends a lifecycle shutdown request to the witness and waits for termination.
 // This is called after a polecat successfully submits an MR.
 func polecatCleanup(rigName, worker, townRoot string) error {
@@ -73,6 +73,9 @@ Delivery modes (--mode):
 
 Queue and wait-idle modes require the target agent to support hooks
 (UserPromptSubmit) for drain. Agents without hook support should use immediate.
+Queued nudges are tracked (see 'gt nudge status <session>'), and urgent
+ones that are dropped or expire unseen are delivered as mail instead.
+Each sender may send 60 nudges per 5 minutes in any mode.
 
 The default is immediate for backward compatibility. For non-urgent messages
 where you don't want to interrupt the agent's current work, use --mode=queue.
@@ -128,6 +131,16 @@ var waitIdleTimeout = 15 * time.Second
 func deliverNudge(t *tmux.Tmux, sessionName, message, sender string) error {
 	townRoot, _ := workspace.FindFromCwd()
 
+	if townRoot != "" {
+		if err := nudge.CheckSenderRate(townRoot, sessionName, nudgeRateKey(sender), nudge.QueuedNudge{
+			Sender:   sender,
+			Message:  message,
+			Priority: nudgePriorityFlag,
+		}); err != nil {
+			return err
+		}
+	}
+
 	// For direct tmux delivery, prefix with sender attribution.
 	// Queue-based delivery stores Sender as a separate field and
 	// FormatForInjection adds the prefix, so we must NOT double-prefix.
@@ -179,6 +192,20 @@ func deliverNudge(t *tmux.Tmux, sessionName, message, sender string) error {
 	}
 }
 
+// nudgeRateKey returns the rate-limit bucket for a nudge sender. Senders
+// without a known role are keyed by their tmux session, so unrelated
+// unidentified callers don't share one bucket; outside tmux (an operator's
+// shell) they are not limited.
+func nudgeRateKey(sender string) string {
+	if sender != "unknown" {
+		return sender
+	}
+	if s := tmux.CurrentSessionName(); s != "" {
+		return "session:" + s
+	}
+	return ""
+}
+
 // validNudgeModes is the set of allowed --mode values.
 var validNudgeModes = map[string]bool{
 	NudgeModeImmediate: true,
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/nudge"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var (
+	nudgeStatusJSON  bool
+	nudgeStatusLimit int
+)
+
+var nudgeStatusCmd = &cobra.Command{
+	Use:   "status <session>",
+	Short: "Show delivery history of queued nudges for a session",
+	Long: `Show what happened to the nudges queued for a session.
+
+Each queued nudge moves through these states:
+  queued        Written to the session's nudge queue
+  injected      Picked up by the agent's hook at a turn boundary
+  acknowledged  The agent finished the turn that carried the nudge
+  expired       Still queued when its TTL elapsed
+  dropped       Refused: queue full or sender rate limit
+
+Urgent nudges that are dropped or expire are delivered as mail instead
+(marked "mailed"). Only queued nudges (--mode=queue, wait-idle fallback,
+mail notifications) are tracked; immediate nudges go straight to tmux.
+
+The session is a tmux session name (gt-gastown-crew-max); "mayor" and
+"deacon" are accepted as shortcuts.
+
+Examples:
+  gt nudge status gt-gastown-crew-max
+  gt nudge status mayor --limit 50
+  gt nudge status hq-deacon --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runNudgeStatus,
+}
+
+func init() {
+	nudgeStatusCmd.Flags().BoolVar(&nudgeStatusJSON, "json", false, "Output as JSON")
+	nudgeStatusCmd.Flags().IntVarP(&nudgeStatusLimit, "limit", "n", 20, "Show the most recent N nudges (0 for all)")
+	nudgeCmd.AddCommand(nudgeStatusCmd)
+
+	nudge.SetDeadLetterHandler(mailDeadLetterNudge)
+}
+
+func runNudgeStatus(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwdOrError()
+	if err != nil {
+		return fmt.Errorf("not in a Gas Town workspace: %w", err)
+	}
+	sessionName := args[0]
+	switch sessionName {
+	case "mayor":
+		sessionName = session.MayorSessionName()
+	case "deacon":
+		sessionName = session.DeaconSessionName()
+	}
+
+	statuses, err := nudge.Statuses(townRoot, sessionName)
+	if err != nil {
+		return fmt.Errorf("reading nudge history: %w", err)
+	}
+	pending, err := nudge.Pending(townRoot, sessionName)
+	if err != nil {
+		return err
+	}
+	if nudgeStatusLimit > 0 && len(statuses) > nudgeStatusLimit {
+		statuses = statuses[len(statuses)-nudgeStatusLimit:]
+	}
+
+	if nudgeStatusJSON {
+		if statuses == nil {
+			statuses = []nudge.NudgeStatus{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"session": sessionName,
+			"pending": pending,
+			"nudges":  statuses,
+		})
+	}
+
+	fmt.Printf("%s Nudges for %s (%d pending)\n", style.Bold.Render("📨"), sessionName, pending)
+	if len(statuses) == 0 {
+		fmt.Printf("  %s\n", style.Dim.Render("No queued nudges recorded"))
+		return nil
+	}
+	for _, s := range statuses {
+		state := s.State
+		if s.DeadLetter {
+			state += ", mailed"
+		}
+		line := fmt.Sprintf("  %s  %-20s %-14s", s.QueuedAt.Local().Format("01-02 15:04:05"), truncateNudgeField(s.Sender, 20), formatNudgeState(s.State, state))
+		if s.Priority == nudge.PriorityUrgent {
+			line += " " + style.Warning.Render("[urgent]")
+		}
+		line += " " + truncateNudgeField(s.Message, 60)
+		fmt.Println(line)
+		detail := fmt.Sprintf("%s, %s", s.ID, formatNudgeAge(s.Time))
+		if s.Reason != "" {
+			detail += ": " + s.Reason
+		}
+		fmt.Printf("    %s\n", style.Dim.Render(detail))
+	}
+	return nil
+}
+
+// formatNudgeState colors a delivery state for display.
+func formatNudgeState(state, label string) string {
+	switch state {
+	case nudge.StateAcknowledged:
+		return style.Success.Render(label)
+	case nudge.StateExpired, nudge.StateDropped:
+		return style.Warning.Render(label)
+	case nudge.StateQueued:
+		return style.Dim.Render(label)
+	default:
+		return label
+	}
+}
+
+// formatNudgeAge describes when a state was reached.
+func formatNudgeAge(t time.Time) string {
+	return fmt.Sprintf("%s ago", time.Since(t).Round(time.Second))
+}
+
+// truncateNudgeField shortens s to n runes on one line.
+func truncateNudgeField(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
+
+// mailDeadLetterNudge converts an urgent nudge that can no longer be
+// delivered into mail for the session's agent, so it is not lost.
+func mailDeadLetterNudge(townRoot, sessionName string, n nudge.QueuedNudge, reason string) error {
+	to := sessionNameToAddress(sessionName)
+	if to == "" {
+		return fmt.Errorf("no mail address for session %s", sessionName)
+	}
+	from := n.Sender
+	if from == "" {
+		from = "unknown"
+	}
+	body := fmt.Sprintf("%s\n\n(Urgent nudge %s, queued %s, was not delivered: %s.)",
+		n.Message, n.ID, n.Timestamp.Local().Format(time.RFC3339), reason)
+	msg := mail.NewMessage(from, to, "Undelivered urgent nudge from "+from, body)
+	msg.Priority = mail.PriorityUrgent
+
+	router := mail.NewRouter(townRoot)
+	defer router.WaitPendingNotifications()
+	return router.Send(msg)
+}
@@ -126,7 +126,8 @@ func TestBuildRefineryPatrolVars_FullConfig(t *testing.T) {
 	vars := buildRefineryPatrolVars(ctx)
 
 	// DefaultMergeQueueConfig: refinery_enabled=true, auto_land=false, run_tests=true,
-	// test_command="go test ./...", target_branch="main" (from rig config), delete_merged_branches=true
+	// test_command="go test ./...", target_branch="main" (from rig config), delete_merged_branches=true,
+	// post_merge_verify=false
 	// New commands (setup, typecheck, lint, build) default to empty = omitted
 	expected := map[string]string{
 		"integration_branch_refinery_enabled": "true",
@@ -135,6 +136,7 @@ func TestBuildRefineryPatrolVars_FullConfig(t *testing.T) {
 		"test_command":                        "go test ./...",
 		"target_branch":                       "main",
 		"delete_merged_branches":              "true",
+		"post_merge_verify":                   "false",
 	}
 
 	varMap := make(map[string]string)
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+)
+
+var (
+	polecatPoolJSON bool
+	polecatPoolAll  bool
+)
+
+var polecatPoolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "Manage the warm worktree pool",
+	Long: `Manage pre-provisioned polecat worktrees.
+
+A rig with a worktree pool keeps idle worktrees ready under polecats/.warm/,
+checked out at the base branch with overlay files and setup hooks already
+applied. Spawning a polecat claims one instead of creating a worktree and
+running setup hooks from scratch. Configure it in <rig>/settings/config.json:
+
+  "worktree_pool": {
+    "size": 3,
+    "refresh_interval": "30m",
+    "shared_caches": true
+  }
+
+Slots whose base branch has moved are refreshed (checkout + setup hooks) at
+most once per refresh_interval. With shared_caches (the default), setup
+hooks and polecat sessions use GOMODCACHE, GOCACHE, npm, yarn and pip caches
+under <town>/.cache/ unless those variables are already set.
+
+The pool is topped up in the background after each claim and by the daemon.`,
+	RunE: requireSubcommand,
+}
+
+var polecatPoolStatusCmd = &cobra.Command{
+	Use:   "status [rig]",
+	Short: "Show warm worktree slots",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runPolecatPoolStatus,
+}
+
+var polecatPoolReconcileCmd = &cobra.Command{
+	Use:   "reconcile [rig]",
+	Short: "Provision, refresh and trim warm worktrees to the configured size",
+	Long: `Bring a rig's warm worktree pool to its configured size.
+
+Removes broken, abandoned and surplus slots, refreshes slots whose base
+branch moved more than refresh_interval ago, and provisions new slots.
+Only one reconcile runs per rig at a time; a concurrent call returns
+immediately.
+
+Examples:
+  gt polecat pool reconcile gastown
+  gt polecat pool reconcile --all`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPolecatPoolReconcile,
+}
+
+func init() {
+	polecatPoolStatusCmd.Flags().BoolVar(&polecatPoolJSON, "json", false, "Output as JSON")
+	polecatPoolStatusCmd.Flags().BoolVar(&polecatPoolAll, "all", false, "Show all rigs")
+	polecatPoolReconcileCmd.Flags().BoolVar(&polecatPoolAll, "all", false, "Reconcile every rig")
+
+	polecatPoolCmd.AddCommand(polecatPoolStatusCmd)
+	polecatPoolCmd.AddCommand(polecatPoolReconcileCmd)
+	polecatCmd.AddCommand(polecatPoolCmd)
+}
+
+// poolRigs returns the rigs named by args or --all.
+func poolRigs(args []string) ([]*rig.Rig, error) {
+	if polecatPoolAll {
+		rigs, _, err := getAllRigs()
+		return rigs, err
+	}
+	if len(args) < 1 {
+		return nil, fmt.Errorf("rig name required (or use --all)")
+	}
+	_, r, err := getPolecatManager(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return []*rig.Rig{r}, nil
+}
+
+func runPolecatPoolStatus(cmd *cobra.Command, args []string) error {
+	rigs, err := poolRigs(args)
+	if err != nil {
+		return err
+	}
+
+	status := make(map[string][]*polecat.WarmSlot)
+	for _, r := range rigs {
+		mgr, _, err := getPolecatManager(r.Name)
+		if err != nil {
+			return err
+		}
+		slots, err := mgr.WarmSlots()
+		if err != nil {
+			return err
+		}
+		if slots == nil {
+			slots = []*polecat.WarmSlot{}
+		}
+		status[r.Name] = slots
+	}
+
+	if polecatPoolJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	for _, r := range rigs {
+		slots := status[r.Name]
+		ready := 0
+		for _, s := range slots {
+			if s.Ready {
+				ready++
+			}
+		}
+		fmt.Printf("%s %s: %d/%d ready\n", style.Bold.Render("●"), r.Name, ready, len(slots))
+		for _, s := range slots {
+			state := style.Success.Render("ready")
+			if !s.Ready {
+				state = style.Warning.Render("provisioning")
+			}
+			fmt.Printf("  %-14s %-12s %s @ %s  refreshed %s ago\n",
+				s.ID, state, s.BaseRef, shortCommit(s.BaseCommit),
+				time.Since(s.RefreshedAt).Round(time.Minute))
+		}
+	}
+	return nil
+}
+
+func runPolecatPoolReconcile(cmd *cobra.Command, args []string) error {
+	rigs, err := poolRigs(args)
+	if err != nil {
+		return err
+	}
+	for _, r := range rigs {
+		mgr, _, err := getPolecatManager(r.Name)
+		if err != nil {
+			return err
+		}
+		res, err := mgr.ReconcileWarmPool()
+		if err != nil {
+			style.PrintWarning("%s: %v", r.Name, err)
+			continue
+		}
+		if res.Size == 0 && res.Removed == 0 {
+			continue
+		}
+		fmt.Printf("%s %s: %d/%d ready (+%d provisioned, %d refreshed, -%d removed)\n",
+			style.Bold.Render("✓"), r.Name, res.Ready, res.Size,
+			res.Provisioned, res.Refreshed, res.Removed)
+	}
+	return nil
+}
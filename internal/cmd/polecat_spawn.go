@@ -14,6 +14,7 @@ import (
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/polecat"
+	"github.com/steveyegge/gastown/internal/quota"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -320,7 +321,11 @@ func (s *SpawnedPolecatInfo) StartSession() (string, error) {
 
 	// Resolve account
 	accountsPath := constants.MayorAccountsPath(townRoot)
-	claudeConfigDir, _, err := config.ResolveAccountConfigDir(accountsPath, s.account)
+	account := s.account
+	if account == "" && os.Getenv("GT_ACCOUNT") == "" {
+		account = preselectAccount(townRoot, accountsPath, s.agent)
+	}
+	claudeConfigDir, _, err := config.ResolveAccountConfigDir(accountsPath, account)
 	if err != nil {
 		return "", fmt.Errorf("resolving account: %w", err)
 	}
@@ -466,3 +471,30 @@ func verifyWorktreeExists(clonePath string) error {
 
 	return nil
 }
+
+// preselectAccount picks the account with the most estimated quota headroom
+// for the agent's provider when accounts.json opts into headroom selection.
+// Returns "" to fall back to the default account. Agents whose provider
+// cannot switch accounts (no config_dir_env) are never pre-selected for.
+func preselectAccount(townRoot, accountsPath, agent string) string {
+	acctCfg, err := config.LoadAccountsConfig(accountsPath)
+	if err != nil || acctCfg.Selection != config.AccountSelectionHeadroom || len(acctCfg.Accounts) < 2 {
+		return ""
+	}
+	provider := string(config.AgentClaude)
+	if agent != "" {
+		provider = agent
+	}
+	if config.ValidateAccountProvider(provider) != nil {
+		return ""
+	}
+	handle, err := quota.SelectAccount(townRoot, acctCfg, provider)
+	if err != nil {
+		style.PrintWarning("account pre-selection failed, using default: %v", err)
+		return ""
+	}
+	if handle != "" {
+		fmt.Printf("Using account %s (most quota headroom)\n", handle)
+	}
+	return handle
+}
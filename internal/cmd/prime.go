@@ -25,6 +25,7 @@ var primeDryRun bool
 var primeState bool
 var primeStateJSON bool
 var primeExplain bool
+var primeBudget int
 
 // primeHookSource stores the SessionStart source ("startup", "resume", "clear", "compact")
 // when running in hook mode. Used to provide lighter output on compaction/resume.
@@ -69,7 +70,20 @@ HOOK MODE (--hook):
   Claude Code sends JSON on stdin:
     {"session_id": "uuid", "transcript_path": "/path", "source": "startup|resume"}
 
-  Other agents can set GT_SESSION_ID environment variable instead.`,
+  Other agents can set GT_SESSION_ID environment variable instead.
+
+CONTEXT BUDGET:
+  Output is assembled from sections (role context, hooked work, mail,
+  escalations, bd prime, ...) under an approximate token budget, 8000 by
+  default. When over budget, optional sections are summarized and then
+  dropped, lowest priority first; a dropped section names the command that
+  shows it in full. Injected mail is never trimmed, since injecting it marks
+  it read. Configure in settings/config.json:
+
+    "prime": {"budget": 8000, "role_budgets": {"mayor": 12000},
+              "priorities": {"bd-prime": 20, "escalations": 95}}
+
+  Use --explain to see each section's size, priority and outcome.`,
 	RunE: runPrime,
 }
 
@@ -83,7 +97,9 @@ func init() {
 	primeCmd.Flags().BoolVar(&primeStateJSON, "json", false,
 		"Output state as JSON (requires --state)")
 	primeCmd.Flags().BoolVar(&primeExplain, "explain", false,
-		"Show why each section was included")
+		"Show why each section was included, summarized or dropped")
+	primeCmd.Flags().IntVar(&primeBudget, "budget", 0,
+		"Approximate token budget for the output (overrides settings; negative disables)")
 	rootCmd.AddCommand(primeCmd)
 }
 
@@ -149,7 +165,8 @@ func runPrime(cmd *cobra.Command, args []string) (retErr error) {
 		return nil
 	}
 
-	formula, err := outputRoleContext(ctx)
+	a := newPrimeAssembler(townRoot, ctx.Role, primeBudget)
+	formula, err := outputRoleContext(a, ctx)
 	if err != nil {
 		return err
 	}
@@ -158,22 +175,24 @@ func runPrime(cmd *cobra.Command, args []string) (retErr error) {
 	// started with. Only emitted when GT telemetry is active (GT_OTEL_LOGS_URL set).
 	telemetry.RecordPrimeContext(context.Background(), formula, os.Getenv("GT_ROLE"), primeHookMode)
 
-	hasSlungWork := checkSlungWork(ctx)
-	explain(hasSlungWork, "Autonomous mode: hooked/in-progress work detected")
+	hasSlungWork := checkSlungWork(a, ctx)
 
-	outputMoleculeContext(ctx)
-	outputCheckpointContext(ctx)
-	runPrimeExternalTools(cwd)
+	a.add(primeSectionMolecule, "bd mol current", func() { outputMoleculeContext(ctx) })
+	a.add(primeSectionCheckpoint, "", func() { outputCheckpointContext(ctx) })
+	runPrimeExternalTools(a, cwd)
 
 	if ctx.Role == RoleMayor {
-		checkPendingEscalations(ctx)
+		a.addList(primeSectionEscalations, "bd list --tag=escalation", func() { checkPendingEscalations(ctx) })
 	}
 
 	if !hasSlungWork {
-		explain(true, "Startup directive: normal mode (no hooked work)")
-		outputStartupDirective(ctx)
+		a.add(primeSectionStartup, "", func() {
+			explain(true, "Startup directive: normal mode (no hooked work)")
+			outputStartupDirective(ctx)
+		})
 	}
 
+	a.flush()
 	return nil
 }
 
@@ -181,34 +200,38 @@ func runPrime(cmd *cobra.Command, args []string) (retErr error) {
 // The agent already has full role context in compressed memory. This just
 // restores identity, checks hook/work status, and injects any new mail.
 func runPrimeCompactResume(ctx RoleContext, cwd string) {
-	// Brief identity confirmation
-	actor := getAgentIdentity(ctx)
-	fmt.Printf("\n> **Recovery**: Context %s complete. You are **%s** (%s).\n",
-		primeHookSource, actor, ctx.Role)
-
-	// Session metadata for seance
-	outputSessionMetadata(ctx)
+	a := newPrimeAssembler(ctx.TownRoot, ctx.Role, primeBudget)
+
+	// Brief identity confirmation and session metadata for seance
+	a.add(primeSectionSession, "", func() {
+		actor := getAgentIdentity(ctx)
+		fmt.Printf("\n> **Recovery**: Context %s complete. You are **%s** (%s).\n",
+			primeHookSource, actor, ctx.Role)
+		outputSessionMetadata(ctx)
+	})
 
 	// Check for hooked work — critical for resuming after compaction
-	hasSlungWork := checkSlungWork(ctx)
+	hasSlungWork := checkSlungWork(a, ctx)
 
 	// Molecule progress if available
-	outputMoleculeContext(ctx)
+	a.add(primeSectionMolecule, "bd mol current", func() { outputMoleculeContext(ctx) })
 
 	// Inject any mail that arrived during compaction
 	if !primeDryRun {
-		runMailCheckInject(cwd)
+		a.addList(primeSectionMail, "gt mail inbox", func() { runMailCheckInject(cwd) })
 	}
 
 	// Startup directive if no hooked work
 	if !hasSlungWork {
-		outputStartupDirective(ctx)
+		a.add(primeSectionStartup, "", func() { outputStartupDirective(ctx) })
 	}
+
+	a.flush()
 }
 
 // validatePrimeFlags checks that CLI flag combinations are valid.
 func validatePrimeFlags() error {
-	if primeState && (primeHookMode || primeDryRun || primeExplain) {
+	if primeState && (primeHookMode || primeDryRun || primeExplain || primeBudget != 0) {
 		return fmt.Errorf("--state cannot be combined with other flags (except --json)")
 	}
 	if primeStateJSON && !primeState {
@@ -306,34 +329,43 @@ func setupPrimeSession(ctx RoleContext, roleInfo RoleInfo) error {
 	return nil
 }
 
-// outputRoleContext emits session metadata and all role/context output sections.
+// outputRoleContext adds session metadata and all role/context output sections.
 // Returns the rendered formula content for OTEL telemetry (empty if using fallback path).
-func outputRoleContext(ctx RoleContext) (string, error) {
-	explain(true, "Session metadata: always included for seance discovery")
-	outputSessionMetadata(ctx)
+func outputRoleContext(a *primeAssembler, ctx RoleContext) (string, error) {
+	a.add(primeSectionSession, "", func() {
+		explain(true, "Session metadata: always included for seance discovery")
+		outputSessionMetadata(ctx)
+	})
 
-	explain(true, fmt.Sprintf("Role context: detected role is %s", ctx.Role))
-	formula, err := outputPrimeContext(ctx)
+	var formula string
+	var err error
+	a.add(primeSectionRole, "", func() {
+		explain(true, fmt.Sprintf("Role context: detected role is %s", ctx.Role))
+		formula, err = outputPrimeContext(ctx)
+	})
 	if err != nil {
 		return "", err
 	}
 
-	outputContextFile(ctx)
-	outputHandoffContent(ctx)
-	outputAttachmentStatus(ctx)
+	a.add(primeSectionContextFile, "cat "+filepath.Join(ctx.TownRoot, "CONTEXT.md"), func() { outputContextFile(ctx) })
+	a.add(primeSectionHandoff, "", func() { outputHandoffContent(ctx) })
+	a.add(primeSectionAttachment, "bd mol current", func() { outputAttachmentStatus(ctx) })
 	return formula, nil
 }
 
 // runPrimeExternalTools runs bd prime and gt mail check --inject.
 // Skipped in dry-run mode with explain output.
-func runPrimeExternalTools(cwd string) {
+func runPrimeExternalTools(a *primeAssembler, cwd string) {
 	if primeDryRun {
-		explain(true, "bd prime: skipped in dry-run mode")
-		explain(true, "gt mail check --inject: skipped in dry-run mode")
+		a.add(primeSectionBdPrime, "", func() {
+			explain(true, "bd prime: skipped in dry-run mode")
+			explain(true, "gt mail check --inject: skipped in dry-run mode")
+		})
 		return
 	}
-	runBdPrime(cwd)
-	runMailCheckInject(cwd)
+	a.add(primeSectionBdPrime, "bd prime", func() { runBdPrime(cwd) })
+	// Injected mail is acked on delivery, so a trimmed list points at the inbox.
+	a.addList(primeSectionMail, "gt mail inbox", func() { runMailCheckInject(cwd) })
 }
 
 // runBdPrime runs `bd prime` and outputs the result.
@@ -391,7 +423,7 @@ func runMailCheckInject(workDir string) {
 // checkSlungWork checks for hooked work on the agent's hook.
 // If found, displays AUTONOMOUS WORK MODE and tells the agent to execute immediately.
 // Returns true if hooked work was found (caller should skip normal startup directive).
-func checkSlungWork(ctx RoleContext) bool {
+func checkSlungWork(a *primeAssembler, ctx RoleContext) bool {
 	hookedBead := findAgentWork(ctx)
 	if hookedBead == nil {
 		return false
@@ -400,13 +432,16 @@ func checkSlungWork(ctx RoleContext) bool {
 	attachment := beads.ParseAttachmentFields(hookedBead)
 	hasMolecule := attachment != nil && attachment.AttachedMolecule != ""
 
-	outputAutonomousDirective(ctx, hookedBead, hasMolecule)
-	outputHookedBeadDetails(hookedBead)
+	a.add(primeSectionDirective, "", func() {
+		explain(true, "Autonomous mode: hooked/in-progress work detected")
+		outputAutonomousDirective(ctx, hookedBead, hasMolecule)
+	})
+	a.add(primeSectionHookedBead, "bd show "+hookedBead.ID, func() { outputHookedBeadDetails(hookedBead) })
 
 	if hasMolecule {
-		outputMoleculeWorkflow(ctx, attachment)
+		a.add(primeSectionWorkflow, "bd mol current", func() { outputMoleculeWorkflow(ctx, attachment) })
 	} else {
-		outputBeadPreview(hookedBead)
+		a.add(primeSectionBeadPreview, "bd show "+hookedBead.ID, func() { outputBeadPreview(hookedBead) })
 	}
 
 	return true
@@ -516,12 +551,25 @@ func outputHookedBeadDetails(hookedBead *beads.Issue) {
 		}
 		fmt.Println("  Description:")
 		for _, line := range lines {
-			fmt.Printf("    %s\n", line)
+			fmt.Printf("    %s\n", truncateDescriptionLine(line, maxDescriptionLineRunes))
 		}
 	}
 	fmt.Println()
 }
 
+// maxDescriptionLineRunes caps each hooked bead description line so a single
+// pasted log line cannot dominate the primer.
+const maxDescriptionLineRunes = 200
+
+// truncateDescriptionLine shortens line to at most max runes.
+func truncateDescriptionLine(line string, max int) string {
+	runes := []rune(line)
+	if len(runes) <= max {
+		return line
+	}
+	return string(runes[:max-1]) + "…"
+}
+
 // outputMoleculeWorkflow displays attached molecule context with current step.
 func outputMoleculeWorkflow(ctx RoleContext, attachment *beads.AttachmentFields) {
 	fmt.Printf("%s\n\n", style.Bold.Render("## 🧬 ATTACHED MOLECULE (FORMULA WORKFLOW)"))
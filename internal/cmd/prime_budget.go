@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Prime section names. These are the keys accepted by the prime.priorities
+// setting and shown by gt prime --explain.
+const (
+	primeSectionSession     = "session"
+	primeSectionRole        = "role"
+	primeSectionContextFile = "context-file"
+	primeSectionHandoff     = "handoff"
+	primeSectionAttachment  = "attachment"
+	primeSectionDirective   = "directive"
+	primeSectionHookedBead  = "hooked-bead"
+	primeSectionWorkflow    = "workflow"
+	primeSectionBeadPreview = "bead-preview"
+	primeSectionMolecule    = "molecule"
+	primeSectionCheckpoint  = "checkpoint"
+	primeSectionBdPrime     = "bd-prime"
+	primeSectionMail        = "mail"
+	primeSectionEscalations = "escalations"
+	primeSectionStartup     = "startup"
+)
+
+// primeRequiredSections are never summarized or dropped: without them the
+// agent does not know who it is or what to do next. Mail is required because
+// gt mail check --inject marks what it injects as read, so trimmed mail would
+// never be seen.
+var primeRequiredSections = map[string]bool{
+	primeSectionSession:   true,
+	primeSectionRole:      true,
+	primeSectionDirective: true,
+	primeSectionMail:      true,
+	primeSectionStartup:   true,
+}
+
+// primeDefaultPriorities ranks optional sections (higher is kept longer).
+var primeDefaultPriorities = map[string]int{
+	primeSectionHookedBead:  90,
+	primeSectionWorkflow:    90,
+	primeSectionHandoff:     80,
+	primeSectionAttachment:  75,
+	primeSectionMolecule:    75,
+	primeSectionEscalations: 65,
+	primeSectionCheckpoint:  60,
+	primeSectionBeadPreview: 55,
+	primeSectionContextFile: 50,
+	primeSectionBdPrime:     40,
+}
+
+// primeSummaryFloor is the smallest a summarized section is shrunk to; below
+// this a section is dropped instead.
+const primeSummaryFloor = 120
+
+// Section outcomes reported by --explain.
+const (
+	primeIncluded   = "included"
+	primeSummarized = "summarized"
+	primeDropped    = "dropped"
+)
+
+// primeSection is one captured block of gt prime output.
+type primeSection struct {
+	name     string
+	hint     string // Command that recovers trimmed content
+	list     bool   // Summarize by keeping headers and the first list items
+	priority int
+	required bool
+	text     string
+	tokens   int    // Tokens of the original text
+	status   string // primeIncluded, primeSummarized or primeDropped
+	reason   string
+}
+
+// primeAssembler captures gt prime's sections and emits them under a token
+// budget once every section has been rendered.
+type primeAssembler struct {
+	budget     int // <= 0 disables the budget
+	priorities map[string]int
+	sections   []*primeSection
+	notes      []string // --explain lines, kept out of the sections
+}
+
+// capturingPrime is the assembler whose section is being captured, so
+// explain can route its notes there instead of into the budgeted output.
+var capturingPrime *primeAssembler
+
+// newPrimeAssembler returns an assembler using the town's prime settings for
+// role. A non-zero override (from --budget) wins over settings; a negative
+// budget disables trimming.
+func newPrimeAssembler(townRoot string, role Role, override int) *primeAssembler {
+	var cfg *config.PrimeConfig
+	if settings, err := config.LoadOrCreateTownSettings(config.TownSettingsPath(townRoot)); err == nil {
+		cfg = settings.Prime
+	}
+	a := &primeAssembler{budget: cfg.BudgetFor(string(role)), priorities: make(map[string]int)}
+	for name, p := range primeDefaultPriorities {
+		a.priorities[name] = p
+	}
+	if cfg != nil {
+		for name, p := range cfg.Priorities {
+			a.priorities[name] = p
+		}
+	}
+	if override != 0 {
+		a.budget = override
+	}
+	return a
+}
+
+// add runs fn and records whatever it prints as a section.
+func (a *primeAssembler) add(name, hint string, fn func()) {
+	a.addText(name, hint, a.capture(fn))
+}
+
+// addList is add for sections made of list items, such as injected mail,
+// which summarize by keeping headers and the first items.
+func (a *primeAssembler) addList(name, hint string, fn func()) {
+	a.addText(name, hint, a.capture(fn))
+	if n := len(a.sections); n > 0 && a.sections[n-1].name == name {
+		a.sections[n-1].list = true
+	}
+}
+
+// capture runs fn with explain notes diverted to a.notes, so --explain does
+// not change what fits the budget.
+func (a *primeAssembler) capture(fn func()) string {
+	prev := capturingPrime
+	capturingPrime = a
+	defer func() { capturingPrime = prev }()
+	return captureSectionOutput(fn)
+}
+
+func (a *primeAssembler) addText(name, hint, text string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	a.sections = append(a.sections, &primeSection{
+		name:     name,
+		hint:     hint,
+		priority: a.priorities[name],
+		required: primeRequiredSections[name],
+		text:     text,
+		tokens:   estimatePrimeTokens(text),
+		status:   primeIncluded,
+	})
+}
+
+// total returns the tokens currently kept.
+func (a *primeAssembler) total() int {
+	n := 0
+	for _, s := range a.sections {
+		if s.status != primeDropped {
+			n += estimatePrimeTokens(s.text)
+		}
+	}
+	return n
+}
+
+// fit shrinks the output to the budget: optional sections are summarized in
+// ascending priority order, then dropped in the same order. Ties go to the
+// section that appears later, so earlier context survives.
+func (a *primeAssembler) fit() {
+	if a.budget <= 0 {
+		return
+	}
+	var optional []*primeSection
+	for _, s := range a.sections {
+		if !s.required {
+			optional = append(optional, s)
+		}
+	}
+	order := make(map[*primeSection]int, len(a.sections))
+	for i, s := range a.sections {
+		order[s] = i
+	}
+	sort.SliceStable(optional, func(i, j int) bool {
+		if optional[i].priority != optional[j].priority {
+			return optional[i].priority < optional[j].priority
+		}
+		return order[optional[i]] > order[optional[j]]
+	})
+
+	for _, s := range optional {
+		over := a.total() - a.budget
+		if over <= 0 {
+			return
+		}
+		current := estimatePrimeTokens(s.text)
+		target := current - over
+		if target < primeSummaryFloor || current <= primeSummaryFloor {
+			continue
+		}
+		summary := summarizePrimeSection(s, target)
+		if estimatePrimeTokens(summary) < current {
+			s.text = summary
+			s.status = primeSummarized
+			s.reason = fmt.Sprintf("over budget by %d tokens", over)
+		}
+	}
+	for _, s := range optional {
+		over := a.total() - a.budget
+		if over <= 0 {
+			return
+		}
+		s.status = primeDropped
+		s.reason = fmt.Sprintf("over budget by %d tokens at priority %d", over, s.priority)
+	}
+}
+
+// write emits the kept sections in their original order.
+func (a *primeAssembler) write(w io.Writer) {
+	for _, s := range a.sections {
+		if s.status == primeDropped {
+			continue
+		}
+		fmt.Fprint(w, s.text)
+	}
+	if dropped := a.dropped(); len(dropped) > 0 {
+		fmt.Fprintf(w, "\n> Omitted to fit the context budget: %s\n", strings.Join(dropped, ", "))
+	}
+}
+
+// dropped returns "name (hint)" for each dropped section with a hint.
+func (a *primeAssembler) dropped() []string {
+	var out []string
+	for _, s := range a.sections {
+		if s.status != primeDropped {
+			continue
+		}
+		if s.hint != "" {
+			out = append(out, fmt.Sprintf("%s (run `%s`)", s.name, s.hint))
+		} else {
+			out = append(out, s.name)
+		}
+	}
+	return out
+}
+
+// explainReport writes the --explain table of section decisions.
+func (a *primeAssembler) explainReport(w io.Writer) {
+	budget := "unlimited"
+	if a.budget > 0 {
+		budget = fmt.Sprintf("%d tokens", a.budget)
+	}
+	for _, note := range a.notes {
+		fmt.Fprintf(w, "\n[EXPLAIN] %s\n", note)
+	}
+	fmt.Fprintf(w, "\n[EXPLAIN] Context budget: %s, used ~%d tokens\n", budget, a.total())
+	for _, s := range a.sections {
+		priority := fmt.Sprintf("p%d", s.priority)
+		if s.required {
+			priority = "required"
+		}
+		line := fmt.Sprintf("[EXPLAIN]   %-13s %-10s %-8s ~%d tokens", s.name, s.status, priority, s.tokens)
+		if s.status == primeSummarized {
+			line += fmt.Sprintf(" → ~%d", estimatePrimeTokens(s.text))
+		}
+		if s.reason != "" {
+			line += " (" + s.reason + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// flush fits, writes and (with --explain) reports, then forgets the sections.
+func (a *primeAssembler) flush() {
+	a.fit()
+	a.write(os.Stdout)
+	if primeExplain {
+		a.explainReport(os.Stdout)
+	}
+	a.sections = nil
+	a.notes = nil
+}
+
+// estimatePrimeTokens approximates the token count of text at four
+// characters per token.
+func estimatePrimeTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// summarizePrimeSection shrinks a section to roughly maxTokens, ending with a
+// marker that tells the agent how to get the rest. Plain sections keep their
+// leading lines; list sections keep every header line and the first items.
+func summarizePrimeSection(s *primeSection, maxTokens int) string {
+	lines := strings.Split(strings.TrimRight(s.text, "\n"), "\n")
+	isItem := func(line string) bool { return strings.HasPrefix(strings.TrimSpace(line), "- ") }
+
+	allowance := maxTokens*4 - 100 // room for the marker
+	if s.list {
+		for _, line := range lines {
+			if !isItem(line) {
+				allowance -= len(line) + 1
+			}
+		}
+	}
+
+	var kept []string
+	used, omitted := 0, 0
+	for i, line := range lines {
+		if s.list && !isItem(line) {
+			kept = append(kept, line)
+			continue
+		}
+		if used+len(line)+1 > allowance {
+			if !s.list {
+				omitted = len(lines) - i
+				break
+			}
+			omitted++
+			continue
+		}
+		kept = append(kept, line)
+		used += len(line) + 1
+	}
+	if omitted == 0 {
+		return s.text
+	}
+
+	marker := fmt.Sprintf("… %d more line(s) trimmed to fit the prime context budget", omitted)
+	if s.hint != "" {
+		marker += fmt.Sprintf("; run `%s` for the rest", s.hint)
+	}
+	return strings.Join(kept, "\n") + "\n" + marker + "\n"
+}
+
+// captureSectionOutput runs fn with stdout redirected and returns what it
+// printed. If the pipe cannot be created, fn prints directly and the section
+// is left out of the budget.
+func captureSectionOutput(fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		fn()
+		return ""
+	}
+
+	done := make(chan string, 1)
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		_ = r.Close()
+		done <- buf.String()
+	}()
+
+	orig := os.Stdout
+	func() {
+		os.Stdout = w
+		defer func() {
+			os.Stdout = orig
+			_ = w.Close()
+		}()
+		fn()
+	}()
+	return <-done
+}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestPrimeAssembler returns an assembler with default priorities.
+func newTestPrimeAssembler(budget int) *primeAssembler {
+	a := &primeAssembler{budget: budget, priorities: make(map[string]int)}
+	for name, p := range primeDefaultPriorities {
+		a.priorities[name] = p
+	}
+	return a
+}
+
+func primeLines(prefix string, n, width int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		line := fmt.Sprintf("%s %d ", prefix, i)
+		b.WriteString(line + strings.Repeat("x", width-len(line)) + "\n")
+	}
+	return b.String()
+}
+
+func TestPrimeAssembler_UnderBudget(t *testing.T) {
+	a := newTestPrimeAssembler(1000)
+	a.add(primeSectionRole, "", func() { fmt.Print("role\n") })
+	a.add(primeSectionBdPrime, "bd prime", func() { fmt.Print("beads\n") })
+	a.add(primeSectionMail, "gt mail inbox", func() {}) // empty sections are skipped
+
+	a.fit()
+	var out bytes.Buffer
+	a.write(&out)
+	if out.String() != "role\nbeads\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	if len(a.sections) != 2 {
+		t.Errorf("sections = %d, want 2", len(a.sections))
+	}
+}
+
+func TestPrimeAssembler_SummarizesThenDrops(t *testing.T) {
+	a := newTestPrimeAssembler(1500)
+	a.addText(primeSectionRole, "", primeLines("role", 40, 100))                    // ~1000 tokens, required
+	a.addText(primeSectionHandoff, "", primeLines("handoff", 10, 100))              // ~250, p80
+	a.addText(primeSectionBdPrime, "bd prime", primeLines("bd", 20, 100))           // ~500, p40
+	a.addText(primeSectionContextFile, "cat CONTEXT.md", primeLines("ctx", 4, 100)) // ~100, p50
+
+	a.fit()
+	if a.total() > a.budget {
+		t.Fatalf("total %d over budget %d", a.total(), a.budget)
+	}
+
+	status := make(map[string]*primeSection)
+	for _, s := range a.sections {
+		status[s.name] = s
+	}
+	if s := status[primeSectionRole]; s.status != primeIncluded {
+		t.Errorf("role = %s, required sections must be kept whole", s.status)
+	}
+	if s := status[primeSectionBdPrime]; s.status != primeSummarized || !strings.Contains(s.text, "run `bd prime` for the rest") {
+		t.Errorf("bd-prime = %s %q, want summarized with hint", s.status, s.text)
+	}
+	if s := status[primeSectionHandoff]; s.status != primeIncluded {
+		t.Errorf("handoff = %s, higher priority should survive", s.status)
+	}
+
+	// A tighter budget drops the low-priority sections outright.
+	b := newTestPrimeAssembler(1100)
+	for _, s := range a.sections {
+		b.addText(s.name, s.hint, primeLines(s.name, s.tokens/25, 100))
+	}
+	b.fit()
+	var out bytes.Buffer
+	b.write(&out)
+	if !strings.Contains(out.String(), "bd-prime (run `bd prime`)") {
+		t.Errorf("output does not name the dropped section:\n%s", out.String())
+	}
+}
+
+func TestPrimeAssembler_PriorityOverride(t *testing.T) {
+	a := newTestPrimeAssembler(300)
+	a.priorities[primeSectionBdPrime] = 99
+	a.addText(primeSectionEscalations, "bd list --tag=escalation", primeLines("- esc", 8, 100))
+	a.addText(primeSectionBdPrime, "bd prime", primeLines("bd", 8, 100))
+	a.fit()
+
+	for _, s := range a.sections {
+		if s.name == primeSectionBdPrime && s.status != primeIncluded {
+			t.Errorf("bd-prime = %s, raised priority should keep it", s.status)
+		}
+		if s.name == primeSectionEscalations && s.status == primeIncluded {
+			t.Error("escalations should be trimmed before bd-prime")
+		}
+	}
+}
+
+func TestPrimeAssembler_KeepsMail(t *testing.T) {
+	// Injected mail is already marked read, so it must survive any budget.
+	a := newTestPrimeAssembler(100)
+	a.addText(primeSectionMail, "gt mail inbox", primeLines("- mail", 20, 100))
+	a.addText(primeSectionBdPrime, "bd prime", primeLines("bd", 8, 100))
+	a.fit()
+	if s := a.sections[0]; s.status != primeIncluded {
+		t.Errorf("mail = %s, want included in full", s.status)
+	}
+}
+
+func TestPrimeAssembler_ExplainOutsideBudget(t *testing.T) {
+	old := primeExplain
+	primeExplain = true
+	defer func() { primeExplain = old }()
+
+	a := newTestPrimeAssembler(1000)
+	a.add(primeSectionStartup, "", func() {
+		explain(true, "Startup directive: normal mode")
+		fmt.Print("start\n")
+	})
+	if got := a.sections[0].text; got != "start\n" {
+		t.Errorf("section text = %q, want explain notes kept out", got)
+	}
+	var out bytes.Buffer
+	a.explainReport(&out)
+	if !strings.Contains(out.String(), "[EXPLAIN] Startup directive: normal mode") {
+		t.Errorf("explain report lost the note:\n%s", out.String())
+	}
+}
+
+func TestPrimeAssembler_NoBudget(t *testing.T) {
+	a := newTestPrimeAssembler(-1)
+	a.addText(primeSectionBdPrime, "bd prime", primeLines("bd", 400, 100))
+	a.fit()
+	if a.sections[0].status != primeIncluded {
+		t.Error("negative budget should disable trimming")
+	}
+}
+
+func TestSummarizePrimeSection_List(t *testing.T) {
+	text := "<system-reminder>\nYou have 30 unread message(s):\n\n" + primeLines("- hq-msg from mayor:", 30, 80) + "Run gt mail inbox.\n</system-reminder>\n"
+	s := &primeSection{name: primeSectionMail, hint: "gt mail inbox", list: true, text: text}
+
+	got := summarizePrimeSection(s, 300)
+	if estimatePrimeTokens(got) > 300 {
+		t.Errorf("summary is %d tokens, want <= 300", estimatePrimeTokens(got))
+	}
+	for _, keep := range []string{"<system-reminder>", "You have 30 unread", "</system-reminder>", "- hq-msg from mayor: 0 "} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("summary lost %q:\n%s", keep, got)
+		}
+	}
+	if !strings.Contains(got, "more line(s) trimmed") || strings.Contains(got, "mayor: 29 ") {
+		t.Errorf("summary did not trim trailing items:\n%s", got)
+	}
+}
+
+func TestNewPrimeAssembler_Settings(t *testing.T) {
+	townRoot := t.TempDir()
+	settings := `{"type":"town-settings","version":1,"prime":{"budget":5000,"role_budgets":{"mayor":12000},"priorities":{"escalations":95}}}`
+	if err := os.MkdirAll(filepath.Join(townRoot, "settings"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(townRoot, "settings", "config.json"), []byte(settings), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if a := newPrimeAssembler(townRoot, RoleMayor, 0); a.budget != 12000 {
+		t.Errorf("mayor budget = %d, want 12000", a.budget)
+	}
+	a := newPrimeAssembler(townRoot, RolePolecat, 0)
+	if a.budget != 5000 || a.priorities[primeSectionEscalations] != 95 || a.priorities[primeSectionBdPrime] != 40 {
+		t.Errorf("polecat assembler = budget %d priorities %v", a.budget, a.priorities)
+	}
+	if a := newPrimeAssembler(townRoot, RolePolecat, 2000); a.budget != 2000 {
+		t.Errorf("--budget override = %d, want 2000", a.budget)
+	}
+}
+
+func TestTruncateDescriptionLine(t *testing.T) {
+	if got := truncateDescriptionLine("short", 10); got != "short" {
+		t.Errorf("got %q", got)
+	}
+	if got := truncateDescriptionLine("ääääääääääää", 5); got != "ääää…" {
+		t.Errorf("got %q", got)
+	}
+}
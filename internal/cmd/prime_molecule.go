@@ -358,5 +358,6 @@ func buildRefineryPatrolVars(ctx RoleContext) []string {
 		vars = append(vars, fmt.Sprintf("build_command=%s", mq.BuildCommand))
 	}
 	vars = append(vars, fmt.Sprintf("delete_merged_branches=%t", mq.IsDeleteMergedBranchesEnabled()))
+	vars = append(vars, fmt.Sprintf("post_merge_verify=%t", mq.IsPostMergeVerifyEnabled()))
 	return vars
 }
@@ -675,8 +675,15 @@ func outputDeaconPausedMessage(state *deacon.PauseState) {
 }
 
 // explain outputs an explanatory message if --explain mode is enabled.
+// Inside a prime section the message is held for the --explain report so it
+// is not counted against the context budget.
 func explain(condition bool, reason string) {
-	if primeExplain && condition {
-		fmt.Printf("\n[EXPLAIN] %s\n", reason)
+	if !primeExplain || !condition {
+		return
+	}
+	if capturingPrime != nil {
+		capturingPrime.notes = append(capturingPrime.notes, reason)
+		return
 	}
+	fmt.Printf("\n[EXPLAIN] %s\n", reason)
 }
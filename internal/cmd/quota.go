@@ -64,11 +64,16 @@ Examples:
 type QuotaStatusItem struct {
 	Handle    string `json:"handle"`
 	Email     string `json:"email"`
+	Provider  string `json:"provider"`
 	Status    string `json:"status"`
 	LimitedAt string `json:"limited_at,omitempty"`
 	ResetsAt  string `json:"resets_at,omitempty"`
+	ResetTime string `json:"reset_time,omitempty"`
 	LastUsed  string `json:"last_used,omitempty"`
 	IsDefault bool   `json:"is_default"`
+
+	WindowTokens int64   `json:"window_tokens"`
+	Headroom     float64 `json:"headroom"` // fraction of estimated window budget left, -1 if unknown
 }
 
 func runQuotaStatus(cmd *cobra.Command, args []string) error {
@@ -102,29 +107,47 @@ func runQuotaStatus(cmd *cobra.Command, args []string) error {
 	// Ensure all accounts are tracked
 	mgr.EnsureAccountsTracked(state, acctCfg.Accounts)
 
+	now := time.Now()
+	handles := slices.Sorted(maps.Keys(acctCfg.Accounts))
+	headroom := make(map[string]quota.AccountHeadroom, len(handles))
+	for _, h := range quota.RankAccounts(handles, state, quota.AccountUsages(acctCfg, state, now), now) {
+		headroom[h.Handle] = h
+	}
+
 	if quotaJSON {
-		return printQuotaStatusJSON(acctCfg, state)
+		return printQuotaStatusJSON(acctCfg, state, headroom)
+	}
+	return printQuotaStatusText(acctCfg, state, headroom)
+}
+
+// effectiveQuotaStatus returns the account's status, treating a limit whose
+// parsed reset time has passed as available.
+func effectiveQuotaStatus(qs config.AccountQuotaState) config.AccountQuotaStatus {
+	if qs.Status == "" || (qs.Status == config.QuotaStatusLimited && quota.IsAvailable(qs, time.Now())) {
+		return config.QuotaStatusAvailable
 	}
-	return printQuotaStatusText(acctCfg, state)
+	return qs.Status
 }
 
-func printQuotaStatusJSON(acctCfg *config.AccountsConfig, state *config.QuotaState) error {
+func printQuotaStatusJSON(acctCfg *config.AccountsConfig, state *config.QuotaState, headroom map[string]quota.AccountHeadroom) error {
 	var items []QuotaStatusItem
 	for _, handle := range slices.Sorted(maps.Keys(acctCfg.Accounts)) {
 		acct := acctCfg.Accounts[handle]
 		qs := state.Accounts[handle]
-		status := string(qs.Status)
-		if status == "" {
-			status = string(config.QuotaStatusAvailable)
-		}
+		status := string(effectiveQuotaStatus(qs))
 		items = append(items, QuotaStatusItem{
 			Handle:    handle,
 			Email:     acct.Email,
+			Provider:  acct.ProviderName(),
 			Status:    status,
 			LimitedAt: qs.LimitedAt,
 			ResetsAt:  qs.ResetsAt,
+			ResetTime: qs.ResetTime,
 			LastUsed:  qs.LastUsed,
 			IsDefault: handle == acctCfg.Default,
+
+			WindowTokens: headroom[handle].WindowTokens,
+			Headroom:     headroom[handle].Headroom,
 		})
 	}
 	enc := json.NewEncoder(os.Stdout)
@@ -132,7 +155,7 @@ func printQuotaStatusJSON(acctCfg *config.AccountsConfig, state *config.QuotaSta
 	return enc.Encode(items)
 }
 
-func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaState) error {
+func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaState, headroom map[string]quota.AccountHeadroom) error {
 	available := 0
 	limited := 0
 
@@ -142,10 +165,7 @@ func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaSta
 	for _, handle := range slices.Sorted(maps.Keys(acctCfg.Accounts)) {
 		acct := acctCfg.Accounts[handle]
 		qs := state.Accounts[handle]
-		status := qs.Status
-		if status == "" {
-			status = config.QuotaStatusAvailable
-		}
+		status := effectiveQuotaStatus(qs)
 
 		// Handle marker and default indicator
 		marker := " "
@@ -162,7 +182,11 @@ func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaSta
 		case config.QuotaStatusLimited:
 			badge = style.Error.Render("limited")
 			limited++
-			if qs.ResetsAt != "" {
+			if qs.ResetTime != "" {
+				if t, err := time.Parse(time.RFC3339, qs.ResetTime); err == nil {
+					badge += style.Dim.Render(" (resets " + t.Local().Format("Jan 2 15:04") + ")")
+				}
+			} else if qs.ResetsAt != "" {
 				badge += style.Dim.Render(" (resets " + qs.ResetsAt + ")")
 			}
 		case config.QuotaStatusCooldown:
@@ -176,8 +200,20 @@ func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaSta
 		if acct.Email != "" {
 			email = style.Dim.Render(" <" + acct.Email + ">")
 		}
+		if acct.Provider != "" && acct.Provider != string(config.AgentClaude) {
+			email += style.Dim.Render(" [" + acct.Provider + "]")
+		}
 
-		fmt.Printf(" %s %-12s %s%s\n", marker, handle, badge, email)
+		usage := ""
+		if h := headroom[handle]; h.WindowTokens > 0 || h.Headroom >= 0 {
+			usage = fmt.Sprintf(" %s tokens/%.0fh", formatTokenCount(h.WindowTokens), quota.UsageWindow.Hours())
+			if h.Headroom >= 0 {
+				usage += fmt.Sprintf(", ~%.0f%% left", h.Headroom*100)
+			}
+			usage = style.Dim.Render(usage)
+		}
+
+		fmt.Printf(" %s %-12s %s%s%s\n", marker, handle, badge, email, usage)
 	}
 
 	fmt.Println()
@@ -187,6 +223,18 @@ func printQuotaStatusText(acctCfg *config.AccountsConfig, state *config.QuotaSta
 	return nil
 }
 
+// formatTokenCount renders a token count compactly (e.g. 850, 12.3k, 4.1M).
+func formatTokenCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
 // Scan command flags
 var (
 	scanUpdate bool
@@ -254,15 +302,13 @@ func updateQuotaState(townRoot string, results []quota.ScanResult, acctCfg *conf
 		}
 		mgr.EnsureAccountsTracked(state, acctCfg.Accounts)
 
-		now := time.Now().UTC().Format(time.RFC3339)
+		now := time.Now()
 		for _, r := range results {
 			if r.RateLimited && r.AccountHandle != "" {
 				existing := state.Accounts[r.AccountHandle]
-				state.Accounts[r.AccountHandle] = config.AccountQuotaState{
-					Status:    config.QuotaStatusLimited,
-					LimitedAt: now,
-					ResetsAt:  r.ResetsAt,
-					LastUsed:  existing.LastUsed,
+				state.Accounts[r.AccountHandle] = quota.LimitedState(existing, r.ResetsAt, now)
+				if existing.Status != config.QuotaStatusLimited {
+					quota.RecordLimitUsage(state, r.AccountHandle, acctCfg.Accounts[r.AccountHandle], now)
 				}
 			}
 		}
@@ -287,6 +333,9 @@ func printScanText(results []quota.ScanResult) error {
 			if account == "" {
 				account = "(unknown)"
 			}
+			if r.Provider != "" && r.Provider != string(config.AgentClaude) {
+				account += style.Dim.Render(" (" + r.Provider + ")")
+			}
 			resets := ""
 			if r.ResetsAt != "" {
 				resets = style.Dim.Render(" resets " + r.ResetsAt)
@@ -326,10 +375,14 @@ var quotaRotateCmd = &cobra.Command{
 Scans all sessions for rate limits, plans account assignments using
 least-recently-used ordering, and restarts blocked sessions with fresh accounts.
 
+Each session is matched against the rate-limit signatures of its agent
+(GT_AGENT) and only rotated to accounts registered for that agent's
+provider (see 'gt account add --provider').
+
 The rotation process:
   1. Scans all Gas Town sessions for rate-limit indicators
-  2. Selects available accounts (LRU order)
-  3. Updates tmux session environment with new CLAUDE_CONFIG_DIR
+  2. Selects available accounts of the session's provider (LRU order)
+  3. Updates the provider's config dir env var (CLAUDE_CONFIG_DIR for Claude)
   4. Restarts blocked sessions via respawn-pane
 
 Examples:
@@ -376,6 +429,7 @@ func runQuotaRotate(cmd *cobra.Command, args []string) error {
 	if len(plan.Assignments) == 0 {
 		fmt.Printf(" %s %d sessions rate-limited but no available accounts to rotate to\n",
 			style.WarningPrefix, len(plan.LimitedSessions))
+		printUnrotatable(plan.Unrotatable)
 		return nil
 	}
 
@@ -404,10 +458,9 @@ func runQuotaRotate(cmd *cobra.Command, args []string) error {
 				style.Success.Render(newAccount),
 			)
 		}
-		unassigned := len(plan.LimitedSessions) - len(plan.Assignments)
-		if unassigned > 0 {
-			fmt.Printf("\n %s %d sessions cannot be rotated (not enough available accounts)\n",
-				style.WarningPrefix, unassigned)
+		if len(plan.Unrotatable) > 0 {
+			fmt.Printf("\n %s %d sessions cannot be rotated:\n", style.WarningPrefix, len(plan.Unrotatable))
+			printUnrotatable(plan.Unrotatable)
 		}
 	}
 
@@ -454,6 +507,13 @@ func runQuotaRotate(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// printUnrotatable lists limited sessions that rotation cannot move, with why.
+func printUnrotatable(unrotatable map[string]string) {
+	for _, session := range slices.Sorted(maps.Keys(unrotatable)) {
+		fmt.Printf("   %-25s %s\n", session, style.Dim.Render(unrotatable[session]))
+	}
+}
+
 var quotaClearCmd = &cobra.Command{
 	Use:   "clear [handle...]",
 	Short: "Mark account(s) as available again",
@@ -22,6 +22,7 @@ import (
 
 var readyJSON bool
 var readyRig string
+var readyAsOf string
 
 var readyCmd = &cobra.Command{
 	Use:     "ready",
@@ -36,16 +37,22 @@ Aggregates ready issues from:
 Ready items have no blockers and can be worked immediately.
 Results are sorted by priority (highest first) then by source.
 
+--as-of reads every database as it was at a time (a duration like 2h,
+RFC 3339, or "2006-01-02 15:04") or at a commit of the town or --rig
+database.
+
 Examples:
   gt ready              # Show all ready work
   gt ready --json       # Output as JSON
-  gt ready --rig=gastown  # Show only one rig`,
+  gt ready --rig=gastown  # Show only one rig
+  gt ready --as-of 2h     # What was ready two hours ago`,
 	RunE: runReady,
 }
 
 func init() {
 	readyCmd.Flags().BoolVar(&readyJSON, "json", false, "Output as JSON")
 	readyCmd.Flags().StringVar(&readyRig, "rig", "", "Filter to a specific rig")
+	readyCmd.Flags().StringVar(&readyAsOf, "as-of", "", "Show ready work as of a time or Dolt commit")
 	rootCmd.AddCommand(readyCmd)
 }
 
@@ -61,6 +68,7 @@ type ReadyResult struct {
 	Sources  []ReadySource `json:"sources"`
 	Summary  ReadySummary  `json:"summary"`
 	TownRoot string        `json:"town_root,omitempty"`
+	AsOf     string        `json:"as_of,omitempty"`
 }
 
 // ReadySummary provides counts for the ready report.
@@ -111,6 +119,25 @@ func runReady(cmd *cobra.Command, args []string) error {
 		rigs = filtered
 	}
 
+	// A commit hash belongs to one database; read the others at its date.
+	asOf := readyAsOf
+	if asOf != "" {
+		dirs := []string{beads.GetTownBeadsPath(townRoot)}
+		if readyRig != "" {
+			dirs = []string{rigs[0].BeadsPath()}
+		}
+		if asOf, err = asOfTimeSpec(asOf, dirs...); err != nil {
+			return err
+		}
+	}
+	readyAt := func(b *beads.Beads) ([]*beads.Issue, error) {
+		if asOf == "" {
+			return b.Ready()
+		}
+		issues, _, err := b.ReadyAsOf(asOf)
+		return issues, err
+	}
+
 	// Collect results from all sources in parallel
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -123,7 +150,7 @@ func runReady(cmd *cobra.Command, args []string) error {
 			defer wg.Done()
 			townBeadsPath := beads.GetTownBeadsPath(townRoot)
 			townBeads := beads.New(townBeadsPath)
-			issues, err := townBeads.Ready()
+			issues, err := readyAt(townBeads)
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -152,7 +179,7 @@ func runReady(cmd *cobra.Command, args []string) error {
 			// Use rig root path where rig-level beads are stored
 			// BeadsPath returns rig root; redirect system handles mayor/rig routing
 			rigBeads := beads.New(r.BeadsPath())
-			issues, err := rigBeads.Ready()
+			issues, err := readyAt(rigBeads)
 
 			mu.Lock()
 			defer mu.Unlock()
@@ -221,6 +248,7 @@ func runReady(cmd *cobra.Command, args []string) error {
 		Sources:  sources,
 		Summary:  summary,
 		TownRoot: townRoot,
+		AsOf:     asOf,
 	}
 
 	// Check for source errors
@@ -254,12 +282,16 @@ func runReady(cmd *cobra.Command, args []string) error {
 }
 
 func printReadyHuman(result ReadyResult) error {
+	when := ""
+	if result.AsOf != "" {
+		when = " as of " + result.AsOf
+	}
 	if result.Summary.Total == 0 {
-		fmt.Println("No ready work across town.")
+		fmt.Printf("No ready work across town%s.\n", when)
 		return nil
 	}
 
-	fmt.Printf("%s Ready work across town:\n\n", style.Bold.Render("📋"))
+	fmt.Printf("%s Ready work across town%s:\n\n", style.Bold.Render("📋"), when)
 
 	for _, src := range result.Sources {
 		if src.Error != "" {
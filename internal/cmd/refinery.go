@@ -730,6 +730,10 @@ func runRefineryReady(cmd *cobra.Command, args []string) error {
 
 	// Human-readable output
 	fmt.Printf("%s Ready MRs for '%s':\n\n", style.Bold.Render("🚀"), rigName)
+	if pause, _ := refinery.LoadQueuePause(r.Path); pause != nil {
+		fmt.Printf("  %s %s\n\n", style.Error.Render("⛔ PAUSED:"),
+			fmt.Sprintf("%s broken at %s; only revert MRs are ready", pause.Target, shortCommit(pause.Commit)))
+	}
 
 	if len(ready) == 0 {
 		fmt.Printf("  %s\n", style.Dim.Render("(none ready)"))
@@ -2003,7 +2003,7 @@ func syncRigHooks(townRoot, rigName string) error {
 		if target.Rig != rigName {
 			continue
 		}
-		if _, err := syncTarget(target, false); err != nil {
+		if _, err := syncTarget(townRoot, target, false); err != nil {
 			fmt.Fprintf(os.Stderr, "  Warning: failed to sync hooks for %s: %v\n", target.DisplayKey(), err)
 			continue
 		}
@@ -0,0 +1,235 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/secrets"
+	"github.com/steveyegge/gastown/internal/style"
+	"golang.org/x/term"
+)
+
+var rigSecretsImportKeep bool
+
+var rigSecretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "Manage encrypted rig secrets",
+	Long: `Manage secrets handed to a rig's agent sessions.
+
+Secrets are stored encrypted in <rig>/settings/secrets.enc under a key file
+outside the town (~/.config/gastown/secrets.key, or $GT_SECRETS_KEY_FILE).
+They are injected into polecat and other rig sessions as environment
+variables only; nothing is written into worktrees.
+
+Secret values (6 characters or longer) are redacted as [REDACTED:NAME] from
+pane captures, mail subjects and bodies, the events log and telemetry.
+'gt doctor' flags secret values found in commits.
+
+Use 'gt rig secrets import' to move an overlay .env file into the store.`,
+	RunE: requireSubcommand,
+}
+
+var rigSecretsListCmd = &cobra.Command{
+	Use:   "list <rig>",
+	Short: "List secret names",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRigSecretsList,
+}
+
+var rigSecretsSetCmd = &cobra.Command{
+	Use:   "set <rig> <NAME>",
+	Short: "Set a secret (value read from stdin)",
+	Long: `Set a secret. The value is read from stdin so it never appears in
+shell history or the process list: typed without echo on a terminal, or
+piped.
+
+Examples:
+  gt rig secrets set gastown NPM_TOKEN
+  op read op://dev/npm/token | gt rig secrets set gastown NPM_TOKEN`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRigSecretsSet,
+}
+
+var rigSecretsUnsetCmd = &cobra.Command{
+	Use:   "unset <rig> <NAME>",
+	Short: "Remove a secret",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runRigSecretsUnset,
+}
+
+var rigSecretsImportCmd = &cobra.Command{
+	Use:   "import <rig> [file]",
+	Short: "Import KEY=VALUE pairs from a .env file",
+	Long: `Import secrets from a .env file into the encrypted store.
+
+The file defaults to the rig's overlay .env (<rig>/.runtime/overlay/.env),
+which would otherwise be copied into every new worktree. The file is
+removed after a successful import unless --keep is given.
+
+Examples:
+  gt rig secrets import gastown
+  gt rig secrets import gastown ~/secrets/gastown.env --keep`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRigSecretsImport,
+}
+
+func init() {
+	rigSecretsImportCmd.Flags().BoolVar(&rigSecretsImportKeep, "keep", false, "Keep the .env file after importing")
+
+	rigCmd.AddCommand(rigSecretsCmd)
+	rigSecretsCmd.AddCommand(rigSecretsListCmd)
+	rigSecretsCmd.AddCommand(rigSecretsSetCmd)
+	rigSecretsCmd.AddCommand(rigSecretsUnsetCmd)
+	rigSecretsCmd.AddCommand(rigSecretsImportCmd)
+}
+
+func runRigSecretsList(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+	names, err := secrets.Names(r.Path)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		fmt.Printf("No secrets for %s\n", r.Name)
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+// readSecretValue reads a secret from stdin, without echo on a terminal.
+func readSecretValue(name string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprintf(os.Stderr, "Value for %s: ", name)
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("reading value: %w", err)
+		}
+		return string(data), nil
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("reading value: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func runRigSecretsSet(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+	name := args[1]
+	if err := secrets.ValidateName(name); err != nil {
+		return err
+	}
+	store, err := secrets.Load(r.Path)
+	if err != nil {
+		return err
+	}
+	value, err := readSecretValue(name)
+	if err != nil {
+		return err
+	}
+	if err := store.Set(name, value); err != nil {
+		return err
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("saving secrets: %w", err)
+	}
+	fmt.Printf("%s Set %s for %s\n", style.Success.Render("✓"), name, r.Name)
+	if len(value) < secrets.MinRedactLength {
+		style.PrintWarning("values shorter than %d characters are not redacted", secrets.MinRedactLength)
+	}
+	fmt.Printf("  Running sessions pick it up when restarted.\n")
+	return nil
+}
+
+func runRigSecretsUnset(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+	store, err := secrets.Load(r.Path)
+	if err != nil {
+		return err
+	}
+	if !store.Delete(args[1]) {
+		return fmt.Errorf("no secret %s in %s", args[1], r.Name)
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("saving secrets: %w", err)
+	}
+	fmt.Printf("%s Removed %s from %s\n", style.Success.Render("✓"), args[1], r.Name)
+	return nil
+}
+
+func runRigSecretsImport(cmd *cobra.Command, args []string) error {
+	_, r, err := getRig(args[0])
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(r.Path, ".runtime", "overlay", ".env")
+	if len(args) > 1 {
+		path = args[1]
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: user-specified import file
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no .env file at %s", path)
+		}
+		return err
+	}
+	values, err := secrets.ParseEnvFile(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	store, err := secrets.Load(r.Path)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var imported []string
+	for _, name := range names {
+		if values[name] == "" {
+			continue
+		}
+		if err := store.Set(name, values[name]); err != nil {
+			return err
+		}
+		imported = append(imported, name)
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("saving secrets: %w", err)
+	}
+
+	fmt.Printf("%s Imported %d secret(s) into %s: %s\n", style.Success.Render("✓"),
+		len(imported), r.Name, strings.Join(imported, ", "))
+	if rigSecretsImportKeep {
+		return nil
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("removing %s: %w", path, err)
+	}
+	fmt.Printf("  Removed %s\n", path)
+	fmt.Printf("  Worktrees that already have a copy keep it until repaired.\n")
+	return nil
+}
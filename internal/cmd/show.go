@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/beads"
 )
 
 func init() {
@@ -23,12 +26,19 @@ Delegates to 'bd show' - all bd show flags are supported.
 Works with any bead prefix (gt-, bd-, hq-, etc.) and routes
 to the correct beads database automatically.
 
+--as-of shows the bead as it was at a Dolt commit or a time. Besides
+the commit hashes bd accepts, gt takes a duration meaning "that long ago"
+(90m, 6h), RFC 3339, or local "2006-01-02 15:04" and resolves it to the
+newest commit at or before that time.
+
 Examples:
   gt show gt-abc123          # Show a gastown issue
   gt show hq-xyz789          # Show a town-level bead (convoy, mail, etc.)
   gt show bd-def456          # Show a beads issue
   gt show gt-abc123 --json   # Output as JSON
-  gt show gt-abc123 -v       # Verbose output`,
+  gt show gt-abc123 -v       # Verbose output
+  gt show gt-abc123 --as-of 2h   # As it was two hours ago
+  gt show gt-abc123 --as-of "2026-10-17 09:00"`,
 	DisableFlagParsing: true, // Pass all flags through to bd show
 	RunE:               runShow,
 }
@@ -43,9 +53,57 @@ func runShow(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("bead ID required\n\nUsage: gt show <bead-id> [flags]")
 	}
 
+	args, err := resolveShowAsOf(args)
+	if err != nil {
+		return err
+	}
+
 	return execBdShow(args)
 }
 
+// resolveShowAsOf rewrites a time-valued --as-of in args to the commit hash
+// bd show expects, resolved in the database of the first bead ID. Commit
+// hashes and branch names pass through unchanged.
+func resolveShowAsOf(args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	var beadID string
+	asOfIdx := -1
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--as-of" && i+1 < len(args):
+			asOfIdx = len(out)
+			out = append(out, arg, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--as-of="):
+			asOfIdx = len(out)
+			out = append(out, "--as-of", strings.TrimPrefix(arg, "--as-of="))
+		default:
+			if beadID == "" && !strings.HasPrefix(arg, "-") {
+				beadID = arg
+			}
+			out = append(out, arg)
+		}
+	}
+	if asOfIdx < 0 || beads.IsCommitRef(out[asOfIdx+1]) {
+		return args, nil
+	}
+	if _, err := beads.ParseAsOfTime(out[asOfIdx+1], time.Now()); err != nil {
+		// Not a time: leave branch names and the like to bd.
+		return args, nil
+	}
+	if beadID == "" {
+		return nil, fmt.Errorf("bead ID required\n\nUsage: gt show <bead-id> --as-of <time|commit>")
+	}
+
+	rev, err := beads.New(resolveBeadDir(beadID)).ResolveAsOf(out[asOfIdx+1])
+	if err != nil {
+		return nil, err
+	}
+	out[asOfIdx+1] = rev.Commit
+	return out, nil
+}
+
 // execBdShow replaces the current process with 'bd show'.
 func execBdShow(args []string) error {
 	bdPath, err := exec.LookPath("bd")
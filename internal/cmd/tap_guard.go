@@ -20,6 +20,7 @@ forbidden operation entirely.
 
 Available guards:
   pr-workflow      - Block PR creation and feature branches
+  policy           - Evaluate the declarative town/rig guard policy
 
 Example hook configuration:
   {
@@ -49,7 +50,7 @@ Exit codes:
 
 The guard blocks in two scenarios:
   1. Running as a Gas Town agent (crew, polecat, witness, etc.)
-  2. Origin remote is steveyegge/gastown (maintainer should push directly)
+  2. Origin remote is the upstream Gas Town repo (maintainers push directly)
 
 Humans running outside Gas Town with a fork origin can still use PRs.`,
 	RunE: runTapGuardPRWorkflow,
@@ -79,13 +80,13 @@ func runTapGuardPRWorkflow(cmd *cobra.Command, args []string) error {
 		return NewSilentExit(2) // Exit 2 = BLOCK in Claude Code hooks
 	}
 
-	// Check if origin is the maintainer's repo (steveyegge/gastown)
+	// Check if origin is the upstream maintainer repo
 	if isMaintainerOrigin() {
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, "╔══════════════════════════════════════════════════════════════════╗")
 		fmt.Fprintln(os.Stderr, "║  ❌ PR BLOCKED - MAINTAINER ORIGIN                               ║")
 		fmt.Fprintln(os.Stderr, "╠══════════════════════════════════════════════════════════════════╣")
-		fmt.Fprintln(os.Stderr, "║  Your origin is steveyegge/gastown - push directly to main.     ║")
+		fmt.Fprintln(os.Stderr, "║  Your origin is the upstream repo - push directly to main.      ║")
 		fmt.Fprintln(os.Stderr, "║  PRs are for external contributors, not maintainers.            ║")
 		fmt.Fprintln(os.Stderr, "║                                                                  ║")
 		fmt.Fprintln(os.Stderr, "║  Instead of:  gh pr create                                      ║")
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/guard"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/workspace"
+)
+
+var tapGuardPolicyCheck bool
+
+var tapGuardPolicyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Evaluate the town and rig guard policy files",
+	Long: `Evaluate a PreToolUse tool call against the declarative guard policy.
+
+Reads the Claude Code PreToolUse payload from stdin and checks it against
+<rig>/settings/guard-policy.json, then settings/guard-policy.json at the
+town root. The first matching rule decides; calls matching no rule are
+allowed. Every decision is logged as a guard_decision audit event.
+
+Policy file format:
+  {
+    "version": 1,
+    "rules": [
+      {"name": "no-rm-root", "action": "deny", "commands": ["rm -rf /*"]},
+      {"name": "crew-may-push", "action": "allow", "roles": ["crew"],
+       "commands": ["git push --force*"]},
+      {"name": "force-push-budget", "action": "limit", "max": 3, "window": "24h",
+       "commands": ["git push --force*", "git push -f*"]},
+      {"name": "no-secrets", "action": "deny", "roles": ["polecat"],
+       "paths": ["*.env", "*/secrets/*"], "message": "Secrets are read-only."}
+    ]
+  }
+
+Actions are allow, deny and limit (max calls per window per agent, then
+deny). Commands apply to Bash and are matched against each segment of a
+chained command; paths apply to Edit, Write, MultiEdit and NotebookEdit
+unless "tools" says otherwise. Roles are mayor, deacon, witness, refinery,
+crew, polecat and human (sessions outside Gas Town).
+
+gt hooks sync installs a PreToolUse matcher calling this command for every
+deny and limit rule, so editing the policy only needs a sync.
+
+Exit codes:
+  0 - Operation allowed
+  2 - Operation BLOCKED
+
+Use --check to validate the policy files without reading stdin.`,
+	RunE: runTapGuardPolicy,
+}
+
+func init() {
+	tapGuardCmd.AddCommand(tapGuardPolicyCmd)
+	tapGuardPolicyCmd.Flags().BoolVar(&tapGuardPolicyCheck, "check", false, "Validate policy files and list the effective rules")
+}
+
+func runTapGuardPolicy(cmd *cobra.Command, args []string) error {
+	townRoot, err := workspace.FindFromCwd()
+	if err != nil || townRoot == "" {
+		if tapGuardPolicyCheck {
+			return fmt.Errorf("not in a Gas Town workspace")
+		}
+		return nil // No town, no policy
+	}
+
+	role, rig, actor := guard.RoleHuman, "", "human"
+	if cwd, err := os.Getwd(); err == nil {
+		if info, err := GetRoleWithContext(cwd, townRoot); err == nil && info.Role != RoleUnknown {
+			role, rig, actor = string(info.Role), info.Rig, info.ActorString()
+		}
+	}
+	if role == string(RoleBoot) {
+		role = string(RoleDeacon) // Boot runs under the deacon's hooks
+	}
+
+	policy, err := guard.Load(townRoot, rig)
+	if tapGuardPolicyCheck {
+		if err != nil {
+			return err
+		}
+		printGuardPolicy(policy, role)
+		return nil
+	}
+	if err != nil {
+		// A broken policy file must not wedge every agent in the town.
+		fmt.Fprintf(os.Stderr, "gt tap guard policy: %v (allowing)\n", err)
+		return nil
+	}
+
+	in, err := guard.ParseHookInput(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt tap guard policy: %v (allowing)\n", err)
+		return nil
+	}
+	in.Role, in.Actor = role, actor
+
+	decision, err := policy.Evaluate(in, guard.NewLimiter(townRoot), time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gt tap guard policy: rule %q: %v\n", decision.Rule, err)
+	}
+
+	subject := in.Command
+	if subject == "" {
+		subject = in.Path
+	}
+	_ = events.LogAudit(events.TypeGuardDecision, actor,
+		events.GuardDecisionPayload(in.Tool, subject, decision.Action, decision.Rule, decision.Scope))
+
+	if !decision.Blocked() {
+		return nil
+	}
+
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintf(os.Stderr, "❌ BLOCKED by guard policy rule %q (%s)\n", decision.Rule, decision.Scope)
+	if decision.Message != "" {
+		fmt.Fprintf(os.Stderr, "   %s\n", decision.Message)
+	}
+	fmt.Fprintln(os.Stderr, "")
+	return NewSilentExit(2) // Exit 2 = BLOCK in Claude Code hooks
+}
+
+// printGuardPolicy lists the effective rules and the hook matchers they need.
+func printGuardPolicy(policy *guard.Policy, role string) {
+	if len(policy.Rules) == 0 {
+		fmt.Printf("%s No guard policy rules\n", style.Dim.Render("○"))
+		return
+	}
+	fmt.Printf("%s Guard policy valid (%d rules, evaluated in order)\n", style.Success.Render("✓"), len(policy.Rules))
+	for _, r := range policy.Rules {
+		var match []string
+		match = append(match, r.Commands...)
+		match = append(match, r.Paths...)
+		line := fmt.Sprintf("  %-6s %-24s %s", r.Action, r.Name, strings.Join(match, ", "))
+		if len(r.Roles) > 0 {
+			line += style.Dim.Render(" roles=" + strings.Join(r.Roles, ","))
+		}
+		if r.Action == guard.ActionLimit {
+			window := r.Window
+			if window == "" {
+				window = guard.DefaultWindow.String()
+			}
+			line += style.Dim.Render(fmt.Sprintf(" max=%d/%s", r.Max, window))
+		}
+		fmt.Println(line + style.Dim.Render(" ["+r.Scope+"]"))
+	}
+	if matchers := policy.Matchers(role); len(matchers) > 0 {
+		fmt.Printf("\nHook matchers for %s: %s\n", role, strings.Join(matchers, " "))
+	}
+}
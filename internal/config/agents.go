@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/steveyegge/gastown/internal/constants"
 )
 
 // AgentPreset identifies a supported LLM agent runtime.
@@ -132,6 +134,16 @@ type AgentPresetInfo struct {
 	// EmitsPermissionWarning indicates the agent shows a bypass-permissions warning on startup
 	// that needs to be acknowledged via tmux.
 	EmitsPermissionWarning bool `json:"emits_permission_warning,omitempty"`
+
+	// RateLimitPatterns are regexes (matched case-insensitively against tmux
+	// pane lines) that indicate the agent's provider has rate-limited the session.
+	// Empty means the Claude Code patterns are used.
+	RateLimitPatterns []string `json:"rate_limit_patterns,omitempty"`
+
+	// ResetTimePattern is a regex whose first capture group extracts the
+	// limit's reset time from a matched line. The capture may be a clock time
+	// ("7pm (America/Los_Angeles)") or a duration ("2 hours 15 minutes").
+	ResetTimePattern string `json:"reset_time_pattern,omitempty"`
 }
 
 // NonInteractiveConfig contains settings for running agents non-interactively.
@@ -185,6 +197,8 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		ReadyDelayMs:           10000,
 		InstructionsFile:       "CLAUDE.md",
 		EmitsPermissionWarning: true,
+		RateLimitPatterns:      constants.DefaultRateLimitPatterns,
+		ResetTimePattern:       `\bresets\s+(.+)`,
 	},
 	AgentGemini: {
 		Name:                AgentGemini,
@@ -208,6 +222,13 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		HooksSettingsFile: "settings.json",
 		ReadyDelayMs:      5000,
 		InstructionsFile:  "AGENTS.md",
+		RateLimitPatterns: []string{
+			`RESOURCE_EXHAUSTED`,
+			`Quota exceeded for quota metric`,
+			`You have exhausted your (daily )?quota`,
+			`status(?: code)?:? 429`,
+		},
+		ResetTimePattern: `(?:retry|try again|reset) in\s+([\d.]+\s*[a-z]+(?:\s+[\d.]+\s*[a-z]+)*)`,
 	},
 	AgentCodex: {
 		Name:                AgentCodex,
@@ -227,6 +248,12 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		PromptMode:       "none",
 		ReadyDelayMs:     3000,
 		InstructionsFile: "AGENTS.md",
+		RateLimitPatterns: []string{
+			`You've hit your usage limit`,
+			`Rate limit reached for`,
+			`exceeded retry limit, last status: 429`,
+		},
+		ResetTimePattern: `try again (?:in|at)\s+(.+?)\.?$`,
 	},
 	AgentCursor: {
 		Name:                AgentCursor,
@@ -300,6 +327,12 @@ var builtinPresets = map[AgentPreset]*AgentPresetInfo{
 		HooksSettingsFile: "gastown.js",
 		ReadyDelayMs:      8000,
 		InstructionsFile:  "AGENTS.md",
+		RateLimitPatterns: []string{
+			`rate_limit_error`,
+			`AI_RetryError: .*(rate limit|429)`,
+			`429 Too Many Requests`,
+		},
+		ResetTimePattern: `(?:retry|try again) in\s+([\d.]+\s*[a-z]+(?:\s+[\d.]+\s*[a-z]+)*)`,
 	},
 	AgentCopilot: {
 		Name:                AgentCopilot,
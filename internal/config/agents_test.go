@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -37,6 +38,27 @@ func TestBuiltinPresets(t *testing.T) {
 	}
 }
 
+func TestBuiltinPresets_RateLimitSignaturesCompile(t *testing.T) {
+	for name, preset := range builtinPresets {
+		for _, p := range preset.RateLimitPatterns {
+			if _, err := regexp.Compile("(?i)" + p); err != nil {
+				t.Errorf("%s: rate limit pattern %q: %v", name, p, err)
+			}
+		}
+		if preset.ResetTimePattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + preset.ResetTimePattern)
+		if err != nil {
+			t.Errorf("%s: reset time pattern %q: %v", name, preset.ResetTimePattern, err)
+			continue
+		}
+		if re.NumSubexp() < 1 {
+			t.Errorf("%s: reset time pattern %q has no capture group", name, preset.ResetTimePattern)
+		}
+	}
+}
+
 func TestGetAgentPresetByName(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -228,9 +229,16 @@ var ErrInvalidOnConflict = errors.New("invalid on_conflict strategy")
 // validateMergeQueueConfig validates a MergeQueueConfig.
 func validateMergeQueueConfig(c *MergeQueueConfig) error {
 	// Validate on_conflict strategy
-	if c.OnConflict != "" && c.OnConflict != OnConflictAssignBack && c.OnConflict != OnConflictAutoRebase {
-		return fmt.Errorf("%w: got '%s', want '%s' or '%s'",
-			ErrInvalidOnConflict, c.OnConflict, OnConflictAssignBack, OnConflictAutoRebase)
+	switch c.OnConflict {
+	case "", OnConflictAssignBack, OnConflictAutoRebase, OnConflictAutoResolve:
+	default:
+		return fmt.Errorf("%w: got '%s', want '%s', '%s' or '%s'",
+			ErrInvalidOnConflict, c.OnConflict, OnConflictAssignBack, OnConflictAutoRebase, OnConflictAutoResolve)
+	}
+	for _, s := range c.ConflictStrategies {
+		if !slices.Contains(ConflictStrategyNames, s) {
+			return fmt.Errorf("invalid conflict strategy '%s', want one of %s", s, strings.Join(ConflictStrategyNames, ", "))
+		}
 	}
 
 	// Validate poll_interval if specified
@@ -695,6 +703,10 @@ func LoadAccountsConfig(path string) (*AccountsConfig, error) {
 		return nil, fmt.Errorf("parsing accounts config: %w", err)
 	}
 
+	// Accounts may name custom providers from the town's agent registry
+	// (accounts.json lives at <town>/mayor/accounts.json).
+	_ = LoadAgentRegistry(DefaultAgentRegistryPath(filepath.Dir(filepath.Dir(path))))
+
 	if err := validateAccountsConfig(&config); err != nil {
 		return nil, err
 	}
@@ -743,6 +755,23 @@ func validateAccountsConfig(c *AccountsConfig) error {
 		if acct.ConfigDir == "" {
 			return fmt.Errorf("%w: config_dir for account '%s'", ErrMissingField, handle)
 		}
+		if err := ValidateAccountProvider(acct.ProviderName()); err != nil {
+			return fmt.Errorf("account '%s': %w", handle, err)
+		}
+	}
+	return nil
+}
+
+// ValidateAccountProvider checks that accounts can be used with provider.
+// Gas Town selects an account by pointing the provider's config_dir_env at
+// the account's config dir, so a provider without one cannot have accounts.
+func ValidateAccountProvider(provider string) error {
+	preset := GetAgentPresetByName(provider)
+	if preset == nil {
+		return fmt.Errorf("unknown provider '%s'", provider)
+	}
+	if preset.ConfigDirEnv == "" {
+		return fmt.Errorf("provider '%s' has no config_dir_env, so it cannot switch accounts", provider)
 	}
 	return nil
 }
@@ -931,6 +960,40 @@ func validateMessagingConfig(c *MessagingConfig) error {
 		}
 	}
 
+	// Validate mail rules
+	for mailbox, rules := range c.Rules {
+		seen := make(map[string]bool)
+		for i, rule := range rules {
+			if rule.Name == "" {
+				return fmt.Errorf("%w: rules['%s'][%d] name", ErrMissingField, mailbox, i)
+			}
+			if seen[rule.Name] {
+				return fmt.Errorf("rules['%s']: duplicate rule name '%s'", mailbox, rule.Name)
+			}
+			seen[rule.Name] = true
+			if rule.Match.IsEmpty() {
+				return fmt.Errorf("%w: rule '%s' match needs at least one condition", ErrMissingField, rule.Name)
+			}
+			switch rule.Action {
+			case MailRuleArchive, MailRuleNudge:
+			case MailRuleLabel:
+				if rule.Label == "" {
+					return fmt.Errorf("%w: rule '%s' label", ErrMissingField, rule.Name)
+				}
+			case MailRuleForward:
+				if rule.ForwardTo == "" {
+					return fmt.Errorf("%w: rule '%s' forward_to", ErrMissingField, rule.Name)
+				}
+			case MailRuleEscalate:
+				if rule.Priority != "" && rule.Priority != "high" && rule.Priority != "urgent" {
+					return fmt.Errorf("rule '%s': escalate priority must be high or urgent, got '%s'", rule.Name, rule.Priority)
+				}
+			default:
+				return fmt.Errorf("rule '%s': unknown action '%s' (want archive, label, forward, nudge or escalate)", rule.Name, rule.Action)
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -577,6 +577,26 @@ func TestAccountsConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "provider without config_dir_env",
+			config: &AccountsConfig{
+				Version: 1,
+				Accounts: map[string]Account{
+					"gem": {ConfigDir: "~/.gemini-accounts/gem", Provider: "gemini"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown provider",
+			config: &AccountsConfig{
+				Version: 1,
+				Accounts: map[string]Account{
+					"x": {ConfigDir: "~/.x-accounts/x", Provider: "no-such-agent"},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -778,6 +798,44 @@ func TestMessagingConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid mail rules",
+			config: &MessagingConfig{
+				Version: 1,
+				Rules: map[string][]MailRule{
+					"mayor/": {
+						{Name: "quiet", Match: MailRuleMatch{Subject: "Convoy*"}, Action: MailRuleArchive},
+						{Name: "ci", Match: MailRuleMatch{From: "*/refinery"}, Action: MailRuleLabel, Label: "ci"},
+						{Name: "up", Match: MailRuleMatch{Type: "task"}, Action: MailRuleEscalate, Priority: "urgent"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mail rule with empty match",
+			config: &MessagingConfig{
+				Version: 1,
+				Rules:   map[string][]MailRule{"mayor/": {{Name: "all", Action: MailRuleArchive}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mail rule forward without target",
+			config: &MessagingConfig{
+				Version: 1,
+				Rules:   map[string][]MailRule{"*": {{Name: "fwd", Match: MailRuleMatch{Priority: "urgent"}, Action: MailRuleForward}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mail rule with unknown action",
+			config: &MessagingConfig{
+				Version: 1,
+				Rules:   map[string][]MailRule{"*": {{Name: "x", Match: MailRuleMatch{Thread: "t"}, Action: "delete"}}},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
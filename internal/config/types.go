@@ -91,6 +91,18 @@ type TownSettings struct {
 
 	// Scheduler configures the capacity scheduler for polecat dispatch.
 	Scheduler *capacity.SchedulerConfig `json:"scheduler,omitempty"`
+
+	// EventSinks forwards events from the town events log to external systems
+	// (webhooks, OTLP log collectors, local Unix sockets). Events are spooled
+	// as they are logged and delivered by the daemon.
+	EventSinks []*EventSinkConfig `json:"event_sinks,omitempty"`
+
+	// IssueSync configures external issue trackers kept in sync with beads
+	// by gt issue sync.
+	IssueSync []*IssueSyncConfig `json:"issue_sync,omitempty"`
+
+	// Prime controls how much context gt prime injects into agent sessions.
+	Prime *PrimeConfig `json:"prime,omitempty"`
 }
 
 // NewTownSettings creates a new TownSettings with defaults.
@@ -187,6 +199,142 @@ type ConvoyConfig struct {
 	NotifyOnComplete bool `json:"notify_on_complete,omitempty"`
 }
 
+// DefaultPrimeBudget is the approximate token budget for gt prime output.
+const DefaultPrimeBudget = 8000
+
+// PrimeConfig controls the token budget gt prime assembles its sections under.
+// When the sections exceed the budget, the lowest-priority ones are
+// summarized first and dropped second; required sections (session metadata,
+// role context, the work directive, injected mail, startup protocol) are
+// always kept.
+type PrimeConfig struct {
+	// Budget is the approximate token budget (about 4 characters per token).
+	// Zero uses DefaultPrimeBudget; a negative value disables the budget.
+	Budget int `json:"budget,omitempty"`
+
+	// RoleBudgets overrides Budget for specific roles (e.g. "mayor": 12000).
+	RoleBudgets map[string]int `json:"role_budgets,omitempty"`
+
+	// Priorities overrides section priorities (0-100, higher is kept longer).
+	// Sections: handoff, hooked-bead, workflow, bead-preview, attachment,
+	// molecule, checkpoint, escalations, context-file, bd-prime.
+	Priorities map[string]int `json:"priorities,omitempty"`
+}
+
+// BudgetFor returns the token budget for role. A nil config yields the default.
+func (c *PrimeConfig) BudgetFor(role string) int {
+	if c == nil {
+		return DefaultPrimeBudget
+	}
+	if b, ok := c.RoleBudgets[role]; ok && b != 0 {
+		return b
+	}
+	if c.Budget != 0 {
+		return c.Budget
+	}
+	return DefaultPrimeBudget
+}
+
+// Event sink types.
+const (
+	EventSinkWebhook = "webhook" // HTTP POST of each event as JSON
+	EventSinkOTLP    = "otlp"    // OTLP/HTTP log record per event
+	EventSinkSocket  = "socket"  // Newline-delimited JSON over a Unix socket
+)
+
+// EventSinkConfig configures a single external destination for gt events.
+type EventSinkConfig struct {
+	// Name identifies the sink. Used for the retry spool filename.
+	// Default: the sink type.
+	Name string `json:"name,omitempty"`
+
+	// Type is the sink kind: "webhook", "otlp", or "socket".
+	Type string `json:"type"`
+
+	// URL is the endpoint for webhook and otlp sinks.
+	URL string `json:"url,omitempty"`
+
+	// Path is the Unix socket path for socket sinks.
+	Path string `json:"path,omitempty"`
+
+	// SecretEnv names the environment variable holding the HMAC-SHA256 key
+	// used to sign webhook bodies. Secrets are never stored in settings files.
+	SecretEnv string `json:"secret_env,omitempty"`
+
+	// Types restricts the sink to these event types. Empty means all types.
+	Types []string `json:"types,omitempty"`
+
+	// Visibility restricts the sink to events with these visibility levels
+	// ("audit", "feed", "both"). Empty means all levels.
+	Visibility []string `json:"visibility,omitempty"`
+
+	// Timeout bounds each delivery attempt. Default: "5s".
+	Timeout string `json:"timeout,omitempty"`
+
+	// Disabled turns the sink off without removing its configuration.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// SinkName returns the configured name, falling back to the sink type.
+func (c *EventSinkConfig) SinkName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Type
+}
+
+// IssueSyncConfig configures one external issue tracker source for gt issue sync.
+type IssueSyncConfig struct {
+	// Name identifies the source. It is recorded on every bead imported from
+	// it, so it must stay stable once issues have been imported.
+	// Default: the provider name.
+	Name string `json:"name,omitempty"`
+
+	// Provider is the tracker kind: "github" or "jira".
+	Provider string `json:"provider"`
+
+	// Repo is the GitHub repository ("owner/name") for github sources.
+	Repo string `json:"repo,omitempty"`
+
+	// URL is the Jira base URL (e.g., "https://example.atlassian.net").
+	URL string `json:"url,omitempty"`
+
+	// Project is the Jira project key for jira sources.
+	Project string `json:"project,omitempty"`
+
+	// Label selects the external issues to import. Required.
+	Label string `json:"label"`
+
+	// Rig is the rig whose beads database receives imported issues.
+	// Empty means the town beads.
+	Rig string `json:"rig,omitempty"`
+
+	// UserEnv and TokenEnv name the environment variables holding the Jira
+	// account email and API token. GitHub sources use the gh CLI's auth.
+	UserEnv  string `json:"user_env,omitempty"`
+	TokenEnv string `json:"token_env,omitempty"`
+
+	// DoneTransition and ReopenTransition are the Jira workflow transitions
+	// used to close and reopen issues. Default: "Done" and "To Do".
+	DoneTransition   string `json:"done_transition,omitempty"`
+	ReopenTransition string `json:"reopen_transition,omitempty"`
+
+	// Assignees maps bead assignees (e.g., "gastown/crew/max") to tracker
+	// users. Assignees are only synced for mapped identities.
+	Assignees map[string]string `json:"assignees,omitempty"`
+
+	// Disabled turns the source off without removing its configuration.
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// SourceName returns the configured name, falling back to the provider.
+func (c *IssueSyncConfig) SourceName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Provider
+}
+
 // ParseDurationOrDefault parses a Go duration string, returning fallback on error or empty input.
 func ParseDurationOrDefault(s string, fallback time.Duration) time.Duration {
 	if s == "" {
@@ -330,14 +478,16 @@ type WorkflowConfig struct {
 
 // RigSettings represents per-rig behavioral configuration (settings/config.json).
 type RigSettings struct {
-	Type       string            `json:"type"`                  // "rig-settings"
-	Version    int               `json:"version"`               // schema version
-	MergeQueue *MergeQueueConfig `json:"merge_queue,omitempty"` // merge queue settings
-	Theme      *ThemeConfig      `json:"theme,omitempty"`       // tmux theme settings
-	Namepool   *NamepoolConfig   `json:"namepool,omitempty"`    // polecat name pool settings
-	Crew       *CrewConfig       `json:"crew,omitempty"`        // crew startup settings
-	Workflow   *WorkflowConfig   `json:"workflow,omitempty"`    // workflow settings
-	Runtime    *RuntimeConfig    `json:"runtime,omitempty"`     // LLM runtime settings (deprecated: use Agent)
+	Type         string              `json:"type"`                    // "rig-settings"
+	Version      int                 `json:"version"`                 // schema version
+	MergeQueue   *MergeQueueConfig   `json:"merge_queue,omitempty"`   // merge queue settings
+	Theme        *ThemeConfig        `json:"theme,omitempty"`         // tmux theme settings
+	Namepool     *NamepoolConfig     `json:"namepool,omitempty"`      // polecat name pool settings
+	WorktreePool *WorktreePoolConfig `json:"worktree_pool,omitempty"` // warm polecat worktree pool
+	Isolation    *IsolationConfig    `json:"isolation,omitempty"`     // polecat filesystem/process isolation
+	Crew         *CrewConfig         `json:"crew,omitempty"`          // crew startup settings
+	Workflow     *WorkflowConfig     `json:"workflow,omitempty"`      // workflow settings
+	Runtime      *RuntimeConfig      `json:"runtime,omitempty"`       // LLM runtime settings (deprecated: use Agent)
 
 	// Agent selects which agent preset to use for this rig.
 	// Can be a built-in preset ("claude", "gemini", "codex", "cursor", "auggie", "amp", "opencode", "copilot")
@@ -851,9 +1001,15 @@ type MergeQueueConfig struct {
 	// Nil defaults to false (manual landing required).
 	IntegrationBranchAutoLand *bool `json:"integration_branch_auto_land,omitempty"`
 
-	// OnConflict specifies conflict resolution strategy: "assign_back" or "auto_rebase".
+	// OnConflict specifies conflict resolution strategy: "assign_back",
+	// "auto_rebase" or "auto_resolve". auto_resolve has the refinery try
+	// ConflictStrategies and re-run gates before falling back to assign_back.
 	OnConflict string `json:"on_conflict"`
 
+	// ConflictStrategies lists the automatic resolutions auto_resolve tries,
+	// in order. Empty means all of them (see ConflictStrategyNames).
+	ConflictStrategies []string `json:"conflict_strategies,omitempty"`
+
 	// RunTests controls whether to run tests before merging.
 	// Nil defaults to true (tests are run).
 	RunTests *bool `json:"run_tests,omitempty"`
@@ -889,14 +1045,43 @@ type MergeQueueConfig struct {
 	// StaleClaimTimeout is how long a claimed MR can go without updates before
 	// being considered abandoned and eligible for re-claim (e.g., "30m").
 	StaleClaimTimeout string `json:"stale_claim_timeout,omitempty"`
+
+	// PostMergeVerify re-runs the gates on each merged commit once it is on
+	// the target. A failure opens a revert MR and pauses the queue until the
+	// target is green. Nil defaults to false.
+	PostMergeVerify *bool `json:"post_merge_verify,omitempty"`
+
+	// PhaseSLA overrides how long an MR may stay in a phase before it is
+	// escalated, keyed by phase (e.g., {"ready": "2h", "preparing": "45m"}).
+	// "0" or "off" disables the check for a phase.
+	PhaseSLA map[string]string `json:"phase_sla,omitempty"`
 }
 
 // OnConflict strategy constants.
 const (
-	OnConflictAssignBack = "assign_back"
-	OnConflictAutoRebase = "auto_rebase"
+	OnConflictAssignBack  = "assign_back"
+	OnConflictAutoRebase  = "auto_rebase"
+	OnConflictAutoResolve = "auto_resolve"
+)
+
+// Automatic conflict resolution strategies, in their default order.
+const (
+	ConflictStrategyRerere    = "rerere"    // replay resolutions recorded in the rig's shared rerere cache
+	ConflictStrategyGoSum     = "go_sum"    // union of go.sum / go.work.sum lines
+	ConflictStrategyLockfile  = "lockfile"  // take the target's lockfile and regenerate it
+	ConflictStrategyImports   = "imports"   // union of import lines (Go, Python, JS/TS)
+	ConflictStrategyChangelog = "changelog" // keep both sides' changelog entries
 )
 
+// ConflictStrategyNames is the default auto_resolve strategy order.
+var ConflictStrategyNames = []string{
+	ConflictStrategyRerere,
+	ConflictStrategyGoSum,
+	ConflictStrategyLockfile,
+	ConflictStrategyImports,
+	ConflictStrategyChangelog,
+}
+
 // IsPolecatIntegrationEnabled returns whether polecat integration branch
 // sourcing is enabled. Nil-safe, defaults to true.
 func (c *MergeQueueConfig) IsPolecatIntegrationEnabled() bool {
@@ -943,6 +1128,15 @@ func (c *MergeQueueConfig) IsDeleteMergedBranchesEnabled() bool {
 	return *c.DeleteMergedBranches
 }
 
+// IsPostMergeVerifyEnabled returns whether merged commits are re-verified on
+// the target. Nil-safe, defaults to false.
+func (c *MergeQueueConfig) IsPostMergeVerifyEnabled() bool {
+	if c.PostMergeVerify == nil {
+		return false
+	}
+	return *c.PostMergeVerify
+}
+
 // boolPtr returns a pointer to a bool value.
 func boolPtr(b bool) *bool {
 	return &b
@@ -988,19 +1182,127 @@ func DefaultNamepoolConfig() *NamepoolConfig {
 	}
 }
 
+// WorktreePoolConfig configures a pool of pre-provisioned idle worktrees for
+// polecats. Spawning claims a warm worktree instead of creating one and
+// running setup hooks from scratch.
+type WorktreePoolConfig struct {
+	// Size is how many warm worktrees to keep ready. 0 disables the pool.
+	Size int `json:"size"`
+
+	// RefreshInterval is the minimum time between refreshes of a warm
+	// worktree whose base branch has moved (default "30m"). A refresh checks
+	// out the new base and re-runs setup hooks.
+	RefreshInterval string `json:"refresh_interval,omitempty"`
+
+	// SharedCaches points polecats and setup hooks at shared dependency
+	// caches (GOMODCACHE, GOCACHE, npm, yarn, pip) under <town>/.cache/.
+	// Nil-safe, defaults to true.
+	SharedCaches *bool `json:"shared_caches,omitempty"`
+
+	// HookTimeout bounds each setup hook while a warm worktree is
+	// provisioned or refreshed in the background (default "20m"), where
+	// dependency installs may take minutes. Spawns keep the 60s limit.
+	HookTimeout string `json:"hook_timeout,omitempty"`
+}
+
+// DefaultWorktreeRefreshInterval is used when RefreshInterval is unset or invalid.
+const DefaultWorktreeRefreshInterval = 30 * time.Minute
+
+// DefaultWarmHookTimeout is used when HookTimeout is unset or invalid.
+const DefaultWarmHookTimeout = 20 * time.Minute
+
+// GetHookTimeout returns HookTimeout parsed, or the default.
+func (c *WorktreePoolConfig) GetHookTimeout() time.Duration {
+	if c.HookTimeout != "" {
+		if d, err := time.ParseDuration(c.HookTimeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultWarmHookTimeout
+}
+
+// GetRefreshInterval returns RefreshInterval parsed, or the default.
+func (c *WorktreePoolConfig) GetRefreshInterval() time.Duration {
+	if c.RefreshInterval != "" {
+		if d, err := time.ParseDuration(c.RefreshInterval); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultWorktreeRefreshInterval
+}
+
+// IsSharedCachesEnabled returns whether shared dependency caches are used.
+// Nil-safe, defaults to true.
+func (c *WorktreePoolConfig) IsSharedCachesEnabled() bool {
+	if c.SharedCaches == nil {
+		return true
+	}
+	return *c.SharedCaches
+}
+
+// IsolationConfig runs polecat sessions inside a Linux namespace sandbox
+// (bubblewrap). A sandboxed polecat sees the system directories read-only,
+// its own worktree and git repository, the beads databases it uses, the
+// town and rig configuration read-only, and the configured extra paths.
+// Sibling worktrees, other rigs and the rest of $HOME are not visible.
+type IsolationConfig struct {
+	// Enabled turns isolation on. A session that cannot be sandboxed
+	// (non-Linux host, bwrap not installed) fails to start.
+	Enabled bool `json:"enabled"`
+
+	// AllowPaths are extra paths mounted read-write. A leading "~/" is
+	// expanded to $HOME. Missing paths are skipped.
+	AllowPaths []string `json:"allow_paths,omitempty"`
+
+	// ReadOnlyPaths are extra paths mounted read-only (toolchains under
+	// $HOME such as ~/.nvm or ~/go/pkg, credentials the agent reads).
+	ReadOnlyPaths []string `json:"read_only_paths,omitempty"`
+
+	// DenyNetwork gives the session its own network namespace with only a
+	// loopback interface. This also cuts off the Dolt server and the agent's
+	// model API unless they are reachable through an allow-listed socket.
+	DenyNetwork bool `json:"deny_network,omitempty"`
+
+	// Bwrap is the bubblewrap binary (default: "bwrap" on PATH).
+	Bwrap string `json:"bwrap,omitempty"`
+}
+
 // AccountsConfig represents Claude Code account configuration (mayor/accounts.json).
 // This enables Gas Town to manage multiple Claude Code accounts with easy switching.
 type AccountsConfig struct {
 	Version  int                `json:"version"`  // schema version
 	Accounts map[string]Account `json:"accounts"` // handle -> account details
 	Default  string             `json:"default"`  // default account handle
+
+	// Selection controls how an account is chosen for new sessions when
+	// neither GT_ACCOUNT nor --account is given. Empty (the default) uses
+	// Default; AccountSelectionHeadroom picks the account with the most
+	// estimated quota headroom.
+	Selection string `json:"selection,omitempty"`
 }
 
+// AccountSelectionHeadroom selects the account most likely to last for new
+// sessions, based on recent token consumption and observed limits.
+const AccountSelectionHeadroom = "headroom"
+
 // Account represents a single Claude Code account.
 type Account struct {
 	Email       string `json:"email"`                 // account email
 	Description string `json:"description,omitempty"` // human description
 	ConfigDir   string `json:"config_dir"`            // path to CLAUDE_CONFIG_DIR
+
+	// Provider is the agent preset this account belongs to (e.g. "gemini",
+	// "codex"). Empty means "claude". Quota rotation only moves a session
+	// between accounts of the session's own provider.
+	Provider string `json:"provider,omitempty"`
+}
+
+// ProviderName returns the agent preset an account belongs to.
+func (a Account) ProviderName() string {
+	if a.Provider == "" {
+		return string(AgentClaude)
+	}
+	return a.Provider
 }
 
 // CurrentAccountsVersion is the current schema version for AccountsConfig.
@@ -1041,7 +1343,12 @@ type AccountQuotaState struct {
 	Status    AccountQuotaStatus `json:"status"`              // current status
 	LimitedAt string             `json:"limited_at,omitempty"` // RFC3339 when limit was detected
 	ResetsAt  string             `json:"resets_at,omitempty"`  // Human-readable reset time from provider (e.g. "7pm (America/Los_Angeles)")
+	ResetTime string             `json:"reset_time,omitempty"` // RFC3339 parse of ResetsAt, empty if unparseable
 	LastUsed  string             `json:"last_used,omitempty"`  // RFC3339 when account was last assigned to a session
+
+	// LimitTokens is the token consumption observed in the usage window when
+	// the account was last rate-limited. Used to estimate remaining headroom.
+	LimitTokens int64 `json:"limit_tokens,omitempty"`
 }
 
 // CurrentQuotaVersion is the current schema version for QuotaState.
@@ -1072,6 +1379,57 @@ type MessagingConfig struct {
 	// Like mailing lists but for tmux send-keys instead of durable mail.
 	// Example: {"workers": ["gastown/polecats/*", "gastown/crew/*"], "witnesses": ["*/witness"]}
 	NudgeChannels map[string][]string `json:"nudge_channels,omitempty"`
+
+	// Rules are per-mailbox mail rules applied at delivery time, keyed by
+	// recipient address. Keys support '*' segments ("gastown/polecats/*") and
+	// "*" alone matches every mailbox. Rules run in order; see MailRule.
+	// Example: {"mayor/": [{"name": "quiet-convoys", "match": {"subject": "Convoy progress*"}, "action": "archive"}]}
+	Rules map[string][]MailRule `json:"rules,omitempty"`
+}
+
+// Mail rule actions.
+const (
+	MailRuleArchive  = "archive"  // Store the message already archived (no notification)
+	MailRuleLabel    = "label"    // Add Label to the message
+	MailRuleForward  = "forward"  // Also deliver a copy to ForwardTo
+	MailRuleNudge    = "nudge"    // Deliver as a nudge instead of mail when the recipient has a session
+	MailRuleEscalate = "escalate" // Raise the message priority to Priority (default "high")
+)
+
+// MailRule is one mail filter. A rule matches when every non-empty field in
+// Match matches the message. Archive and nudge end rule processing; other
+// actions continue to the next rule unless Stop is set.
+type MailRule struct {
+	Name   string        `json:"name"`
+	Match  MailRuleMatch `json:"match"`
+	Action string        `json:"action"`
+
+	// Label is the label added by the label action.
+	Label string `json:"label,omitempty"`
+
+	// ForwardTo is the address that receives a copy for the forward action.
+	ForwardTo string `json:"forward_to,omitempty"`
+
+	// Priority is the target for the escalate action: "high" or "urgent".
+	Priority string `json:"priority,omitempty"`
+
+	// Stop ends rule processing after this rule matches.
+	Stop bool `json:"stop,omitempty"`
+}
+
+// MailRuleMatch selects messages for a MailRule. From and Subject are globs
+// ('*' matches any run of characters; subject matching ignores case).
+type MailRuleMatch struct {
+	From     string `json:"from,omitempty"`
+	Subject  string `json:"subject,omitempty"`
+	Type     string `json:"type,omitempty"`     // task, scavenge, notification, reply
+	Priority string `json:"priority,omitempty"` // urgent, high, normal, low
+	Thread   string `json:"thread,omitempty"`
+}
+
+// IsEmpty reports whether the match has no conditions.
+func (m MailRuleMatch) IsEmpty() bool {
+	return m == MailRuleMatch{}
 }
 
 // QueueConfig represents a work queue configuration.
@@ -0,0 +1,474 @@
+package daemon
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/townlog"
+)
+
+const (
+	// crashPaneLines is how many trailing pane lines a crash report captures.
+	crashPaneLines = 50
+
+	// crashSignatureLines is how many trailing non-empty pane lines feed the
+	// crash signature. Only the tail matters: it holds the fatal error.
+	crashSignatureLines = 5
+
+	// maxCrashReports caps the local crash report log.
+	maxCrashReports = 1000
+
+	// crashExitLookback bounds how far back the town log is searched for an
+	// exit status when the agent has no recorded restart.
+	crashExitLookback = time.Hour
+)
+
+// CrashReport captures why an agent died, recorded by the daemon on each
+// detected death. Reports are appended to daemon/crashes.jsonl and mirrored
+// into a crash report bead when beads is reachable.
+type CrashReport struct {
+	Agent        string    `json:"agent"`
+	Session      string    `json:"session"`
+	CapturedAt   time.Time `json:"captured_at"`
+	ExitStatus   int       `json:"exit_status"` // -1 if unknown
+	PaneTail     []string  `json:"pane_tail,omitempty"`
+	TrackedPID   int       `json:"tracked_pid,omitempty"`
+	PIDAlive     bool      `json:"pid_alive,omitempty"`
+	DoltHealthy  bool      `json:"dolt_healthy"`
+	DoltHealth   string    `json:"dolt_health,omitempty"`
+	RestartCount int       `json:"restart_count"`
+	CrashLoop    bool      `json:"crash_loop"`
+	Signature    string    `json:"signature"`
+	SuggestedFix string    `json:"suggested_fix,omitempty"`
+	BeadID       string    `json:"bead_id,omitempty"`
+}
+
+// CrashCluster groups crash reports that share a signature.
+type CrashCluster struct {
+	Signature    string       `json:"signature"`
+	Count        int          `json:"count"`
+	Agents       []string     `json:"agents"`
+	FirstSeen    time.Time    `json:"first_seen"`
+	LastSeen     time.Time    `json:"last_seen"`
+	SuggestedFix string       `json:"suggested_fix,omitempty"`
+	Latest       *CrashReport `json:"latest"`
+}
+
+// crashReportsFile returns the path to the local crash report log.
+func crashReportsFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "crashes.jsonl")
+}
+
+// captureCrashReport gathers diagnostic context for a dead agent session:
+// the last pane lines and exit status (when the pane is still around), the
+// tracked PID, and the current Dolt health. Never fails; missing signals are
+// left empty.
+func (d *Daemon) captureCrashReport(agentID, sessionName string) *CrashReport {
+	r := &CrashReport{
+		Agent:      agentID,
+		Session:    sessionName,
+		CapturedAt: time.Now().UTC(),
+		ExitStatus: -1,
+	}
+
+	if has, err := d.tmux.HasSession(sessionName); err == nil && has {
+		if lines, err := d.tmux.CapturePaneLines(sessionName, crashPaneLines); err == nil {
+			r.PaneTail = trimTrailingBlank(lines)
+		}
+		if dead, status, err := d.tmux.GetPaneDeadStatus(sessionName); err == nil && dead {
+			r.ExitStatus = status
+		}
+	}
+	if r.ExitStatus == -1 {
+		// Only trust pane-died records newer than the agent's last restart;
+		// older ones belong to a previous incarnation of the session.
+		since := r.CapturedAt.Add(-crashExitLookback)
+		if d.restartTracker != nil {
+			if last := d.restartTracker.Info(agentID).LastRestart; !last.IsZero() {
+				since = last
+			}
+		}
+		r.ExitStatus = lastExitStatusFromTownLog(d.config.TownRoot, agentID, sessionName, since)
+	}
+
+	if pid, alive, err := session.ReadTrackedPID(d.config.TownRoot, sessionName); err == nil {
+		r.TrackedPID = pid
+		r.PIDAlive = alive
+	}
+
+	r.DoltHealthy = true
+	if d.doltServer != nil && d.doltServer.IsEnabled() {
+		h := doltserver.GetHealthMetrics(d.config.TownRoot)
+		r.DoltHealthy = h.Healthy
+		r.DoltHealth = formatDoltHealth(h)
+	}
+
+	return r
+}
+
+// deaconCrashReport captures a crash report if the Deacon session has died.
+// Returns nil if the Deacon is alive, or if its session is simply gone with
+// no crash recorded by the pane-died hook since the last restart (e.g. after
+// a clean 'gt down'), so a normal start is not reported as a crash.
+func (d *Daemon) deaconCrashReport(agentID string) *CrashReport {
+	sessionName := d.getDeaconSessionName()
+	has, err := d.tmux.HasSession(sessionName)
+	if err != nil {
+		return nil
+	}
+	if has {
+		if d.tmux.IsAgentAlive(sessionName) {
+			return nil
+		}
+		return d.captureCrashReport(agentID, sessionName)
+	}
+
+	r := d.captureCrashReport(agentID, sessionName)
+	if r.ExitStatus <= 0 {
+		return nil
+	}
+	return r
+}
+
+// recordCrashReport finalizes a crash report with restart tracker state,
+// signature and suggested fix, then persists it locally and as a bead.
+func (d *Daemon) recordCrashReport(r *CrashReport) {
+	if r == nil {
+		return
+	}
+
+	r.Signature = CrashSignature(r.ExitStatus, r.PaneTail)
+	r.SuggestedFix = SuggestCrashFix(r)
+
+	if d.restartTracker != nil {
+		info := d.restartTracker.Info(r.Agent)
+		r.RestartCount = info.RestartCount
+		r.CrashLoop = !info.CrashLoopSince.IsZero()
+		d.restartTracker.RecordCrash(r.Agent, r.Signature)
+		if err := d.restartTracker.Save(); err != nil {
+			d.logger.Printf("Warning: failed to save restart state: %v", err)
+		}
+	}
+
+	// Bead first so the local log carries the bead ID. Beads may be down
+	// (Dolt is a common cause of crashes), so the local log is authoritative.
+	title := fmt.Sprintf("Crash: %s (exit %d, sig %s)", r.Agent, r.ExitStatus, r.Signature)
+	fields := &beads.CrashReportFields{
+		Agent:        r.Agent,
+		Session:      r.Session,
+		ExitStatus:   r.ExitStatus,
+		Signature:    r.Signature,
+		CapturedAt:   r.CapturedAt.Format(time.RFC3339),
+		RestartCount: r.RestartCount,
+		CrashLoop:    r.CrashLoop,
+		TrackedPID:   r.TrackedPID,
+		PIDAlive:     r.PIDAlive,
+		DoltHealth:   r.DoltHealth,
+		SuggestedFix: r.SuggestedFix,
+	}
+	// A crash that keeps recurring on the same session updates its open
+	// report rather than filing a new bead each time.
+	bd := beads.New(d.config.TownRoot)
+	if existing, err := bd.FindOpenCrashReport(r.Session, r.Signature); err == nil && existing != nil {
+		fields.Occurrences = beads.ParseCrashReportFields(existing.Description).Occurrences + 1
+		if err := bd.UpdateCrashReportBead(existing.ID, title, fields, r.PaneTail); err != nil {
+			d.logger.Printf("Warning: failed to update crash report bead %s for %s: %v", existing.ID, r.Agent, err)
+		}
+		r.BeadID = existing.ID
+	} else if issue, err := bd.CreateCrashReportBead(title, fields, r.PaneTail); err != nil {
+		d.logger.Printf("Warning: failed to create crash report bead for %s: %v", r.Agent, err)
+	} else {
+		r.BeadID = issue.ID
+	}
+
+	if err := AppendCrashReport(d.config.TownRoot, r); err != nil {
+		d.logger.Printf("Warning: failed to write crash report for %s: %v", r.Agent, err)
+	}
+
+	d.logger.Printf("Crash report for %s: exit=%d sig=%s crash_loop=%t fix=%q",
+		r.Agent, r.ExitStatus, r.Signature, r.CrashLoop, r.SuggestedFix)
+}
+
+// AppendCrashReport appends a crash report to daemon/crashes.jsonl,
+// trimming the log to the newest maxCrashReports entries.
+func AppendCrashReport(townRoot string, r *CrashReport) error {
+	reports, err := LoadCrashReports(townRoot)
+	if err != nil {
+		return err
+	}
+	reports = append(reports, r)
+	if len(reports) > maxCrashReports {
+		reports = reports[len(reports)-maxCrashReports:]
+	}
+
+	var sb strings.Builder
+	for _, rep := range reports {
+		data, err := json.Marshal(rep)
+		if err != nil {
+			return fmt.Errorf("marshaling crash report: %w", err)
+		}
+		sb.Write(data)
+		sb.WriteByte('\n')
+	}
+
+	path := crashReportsFile(townRoot)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating daemon dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0600); err != nil {
+		return fmt.Errorf("writing crash reports: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadCrashReports reads all crash reports from daemon/crashes.jsonl,
+// oldest first. Returns nil if no reports have been recorded.
+func LoadCrashReports(townRoot string) ([]*CrashReport, error) {
+	f, err := os.Open(crashReportsFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening crash reports: %w", err)
+	}
+	defer f.Close()
+
+	var reports []*CrashReport
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var r CrashReport
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			continue // Skip malformed lines
+		}
+		reports = append(reports, &r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading crash reports: %w", err)
+	}
+	return reports, nil
+}
+
+// ClusterCrashReports groups reports by signature, most frequent first.
+// Ties are broken by most recent occurrence.
+func ClusterCrashReports(reports []*CrashReport) []*CrashCluster {
+	bySig := make(map[string]*CrashCluster)
+	var order []string
+	for _, r := range reports {
+		c, ok := bySig[r.Signature]
+		if !ok {
+			c = &CrashCluster{Signature: r.Signature, FirstSeen: r.CapturedAt}
+			bySig[r.Signature] = c
+			order = append(order, r.Signature)
+		}
+		c.Count++
+		if r.CapturedAt.Before(c.FirstSeen) {
+			c.FirstSeen = r.CapturedAt
+		}
+		if c.Latest == nil || !r.CapturedAt.Before(c.LastSeen) {
+			c.LastSeen = r.CapturedAt
+			c.Latest = r
+			c.SuggestedFix = r.SuggestedFix
+		}
+		if !containsString(c.Agents, r.Agent) {
+			c.Agents = append(c.Agents, r.Agent)
+		}
+	}
+
+	clusters := make([]*CrashCluster, 0, len(order))
+	for _, sig := range order {
+		clusters = append(clusters, bySig[sig])
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].LastSeen.After(clusters[j].LastSeen)
+	})
+	return clusters
+}
+
+var (
+	sigHexRe    = regexp.MustCompile(`\b(0x)?[0-9a-fA-F]{6,}\b`)
+	sigDigitsRe = regexp.MustCompile(`[0-9]+`)
+	sigSpaceRe  = regexp.MustCompile(`\s+`)
+)
+
+// CrashSignature returns a short stable hash identifying a class of crash.
+// It combines the exit status with the last few non-empty pane lines after
+// normalizing away volatile content (numbers, hashes, whitespace), so the
+// same failure on different agents or at different times clusters together.
+func CrashSignature(exitStatus int, paneTail []string) string {
+	var tail []string
+	for i := len(paneTail) - 1; i >= 0 && len(tail) < crashSignatureLines; i-- {
+		line := strings.TrimSpace(paneTail[i])
+		if line == "" {
+			continue
+		}
+		line = strings.ToLower(line)
+		line = sigHexRe.ReplaceAllString(line, "x")
+		line = sigDigitsRe.ReplaceAllString(line, "#")
+		line = sigSpaceRe.ReplaceAllString(line, " ")
+		tail = append(tail, line)
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "exit=%d\n", exitStatus)
+	for _, line := range tail {
+		h.Write([]byte(line))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// crashFixRule maps a pane-output pattern to a remediation hint.
+type crashFixRule struct {
+	pattern *regexp.Regexp
+	fix     string
+}
+
+// crashFixRules are checked in order against the captured pane tail, most
+// specific first: a disk "quota exceeded" must not read as a rate limit, and
+// a Dolt "connection refused" must not read as an auth failure. Status codes
+// are anchored to an HTTP/status prefix so they don't match PIDs or counts.
+var crashFixRules = []crashFixRule{
+	{regexp.MustCompile(`(?i)no space left on device|disk full|disk quota exceeded|\bquota exceeded\b.*(disk|device|file)`),
+		"Disk is full: free space (gt cleanup, prune worktrees) and restart"},
+	{regexp.MustCompile(`(?i)(dolt|mysql|3306|3307).*(refused|timeout|gone away)|connection refused.*(dolt|3306|3307)`),
+		"Dolt server unreachable: check 'gt dolt status' and restart the server"},
+	{regexp.MustCompile(`(?i)(invalid|missing|expired).*(api key|token|credential)|unauthori[sz]ed|\b(HTTP|status)\s*401\b`),
+		"Authentication failed: refresh agent credentials or API key"},
+	{regexp.MustCompile(`(?i)out of memory|\boom\b|oom-kill|cannot allocate`),
+		"Process ran out of memory: reduce concurrent polecats or raise memory limits"},
+	{regexp.MustCompile(`(?i)rate.?limit|\b(HTTP|status)\s*429\b|too many requests|usage limit|quota`),
+		"Provider rate limit hit: wait for the window to reset or rotate accounts (gt account)"},
+	{regexp.MustCompile(`(?i)command not found|no such file or directory|executable file not found`),
+		"Agent binary or script missing: verify the agent command in settings and PATH"},
+}
+
+// SuggestCrashFix returns a heuristic remediation hint for a crash report.
+// Pane output is checked first since it is the most specific signal, then
+// exit status and Dolt health.
+func SuggestCrashFix(r *CrashReport) string {
+	text := strings.Join(r.PaneTail, "\n")
+	for _, rule := range crashFixRules {
+		if rule.pattern.MatchString(text) {
+			return rule.fix
+		}
+	}
+
+	switch r.ExitStatus {
+	case 137:
+		return "Process was SIGKILLed (exit 137), likely by the OOM killer: check dmesg and memory limits"
+	case 139:
+		return "Process segfaulted (exit 139): upgrade the agent runtime"
+	case 143:
+		return "Process was terminated (SIGTERM): check for an external kill or shutdown"
+	case 130:
+		return "Process was interrupted (Ctrl-C): likely intentional"
+	}
+
+	if !r.DoltHealthy {
+		return "Dolt server is unhealthy: check 'gt dolt status' before restarting agents"
+	}
+	if r.PIDAlive {
+		return "Tracked process is still alive after session death: kill orphans with 'gt cleanup'"
+	}
+	return ""
+}
+
+// lastExitStatusFromTownLog finds the exit status recorded by the pane-died
+// hook (gt log crash) for the agent or session since the given time.
+// Returns -1 if none is found.
+func lastExitStatusFromTownLog(townRoot, agentID, sessionName string, since time.Time) int {
+	evts, err := townlog.ReadEvents(townRoot)
+	if err != nil {
+		return -1
+	}
+	sessionTag := "(session: " + sessionName + ")"
+	for i := len(evts) - 1; i >= 0; i-- {
+		e := evts[i]
+		if e.Timestamp.Before(since) {
+			break
+		}
+		if e.Agent != agentID && !strings.Contains(e.Context, sessionTag) {
+			continue
+		}
+		switch e.Type {
+		case townlog.EventCrash, townlog.EventKill:
+			if n := parseExitCode(e.Context); n >= 0 {
+				return n
+			}
+		case townlog.EventDone:
+			if strings.Contains(e.Context, "exited normally") {
+				return 0
+			}
+		}
+	}
+	return -1
+}
+
+var exitCodeRe = regexp.MustCompile(`exit(?: code)? (-?\d+)`)
+
+// parseExitCode extracts N from "exit code N" or "(exit N)". Returns -1 if absent.
+func parseExitCode(context string) int {
+	m := exitCodeRe.FindStringSubmatch(context)
+	if m == nil {
+		return -1
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// formatDoltHealth summarizes Dolt health metrics on one line.
+func formatDoltHealth(h *doltserver.HealthMetrics) string {
+	state := "healthy"
+	if !h.Healthy {
+		state = "unhealthy"
+	}
+	s := fmt.Sprintf("%s latency=%s connections=%d/%d", state,
+		h.QueryLatency.Round(time.Millisecond), h.Connections, h.MaxConnections)
+	if len(h.Warnings) > 0 {
+		s += " warnings=" + strings.Join(h.Warnings, "; ")
+	}
+	return s
+}
+
+// trimTrailingBlank drops trailing empty lines (tmux pads the visible area).
+func trimTrailingBlank(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
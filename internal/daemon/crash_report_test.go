@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrashSignature_NormalizesVolatileContent(t *testing.T) {
+	a := CrashSignature(1, []string{"starting", "Error: request 4821 failed at 0xdeadbeef01", "", ""})
+	b := CrashSignature(1, []string{"starting", "Error: request 99 failed at 0xcafebabe22"})
+	if a != b {
+		t.Errorf("signatures differ for same failure: %s vs %s", a, b)
+	}
+
+	// A different exit status or error must not cluster together.
+	if c := CrashSignature(2, []string{"Error: request 1 failed at 0xdeadbeef01"}); c == a {
+		t.Error("different exit status produced same signature")
+	}
+	if c := CrashSignature(1, []string{"panic: nil map"}); c == a {
+		t.Error("different error produced same signature")
+	}
+	if len(a) != 12 {
+		t.Errorf("signature length = %d, want 12", len(a))
+	}
+}
+
+func TestSuggestCrashFix(t *testing.T) {
+	tests := []struct {
+		name   string
+		report CrashReport
+		want   string
+	}{
+		{"rate limit in pane", CrashReport{PaneTail: []string{"API Error: 429 Too Many Requests"}, DoltHealthy: true}, "rate limit"},
+		{"disk full", CrashReport{PaneTail: []string{"write: no space left on device"}, DoltHealthy: true}, "Disk is full"},
+		{"disk quota is not a rate limit", CrashReport{PaneTail: []string{"open /tmp/x: disk quota exceeded"}, DoltHealthy: true}, "Disk is full"},
+		{"status 429", CrashReport{PaneTail: []string{"request failed: status 429"}, DoltHealthy: true}, "rate limit"},
+		{"bare 429 is not a rate limit", CrashReport{PaneTail: []string{"retried 429 times", "exit"}, ExitStatus: 1, DoltHealthy: true}, ""},
+		{"HTTP 401", CrashReport{PaneTail: []string{"HTTP 401 from api.anthropic.com"}, DoltHealthy: true}, "Authentication failed"},
+		{"bare 401 is not auth", CrashReport{PaneTail: []string{"processed 401 files"}, ExitStatus: 1, DoltHealthy: true}, ""},
+		{"dolt refused before auth", CrashReport{PaneTail: []string{"dolt: connection refused (token cache)"}, DoltHealthy: true}, "Dolt server unreachable"},
+		{"oom exit code", CrashReport{ExitStatus: 137, DoltHealthy: true}, "exit 137"},
+		{"dolt unhealthy", CrashReport{ExitStatus: 1, DoltHealthy: false}, "Dolt server is unhealthy"},
+		{"unknown", CrashReport{ExitStatus: 1, DoltHealthy: true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestCrashFix(&tt.report)
+			if tt.want == "" {
+				if got != "" {
+					t.Errorf("SuggestCrashFix() = %q, want empty", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("SuggestCrashFix() = %q, want substring %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrashReports_AppendLoadCluster(t *testing.T) {
+	townRoot := t.TempDir()
+	base := time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC)
+
+	reports := []*CrashReport{
+		{Agent: "deacon", CapturedAt: base, Signature: "aaa", SuggestedFix: "old fix"},
+		{Agent: "gastown/Toast", CapturedAt: base.Add(time.Minute), Signature: "bbb"},
+		{Agent: "deacon", CapturedAt: base.Add(2 * time.Minute), Signature: "aaa", SuggestedFix: "new fix"},
+	}
+	for _, r := range reports {
+		if err := AppendCrashReport(townRoot, r); err != nil {
+			t.Fatalf("AppendCrashReport: %v", err)
+		}
+	}
+
+	loaded, err := LoadCrashReports(townRoot)
+	if err != nil {
+		t.Fatalf("LoadCrashReports: %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Fatalf("loaded %d reports, want 3", len(loaded))
+	}
+
+	clusters := ClusterCrashReports(loaded)
+	if len(clusters) != 2 {
+		t.Fatalf("got %d clusters, want 2", len(clusters))
+	}
+	top := clusters[0]
+	if top.Signature != "aaa" || top.Count != 2 {
+		t.Errorf("top cluster = %s x%d, want aaa x2", top.Signature, top.Count)
+	}
+	if !top.FirstSeen.Equal(base) || !top.LastSeen.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("cluster window = %v..%v", top.FirstSeen, top.LastSeen)
+	}
+	if top.SuggestedFix != "new fix" {
+		t.Errorf("cluster fix = %q, want latest report's fix", top.SuggestedFix)
+	}
+}
+
+func TestParseExitCode(t *testing.T) {
+	tests := map[string]int{
+		"exit code 1 (session: hq-deacon)": 1,
+		"interrupted (exit 130)":           130,
+		"exited normally":                  -1,
+	}
+	for in, want := range tests {
+		if got := parseExitCode(in); got != want {
+			t.Errorf("parseExitCode(%q) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestRestartTracker_RecordCrash(t *testing.T) {
+	rt := NewRestartTracker(t.TempDir())
+	rt.RecordCrash("deacon", "aaa")
+	rt.RecordCrash("deacon", "aaa")
+	if info := rt.Info("deacon"); info.LastCrashSignature != "aaa" || info.SameSignatureCount != 2 {
+		t.Errorf("after repeat: %+v", info)
+	}
+	rt.RecordCrash("deacon", "bbb")
+	if info := rt.Info("deacon"); info.LastCrashSignature != "bbb" || info.SameSignatureCount != 1 {
+		t.Errorf("after new signature: %+v", info)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/steveyegge/gastown/internal/mayor"
 	"github.com/steveyegge/gastown/internal/refinery"
 	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -58,6 +59,17 @@ type Daemon struct {
 	deathsMu     sync.Mutex
 	recentDeaths []sessionDeath
 
+	// recordedDeaths maps a dead session to the incarnation whose death was
+	// already recorded, so a session found dead on several heartbeats (e.g.
+	// while its restart is deferred) counts once. Guarded by deathsMu.
+	recordedDeaths map[string]string
+
+	// Restart strategy chosen by mass death correlation (see death_correlation.go).
+	// Only accessed from heartbeat loop goroutine - no sync needed.
+	restartStrategy      string
+	restartStrategyUntil time.Time
+	lastStaggeredRestart time.Time
+
 	// Deacon startup tracking: prevents race condition where newly started
 	// sessions are immediately killed by the heartbeat check.
 	// See: https://github.com/steveyegge/gastown/issues/567
@@ -122,6 +134,7 @@ func New(config *Config) (*Daemon, error) {
 	if err := session.InitRegistry(config.TownRoot); err != nil {
 		logger.Printf("Warning: failed to initialize town registry: %v", err)
 	}
+	secrets.SetTownRoot(config.TownRoot)
 
 	// Load patrol config from mayor/daemon.json (optional - nil if missing)
 	patrolConfig := LoadPatrolConfig(config.TownRoot)
@@ -325,6 +338,28 @@ func (d *Daemon) Run() error {
 		d.logger.Printf("Dolt remotes push ticker started (interval %v)", interval)
 	}
 
+	// Start dedicated Dolt backup ticker if configured (default hourly).
+	// Snapshots go to the configured backup dir and are pruned by retention tier.
+	var doltBackupTicker *time.Ticker
+	var doltBackupChan <-chan time.Time
+	if IsPatrolEnabled(d.patrolConfig, "dolt_backup") {
+		interval := doltBackupInterval(d.patrolConfig)
+		doltBackupTicker = time.NewTicker(interval)
+		doltBackupChan = doltBackupTicker.C
+		defer doltBackupTicker.Stop()
+		d.logger.Printf("Dolt backup ticker started (interval %v)", interval)
+	}
+
+	// Deliver spooled event sink events on a short interval: gt processes
+	// only append events to the sink spools, so this bounds delivery latency.
+	eventSinkTicker := time.NewTicker(eventSinkFlushInterval)
+	defer eventSinkTicker.Stop()
+
+	// End the sessions of sandboxed polecats that finished: they cannot
+	// reach tmux and leave a done marker instead.
+	doneMarkerTicker := time.NewTicker(doneMarkerInterval)
+	defer doneMarkerTicker.Stop()
+
 	// Note: PATCH-010 uses per-session hooks in deacon/manager.go (SetAutoRespawnHook).
 	// Global pane-died hooks don't fire reliably in tmux 3.2a, so we rely on the
 	// per-session approach which has been tested to work for continuous recovery.
@@ -362,6 +397,26 @@ func (d *Daemon) Run() error {
 				d.pushDoltRemotes()
 			}
 
+		case <-doltBackupChan:
+			// Scheduled Dolt backup — snapshot all databases, then prune
+			// old snapshots by retention tier.
+			if !d.isShutdownInProgress() {
+				d.backupDolt()
+			}
+
+		case <-eventSinkTicker.C:
+			// Off the main loop so a slow endpoint cannot delay recovery.
+			// Overlapping flushes are harmless: each sink's spool has a
+			// delivery lock and a busy sink is skipped.
+			if !d.isShutdownInProgress() {
+				go d.flushEventSpools()
+			}
+
+		case <-doneMarkerTicker.C:
+			if !d.isShutdownInProgress() {
+				d.endDoneSessions()
+			}
+
 		case <-timer.C:
 			d.heartbeat(state)
 
@@ -377,6 +432,14 @@ func (d *Daemon) Run() error {
 // 3 minutes is fast enough to detect stuck agents promptly while avoiding excessive overhead.
 const recoveryHeartbeatInterval = 3 * time.Minute
 
+// eventSinkFlushInterval is how often the daemon delivers spooled events to
+// configured event sinks.
+const eventSinkFlushInterval = 10 * time.Second
+
+// doneMarkerInterval is how often the daemon ends the sessions of sandboxed
+// polecats that ran gt done.
+const doneMarkerInterval = 10 * time.Second
+
 // heartbeat performs one heartbeat cycle.
 // The daemon is recovery-focused: it ensures agents are running and detects failures.
 // Normal wake is handled by feed subscription (bd activity --follow).
@@ -482,6 +545,9 @@ func (d *Daemon) heartbeat(state *State) {
 	// branches persist indefinitely. This cleans them up periodically.
 	d.pruneStaleBranches()
 
+	// 13.5. Top up warm polecat worktree pools (rigs with worktree_pool set).
+	d.reconcileWorktreePools()
+
 	// 14. Dispatch scheduled work (capacity-controlled polecat dispatch).
 	// Shells out to `gt scheduler run` to avoid circular import between daemon and cmd.
 	d.dispatchQueuedWork()
@@ -496,6 +562,45 @@ func (d *Daemon) heartbeat(state *State) {
 	d.logger.Printf("Heartbeat complete (#%d)", state.HeartbeatCount)
 }
 
+// flushEventSpools delivers spooled events to the configured event sinks.
+func (d *Daemon) flushEventSpools() {
+	n, err := events.FlushSpools(d.config.TownRoot)
+	if n > 0 {
+		d.logger.Printf("Delivered %d spooled event(s) to event sinks", n)
+	}
+	if err != nil {
+		d.logger.Printf("Warning: %v", err)
+	}
+}
+
+// endDoneSessions kills the session of every polecat that left a done
+// marker (see session.WriteDoneMarker). Markers naming a polecat the rig
+// does not have are dropped; a failed kill is retried on the next tick.
+func (d *Daemon) endDoneSessions() {
+	for _, rigName := range d.getKnownRigs() {
+		rigPath := filepath.Join(d.config.TownRoot, rigName)
+		for _, marker := range session.DoneMarkers(rigPath, rigName) {
+			if info, err := os.Stat(filepath.Join(rigPath, "polecats", marker.Polecat)); err != nil || !info.IsDir() {
+				d.logger.Printf("Ignoring done marker for unknown polecat %s/%s", rigName, marker.Polecat)
+				session.RemoveDoneMarker(rigPath, marker.Polecat)
+				continue
+			}
+			alive, err := d.tmux.HasSession(marker.Session)
+			if err != nil {
+				continue
+			}
+			if alive {
+				if err := d.tmux.KillSessionWithProcesses(marker.Session); err != nil {
+					d.logger.Printf("Warning: failed to end done session %s: %v", marker.Session, err)
+					continue
+				}
+				d.logger.Printf("Ended session %s after gt done (sandboxed polecat)", marker.Session)
+			}
+			session.RemoveDoneMarker(rigPath, marker.Polecat)
+		}
+	}
+}
+
 // ensureDoltServerRunning ensures the Dolt SQL server is running if configured.
 // This provides the backend for beads database access in server mode.
 func (d *Daemon) ensureDoltServerRunning() {
@@ -648,7 +753,13 @@ func (d *Daemon) ensureDeaconRunning() {
 	// Check restart tracker for backoff/crash loop
 	if d.restartTracker != nil {
 		if d.restartTracker.IsInCrashLoop(agentID) {
-			d.logger.Printf("Deacon is in crash loop, skipping restart (use 'gt daemon clear-backoff deacon' to reset)")
+			info := d.restartTracker.Info(agentID)
+			if info.LastCrashSignature != "" {
+				d.logger.Printf("Deacon is in crash loop (last crash sig %s, seen %dx), skipping restart (see 'gt daemon crashes')",
+					info.LastCrashSignature, info.SameSignatureCount)
+			} else {
+				d.logger.Printf("Deacon is in crash loop, skipping restart (use 'gt daemon clear-backoff deacon' to reset)")
+			}
 			return
 		}
 		if !d.restartTracker.CanRestart(agentID) {
@@ -660,6 +771,18 @@ func (d *Daemon) ensureDeaconRunning() {
 
 	mgr := deacon.NewManager(d.config.TownRoot)
 
+	// A recent mass death may call for holding or pacing restarts.
+	sessionName := mgr.SessionName()
+	if alive, _ := d.tmux.HasSession(sessionName); !alive || !d.tmux.IsAgentAlive(sessionName) {
+		if ok, reason := d.allowCrashRestart(); !ok {
+			d.logger.Printf("Deferring Deacon restart: %s", reason)
+			return
+		}
+	}
+
+	// Capture why the Deacon died before Start replaces the dead session.
+	crash := d.deaconCrashReport(agentID)
+
 	if err := mgr.Start(""); err != nil {
 		if err == deacon.ErrAlreadyRunning {
 			// Deacon is running - record success to reset backoff
@@ -679,6 +802,7 @@ func (d *Daemon) ensureDeaconRunning() {
 			d.logger.Printf("Warning: failed to save restart state: %v", err)
 		}
 	}
+	d.recordCrashReport(crash)
 
 	// Track when we started the Deacon to prevent race condition in checkDeaconHeartbeat.
 	// The heartbeat file will still be stale until the Deacon runs a full patrol cycle.
@@ -830,10 +954,19 @@ func (d *Daemon) ensureWitnessRunning(rigName string) {
 	}
 	mgr := witness.NewManager(r)
 
+	// A recent mass death may call for holding or pacing restarts.
+	status := mgr.IsHealthy(hungSessionThreshold)
+	if status != tmux.SessionHealthy {
+		if ok, reason := d.allowCrashRestart(); !ok {
+			d.logger.Printf("Deferring witness restart for %s: %s", rigName, reason)
+			return
+		}
+	}
+
 	// Check for hung session before Start (which only detects process-dead zombies).
 	// A hung session has a live process but no tmux activity for an extended period,
 	// indicating Claude is stuck. Kill it so Start() can recreate a fresh one.
-	if status := mgr.IsHealthy(hungSessionThreshold); status == tmux.AgentHung {
+	if status == tmux.AgentHung {
 		d.logger.Printf("Witness for %s is hung (no activity for %v), killing for restart", rigName, hungSessionThreshold)
 		t := tmux.NewTmux()
 		_ = t.KillSession(mgr.SessionName())
@@ -882,10 +1015,19 @@ func (d *Daemon) ensureRefineryRunning(rigName string) {
 	}
 	mgr := refinery.NewManager(r)
 
+	// A recent mass death may call for holding or pacing restarts.
+	status := mgr.IsHealthy(hungSessionThreshold)
+	if status != tmux.SessionHealthy {
+		if ok, reason := d.allowCrashRestart(); !ok {
+			d.logger.Printf("Deferring refinery restart for %s: %s", rigName, reason)
+			return
+		}
+	}
+
 	// Check for hung session before Start (which only detects process-dead zombies).
 	// A hung refinery means MRs pile up with no processing. Kill it so Start()
 	// can recreate a fresh one. See: gt-tr3d
-	if status := mgr.IsHealthy(hungSessionThreshold); status == tmux.AgentHung {
+	if status == tmux.AgentHung {
 		d.logger.Printf("Refinery for %s is hung (no activity for %v), killing for restart", rigName, hungSessionThreshold)
 		t := tmux.NewTmux()
 		_ = t.KillSession(mgr.SessionName())
@@ -1121,6 +1263,7 @@ func (d *Daemon) shutdown(state *State) error { //nolint:unparam // error return
 		d.logger.Println("Convoy manager stopped")
 	}
 	d.beadsStores = nil
+	beads.CloseStores()
 
 	// Stop KRC pruner
 	if d.krcPruner != nil {
@@ -1521,8 +1664,19 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 	d.logger.Printf("CRASH DETECTED: polecat %s/%s has hook_bead=%s but session %s is dead",
 		rigName, polecatName, info.HookBead, sessionName)
 
-	// Track this death for mass death detection
-	d.recordSessionDeath(sessionName)
+	// A recent mass death may call for holding or pacing restarts. Decide
+	// first, then record the death once: a deferred polecat is found dead
+	// again on every heartbeat until it is restarted.
+	allowed, reason := d.allowCrashRestart()
+	if d.markDeathRecorded(sessionName) {
+		d.recordSessionDeath(sessionName)
+		crash := d.captureCrashReport(fmt.Sprintf("%s/%s", rigName, polecatName), sessionName)
+		defer d.recordCrashReport(crash)
+	}
+	if !allowed {
+		d.logger.Printf("Deferring restart of %s/%s: %s", rigName, polecatName, reason)
+		return
+	}
 
 	// Auto-restart the polecat
 	if err := d.restartPolecatSession(rigName, polecatName, sessionName); err != nil {
@@ -1531,7 +1685,34 @@ func (d *Daemon) checkPolecatHealth(rigName, polecatName string) {
 		d.notifyWitnessOfCrashedPolecat(rigName, polecatName, info.HookBead, err)
 	} else {
 		d.logger.Printf("Successfully restarted crashed polecat %s/%s", rigName, polecatName)
+		d.clearRecordedDeath(sessionName)
+	}
+}
+
+// markDeathRecorded reports whether the death of the session's current
+// incarnation (identified by its tracked PID and start time) has yet to be
+// recorded, and marks it recorded.
+func (d *Daemon) markDeathRecorded(sessionName string) bool {
+	incarnation := session.TrackedIncarnation(d.config.TownRoot, sessionName)
+
+	d.deathsMu.Lock()
+	defer d.deathsMu.Unlock()
+	if prev, ok := d.recordedDeaths[sessionName]; ok && prev == incarnation {
+		return false
+	}
+	if d.recordedDeaths == nil {
+		d.recordedDeaths = make(map[string]string)
 	}
+	d.recordedDeaths[sessionName] = incarnation
+	return true
+}
+
+// clearRecordedDeath forgets a session's recorded death once it has been
+// restarted, so its next death is recorded even without a tracked PID.
+func (d *Daemon) clearRecordedDeath(sessionName string) {
+	d.deathsMu.Lock()
+	defer d.deathsMu.Unlock()
+	delete(d.recordedDeaths, sessionName)
 }
 
 // recordSessionDeath records a session death and checks for mass death pattern.
@@ -1565,10 +1746,17 @@ func (d *Daemon) recordSessionDeath(sessionName string) {
 
 // emitMassDeathEvent logs a mass death event when multiple sessions die in a short window.
 func (d *Daemon) emitMassDeathEvent() {
-	// Collect session names
+	// Collect session names and the actual death window
 	var sessions []string
+	var start, end time.Time
 	for _, death := range d.recentDeaths {
 		sessions = append(sessions, death.sessionName)
+		if start.IsZero() || death.timestamp.Before(start) {
+			start = death.timestamp
+		}
+		if death.timestamp.After(end) {
+			end = death.timestamp
+		}
 	}
 
 	count := len(sessions)
@@ -1576,9 +1764,25 @@ func (d *Daemon) emitMassDeathEvent() {
 
 	d.logger.Printf("MASS DEATH DETECTED: %d sessions died in %s: %v", count, window, sessions)
 
+	// Correlate with host, Dolt, tmux and quota signals to rank likely causes,
+	// then pick how to restart the survivors' work.
+	sig := d.collectDeathSignals(start, end)
+	causes := rankDeathCauses(sig)
+	strategy := strategyForCauses(causes)
+	possibleCause := ""
+	if len(causes) > 0 {
+		possibleCause = causes[0].Cause
+		for _, c := range causes {
+			d.logger.Printf("  possible cause %s (score %.2f): %s", c.Cause, c.Score, c.Evidence)
+		}
+	}
+	d.setRestartStrategy(strategy, strategyExpiry(strategy, sig, time.Now()))
+
 	// Emit feed event
-	_ = events.LogFeed(events.TypeMassDeath, "daemon",
-		events.MassDeathPayload(count, window, sessions, ""))
+	payload := events.MassDeathPayload(count, window, sessions, possibleCause)
+	payload["causes"] = causes
+	payload["restart_strategy"] = strategy
+	_ = events.LogFeed(events.TypeMassDeath, "daemon", payload)
 
 	// Clear the deaths to avoid repeated alerts
 	d.recentDeaths = nil
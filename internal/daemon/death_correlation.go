@@ -0,0 +1,358 @@
+package daemon
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/quota"
+)
+
+// Mass death causes ranked by the correlation engine.
+const (
+	CauseTmuxRestart = "tmux_server_restart"
+	CauseOOMKiller   = "oom_killer"
+	CauseDiskFull    = "disk_full"
+	CauseDoltDown    = "dolt_down"
+	CauseDoltRestart = "dolt_restart"
+	CauseDoltHealth  = "dolt_unhealthy"
+	CauseRateLimit   = "rate_limit"
+)
+
+// Restart strategies chosen after a mass death.
+const (
+	// StrategyNormal restarts crashed sessions as they are found.
+	StrategyNormal = "normal"
+	// StrategyWaitDolt holds restarts until the Dolt server is healthy again.
+	StrategyWaitDolt = "wait_dolt"
+	// StrategyStagger restarts at most one session per staggerInterval to
+	// avoid re-triggering memory pressure.
+	StrategyStagger = "stagger"
+	// StrategyHoldDisk holds restarts until disk space recovers.
+	StrategyHoldDisk = "hold_disk"
+	// StrategyDeferQuota holds restarts until the first rate-limited account
+	// resets (or the strategy expires when no reset time is known).
+	StrategyDeferQuota = "defer_quota"
+)
+
+const (
+	// correlationSlack widens the death window when matching signals, since
+	// the cause typically precedes the deaths the daemon observes.
+	correlationSlack = 2 * time.Minute
+
+	// minCauseScore is the minimum score for a cause to drive the strategy.
+	minCauseScore = 0.5
+
+	// strategyTTL bounds how long a non-normal strategy stays in force.
+	strategyTTL = 30 * time.Minute
+
+	// staggerInterval is the minimum gap between restarts under StrategyStagger.
+	staggerInterval = time.Minute
+
+	// diskFullFraction / diskLowFraction are free-space thresholds.
+	diskFullFraction = 0.02
+	diskLowFraction  = 0.05
+)
+
+// DeathCause is one candidate explanation for a mass death, with a
+// confidence score in [0,1] and human-readable evidence.
+type DeathCause struct {
+	Cause    string  `json:"cause"`
+	Score    float64 `json:"score"`
+	Evidence string  `json:"evidence"`
+}
+
+// deathSignals holds the host, Dolt, tmux and quota observations collected
+// around a mass death window. Zero values mean "no signal".
+type deathSignals struct {
+	windowStart time.Time
+	windowEnd   time.Time
+
+	tmuxServerStart time.Time
+
+	oomKills []string
+
+	diskFree float64 // fraction available, -1 if unknown
+
+	doltEnabled     bool
+	doltRunning     bool
+	doltHealthy     bool
+	doltWarnings    []string
+	doltRestarts    []time.Time
+	rateLimited     []string // account handles limited inside the window
+	rateLimitResets []string
+	quotaResetAt    time.Time // earliest parsed reset among rateLimited
+}
+
+// collectDeathSignals gathers correlation inputs for deaths in [start, end].
+// Every probe is best-effort; failures leave the signal empty.
+func (d *Daemon) collectDeathSignals(start, end time.Time) deathSignals {
+	sig := deathSignals{windowStart: start, windowEnd: end, diskFree: -1, doltHealthy: true}
+	from := start.Add(-correlationSlack)
+
+	if ts, err := d.tmux.ServerStartTime(); err == nil {
+		sig.tmuxServerStart = ts
+	}
+
+	sig.oomKills = readOOMKills(from, end)
+	sig.diskFree = diskFreeFraction(d.config.TownRoot)
+
+	if d.doltServer != nil && d.doltServer.IsEnabled() {
+		sig.doltEnabled = true
+		m := d.doltServer.Metrics()
+		sig.doltRunning = m.Running
+		for _, t := range m.RecentRestarts {
+			if !t.Before(from) && !t.After(end) {
+				sig.doltRestarts = append(sig.doltRestarts, t)
+			}
+		}
+		if m.Running {
+			h := doltserver.GetHealthMetrics(d.config.TownRoot)
+			sig.doltHealthy = h.Healthy
+			sig.doltWarnings = h.Warnings
+		}
+	}
+
+	if state, err := quota.NewManager(d.config.TownRoot).Load(); err == nil && state != nil {
+		for handle, acct := range state.Accounts {
+			if acct.Status != config.QuotaStatusLimited {
+				continue
+			}
+			limitedAt, err := time.Parse(time.RFC3339, acct.LimitedAt)
+			if err != nil || limitedAt.Before(from) || limitedAt.After(end) {
+				continue
+			}
+			sig.rateLimited = append(sig.rateLimited, handle)
+			if acct.ResetsAt != "" {
+				sig.rateLimitResets = append(sig.rateLimitResets, acct.ResetsAt)
+			}
+			if reset, err := time.Parse(time.RFC3339, acct.ResetTime); err == nil &&
+				(sig.quotaResetAt.IsZero() || reset.Before(sig.quotaResetAt)) {
+				sig.quotaResetAt = reset
+			}
+		}
+		sort.Strings(sig.rateLimited)
+	}
+
+	return sig
+}
+
+// rankDeathCauses scores each candidate cause against the collected signals,
+// most likely first. Causes with no supporting signal are omitted.
+func rankDeathCauses(sig deathSignals) []DeathCause {
+	var causes []DeathCause
+	from := sig.windowStart.Add(-correlationSlack)
+
+	// A tmux server restart kills every session at once; nothing else
+	// explains a mass death as completely.
+	if !sig.tmuxServerStart.IsZero() && !sig.tmuxServerStart.Before(from) && !sig.tmuxServerStart.After(sig.windowEnd) {
+		causes = append(causes, DeathCause{
+			Cause:    CauseTmuxRestart,
+			Score:    0.95,
+			Evidence: fmt.Sprintf("tmux server started at %s", sig.tmuxServerStart.Format(time.RFC3339)),
+		})
+	}
+
+	if n := len(sig.oomKills); n > 0 {
+		score := 0.7 + 0.1*float64(n)
+		if score > 0.9 {
+			score = 0.9
+		}
+		causes = append(causes, DeathCause{
+			Cause:    CauseOOMKiller,
+			Score:    score,
+			Evidence: fmt.Sprintf("%d OOM kill(s) in kernel log: %s", n, sig.oomKills[0]),
+		})
+	}
+
+	if sig.diskFree >= 0 && sig.diskFree < diskLowFraction {
+		score := 0.5
+		if sig.diskFree < diskFullFraction {
+			score = 0.85
+		}
+		causes = append(causes, DeathCause{
+			Cause:    CauseDiskFull,
+			Score:    score,
+			Evidence: fmt.Sprintf("%.1f%% disk free on town filesystem", sig.diskFree*100),
+		})
+	}
+
+	if sig.doltEnabled {
+		switch {
+		case !sig.doltRunning:
+			causes = append(causes, DeathCause{
+				Cause:    CauseDoltDown,
+				Score:    0.8,
+				Evidence: "Dolt server is not running",
+			})
+		case len(sig.doltRestarts) > 0:
+			causes = append(causes, DeathCause{
+				Cause:    CauseDoltRestart,
+				Score:    0.7,
+				Evidence: fmt.Sprintf("Dolt server restarted %d time(s) around the window", len(sig.doltRestarts)),
+			})
+		case !sig.doltHealthy:
+			causes = append(causes, DeathCause{
+				Cause:    CauseDoltHealth,
+				Score:    0.5,
+				Evidence: "Dolt server unhealthy: " + strings.Join(sig.doltWarnings, "; "),
+			})
+		}
+	}
+
+	if n := len(sig.rateLimited); n > 0 {
+		evidence := fmt.Sprintf("%d account(s) rate-limited in window: %s", n, strings.Join(sig.rateLimited, ", "))
+		if len(sig.rateLimitResets) > 0 {
+			evidence += " (resets " + sig.rateLimitResets[0] + ")"
+		}
+		causes = append(causes, DeathCause{
+			Cause:    CauseRateLimit,
+			Score:    0.6,
+			Evidence: evidence,
+		})
+	}
+
+	sort.SliceStable(causes, func(i, j int) bool {
+		return causes[i].Score > causes[j].Score
+	})
+	return causes
+}
+
+// strategyForCauses picks the restart strategy for the top-ranked cause.
+func strategyForCauses(causes []DeathCause) string {
+	if len(causes) == 0 || causes[0].Score < minCauseScore {
+		return StrategyNormal
+	}
+	switch causes[0].Cause {
+	case CauseDoltDown, CauseDoltRestart, CauseDoltHealth:
+		return StrategyWaitDolt
+	case CauseOOMKiller:
+		return StrategyStagger
+	case CauseDiskFull:
+		return StrategyHoldDisk
+	case CauseRateLimit:
+		return StrategyDeferQuota
+	default:
+		// tmux server restarts leave nothing to wait for: restart everything.
+		return StrategyNormal
+	}
+}
+
+// strategyExpiry returns when strategy lapses: the earliest account reset
+// for StrategyDeferQuota when one is known, otherwise strategyTTL from now.
+func strategyExpiry(strategy string, sig deathSignals, now time.Time) time.Time {
+	if strategy == StrategyDeferQuota && sig.quotaResetAt.After(now) {
+		return sig.quotaResetAt
+	}
+	return now.Add(strategyTTL)
+}
+
+// setRestartStrategy installs a restart strategy after a mass death, in
+// force until the given time. Only accessed from the heartbeat goroutine.
+func (d *Daemon) setRestartStrategy(strategy string, until time.Time) {
+	d.restartStrategy = strategy
+	d.restartStrategyUntil = until
+	if strategy != StrategyNormal {
+		d.logger.Printf("Restart strategy set to %s until %s", strategy, until.Format(time.RFC3339))
+	}
+}
+
+// allowCrashRestart reports whether a crashed session may be restarted now
+// under the current restart strategy, and why not if it may not.
+func (d *Daemon) allowCrashRestart() (bool, string) {
+	if d.restartStrategy == "" || d.restartStrategy == StrategyNormal {
+		return true, ""
+	}
+	if time.Now().After(d.restartStrategyUntil) {
+		d.logger.Printf("Restart strategy %s expired, resuming normal restarts", d.restartStrategy)
+		d.restartStrategy = StrategyNormal
+		return true, ""
+	}
+
+	switch d.restartStrategy {
+	case StrategyWaitDolt:
+		if d.doltServer == nil || !d.doltServer.IsEnabled() {
+			break
+		}
+		if !d.doltServer.Metrics().Running || !doltserver.GetHealthMetrics(d.config.TownRoot).Healthy {
+			return false, "waiting for Dolt server to recover after mass death"
+		}
+	case StrategyHoldDisk:
+		if free := diskFreeFraction(d.config.TownRoot); free >= 0 && free < diskLowFraction {
+			return false, fmt.Sprintf("holding restarts: %.1f%% disk free", free*100)
+		}
+	case StrategyDeferQuota:
+		return false, fmt.Sprintf("deferring restarts until rate limits reset at %s",
+			d.restartStrategyUntil.Format(time.RFC3339))
+	case StrategyStagger:
+		if since := time.Since(d.lastStaggeredRestart); since < staggerInterval {
+			return false, fmt.Sprintf("staggering restarts after OOM (next in %s)", (staggerInterval - since).Round(time.Second))
+		}
+		d.lastStaggeredRestart = time.Now()
+		return true, ""
+	}
+
+	// Condition cleared: resume normal restarts.
+	d.logger.Printf("Restart strategy %s condition cleared, resuming normal restarts", d.restartStrategy)
+	d.restartStrategy = StrategyNormal
+	return true, ""
+}
+
+var oomLineRe = regexp.MustCompile(`(?i)out of memory|oom-kill|oom_kill|killed process`)
+
+// readOOMKills returns kernel log lines reporting OOM kills in [from, to].
+// Linux only; tries dmesg first, then journalctl (dmesg may be restricted).
+func readOOMKills(from, to time.Time) []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	out, err := exec.Command("dmesg", "--time-format", "iso").Output()
+	if err != nil || len(out) == 0 {
+		out, err = exec.Command("journalctl", "-k", "--no-pager", "-o", "short-iso", //nolint:gosec // G204: args are constructed internally
+			"--since", "@"+fmt.Sprint(from.Unix())).Output()
+		if err != nil {
+			return nil
+		}
+	}
+	return parseOOMKills(string(out), from, to)
+}
+
+// kernelLogTimeLayouts covers dmesg --time-format iso and journalctl short-iso.
+var kernelLogTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999-07:00",
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+}
+
+// parseOOMKills extracts OOM kill lines within [from, to] from kernel log output.
+func parseOOMKills(out string, from, to time.Time) []string {
+	var kills []string
+	for _, line := range strings.Split(out, "\n") {
+		if !oomLineRe.MatchString(line) {
+			continue
+		}
+		tsField, rest, ok := strings.Cut(strings.TrimSpace(line), " ")
+		if !ok {
+			continue
+		}
+		tsField = strings.Replace(tsField, ",", ".", 1)
+		var ts time.Time
+		for _, layout := range kernelLogTimeLayouts {
+			if t, err := time.Parse(layout, tsField); err == nil {
+				ts = t
+				break
+			}
+		}
+		if ts.IsZero() || ts.Before(from) || ts.After(to) {
+			continue
+		}
+		kills = append(kills, strings.TrimSpace(rest))
+	}
+	return kills
+}
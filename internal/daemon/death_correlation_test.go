@@ -0,0 +1,148 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRankDeathCauses(t *testing.T) {
+	end := time.Date(2026, 1, 15, 3, 0, 30, 0, time.UTC)
+	start := end.Add(-20 * time.Second)
+
+	tests := []struct {
+		name     string
+		sig      deathSignals
+		wantTop  string
+		wantStrt string
+	}{
+		{
+			name:     "no signals",
+			sig:      deathSignals{windowStart: start, windowEnd: end, diskFree: 0.5, doltHealthy: true},
+			wantTop:  "",
+			wantStrt: StrategyNormal,
+		},
+		{
+			name: "tmux restart beats dolt",
+			sig: deathSignals{windowStart: start, windowEnd: end, diskFree: 0.5,
+				tmuxServerStart: start.Add(-time.Second), doltEnabled: true, doltRunning: false},
+			wantTop:  CauseTmuxRestart,
+			wantStrt: StrategyNormal,
+		},
+		{
+			name: "old tmux server is not a cause",
+			sig: deathSignals{windowStart: start, windowEnd: end, diskFree: 0.5,
+				tmuxServerStart: start.Add(-time.Hour), doltEnabled: true, doltRunning: false},
+			wantTop:  CauseDoltDown,
+			wantStrt: StrategyWaitDolt,
+		},
+		{
+			name: "oom",
+			sig: deathSignals{windowStart: start, windowEnd: end, diskFree: 0.5, doltHealthy: true,
+				oomKills: []string{"Out of memory: Killed process 123 (claude)"}},
+			wantTop:  CauseOOMKiller,
+			wantStrt: StrategyStagger,
+		},
+		{
+			name:     "disk full",
+			sig:      deathSignals{windowStart: start, windowEnd: end, diskFree: 0.01, doltHealthy: true},
+			wantTop:  CauseDiskFull,
+			wantStrt: StrategyHoldDisk,
+		},
+		{
+			name: "rate limit",
+			sig: deathSignals{windowStart: start, windowEnd: end, diskFree: 0.5, doltHealthy: true,
+				rateLimited: []string{"work"}},
+			wantTop:  CauseRateLimit,
+			wantStrt: StrategyDeferQuota,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			causes := rankDeathCauses(tt.sig)
+			top := ""
+			if len(causes) > 0 {
+				top = causes[0].Cause
+			}
+			if top != tt.wantTop {
+				t.Errorf("top cause = %q, want %q (all: %+v)", top, tt.wantTop, causes)
+			}
+			for i := 1; i < len(causes); i++ {
+				if causes[i].Score > causes[i-1].Score {
+					t.Errorf("causes not sorted by score: %+v", causes)
+				}
+			}
+			if got := strategyForCauses(causes); got != tt.wantStrt {
+				t.Errorf("strategy = %q, want %q", got, tt.wantStrt)
+			}
+		})
+	}
+}
+
+func TestParseOOMKills(t *testing.T) {
+	from := time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC)
+	to := from.Add(time.Minute)
+	out := `2026-01-15T02:50:00,000000+00:00 Out of memory: Killed process 1 (old)
+2026-01-15T03:00:10,123456+00:00 Out of memory: Killed process 4242 (claude)
+2026-01-15T03:00:11,000000+00:00 usb 1-1: new device
+2026-01-15T03:00:20+0000 host kernel: oom-kill:constraint=CONSTRAINT_NONE`
+
+	kills := parseOOMKills(out, from, to)
+	if len(kills) != 2 {
+		t.Fatalf("got %d kills, want 2: %v", len(kills), kills)
+	}
+	if kills[0] != "Out of memory: Killed process 4242 (claude)" {
+		t.Errorf("kills[0] = %q", kills[0])
+	}
+}
+
+func TestStrategyExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 15, 3, 0, 0, 0, time.UTC)
+	reset := now.Add(3 * time.Hour)
+	sig := deathSignals{quotaResetAt: reset}
+
+	if got := strategyExpiry(StrategyDeferQuota, sig, now); !got.Equal(reset) {
+		t.Errorf("defer_quota expiry = %v, want account reset %v", got, reset)
+	}
+	if got := strategyExpiry(StrategyDeferQuota, deathSignals{}, now); !got.Equal(now.Add(strategyTTL)) {
+		t.Errorf("defer_quota without reset = %v, want TTL", got)
+	}
+	if got := strategyExpiry(StrategyDeferQuota, deathSignals{quotaResetAt: now.Add(-time.Minute)}, now); !got.Equal(now.Add(strategyTTL)) {
+		t.Errorf("defer_quota with past reset = %v, want TTL", got)
+	}
+	if got := strategyExpiry(StrategyWaitDolt, sig, now); !got.Equal(now.Add(strategyTTL)) {
+		t.Errorf("wait_dolt expiry = %v, want TTL", got)
+	}
+}
+
+func TestMarkDeathRecorded(t *testing.T) {
+	townRoot := t.TempDir()
+	d := &Daemon{config: &Config{TownRoot: townRoot}}
+	const sess = "gt-Toast"
+
+	if !d.markDeathRecorded(sess) {
+		t.Fatal("first sighting of a death not recorded")
+	}
+	if d.markDeathRecorded(sess) {
+		t.Error("same death recorded twice")
+	}
+
+	// A new incarnation of the session is a new death.
+	pids := filepath.Join(townRoot, ".runtime", "pids")
+	if err := os.MkdirAll(pids, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pids, sess+".pid"), []byte("4242|Thu Jan 15 03:00:00 2026\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !d.markDeathRecorded(sess) {
+		t.Error("death of a new incarnation not recorded")
+	}
+
+	// After a restart, the next death counts even without a tracked PID.
+	d.clearRecordedDeath(sess)
+	if !d.markDeathRecorded(sess) {
+		t.Error("death after restart not recorded")
+	}
+}
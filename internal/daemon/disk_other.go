@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package daemon
+
+// diskFreeFraction is not implemented on this platform.
+func diskFreeFraction(path string) float64 {
+	return -1
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+
+package daemon
+
+import "syscall"
+
+// diskFreeFraction returns the fraction of the filesystem holding path that
+// is available to unprivileged users, or -1 if it cannot be determined.
+func diskFreeFraction(path string) float64 {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil || st.Blocks == 0 {
+		return -1
+	}
+	return float64(st.Bavail) / float64(st.Blocks)
+}
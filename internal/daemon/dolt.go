@@ -51,6 +51,10 @@ type DoltServerConfig struct {
 	// LogFile is the path to the Dolt server log file.
 	LogFile string `json:"log_file,omitempty"`
 
+	// MaxConnections is the maximum number of simultaneous connections the
+	// server accepts (default doltserver.DefaultMaxConnections).
+	MaxConnections int `json:"max_connections,omitempty"`
+
 	// AutoRestart controls whether to restart on crash.
 	AutoRestart bool `json:"auto_restart,omitempty"`
 
@@ -76,6 +80,11 @@ type DoltServerConfig struct {
 	// detection of Dolt server crashes without changing the overall
 	// heartbeat frequency. Default 30s.
 	HealthCheckInterval time.Duration `json:"health_check_interval,omitempty"`
+
+	// Standby configures an optional hot standby kept in sync by Dolt
+	// cluster replication. When the primary fails Standby.FailoverAfter
+	// consecutive health checks, the daemon promotes the standby.
+	Standby *doltserver.StandbyConfig `json:"standby,omitempty"`
 }
 
 // DefaultDoltServerConfig returns sensible defaults for Dolt server config.
@@ -87,6 +96,7 @@ func DefaultDoltServerConfig(townRoot string) *DoltServerConfig {
 		User:                 "root",
 		DataDir:              filepath.Join(townRoot, "dolt"),
 		LogFile:              filepath.Join(townRoot, "daemon", "dolt-server.log"),
+		MaxConnections:       doltserver.DefaultMaxConnections,
 		AutoRestart:          true,
 		RestartDelay:         5 * time.Second,
 		MaxRestartDelay:      5 * time.Minute,
@@ -130,6 +140,9 @@ type DoltServerManager struct {
 	// Identity verification state
 	lastIdentityCheck time.Time // Last time we ran the database identity check
 
+	// Failover state
+	primaryFailures int // Consecutive failed primary health checks
+
 	// Test hooks (nil = use real implementations; set only in tests)
 	healthCheckFn      func() error
 	writeProbeCheckFn  func() error
@@ -144,6 +157,10 @@ type DoltServerManager struct {
 	readOnlyAlertFn    func(error)
 	crashAlertFn       func(int)
 	listDatabasesFn    func() ([]string, error)
+	standbyStartFn     func() error
+	promoteFn          func(reason string) error
+	failbackFn         func() error
+	failoverAlertFn    func(reason string)
 }
 
 // NewDoltServerManager creates a new Dolt server manager.
@@ -178,6 +195,15 @@ func (m *DoltServerManager) pidFile() string {
 	return filepath.Join(m.townRoot, "daemon", "dolt.pid")
 }
 
+// maxConnections returns the configured connection limit, or the default
+// when the config leaves it unset.
+func (m *DoltServerManager) maxConnections() int {
+	if m.config.MaxConnections > 0 {
+		return m.config.MaxConnections
+	}
+	return doltserver.DefaultMaxConnections
+}
+
 // IsEnabled returns whether Dolt server management is enabled.
 func (m *DoltServerManager) IsEnabled() bool {
 	return m.config != nil && m.config.Enabled
@@ -278,6 +304,32 @@ func (m *DoltServerManager) Status() *DoltServerStatus {
 	return status
 }
 
+// DoltServerMetrics is a snapshot of the manager's lifecycle bookkeeping,
+// used to correlate agent deaths with Dolt server trouble.
+type DoltServerMetrics struct {
+	Running        bool        `json:"running"`
+	StartedAt      time.Time   `json:"started_at,omitempty"`
+	LastHealthy    time.Time   `json:"last_healthy,omitempty"`
+	RecentRestarts []time.Time `json:"recent_restarts,omitempty"`
+	Escalated      bool        `json:"escalated"`
+}
+
+// Metrics returns a snapshot of the manager's restart and health state
+// without running any health checks.
+func (m *DoltServerManager) Metrics() DoltServerMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, running := m.isRunning()
+	return DoltServerMetrics{
+		Running:        running,
+		StartedAt:      m.startedAt,
+		LastHealthy:    m.lastHealthyTime,
+		RecentRestarts: append([]time.Time(nil), m.restartTimes...),
+		Escalated:      m.escalated,
+	}
+}
+
 // isRunning checks if the Dolt server process is running.
 // Must be called with m.mu held.
 func (m *DoltServerManager) isRunning() (int, bool) {
@@ -362,6 +414,8 @@ func (m *DoltServerManager) EnsureRunning() error {
 		return nil
 	}
 
+	m.ensureStandbyLocked()
+
 	pid, running := m.isRunning()
 	if running {
 		// Already running, check health
@@ -370,9 +424,18 @@ func (m *DoltServerManager) EnsureRunning() error {
 			m.logger("Dolt server unhealthy: %v, restarting...", err)
 			m.sendUnhealthyAlert(err)
 			m.writeUnhealthySignal("health_check_failed", err.Error())
+			m.recordPrimaryFailureLocked(err.Error())
 			m.stopLocked()
 			return m.restartWithBackoff()
 		}
+		// While failed over the primary is a cluster standby, which is
+		// read-only by design: skip the write probe and watch for fail-back.
+		if m.standbyEnabled() && m.failedOver() {
+			m.checkFailedOverLocked()
+			m.clearUnhealthySignal()
+			m.maybeResetBackoff()
+			return nil
+		}
 		// Check write capability (read-only detection).
 		// The SELECT 1 health check above only verifies read connectivity.
 		// Under concurrent write load, Dolt can enter a persistent read-only
@@ -381,6 +444,7 @@ func (m *DoltServerManager) EnsureRunning() error {
 			m.logger("Dolt server read-only: %v, restarting...", err)
 			m.sendReadOnlyAlert(err)
 			m.writeUnhealthySignal("read_only", err.Error())
+			m.recordPrimaryFailureLocked(err.Error())
 			m.stopLocked()
 			return m.restartWithBackoff()
 		}
@@ -405,6 +469,7 @@ func (m *DoltServerManager) EnsureRunning() error {
 		}
 
 		// Server is healthy — clear any stale unhealthy signal and reset backoff
+		m.primaryFailures = 0
 		m.clearUnhealthySignal()
 		m.maybeResetBackoff()
 		return nil
@@ -415,6 +480,7 @@ func (m *DoltServerManager) EnsureRunning() error {
 		m.logger("Dolt server PID %d is dead, cleaning up and restarting...", pid)
 		m.sendCrashAlert(pid)
 		m.writeUnhealthySignal("server_dead", fmt.Sprintf("PID %d is dead", pid))
+		m.recordPrimaryFailureLocked(fmt.Sprintf("PID %d is dead", pid))
 	}
 	return m.restartWithBackoff()
 }
@@ -757,7 +823,9 @@ func (m *DoltServerManager) startLocked() error {
 		"--host", m.config.Host,
 		"--port", strconv.Itoa(m.config.Port),
 		"--data-dir", m.config.DataDir,
+		"--max-connections", strconv.Itoa(m.maxConnections()),
 	}
+	args = m.clusterArgs(args)
 
 	// Open log file
 	logFile, err := os.OpenFile(m.config.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
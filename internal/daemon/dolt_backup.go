@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/doltserver"
+)
+
+const defaultDoltBackupInterval = time.Hour
+
+// doltBackupInterval returns the configured snapshot interval, or the default (1h).
+func doltBackupInterval(config *DaemonPatrolConfig) time.Duration {
+	if config != nil && config.Patrols != nil && config.Patrols.DoltBackup != nil {
+		if config.Patrols.DoltBackup.Interval > 0 {
+			return config.Patrols.DoltBackup.Interval
+		}
+	}
+	return defaultDoltBackupInterval
+}
+
+// DoltBackupDir returns the backup root configured for the dolt_backup patrol,
+// or the default <town>/.dolt-backups. Used by gt dolt backup as well, so the
+// CLI reads the same snapshots the daemon writes.
+func DoltBackupDir(townRoot string, config *DaemonPatrolConfig) string {
+	if config != nil && config.Patrols != nil && config.Patrols.DoltBackup != nil {
+		if dir := config.Patrols.DoltBackup.Dir; dir != "" {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(townRoot, dir)
+			}
+			return dir
+		}
+	}
+	return doltserver.DefaultBackupDir(townRoot)
+}
+
+// doltBackupRetention returns the configured retention tiers, filling unset
+// tiers from doltserver.DefaultBackupRetention.
+func doltBackupRetention(config *DaemonPatrolConfig) doltserver.BackupRetention {
+	keep := doltserver.DefaultBackupRetention
+	if config == nil || config.Patrols == nil || config.Patrols.DoltBackup == nil || config.Patrols.DoltBackup.Retention == nil {
+		return keep
+	}
+	r := config.Patrols.DoltBackup.Retention
+	if r.Hourly > 0 {
+		keep.Hourly = r.Hourly
+	}
+	if r.Daily > 0 {
+		keep.Daily = r.Daily
+	}
+	if r.Weekly > 0 {
+		keep.Weekly = r.Weekly
+	}
+	return keep
+}
+
+// backupDolt takes a snapshot of every Dolt database and prunes snapshots
+// that fall outside the retention tiers.
+// Non-fatal: errors are logged but don't stop the patrol.
+func (d *Daemon) backupDolt() {
+	if !IsPatrolEnabled(d.patrolConfig, "dolt_backup") {
+		return
+	}
+
+	if d.doltServer == nil || !d.doltServer.IsEnabled() {
+		d.logger.Printf("dolt_backup: dolt server not configured, skipping")
+		return
+	}
+
+	townRoot := d.config.TownRoot
+	dir := DoltBackupDir(townRoot, d.patrolConfig)
+
+	snap, err := doltserver.CreateSnapshot(townRoot, dir, time.Now())
+	if err != nil {
+		d.logger.Printf("dolt_backup: snapshot failed: %v", err)
+		return
+	}
+	d.logger.Printf("dolt_backup: snapshot %s: %d database(s) backed up to %s", snap.ID, len(snap.Databases), snap.Path)
+
+	removed, err := doltserver.PruneSnapshots(dir, doltBackupRetention(d.patrolConfig), time.Now())
+	if err != nil {
+		d.logger.Printf("dolt_backup: prune failed: %v", err)
+	}
+	if len(removed) > 0 {
+		d.logger.Printf("dolt_backup: pruned %d snapshot(s)", len(removed))
+	}
+}
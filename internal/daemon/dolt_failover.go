@@ -0,0 +1,204 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/doltserver"
+)
+
+// standbyEnabled reports whether a hot standby is configured.
+func (m *DoltServerManager) standbyEnabled() bool {
+	return m.config != nil && m.config.Standby != nil && m.config.Standby.Enabled
+}
+
+// standbyConfig returns the standby config with defaults applied.
+func (m *DoltServerManager) standbyConfig() doltserver.StandbyConfig {
+	return m.config.Standby.WithDefaults(m.townRoot)
+}
+
+// primaryEndpoint returns the managed server's SQL endpoint.
+func (m *DoltServerManager) primaryEndpoint() doltserver.Endpoint {
+	return doltserver.Endpoint{
+		Host:     m.config.Host,
+		Port:     m.config.Port,
+		User:     m.config.User,
+		Password: m.config.Password,
+	}
+}
+
+// failedOver reports whether the standby is currently the active server.
+func (m *DoltServerManager) failedOver() bool {
+	active, err := doltserver.LoadActiveServer(m.townRoot)
+	return err == nil && active != nil
+}
+
+// ensureStandbyLocked starts the local standby if it is not listening.
+// A remote standby is managed elsewhere. Must be called with m.mu held.
+func (m *DoltServerManager) ensureStandbyLocked() {
+	if !m.standbyEnabled() {
+		return
+	}
+	if m.standbyStartFn != nil {
+		if err := m.standbyStartFn(); err != nil {
+			m.logger("Warning: failed to start Dolt standby: %v", err)
+		}
+		return
+	}
+	standby := m.standbyConfig()
+	if !standby.IsLocal() {
+		return
+	}
+	if err := doltserver.StartLocalStandby(m.townRoot, standby); err != nil {
+		m.logger("Warning: failed to start Dolt standby: %v", err)
+	}
+}
+
+// recordPrimaryFailureLocked counts a failed primary health check and
+// promotes the standby once FailoverAfter consecutive checks have failed.
+// The caller still restarts the primary, which rejoins as a standby.
+// Must be called with m.mu held.
+func (m *DoltServerManager) recordPrimaryFailureLocked(reason string) {
+	if !m.standbyEnabled() || m.failedOver() {
+		return
+	}
+	m.primaryFailures++
+	threshold := m.standbyConfig().FailoverAfter
+	if m.primaryFailures < threshold {
+		m.logger("Dolt primary failure %d/%d before failover: %s", m.primaryFailures, threshold, reason)
+		return
+	}
+
+	m.logger("Dolt primary failed %d consecutive health checks, promoting standby: %s", m.primaryFailures, reason)
+	if err := m.promote(reason); err != nil {
+		m.logger("Dolt failover failed: %v", err)
+		return
+	}
+	m.primaryFailures = 0
+	m.sendFailoverAlert(reason)
+}
+
+// promote runs the failover. Must be called with m.mu held.
+func (m *DoltServerManager) promote(reason string) error {
+	if m.promoteFn != nil {
+		return m.promoteFn(reason)
+	}
+	standby := m.standbyConfig()
+	active, err := doltserver.Promote(m.townRoot, m.primaryEndpoint(), standby.Endpoint(), reason, m.now())
+	if active != nil {
+		m.logger("Dolt standby %s:%d promoted to primary at epoch %d", active.Host, active.Port, active.Epoch)
+	}
+	return err
+}
+
+// checkFailedOverLocked runs on a healthy primary while the standby is
+// active. It fences the primary to the standby role if it came back as
+// primary, and fails back when auto-failback is on and the primary has
+// caught up. Must be called with m.mu held.
+func (m *DoltServerManager) checkFailedOverLocked() {
+	if m.failbackFn != nil {
+		if err := m.failbackFn(); err != nil {
+			m.logger("Dolt fail-back not done: %v", err)
+		}
+		return
+	}
+	active, err := doltserver.LoadActiveServer(m.townRoot)
+	if err != nil || active == nil {
+		return
+	}
+	primary := m.primaryEndpoint()
+	if role, epoch, err := doltserver.ClusterRole(primary); err == nil && role == doltserver.ClusterRolePrimary && epoch <= active.Epoch {
+		m.logger("Fencing restarted Dolt primary to standby at epoch %d", active.Epoch)
+		if err := doltserver.AssumeClusterRole(primary, doltserver.ClusterRoleStandby, active.Epoch); err != nil {
+			m.logger("Warning: failed to fence Dolt primary: %v", err)
+		}
+		return
+	}
+
+	standby := m.standbyConfig()
+	if !standby.AutoFailback {
+		return
+	}
+	current := doltserver.Endpoint{Host: active.Host, Port: active.Port, User: standby.User, Password: standby.Password}
+	if ready, why := doltserver.FailbackReady(primary, current); !ready {
+		m.logger("Dolt fail-back pending: %s", why)
+		return
+	}
+	if err := doltserver.Failback(m.townRoot, primary, current); err != nil {
+		m.logger("Dolt fail-back failed: %v", err)
+		return
+	}
+	m.logger("Dolt failed back to primary %s:%d", primary.Host, primary.Port)
+}
+
+// sendFailoverAlert tells the mayor and witnesses the standby took over.
+// Runs asynchronously.
+func (m *DoltServerManager) sendFailoverAlert(reason string) {
+	if m.failoverAlertFn != nil {
+		m.failoverAlertFn(reason)
+		return
+	}
+	standby := m.standbyConfig()
+	subject := "ALERT: Dolt failed over to standby"
+	body := fmt.Sprintf(`The Dolt primary failed %d consecutive health checks and the standby
+has been promoted. Beads consumers now use the standby.
+
+Reason: %s
+
+Primary: %s:%d
+Standby: %s
+
+The daemon keeps restarting the primary; it rejoins as a standby. Once it
+has caught up, fail back with: gt dolt failover back`,
+		standby.FailoverAfter, reason,
+		m.config.Host, m.config.Port,
+		standby.Endpoint().HostPort())
+
+	townRoot := m.townRoot
+	logger := m.logger
+
+	go func() {
+		sendDoltAlertMail(townRoot, "mayor/", subject, body, logger)
+		sendDoltAlertToWitnesses(townRoot, subject, body, logger)
+	}()
+}
+
+// clusterArgs returns the sql-server arguments for a primary with a standby:
+// the generated cluster config replaces the flags. Falls back to flagArgs if
+// the config cannot be written.
+func (m *DoltServerManager) clusterArgs(flagArgs []string) []string {
+	if !m.standbyEnabled() {
+		doltserver.RemoveClusterConfigs(m.townRoot)
+		return flagArgs
+	}
+	if err := doltserver.WriteClusterConfigs(m.townRoot, m.config.Host, m.config.Port, m.config.DataDir, m.maxConnections(), *m.config.Standby); err != nil {
+		m.logger("Warning: failed to write Dolt cluster config, starting without replication: %v", err)
+		return flagArgs
+	}
+	return doltserver.ServerArgs(m.townRoot, flagArgs)
+}
+
+// DoltStandbyConfig returns the configured hot standby with defaults applied,
+// and the primary it replicates from. ok is false when no standby is enabled.
+func DoltStandbyConfig(townRoot string, config *DaemonPatrolConfig) (primary doltserver.Endpoint, standby doltserver.StandbyConfig, ok bool) {
+	if config == nil || config.Patrols == nil || config.Patrols.DoltServer == nil {
+		return primary, standby, false
+	}
+	server := config.Patrols.DoltServer
+	if server.Standby == nil || !server.Standby.Enabled {
+		return primary, standby, false
+	}
+	primary = doltserver.PrimaryEndpoint(doltserver.DefaultConfig(townRoot))
+	if server.Host != "" {
+		primary.Host = server.Host
+	}
+	if server.Port != 0 {
+		primary.Port = server.Port
+	}
+	if server.User != "" {
+		primary.User = server.User
+	}
+	if server.Password != "" {
+		primary.Password = server.Password
+	}
+	return primary, server.Standby.WithDefaults(townRoot), true
+}
@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/doltserver"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// newFailoverTestManager returns a test manager with a standby configured
+// and a primary that is running but failing health checks.
+func newFailoverTestManager(t *testing.T, healthy *bool) *DoltServerManager {
+	t.Helper()
+	m := newTestManager(t)
+	m.config.Standby = &doltserver.StandbyConfig{Enabled: true, FailoverAfter: 3}
+	m.runningFn = func() (int, bool) { return 1234, true }
+	m.healthCheckFn = func() error {
+		if *healthy {
+			return nil
+		}
+		return fmt.Errorf("connection refused")
+	}
+	m.writeProbeCheckFn = func() error { return nil }
+	m.sleepFn = func(time.Duration) {}
+	m.standbyStartFn = func() error { return nil }
+	m.failoverAlertFn = func(string) {}
+	return m
+}
+
+func TestEnsureRunning_FailoverAfterConsecutiveFailures(t *testing.T) {
+	healthy := false
+	m := newFailoverTestManager(t, &healthy)
+	var promoted []string
+	m.promoteFn = func(reason string) error {
+		promoted = append(promoted, reason)
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_ = m.EnsureRunning()
+	}
+	if len(promoted) != 0 {
+		t.Fatalf("promoted after 2 failures, want threshold of 3")
+	}
+	_ = m.EnsureRunning()
+	if len(promoted) != 1 {
+		t.Fatalf("expected promotion on 3rd consecutive failure, got %d", len(promoted))
+	}
+	if m.primaryFailures != 0 {
+		t.Errorf("failure count should reset after failover, got %d", m.primaryFailures)
+	}
+}
+
+func TestEnsureRunning_HealthyCheckResetsFailoverCount(t *testing.T) {
+	healthy := false
+	m := newFailoverTestManager(t, &healthy)
+	m.promoteFn = func(string) error {
+		t.Fatal("failures interrupted by a healthy check must not fail over")
+		return nil
+	}
+
+	_ = m.EnsureRunning()
+	_ = m.EnsureRunning()
+	healthy = true
+	_ = m.EnsureRunning()
+	healthy = false
+	_ = m.EnsureRunning()
+	_ = m.EnsureRunning()
+}
+
+func TestEnsureRunning_NoFailoverWithoutStandby(t *testing.T) {
+	healthy := false
+	m := newFailoverTestManager(t, &healthy)
+	m.config.Standby = nil
+	m.promoteFn = func(string) error {
+		t.Fatal("promoted without a standby configured")
+		return nil
+	}
+	for i := 0; i < 5; i++ {
+		_ = m.EnsureRunning()
+	}
+}
+
+func TestEnsureRunning_FailedOverSkipsWriteProbe(t *testing.T) {
+	healthy := true
+	m := newFailoverTestManager(t, &healthy)
+	if err := util.EnsureDirAndWriteJSON(doltserver.ActiveServerFile(m.townRoot),
+		&doltserver.ActiveServer{Host: "127.0.0.1", Port: 3308, Epoch: 2}); err != nil {
+		t.Fatal(err)
+	}
+	// The old primary rejoined as a standby, which is read-only.
+	m.writeProbeCheckFn = func() error { return fmt.Errorf("dolt server is in read-only mode") }
+	var failbackChecks int
+	m.failbackFn = func() error {
+		failbackChecks++
+		return nil
+	}
+	m.stopFn = func() { t.Fatal("read-only standby must not be restarted") }
+
+	if err := m.EnsureRunning(); err != nil {
+		t.Fatalf("EnsureRunning: %v", err)
+	}
+	if failbackChecks != 1 {
+		t.Errorf("expected one fail-back check, got %d", failbackChecks)
+	}
+}
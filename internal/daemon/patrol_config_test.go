@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/steveyegge/gastown/internal/doltserver"
 )
 
 func TestLoadPatrolConfig(t *testing.T) {
@@ -98,3 +100,54 @@ func TestDoltRemotesInterval(t *testing.T) {
 		t.Errorf("expected 5m interval, got %v", got)
 	}
 }
+
+func TestIsPatrolEnabled_DoltBackup(t *testing.T) {
+	// dolt_backup is opt-in like dolt_remotes
+	if IsPatrolEnabled(nil, "dolt_backup") {
+		t.Error("expected dolt_backup to be disabled with nil config")
+	}
+
+	config := &DaemonPatrolConfig{Patrols: &PatrolsConfig{}}
+	if IsPatrolEnabled(config, "dolt_backup") {
+		t.Error("expected dolt_backup to be disabled by default")
+	}
+
+	config.Patrols.DoltBackup = &DoltBackupConfig{Enabled: true}
+	if !IsPatrolEnabled(config, "dolt_backup") {
+		t.Error("expected dolt_backup to be enabled when configured")
+	}
+}
+
+func TestDoltBackupSettings(t *testing.T) {
+	townRoot := filepath.Join(string(filepath.Separator), "town")
+
+	// Defaults
+	if got := doltBackupInterval(nil); got != defaultDoltBackupInterval {
+		t.Errorf("expected default interval %v, got %v", defaultDoltBackupInterval, got)
+	}
+	if got := DoltBackupDir(townRoot, nil); got != filepath.Join(townRoot, ".dolt-backups") {
+		t.Errorf("expected default backup dir, got %s", got)
+	}
+	if got := doltBackupRetention(nil); got != doltserver.DefaultBackupRetention {
+		t.Errorf("expected default retention, got %+v", got)
+	}
+
+	// Relative dir resolves against the town root; unset tiers keep defaults.
+	config := &DaemonPatrolConfig{
+		Patrols: &PatrolsConfig{
+			DoltBackup: &DoltBackupConfig{
+				Enabled:   true,
+				Dir:       "backups",
+				Retention: &doltserver.BackupRetention{Daily: 30},
+			},
+		},
+	}
+	if got := DoltBackupDir(townRoot, config); got != filepath.Join(townRoot, "backups") {
+		t.Errorf("expected relative dir under town root, got %s", got)
+	}
+	want := doltserver.DefaultBackupRetention
+	want.Daily = 30
+	if got := doltBackupRetention(config); got != want {
+		t.Errorf("retention = %+v, want %+v", got, want)
+	}
+}
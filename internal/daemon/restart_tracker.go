@@ -27,6 +27,13 @@ type AgentRestartInfo struct {
 	RestartCount   int       `json:"restart_count"`
 	BackoffUntil   time.Time `json:"backoff_until"`
 	CrashLoopSince time.Time `json:"crash_loop_since,omitempty"`
+
+	// LastCrashSignature is the signature of the most recent crash report
+	// (see CrashSignature). SameSignatureCount counts consecutive crashes
+	// with that signature, distinguishing a deterministic crash loop from
+	// unrelated failures.
+	LastCrashSignature string `json:"last_crash_signature,omitempty"`
+	SameSignatureCount int    `json:"same_signature_count,omitempty"`
 }
 
 // Backoff parameters
@@ -189,6 +196,35 @@ func (rt *RestartTracker) GetBackoffRemaining(agentID string) time.Duration {
 	return remaining
 }
 
+// RecordCrash records the signature of a captured crash for an agent.
+func (rt *RestartTracker) RecordCrash(agentID, signature string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	info, exists := rt.state.Agents[agentID]
+	if !exists {
+		info = &AgentRestartInfo{}
+		rt.state.Agents[agentID] = info
+	}
+	if info.LastCrashSignature == signature {
+		info.SameSignatureCount++
+	} else {
+		info.LastCrashSignature = signature
+		info.SameSignatureCount = 1
+	}
+}
+
+// Info returns a copy of the restart info for an agent (zero value if untracked).
+func (rt *RestartTracker) Info(agentID string) AgentRestartInfo {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+
+	if info, exists := rt.state.Agents[agentID]; exists {
+		return *info
+	}
+	return AgentRestartInfo{}
+}
+
 // ClearCrashLoop manually clears the crash loop state for an agent.
 func (rt *RestartTracker) ClearCrashLoop(agentID string) {
 	rt.mu.Lock()
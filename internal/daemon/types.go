@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/steveyegge/gastown/internal/doltserver"
 	"github.com/steveyegge/gastown/internal/util"
 )
 
@@ -119,6 +120,7 @@ type PatrolsConfig struct {
 	Handler     *PatrolConfig      `json:"handler,omitempty"`
 	DoltServer  *DoltServerConfig  `json:"dolt_server,omitempty"`
 	DoltRemotes *DoltRemotesConfig `json:"dolt_remotes,omitempty"`
+	DoltBackup  *DoltBackupConfig  `json:"dolt_backup,omitempty"`
 }
 
 // DoltRemotesConfig holds configuration for the dolt_remotes patrol.
@@ -141,6 +143,25 @@ type DoltRemotesConfig struct {
 	Branch string `json:"branch,omitempty"`
 }
 
+// DoltBackupConfig holds configuration for the dolt_backup patrol.
+// This patrol periodically snapshots every Dolt database to a backup directory
+// and prunes old snapshots by retention tier.
+type DoltBackupConfig struct {
+	// Enabled controls whether scheduled backups run.
+	Enabled bool `json:"enabled"`
+
+	// Interval is how often to take a snapshot (default 1h).
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// Dir is the backup root, a local or mounted path. Relative paths are
+	// resolved against the town root (default <town>/.dolt-backups).
+	Dir string `json:"dir,omitempty"`
+
+	// Retention is how many hourly, daily and weekly snapshots to keep.
+	// Zero tiers fall back to the defaults (24 hourly, 7 daily, 4 weekly).
+	Retention *doltserver.BackupRetention `json:"retention,omitempty"`
+}
+
 // DaemonPatrolConfig is the structure of mayor/daemon.json.
 type DaemonPatrolConfig struct {
 	Type      string         `json:"type"`
@@ -172,7 +193,7 @@ func LoadPatrolConfig(townRoot string) *DaemonPatrolConfig {
 
 // IsPatrolEnabled checks if a patrol is enabled in the config.
 // Returns true if the config doesn't exist (default enabled for backwards compatibility).
-// Exception: opt-in patrols (dolt_remotes) default to disabled.
+// Exception: opt-in patrols (dolt_remotes, dolt_backup) default to disabled.
 func IsPatrolEnabled(config *DaemonPatrolConfig, patrol string) bool {
 	// Opt-in patrols: disabled unless explicitly enabled in config.
 	// Must check before the nil-config fallback, otherwise nil config
@@ -183,6 +204,12 @@ func IsPatrolEnabled(config *DaemonPatrolConfig, patrol string) bool {
 		}
 		return config.Patrols.DoltRemotes.Enabled
 	}
+	if patrol == "dolt_backup" {
+		if config == nil || config.Patrols == nil || config.Patrols.DoltBackup == nil {
+			return false
+		}
+		return config.Patrols.DoltBackup.Enabled
+	}
 
 	if config == nil || config.Patrols == nil {
 		return true // Default: enabled
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// reconcileWorktreePools tops up the warm polecat worktree pool of every
+// operational rig that has one. Each rig's reconcile runs as a background
+// `gt polecat pool reconcile` so slow setup hooks never hold up the
+// heartbeat; the per-rig reconcile lock makes overlapping runs no-ops.
+func (d *Daemon) reconcileWorktreePools() {
+	for _, rigName := range d.getKnownRigs() {
+		settingsPath := filepath.Join(d.config.TownRoot, rigName, "settings", "config.json")
+		settings, err := config.LoadRigSettings(settingsPath)
+		if err != nil || settings.WorktreePool == nil || settings.WorktreePool.Size <= 0 {
+			continue
+		}
+		if ok, _ := d.isRigOperational(rigName); !ok {
+			continue
+		}
+
+		cmd := exec.Command(d.gtPath, "polecat", "pool", "reconcile", rigName) //nolint:gosec // G204: args are constructed internally
+		cmd.Dir = d.config.TownRoot
+		cmd.Env = append(os.Environ(), "GT_DAEMON=1", "BD_DOLT_AUTO_COMMIT=off")
+		setSysProcAttr(cmd)
+		if err := cmd.Start(); err != nil {
+			d.logger.Printf("Warning: worktree pool reconcile for %s failed to start: %v", rigName, err)
+			continue
+		}
+		go func() { _ = cmd.Wait() }()
+	}
+}
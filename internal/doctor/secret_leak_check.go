@@ -0,0 +1,196 @@
+package doctor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/secrets"
+)
+
+// secretScanCommits is how many recent commits (across all branches) are
+// scanned per rig.
+const secretScanCommits = 500
+
+// SecretLeakCheck looks for rig secret values committed to git. A match
+// means a polecat wrote a secret into a file; the secret must be rotated
+// and the branch rewritten before it is pushed or merged.
+type SecretLeakCheck struct {
+	BaseCheck
+}
+
+// NewSecretLeakCheck creates a new secret leak check.
+func NewSecretLeakCheck() *SecretLeakCheck {
+	return &SecretLeakCheck{
+		BaseCheck: BaseCheck{
+			CheckName:        "secret-leaks",
+			CheckDescription: "Check that rig secrets have not been committed",
+			CheckCategory:    CategoryRig,
+		},
+	}
+}
+
+// secretLeak is one commit that adds a secret value.
+type secretLeak struct {
+	Commit string
+	Secret string
+}
+
+// Run scans recent commits of every rig that has secrets.
+func (c *SecretLeakCheck) Run(ctx *CheckContext) *CheckResult {
+	var details []string
+	var problems, scanned int
+	status := StatusOK
+
+	for _, rigPath := range findAllRigs(ctx.TownRoot) {
+		if _, err := os.Stat(secrets.StorePath(rigPath)); err != nil {
+			continue
+		}
+		rigName := filepath.Base(rigPath)
+		store, err := secrets.Load(rigPath)
+		if err != nil {
+			if errors.Is(err, secrets.ErrNoKey) {
+				details = append(details, fmt.Sprintf("%s: cannot decrypt secrets to scan (%v)", rigName, err))
+				if status == StatusOK {
+					status = StatusWarning
+				}
+				continue
+			}
+			details = append(details, fmt.Sprintf("%s: %v", rigName, err))
+			status = StatusError
+			problems++
+			continue
+		}
+
+		repo := rigRepoPath(rigPath)
+		if repo == "" {
+			continue
+		}
+		leaks, err := scanRepoForSecrets(repo, store.Env())
+		if err != nil {
+			details = append(details, fmt.Sprintf("%s: scan failed: %v", rigName, err))
+			if status == StatusOK {
+				status = StatusWarning
+			}
+			continue
+		}
+		scanned++
+		for _, l := range leaks {
+			details = append(details, fmt.Sprintf("%s: commit %s contains %s", rigName, l.Commit, l.Secret))
+		}
+		if len(leaks) > 0 {
+			status = StatusError
+			problems++
+		}
+	}
+
+	if problems > 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  status,
+			Message: fmt.Sprintf("Secrets found in commits of %d rig(s)", problems),
+			Details: details,
+			FixHint: "Rotate the secret, then rewrite or drop the branch before it is pushed or merged",
+		}
+	}
+	if status != StatusOK {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  status,
+			Message: "Could not scan all rigs for committed secrets",
+			Details: details,
+		}
+	}
+	if scanned == 0 {
+		return &CheckResult{
+			Name:    c.Name(),
+			Status:  StatusOK,
+			Message: "No rig secrets configured",
+		}
+	}
+	return &CheckResult{
+		Name:    c.Name(),
+		Status:  StatusOK,
+		Message: fmt.Sprintf("No secrets in the last %d commits of %d rig(s)", secretScanCommits, scanned),
+	}
+}
+
+// rigRepoPath returns the git repository polecat branches live in.
+func rigRepoPath(rigPath string) string {
+	for _, p := range []string{
+		filepath.Join(rigPath, ".repo.git"),
+		filepath.Join(rigPath, "mayor", "rig"),
+	} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// scanRepoForSecrets streams recent patches from every branch and reports
+// commits that add a secret value. Values are matched in-process so they
+// never appear on a command line.
+func scanRepoForSecrets(repo string, values map[string]string) ([]secretLeak, error) {
+	cmd := exec.Command("git", "-C", repo, "log", "--all", "-p", "--no-color", "--no-ext-diff",
+		"--format=commit %h", fmt.Sprintf("-n%d", secretScanCommits))
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	leaks, scanErr := scanPatchesForSecrets(out, values)
+	waitErr := cmd.Wait()
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	if waitErr != nil {
+		return nil, waitErr
+	}
+	return leaks, nil
+}
+
+// scanPatchesForSecrets reads `git log -p --format="commit %h"` output and
+// returns each (commit, secret) whose value appears on an added line.
+func scanPatchesForSecrets(r io.Reader, values map[string]string) ([]secretLeak, error) {
+	var names []string
+	for name, v := range values {
+		if len(v) >= secrets.MinRedactLength {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var leaks []secretLeak
+	seen := make(map[secretLeak]bool)
+	commit := ""
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if c, ok := strings.CutPrefix(line, "commit "); ok {
+			commit = strings.TrimSpace(c)
+		} else if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++ ") {
+			for _, name := range names {
+				l := secretLeak{Commit: commit, Secret: name}
+				if !seen[l] && strings.Contains(line, values[name]) {
+					seen[l] = true
+					leaks = append(leaks, l)
+				}
+			}
+		}
+		if err == io.EOF {
+			return leaks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package doctor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanPatchesForSecrets(t *testing.T) {
+	log := `commit abc1234
+diff --git a/.env b/.env
++++ b/.env
++NPM_TOKEN=npm_abcdef123456
+commit def5678
+diff --git a/config.js b/config.js
+-const token = "sk-live-999999";
++const token = process.env.API_KEY;
+commit 0a0a0a0
++short=abc
+`
+	leaks, err := scanPatchesForSecrets(strings.NewReader(log), map[string]string{
+		"NPM_TOKEN": "npm_abcdef123456",
+		"API_KEY":   "sk-live-999999",
+		"SHORT":     "abc",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaks) != 1 || leaks[0].Commit != "abc1234" || leaks[0].Secret != "NPM_TOKEN" {
+		t.Errorf("leaks = %+v, want NPM_TOKEN in abc1234 only", leaks)
+	}
+}
@@ -128,7 +128,7 @@ func (c *StaleTaskDispatchCheck) Fix(ctx *CheckContext) error {
 
 	var errs []string
 	for _, target := range c.staleTargets {
-		expected, err := hooks.ComputeExpected(target.Key)
+		expected, err := hooks.ComputeExpectedForTarget(ctx.TownRoot, target)
 		if err != nil {
 			errs = append(errs, fmt.Sprintf("%s: %v", target.DisplayKey(), err))
 			continue
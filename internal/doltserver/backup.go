@@ -0,0 +1,467 @@
+package doltserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Scheduled backups of the town's Dolt databases.
+//
+// Each backup is a snapshot directory under the backup root, holding one
+// Dolt backup (CALL DOLT_BACKUP('sync-url', ...)) per database:
+//
+//	<backupDir>/<YYYYMMDD-HHMMSS>/
+//	├── manifest.json   written last; directories without it are incomplete
+//	└── <db>/           Dolt backup of one database
+//
+// The backup root may be a local or mounted path. Restores go through a
+// staging directory that is validated before it replaces .dolt-data/.
+
+const (
+	// backupIDFormat names snapshot directories (UTC).
+	backupIDFormat = "20060102-150405"
+
+	// backupManifestFile marks a snapshot as complete.
+	backupManifestFile = "manifest.json"
+
+	// backupCmdTimeout bounds a single dolt backup or restore call.
+	backupCmdTimeout = 10 * time.Minute
+
+	// incompleteBackupGrace is how long an incomplete snapshot directory is
+	// left alone before PruneSnapshots treats it as debris from a crash.
+	incompleteBackupGrace = 6 * time.Hour
+)
+
+// BackupRetention is the number of snapshots kept per tier. Each tier keeps
+// the newest snapshot of each of its most recent N hours, days or ISO weeks.
+type BackupRetention struct {
+	Hourly int `json:"hourly,omitempty"`
+	Daily  int `json:"daily,omitempty"`
+	Weekly int `json:"weekly,omitempty"`
+}
+
+// DefaultBackupRetention keeps a day of hourlies, a week of dailies and a
+// month of weeklies.
+var DefaultBackupRetention = BackupRetention{Hourly: 24, Daily: 7, Weekly: 4}
+
+// BackupManifest describes a complete snapshot.
+type BackupManifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Databases []string  `json:"databases"`
+}
+
+// Snapshot is a complete backup of every database at one point in time.
+type Snapshot struct {
+	// ID is the snapshot directory name (YYYYMMDD-HHMMSS, UTC).
+	ID string `json:"id"`
+
+	// Path is the absolute path to the snapshot directory.
+	Path string `json:"path"`
+
+	// CreatedAt is when the snapshot was taken.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Databases lists the databases contained in the snapshot.
+	Databases []string `json:"databases"`
+}
+
+// RestoreResult records what RestoreSnapshot swapped in.
+type RestoreResult struct {
+	Snapshot *Snapshot
+
+	// Databases are the databases now in .dolt-data/.
+	Databases []string
+
+	// Dropped are databases that existed before the restore but are not in
+	// the snapshot. They remain in PreviousDataDir.
+	Dropped []string
+
+	// PreviousDataDir holds the data directory as it was before the restore.
+	PreviousDataDir string
+}
+
+// DefaultBackupDir returns the default backup root for a town.
+func DefaultBackupDir(townRoot string) string {
+	return filepath.Join(townRoot, ".dolt-backups")
+}
+
+// CreateSnapshot backs up every database into a new snapshot directory under
+// backupDir. A snapshot is all-or-nothing: if any database fails, the partial
+// directory is removed and an error returned.
+func CreateSnapshot(townRoot, backupDir string, now time.Time) (*Snapshot, error) {
+	config := DefaultConfig(townRoot)
+	if config.IsRemote() {
+		return nil, fmt.Errorf("Dolt server is remote (%s) — backups require local server access", config.HostPort())
+	}
+
+	databases, err := ListDatabases(townRoot)
+	if err != nil {
+		return nil, fmt.Errorf("listing databases: %w", err)
+	}
+	if len(databases) == 0 {
+		return nil, fmt.Errorf("no databases to back up in %s", config.DataDir)
+	}
+	sort.Strings(databases)
+
+	absDir, err := filepath.Abs(backupDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving backup dir: %w", err)
+	}
+	id := now.UTC().Format(backupIDFormat)
+	snapDir := filepath.Join(absDir, id)
+	if _, err := os.Stat(snapDir); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", id)
+	}
+	if err := os.MkdirAll(snapDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating snapshot dir: %w", err)
+	}
+
+	for _, db := range databases {
+		query := fmt.Sprintf("USE `%s`; CALL DOLT_BACKUP('sync-url', '%s')", db, fileURL(filepath.Join(snapDir, db)))
+		if err := runBackupCmd(config.DataDir, "sql", "-q", query); err != nil {
+			_ = os.RemoveAll(snapDir)
+			return nil, fmt.Errorf("backing up %s: %w", db, err)
+		}
+	}
+
+	manifest := BackupManifest{CreatedAt: now, Databases: databases}
+	if err := util.AtomicWriteJSON(filepath.Join(snapDir, backupManifestFile), manifest); err != nil {
+		_ = os.RemoveAll(snapDir)
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+
+	return &Snapshot{ID: id, Path: snapDir, CreatedAt: now, Databases: databases}, nil
+}
+
+// ListSnapshots returns the complete snapshots under backupDir, newest first.
+// A missing backup directory has no snapshots.
+func ListSnapshots(backupDir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading backup dir: %w", err)
+	}
+
+	var snaps []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if _, err := time.Parse(backupIDFormat, entry.Name()); err != nil {
+			continue
+		}
+		path := filepath.Join(backupDir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(path, backupManifestFile))
+		if err != nil {
+			continue // incomplete
+		}
+		var m BackupManifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		snaps = append(snaps, Snapshot{ID: entry.Name(), Path: path, CreatedAt: m.CreatedAt, Databases: m.Databases})
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].CreatedAt.After(snaps[j].CreatedAt)
+	})
+	return snaps, nil
+}
+
+// FindSnapshot returns the snapshot with the given ID.
+func FindSnapshot(snaps []Snapshot, id string) (*Snapshot, error) {
+	for i := range snaps {
+		if snaps[i].ID == id {
+			return &snaps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("backup %s not found", id)
+}
+
+// SelectSnapshot returns the newest snapshot taken at or before at.
+// snaps must be sorted newest first, as returned by ListSnapshots.
+func SelectSnapshot(snaps []Snapshot, at time.Time) (*Snapshot, error) {
+	for i := range snaps {
+		if !snaps[i].CreatedAt.After(at) {
+			return &snaps[i], nil
+		}
+	}
+	if len(snaps) == 0 {
+		return nil, fmt.Errorf("no backups found")
+	}
+	oldest := snaps[len(snaps)-1]
+	return nil, fmt.Errorf("no backup at or before %s (oldest is %s)",
+		at.Local().Format(time.RFC3339), oldest.CreatedAt.Local().Format(time.RFC3339))
+}
+
+// ParseRestoreTime parses a --to argument. Accepted forms are a duration
+// meaning "that long ago" (90m, 6h), a snapshot ID (20260102-150405, UTC),
+// RFC 3339, and local "2006-01-02 15:04" / "2006-01-02T15:04" / "2006-01-02".
+func ParseRestoreTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("duration %q must not be negative", s)
+		}
+		return now.Add(-d), nil
+	}
+	if t, err := time.Parse(backupIDFormat, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q (use e.g. 2h, 2026-01-02 15:04, or RFC 3339)", s)
+}
+
+// PruneSnapshots removes snapshots not retained by keep, plus incomplete
+// snapshot directories older than incompleteBackupGrace. The newest snapshot
+// is always kept. Returns the IDs removed.
+func PruneSnapshots(backupDir string, keep BackupRetention, now time.Time) ([]string, error) {
+	snaps, err := ListSnapshots(backupDir)
+	if err != nil {
+		return nil, err
+	}
+	retained := retainedSnapshots(snaps, keep)
+	complete := make(map[string]bool, len(snaps))
+	for _, s := range snaps {
+		complete[s.ID] = true
+	}
+
+	var removed []string
+	for _, s := range snaps {
+		if retained[s.ID] {
+			continue
+		}
+		if err := os.RemoveAll(s.Path); err != nil {
+			return removed, fmt.Errorf("removing backup %s: %w", s.ID, err)
+		}
+		removed = append(removed, s.ID)
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return removed, nil
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || complete[entry.Name()] {
+			continue
+		}
+		t, err := time.Parse(backupIDFormat, entry.Name())
+		if err != nil || now.Sub(t) < incompleteBackupGrace {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(backupDir, entry.Name())); err == nil {
+			removed = append(removed, entry.Name())
+		}
+	}
+	return removed, nil
+}
+
+// retainedSnapshots returns the IDs kept by the retention tiers.
+// snaps must be sorted newest first.
+func retainedSnapshots(snaps []Snapshot, keep BackupRetention) map[string]bool {
+	retained := make(map[string]bool)
+	if len(snaps) > 0 {
+		retained[snaps[0].ID] = true
+	}
+
+	tiers := []struct {
+		n      int
+		bucket func(time.Time) string
+	}{
+		{keep.Hourly, func(t time.Time) string { return t.UTC().Format("2006010215") }},
+		{keep.Daily, func(t time.Time) string { return t.UTC().Format("20060102") }},
+		{keep.Weekly, func(t time.Time) string {
+			y, w := t.UTC().ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		}},
+	}
+	for _, tier := range tiers {
+		seen := make(map[string]bool)
+		for _, s := range snaps {
+			if len(seen) >= tier.n {
+				break
+			}
+			b := tier.bucket(s.CreatedAt)
+			if seen[b] {
+				continue
+			}
+			seen[b] = true
+			retained[s.ID] = true
+		}
+	}
+	return retained
+}
+
+// VerifySnapshot restores a snapshot into a scratch directory, validates it,
+// and discards it. The live data directory is not touched.
+func VerifySnapshot(townRoot string, snap *Snapshot) error {
+	staging, err := os.MkdirTemp(townRoot, ".dolt-verify-")
+	if err != nil {
+		return fmt.Errorf("creating verify dir: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	return restoreToStaging(snap, staging)
+}
+
+// StageSnapshot restores a snapshot into a staging directory next to
+// .dolt-data/ and validates it, returning the staging path. The server may
+// keep running while the snapshot is staged; pass the result to
+// RestoreSnapshot to swap it in.
+func StageSnapshot(townRoot string, snap *Snapshot) (string, error) {
+	config := DefaultConfig(townRoot)
+	if config.IsRemote() {
+		return "", fmt.Errorf("Dolt server is remote (%s) — restore requires local server access", config.HostPort())
+	}
+
+	staging := filepath.Join(filepath.Dir(config.DataDir), ".dolt-restore-staging-"+snap.ID)
+	if err := os.RemoveAll(staging); err != nil {
+		return "", fmt.Errorf("clearing staging dir: %w", err)
+	}
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return "", fmt.Errorf("creating staging dir: %w", err)
+	}
+	if err := restoreToStaging(snap, staging); err != nil {
+		_ = os.RemoveAll(staging)
+		return "", err
+	}
+	return staging, nil
+}
+
+// RestoreSnapshot replaces the data directory with a snapshot staged by
+// StageSnapshot. The current data directory is moved aside (kept as
+// .dolt-data.pre-restore-<time>) and the staging directory swapped in.
+// The Dolt server must be stopped.
+func RestoreSnapshot(townRoot string, snap *Snapshot, staging string, now time.Time) (*RestoreResult, error) {
+	config := DefaultConfig(townRoot)
+	if running, pid, _ := IsRunning(townRoot); running {
+		return nil, fmt.Errorf("Dolt server is running (PID %d) — stop it before restoring", pid)
+	}
+	if _, err := os.Stat(staging); err != nil {
+		return nil, fmt.Errorf("staged restore not found: %w", err)
+	}
+
+	before, _ := ListDatabases(townRoot)
+
+	previous := config.DataDir + ".pre-restore-" + now.UTC().Format(backupIDFormat)
+	if err := swapDataDir(config.DataDir, staging, previous); err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{Snapshot: snap, Databases: snap.Databases}
+	if _, err := os.Stat(previous); err == nil {
+		result.PreviousDataDir = previous
+	}
+	restored := make(map[string]bool, len(snap.Databases))
+	for _, db := range snap.Databases {
+		restored[db] = true
+	}
+	for _, db := range before {
+		if !restored[db] {
+			result.Dropped = append(result.Dropped, db)
+		}
+	}
+	return result, nil
+}
+
+// restoreToStaging restores every database of a snapshot into staging and
+// validates the result.
+func restoreToStaging(snap *Snapshot, staging string) error {
+	for _, db := range snap.Databases {
+		src := filepath.Join(snap.Path, db)
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("backup %s is missing database %s: %w", snap.ID, db, err)
+		}
+		if err := runBackupCmd(staging, "backup", "restore", fileURL(src), db); err != nil {
+			return fmt.Errorf("restoring %s: %w", db, err)
+		}
+	}
+	return validateRestore(staging, snap.Databases)
+}
+
+// validateRestore checks that every restored database has a readable commit
+// graph and working set.
+func validateRestore(staging string, databases []string) error {
+	for _, db := range databases {
+		dbDir := filepath.Join(staging, db)
+		if _, err := os.Stat(filepath.Join(dbDir, ".dolt")); err != nil {
+			return fmt.Errorf("validating %s: not a Dolt database: %w", db, err)
+		}
+		if err := runBackupCmd(dbDir, "log", "-n", "1", "--oneline"); err != nil {
+			return fmt.Errorf("validating %s: reading commit log: %w", db, err)
+		}
+		if err := runBackupCmd(dbDir, "sql", "-q", "SHOW TABLES"); err != nil {
+			return fmt.Errorf("validating %s: reading tables: %w", db, err)
+		}
+	}
+	return nil
+}
+
+// swapDataDir moves dataDir aside to previous (if it exists) and moves
+// staging into its place. If the second move fails the original data
+// directory is put back.
+func swapDataDir(dataDir, staging, previous string) error {
+	hadData := false
+	if _, err := os.Stat(dataDir); err == nil {
+		if err := moveDir(dataDir, previous); err != nil {
+			return fmt.Errorf("moving current data dir aside: %w", err)
+		}
+		hadData = true
+	}
+	if err := moveDir(staging, dataDir); err != nil {
+		if hadData {
+			if rbErr := moveDir(previous, dataDir); rbErr != nil {
+				return fmt.Errorf("swapping in restored data: %w (rollback also failed: %v; original data is in %s)", err, rbErr, previous)
+			}
+		}
+		return fmt.Errorf("swapping in restored data: %w", err)
+	}
+	return nil
+}
+
+// fileURL returns a file:// URL for an absolute path.
+func fileURL(path string) string {
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows drive paths: file:///C:/...
+	}
+	return "file://" + p
+}
+
+// runBackupCmd runs a dolt command in dir with backupCmdTimeout.
+func runBackupCmd(dir string, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), backupCmdTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "dolt", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("dolt %s: timed out after %s", args[0], backupCmdTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("dolt %s: %w (%s)", args[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
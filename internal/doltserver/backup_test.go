@@ -0,0 +1,229 @@
+package doltserver
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// writeSnapshot creates a complete snapshot directory for tests.
+func writeSnapshot(t *testing.T, backupDir string, at time.Time, dbs ...string) Snapshot {
+	t.Helper()
+	id := at.UTC().Format(backupIDFormat)
+	dir := filepath.Join(backupDir, id)
+	for _, db := range dbs {
+		if err := os.MkdirAll(filepath.Join(dir, db), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := util.AtomicWriteJSON(filepath.Join(dir, backupManifestFile), BackupManifest{CreatedAt: at, Databases: dbs}); err != nil {
+		t.Fatal(err)
+	}
+	return Snapshot{ID: id, Path: dir, CreatedAt: at, Databases: dbs}
+}
+
+func TestListSnapshots(t *testing.T) {
+	backupDir := t.TempDir()
+	base := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	writeSnapshot(t, backupDir, base, "hq", "gastown")
+	writeSnapshot(t, backupDir, base.Add(time.Hour), "hq")
+
+	// Incomplete (no manifest) and unrelated directories are ignored.
+	if err := os.MkdirAll(filepath.Join(backupDir, base.Add(2*time.Hour).Format(backupIDFormat), "hq"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(backupDir, "notes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	snaps, err := ListSnapshots(backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("got %d snapshots, want 2", len(snaps))
+	}
+	if snaps[0].ID != "20261017-130000" || snaps[1].ID != "20261017-120000" {
+		t.Errorf("order = %s, %s; want newest first", snaps[0].ID, snaps[1].ID)
+	}
+	if len(snaps[1].Databases) != 2 {
+		t.Errorf("databases = %v", snaps[1].Databases)
+	}
+
+	if snaps, err := ListSnapshots(filepath.Join(backupDir, "missing")); err != nil || snaps != nil {
+		t.Errorf("missing dir: %v, %v", snaps, err)
+	}
+}
+
+func TestSelectSnapshot(t *testing.T) {
+	base := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	snaps := []Snapshot{
+		{ID: "c", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "b", CreatedAt: base.Add(time.Hour)},
+		{ID: "a", CreatedAt: base},
+	}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{base.Add(3 * time.Hour), "c"},
+		{base.Add(2 * time.Hour), "c"},
+		{base.Add(90 * time.Minute), "b"},
+		{base, "a"},
+	}
+	for _, tt := range tests {
+		got, err := SelectSnapshot(snaps, tt.at)
+		if err != nil {
+			t.Errorf("SelectSnapshot(%v): %v", tt.at, err)
+			continue
+		}
+		if got.ID != tt.want {
+			t.Errorf("SelectSnapshot(%v) = %s, want %s", tt.at, got.ID, tt.want)
+		}
+	}
+
+	if _, err := SelectSnapshot(snaps, base.Add(-time.Minute)); err == nil {
+		t.Error("expected error for time before oldest backup")
+	}
+}
+
+func TestParseRestoreTime(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2h", now.Add(-2 * time.Hour)},
+		{"20261016-080000", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{"2026-10-16T08:00:00Z", time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)},
+		{"2026-10-16 08:00", time.Date(2026, 10, 16, 8, 0, 0, 0, time.Local)},
+		{"2026-10-16", time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseRestoreTime(tt.in, now)
+		if err != nil {
+			t.Errorf("ParseRestoreTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseRestoreTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "yesterday", "-1h"} {
+		if _, err := ParseRestoreTime(bad, now); err == nil {
+			t.Errorf("ParseRestoreTime(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRetainedSnapshots(t *testing.T) {
+	// Two snapshots per hour for three days, newest first.
+	base := time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC)
+	var snaps []Snapshot
+	for i := 0; i < 144; i++ {
+		at := base.Add(-time.Duration(i) * 30 * time.Minute)
+		snaps = append(snaps, Snapshot{ID: at.Format(backupIDFormat), CreatedAt: at})
+	}
+
+	kept := retainedSnapshots(snaps, BackupRetention{Hourly: 3, Daily: 2, Weekly: 1})
+	var ids []string
+	for id := range kept {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Hourly: newest of 23h, 22h, 21h on the 17th. Daily: newest of the 17th
+	// (already kept) and the 16th. Weekly: newest of the week (already kept).
+	want := []string{"20261016-233000", "20261017-213000", "20261017-223000", "20261017-233000"}
+	if len(ids) != len(want) {
+		t.Fatalf("kept %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("kept %v, want %v", ids, want)
+		}
+	}
+
+	// The newest snapshot survives even with every tier disabled.
+	if kept := retainedSnapshots(snaps, BackupRetention{}); len(kept) != 1 || !kept[snaps[0].ID] {
+		t.Errorf("zero retention kept %v, want only the newest", kept)
+	}
+}
+
+func TestPruneSnapshots(t *testing.T) {
+	backupDir := t.TempDir()
+	now := time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC)
+	newest := writeSnapshot(t, backupDir, now, "hq")
+	older := writeSnapshot(t, backupDir, now.Add(-20*time.Minute), "hq") // same hour as newest
+	prevHour := writeSnapshot(t, backupDir, now.Add(-time.Hour), "hq")
+
+	// A stale incomplete snapshot from a crashed backup, and a fresh one that
+	// may still be in progress.
+	stale := filepath.Join(backupDir, now.Add(-24*time.Hour).Format(backupIDFormat))
+	fresh := filepath.Join(backupDir, now.Add(-time.Minute).Format(backupIDFormat))
+	for _, dir := range []string{stale, fresh} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := PruneSnapshots(backupDir, BackupRetention{Hourly: 2}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %v, want the superseded snapshot and the stale incomplete one", removed)
+	}
+	for _, dir := range []string{newest.Path, prevHour.Path, fresh} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s should be kept: %v", filepath.Base(dir), err)
+		}
+	}
+	for _, dir := range []string{older.Path, stale} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed", filepath.Base(dir))
+		}
+	}
+}
+
+func TestSwapDataDir(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, ".dolt-data")
+	staging := filepath.Join(root, ".dolt-restore-staging")
+	previous := filepath.Join(root, ".dolt-data.pre-restore")
+
+	for dir, marker := range map[string]string{dataDir: "live", staging: "restored"} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, marker), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := swapDataDir(dataDir, staging, previous); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "restored")); err != nil {
+		t.Error("restored data not swapped in")
+	}
+	if _, err := os.Stat(filepath.Join(previous, "live")); err != nil {
+		t.Error("previous data not kept aside")
+	}
+	if _, err := os.Stat(staging); !os.IsNotExist(err) {
+		t.Error("staging dir should be gone after swap")
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	if got := fileURL("/srv/backups/hq"); got != "file:///srv/backups/hq" {
+		t.Errorf("fileURL = %q", got)
+	}
+}
@@ -806,6 +806,8 @@ func Start(townRoot string) error {
 	if config.MaxConnections > 0 {
 		args = append(args, "--max-connections", strconv.Itoa(config.MaxConnections))
 	}
+	// With a hot standby configured, the server runs from its cluster config.
+	args = ServerArgs(townRoot, args)
 	cmd := exec.Command("dolt", args...)
 	cmd.Stdout = logFile
 	cmd.Stderr = logFile
@@ -959,15 +961,16 @@ func Stop(townRoot string) error {
 }
 
 // GetConnectionString returns the MySQL connection string for the server.
+// While failed over to a standby, this is the standby.
 // Use GetConnectionStringForRig for a specific database.
 func GetConnectionString(townRoot string) string {
-	config := DefaultConfig(townRoot)
+	config := ActiveConfig(townRoot)
 	return fmt.Sprintf("%s@tcp(%s)/", config.displayDSN(), config.HostPort())
 }
 
 // GetConnectionStringForRig returns the MySQL connection string for a specific rig database.
 func GetConnectionStringForRig(townRoot, rigName string) string {
-	config := DefaultConfig(townRoot)
+	config := ActiveConfig(townRoot)
 	return fmt.Sprintf("%s@tcp(%s)/%s", config.displayDSN(), config.HostPort(), rigName)
 }
 
@@ -0,0 +1,720 @@
+package doltserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Hot standby and failover.
+//
+// When a standby is configured, the primary and the standby run as a Dolt
+// cluster: each sql-server is started from a generated config file with a
+// cluster section, and every commit on the primary is replicated to the
+// standby over the remotesapi. Failover promotes the standby with
+// DOLT_ASSUME_CLUSTER_ROLE at a higher epoch, records it as the active server
+// in daemon/dolt-active.json, and re-points every beads metadata.json at it.
+// Fail-back reverses this once the old primary has rejoined as a standby and
+// caught up.
+
+// Standby defaults.
+const (
+	DefaultStandbyPort           = 3308
+	DefaultRemotesAPIPort        = 50051
+	DefaultStandbyRemotesAPIPort = 50052
+	DefaultFailoverAfter         = 3
+)
+
+// Cluster roles as reported by @@GLOBAL.dolt_cluster_role.
+const (
+	ClusterRolePrimary = "primary"
+	ClusterRoleStandby = "standby"
+)
+
+// ErrNotFailedOver is returned by Failback when the primary is already active.
+var ErrNotFailedOver = errors.New("not failed over: the primary is the active server")
+
+// StandbyConfig configures the hot standby. It lives in the dolt_server
+// section of mayor/daemon.json.
+type StandbyConfig struct {
+	// Enabled turns on cluster replication and automatic failover.
+	Enabled bool `json:"enabled"`
+
+	// Host is the standby's SQL host. Empty or a loopback address means a
+	// local standby that the daemon starts and supervises.
+	Host string `json:"host,omitempty"`
+
+	// Port is the standby's SQL port (default 3308).
+	Port int `json:"port,omitempty"`
+
+	// User and Password authenticate to the standby (default root, no password).
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// DataDir is the local standby's data directory (default <town>/.dolt-standby).
+	DataDir string `json:"data_dir,omitempty"`
+
+	// RemotesAPIPort is the standby's replication port (default 50052).
+	RemotesAPIPort int `json:"remotesapi_port,omitempty"`
+
+	// PrimaryRemotesAPIPort is the primary's replication port (default 50051).
+	PrimaryRemotesAPIPort int `json:"primary_remotesapi_port,omitempty"`
+
+	// PrimaryHost is the address the standby uses to reach the primary's
+	// remotesapi for fail-back replication (default 127.0.0.1).
+	PrimaryHost string `json:"primary_host,omitempty"`
+
+	// FailoverAfter is the number of consecutive failed primary health checks
+	// before the daemon promotes the standby (default 3).
+	FailoverAfter int `json:"failover_after,omitempty"`
+
+	// AutoFailback lets the daemon fail back once the old primary has
+	// rejoined as a standby and caught up. Off by default: fail-back is
+	// normally a deliberate `gt dolt failover back`.
+	AutoFailback bool `json:"auto_failback,omitempty"`
+}
+
+// WithDefaults returns a copy with unset fields filled in.
+func (s StandbyConfig) WithDefaults(townRoot string) StandbyConfig {
+	if s.Port == 0 {
+		s.Port = DefaultStandbyPort
+	}
+	if s.User == "" {
+		s.User = DefaultUser
+	}
+	if s.DataDir == "" {
+		s.DataDir = filepath.Join(townRoot, ".dolt-standby")
+	}
+	if s.RemotesAPIPort == 0 {
+		s.RemotesAPIPort = DefaultStandbyRemotesAPIPort
+	}
+	if s.PrimaryRemotesAPIPort == 0 {
+		s.PrimaryRemotesAPIPort = DefaultRemotesAPIPort
+	}
+	if s.PrimaryHost == "" {
+		s.PrimaryHost = "127.0.0.1"
+	}
+	if s.FailoverAfter <= 0 {
+		s.FailoverAfter = DefaultFailoverAfter
+	}
+	return s
+}
+
+// IsLocal reports whether the standby runs on this machine under the daemon.
+func (s StandbyConfig) IsLocal() bool {
+	return !(&Config{Host: s.Host}).IsRemote()
+}
+
+// Endpoint returns the standby's SQL endpoint.
+func (s StandbyConfig) Endpoint() Endpoint {
+	host := s.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return Endpoint{Host: host, Port: s.Port, User: s.User, Password: s.Password}
+}
+
+// Endpoint is a SQL server address with credentials.
+type Endpoint struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"-"`
+	Password string `json:"-"`
+}
+
+// HostPort returns "host:port".
+func (e Endpoint) HostPort() string {
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+// PrimaryEndpoint returns the primary server's endpoint from its config.
+func PrimaryEndpoint(config *Config) Endpoint {
+	host := config.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return Endpoint{Host: host, Port: config.Port, User: config.User, Password: config.Password}
+}
+
+// Reachable reports whether the endpoint accepts TCP connections.
+func (e Endpoint) Reachable() bool {
+	conn, err := net.DialTimeout("tcp", e.HostPort(), 2*time.Second)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// ClusterNode describes one sql-server of the cluster for its config file.
+type ClusterNode struct {
+	Host           string
+	Port           int
+	DataDir        string
+	MaxConnections int
+	Role           string // bootstrap role
+	RemotesAPIPort int
+	PeerName       string // name of the other node as a standby remote
+	PeerRemotesAPI string // host:port of the other node's remotesapi
+}
+
+// ClusterConfigYAML renders a dolt sql-server config file for node.
+func ClusterConfigYAML(n ClusterNode) string {
+	host := n.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	var b strings.Builder
+	b.WriteString("# Generated by gt for Dolt cluster replication; rewritten on daemon start.\n")
+	b.WriteString("log_level: info\n")
+	b.WriteString("listener:\n")
+	fmt.Fprintf(&b, "  host: %q\n", host)
+	fmt.Fprintf(&b, "  port: %d\n", n.Port)
+	if n.MaxConnections > 0 {
+		fmt.Fprintf(&b, "  max_connections: %d\n", n.MaxConnections)
+	}
+	fmt.Fprintf(&b, "data_dir: %q\n", n.DataDir)
+	b.WriteString("cluster:\n")
+	b.WriteString("  standby_remotes:\n")
+	fmt.Fprintf(&b, "    - name: %s\n", n.PeerName)
+	fmt.Fprintf(&b, "      remote_url_template: %q\n", "http://"+n.PeerRemotesAPI+"/{database}")
+	fmt.Fprintf(&b, "  bootstrap_role: %s\n", n.Role)
+	b.WriteString("  bootstrap_epoch: 1\n")
+	b.WriteString("  remotesapi:\n")
+	fmt.Fprintf(&b, "    port: %d\n", n.RemotesAPIPort)
+	return b.String()
+}
+
+// ClusterConfigFile is the primary's generated sql-server config. While it
+// exists, the primary is started from it instead of from flags.
+func ClusterConfigFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "dolt-cluster.yaml")
+}
+
+// StandbyConfigFile is the local standby's generated sql-server config.
+func StandbyConfigFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "dolt-standby.yaml")
+}
+
+// WriteClusterConfigs writes the primary's and standby's config files for
+// a primary listening on host:port and serving dataDir. For a remote
+// standby the standby file is a template to copy to that machine.
+func WriteClusterConfigs(townRoot, host string, port int, dataDir string, maxConns int, s StandbyConfig) error {
+	s = s.WithDefaults(townRoot)
+	if err := os.MkdirAll(filepath.Join(townRoot, "daemon"), 0755); err != nil {
+		return err
+	}
+	standbyHost := s.Endpoint().Host
+	primary := ClusterNode{
+		Host: host, Port: port, DataDir: dataDir, MaxConnections: maxConns,
+		Role: ClusterRolePrimary, RemotesAPIPort: s.PrimaryRemotesAPIPort,
+		PeerName: "standby", PeerRemotesAPI: net.JoinHostPort(standbyHost, strconv.Itoa(s.RemotesAPIPort)),
+	}
+	standby := ClusterNode{
+		Host: standbyHost, Port: s.Port, DataDir: s.DataDir, MaxConnections: maxConns,
+		Role: ClusterRoleStandby, RemotesAPIPort: s.RemotesAPIPort,
+		PeerName: "primary", PeerRemotesAPI: net.JoinHostPort(s.PrimaryHost, strconv.Itoa(s.PrimaryRemotesAPIPort)),
+	}
+	if err := util.AtomicWriteFile(ClusterConfigFile(townRoot), []byte(ClusterConfigYAML(primary)), 0644); err != nil {
+		return fmt.Errorf("writing primary cluster config: %w", err)
+	}
+	if err := util.AtomicWriteFile(StandbyConfigFile(townRoot), []byte(ClusterConfigYAML(standby)), 0644); err != nil {
+		return fmt.Errorf("writing standby cluster config: %w", err)
+	}
+	return nil
+}
+
+// RemoveClusterConfigs removes the generated config files, returning the
+// primary to flag-based startup on its next restart.
+func RemoveClusterConfigs(townRoot string) {
+	_ = os.Remove(ClusterConfigFile(townRoot))
+	_ = os.Remove(StandbyConfigFile(townRoot))
+}
+
+// ServerArgs returns the dolt arguments that start the primary: the cluster
+// config file when one has been written, otherwise the given flags.
+func ServerArgs(townRoot string, flagArgs []string) []string {
+	if _, err := os.Stat(ClusterConfigFile(townRoot)); err == nil {
+		return []string{"sql-server", "--config", ClusterConfigFile(townRoot)}
+	}
+	return flagArgs
+}
+
+// standbyPidFile is the local standby's PID file.
+func standbyPidFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "dolt-standby.pid")
+}
+
+// StartLocalStandby starts the local standby sql-server from its config file
+// unless it is already listening.
+func StartLocalStandby(townRoot string, s StandbyConfig) error {
+	s = s.WithDefaults(townRoot)
+	if s.Endpoint().Reachable() {
+		return nil
+	}
+	if err := os.MkdirAll(s.DataDir, 0755); err != nil {
+		return fmt.Errorf("creating standby data directory: %w", err)
+	}
+	logPath := filepath.Join(townRoot, "daemon", "dolt-standby.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening standby log file: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command("dolt", "sql-server", "--config", StandbyConfigFile(townRoot))
+	cmd.Dir = s.DataDir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting standby sql-server: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	if err := os.WriteFile(standbyPidFile(townRoot), []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		return fmt.Errorf("writing standby PID file: %w", err)
+	}
+	return nil
+}
+
+// StopLocalStandby stops the local standby started by StartLocalStandby.
+func StopLocalStandby(townRoot string) error {
+	data, err := os.ReadFile(standbyPidFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("invalid standby PID file: %w", err)
+	}
+	if proc, err := os.FindProcess(pid); err == nil && isDoltProcess(pid) {
+		_ = proc.Kill()
+	}
+	return os.Remove(standbyPidFile(townRoot))
+}
+
+// endpointQuery runs a query against ep with explicit connection flags (a
+// local standby must not be auto-detected as the primary) and returns the
+// CSV rows after the header.
+func endpointQuery(ep Endpoint, query string) ([][]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	user := ep.User
+	if user == "" {
+		user = DefaultUser
+	}
+	cmd := exec.CommandContext(ctx, "dolt", "sql", //nolint:gosec // G204: args are constructed internally
+		"--host", ep.Host, "--port", strconv.Itoa(ep.Port), "--user", user, "--no-tls",
+		"-r", "csv", "-q", query)
+	cmd.Dir = os.TempDir()
+	if ep.Password != "" {
+		cmd.Env = append(os.Environ(), "DOLT_CLI_PASSWORD="+ep.Password)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w (%s)", ep.HostPort(), err, strings.TrimSpace(stderr.String()))
+	}
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s output: %w", ep.HostPort(), err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return records[1:], nil
+}
+
+// ClusterRole returns the server's cluster role and epoch.
+func ClusterRole(ep Endpoint) (string, int, error) {
+	rows, err := endpointQuery(ep, "SELECT @@GLOBAL.dolt_cluster_role, @@GLOBAL.dolt_cluster_role_epoch")
+	if err != nil {
+		return "", 0, err
+	}
+	if len(rows) == 0 || len(rows[0]) < 2 {
+		return "", 0, fmt.Errorf("%s: no cluster role (not started with a cluster config?)", ep.HostPort())
+	}
+	epoch, _ := strconv.Atoi(rows[0][1])
+	return rows[0][0], epoch, nil
+}
+
+// AssumeClusterRole switches the server to role at epoch. Demoting a primary
+// blocks until its standbys have caught up, and fails if they cannot.
+func AssumeClusterRole(ep Endpoint, role string, epoch int) error {
+	_, err := endpointQuery(ep, fmt.Sprintf("CALL DOLT_ASSUME_CLUSTER_ROLE('%s', %d)", role, epoch))
+	return err
+}
+
+// ReplicationStatus is one database's replication state on a primary.
+type ReplicationStatus struct {
+	Database      string `json:"database"`
+	StandbyRemote string `json:"standby_remote"`
+	Role          string `json:"role"`
+	Epoch         int    `json:"epoch"`
+	LagMillis     int64  `json:"replication_lag_millis"` // -1 when unknown
+	LastUpdate    string `json:"last_update,omitempty"`
+	Error         string `json:"current_error,omitempty"`
+}
+
+// ClusterStatus returns per-database replication state from ep.
+func ClusterStatus(ep Endpoint) ([]ReplicationStatus, error) {
+	rows, err := endpointQuery(ep, "SELECT `database`, standby_remote, role, epoch, replication_lag_millis, last_update, current_error FROM dolt_cluster.dolt_cluster_status")
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]ReplicationStatus, 0, len(rows))
+	for _, r := range rows {
+		if len(r) < 7 {
+			continue
+		}
+		epoch, _ := strconv.Atoi(r[3])
+		lag, err := strconv.ParseInt(r[4], 10, 64)
+		if err != nil {
+			lag = -1
+		}
+		statuses = append(statuses, ReplicationStatus{
+			Database: r[0], StandbyRemote: r[1], Role: r[2], Epoch: epoch,
+			LagMillis: lag, LastUpdate: r[5], Error: r[6],
+		})
+	}
+	return statuses, nil
+}
+
+// CaughtUp reports whether every database has replicated with no lag and no
+// error.
+func CaughtUp(statuses []ReplicationStatus) bool {
+	for _, s := range statuses {
+		if s.LagMillis != 0 || s.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ActiveServer records a failover: which server consumers are pointed at and
+// what each beads metadata.json held before, for fail-back.
+type ActiveServer struct {
+	Host   string    `json:"host"`
+	Port   int       `json:"port"`
+	Epoch  int       `json:"epoch"`
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+
+	// Metadata maps each re-pointed metadata.json to its previous
+	// dolt_server_host/dolt_server_port (zero values meaning unset).
+	Metadata map[string]Endpoint `json:"metadata,omitempty"`
+}
+
+// ActiveServerFile is where the current failover is recorded. It exists only
+// while the standby is the active server.
+func ActiveServerFile(townRoot string) string {
+	return filepath.Join(townRoot, "daemon", "dolt-active.json")
+}
+
+// LoadActiveServer returns the recorded failover, or nil when the primary is
+// active.
+func LoadActiveServer(townRoot string) (*ActiveServer, error) {
+	data, err := os.ReadFile(ActiveServerFile(townRoot))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var active ActiveServer
+	if err := json.Unmarshal(data, &active); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ActiveServerFile(townRoot), err)
+	}
+	return &active, nil
+}
+
+// ActiveConfig is DefaultConfig re-pointed at the standby while failed over.
+// Explicit GT_DOLT_HOST / GT_DOLT_PORT settings still win.
+func ActiveConfig(townRoot string) *Config {
+	config := DefaultConfig(townRoot)
+	if os.Getenv("GT_DOLT_HOST") != "" || os.Getenv("GT_DOLT_PORT") != "" {
+		return config
+	}
+	if active, err := LoadActiveServer(townRoot); err == nil && active != nil {
+		config.Host = active.Host
+		config.Port = active.Port
+	}
+	return config
+}
+
+// beadsMetadataFiles returns the metadata.json of every beads dir backed by
+// a database in the data directory.
+func beadsMetadataFiles(townRoot string) []string {
+	databases, _ := ListDatabases(townRoot)
+	var files []string
+	for _, db := range databases {
+		path := filepath.Join(FindRigBeadsDir(townRoot, db), "metadata.json")
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// repointMetadata sets dolt_server_host/port in each file to ep and returns
+// the previous values. Either every file is switched or none is.
+func repointMetadata(files []string, ep Endpoint) (map[string]Endpoint, error) {
+	targets := make(map[string]*Endpoint, len(files))
+	for _, path := range files {
+		targets[path] = &ep
+	}
+	return rewriteMetadata(targets)
+}
+
+// restoreMetadata puts back the values recorded by repointMetadata.
+func restoreMetadata(prev map[string]Endpoint) error {
+	targets := make(map[string]*Endpoint, len(prev))
+	for path, ep := range prev {
+		ep := ep
+		targets[path] = &ep
+		if ep.Host == "" && ep.Port == 0 {
+			targets[path] = nil
+		}
+	}
+	_, err := rewriteMetadata(targets)
+	return err
+}
+
+// rewriteMetadata sets (or with a nil endpoint, removes) the server fields of
+// every file in targets as one change: each new version is staged in a temp
+// file next to its target before any is renamed into place, and if a rename
+// fails the files already switched are put back. Consumers therefore never
+// see some databases on the old server and some on the new. Returns the
+// previous values.
+func rewriteMetadata(targets map[string]*Endpoint) (map[string]Endpoint, error) {
+	paths := make([]string, 0, len(targets))
+	for path := range targets {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		mu := getMetadataMu(path)
+		mu.Lock()
+		defer mu.Unlock()
+	}
+
+	type staged struct {
+		path, tmp string
+		orig      []byte
+	}
+	var pending []staged
+	discard := func() {
+		for _, st := range pending {
+			_ = os.Remove(st.tmp)
+		}
+	}
+
+	prev := make(map[string]Endpoint, len(paths))
+	for _, path := range paths {
+		orig, err := os.ReadFile(path) //nolint:gosec // G304: path is a beads metadata file
+		if err != nil {
+			discard()
+			return nil, err
+		}
+		out, old, err := patchMetadataServer(orig, targets[path])
+		if err != nil {
+			discard()
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		tmp, err := stageFile(path, out)
+		if err != nil {
+			discard()
+			return nil, fmt.Errorf("staging %s: %w", path, err)
+		}
+		pending = append(pending, staged{path: path, tmp: tmp, orig: orig})
+		prev[path] = old
+	}
+
+	for i, st := range pending {
+		if err := os.Rename(st.tmp, st.path); err != nil {
+			for _, done := range pending[:i] {
+				_ = util.AtomicWriteFile(done.path, done.orig, 0600)
+			}
+			discard()
+			return nil, fmt.Errorf("switching %s: %w", st.path, err)
+		}
+	}
+	return prev, nil
+}
+
+// stageFile writes data to a new temp file beside path and returns its name.
+func stageFile(path string, data []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp.*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// patchMetadataServer sets (or with ep nil, removes) dolt_server_host and
+// dolt_server_port in metadata.json content, keeping every other field, and
+// returns the new content and the previous values.
+func patchMetadataServer(data []byte, ep *Endpoint) ([]byte, Endpoint, error) {
+	meta := make(map[string]interface{})
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, Endpoint{}, err
+	}
+
+	var old Endpoint
+	old.Host, _ = meta["dolt_server_host"].(string)
+	if p, ok := meta["dolt_server_port"].(float64); ok {
+		old.Port = int(p)
+	}
+
+	if ep == nil {
+		delete(meta, "dolt_server_host")
+		delete(meta, "dolt_server_port")
+	} else {
+		meta["dolt_server_host"] = ep.Host
+		meta["dolt_server_port"] = ep.Port
+	}
+
+	out, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return nil, old, err
+	}
+	return append(out, '\n'), old, nil
+}
+
+// Promote makes the standby the primary and re-points consumers at it: the
+// standby assumes the primary role at a higher epoch, every beads
+// metadata.json is switched to it, and the switch is recorded in
+// dolt-active.json (which GetConnectionString follows). The old primary is
+// fenced to standby if it still answers; if not, it steps down on its own
+// when it next hears from the higher-epoch primary.
+func Promote(townRoot string, primary, standby Endpoint, reason string, now time.Time) (*ActiveServer, error) {
+	if active, err := LoadActiveServer(townRoot); err != nil {
+		return nil, err
+	} else if active != nil {
+		return nil, fmt.Errorf("already failed over to %s:%d since %s", active.Host, active.Port, active.Since.Format(time.RFC3339))
+	}
+
+	role, epoch, err := ClusterRole(standby)
+	if err != nil {
+		return nil, fmt.Errorf("standby not available: %w", err)
+	}
+	if _, primaryEpoch, err := ClusterRole(primary); err == nil && primaryEpoch > epoch {
+		epoch = primaryEpoch
+	}
+	epoch++
+
+	if role != ClusterRolePrimary {
+		if err := AssumeClusterRole(standby, ClusterRolePrimary, epoch); err != nil {
+			return nil, fmt.Errorf("promoting standby: %w", err)
+		}
+	}
+	_ = AssumeClusterRole(primary, ClusterRoleStandby, epoch)
+
+	prev, err := repointMetadata(beadsMetadataFiles(townRoot), standby)
+	active := &ActiveServer{
+		Host: standby.Host, Port: standby.Port, Epoch: epoch,
+		Since: now, Reason: reason, Metadata: prev,
+	}
+	// Record the promotion even if the re-point failed (and was rolled
+	// back): the standby is primary now and GetConnectionString follows it.
+	if saveErr := util.EnsureDirAndWriteJSON(ActiveServerFile(townRoot), active); saveErr != nil {
+		return nil, fmt.Errorf("recording failover: %w", saveErr)
+	}
+	// Cached in-process stores still hold connections to the old primary.
+	beads.CloseStores()
+	if err != nil {
+		return active, fmt.Errorf("standby promoted but re-pointing metadata failed: %w", err)
+	}
+	return active, nil
+}
+
+// FailbackReady reports whether the original primary can take over again:
+// it must be back as a standby and the active server must have replicated
+// to it with no lag.
+func FailbackReady(primary, active Endpoint) (bool, string) {
+	role, _, err := ClusterRole(primary)
+	if err != nil {
+		return false, fmt.Sprintf("primary not available: %v", err)
+	}
+	if role != ClusterRoleStandby {
+		return false, fmt.Sprintf("primary is %s, not standby", role)
+	}
+	statuses, err := ClusterStatus(active)
+	if err != nil {
+		return false, fmt.Sprintf("reading replication status: %v", err)
+	}
+	if !CaughtUp(statuses) {
+		return false, "primary has not caught up with the active server"
+	}
+	return true, ""
+}
+
+// Failback hands the primary role back to the original primary and restores
+// every re-pointed metadata.json. The active standby is demoted first, which
+// waits for the original primary to catch up, so no write is lost.
+func Failback(townRoot string, primary, standby Endpoint) error {
+	active, err := LoadActiveServer(townRoot)
+	if err != nil {
+		return err
+	}
+	if active == nil {
+		return ErrNotFailedOver
+	}
+
+	role, epoch, err := ClusterRole(primary)
+	if err != nil {
+		return fmt.Errorf("primary not available: %w", err)
+	}
+	if role != ClusterRoleStandby {
+		return fmt.Errorf("primary is %s, not standby; restart it so it rejoins the cluster", role)
+	}
+	if _, standbyEpoch, err := ClusterRole(standby); err == nil && standbyEpoch > epoch {
+		epoch = standbyEpoch
+	}
+	if active.Epoch > epoch {
+		epoch = active.Epoch
+	}
+	epoch++
+
+	if err := AssumeClusterRole(standby, ClusterRoleStandby, epoch); err != nil {
+		return fmt.Errorf("demoting standby (primary not caught up?): %w", err)
+	}
+	if err := AssumeClusterRole(primary, ClusterRolePrimary, epoch); err != nil {
+		// Put the standby back in charge rather than leave no primary.
+		_ = AssumeClusterRole(standby, ClusterRolePrimary, epoch+1)
+		return fmt.Errorf("promoting primary: %w", err)
+	}
+
+	err = restoreMetadata(active.Metadata)
+	beads.CloseStores()
+	if err != nil {
+		return fmt.Errorf("primary restored but metadata restore failed: %w", err)
+	}
+	return os.Remove(ActiveServerFile(townRoot))
+}
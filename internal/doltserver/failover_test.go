@@ -0,0 +1,199 @@
+package doltserver
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+func TestStandbyConfigDefaults(t *testing.T) {
+	s := StandbyConfig{Enabled: true}.WithDefaults("/town")
+	if s.Port != DefaultStandbyPort || s.RemotesAPIPort != DefaultStandbyRemotesAPIPort || s.PrimaryRemotesAPIPort != DefaultRemotesAPIPort {
+		t.Errorf("ports = %d/%d/%d", s.Port, s.RemotesAPIPort, s.PrimaryRemotesAPIPort)
+	}
+	if s.DataDir != filepath.Join("/town", ".dolt-standby") || s.FailoverAfter != DefaultFailoverAfter {
+		t.Errorf("defaults = %+v", s)
+	}
+	if !s.IsLocal() || s.Endpoint().HostPort() != "127.0.0.1:3308" {
+		t.Errorf("empty host should be a local standby on 127.0.0.1:3308, got %s", s.Endpoint().HostPort())
+	}
+	if (StandbyConfig{Host: "10.0.0.5"}).IsLocal() {
+		t.Error("10.0.0.5 should be a remote standby")
+	}
+}
+
+func TestClusterConfigYAML(t *testing.T) {
+	got := ClusterConfigYAML(ClusterNode{
+		Port: 3307, DataDir: "/town/.dolt-data", MaxConnections: 100,
+		Role: ClusterRolePrimary, RemotesAPIPort: 50051,
+		PeerName: "standby", PeerRemotesAPI: "127.0.0.1:50052",
+	})
+	for _, want := range []string{
+		`host: "127.0.0.1"`,
+		"port: 3307",
+		"max_connections: 100",
+		`data_dir: "/town/.dolt-data"`,
+		"- name: standby",
+		`remote_url_template: "http://127.0.0.1:50052/{database}"`,
+		"bootstrap_role: primary",
+		"bootstrap_epoch: 1",
+		"remotesapi:\n    port: 50051",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("config missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestServerArgsUsesClusterConfig(t *testing.T) {
+	townRoot := t.TempDir()
+	flags := []string{"sql-server", "--port", "3307"}
+	if got := ServerArgs(townRoot, flags); strings.Join(got, " ") != strings.Join(flags, " ") {
+		t.Errorf("without cluster config: %v", got)
+	}
+
+	if err := WriteClusterConfigs(townRoot, "127.0.0.1", 3307, filepath.Join(townRoot, ".dolt-data"), 0, StandbyConfig{Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	got := ServerArgs(townRoot, flags)
+	if len(got) != 3 || got[1] != "--config" || got[2] != ClusterConfigFile(townRoot) {
+		t.Errorf("with cluster config: %v", got)
+	}
+	standby, err := os.ReadFile(StandbyConfigFile(townRoot))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(standby), "bootstrap_role: standby") || !strings.Contains(string(standby), "http://127.0.0.1:50051/{database}") {
+		t.Errorf("standby config:\n%s", standby)
+	}
+
+	RemoveClusterConfigs(townRoot)
+	if got := ServerArgs(townRoot, flags); len(got) != len(flags) {
+		t.Errorf("after removal: %v", got)
+	}
+}
+
+func TestActiveConfigFollowsFailover(t *testing.T) {
+	t.Setenv("GT_DOLT_HOST", "")
+	t.Setenv("GT_DOLT_PORT", "")
+	townRoot := t.TempDir()
+
+	if got := ActiveConfig(townRoot).Port; got != DefaultPort {
+		t.Errorf("Port = %d before failover, want %d", got, DefaultPort)
+	}
+
+	active := &ActiveServer{Host: "10.0.0.5", Port: 3308, Epoch: 2, Since: time.Now()}
+	if err := util.EnsureDirAndWriteJSON(ActiveServerFile(townRoot), active); err != nil {
+		t.Fatal(err)
+	}
+	config := ActiveConfig(townRoot)
+	if config.HostPort() != "10.0.0.5:3308" {
+		t.Errorf("HostPort = %s after failover, want 10.0.0.5:3308", config.HostPort())
+	}
+	if !strings.Contains(GetConnectionString(townRoot), "tcp(10.0.0.5:3308)") {
+		t.Errorf("GetConnectionString = %s", GetConnectionString(townRoot))
+	}
+
+	t.Setenv("GT_DOLT_PORT", "4000")
+	if got := ActiveConfig(townRoot).Port; got != 4000 {
+		t.Errorf("explicit GT_DOLT_PORT should win, got %d", got)
+	}
+}
+
+func TestRepointAndRestoreMetadata(t *testing.T) {
+	dir := t.TempDir()
+	withServer := filepath.Join(dir, "a.json")
+	without := filepath.Join(dir, "b.json")
+	if err := os.WriteFile(withServer, []byte(`{"backend":"dolt","dolt_database":"hq","dolt_server_host":"127.0.0.1","dolt_server_port":3307}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(without, []byte(`{"backend":"dolt","dolt_database":"gastown"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	prev, err := repointMetadata([]string{withServer, without}, Endpoint{Host: "127.0.0.1", Port: 3308})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{withServer, without} {
+		meta := readMeta(t, path)
+		if meta["dolt_server_port"] != float64(3308) || meta["backend"] != "dolt" {
+			t.Errorf("%s after repoint = %v", filepath.Base(path), meta)
+		}
+	}
+
+	if err := restoreMetadata(prev); err != nil {
+		t.Fatal(err)
+	}
+	if meta := readMeta(t, withServer); meta["dolt_server_port"] != float64(3307) || meta["dolt_database"] != "hq" {
+		t.Errorf("a.json after restore = %v", meta)
+	}
+	if meta := readMeta(t, without); meta["dolt_server_port"] != nil || meta["dolt_server_host"] != nil {
+		t.Errorf("b.json after restore should have no server fields, got %v", meta)
+	}
+}
+
+func TestRepointMetadataAllOrNothing(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.json")
+	bad := filepath.Join(dir, "b.json")
+	if err := os.WriteFile(good, []byte(`{"backend":"dolt","dolt_server_port":3307}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(bad, []byte(`{not json`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repointMetadata([]string{good, bad}, Endpoint{Host: "127.0.0.1", Port: 3308}); err == nil {
+		t.Fatal("expected error for unparseable metadata")
+	}
+	if meta := readMeta(t, good); meta["dolt_server_port"] != float64(3307) {
+		t.Errorf("a.json re-pointed despite failure: %v", meta)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("staged temp files left behind: %d entries", len(entries))
+	}
+}
+
+func TestCaughtUp(t *testing.T) {
+	if !CaughtUp([]ReplicationStatus{{Database: "hq"}, {Database: "gastown"}}) {
+		t.Error("zero lag and no error should be caught up")
+	}
+	if CaughtUp([]ReplicationStatus{{Database: "hq", LagMillis: 120}}) {
+		t.Error("lagging database should not be caught up")
+	}
+	if CaughtUp([]ReplicationStatus{{Database: "hq", LagMillis: -1}}) {
+		t.Error("unknown lag should not be caught up")
+	}
+	if CaughtUp([]ReplicationStatus{{Database: "hq", Error: "connection refused"}}) {
+		t.Error("replication error should not be caught up")
+	}
+}
+
+func TestFailbackNotFailedOver(t *testing.T) {
+	if err := Failback(t.TempDir(), Endpoint{}, Endpoint{}); err != ErrNotFailedOver {
+		t.Errorf("Failback = %v, want ErrNotFailedOver", err)
+	}
+}
+
+func readMeta(t *testing.T, path string) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := map[string]interface{}{}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	return meta
+}
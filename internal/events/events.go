@@ -2,6 +2,10 @@
 //
 // Events are written to ~/gt/.events.jsonl (raw audit log) and later
 // curated by the feed daemon into ~/.feed.jsonl (user-facing).
+//
+// Events can also be forwarded to external sinks (webhooks, OTLP log
+// collectors, Unix sockets) configured via event_sinks in town settings.
+// See sinks.go.
 package events
 
 import (
@@ -12,6 +16,7 @@ import (
 	"time"
 
 	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/workspace"
 )
 
@@ -68,12 +73,23 @@ const (
 	TypeMerged       = "merged"
 	TypeMergeFailed  = "merge_failed"
 	TypeMergeSkipped = "merge_skipped"
+	TypeMQSLABreach  = "mq_sla_breach" // MR stayed in a phase past its SLA; escalated
+
+	// Target branch health (post-merge verification)
+	TypeMainBroken = "main.broken" // Merged commit failed verification; queue paused
+	TypeMainGreen  = "main.green"  // Target verified green again; queue resumed
 
 	// Scheduler events
 	TypeSchedulerEnqueue        = "scheduler_enqueue"         // Bead scheduled for deferred dispatch
 	TypeSchedulerDispatch       = "scheduler_dispatch"        // Bead dispatched from scheduler
 	TypeSchedulerDispatchFailed = "scheduler_dispatch_failed" // Bead dispatch failed (requeued)
 	TypeSchedulerCloseRetry     = "scheduler_close_retry"     // Context close needed last-resort attempt
+
+	// Nudge queue events
+	TypeNudgeState = "nudge_state" // Queued nudge changed delivery state
+
+	// Guard events
+	TypeGuardDecision = "guard_decision" // gt tap guard policy evaluated a tool call
 )
 
 // EventsFile is the name of the raw events log.
@@ -104,8 +120,8 @@ func LogAudit(eventType, actor string, payload map[string]interface{}) error {
 	return Log(eventType, actor, payload, VisibilityAudit)
 }
 
-// write appends an event to the events file.
-// Uses flock for cross-process synchronization — sync.Mutex only protects
+// write appends an event to the events file and queues it for any
+// configured event sinks. Uses flock for cross-process synchronization — sync.Mutex only protects
 // intra-process goroutines, but multiple gt processes write concurrently.
 func write(event Event) error {
 	// Find town root
@@ -115,7 +131,12 @@ func write(event Event) error {
 		return nil
 	}
 
-	eventsPath := filepath.Join(townRoot, EventsFile)
+	// Keep rig secret values out of the log and sinks.
+	if r := secrets.ForTown(townRoot); !r.Empty() {
+		if payload, ok := r.RedactValue(event.Payload).(map[string]interface{}); ok {
+			event.Payload = payload
+		}
+	}
 
 	// Marshal event to JSON
 	data, err := json.Marshal(event)
@@ -124,6 +145,19 @@ func write(event Event) error {
 	}
 	data = append(data, '\n')
 
+	if err := appendEvent(townRoot, data); err != nil {
+		return err
+	}
+
+	// Sinks only get a spool append here; the daemon delivers them.
+	enqueueSinks(townRoot, event, data)
+	return nil
+}
+
+// appendEvent appends an encoded event line to the town events file.
+func appendEvent(townRoot string, data []byte) error {
+	eventsPath := filepath.Join(townRoot, EventsFile)
+
 	// Acquire cross-process file lock
 	fl := flock.New(eventsPath + ".lock")
 	if err := fl.Lock(); err != nil {
@@ -225,6 +259,40 @@ func MergePayload(mrID, worker, branch, reason string) map[string]interface{} {
 	return p
 }
 
+// MainBrokenPayload creates a payload for main.broken events.
+func MainBrokenPayload(rig, target, commit, mrID, revertMR, reason string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":    rig,
+		"target": target,
+		"commit": commit,
+		"mr":     mrID,
+	}
+	if revertMR != "" {
+		p["revert_mr"] = revertMR
+	}
+	if reason != "" {
+		p["reason"] = reason
+	}
+	return p
+}
+
+// SLABreachPayload creates a payload for mq_sla_breach events.
+// phase: the MR phase it is stuck in
+// age, limit: time spent in the phase and the SLA, as duration strings
+func SLABreachPayload(rig, mrID, worker, phase, age, limit string) map[string]interface{} {
+	p := map[string]interface{}{
+		"rig":   rig,
+		"mr":    mrID,
+		"phase": phase,
+		"age":   age,
+		"limit": limit,
+	}
+	if worker != "" {
+		p["worker"] = worker
+	}
+	return p
+}
+
 // PatrolPayload creates a payload for patrol start/complete events.
 func PatrolPayload(rig string, polecatCount int, message string) map[string]interface{} {
 	p := map[string]interface{}{
@@ -259,6 +327,19 @@ func NudgePayload(rig, target, reason string) map[string]interface{} {
 	}
 }
 
+// NudgeStatePayload creates a payload for queued nudge delivery state events.
+func NudgeStatePayload(session, id, state, reason string) map[string]interface{} {
+	p := map[string]interface{}{
+		"session": session,
+		"id":      id,
+		"state":   state,
+	}
+	if reason != "" {
+		p["reason"] = reason
+	}
+	return p
+}
+
 // EscalationPayload creates a payload for escalation events.
 func EscalationPayload(rig, target, to, reason string) map[string]interface{} {
 	return map[string]interface{}{
@@ -368,3 +449,18 @@ func SchedulerDispatchFailedPayload(beadID, rig, errMsg string) map[string]inter
 		"error": errMsg,
 	}
 }
+
+// GuardDecisionPayload creates a payload for guard policy decisions.
+// subject is the Bash command or file path the decision was made on.
+func GuardDecisionPayload(tool, subject, decision, rule, scope string) map[string]interface{} {
+	p := map[string]interface{}{
+		"tool":     tool,
+		"subject":  subject,
+		"decision": decision,
+	}
+	if rule != "" {
+		p["rule"] = rule
+		p["scope"] = scope
+	}
+	return p
+}
@@ -0,0 +1,500 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	otellog "go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+)
+
+// SpoolDir is the directory (relative to the town root) holding per-sink
+// spools of events awaiting delivery.
+const SpoolDir = ".events-spool"
+
+const (
+	// defaultSinkTimeout bounds a single delivery attempt when the sink
+	// config does not set one.
+	defaultSinkTimeout = 5 * time.Second
+
+	// maxSpoolEntries caps each sink's spool. When exceeded the oldest
+	// entries are dropped so a dead endpoint cannot fill the disk.
+	maxSpoolEntries = 10000
+
+	// maxSpoolBytes bounds a spool between flushes. An append past it trims
+	// the spool to maxSpoolEntries, so events logged while no daemon is
+	// running cannot fill the disk either.
+	maxSpoolBytes = 64 << 20
+
+	// SignatureHeader carries the hex HMAC-SHA256 of the webhook body.
+	SignatureHeader = "X-Gastown-Signature"
+
+	// EventTypeHeader carries the event type on webhook deliveries.
+	EventTypeHeader = "X-Gastown-Event"
+)
+
+// sink delivers one encoded event to an external destination.
+type sink interface {
+	deliver(ctx context.Context, event Event, data []byte) error
+}
+
+// newSink builds the deliverer for a sink config.
+func newSink(cfg *config.EventSinkConfig) (sink, error) {
+	switch cfg.Type {
+	case config.EventSinkWebhook:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("webhook sink %q: url is required", cfg.SinkName())
+		}
+		var secret []byte
+		if cfg.SecretEnv != "" {
+			secret = []byte(os.Getenv(cfg.SecretEnv))
+		}
+		return &webhookSink{url: cfg.URL, secret: secret}, nil
+	case config.EventSinkOTLP:
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("otlp sink %q: url is required", cfg.SinkName())
+		}
+		return &otlpSink{url: cfg.URL}, nil
+	case config.EventSinkSocket:
+		if cfg.Path == "" {
+			return nil, fmt.Errorf("socket sink %q: path is required", cfg.SinkName())
+		}
+		return &socketSink{path: cfg.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown event sink type %q", cfg.Type)
+	}
+}
+
+// sinkMatches reports whether an event passes a sink's type and visibility filters.
+// An event with visibility "both" matches a filter for either "audit" or "feed".
+func sinkMatches(cfg *config.EventSinkConfig, event Event) bool {
+	if len(cfg.Types) > 0 && !contains(cfg.Types, event.Type) {
+		return false
+	}
+	if len(cfg.Visibility) == 0 {
+		return true
+	}
+	if contains(cfg.Visibility, event.Visibility) {
+		return true
+	}
+	if event.Visibility == VisibilityBoth {
+		return contains(cfg.Visibility, VisibilityAudit) || contains(cfg.Visibility, VisibilityFeed)
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// activeSink is an enabled sink config with its deliverer, or the error
+// that prevented building one.
+type activeSink struct {
+	cfg  *config.EventSinkConfig
+	sink sink
+	err  error
+}
+
+// townSinks caches a town's active sinks along with the settings file
+// version they were loaded from.
+type townSinks struct {
+	modTime time.Time
+	size    int64
+	active  []activeSink
+}
+
+// sinkCache holds each town's sinks so long-lived processes such as the
+// daemon re-read settings and rebuild deliverers (and their OTLP exporters)
+// only when town settings change, not once per event.
+var (
+	sinkCacheMu sync.Mutex
+	sinkCache   = map[string]*townSinks{}
+)
+
+// loadSinks returns the enabled event sinks from town settings.
+// Returns nil when settings are missing or unreadable (sinks are best-effort).
+func loadSinks(townRoot string) []activeSink {
+	path := config.TownSettingsPath(townRoot)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	sinkCacheMu.Lock()
+	defer sinkCacheMu.Unlock()
+	if cached := sinkCache[townRoot]; cached != nil && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.active
+	}
+
+	settings, err := config.LoadOrCreateTownSettings(path)
+	if err != nil || settings == nil {
+		return nil
+	}
+	if old := sinkCache[townRoot]; old != nil {
+		for _, a := range old.active {
+			if o, ok := a.sink.(*otlpSink); ok {
+				o.shutdown()
+			}
+		}
+	}
+	var active []activeSink
+	for _, cfg := range settings.EventSinks {
+		if cfg != nil && !cfg.Disabled {
+			s, err := newSink(cfg)
+			active = append(active, activeSink{cfg: cfg, sink: s, err: err})
+		}
+	}
+	sinkCache[townRoot] = &townSinks{modTime: info.ModTime(), size: info.Size(), active: active}
+	return active
+}
+
+// enqueueSinks appends an event to the spool of every configured sink whose
+// filters match. It does no network I/O: the daemon delivers spooled events
+// (see FlushSpools), so a slow endpoint never holds up the process logging
+// the event.
+func enqueueSinks(townRoot string, event Event, data []byte) {
+	for _, a := range loadSinks(townRoot) {
+		if a.err != nil || !sinkMatches(a.cfg, event) {
+			continue
+		}
+		_ = appendSpool(spoolPathFor(townRoot, a.cfg), data)
+	}
+}
+
+// appendSpool appends one encoded event line to a sink's spool.
+func appendSpool(spoolPath string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(spoolPath), 0755); err != nil {
+		return fmt.Errorf("creating spool dir: %w", err)
+	}
+	fl := flock.New(spoolPath + ".lock")
+	if err := fl.Lock(); err != nil {
+		return fmt.Errorf("acquiring spool lock: %w", err)
+	}
+	defer fl.Unlock() //nolint:errcheck // best-effort unlock
+
+	f, err := os.OpenFile(spoolPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("opening spool: %w", err)
+	}
+	if _, err := f.Write(append(bytes.TrimRight(data, "\n"), '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing spool: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("closing spool: %w", err)
+	}
+
+	info, err := os.Stat(spoolPath)
+	if err != nil || info.Size() <= maxSpoolBytes {
+		return nil
+	}
+	// Trim only while no flush is in progress: a flush drops the lines it
+	// delivered from the head of the spool and must find them still there.
+	dl := flock.New(spoolPath + ".deliver.lock")
+	if locked, err := dl.TryLock(); err != nil || !locked {
+		return nil
+	}
+	defer dl.Unlock() //nolint:errcheck // best-effort unlock
+	lines, err := readSpool(spoolPath)
+	if err != nil {
+		return err
+	}
+	return writeSpool(spoolPath, lines)
+}
+
+// FlushSpools delivers spooled events for every configured sink, in order.
+// Returns the number of events delivered. The daemon calls it on a short
+// interval; events stay spooled while an endpoint is down.
+func FlushSpools(townRoot string) (int, error) {
+	delivered := 0
+	var errs []string
+	for _, a := range loadSinks(townRoot) {
+		n, err := flushSpool(townRoot, a.cfg, a.sink, a.err)
+		delivered += n
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", a.cfg.SinkName(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return delivered, fmt.Errorf("flushing event spools: %s", strings.Join(errs, "; "))
+	}
+	return delivered, nil
+}
+
+// flushSpool delivers a sink's spooled events. The spool lock is held only
+// to snapshot the spool and to drop the delivered lines, never across network
+// I/O, so processes appending events are not blocked by a slow endpoint.
+// A separate delivery lock keeps concurrent flushers from sending twice.
+func flushSpool(townRoot string, cfg *config.EventSinkConfig, s sink, sinkErr error) (int, error) {
+	spoolPath := spoolPathFor(townRoot, cfg)
+	if _, err := os.Stat(spoolPath); os.IsNotExist(err) {
+		return 0, nil
+	}
+	if sinkErr != nil {
+		return 0, sinkErr
+	}
+
+	dl := flock.New(spoolPath + ".deliver.lock")
+	locked, err := dl.TryLock()
+	if err != nil {
+		return 0, fmt.Errorf("acquiring delivery lock: %w", err)
+	}
+	if !locked {
+		return 0, nil // another process is flushing this sink
+	}
+	defer dl.Unlock() //nolint:errcheck // best-effort unlock
+
+	pending, err := withSpoolLock(spoolPath, func() ([][]byte, error) {
+		return readSpool(spoolPath)
+	})
+	if err != nil {
+		return 0, err
+	}
+	timeout := config.ParseDurationOrDefault(cfg.Timeout, defaultSinkTimeout)
+	remaining := replay(s, pending, timeout)
+	done := len(pending) - len(remaining)
+	if done == 0 {
+		return 0, nil
+	}
+
+	// Only appends happen while we deliver, so the delivered lines are still
+	// the head of the spool.
+	_, err = withSpoolLock(spoolPath, func() ([][]byte, error) {
+		lines, err := readSpool(spoolPath)
+		if err != nil {
+			return nil, err
+		}
+		return nil, writeSpool(spoolPath, lines[min(done, len(lines)):])
+	})
+	return done, err
+}
+
+// withSpoolLock runs fn while holding the spool's append lock.
+func withSpoolLock(spoolPath string, fn func() ([][]byte, error)) ([][]byte, error) {
+	fl := flock.New(spoolPath + ".lock")
+	if err := fl.Lock(); err != nil {
+		return nil, fmt.Errorf("acquiring spool lock: %w", err)
+	}
+	defer fl.Unlock() //nolint:errcheck // best-effort unlock
+	return fn()
+}
+
+// replay delivers spooled lines in order, stopping at the first failure.
+// Returns the lines still pending. Undecodable lines are dropped.
+func replay(s sink, pending [][]byte, timeout time.Duration) [][]byte {
+	for i, line := range pending {
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := s.deliver(ctx, event, append(line, '\n'))
+		cancel()
+		if err != nil {
+			return pending[i:]
+		}
+	}
+	return nil
+}
+
+// spoolPathFor returns the spool file for a sink.
+func spoolPathFor(townRoot string, cfg *config.EventSinkConfig) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == os.PathSeparator || r == ' ' {
+			return '_'
+		}
+		return r
+	}, cfg.SinkName())
+	return filepath.Join(townRoot, SpoolDir, name+".jsonl")
+}
+
+func readSpool(path string) ([][]byte, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("opening spool: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		lines = append(lines, append([]byte(nil), line...))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading spool: %w", err)
+	}
+	return lines, nil
+}
+
+// writeSpool atomically replaces the spool with lines, keeping only the newest
+// maxSpoolEntries. An empty slice removes the spool file.
+func writeSpool(path string, lines [][]byte) error {
+	if len(lines) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing spool: %w", err)
+		}
+		return nil
+	}
+	if len(lines) > maxSpoolEntries {
+		lines = lines[len(lines)-maxSpoolEntries:]
+	}
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("writing spool: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("replacing spool: %w", err)
+	}
+	return nil
+}
+
+// webhookSink POSTs each event as JSON, optionally signed with HMAC-SHA256.
+type webhookSink struct {
+	url    string
+	secret []byte
+}
+
+// SignPayload returns the hex-encoded HMAC-SHA256 of body under secret,
+// in the form sent in the X-Gastown-Signature header ("sha256=<hex>").
+func SignPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (w *webhookSink) deliver(ctx context.Context, event Event, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("building webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, event.Type)
+	if len(w.secret) > 0 {
+		req.Header.Set(SignatureHeader, SignPayload(w.secret, data))
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpSink exports each event as an OTLP log record over HTTP.
+// It uses its own exporter rather than the global telemetry provider so that
+// events are shipped even when GT_OTEL_* telemetry is disabled, and so that
+// failures are visible to the spool instead of being dropped by a batcher.
+// The exporter is created on first delivery and reused until shutdown.
+type otlpSink struct {
+	url string
+
+	mu  sync.Mutex
+	exp *otlploghttp.Exporter
+}
+
+func (o *otlpSink) exporter() (*otlploghttp.Exporter, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.exp == nil {
+		exp, err := otlploghttp.New(context.Background(), otlploghttp.WithEndpointURL(o.url))
+		if err != nil {
+			return nil, fmt.Errorf("creating OTLP log exporter: %w", err)
+		}
+		o.exp = exp
+	}
+	return o.exp, nil
+}
+
+// shutdown releases the cached exporter, if any.
+func (o *otlpSink) shutdown() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.exp != nil {
+		_ = o.exp.Shutdown(context.Background())
+		o.exp = nil
+	}
+}
+
+func (o *otlpSink) deliver(ctx context.Context, event Event, data []byte) error {
+	exp, err := o.exporter()
+	if err != nil {
+		return err
+	}
+
+	var r sdklog.Record
+	if ts, perr := time.Parse(time.RFC3339, event.Timestamp); perr == nil {
+		r.SetTimestamp(ts)
+	}
+	r.SetObservedTimestamp(time.Now())
+	r.SetSeverity(otellog.SeverityInfo)
+	r.SetBody(otellog.StringValue("gt.event"))
+	r.AddAttributes(
+		otellog.String("event.type", event.Type),
+		otellog.String("event.source", event.Source),
+		otellog.String("event.actor", event.Actor),
+		otellog.String("event.visibility", event.Visibility),
+		otellog.String("event.json", string(bytes.TrimRight(data, "\n"))),
+	)
+	if err := exp.Export(ctx, []sdklog.Record{r}); err != nil {
+		return fmt.Errorf("exporting OTLP log: %w", err)
+	}
+	return nil
+}
+
+// socketSink writes newline-delimited JSON to a Unix domain socket.
+type socketSink struct {
+	path string
+}
+
+func (u *socketSink) deliver(ctx context.Context, _ Event, data []byte) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", u.path)
+	if err != nil {
+		return fmt.Errorf("dialing event socket: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("writing event socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func testEvent(eventType, visibility string) (Event, []byte) {
+	e := Event{
+		Timestamp:  "2026-01-01T00:00:00Z",
+		Source:     "gt",
+		Type:       eventType,
+		Actor:      "gastown/polecats/Toast",
+		Payload:    map[string]interface{}{"bead": "gt-1"},
+		Visibility: visibility,
+	}
+	data, _ := json.Marshal(e)
+	return e, append(data, '\n')
+}
+
+// deliverNow spools an event for cfg and flushes the spool, as the daemon would.
+func deliverNow(t *testing.T, townRoot string, cfg *config.EventSinkConfig, data []byte) (int, error) {
+	t.Helper()
+	s, err := newSink(cfg)
+	if err != nil {
+		t.Fatalf("newSink: %v", err)
+	}
+	if err := appendSpool(spoolPathFor(townRoot, cfg), data); err != nil {
+		t.Fatalf("appendSpool: %v", err)
+	}
+	return flushSpool(townRoot, cfg, s, nil)
+}
+
+func TestSinkMatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.EventSinkConfig
+		eventType  string
+		visibility string
+		want       bool
+	}{
+		{"no filters", config.EventSinkConfig{}, TypeSling, VisibilityAudit, true},
+		{"type match", config.EventSinkConfig{Types: []string{TypeDone, TypeSling}}, TypeSling, VisibilityFeed, true},
+		{"type miss", config.EventSinkConfig{Types: []string{TypeDone}}, TypeSling, VisibilityFeed, false},
+		{"visibility match", config.EventSinkConfig{Visibility: []string{VisibilityFeed}}, TypeSling, VisibilityFeed, true},
+		{"visibility miss", config.EventSinkConfig{Visibility: []string{VisibilityFeed}}, TypeSling, VisibilityAudit, false},
+		{"both matches feed filter", config.EventSinkConfig{Visibility: []string{VisibilityFeed}}, TypeSling, VisibilityBoth, true},
+		{"both matches audit filter", config.EventSinkConfig{Visibility: []string{VisibilityAudit}}, TypeSling, VisibilityBoth, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, _ := testEvent(tt.eventType, tt.visibility)
+			if got := sinkMatches(&tt.cfg, e); got != tt.want {
+				t.Errorf("sinkMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookSink_SignsBody(t *testing.T) {
+	t.Setenv("GT_TEST_SINK_SECRET", "s3cret")
+
+	var gotSig, gotType string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSig = r.Header.Get(SignatureHeader)
+		gotType = r.Header.Get(EventTypeHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	townRoot := t.TempDir()
+	cfg := &config.EventSinkConfig{Type: config.EventSinkWebhook, URL: srv.URL, SecretEnv: "GT_TEST_SINK_SECRET"}
+	_, data := testEvent(TypeDone, VisibilityFeed)
+
+	if n, err := deliverNow(t, townRoot, cfg, data); err != nil || n != 1 {
+		t.Fatalf("deliverNow = %d, %v; want 1 delivered", n, err)
+	}
+	if gotType != TypeDone {
+		t.Errorf("event type header = %q, want %q", gotType, TypeDone)
+	}
+	if want := SignPayload([]byte("s3cret"), gotBody); gotSig != want {
+		t.Errorf("signature = %q, want %q", gotSig, want)
+	}
+	if _, err := os.Stat(spoolPathFor(townRoot, cfg)); !os.IsNotExist(err) {
+		t.Errorf("expected no spool after successful delivery, stat err = %v", err)
+	}
+}
+
+func TestWebhookSink_SpoolsAndReplaysInOrder(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var e Event
+		_ = json.NewDecoder(r.Body).Decode(&e)
+		received = append(received, e.Type)
+	}))
+	defer srv.Close()
+
+	townRoot := t.TempDir()
+	cfg := &config.EventSinkConfig{Name: "incident", Type: config.EventSinkWebhook, URL: srv.URL}
+
+	_, d1 := testEvent(TypeSling, VisibilityFeed)
+	if n, _ := deliverNow(t, townRoot, cfg, d1); n != 0 {
+		t.Fatalf("delivered %d while endpoint is failing, want 0", n)
+	}
+	spooled, err := readSpool(spoolPathFor(townRoot, cfg))
+	if err != nil || len(spooled) != 1 {
+		t.Fatalf("spool = %d entries (err %v), want 1", len(spooled), err)
+	}
+
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+
+	_, d2 := testEvent(TypeDone, VisibilityFeed)
+	if n, err := deliverNow(t, townRoot, cfg, d2); err != nil || n != 2 {
+		t.Fatalf("deliverNow after recovery = %d, %v; want 2 delivered", n, err)
+	}
+	if len(received) != 2 || received[0] != TypeSling || received[1] != TypeDone {
+		t.Errorf("received = %v, want [sling done]", received)
+	}
+	if _, err := os.Stat(spoolPathFor(townRoot, cfg)); !os.IsNotExist(err) {
+		t.Errorf("expected spool removed after drain, stat err = %v", err)
+	}
+}
+
+func TestFlushSpools(t *testing.T) {
+	var count int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+	}))
+	defer srv.Close()
+
+	townRoot := t.TempDir()
+	settings := config.NewTownSettings()
+	settings.EventSinks = []*config.EventSinkConfig{{Name: "hook", Type: config.EventSinkWebhook, URL: srv.URL}}
+	if err := config.SaveTownSettings(config.TownSettingsPath(townRoot), settings); err != nil {
+		t.Fatal(err)
+	}
+
+	_, d1 := testEvent(TypeSling, VisibilityFeed)
+	_, d2 := testEvent(TypeDone, VisibilityFeed)
+	path := spoolPathFor(townRoot, settings.EventSinks[0])
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSpool(path, [][]byte{d1[:len(d1)-1], d2[:len(d2)-1]}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := FlushSpools(townRoot)
+	if err != nil {
+		t.Fatalf("FlushSpools: %v", err)
+	}
+	if n != 2 || count != 2 {
+		t.Errorf("delivered = %d (server saw %d), want 2", n, count)
+	}
+}
+
+func TestEnqueueSinks_SpoolsWithoutDelivering(t *testing.T) {
+	var mu sync.Mutex
+	count := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	townRoot := t.TempDir()
+	settings := config.NewTownSettings()
+	settings.EventSinks = []*config.EventSinkConfig{
+		{Name: "done-only", Type: config.EventSinkWebhook, URL: srv.URL, Types: []string{TypeDone}},
+	}
+	if err := config.SaveTownSettings(config.TownSettingsPath(townRoot), settings); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, typ := range []string{TypeDone, TypeSling, TypeDone} {
+		e, data := testEvent(typ, VisibilityFeed)
+		enqueueSinks(townRoot, e, data)
+	}
+	mu.Lock()
+	if count != 0 {
+		t.Errorf("server saw %d deliveries while enqueueing, want 0", count)
+	}
+	mu.Unlock()
+	spooled, err := readSpool(spoolPathFor(townRoot, settings.EventSinks[0]))
+	if err != nil || len(spooled) != 2 {
+		t.Fatalf("spool = %d entries (err %v), want 2 matching events", len(spooled), err)
+	}
+
+	if n, err := FlushSpools(townRoot); err != nil || n != 2 {
+		t.Fatalf("FlushSpools = %d, %v; want 2", n, err)
+	}
+	if _, err := os.Stat(spoolPathFor(townRoot, settings.EventSinks[0])); !os.IsNotExist(err) {
+		t.Errorf("expected spool removed after flush, stat err = %v", err)
+	}
+}
+
+func TestSocketSink(t *testing.T) {
+	// Unix socket paths have a short length limit, so avoid t.TempDir().
+	dir, err := os.MkdirTemp("", "gtsock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "events.sock")
+
+	ln, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+
+	cfg := &config.EventSinkConfig{Type: config.EventSinkSocket, Path: sockPath}
+	_, data := testEvent(TypeMassDeath, VisibilityBoth)
+	if _, err := deliverNow(t, t.TempDir(), cfg, data); err != nil {
+		t.Fatalf("deliverNow: %v", err)
+	}
+	if got := <-lines; got != string(data) {
+		t.Errorf("socket received %q, want %q", got, data)
+	}
+}
+
+func TestNewSink_Validation(t *testing.T) {
+	for _, cfg := range []*config.EventSinkConfig{
+		{Type: config.EventSinkWebhook},
+		{Type: config.EventSinkOTLP},
+		{Type: config.EventSinkSocket},
+		{Type: "carrier-pigeon"},
+	} {
+		if _, err := newSink(cfg); err == nil {
+			t.Errorf("newSink(%+v) = nil error, want error", cfg)
+		}
+	}
+}
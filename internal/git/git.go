@@ -368,6 +368,26 @@ func (g *Git) Checkout(ref string) error {
 	return err
 }
 
+// CheckoutNewBranch creates (or resets) branch at startPoint and checks it out.
+// Skips LFS smudge filter during checkout (see WorktreeAddFromRef).
+func (g *Git) CheckoutNewBranch(branch, startPoint string) error {
+	_, err := g.runWithEnv(
+		[]string{"checkout", "-B", branch, startPoint},
+		[]string{"GIT_LFS_SKIP_SMUDGE=1"},
+	)
+	return err
+}
+
+// CheckoutDetached checks out ref with a detached HEAD.
+// Skips LFS smudge filter during checkout (see WorktreeAddFromRef).
+func (g *Git) CheckoutDetached(ref string) error {
+	_, err := g.runWithEnv(
+		[]string{"checkout", "--detach", ref},
+		[]string{"GIT_LFS_SKIP_SMUDGE=1"},
+	)
+	return err
+}
+
 // Fetch fetches from the remote.
 func (g *Git) Fetch(remote string) error {
 	_, err := g.run("fetch", remote)
@@ -779,6 +799,97 @@ func (g *Git) GetConflictingFiles() ([]string, error) {
 	return result, nil
 }
 
+// ConfigSet sets a git config key in the repository's local config.
+func (g *Git) ConfigSet(key, value string) error {
+	_, err := g.run("config", key, value)
+	return err
+}
+
+// MergeSquashNoCommit stages a squash merge of branch without committing.
+// Unlike MergeSquash, conflicts are left in the index and working tree so
+// they can be inspected or resolved; callers must reset on failure.
+func (g *Git) MergeSquashNoCommit(branch string) error {
+	_, err := g.runMergeCheck("merge", "--squash", branch)
+	return err
+}
+
+// ShowStage returns a path's content at an index stage during a conflicted
+// merge: 1 is the common ancestor, 2 is ours (HEAD) and 3 is theirs.
+func (g *Git) ShowStage(stage int, path string) (string, error) {
+	cmd := exec.Command("git", "show", fmt.Sprintf(":%d:%s", stage, path))
+	cmd.Dir = g.workDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", g.wrapError(err, stdout.String(), stderr.String(), []string{"show", fmt.Sprintf(":%d:%s", stage, path)})
+	}
+	// Not trimmed: callers write the content back verbatim.
+	return stdout.String(), nil
+}
+
+// RerereRemaining returns conflicted paths that rerere did not resolve from
+// its recorded resolutions.
+func (g *Git) RerereRemaining() ([]string, error) {
+	out, err := g.run("rerere", "remaining")
+	if err != nil {
+		return nil, err
+	}
+	if out == "" {
+		return nil, nil
+	}
+	return strings.Split(out, "\n"), nil
+}
+
+// CheckoutStage replaces a conflicted path with one side of the merge:
+// "--ours" or "--theirs".
+func (g *Git) CheckoutStage(side, path string) error {
+	_, err := g.run("checkout", side, "--", path)
+	return err
+}
+
+// RebaseOnto replays the commits of the current HEAD that are not in
+// upstream onto newBase (git rebase --onto newBase upstream).
+func (g *Git) RebaseOnto(newBase, upstream string) error {
+	_, err := g.run("rebase", "--onto", newBase, upstream)
+	return err
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (g *Git) MergeBase(a, b string) (string, error) {
+	return g.run("merge-base", a, b)
+}
+
+// UpdateRef points ref at newSHA, only if it currently points at oldSHA.
+// Unlike checkout-based updates this works for branches checked out in
+// another worktree.
+func (g *Git) UpdateRef(ref, newSHA, oldSHA string) error {
+	_, err := g.run("update-ref", ref, newSHA, oldSHA)
+	return err
+}
+
+// PushForceWithLease pushes src to branch on remote, overwriting it only if
+// the remote branch is still at expectSHA.
+func (g *Git) PushForceWithLease(remote, src, branch, expectSHA string) error {
+	_, err := g.run("push", fmt.Sprintf("--force-with-lease=%s:%s", branch, expectSHA),
+		remote, src+":refs/heads/"+branch)
+	return err
+}
+
+// RevertRangeNoCommit applies the reverse of every commit in base..head to
+// the index and worktree without committing, so the range can be undone in
+// a single commit.
+func (g *Git) RevertRangeNoCommit(base, head string) error {
+	_, err := g.run("revert", "--no-commit", base+".."+head)
+	return err
+}
+
+// AbortRevert aborts a revert in progress.
+func (g *Git) AbortRevert() error {
+	_, err := g.run("revert", "--abort")
+	return err
+}
+
 // AbortRebase aborts a rebase in progress.
 func (g *Git) AbortRebase() error {
 	_, err := g.run("rebase", "--abort")
@@ -1032,6 +1143,13 @@ func (g *Git) WorktreeRemove(path string, force bool) error {
 	return err
 }
 
+// WorktreeMove moves a worktree to a new path, keeping its registration.
+// Git refuses to move worktrees with initialized submodules.
+func (g *Git) WorktreeMove(from, to string) error {
+	_, err := g.run("worktree", "move", from, to)
+	return err
+}
+
 // WorktreePrune removes worktree entries for deleted paths.
 func (g *Git) WorktreePrune() error {
 	_, err := g.run("worktree", "prune")
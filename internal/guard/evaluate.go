@@ -0,0 +1,160 @@
+package guard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+)
+
+// Input is a single PreToolUse tool call as seen by the guard.
+type Input struct {
+	Tool    string // Claude Code tool name, e.g. "Bash" or "Edit"
+	Command string // Bash command, if any
+	Path    string // file_path or notebook_path, if any
+	Role    string // Agent role, or RoleHuman outside agent sessions
+	Actor   string // Agent address used to key rate limits, e.g. "gastown/polecats/Toast"
+}
+
+// hookPayload is the JSON Claude Code writes to a PreToolUse hook's stdin.
+type hookPayload struct {
+	ToolName  string `json:"tool_name"`
+	ToolInput struct {
+		Command      string `json:"command"`
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	} `json:"tool_input"`
+}
+
+// ParseHookInput reads a PreToolUse payload. Role and Actor are left for the
+// caller to fill in.
+func ParseHookInput(r io.Reader) (Input, error) {
+	var p hookPayload
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return Input{}, fmt.Errorf("parsing hook input: %w", err)
+	}
+	in := Input{Tool: p.ToolName, Command: p.ToolInput.Command, Path: p.ToolInput.FilePath}
+	if in.Path == "" {
+		in.Path = p.ToolInput.NotebookPath
+	}
+	return in, nil
+}
+
+// Decision is the outcome of evaluating a tool call against a policy.
+type Decision struct {
+	Action  string // ActionAllow or ActionDeny
+	Rule    string // Matching rule name; empty when no rule matched
+	Scope   string // "town" or rig name of the matching rule
+	Message string // Rule message, or a generated one for limits
+	Count   int    // limit: matching calls in the current window, including this one if allowed
+	Max     int    // limit: calls allowed per window
+}
+
+// Blocked reports whether the tool call must be blocked.
+func (d Decision) Blocked() bool {
+	return d.Action == ActionDeny
+}
+
+// Evaluate returns the decision of the first rule matching in. Limit rules
+// consume from limiter; a nil limiter never blocks on limits.
+func (p *Policy) Evaluate(in Input, limiter *Limiter, now time.Time) (Decision, error) {
+	for _, r := range p.Rules {
+		if !r.matches(in) {
+			continue
+		}
+		d := Decision{Action: r.Action, Rule: r.Name, Scope: r.Scope, Message: r.Message}
+		if r.Action != ActionLimit {
+			return d, nil
+		}
+
+		d.Action, d.Max = ActionAllow, r.Max
+		if limiter == nil {
+			return d, nil
+		}
+		window, _ := r.window() // validated at load
+		count, ok, err := limiter.Take(r.Scope+"/"+r.Name+"/"+in.Actor, r.Max, window, now)
+		if err != nil {
+			return d, err
+		}
+		d.Count = count
+		if !ok {
+			d.Action = ActionDeny
+			if d.Message == "" {
+				d.Message = fmt.Sprintf("Limit reached: %d per %s.", r.Max, window)
+			}
+		}
+		return d, nil
+	}
+	return Decision{Action: ActionAllow}, nil
+}
+
+// staleAfter is how long an idle rate limit key is kept in the state file.
+const staleAfter = 7 * 24 * time.Hour
+
+// Limiter records rate-limited calls in a JSON state file shared by every gt
+// process in the town.
+type Limiter struct {
+	path string
+}
+
+// NewLimiter returns a limiter whose state lives under the town's runtime
+// directory.
+func NewLimiter(townRoot string) *Limiter {
+	return &Limiter{path: filepath.Join(townRoot, ".runtime", "guard", "limits.json")}
+}
+
+// Take records a call against key unless max calls already happened within
+// window. It returns the number of calls in the window and whether this one
+// was allowed.
+func (l *Limiter) Take(key string, max int, window time.Duration, now time.Time) (int, bool, error) {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return 0, false, fmt.Errorf("creating guard state dir: %w", err)
+	}
+	fl := flock.New(l.path + ".lock")
+	if err := fl.Lock(); err != nil {
+		return 0, false, fmt.Errorf("locking guard state: %w", err)
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	state := make(map[string][]int64)
+	if data, err := os.ReadFile(l.path); err == nil {
+		_ = json.Unmarshal(data, &state) // corrupt state resets the counters
+	}
+
+	cutoff := now.Add(-window).Unix()
+	kept := state[key][:0]
+	for _, ts := range state[key] {
+		if ts > cutoff {
+			kept = append(kept, ts)
+		}
+	}
+	state[key] = kept
+
+	// Other keys have their own windows; drop only ones idle for a week so
+	// the file does not grow without bound.
+	stale := now.Add(-staleAfter).Unix()
+	for k, stamps := range state {
+		if k != key && (len(stamps) == 0 || stamps[len(stamps)-1] < stale) {
+			delete(state, k)
+		}
+	}
+
+	count := len(state[key])
+	if count >= max {
+		return count, false, nil
+	}
+	state[key] = append(state[key], now.Unix())
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return 0, false, err
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		return 0, false, fmt.Errorf("writing guard state: %w", err)
+	}
+	return count + 1, true, nil
+}
@@ -0,0 +1,321 @@
+// Package guard implements the declarative PreToolUse policy engine behind
+// `gt tap guard policy`.
+//
+// Policies live in settings/guard-policy.json at the town level and in
+// <rig>/settings/guard-policy.json for each rig. Rules are evaluated in order,
+// rig rules before town rules, and the first matching rule decides. Tool
+// calls that match no rule are allowed.
+package guard
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PolicyFile is the file name of a guard policy inside a settings directory.
+const PolicyFile = "guard-policy.json"
+
+// Rule actions.
+const (
+	ActionAllow = "allow" // Permit the tool call and stop evaluating
+	ActionDeny  = "deny"  // Block the tool call
+	ActionLimit = "limit" // Permit up to Max matching calls per Window, then block
+)
+
+// RoleHuman scopes a rule to sessions that are not Gas Town agents.
+const RoleHuman = "human"
+
+// DefaultWindow is the rate limit window used when a limit rule omits one.
+const DefaultWindow = 24 * time.Hour
+
+// BashTool is the Claude Code tool name for shell commands.
+const BashTool = "Bash"
+
+// FileTools are the tools a path rule applies to when it names none.
+var FileTools = []string{"Edit", "Write", "MultiEdit", "NotebookEdit"}
+
+var validRoles = map[string]bool{
+	"mayor": true, "deacon": true, "witness": true, "refinery": true,
+	"crew": true, "polecat": true, RoleHuman: true,
+}
+
+// Policy is an ordered list of guard rules.
+type Policy struct {
+	Version int     `json:"version"`
+	Rules   []*Rule `json:"rules"`
+}
+
+// Rule matches Bash commands or file paths and decides what happens to them.
+// Patterns are globs where * matches any run of characters (including / and
+// spaces) and ? matches a single character; they must match the whole
+// command or path.
+type Rule struct {
+	Name     string   `json:"name"`
+	Action   string   `json:"action"`
+	Tools    []string `json:"tools,omitempty"`    // Defaults to Bash for commands, FileTools for paths
+	Commands []string `json:"commands,omitempty"` // Globs matched against each segment of a Bash command
+	Paths    []string `json:"paths,omitempty"`    // Globs matched against file_path / notebook_path
+	Roles    []string `json:"roles,omitempty"`    // Empty means every role
+	Message  string   `json:"message,omitempty"`  // Shown to the agent when the rule blocks
+	Max      int      `json:"max,omitempty"`      // limit: calls allowed per window
+	Window   string   `json:"window,omitempty"`   // limit: Go duration, default 24h
+
+	// Scope is "town" or the rig name the rule was loaded from.
+	Scope string `json:"-"`
+}
+
+// TownPolicyPath returns the town-level policy path.
+func TownPolicyPath(townRoot string) string {
+	return filepath.Join(townRoot, "settings", PolicyFile)
+}
+
+// RigPolicyPath returns the policy path for a rig.
+func RigPolicyPath(townRoot, rig string) string {
+	return filepath.Join(townRoot, rig, "settings", PolicyFile)
+}
+
+// Load returns the effective policy for a rig: the rig's rules followed by
+// the town's. An empty rig loads only the town policy. Missing files yield
+// an empty policy.
+func Load(townRoot, rig string) (*Policy, error) {
+	result := &Policy{Version: 1}
+	if rig != "" {
+		p, err := LoadFile(RigPolicyPath(townRoot, rig), rig)
+		if err != nil {
+			return nil, err
+		}
+		result.Rules = append(result.Rules, p.Rules...)
+	}
+	p, err := LoadFile(TownPolicyPath(townRoot), "town")
+	if err != nil {
+		return nil, err
+	}
+	result.Rules = append(result.Rules, p.Rules...)
+	return result, nil
+}
+
+// LoadFile reads and validates a single policy file, tagging its rules with
+// scope. A missing file yields an empty policy.
+func LoadFile(path, scope string) (*Policy, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed internally
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &Policy{Version: 1}, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, r := range p.Rules {
+		r.Scope = scope
+	}
+	return &p, nil
+}
+
+// Validate checks rule names, actions, roles and limits.
+func (p *Policy) Validate() error {
+	if p.Version > 1 {
+		return fmt.Errorf("unsupported policy version %d", p.Version)
+	}
+	seen := make(map[string]bool)
+	for i, r := range p.Rules {
+		if r == nil {
+			return fmt.Errorf("rule %d is empty", i)
+		}
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate rule name %q", r.Name)
+		}
+		seen[r.Name] = true
+
+		switch r.Action {
+		case ActionAllow, ActionDeny:
+		case ActionLimit:
+			if r.Max <= 0 {
+				return fmt.Errorf("rule %q: limit requires max > 0", r.Name)
+			}
+		default:
+			return fmt.Errorf("rule %q: unknown action %q (want allow, deny or limit)", r.Name, r.Action)
+		}
+		if _, err := r.window(); err != nil {
+			return fmt.Errorf("rule %q: invalid window %q: %w", r.Name, r.Window, err)
+		}
+		if len(r.Commands) == 0 && len(r.Paths) == 0 {
+			return fmt.Errorf("rule %q: needs commands or paths", r.Name)
+		}
+		for _, role := range r.Roles {
+			if !validRoles[role] {
+				return fmt.Errorf("rule %q: unknown role %q", r.Name, role)
+			}
+		}
+	}
+	return nil
+}
+
+// window returns the rule's rate limit window.
+func (r *Rule) window() (time.Duration, error) {
+	if r.Window == "" {
+		return DefaultWindow, nil
+	}
+	d, err := time.ParseDuration(r.Window)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return d, nil
+}
+
+// tools returns the tools the rule applies to.
+func (r *Rule) tools() []string {
+	if len(r.Tools) > 0 {
+		return r.Tools
+	}
+	var tools []string
+	if len(r.Commands) > 0 {
+		tools = append(tools, BashTool)
+	}
+	if len(r.Paths) > 0 {
+		tools = append(tools, FileTools...)
+	}
+	return tools
+}
+
+// appliesToRole reports whether the rule is scoped to role.
+func (r *Rule) appliesToRole(role string) bool {
+	if len(r.Roles) == 0 {
+		return true
+	}
+	for _, want := range r.Roles {
+		if want == role {
+			return true
+		}
+	}
+	return false
+}
+
+// appliesToTool reports whether the rule covers tool.
+func (r *Rule) appliesToTool(tool string) bool {
+	for _, t := range r.tools() {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the rule matches the tool call.
+func (r *Rule) matches(in Input) bool {
+	if !r.appliesToTool(in.Tool) || !r.appliesToRole(in.Role) {
+		return false
+	}
+	if in.Command != "" {
+		for _, seg := range commandSegments(in.Command) {
+			for _, pat := range r.Commands {
+				if matchGlob(pat, seg) {
+					return true
+				}
+			}
+		}
+	}
+	if in.Path != "" {
+		for _, pat := range r.Paths {
+			if matchGlob(pat, in.Path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// commandSegments returns the full command followed by each simple command
+// in a &&, ||, ; or | chain, so "cd x && git push -f" is checked both as a
+// whole and as "git push -f". The split ignores quoting.
+func commandSegments(command string) []string {
+	command = strings.TrimSpace(command)
+	segs := []string{command}
+	fields := strings.FieldsFunc(strings.NewReplacer("&&", "\n", "||", "\n", ";", "\n", "|", "\n").Replace(command),
+		func(r rune) bool { return r == '\n' })
+	if len(fields) <= 1 {
+		return segs
+	}
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			segs = append(segs, f)
+		}
+	}
+	return segs
+}
+
+// matchGlob matches s against a pattern where * matches any run of
+// characters and ? matches exactly one.
+func matchGlob(pattern, s string) bool {
+	p, str := []rune(pattern), []rune(s)
+	pi, si := 0, 0
+	star, mark := -1, 0
+	for si < len(str) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == str[si]):
+			pi++
+			si++
+		case pi < len(p) && p[pi] == '*':
+			star, mark = pi, si
+			pi++
+		case star >= 0:
+			pi = star + 1
+			mark++
+			si = mark
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// Matchers returns the PreToolUse hook matchers needed for the policy's
+// deny and limit rules to see the tool calls of role. Command matchers are
+// widened with a leading * so chained commands still reach the guard, which
+// then applies the exact patterns.
+func (p *Policy) Matchers(role string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(m string) {
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	for _, r := range p.Rules {
+		if r.Action == ActionAllow || !r.appliesToRole(role) {
+			continue
+		}
+		for _, tool := range r.tools() {
+			if tool != BashTool {
+				if len(r.Paths) > 0 {
+					add(tool)
+				}
+				continue
+			}
+			for _, pat := range r.Commands {
+				add(fmt.Sprintf("Bash(*%s)", strings.TrimLeft(pat, "*")))
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package guard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePolicy(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"git push --force*", "git push --force origin main", true},
+		{"git push --force*", "git push origin main", false},
+		{"*.env", "/home/gt/rig/.env", true},
+		{"*/secrets/*", "/town/rig/secrets/key.pem", true},
+		{"rm -rf ?", "rm -rf /", true},
+		{"rm -rf ?", "rm -rf /tmp", false},
+		{"*", "", true},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestCommandSegments(t *testing.T) {
+	got := commandSegments("cd rig && git push -f; echo done | tee log")
+	want := []string{"cd rig && git push -f; echo done | tee log", "cd rig", "git push -f", "echo done", "tee log"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("commandSegments = %q, want %q", got, want)
+	}
+}
+
+func TestLoad_RigRulesFirst(t *testing.T) {
+	town := t.TempDir()
+	writePolicy(t, TownPolicyPath(town), `{"version":1,"rules":[
+		{"name":"no-force","action":"deny","commands":["git push --force*"]}]}`)
+	writePolicy(t, RigPolicyPath(town, "gastown"), `{"version":1,"rules":[
+		{"name":"crew-force","action":"allow","roles":["crew"],"commands":["git push --force*"]}]}`)
+
+	p, err := Load(town, "gastown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Rules) != 2 || p.Rules[0].Scope != "gastown" || p.Rules[1].Scope != "town" {
+		t.Fatalf("rules = %+v", p.Rules)
+	}
+
+	push := Input{Tool: "Bash", Command: "git push --force origin main"}
+	for role, want := range map[string]string{"crew": ActionAllow, "polecat": ActionDeny} {
+		push.Role = role
+		d, err := p.Evaluate(push, nil, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Action != want {
+			t.Errorf("%s: action = %q, want %q (%+v)", role, d.Action, want, d)
+		}
+	}
+
+	townOnly, err := Load(town, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(townOnly.Rules) != 1 {
+		t.Errorf("town-only rules = %d, want 1", len(townOnly.Rules))
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	p, err := Load(t.TempDir(), "gastown")
+	if err != nil || len(p.Rules) != 0 {
+		t.Errorf("Load() = %+v, %v; want empty policy", p, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name, body, wantErr string
+	}{
+		{"unknown action", `{"rules":[{"name":"a","action":"block","commands":["x"]}]}`, "unknown action"},
+		{"no patterns", `{"rules":[{"name":"a","action":"deny"}]}`, "needs commands or paths"},
+		{"limit without max", `{"rules":[{"name":"a","action":"limit","commands":["x"]}]}`, "max > 0"},
+		{"bad window", `{"rules":[{"name":"a","action":"limit","max":1,"window":"soon","commands":["x"]}]}`, "invalid window"},
+		{"bad role", `{"rules":[{"name":"a","action":"deny","roles":["intern"],"commands":["x"]}]}`, "unknown role"},
+		{"duplicate", `{"rules":[{"name":"a","action":"deny","commands":["x"]},{"name":"a","action":"deny","commands":["y"]}]}`, "duplicate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), PolicyFile)
+			writePolicy(t, path, tt.body)
+			_, err := LoadFile(path, "town")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Paths(t *testing.T) {
+	p := &Policy{Rules: []*Rule{
+		{Name: "no-secrets", Action: ActionDeny, Roles: []string{"polecat"}, Paths: []string{"*.env"}, Message: "read-only", Scope: "town"},
+	}}
+
+	d, _ := p.Evaluate(Input{Tool: "Edit", Path: "/rig/.env", Role: "polecat"}, nil, time.Now())
+	if !d.Blocked() || d.Message != "read-only" || d.Rule != "no-secrets" {
+		t.Errorf("Edit .env = %+v, want blocked by no-secrets", d)
+	}
+	d, _ = p.Evaluate(Input{Tool: "Read", Path: "/rig/.env", Role: "polecat"}, nil, time.Now())
+	if d.Blocked() {
+		t.Error("Read is not a default file tool and should be allowed")
+	}
+	d, _ = p.Evaluate(Input{Tool: "Write", Path: "/rig/.env", Role: RoleHuman}, nil, time.Now())
+	if d.Blocked() || d.Rule != "" {
+		t.Errorf("human Write = %+v, want default allow", d)
+	}
+}
+
+func TestEvaluate_Limit(t *testing.T) {
+	town := t.TempDir()
+	p := &Policy{Rules: []*Rule{
+		{Name: "force-push", Action: ActionLimit, Max: 2, Window: "1h", Commands: []string{"git push -f*"}, Scope: "town"},
+	}}
+	lim := NewLimiter(town)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	in := Input{Tool: "Bash", Command: "cd rig && git push -f", Role: "polecat", Actor: "gastown/polecats/Toast"}
+
+	for i, wantBlocked := range []bool{false, false, true} {
+		d, err := p.Evaluate(in, lim, now.Add(time.Duration(i)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Blocked() != wantBlocked {
+			t.Errorf("call %d: blocked = %v, want %v (%+v)", i+1, d.Blocked(), wantBlocked, d)
+		}
+	}
+
+	// Another agent has its own budget.
+	other := in
+	other.Actor = "gastown/polecats/Nux"
+	if d, _ := p.Evaluate(other, lim, now); d.Blocked() {
+		t.Error("limit leaked across agents")
+	}
+
+	// The window slides.
+	if d, _ := p.Evaluate(in, lim, now.Add(61*time.Minute)); d.Blocked() {
+		t.Error("limit still blocking after the window passed")
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	p := &Policy{Rules: []*Rule{
+		{Name: "crew-force", Action: ActionAllow, Commands: []string{"git push --force*"}},
+		{Name: "no-force", Action: ActionDeny, Commands: []string{"git push --force*", "*reset --hard*"}},
+		{Name: "no-secrets", Action: ActionDeny, Roles: []string{"polecat"}, Paths: []string{"*.env"}},
+		{Name: "no-rm", Action: ActionLimit, Max: 1, Commands: []string{"git push --force*"}},
+	}}
+
+	want := []string{"Bash(*git push --force*)", "Bash(*reset --hard*)", "Edit", "Write", "MultiEdit", "NotebookEdit"}
+	if got := p.Matchers("polecat"); !reflect.DeepEqual(got, want) {
+		t.Errorf("polecat matchers = %v, want %v", got, want)
+	}
+	if got := p.Matchers("crew"); len(got) != 2 {
+		t.Errorf("crew matchers = %v, want only the Bash matchers", got)
+	}
+}
+
+func TestParseHookInput(t *testing.T) {
+	in, err := ParseHookInput(strings.NewReader(`{"session_id":"s","tool_name":"NotebookEdit","tool_input":{"notebook_path":"/x.ipynb"},"cwd":"/"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Tool != "NotebookEdit" || in.Path != "/x.ipynb" {
+		t.Errorf("ParseHookInput = %+v", in)
+	}
+	if _, err := ParseHookInput(strings.NewReader("not json")); err == nil {
+		t.Error("expected error for invalid input")
+	}
+}
@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/steveyegge/gastown/internal/guard"
+)
+
+// GuardPolicyCommand is the hook command installed for guard policy matchers.
+const GuardPolicyCommand = `export PATH="$HOME/go/bin:$HOME/.local/bin:$PATH" && gt tap guard policy`
+
+// ComputeExpectedForTarget computes the expected HooksConfig for a discovered
+// target: ComputeExpected for its key, plus the PreToolUse matchers required
+// by the town and rig guard policies for the target's role.
+func ComputeExpectedForTarget(townRoot string, target Target) (*HooksConfig, error) {
+	expected, err := ComputeExpected(target.Key)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := guard.Load(townRoot, target.Rig)
+	if err != nil {
+		return nil, fmt.Errorf("loading guard policy: %w", err)
+	}
+	return ApplyGuardPolicy(expected, policy.Matchers(target.Role)), nil
+}
+
+// ApplyGuardPolicy returns a copy of cfg with a `gt tap guard policy` hook on
+// each matcher. Matchers that already exist get the hook appended, so policy
+// rules never displace base or override hooks.
+func ApplyGuardPolicy(cfg *HooksConfig, matchers []string) *HooksConfig {
+	result := cloneConfig(cfg)
+	if len(matchers) == 0 {
+		return result
+	}
+	policyHook := Hook{Type: "command", Command: GuardPolicyCommand}
+
+	entries := result.PreToolUse
+	for _, m := range matchers {
+		found := false
+		for i := range entries {
+			if entries[i].Matcher != m {
+				continue
+			}
+			found = true
+			if !hasCommand(entries[i].Hooks, GuardPolicyCommand) {
+				entries[i].Hooks = append(entries[i].Hooks, policyHook)
+			}
+			break
+		}
+		if !found {
+			entries = append(entries, HookEntry{Matcher: m, Hooks: []Hook{policyHook}})
+		}
+	}
+	result.PreToolUse = entries
+	return result
+}
+
+func hasCommand(hooks []Hook, command string) bool {
+	for _, h := range hooks {
+		if h.Command == command {
+			return true
+		}
+	}
+	return false
+}
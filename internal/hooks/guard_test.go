@@ -0,0 +1,65 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyGuardPolicy(t *testing.T) {
+	base := DefaultBase()
+	got := ApplyGuardPolicy(base, []string{"Bash(gh pr create*)", "Bash(*git push --force*)", "Edit"})
+
+	if len(base.PreToolUse) != 3 {
+		t.Fatalf("ApplyGuardPolicy mutated its input: %d entries", len(base.PreToolUse))
+	}
+	if len(got.PreToolUse) != 5 {
+		t.Fatalf("PreToolUse entries = %d, want 5", len(got.PreToolUse))
+	}
+	if err := validateUniqueMatchers(got); err != nil {
+		t.Fatalf("duplicate matchers: %v", err)
+	}
+
+	// Existing matcher keeps its hook and gains the policy hook.
+	pr := got.PreToolUse[0]
+	if len(pr.Hooks) != 2 || pr.Hooks[1].Command != GuardPolicyCommand {
+		t.Errorf("existing matcher hooks = %+v", pr.Hooks)
+	}
+
+	// Idempotent.
+	again := ApplyGuardPolicy(got, []string{"Bash(gh pr create*)", "Edit"})
+	if !HooksEqual(got, again) {
+		t.Error("ApplyGuardPolicy is not idempotent")
+	}
+}
+
+func TestComputeExpectedForTarget(t *testing.T) {
+	setTestHome(t, t.TempDir())
+	town := t.TempDir()
+	policy := `{"version":1,"rules":[{"name":"no-secrets","action":"deny","roles":["polecat"],"paths":["*.env"],"tools":["Write"]}]}`
+	path := filepath.Join(town, "gastown", "settings", "guard-policy.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	polecats, err := ComputeExpectedForTarget(town, Target{Key: "gastown/polecats", Rig: "gastown", Role: "polecat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := polecats.PreToolUse[len(polecats.PreToolUse)-1]; last.Matcher != "Write" {
+		t.Errorf("polecat PreToolUse = %+v, want a Write matcher", polecats.PreToolUse)
+	}
+
+	crew, err := ComputeExpectedForTarget(town, Target{Key: "gastown/crew", Rig: "gastown", Role: "crew"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range crew.PreToolUse {
+		if e.Matcher == "Write" {
+			t.Error("polecat-only rule installed for crew")
+		}
+	}
+}
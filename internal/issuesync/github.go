@@ -0,0 +1,158 @@
+package issuesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// githubListLimit caps how many labeled issues a sync reads from GitHub.
+const githubListLimit = 1000
+
+// CommandRunner runs a CLI command and returns its stdout.
+type CommandRunner func(ctx context.Context, args ...string) ([]byte, error)
+
+// GitHubProvider syncs GitHub Issues through the gh CLI, reusing its
+// authentication (gh auth login).
+type GitHubProvider struct {
+	repo  string
+	label string
+	run   CommandRunner
+}
+
+// NewGitHubProvider creates a provider for repo ("owner/name"). A nil runner
+// executes gh.
+func NewGitHubProvider(repo, label string, run CommandRunner) *GitHubProvider {
+	if run == nil {
+		run = runGH
+	}
+	return &GitHubProvider{repo: repo, label: label, run: run}
+}
+
+func runGH(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", args...) //nolint:gosec // G204: args are built from settings and issue refs
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("gh %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("gh %s: %w", args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// ghIssue is one element of gh issue list --json output.
+type ghIssue struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	State     string `json:"state"`
+	URL       string `json:"url"`
+	UpdatedAt string `json:"updatedAt"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+}
+
+// List returns labeled issues in any state.
+func (g *GitHubProvider) List(ctx context.Context) ([]*ExternalIssue, error) {
+	out, err := g.run(ctx, "issue", "list",
+		"--repo", g.repo,
+		"--label", g.label,
+		"--state", "all",
+		"--limit", fmt.Sprintf("%d", githubListLimit),
+		"--json", "number,title,body,state,url,updatedAt,assignees")
+	if err != nil {
+		return nil, err
+	}
+
+	var raw []ghIssue
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parsing gh issue list output: %w", err)
+	}
+
+	issues := make([]*ExternalIssue, 0, len(raw))
+	for _, r := range raw {
+		issue := &ExternalIssue{
+			Ref:   fmt.Sprintf("%s#%d", g.repo, r.Number),
+			URL:   r.URL,
+			Title: r.Title,
+			Body:  r.Body,
+			State: StateOpen,
+		}
+		if strings.EqualFold(r.State, "closed") {
+			issue.State = StateClosed
+		}
+		if len(r.Assignees) > 0 {
+			issue.Assignee = r.Assignees[0].Login
+		}
+		if t, err := time.Parse(time.RFC3339, r.UpdatedAt); err == nil {
+			issue.UpdatedAt = t
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// SetState closes or reopens an issue.
+func (g *GitHubProvider) SetState(ctx context.Context, ref, state, comment string) error {
+	number, err := g.number(ref)
+	if err != nil {
+		return err
+	}
+	verb := "reopen"
+	if state == StateClosed {
+		verb = "close"
+	}
+	args := []string{"issue", verb, number, "--repo", g.repo}
+	if comment != "" {
+		args = append(args, "--comment", comment)
+	}
+	_, err = g.run(ctx, args...)
+	return err
+}
+
+// SetAssignee replaces the assignee.
+func (g *GitHubProvider) SetAssignee(ctx context.Context, ref, from, to string) error {
+	number, err := g.number(ref)
+	if err != nil {
+		return err
+	}
+	args := []string{"issue", "edit", number, "--repo", g.repo}
+	if from != "" {
+		args = append(args, "--remove-assignee", from)
+	}
+	if to != "" {
+		args = append(args, "--add-assignee", to)
+	}
+	if from == "" && to == "" {
+		return nil
+	}
+	_, err = g.run(ctx, args...)
+	return err
+}
+
+// Comment posts a comment.
+func (g *GitHubProvider) Comment(ctx context.Context, ref, body string) error {
+	number, err := g.number(ref)
+	if err != nil {
+		return err
+	}
+	_, err = g.run(ctx, "issue", "comment", number, "--repo", g.repo, "--body", body)
+	return err
+}
+
+// number extracts the issue number from a "owner/repo#N" ref.
+func (g *GitHubProvider) number(ref string) (string, error) {
+	prefix := g.repo + "#"
+	if !strings.HasPrefix(ref, prefix) || len(ref) == len(prefix) {
+		return "", fmt.Errorf("ref %q does not belong to %s", ref, g.repo)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
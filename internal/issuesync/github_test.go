@@ -0,0 +1,86 @@
+package issuesync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordedGH replays gh output from testdata and records every invocation.
+type recordedGH struct {
+	calls [][]string
+}
+
+func (r *recordedGH) run(_ context.Context, args ...string) ([]byte, error) {
+	r.calls = append(r.calls, args)
+	if len(args) >= 2 && args[0] == "issue" && args[1] == "list" {
+		return os.ReadFile(filepath.Join("testdata", "gh_issue_list.json"))
+	}
+	return nil, nil
+}
+
+func TestGitHubProvider_List(t *testing.T) {
+	rec := &recordedGH{}
+	p := NewGitHubProvider("acme/widgets", "gastown", rec.run)
+
+	issues, err := p.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2", len(issues))
+	}
+
+	open := issues[0]
+	if open.Ref != "acme/widgets#412" || open.State != StateOpen || open.Assignee != "octocat" {
+		t.Errorf("issue 412 = %+v", open)
+	}
+	if open.URL != "https://github.com/acme/widgets/issues/412" || !strings.Contains(open.Body, "rename main") {
+		t.Errorf("issue 412 URL/body = %q / %q", open.URL, open.Body)
+	}
+	if open.UpdatedAt.IsZero() {
+		t.Error("UpdatedAt not parsed")
+	}
+	if closed := issues[1]; closed.State != StateClosed || closed.Assignee != "" {
+		t.Errorf("issue 398 = %+v", closed)
+	}
+
+	args := strings.Join(rec.calls[0], " ")
+	for _, want := range []string{"--repo acme/widgets", "--label gastown", "--state all"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("gh args %q missing %q", args, want)
+		}
+	}
+}
+
+func TestGitHubProvider_Push(t *testing.T) {
+	rec := &recordedGH{}
+	p := NewGitHubProvider("acme/widgets", "gastown", rec.run)
+	ctx := context.Background()
+
+	if err := p.SetState(ctx, "acme/widgets#412", StateClosed, "Closed in Gas Town (gt-abc)."); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetAssignee(ctx, "acme/widgets#412", "octocat", "hubot"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Comment(ctx, "acme/widgets#412", "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"issue", "close", "412", "--repo", "acme/widgets", "--comment", "Closed in Gas Town (gt-abc)."},
+		{"issue", "edit", "412", "--repo", "acme/widgets", "--remove-assignee", "octocat", "--add-assignee", "hubot"},
+		{"issue", "comment", "412", "--repo", "acme/widgets", "--body", "hello"},
+	}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("calls =\n%v\nwant\n%v", rec.calls, want)
+	}
+
+	if err := p.Comment(ctx, "other/repo#1", "x"); err == nil {
+		t.Error("Comment() accepted a ref from another repo")
+	}
+}
@@ -0,0 +1,235 @@
+package issuesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// jiraPageSize is the search page size; Jira caps it at 100.
+const jiraPageSize = 100
+
+// JiraProvider syncs Jira issues through the REST API (v2).
+type JiraProvider struct {
+	baseURL string
+	project string
+	label   string
+	user    string
+	token   string
+	done    string
+	reopen  string
+	// cloud selects accountId-based assignment (Jira Cloud) over username
+	// assignment (Jira Server/Data Center).
+	cloud  bool
+	client *http.Client
+}
+
+// NewJiraProvider creates a provider for cfg. Credentials are read from the
+// environment variables named in cfg. A nil client uses a default with a
+// 30s timeout.
+func NewJiraProvider(cfg *config.IssueSyncConfig, client *http.Client) *JiraProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	p := &JiraProvider{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		project: cfg.Project,
+		label:   cfg.Label,
+		done:    cfg.DoneTransition,
+		reopen:  cfg.ReopenTransition,
+		client:  client,
+	}
+	if cfg.UserEnv != "" {
+		p.user = os.Getenv(cfg.UserEnv)
+	}
+	if cfg.TokenEnv != "" {
+		p.token = os.Getenv(cfg.TokenEnv)
+	}
+	if p.done == "" {
+		p.done = "Done"
+	}
+	if p.reopen == "" {
+		p.reopen = "To Do"
+	}
+	if u, err := url.Parse(p.baseURL); err == nil {
+		p.cloud = strings.HasSuffix(u.Hostname(), ".atlassian.net")
+	}
+	return p
+}
+
+// jiraSearch is the subset of a /rest/api/2/search response used for sync.
+type jiraSearch struct {
+	StartAt int `json:"startAt"`
+	Total   int `json:"total"`
+	Issues  []struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+			Updated     string `json:"updated"`
+			Status      struct {
+				Name           string `json:"name"`
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+			Assignee *struct {
+				AccountID string `json:"accountId"`
+				Name      string `json:"name"`
+			} `json:"assignee"`
+		} `json:"fields"`
+	} `json:"issues"`
+}
+
+// jiraTimeLayout is the timestamp format of Jira's REST API.
+const jiraTimeLayout = "2006-01-02T15:04:05.000-0700"
+
+// List returns labeled issues in the project, in any status.
+func (j *JiraProvider) List(ctx context.Context) ([]*ExternalIssue, error) {
+	jql := fmt.Sprintf("project = %q AND labels = %q ORDER BY key ASC", j.project, j.label)
+	var issues []*ExternalIssue
+	for startAt := 0; ; {
+		q := url.Values{}
+		q.Set("jql", jql)
+		q.Set("fields", "summary,description,status,assignee,updated")
+		q.Set("startAt", fmt.Sprintf("%d", startAt))
+		q.Set("maxResults", fmt.Sprintf("%d", jiraPageSize))
+
+		var page jiraSearch
+		if err := j.do(ctx, http.MethodGet, "/rest/api/2/search?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		for _, r := range page.Issues {
+			issue := &ExternalIssue{
+				Ref:   r.Key,
+				URL:   j.baseURL + "/browse/" + r.Key,
+				Title: r.Fields.Summary,
+				Body:  r.Fields.Description,
+				State: StateOpen,
+			}
+			if r.Fields.Status.StatusCategory.Key == "done" {
+				issue.State = StateClosed
+			}
+			if a := r.Fields.Assignee; a != nil {
+				issue.Assignee = a.Name
+				if j.cloud || issue.Assignee == "" {
+					issue.Assignee = a.AccountID
+				}
+			}
+			if t, err := time.Parse(jiraTimeLayout, r.Fields.Updated); err == nil {
+				issue.UpdatedAt = t
+			}
+			issues = append(issues, issue)
+		}
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			return issues, nil
+		}
+	}
+}
+
+// SetState applies the configured done or reopen transition.
+func (j *JiraProvider) SetState(ctx context.Context, ref, state, comment string) error {
+	name := j.reopen
+	if state == StateClosed {
+		name = j.done
+	}
+
+	var resp struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := j.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(ref)+"/transitions", nil, &resp); err != nil {
+		return err
+	}
+	id := ""
+	for _, t := range resp.Transitions {
+		if strings.EqualFold(t.Name, name) {
+			id = t.ID
+			break
+		}
+	}
+	if id == "" {
+		return fmt.Errorf("%s: no %q transition available", ref, name)
+	}
+
+	if comment != "" {
+		if err := j.Comment(ctx, ref, comment); err != nil {
+			return err
+		}
+	}
+	body := map[string]any{"transition": map[string]string{"id": id}}
+	return j.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(ref)+"/transitions", body, nil)
+}
+
+// SetAssignee assigns the issue to to, or unassigns it when to is empty.
+func (j *JiraProvider) SetAssignee(ctx context.Context, ref, _, to string) error {
+	key := "name"
+	if j.cloud {
+		key = "accountId"
+	}
+	var value any
+	if to != "" {
+		value = to
+	}
+	return j.do(ctx, http.MethodPut, "/rest/api/2/issue/"+url.PathEscape(ref)+"/assignee", map[string]any{key: value}, nil)
+}
+
+// Comment posts a comment.
+func (j *JiraProvider) Comment(ctx context.Context, ref, body string) error {
+	return j.do(ctx, http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(ref)+"/comment", map[string]string{"body": body}, nil)
+}
+
+// do sends a JSON request and decodes the response into out (if non-nil).
+func (j *JiraProvider) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, j.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case j.user != "" && j.token != "":
+		req.SetBasicAuth(j.user, j.token)
+	case j.token != "":
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("jira %s %s: %w", method, strings.SplitN(path, "?", 2)[0], err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("jira %s %s: %s: %s", method, strings.SplitN(path, "?", 2)[0], resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("parsing jira response: %w", err)
+	}
+	return nil
+}
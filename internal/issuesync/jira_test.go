@@ -0,0 +1,135 @@
+package issuesync
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// jiraRequest is a request received by the recorded Jira server.
+type jiraRequest struct {
+	Method string
+	Path   string
+	Body   map[string]any
+	Auth   string
+}
+
+// newRecordedJira serves testdata fixtures for Jira reads and records writes.
+func newRecordedJira(t *testing.T) (*httptest.Server, *[]jiraRequest) {
+	t.Helper()
+	var reqs []jiraRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := jiraRequest{Method: r.Method, Path: r.URL.Path, Auth: r.Header.Get("Authorization")}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &req.Body)
+		}
+		reqs = append(reqs, req)
+
+		fixture := ""
+		switch {
+		case r.URL.Path == "/rest/api/2/search":
+			fixture = "jira_search.json"
+		case r.Method == http.MethodGet && r.URL.Path == "/rest/api/2/issue/WID-17/transitions":
+			fixture = "jira_transitions.json"
+		}
+		if fixture == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Errorf("reading fixture: %v", err)
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+func TestJiraProvider_List(t *testing.T) {
+	srv, _ := newRecordedJira(t)
+	p := NewJiraProvider(&config.IssueSyncConfig{Provider: "jira", URL: srv.URL, Project: "WID", Label: "gastown"}, srv.Client())
+	p.cloud = true // fixture is a Jira Cloud response
+
+	issues, err := p.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error: %v", err)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("got %d issues, want 2", len(issues))
+	}
+	if i := issues[0]; i.Ref != "WID-17" || i.State != StateOpen || i.Assignee != "5b10ac8d82e05b22cc7d4ef5" || i.URL != srv.URL+"/browse/WID-17" {
+		t.Errorf("WID-17 = %+v", i)
+	}
+	if issues[0].UpdatedAt.IsZero() {
+		t.Error("UpdatedAt not parsed")
+	}
+	if i := issues[1]; i.State != StateClosed || i.Assignee != "" || i.Body != "" {
+		t.Errorf("WID-9 = %+v", i)
+	}
+}
+
+func TestJiraProvider_SetState(t *testing.T) {
+	srv, reqs := newRecordedJira(t)
+	t.Setenv("TEST_JIRA_USER", "bot@acme.test")
+	t.Setenv("TEST_JIRA_TOKEN", "secret")
+	p := NewJiraProvider(&config.IssueSyncConfig{
+		Provider: "jira", URL: srv.URL, Project: "WID", Label: "gastown",
+		UserEnv: "TEST_JIRA_USER", TokenEnv: "TEST_JIRA_TOKEN",
+	}, srv.Client())
+
+	if err := p.SetState(context.Background(), "WID-17", StateClosed, "Closed in Gas Town (gt-abc)."); err != nil {
+		t.Fatalf("SetState() error: %v", err)
+	}
+
+	got := *reqs
+	if len(got) != 3 {
+		t.Fatalf("got %d requests, want transitions lookup, comment, transition: %+v", len(got), got)
+	}
+	if got[1].Path != "/rest/api/2/issue/WID-17/comment" || got[1].Body["body"] != "Closed in Gas Town (gt-abc)." {
+		t.Errorf("comment request = %+v", got[1])
+	}
+	tr, _ := got[2].Body["transition"].(map[string]any)
+	if got[2].Method != http.MethodPost || tr["id"] != "31" {
+		t.Errorf("transition request = %+v, want Done (31)", got[2])
+	}
+	if got[0].Auth == "" || got[0].Auth[:6] != "Basic " {
+		t.Errorf("Authorization = %q, want basic auth", got[0].Auth)
+	}
+
+	if err := p.SetState(context.Background(), "WID-17", StateOpen, ""); err != nil {
+		t.Fatalf("reopen error: %v", err)
+	}
+	tr, _ = (*reqs)[len(*reqs)-1].Body["transition"].(map[string]any)
+	if tr["id"] != "11" {
+		t.Errorf("reopen transition = %v, want To Do (11)", tr["id"])
+	}
+}
+
+func TestJiraProvider_SetAssignee(t *testing.T) {
+	srv, reqs := newRecordedJira(t)
+	p := NewJiraProvider(&config.IssueSyncConfig{Provider: "jira", URL: srv.URL, Project: "WID", Label: "gastown"}, srv.Client())
+
+	if err := p.SetAssignee(context.Background(), "WID-17", "", "mkrystof"); err != nil {
+		t.Fatal(err)
+	}
+	p.cloud = true
+	if err := p.SetAssignee(context.Background(), "WID-17", "mkrystof", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	got := *reqs
+	if got[0].Method != http.MethodPut || got[0].Body["name"] != "mkrystof" {
+		t.Errorf("server assign = %+v", got[0])
+	}
+	if v, ok := got[1].Body["accountId"]; !ok || v != nil {
+		t.Errorf("cloud unassign body = %+v, want accountId null", got[1].Body)
+	}
+}
@@ -0,0 +1,70 @@
+// Package issuesync keeps beads in sync with external issue trackers.
+//
+// Labeled external issues are imported as beads carrying tracker fields
+// (see beads.TrackerFields). On every sync, state and assignee changes are
+// merged in both directions against the values recorded at the last sync,
+// and merge request progress is posted back to the external issue.
+package issuesync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// External issue states. Trackers with richer workflows are collapsed to
+// these two: an issue is either being worked on or done.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+)
+
+// ExternalIssue is an issue as seen in an external tracker.
+type ExternalIssue struct {
+	Ref       string    `json:"ref"`                // Stable key: "owner/repo#12", "PROJ-12"
+	URL       string    `json:"url,omitempty"`      // Browser link
+	Title     string    `json:"title"`              // Summary line
+	Body      string    `json:"body,omitempty"`     // Description
+	State     string    `json:"state"`              // StateOpen or StateClosed
+	Assignee  string    `json:"assignee,omitempty"` // Tracker user, empty if unassigned
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// Provider is an external issue tracker.
+type Provider interface {
+	// List returns every issue carrying the source's label, open or closed.
+	List(ctx context.Context) ([]*ExternalIssue, error)
+
+	// SetState closes or reopens an issue. A non-empty comment is posted
+	// alongside the transition.
+	SetState(ctx context.Context, ref, state, comment string) error
+
+	// SetAssignee replaces assignee from with to. Either may be empty.
+	SetAssignee(ctx context.Context, ref, from, to string) error
+
+	// Comment posts a comment on an issue.
+	Comment(ctx context.Context, ref, body string) error
+}
+
+// NewProvider creates the provider for a sync source.
+func NewProvider(cfg *config.IssueSyncConfig) (Provider, error) {
+	if cfg.Label == "" {
+		return nil, fmt.Errorf("issue sync source %q: label is required", cfg.SourceName())
+	}
+	switch cfg.Provider {
+	case "github":
+		if cfg.Repo == "" {
+			return nil, fmt.Errorf("issue sync source %q: github provider requires repo", cfg.SourceName())
+		}
+		return NewGitHubProvider(cfg.Repo, cfg.Label, nil), nil
+	case "jira":
+		if cfg.URL == "" || cfg.Project == "" {
+			return nil, fmt.Errorf("issue sync source %q: jira provider requires url and project", cfg.SourceName())
+		}
+		return NewJiraProvider(cfg, nil), nil
+	default:
+		return nil, fmt.Errorf("issue sync source %q: unknown provider %q", cfg.SourceName(), cfg.Provider)
+	}
+}
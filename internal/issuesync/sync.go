@@ -0,0 +1,502 @@
+package issuesync
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// Store is the beads side of a sync.
+type Store interface {
+	// ListTracked returns all beads imported from source, in any status.
+	ListTracked(source string) ([]*beads.Issue, error)
+	// ListMergeRequests returns all merge request beads, in any status.
+	ListMergeRequests() ([]*beads.Issue, error)
+	// CreateTracked creates a bead for an external issue.
+	CreateTracked(title string, fields *beads.TrackerFields, body string) (*beads.Issue, error)
+	// Update changes bead fields.
+	Update(id string, opts beads.UpdateOptions) error
+	// Close closes a bead with a reason.
+	Close(id, reason string) error
+}
+
+// beadsStore adapts a beads wrapper to Store.
+type beadsStore struct {
+	b *beads.Beads
+}
+
+// NewBeadsStore returns the Store for a beads wrapper.
+func NewBeadsStore(b *beads.Beads) Store {
+	return &beadsStore{b: b}
+}
+
+func (s *beadsStore) ListTracked(source string) ([]*beads.Issue, error) {
+	return s.b.ListTrackedBeads(source)
+}
+
+func (s *beadsStore) ListMergeRequests() ([]*beads.Issue, error) {
+	return s.b.List(beads.ListOptions{Status: "all", Label: "gt:merge-request", Priority: -1})
+}
+
+func (s *beadsStore) CreateTracked(title string, fields *beads.TrackerFields, body string) (*beads.Issue, error) {
+	return s.b.CreateTrackedBead(title, fields, body, nil)
+}
+
+func (s *beadsStore) Update(id string, opts beads.UpdateOptions) error {
+	return s.b.Update(id, opts)
+}
+
+func (s *beadsStore) Close(id, reason string) error {
+	return s.b.CloseWithReason(reason, id)
+}
+
+// StoreForSource returns the beads store that receives a source's imports:
+// the configured rig's beads, or the town beads.
+func StoreForSource(townRoot string, cfg *config.IssueSyncConfig) Store {
+	dir := townRoot
+	if cfg.Rig != "" {
+		dir = filepath.Join(townRoot, cfg.Rig)
+	}
+	return NewBeadsStore(beads.New(dir))
+}
+
+// Action kinds reported by a sync.
+const (
+	ActionImport       = "import"        // external issue imported as a new bead
+	ActionUpdateBead   = "update-bead"   // bead title, body or assignee refreshed
+	ActionCloseBead    = "close-bead"    // bead closed because the external issue closed
+	ActionReopenBead   = "reopen-bead"   // bead reopened because the external issue reopened
+	ActionPushState    = "push-state"    // external issue closed or reopened
+	ActionPushAssignee = "push-assignee" // external assignee changed
+	ActionPushComment  = "push-comment"  // merge request progress posted
+)
+
+// Action is one change made (or, in a dry run, planned) by a sync.
+type Action struct {
+	Kind   string `json:"kind"`
+	Ref    string `json:"ref"`
+	BeadID string `json:"bead_id,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Conflict records a field changed on both sides since the last sync and
+// how it was resolved.
+type Conflict struct {
+	Ref        string `json:"ref"`
+	BeadID     string `json:"bead_id"`
+	Field      string `json:"field"`
+	Bead       string `json:"bead"`
+	External   string `json:"external"`
+	Resolution string `json:"resolution"`
+}
+
+// Report summarizes one sync of one source.
+type Report struct {
+	Source    string     `json:"source"`
+	Actions   []Action   `json:"actions,omitempty"`
+	Conflicts []Conflict `json:"conflicts,omitempty"`
+	Errors    []string   `json:"errors,omitempty"`
+}
+
+// Syncer syncs one source.
+type Syncer struct {
+	Source   string
+	Provider Provider
+	Store    Store
+	// Assignees maps bead assignees to tracker users.
+	Assignees map[string]string
+	// DryRun plans actions without changing either side.
+	DryRun bool
+	// Now returns the current time; defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewSyncer creates a Syncer for a configured source.
+func NewSyncer(townRoot string, cfg *config.IssueSyncConfig) (*Syncer, error) {
+	p, err := NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{
+		Source:    cfg.SourceName(),
+		Provider:  p,
+		Store:     StoreForSource(townRoot, cfg),
+		Assignees: cfg.Assignees,
+	}, nil
+}
+
+// Sync imports new external issues and reconciles already-tracked ones.
+// Per-issue failures are collected in the report and do not stop the sync;
+// an error is returned only when either side cannot be listed.
+//
+// Conflict rules, applied per field against the value recorded at the last
+// sync (the base):
+//   - Title and body are owned by the tracker and always flow into the bead.
+//   - If only one side changed since the base, that side wins.
+//   - State changed on both sides, or with no base: closed wins.
+//   - Assignee changed on both sides: the bead wins, since Gas Town is
+//     where the work is assigned.
+func (s *Syncer) Sync(ctx context.Context) (*Report, error) {
+	report := &Report{Source: s.Source}
+
+	external, err := s.Provider.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s issues: %w", s.Source, err)
+	}
+	tracked, err := s.Store.ListTracked(s.Source)
+	if err != nil {
+		return nil, fmt.Errorf("listing tracked beads: %w", err)
+	}
+	mrs, err := s.Store.ListMergeRequests()
+	if err != nil {
+		return nil, fmt.Errorf("listing merge requests: %w", err)
+	}
+
+	byRef := make(map[string]*beads.Issue)
+	// Sort by ID so a duplicate mapping always resolves to the oldest bead.
+	sort.Slice(tracked, func(i, j int) bool { return tracked[i].ID < tracked[j].ID })
+	for _, bead := range tracked {
+		f := beads.ParseTrackerFields(bead)
+		if f == nil || f.Source != s.Source {
+			continue
+		}
+		if _, dup := byRef[f.Ref]; dup {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %s is also mapped to %s; ignoring it", f.Ref, bead.ID, byRef[f.Ref].ID))
+			continue
+		}
+		byRef[f.Ref] = bead
+	}
+	mrByIssue := latestMRs(mrs)
+
+	sort.Slice(external, func(i, j int) bool { return external[i].Ref < external[j].Ref })
+	for _, ext := range external {
+		bead, ok := byRef[ext.Ref]
+		if !ok {
+			if ext.State == StateOpen {
+				s.importIssue(ctx, ext, report)
+			}
+			continue
+		}
+		s.reconcile(ctx, bead, ext, mrByIssue[bead.ID], report)
+	}
+	return report, nil
+}
+
+func (s *Syncer) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// importIssue creates a bead for an open external issue.
+func (s *Syncer) importIssue(_ context.Context, ext *ExternalIssue, report *Report) {
+	fields := &beads.TrackerFields{
+		Source:   s.Source,
+		Ref:      ext.Ref,
+		URL:      ext.URL,
+		State:    StateOpen,
+		Assignee: ext.Assignee,
+		BodyHash: beads.TrackerBodyHash(ext.Body),
+		SyncedAt: s.now().UTC().Format(time.RFC3339),
+	}
+	beadAssignee := s.beadAssignee(ext.Assignee)
+
+	action := Action{Kind: ActionImport, Ref: ext.Ref, Detail: ext.Title}
+	if s.DryRun {
+		report.Actions = append(report.Actions, action)
+		return
+	}
+	bead, err := s.Store.CreateTracked(ext.Title, fields, ext.Body)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: import: %v", ext.Ref, err))
+		return
+	}
+	action.BeadID = bead.ID
+	report.Actions = append(report.Actions, action)
+
+	if beadAssignee != "" {
+		if err := s.Store.Update(bead.ID, beads.UpdateOptions{Assignee: &beadAssignee}); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: assign %s: %v", ext.Ref, bead.ID, err))
+		}
+	}
+}
+
+// reconcile merges one tracked bead with its external issue.
+func (s *Syncer) reconcile(ctx context.Context, bead *beads.Issue, ext *ExternalIssue, mr *beads.Issue, report *Report) {
+	base := beads.ParseTrackerFields(bead)
+	next := *base
+	next.URL = ext.URL
+	var upd beads.UpdateOptions
+	fail := func(what string, err error) {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %s: %v", ext.Ref, what, err))
+	}
+	act := func(kind, detail string) {
+		report.Actions = append(report.Actions, Action{Kind: kind, Ref: ext.Ref, BeadID: bead.ID, Detail: detail})
+	}
+
+	// Title and body: the tracker owns them.
+	if bead.Title != ext.Title {
+		title := ext.Title
+		upd.Title = &title
+	}
+	// Compared by hash against the body imported at the last sync, since bd
+	// may normalize the stored description. Beads imported before hashes
+	// were recorded fall back to comparing the stored body.
+	next.BodyHash = beads.TrackerBodyHash(ext.Body)
+	bodyChanged := next.BodyHash != base.BodyHash
+	if base.BodyHash == "" {
+		bodyChanged = beads.TrackerBody(bead.Description) != strings.TrimSpace(ext.Body)
+	}
+
+	// State.
+	local := beadState(bead)
+	state, conflict := mergeState(base.State, local, ext.State)
+	if conflict {
+		report.Conflicts = append(report.Conflicts, Conflict{
+			Ref: ext.Ref, BeadID: bead.ID, Field: "state",
+			Bead: local, External: ext.State, Resolution: state,
+		})
+	}
+	next.State = state
+	if state != local {
+		act(map[string]string{StateClosed: ActionCloseBead, StateOpen: ActionReopenBead}[state], "")
+		if !s.DryRun {
+			var err error
+			if state == StateClosed {
+				err = s.Store.Close(bead.ID, fmt.Sprintf("closed in %s (%s)", s.Source, ext.Ref))
+			} else {
+				open := "open"
+				err = s.Store.Update(bead.ID, beads.UpdateOptions{Status: &open})
+			}
+			if err != nil {
+				fail("update bead state", err)
+				next.State = base.State
+			}
+		}
+	}
+	if state != ext.State {
+		act(ActionPushState, state)
+		if !s.DryRun {
+			if err := s.Provider.SetState(ctx, ext.Ref, state, stateComment(bead, state)); err != nil {
+				fail("push state", err)
+				next.State = base.State
+			}
+		}
+	}
+
+	// Assignee.
+	if localA, known := s.externalAssignee(bead.Assignee, base.Assignee); known {
+		assignee, conflict := mergeAssignee(base.Assignee, localA, ext.Assignee)
+		if conflict {
+			report.Conflicts = append(report.Conflicts, Conflict{
+				Ref: ext.Ref, BeadID: bead.ID, Field: "assignee",
+				Bead: localA, External: ext.Assignee, Resolution: assignee,
+			})
+		}
+		next.Assignee = assignee
+		if assignee != localA {
+			if to := s.beadAssignee(assignee); to != "" || assignee == "" {
+				upd.Assignee = &to
+			}
+		}
+		if assignee != ext.Assignee {
+			act(ActionPushAssignee, assignee)
+			if !s.DryRun {
+				if err := s.Provider.SetAssignee(ctx, ext.Ref, ext.Assignee, assignee); err != nil {
+					fail("push assignee", err)
+					next.Assignee = base.Assignee
+				}
+			}
+		}
+	} else {
+		// Assigned in Gas Town to an identity with no tracker user (e.g. a
+		// polecat): leave both sides alone.
+		next.Assignee = ext.Assignee
+	}
+
+	// Merge request progress.
+	if mr != nil {
+		if note := mrNote(mr); note != base.MR {
+			act(ActionPushComment, note)
+			next.MR = note
+			if !s.DryRun {
+				if err := s.Provider.Comment(ctx, ext.Ref, mrComment(mr)); err != nil {
+					fail("push merge request", err)
+					next.MR = base.MR
+				}
+			}
+		}
+	}
+
+	// Persist the new base and any bead-side field changes.
+	next.SyncedAt = base.SyncedAt
+	if next != *base || bodyChanged {
+		next.SyncedAt = s.now().UTC().Format(time.RFC3339)
+		desc := beads.FormatTrackerDescription(&next, ext.Body)
+		upd.Description = &desc
+	}
+	if upd.Title != nil || upd.Assignee != nil || bodyChanged {
+		act(ActionUpdateBead, updateDetail(upd, bodyChanged))
+	}
+	if (upd.Title == nil && upd.Assignee == nil && upd.Description == nil) || s.DryRun {
+		return
+	}
+	if err := s.Store.Update(bead.ID, upd); err != nil {
+		fail("update bead", err)
+	}
+}
+
+// beadState collapses a bead status to an external state.
+func beadState(bead *beads.Issue) string {
+	if bead.Status == "closed" {
+		return StateClosed
+	}
+	return StateOpen
+}
+
+// mergeState resolves the state from the base and both sides. Reports a
+// conflict when both sides differ from the base and from each other.
+func mergeState(base, local, remote string) (string, bool) {
+	switch {
+	case local == remote:
+		return local, false
+	case base == "":
+		return StateClosed, true
+	case local == base:
+		return remote, false
+	case remote == base:
+		return local, false
+	default:
+		return StateClosed, true
+	}
+}
+
+// mergeAssignee resolves the external assignee; on conflict the bead wins.
+func mergeAssignee(base, local, remote string) (string, bool) {
+	switch {
+	case local == remote:
+		return local, false
+	case local == base:
+		return remote, false
+	case remote == base:
+		return local, false
+	default:
+		return local, true
+	}
+}
+
+// externalAssignee maps a bead assignee to a tracker user. known is false
+// when the bead is assigned to an identity with no tracker user.
+func (s *Syncer) externalAssignee(beadAssignee, base string) (string, bool) {
+	if beadAssignee == "" {
+		if base != "" && s.beadAssignee(base) == "" {
+			// The tracker user has no Gas Town identity, so the bead could
+			// never show it: treat the bead as unchanged.
+			return base, true
+		}
+		return "", true
+	}
+	user, ok := s.Assignees[beadAssignee]
+	return user, ok
+}
+
+// beadAssignee maps a tracker user back to a bead assignee, or "" if unmapped.
+func (s *Syncer) beadAssignee(user string) string {
+	if user == "" {
+		return ""
+	}
+	var matches []string
+	for beadID, u := range s.Assignees {
+		if u == user {
+			matches = append(matches, beadID)
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+	sort.Strings(matches)
+	return matches[0]
+}
+
+// latestMRs indexes merge request beads by source issue, keeping the most
+// recently updated one per issue.
+func latestMRs(mrs []*beads.Issue) map[string]*beads.Issue {
+	out := make(map[string]*beads.Issue)
+	for _, mr := range mrs {
+		f := beads.ParseMRFields(mr)
+		if f == nil || f.SourceIssue == "" {
+			continue
+		}
+		cur, ok := out[f.SourceIssue]
+		if !ok || mr.UpdatedAt > cur.UpdatedAt || (mr.UpdatedAt == cur.UpdatedAt && mr.ID > cur.ID) {
+			out[f.SourceIssue] = mr
+		}
+	}
+	return out
+}
+
+// mrNote is the compact merge request state recorded in tracker_mr.
+func mrNote(mr *beads.Issue) string {
+	note := mr.ID + ":" + mr.Status
+	if f := beads.ParseMRFields(mr); f != nil && f.CloseReason != "" {
+		note += ":" + f.CloseReason
+	}
+	return note
+}
+
+// mrComment describes a merge request for the external issue.
+func mrComment(mr *beads.Issue) string {
+	f := beads.ParseMRFields(mr)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Gas Town merge request %s", mr.ID)
+	if f != nil && f.Branch != "" {
+		target := f.Target
+		if target == "" {
+			target = "main"
+		}
+		fmt.Fprintf(&b, " (%s → %s)", f.Branch, target)
+	}
+	switch {
+	case mr.Status != "closed":
+		b.WriteString(" is in the merge queue.")
+	case f != nil && f.CloseReason == "merged" && f.MergeCommit != "":
+		fmt.Fprintf(&b, " merged as %s.", f.MergeCommit)
+	case f != nil && f.CloseReason != "":
+		fmt.Fprintf(&b, " closed: %s.", f.CloseReason)
+	default:
+		b.WriteString(" closed.")
+	}
+	return b.String()
+}
+
+// stateComment explains a state change pushed to the tracker.
+func stateComment(bead *beads.Issue, state string) string {
+	if state != StateClosed {
+		return fmt.Sprintf("Reopened in Gas Town (%s).", bead.ID)
+	}
+	if bead.CloseReason != "" {
+		return fmt.Sprintf("Closed in Gas Town (%s): %s", bead.ID, bead.CloseReason)
+	}
+	return fmt.Sprintf("Closed in Gas Town (%s).", bead.ID)
+}
+
+func updateDetail(upd beads.UpdateOptions, bodyChanged bool) string {
+	var parts []string
+	if upd.Title != nil {
+		parts = append(parts, "title")
+	}
+	if bodyChanged {
+		parts = append(parts, "body")
+	}
+	if upd.Assignee != nil {
+		parts = append(parts, "assignee")
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,377 @@
+package issuesync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// fakeProvider is an in-memory tracker.
+type fakeProvider struct {
+	issues   map[string]*ExternalIssue
+	comments map[string][]string
+	pushes   []string
+}
+
+func newFakeProvider(issues ...*ExternalIssue) *fakeProvider {
+	p := &fakeProvider{issues: make(map[string]*ExternalIssue), comments: make(map[string][]string)}
+	for _, i := range issues {
+		p.issues[i.Ref] = i
+	}
+	return p
+}
+
+func (p *fakeProvider) List(context.Context) ([]*ExternalIssue, error) {
+	var out []*ExternalIssue
+	for _, i := range p.issues {
+		cp := *i
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (p *fakeProvider) SetState(_ context.Context, ref, state, comment string) error {
+	p.issues[ref].State = state
+	p.pushes = append(p.pushes, "state "+ref+" "+state)
+	if comment != "" {
+		p.comments[ref] = append(p.comments[ref], comment)
+	}
+	return nil
+}
+
+func (p *fakeProvider) SetAssignee(_ context.Context, ref, _, to string) error {
+	p.issues[ref].Assignee = to
+	p.pushes = append(p.pushes, "assignee "+ref+" "+to)
+	return nil
+}
+
+func (p *fakeProvider) Comment(_ context.Context, ref, body string) error {
+	p.comments[ref] = append(p.comments[ref], body)
+	return nil
+}
+
+// fakeStore is an in-memory beads database.
+type fakeStore struct {
+	beads []*beads.Issue
+	mrs   []*beads.Issue
+	next  int
+}
+
+func (s *fakeStore) ListTracked(source string) ([]*beads.Issue, error) {
+	var out []*beads.Issue
+	for _, b := range s.beads {
+		if beads.HasLabel(b, beads.TrackerSourceLabel(source)) {
+			cp := *b
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) ListMergeRequests() ([]*beads.Issue, error) { return s.mrs, nil }
+
+func (s *fakeStore) CreateTracked(title string, fields *beads.TrackerFields, body string) (*beads.Issue, error) {
+	s.next++
+	b := &beads.Issue{
+		ID:          fmt.Sprintf("gt-t%d", s.next),
+		Title:       title,
+		Description: beads.FormatTrackerDescription(fields, body),
+		Status:      "open",
+		Labels:      []string{beads.TrackerLabel, beads.TrackerSourceLabel(fields.Source)},
+	}
+	s.beads = append(s.beads, b)
+	return b, nil
+}
+
+func (s *fakeStore) get(id string) *beads.Issue {
+	for _, b := range s.beads {
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) Update(id string, opts beads.UpdateOptions) error {
+	b := s.get(id)
+	if opts.Title != nil {
+		b.Title = *opts.Title
+	}
+	if opts.Description != nil {
+		b.Description = *opts.Description
+	}
+	if opts.Assignee != nil {
+		b.Assignee = *opts.Assignee
+	}
+	if opts.Status != nil {
+		b.Status = *opts.Status
+		b.CloseReason = ""
+	}
+	return nil
+}
+
+func (s *fakeStore) Close(id, reason string) error {
+	b := s.get(id)
+	b.Status = "closed"
+	b.CloseReason = reason
+	return nil
+}
+
+func newTestSyncer(p *fakeProvider, s *fakeStore) *Syncer {
+	return &Syncer{
+		Source:    "gh",
+		Provider:  p,
+		Store:     s,
+		Assignees: map[string]string{"gastown/crew/max": "maxgh"},
+		Now:       func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) },
+	}
+}
+
+func runSync(t *testing.T, s *Syncer) *Report {
+	t.Helper()
+	r, err := s.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error: %v", err)
+	}
+	if len(r.Errors) > 0 {
+		t.Fatalf("Sync() errors: %v", r.Errors)
+	}
+	return r
+}
+
+func kinds(r *Report) string {
+	var out []string
+	for _, a := range r.Actions {
+		out = append(out, a.Kind)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestSync_ImportsOpenIssuesOnce(t *testing.T) {
+	p := newFakeProvider(
+		&ExternalIssue{Ref: "acme/w#1", Title: "First", Body: "body one", State: StateOpen, Assignee: "maxgh"},
+		&ExternalIssue{Ref: "acme/w#2", Title: "Done already", State: StateClosed},
+	)
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+
+	r := runSync(t, s)
+	if kinds(r) != ActionImport {
+		t.Fatalf("actions = %q, want one import", kinds(r))
+	}
+	if len(store.beads) != 1 {
+		t.Fatalf("created %d beads, want 1 (closed issues are not imported)", len(store.beads))
+	}
+	b := store.beads[0]
+	f := beads.ParseTrackerFields(b)
+	if f == nil || f.Ref != "acme/w#1" || f.State != StateOpen || f.Assignee != "maxgh" {
+		t.Errorf("tracker fields = %+v", f)
+	}
+	if b.Assignee != "gastown/crew/max" {
+		t.Errorf("bead assignee = %q, want mapped gastown/crew/max", b.Assignee)
+	}
+	if beads.TrackerBody(b.Description) != "body one" {
+		t.Errorf("body = %q", beads.TrackerBody(b.Description))
+	}
+
+	if r := runSync(t, s); len(r.Actions) != 0 {
+		t.Errorf("second sync actions = %+v, want none", r.Actions)
+	}
+}
+
+func TestSync_ExternalCloseClosesBead(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	p.issues["acme/w#1"].State = StateClosed
+	r := runSync(t, s)
+	if kinds(r) != ActionCloseBead {
+		t.Errorf("actions = %q, want close-bead", kinds(r))
+	}
+	if store.beads[0].Status != "closed" || len(p.pushes) != 0 {
+		t.Errorf("bead status = %q, pushes = %v", store.beads[0].Status, p.pushes)
+	}
+
+	p.issues["acme/w#1"].State = StateOpen
+	r = runSync(t, s)
+	if kinds(r) != ActionReopenBead || store.beads[0].Status != "open" {
+		t.Errorf("actions = %q, bead status = %q, want reopen", kinds(r), store.beads[0].Status)
+	}
+}
+
+func TestSync_BeadClosePushesReason(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	_ = store.Close("gt-t1", "fixed in refinery")
+	r := runSync(t, s)
+	if kinds(r) != ActionPushState {
+		t.Errorf("actions = %q, want push-state", kinds(r))
+	}
+	if p.issues["acme/w#1"].State != StateClosed {
+		t.Error("external issue not closed")
+	}
+	if c := p.comments["acme/w#1"]; len(c) != 1 || !strings.Contains(c[0], "fixed in refinery") {
+		t.Errorf("comments = %v, want close reason", c)
+	}
+	if f := beads.ParseTrackerFields(store.beads[0]); f.State != StateClosed {
+		t.Errorf("base state = %q, want closed", f.State)
+	}
+}
+
+func TestSync_AssigneeConflictBeadWins(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	store.beads[0].Assignee = "gastown/crew/max"
+	p.issues["acme/w#1"].Assignee = "someone-else"
+	r := runSync(t, s)
+
+	if len(r.Conflicts) != 1 || r.Conflicts[0].Field != "assignee" || r.Conflicts[0].Resolution != "maxgh" {
+		t.Fatalf("conflicts = %+v", r.Conflicts)
+	}
+	if p.issues["acme/w#1"].Assignee != "maxgh" {
+		t.Errorf("external assignee = %q, want maxgh", p.issues["acme/w#1"].Assignee)
+	}
+	if r := runSync(t, s); len(r.Actions) != 0 || len(r.Conflicts) != 0 {
+		t.Errorf("third sync = %+v, want no actions", r)
+	}
+}
+
+func TestSync_UnmappedAssigneesAreLeftAlone(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen, Assignee: "stranger"})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	if store.beads[0].Assignee != "" {
+		t.Errorf("bead assignee = %q, want empty for unmapped user", store.beads[0].Assignee)
+	}
+	if r := runSync(t, s); len(r.Actions) != 0 {
+		t.Errorf("actions = %+v, want none (must not unassign stranger)", r.Actions)
+	}
+
+	store.beads[0].Assignee = "gastown/polecats/Toast"
+	if r := runSync(t, s); len(r.Actions) != 0 || len(p.pushes) != 0 {
+		t.Errorf("polecat assignment pushed: actions=%+v pushes=%v", r.Actions, p.pushes)
+	}
+}
+
+func TestSync_TitleAndBodyFollowTracker(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", Body: "old", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	p.issues["acme/w#1"].Title = "First (renamed)"
+	p.issues["acme/w#1"].Body = "new"
+	r := runSync(t, s)
+	if kinds(r) != ActionUpdateBead || r.Actions[0].Detail != "title,body" {
+		t.Errorf("actions = %+v", r.Actions)
+	}
+	b := store.beads[0]
+	if b.Title != "First (renamed)" || beads.TrackerBody(b.Description) != "new" {
+		t.Errorf("bead = %q / %q", b.Title, b.Description)
+	}
+	if beads.ParseTrackerFields(b).Ref != "acme/w#1" {
+		t.Error("tracker fields lost on body update")
+	}
+}
+
+func TestSync_UnchangedBodyIsNotRewritten(t *testing.T) {
+	// A body with field-like lines and trailing whitespace that bd would
+	// normalize away must not look changed on every sync.
+	body := "tracker_state: closed\ntracker: evil\n\nsteps   \r\n"
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", Body: body, State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	b := store.beads[0]
+	b.Description = strings.ReplaceAll(strings.TrimRight(b.Description, " \r\n"), "   \r", "")
+	if r := runSync(t, s); len(r.Actions) != 0 {
+		t.Errorf("unchanged issue produced actions: %+v", r.Actions)
+	}
+	if f := beads.ParseTrackerFields(b); f.Source != "gh" || f.State != StateOpen {
+		t.Errorf("body lines parsed as tracker fields: %+v", f)
+	}
+}
+
+func TestSync_MergeRequestCommentedOncePerState(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+
+	mr := &beads.Issue{
+		ID:          "gt-mr1",
+		Status:      "open",
+		UpdatedAt:   "2026-10-18T10:00:00Z",
+		Description: "branch: polecat/Toast/gt-t1\ntarget: main\nsource_issue: gt-t1",
+	}
+	store.mrs = []*beads.Issue{mr}
+
+	runSync(t, s)
+	runSync(t, s)
+	if c := p.comments["acme/w#1"]; len(c) != 1 || !strings.Contains(c[0], "gt-mr1") || !strings.Contains(c[0], "merge queue") {
+		t.Fatalf("comments = %v, want one queued note", c)
+	}
+
+	mr.Status = "closed"
+	mr.Description += "\nclose_reason: merged\nmerge_commit: abc1234"
+	runSync(t, s)
+	if c := p.comments["acme/w#1"]; len(c) != 2 || !strings.Contains(c[1], "merged as abc1234") {
+		t.Errorf("comments = %v, want merged note", c)
+	}
+}
+
+func TestSync_DryRunChangesNothing(t *testing.T) {
+	p := newFakeProvider(&ExternalIssue{Ref: "acme/w#1", Title: "First", State: StateOpen})
+	store := &fakeStore{}
+	s := newTestSyncer(p, store)
+	runSync(t, s)
+	_ = store.Close("gt-t1", "done")
+	p.issues["acme/w#2"] = &ExternalIssue{Ref: "acme/w#2", Title: "Second", State: StateOpen}
+
+	s.DryRun = true
+	r := runSync(t, s)
+	if kinds(r) != ActionPushState+","+ActionImport {
+		t.Errorf("planned actions = %q", kinds(r))
+	}
+	if len(store.beads) != 1 || p.issues["acme/w#1"].State != StateOpen {
+		t.Error("dry run changed state")
+	}
+	if beads.ParseTrackerFields(store.beads[0]).State != StateOpen {
+		t.Error("dry run advanced the merge base")
+	}
+}
+
+func TestMergeState(t *testing.T) {
+	tests := []struct {
+		base, local, remote string
+		want                string
+		conflict            bool
+	}{
+		{"open", "open", "open", "open", false},
+		{"open", "closed", "open", "closed", false},
+		{"open", "open", "closed", "closed", false},
+		{"closed", "open", "closed", "open", false},
+		{"", "open", "closed", "closed", true},
+	}
+	for _, tt := range tests {
+		got, conflict := mergeState(tt.base, tt.local, tt.remote)
+		if got != tt.want || conflict != tt.conflict {
+			t.Errorf("mergeState(%q, %q, %q) = %q, %v; want %q, %v", tt.base, tt.local, tt.remote, got, conflict, tt.want, tt.conflict)
+		}
+	}
+}
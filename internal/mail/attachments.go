@@ -0,0 +1,308 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// MaxAttachmentSize is the largest blob accepted by StoreAttachment.
+const MaxAttachmentSize = 25 << 20
+
+// LabelPayloadPrefix records the schema of a message's typed payload, so
+// handlers can find protocol messages without reading every body.
+const LabelPayloadPrefix = "payload:"
+
+// digestPrefix is the only digest algorithm the blob store uses.
+const digestPrefix = "sha256:"
+
+// Markers around the metadata line appended to a message bead's description.
+// json.Marshal escapes '>' so the JSON can never contain the end marker.
+const (
+	metaStart = "<!-- gt:mail "
+	metaEnd   = " -->"
+)
+
+// ErrAttachmentTooLarge is returned when a blob exceeds MaxAttachmentSize.
+var ErrAttachmentTooLarge = fmt.Errorf("attachment exceeds %d MiB limit", MaxAttachmentSize>>20)
+
+// schemaPattern limits payload schema names to label-safe characters.
+var schemaPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/-]*$`)
+
+// Payload is a typed JSON body identified by a schema name such as
+// "protocol.merge_ready/v1". Receivers decode Data based on Schema.
+type Payload struct {
+	Schema string          `json:"schema"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// NewPayload marshals v as a payload with the given schema.
+func NewPayload(schema string, v interface{}) (*Payload, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s payload: %w", schema, err)
+	}
+	p := &Payload{Schema: schema, Data: data}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the schema name and that Data is well-formed JSON.
+func (p *Payload) Validate() error {
+	if !schemaPattern.MatchString(p.Schema) {
+		return fmt.Errorf("invalid payload schema %q", p.Schema)
+	}
+	if !json.Valid(p.Data) {
+		return fmt.Errorf("payload %s is not valid JSON", p.Schema)
+	}
+	return nil
+}
+
+// Decode unmarshals the payload into v after checking it has the expected schema.
+func (p *Payload) Decode(schema string, v interface{}) error {
+	if p.Schema != schema {
+		return fmt.Errorf("payload schema is %q, want %q", p.Schema, schema)
+	}
+	if err := json.Unmarshal(p.Data, v); err != nil {
+		return fmt.Errorf("decoding %s payload: %w", schema, err)
+	}
+	return nil
+}
+
+// Attachment references a blob in the town's content-addressed blob store.
+type Attachment struct {
+	Name      string `json:"name"`
+	MediaType string `json:"media_type,omitempty"`
+	Size      int64  `json:"size"`
+	Digest    string `json:"digest"` // "sha256:<hex>"
+}
+
+// Validate checks that the attachment has a name and a well-formed digest.
+func (a Attachment) Validate() error {
+	if a.Name == "" {
+		return fmt.Errorf("attachment %s has no name", a.Digest)
+	}
+	if _, err := digestHex(a.Digest); err != nil {
+		return fmt.Errorf("attachment %q: %w", a.Name, err)
+	}
+	return nil
+}
+
+// BlobDir returns the directory holding the town's mail blobs.
+func BlobDir(townRoot string) string {
+	return filepath.Join(townRoot, "mail", "blobs")
+}
+
+// digestHex returns the hex part of a "sha256:<hex>" digest.
+func digestHex(digest string) (string, error) {
+	h := strings.TrimPrefix(digest, digestPrefix)
+	if h == digest || len(h) != sha256.Size*2 {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	if _, err := hex.DecodeString(h); err != nil {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return h, nil
+}
+
+// blobPath returns where the blob with digest is stored, fanned out by the
+// first two hex characters.
+func blobPath(townRoot, digest string) (string, error) {
+	h, err := digestHex(digest)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(BlobDir(townRoot), "sha256", h[:2], h), nil
+}
+
+// StoreAttachment copies r into the blob store and returns an attachment
+// named name. Identical content is stored once. The media type is taken from
+// the name's extension, falling back to content sniffing.
+func StoreAttachment(townRoot, name string, r io.Reader) (Attachment, error) {
+	if townRoot == "" {
+		return Attachment{}, errors.New("storing attachment: no town root")
+	}
+	dir := filepath.Join(BlobDir(townRoot), "sha256")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Attachment{}, fmt.Errorf("creating blob store: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return Attachment{}, fmt.Errorf("creating blob: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	sum := sha256.New()
+	var head bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(tmp, sum, &limitedBuffer{buf: &head, max: 512}), io.LimitReader(r, MaxAttachmentSize+1))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Attachment{}, fmt.Errorf("writing blob: %w", err)
+	}
+	if n > MaxAttachmentSize {
+		return Attachment{}, ErrAttachmentTooLarge
+	}
+
+	att := Attachment{
+		Name:      filepath.Base(name),
+		MediaType: mime.TypeByExtension(filepath.Ext(name)),
+		Size:      n,
+		Digest:    digestPrefix + hex.EncodeToString(sum.Sum(nil)),
+	}
+	if att.MediaType == "" {
+		att.MediaType = http.DetectContentType(head.Bytes())
+	}
+
+	path, _ := blobPath(townRoot, att.Digest)
+	if _, err := os.Stat(path); err == nil {
+		return att, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Attachment{}, fmt.Errorf("creating blob dir: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return Attachment{}, fmt.Errorf("storing blob: %w", err)
+	}
+	return att, nil
+}
+
+// AttachFile stores the file at path and returns its attachment.
+func AttachFile(townRoot, path string) (Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer f.Close()
+	return StoreAttachment(townRoot, path, f)
+}
+
+// OpenAttachment opens the blob for a. The content is verified against the
+// digest as it is read; a mismatch surfaces as an error from Read at EOF.
+func OpenAttachment(townRoot string, a Attachment) (io.ReadCloser, error) {
+	path, err := blobPath(townRoot, a.Digest)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("attachment %q: blob %s not found", a.Name, a.Digest)
+		}
+		return nil, err
+	}
+	return &verifyingReader{f: f, hash: sha256.New(), digest: a.Digest}, nil
+}
+
+// verifyingReader hashes a blob as it is read and fails at EOF on mismatch.
+type verifyingReader struct {
+	f      *os.File
+	hash   hash.Hash
+	digest string
+}
+
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	n, err := v.f.Read(p)
+	v.hash.Write(p[:n])
+	if err == io.EOF {
+		if got := digestPrefix + hex.EncodeToString(v.hash.Sum(nil)); got != v.digest {
+			return n, fmt.Errorf("blob %s is corrupt (content hashes to %s)", v.digest, got)
+		}
+	}
+	return n, err
+}
+
+func (v *verifyingReader) Close() error { return v.f.Close() }
+
+// limitedBuffer keeps the first max bytes written to it, for content sniffing.
+type limitedBuffer struct {
+	buf *bytes.Buffer
+	max int
+}
+
+func (l *limitedBuffer) Write(p []byte) (int, error) {
+	if room := l.max - l.buf.Len(); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		l.buf.Write(p[:room])
+	}
+	return len(p), nil
+}
+
+// FindAttachment returns the attachment of msg matching ref, which may be a
+// name or a digest (with or without the "sha256:" prefix). With an empty ref
+// the message must have exactly one attachment.
+func FindAttachment(msg *Message, ref string) (Attachment, error) {
+	if ref == "" {
+		if len(msg.Attachments) == 1 {
+			return msg.Attachments[0], nil
+		}
+		return Attachment{}, fmt.Errorf("message %s has %d attachments; name one", msg.ID, len(msg.Attachments))
+	}
+	for _, a := range msg.Attachments {
+		if a.Name == ref || a.Digest == ref || a.Digest == digestPrefix+ref {
+			return a, nil
+		}
+	}
+	return Attachment{}, fmt.Errorf("message %s has no attachment %q", msg.ID, ref)
+}
+
+// messageMeta is the metadata line stored in the bead description.
+type messageMeta struct {
+	Payload     *Payload     `json:"payload,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+}
+
+// EncodeDescription returns the bead description for msg: the body, followed
+// by a single metadata line when the message has a payload or attachments.
+// Payloads and attachment references are too large and too structured for
+// labels, so they ride in the description where bd stores them verbatim.
+func EncodeDescription(msg *Message) string {
+	if msg.Payload == nil && len(msg.Attachments) == 0 {
+		return msg.Body
+	}
+	data, err := json.Marshal(messageMeta{Payload: msg.Payload, Attachments: msg.Attachments})
+	if err != nil {
+		return msg.Body
+	}
+	return msg.Body + "\n\n" + metaStart + string(data) + metaEnd
+}
+
+// DecodeDescription splits a bead description into the body and any payload
+// and attachments stored by EncodeDescription. A description without a
+// metadata line is returned unchanged.
+func DecodeDescription(desc string) (string, *Payload, []Attachment) {
+	i := strings.LastIndex(desc, metaStart)
+	if i < 0 || !strings.HasSuffix(desc, metaEnd) {
+		return desc, nil, nil
+	}
+	raw := desc[i+len(metaStart) : len(desc)-len(metaEnd)]
+	var meta messageMeta
+	if strings.Contains(raw, "\n") || json.Unmarshal([]byte(raw), &meta) != nil {
+		return desc, nil, nil
+	}
+	return strings.TrimSuffix(desc[:i], "\n\n"), meta.Payload, meta.Attachments
+}
+
+// PayloadLabels returns the label recording the payload schema of msg, if any.
+func PayloadLabels(msg *Message) []string {
+	if msg.Payload == nil {
+		return nil
+	}
+	return []string{LabelPayloadPrefix + msg.Payload.Schema}
+}
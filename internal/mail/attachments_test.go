@@ -0,0 +1,153 @@
+package mail
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDescriptionRoundTrip(t *testing.T) {
+	payload, err := NewPayload("stats/v1", map[string]string{"note": "a --> b <!-- c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &Message{
+		Body:    "See attached.\n\n<!-- not metadata -->",
+		Payload: payload,
+		Attachments: []Attachment{{
+			Name: "build.log", MediaType: "text/plain", Size: 3,
+			Digest: "sha256:" + strings.Repeat("ab", 32),
+		}},
+	}
+
+	desc := EncodeDescription(msg)
+	body, gotPayload, atts := DecodeDescription(desc)
+	if body != msg.Body {
+		t.Errorf("body = %q, want %q", body, msg.Body)
+	}
+	if gotPayload == nil || gotPayload.Schema != "stats/v1" || string(gotPayload.Data) != string(payload.Data) {
+		t.Errorf("payload = %+v, want %+v", gotPayload, payload)
+	}
+	if len(atts) != 1 || atts[0] != msg.Attachments[0] {
+		t.Errorf("attachments = %+v", atts)
+	}
+
+	bm := &BeadsMessage{ID: "hq-1", Title: "x", Description: desc, Status: "open"}
+	if got := bm.ToMessage(); got.Body != msg.Body || got.Payload == nil || len(got.Attachments) != 1 {
+		t.Errorf("ToMessage() = body %q payload %v attachments %d", got.Body, got.Payload, len(got.Attachments))
+	}
+}
+
+func TestDecodeDescription_PlainBody(t *testing.T) {
+	for _, desc := range []string{
+		"",
+		"hello",
+		"trailing <!-- gt:mail not json -->",
+		"<!-- gt:mail {\"payload\":\n{}} -->",
+	} {
+		body, payload, atts := DecodeDescription(desc)
+		if body != desc || payload != nil || atts != nil {
+			t.Errorf("DecodeDescription(%q) = %q %v %v, want unchanged", desc, body, payload, atts)
+		}
+	}
+	if got := EncodeDescription(&Message{Body: "plain"}); got != "plain" {
+		t.Errorf("EncodeDescription without metadata = %q", got)
+	}
+}
+
+func TestPayloadValidate(t *testing.T) {
+	if err := (&Payload{Schema: "a b", Data: []byte(`{}`)}).Validate(); err == nil {
+		t.Error("expected error for schema with space")
+	}
+	if err := (&Payload{Schema: "x/v1", Data: []byte(`{`)}).Validate(); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+	p := &Payload{Schema: "x/v1", Data: []byte(`{"n":1}`)}
+	var v struct{ N int }
+	if err := p.Decode("y/v1", &v); err == nil {
+		t.Error("expected schema mismatch error")
+	}
+	if err := p.Decode("x/v1", &v); err != nil || v.N != 1 {
+		t.Errorf("Decode = %v, N = %d", err, v.N)
+	}
+}
+
+func TestStoreAndOpenAttachment(t *testing.T) {
+	town := t.TempDir()
+	att, err := StoreAttachment(town, "/tmp/logs/OUTPUT", strings.NewReader("ok\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Name != "OUTPUT" || att.Size != 3 || !strings.HasPrefix(att.MediaType, "text/plain") {
+		t.Errorf("attachment = %+v", att)
+	}
+	if err := att.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Identical content is stored once.
+	again, err := StoreAttachment(town, "copy.txt", strings.NewReader("ok\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Digest != att.Digest {
+		t.Errorf("digest changed for identical content")
+	}
+	blobs, _ := filepath.Glob(filepath.Join(BlobDir(town), "sha256", "*", "*"))
+	if len(blobs) != 1 {
+		t.Errorf("blobs = %v, want one", blobs)
+	}
+
+	r, err := OpenAttachment(town, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "ok\n" {
+		t.Errorf("read = %q, %v", data, err)
+	}
+
+	// Corruption is detected on read.
+	if err := os.WriteFile(blobs[0], []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err = OpenAttachment(town, att)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(r)
+	r.Close()
+	if err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("read of tampered blob: err = %v, want corrupt", err)
+	}
+}
+
+func TestOpenAttachment_RejectsBadDigest(t *testing.T) {
+	for _, digest := range []string{"", "sha256:../../etc/passwd", "md5:" + strings.Repeat("0", 64)} {
+		if _, err := OpenAttachment(t.TempDir(), Attachment{Name: "x", Digest: digest}); err == nil {
+			t.Errorf("OpenAttachment(%q) succeeded", digest)
+		}
+	}
+}
+
+func TestFindAttachment(t *testing.T) {
+	a := Attachment{Name: "a.diff", Digest: "sha256:" + strings.Repeat("1", 64)}
+	b := Attachment{Name: "b.log", Digest: "sha256:" + strings.Repeat("2", 64)}
+	msg := &Message{ID: "hq-1", Attachments: []Attachment{a, b}}
+
+	if _, err := FindAttachment(msg, ""); err == nil {
+		t.Error("expected error naming nothing with two attachments")
+	}
+	if got, err := FindAttachment(msg, "b.log"); err != nil || got != b {
+		t.Errorf("by name = %+v, %v", got, err)
+	}
+	if got, err := FindAttachment(msg, strings.Repeat("1", 64)); err != nil || got != a {
+		t.Errorf("by bare digest = %+v, %v", got, err)
+	}
+	if got, err := FindAttachment(&Message{Attachments: []Attachment{a}}, ""); err != nil || got != a {
+		t.Errorf("single = %+v, %v", got, err)
+	}
+}
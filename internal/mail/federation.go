@@ -0,0 +1,723 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Cross-town federation.
+//
+// Towns exchange mail through spool directories: each town has an inbound
+// spool that its peers can write to (a shared mount, a synced folder, ...).
+// A remote address has the form @<town>/<identity>, e.g. @othertown/mayor/.
+//
+// Sending writes a signed envelope into the peer's spool and keeps a copy in
+// the local outbox. When the peer syncs, it verifies the signature, delivers
+// the message locally, records the message ID so a retried envelope is not
+// delivered twice, and writes a signed acknowledgement into the sender's
+// spool. The sender drops the outbox entry once the ack arrives and rewrites
+// the envelope if it disappeared without one. Envelopes the peer cannot
+// deliver (unknown recipient) are acked with an error and bounced to the
+// sender, as are envelopes that stay unacknowledged for FederationMaxAge.
+//
+// Signatures are HMAC-SHA256 with a secret shared by each pair of towns.
+
+// Federation timing.
+const (
+	// FederationRetryInterval is the minimum time between pushes of an
+	// unacknowledged envelope.
+	FederationRetryInterval = time.Minute
+
+	// FederationMaxAge is how long an envelope may stay unacknowledged before
+	// it is bounced to the sender.
+	FederationMaxAge = 72 * time.Hour
+)
+
+// Spool file kinds.
+const (
+	spoolKindMessage = "msg"
+	spoolKindAck     = "ack"
+)
+
+// federationPostmaster is the identity bounces come from (as @<town>/postmaster).
+const federationPostmaster = "postmaster"
+
+var (
+	// ErrUnknownPeer indicates a remote address names a town that is not a
+	// configured federation peer.
+	ErrUnknownPeer = errors.New("unknown federation peer")
+
+	townNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	spoolIDPattern  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+)
+
+// reservedTownNames are @group prefixes that take a slash, so a town with one
+// of these names could not be addressed.
+var reservedTownNames = map[string]bool{"rig": true, "crew": true, "polecats": true}
+
+// FederationConfigPath returns the federation config file for a town.
+func FederationConfigPath(townRoot string) string {
+	return filepath.Join(townRoot, "mail", "federation.json")
+}
+
+// federationStateDir holds the outbox and the received-message ledger.
+func federationStateDir(townRoot string) string {
+	return filepath.Join(townRoot, "mail", "federation")
+}
+
+// FederationConfig is the federation setup of a town. It holds the shared
+// secrets, so it is written with owner-only permissions.
+type FederationConfig struct {
+	Town  string                     `json:"town"`            // This town's name, as peers address it
+	Spool string                     `json:"spool,omitempty"` // Inbound spool (default <town>/mail/federation/spool)
+	Peers map[string]*FederationPeer `json:"peers,omitempty"` // Keyed by town name
+}
+
+// FederationPeer is a remote town.
+type FederationPeer struct {
+	Spool  string `json:"spool"`  // The peer's inbound spool directory
+	Secret string `json:"secret"` // Shared HMAC key (hex), configured on both sides
+}
+
+// ValidateTownName checks that name can be used as a town in @town/ addresses.
+func ValidateTownName(name string) error {
+	if !townNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid town name %q (letters, digits, '-' and '_')", name)
+	}
+	if reservedTownNames[name] {
+		return fmt.Errorf("town name %q is reserved for @%s/ group addresses", name, name)
+	}
+	return nil
+}
+
+// GenerateFederationSecret returns a new random shared secret.
+func GenerateFederationSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating secret: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Validate checks the configuration.
+func (c *FederationConfig) Validate() error {
+	if c.Town != "" {
+		if err := ValidateTownName(c.Town); err != nil {
+			return err
+		}
+	}
+	for name, peer := range c.Peers {
+		if err := ValidateTownName(name); err != nil {
+			return fmt.Errorf("peer %s: %w", name, err)
+		}
+		if name == c.Town {
+			return fmt.Errorf("peer %s: a town cannot peer with itself", name)
+		}
+		if peer == nil || peer.Spool == "" {
+			return fmt.Errorf("peer %s: spool is required", name)
+		}
+		if key, err := hex.DecodeString(peer.Secret); err != nil || len(key) < 16 {
+			return fmt.Errorf("peer %s: secret must be at least 32 hex characters", name)
+		}
+	}
+	return nil
+}
+
+// LoadFederationConfig reads a federation config. A missing file yields an
+// empty config (no town name, no peers).
+func LoadFederationConfig(path string) (*FederationConfig, error) {
+	cfg := &FederationConfig{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading federation config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing federation config %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("federation config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveFederationConfig validates and writes a federation config.
+func SaveFederationConfig(path string, cfg *FederationConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	return util.EnsureDirAndWriteJSONWithPerm(path, cfg, 0600)
+}
+
+// ParseRemoteAddress splits a remote address "@town/identity". It only checks
+// the form; whether town is a configured peer is up to the caller.
+func ParseRemoteAddress(address string) (town, identity string, ok bool) {
+	if !strings.HasPrefix(address, "@") {
+		return "", "", false
+	}
+	town, identity, found := strings.Cut(address[1:], "/")
+	if !found || town == "" || identity == "" {
+		return "", "", false
+	}
+	return town, identity, true
+}
+
+// RemoteAddress returns the address of identity in another town.
+func RemoteAddress(town, identity string) string {
+	return "@" + town + "/" + identity
+}
+
+// Federation sends and receives mail for one town.
+type Federation struct {
+	townRoot string
+	cfg      *FederationConfig
+}
+
+// LoadFederation loads the federation config of a town.
+func LoadFederation(townRoot string) (*Federation, error) {
+	cfg, err := LoadFederationConfig(FederationConfigPath(townRoot))
+	if err != nil {
+		return nil, err
+	}
+	return &Federation{townRoot: townRoot, cfg: cfg}, nil
+}
+
+// Config returns the loaded configuration.
+func (f *Federation) Config() *FederationConfig { return f.cfg }
+
+// SpoolDir returns this town's inbound spool directory.
+func (f *Federation) SpoolDir() string {
+	if f.cfg.Spool != "" {
+		return f.cfg.Spool
+	}
+	return filepath.Join(federationStateDir(f.townRoot), "spool")
+}
+
+func (f *Federation) peer(town string) (*FederationPeer, error) {
+	if f.cfg.Town == "" {
+		return nil, fmt.Errorf("%w: %s (this town has no federation name; run 'gt mail federation init')", ErrUnknownPeer, town)
+	}
+	peer, ok := f.cfg.Peers[town]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPeer, town)
+	}
+	return peer, nil
+}
+
+// spoolFile is the on-disk form of an envelope or ack. The signature covers
+// kind, sender, receiver and the exact body bytes.
+type spoolFile struct {
+	Kind      string          `json:"kind"`
+	From      string          `json:"from"` // Sending town
+	To        string          `json:"to"`   // Receiving town
+	ID        string          `json:"id"`   // Message ID (dedup key)
+	Body      json.RawMessage `json:"body"`
+	Signature string          `json:"signature"`
+}
+
+func (s *spoolFile) name() string {
+	return s.Kind + "-" + s.From + "-" + s.ID + ".json"
+}
+
+func (s *spoolFile) mac(secret string) string {
+	key, _ := hex.DecodeString(secret)
+	h := hmac.New(sha256.New, key)
+	for _, part := range []string{s.Kind, s.From, s.To, s.ID} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(s.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *spoolFile) sign(secret string) { s.Signature = s.mac(secret) }
+
+func (s *spoolFile) verify(secret string) bool {
+	return hmac.Equal([]byte(s.Signature), []byte(s.mac(secret)))
+}
+
+// federationEnvelope is the body of a message spool file.
+type federationEnvelope struct {
+	To      string            `json:"to"` // Recipient identity in the receiving town
+	Message *Message          `json:"message"`
+	Blobs   map[string][]byte `json:"blobs,omitempty"` // Attachment content by digest
+}
+
+// federationAck is the body of an ack spool file.
+type federationAck struct {
+	ReceivedAt time.Time `json:"received_at"`
+	Error      string    `json:"error,omitempty"` // Set when the message was refused
+}
+
+// OutboxEntry is a sent message waiting for the peer's acknowledgement.
+type OutboxEntry struct {
+	ID          string    `json:"id"`
+	Peer        string    `json:"peer"`
+	From        string    `json:"from"` // Local sender identity
+	To          string    `json:"to"`   // Recipient identity in the peer town
+	Subject     string    `json:"subject"`
+	Created     time.Time `json:"created"`
+	LastAttempt time.Time `json:"last_attempt"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	File        spoolFile `json:"file"`
+}
+
+func (f *Federation) outboxPath(peer, id string) string {
+	return filepath.Join(federationStateDir(f.townRoot), "outbox", peer, id+".json")
+}
+
+func (f *Federation) receivedPath(peer, id string) string {
+	return filepath.Join(federationStateDir(f.townRoot), "received", peer, id)
+}
+
+// Enqueue sends msg to identity in the peer town. The envelope is kept in the
+// outbox until acknowledged; a failed push is recorded and retried by Sync,
+// so Enqueue only fails if the message cannot be queued at all.
+func (f *Federation) Enqueue(town, identity string, msg *Message) (*OutboxEntry, error) {
+	peer, err := f.peer(town)
+	if err != nil {
+		return nil, err
+	}
+	if !spoolIDPattern.MatchString(msg.ID) {
+		return nil, fmt.Errorf("message ID %q cannot be federated", msg.ID)
+	}
+
+	remote := *msg
+	remote.To = identity
+	remote.Read = false
+	remote.ReadBy, remote.ReadAt = "", nil
+	// CC addresses are local to this town; make them reachable from the peer.
+	remote.CC = nil
+	for _, cc := range msg.CC {
+		if _, _, ok := ParseRemoteAddress(cc); !ok {
+			cc = RemoteAddress(f.cfg.Town, AddressToIdentity(cc))
+		}
+		remote.CC = append(remote.CC, cc)
+	}
+	env := federationEnvelope{To: identity, Message: &remote}
+	for _, att := range msg.Attachments {
+		data, err := readAttachment(f.townRoot, att)
+		if err != nil {
+			return nil, err
+		}
+		if env.Blobs == nil {
+			env.Blobs = make(map[string][]byte)
+		}
+		env.Blobs[att.Digest] = data
+	}
+	body, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("encoding envelope: %w", err)
+	}
+
+	file := spoolFile{Kind: spoolKindMessage, From: f.cfg.Town, To: town, ID: msg.ID, Body: body}
+	file.sign(peer.Secret)
+	now := timeNow()
+	entry := &OutboxEntry{
+		ID:      msg.ID,
+		Peer:    town,
+		From:    msg.From,
+		To:      identity,
+		Subject: msg.Subject,
+		Created: now,
+		File:    file,
+	}
+	// Record the entry before the push so an ack can never arrive for a
+	// message the outbox does not know about.
+	if err := f.saveOutbox(entry); err != nil {
+		return nil, err
+	}
+	f.push(entry, peer, now)
+	if err := f.saveOutbox(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func readAttachment(townRoot string, att Attachment) ([]byte, error) {
+	rc, err := OpenAttachment(townRoot, att)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading attachment %q: %w", att.Name, err)
+	}
+	return data, nil
+}
+
+// push writes the entry's envelope into the peer's spool and records the
+// attempt. The peer spool must already exist: creating it here would hide
+// an unmounted share.
+func (f *Federation) push(entry *OutboxEntry, peer *FederationPeer, now time.Time) {
+	entry.Attempts++
+	entry.LastAttempt = now
+	entry.LastError = ""
+	if err := writeSpoolFile(peer.Spool, &entry.File); err != nil {
+		entry.LastError = err.Error()
+	}
+}
+
+func writeSpoolFile(dir string, file *spoolFile) error {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("spool %s is not available", dir)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	return util.AtomicWriteFile(filepath.Join(dir, file.name()), data, 0644)
+}
+
+func (f *Federation) saveOutbox(entry *OutboxEntry) error {
+	return util.EnsureDirAndWriteJSONWithPerm(f.outboxPath(entry.Peer, entry.ID), entry, 0600)
+}
+
+// Outbox returns the messages waiting for acknowledgement, oldest first.
+func (f *Federation) Outbox() ([]*OutboxEntry, error) {
+	dir := filepath.Join(federationStateDir(f.townRoot), "outbox")
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*OutboxEntry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading outbox: %w", err)
+		}
+		var entry OutboxEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("parsing outbox entry %s: %w", path, err)
+		}
+		entries = append(entries, &entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Created.Before(entries[j].Created) })
+	return entries, nil
+}
+
+// FederationSyncResult reports what Federation.Sync did.
+type FederationSyncResult struct {
+	Received   []*Message `json:"received"`             // Messages delivered locally
+	Duplicates []string   `json:"duplicates,omitempty"` // Retried envelopes already delivered (re-acked)
+	Refused    []string   `json:"refused,omitempty"`    // Incoming envelopes acked with an error
+	Acked      []string   `json:"acked"`                // Sent messages confirmed by the peer
+	Bounced    []string   `json:"bounced,omitempty"`    // Sent messages returned to the sender
+	Retried    []string   `json:"retried,omitempty"`    // Envelopes pushed again
+	Pending    int        `json:"pending"`              // Still waiting for an ack
+	Errors     []string   `json:"errors,omitempty"`
+}
+
+// FederationDelivery is how Sync hands messages to the local town.
+type FederationDelivery struct {
+	// Validate reports whether identity is a local recipient. An error
+	// refuses the message permanently.
+	Validate func(identity string) error
+	// Deliver stores a message locally. An error leaves the envelope in the
+	// spool for the next sync.
+	Deliver func(msg *Message) error
+}
+
+// Sync processes the inbound spool (messages and acks) and re-pushes
+// unacknowledged envelopes. It is safe to run repeatedly: deliveries are
+// recorded by message ID, and acks and retries are idempotent.
+func (f *Federation) Sync(d FederationDelivery) (*FederationSyncResult, error) {
+	result := &FederationSyncResult{}
+	if f.cfg.Town == "" {
+		return result, nil
+	}
+	spool := f.SpoolDir()
+	if err := os.MkdirAll(spool, 0755); err != nil {
+		return nil, fmt.Errorf("creating spool: %w", err)
+	}
+	paths, err := filepath.Glob(filepath.Join(spool, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := f.processSpoolFile(path, d, result); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", filepath.Base(path), err))
+		}
+	}
+
+	f.retry(d, result)
+	return result, nil
+}
+
+// processSpoolFile handles one inbound file. The file is removed once it is
+// fully handled; an error leaves it for the next sync, except for files
+// that fail verification, which are moved to the rejected directory.
+func (f *Federation) processSpoolFile(path string, d FederationDelivery, result *FederationSyncResult) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file spoolFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return f.reject(path, fmt.Errorf("malformed spool file: %w", err))
+	}
+	peer, ok := f.cfg.Peers[file.From]
+	switch {
+	case !ok:
+		return f.reject(path, fmt.Errorf("%w: %s", ErrUnknownPeer, file.From))
+	case file.To != f.cfg.Town:
+		return f.reject(path, fmt.Errorf("addressed to town %q, not %q", file.To, f.cfg.Town))
+	case !spoolIDPattern.MatchString(file.ID):
+		return f.reject(path, fmt.Errorf("invalid message ID %q", file.ID))
+	case !file.verify(peer.Secret):
+		return f.reject(path, errors.New("bad signature"))
+	}
+
+	switch file.Kind {
+	case spoolKindMessage:
+		err = f.receive(&file, peer, d, result)
+	case spoolKindAck:
+		err = f.acknowledge(&file, d, result)
+	default:
+		return f.reject(path, fmt.Errorf("unknown spool file kind %q", file.Kind))
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// reject moves an unverifiable file aside so it is not retried forever.
+func (f *Federation) reject(path string, cause error) error {
+	dir := filepath.Join(federationStateDir(f.townRoot), "rejected")
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		_ = os.Rename(path, filepath.Join(dir, filepath.Base(path)))
+	}
+	return fmt.Errorf("rejected: %w", cause)
+}
+
+// receive delivers an incoming envelope and acknowledges it.
+func (f *Federation) receive(file *spoolFile, peer *FederationPeer, d FederationDelivery, result *FederationSyncResult) error {
+	marker := f.receivedPath(file.From, file.ID)
+	if _, err := os.Stat(marker); err == nil {
+		// Already delivered: the sender retried because our ack was lost.
+		result.Duplicates = append(result.Duplicates, file.ID)
+		return f.sendAck(file, peer, "")
+	}
+
+	var env federationEnvelope
+	if err := json.Unmarshal(file.Body, &env); err != nil || env.Message == nil {
+		result.Refused = append(result.Refused, file.ID)
+		return f.sendAck(file, peer, "malformed envelope")
+	}
+	to := AddressToIdentity(env.To)
+	if err := d.Validate(to); err != nil {
+		result.Refused = append(result.Refused, file.ID)
+		return f.sendAck(file, peer, fmt.Sprintf("unknown recipient %s in town %s: %v", env.To, f.cfg.Town, err))
+	}
+	// Check every attachment before storing any, so a refused envelope
+	// leaves nothing in the blob store.
+	for _, att := range env.Message.Attachments {
+		if refusal := checkEnvelopeBlob(env.Blobs, att); refusal != "" {
+			result.Refused = append(result.Refused, file.ID)
+			return f.sendAck(file, peer, refusal)
+		}
+	}
+	for _, att := range env.Message.Attachments {
+		if _, err := StoreAttachment(f.townRoot, att.Name, bytes.NewReader(env.Blobs[att.Digest])); err != nil {
+			return fmt.Errorf("storing attachment %q: %w", att.Name, err)
+		}
+	}
+
+	msg := *env.Message
+	msg.ID = ""
+	msg.To = to
+	// The sender is whoever signed the envelope, never a local identity.
+	msg.From = RemoteAddress(file.From, msg.From)
+	msg.Queue, msg.Channel, msg.ClaimedBy, msg.ClaimedAt = "", "", "", nil
+	if err := d.Deliver(&msg); err != nil {
+		return fmt.Errorf("delivering to %s: %w", to, err)
+	}
+	if err := util.EnsureDirAndWriteJSON(marker, map[string]string{"local_id": msg.ID}); err != nil {
+		return fmt.Errorf("recording delivery: %w", err)
+	}
+	result.Received = append(result.Received, &msg)
+	return f.sendAck(file, peer, "")
+}
+
+// checkEnvelopeBlob returns why an incoming attachment's content is
+// unacceptable (missing, too large or not matching its digest), or "".
+func checkEnvelopeBlob(blobs map[string][]byte, att Attachment) string {
+	data, ok := blobs[att.Digest]
+	if !ok {
+		return fmt.Sprintf("attachment %q has no content", att.Name)
+	}
+	if int64(len(data)) > MaxAttachmentSize {
+		return fmt.Sprintf("attachment %q is larger than %d bytes", att.Name, MaxAttachmentSize)
+	}
+	sum := sha256.Sum256(data)
+	if digestPrefix+hex.EncodeToString(sum[:]) != att.Digest {
+		return fmt.Sprintf("attachment %q does not match its digest", att.Name)
+	}
+	return ""
+}
+
+func (f *Federation) sendAck(file *spoolFile, peer *FederationPeer, refusal string) error {
+	body, err := json.Marshal(federationAck{ReceivedAt: timeNow(), Error: refusal})
+	if err != nil {
+		return err
+	}
+	ack := spoolFile{Kind: spoolKindAck, From: f.cfg.Town, To: file.From, ID: file.ID, Body: body}
+	ack.sign(peer.Secret)
+	return writeSpoolFile(peer.Spool, &ack)
+}
+
+// acknowledge clears the outbox entry an ack refers to, bouncing refusals.
+func (f *Federation) acknowledge(file *spoolFile, d FederationDelivery, result *FederationSyncResult) error {
+	var ack federationAck
+	if err := json.Unmarshal(file.Body, &ack); err != nil {
+		return fmt.Errorf("malformed ack: %w", err)
+	}
+	path := f.outboxPath(file.From, file.ID)
+	entry, err := loadOutboxEntry(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // Duplicate ack for a message already settled.
+	}
+	if err != nil {
+		return err
+	}
+	if ack.Error != "" {
+		if err := f.bounce(entry, ack.Error, d); err != nil {
+			return err
+		}
+		result.Bounced = append(result.Bounced, entry.ID)
+	} else {
+		result.Acked = append(result.Acked, entry.ID)
+	}
+	return os.Remove(path)
+}
+
+func loadOutboxEntry(path string) (*OutboxEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry OutboxEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("parsing outbox entry %s: %w", path, err)
+	}
+	return &entry, nil
+}
+
+// bounce tells the local sender that a message could not be delivered.
+func (f *Federation) bounce(entry *OutboxEntry, reason string, d FederationDelivery) error {
+	msg := NewMessage(RemoteAddress(entry.Peer, federationPostmaster), entry.From,
+		"Undeliverable: "+entry.Subject,
+		fmt.Sprintf("Your message %s to %s could not be delivered.\n\nReason: %s",
+			entry.ID, RemoteAddress(entry.Peer, entry.To), reason))
+	msg.Priority = PriorityHigh
+	msg.ReplyTo = entry.ID
+	msg.ID = ""
+	if err := d.Deliver(msg); err != nil {
+		return fmt.Errorf("bouncing %s: %w", entry.ID, err)
+	}
+	return nil
+}
+
+// retry re-pushes envelopes that left the peer's spool without an ack and
+// bounces envelopes that have waited longer than FederationMaxAge.
+func (f *Federation) retry(d FederationDelivery, result *FederationSyncResult) {
+	entries, err := f.Outbox()
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+	now := timeNow()
+	for _, entry := range entries {
+		if now.Sub(entry.Created) > FederationMaxAge {
+			reason := fmt.Sprintf("not acknowledged by %s after %s", entry.Peer, FederationMaxAge)
+			if err := f.bounce(entry, reason, d); err != nil {
+				result.Errors = append(result.Errors, err.Error())
+				result.Pending++
+				continue
+			}
+			_ = os.Remove(f.outboxPath(entry.Peer, entry.ID))
+			result.Bounced = append(result.Bounced, entry.ID)
+			continue
+		}
+
+		result.Pending++
+		peer, ok := f.cfg.Peers[entry.Peer]
+		if !ok || now.Sub(entry.LastAttempt) < FederationRetryInterval {
+			continue
+		}
+		// Still waiting in the peer's spool: nothing to do until it syncs.
+		if entry.LastError == "" {
+			if _, err := os.Stat(filepath.Join(peer.Spool, entry.File.name())); err == nil {
+				continue
+			}
+		}
+		f.push(entry, peer, now)
+		if err := f.saveOutbox(entry); err != nil {
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if entry.LastError != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", entry.ID, entry.LastError))
+			continue
+		}
+		result.Retried = append(result.Retried, entry.ID)
+	}
+}
+
+// sendToRemote queues msg for identity in a peer town.
+func (r *Router) sendToRemote(msg *Message, town, identity string) error {
+	if r.townRoot == "" {
+		return fmt.Errorf("%w: %s (no town root)", ErrUnknownPeer, town)
+	}
+	fed, err := LoadFederation(r.townRoot)
+	if err != nil {
+		return err
+	}
+	if msg.ID == "" {
+		msg.ID = generateID()
+	}
+	if err := msg.Validate(); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+	if _, err := fed.Enqueue(town, AddressToIdentity(identity), msg); err != nil {
+		return fmt.Errorf("sending to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// SyncFederation exchanges mail with peer towns (see Federation.Sync),
+// delivering incoming messages through this router.
+func (r *Router) SyncFederation() (*FederationSyncResult, error) {
+	if r.townRoot == "" {
+		return nil, fmt.Errorf("federation sync: no town root")
+	}
+	fed, err := LoadFederation(r.townRoot)
+	if err != nil {
+		return nil, err
+	}
+	return fed.Sync(FederationDelivery{
+		Validate: r.validateRecipient,
+		Deliver:  r.sendToSingle,
+	})
+}
@@ -0,0 +1,286 @@
+package mail
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTown records deliveries for a federation test town.
+type fakeTown struct {
+	fed       *Federation
+	delivered []*Message
+	known     map[string]bool
+	failNext  error
+}
+
+func (t *fakeTown) delivery() FederationDelivery {
+	return FederationDelivery{
+		Validate: func(identity string) error {
+			if !t.known[identity] {
+				return errors.New("no agent found")
+			}
+			return nil
+		},
+		Deliver: func(msg *Message) error {
+			if t.failNext != nil {
+				err := t.failNext
+				t.failNext = nil
+				return err
+			}
+			msg.ID = "hq-local-" + msg.Subject
+			t.delivered = append(t.delivered, msg)
+			return nil
+		},
+	}
+}
+
+// setupFederatedTowns creates towns "alpha" and "beta" peered with each other.
+func setupFederatedTowns(t *testing.T) (*fakeTown, *fakeTown) {
+	t.Helper()
+	secret, err := GenerateFederationSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alphaRoot, betaRoot := t.TempDir(), t.TempDir()
+	alphaSpool := filepath.Join(alphaRoot, "mail", "federation", "spool")
+	betaSpool := filepath.Join(betaRoot, "mail", "federation", "spool")
+	for _, dir := range []string{alphaSpool, betaSpool} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configs := map[string]*FederationConfig{
+		alphaRoot: {Town: "alpha", Peers: map[string]*FederationPeer{"beta": {Spool: betaSpool, Secret: secret}}},
+		betaRoot:  {Town: "beta", Peers: map[string]*FederationPeer{"alpha": {Spool: alphaSpool, Secret: secret}}},
+	}
+	var towns []*fakeTown
+	for _, root := range []string{alphaRoot, betaRoot} {
+		if err := SaveFederationConfig(FederationConfigPath(root), configs[root]); err != nil {
+			t.Fatal(err)
+		}
+		fed, err := LoadFederation(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		towns = append(towns, &fakeTown{fed: fed, known: map[string]bool{"mayor/": true}})
+	}
+	return towns[0], towns[1]
+}
+
+func TestFederationDeliverAndAck(t *testing.T) {
+	alpha, beta := setupFederatedTowns(t)
+
+	msg := NewMessage("mayor/", "@beta/mayor/", "Coordinate", "Let's sync convoys.")
+	if _, err := alpha.fed.Enqueue("beta", "mayor/", msg); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if pending, _ := alpha.fed.Outbox(); len(pending) != 1 || pending[0].LastError != "" {
+		t.Fatalf("outbox = %+v, want one pushed entry", pending)
+	}
+
+	res, err := beta.fed.Sync(beta.delivery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Received) != 1 || len(beta.delivered) != 1 {
+		t.Fatalf("beta received %d messages, want 1 (%+v)", len(beta.delivered), res)
+	}
+	got := beta.delivered[0]
+	if got.From != "@alpha/mayor/" || got.To != "mayor/" || got.Subject != "Coordinate" || got.ThreadID != msg.ThreadID {
+		t.Errorf("delivered = %+v", got)
+	}
+
+	res, err = alpha.fed.Sync(alpha.delivery())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Acked) != 1 || res.Acked[0] != msg.ID || res.Pending != 0 {
+		t.Errorf("alpha sync = %+v, want ack for %s", res, msg.ID)
+	}
+	if pending, _ := alpha.fed.Outbox(); len(pending) != 0 {
+		t.Errorf("outbox still has %d entries after ack", len(pending))
+	}
+}
+
+func TestFederationRetryIsIdempotent(t *testing.T) {
+	alpha, beta := setupFederatedTowns(t)
+
+	msg := NewMessage("mayor/", "@beta/mayor/", "Once", "only once")
+	entry, err := alpha.fed.Enqueue("beta", "mayor/", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first delivery attempt fails: the envelope stays in the spool.
+	beta.failNext = errors.New("bd unavailable")
+	res, _ := beta.fed.Sync(beta.delivery())
+	if len(res.Errors) != 1 || len(beta.delivered) != 0 {
+		t.Fatalf("sync with failing delivery = %+v", res)
+	}
+	if _, err := beta.fed.Sync(beta.delivery()); err != nil || len(beta.delivered) != 1 {
+		t.Fatalf("delivered %d, want 1 after retry", len(beta.delivered))
+	}
+
+	// The ack is lost and alpha pushes the envelope again.
+	os.Remove(filepath.Join(alpha.fed.SpoolDir(), "ack-beta-"+msg.ID+".json"))
+	alpha.fed.push(entry, alpha.fed.cfg.Peers["beta"], time.Now())
+	res, _ = beta.fed.Sync(beta.delivery())
+	if len(beta.delivered) != 1 || len(res.Duplicates) != 1 {
+		t.Errorf("redelivered: delivered=%d duplicates=%v", len(beta.delivered), res.Duplicates)
+	}
+	if res, _ := alpha.fed.Sync(alpha.delivery()); len(res.Acked) != 1 {
+		t.Errorf("alpha did not get the re-ack: %+v", res)
+	}
+}
+
+func TestFederationRefusedMessageBounces(t *testing.T) {
+	alpha, beta := setupFederatedTowns(t)
+
+	msg := NewMessage("mayor/", "@beta/nobody", "Hello?", "anyone")
+	if _, err := alpha.fed.Enqueue("beta", "nobody", msg); err != nil {
+		t.Fatal(err)
+	}
+	res, _ := beta.fed.Sync(beta.delivery())
+	if len(res.Refused) != 1 || len(beta.delivered) != 0 {
+		t.Fatalf("beta sync = %+v, want refusal", res)
+	}
+
+	res, _ = alpha.fed.Sync(alpha.delivery())
+	if len(res.Bounced) != 1 || len(alpha.delivered) != 1 {
+		t.Fatalf("alpha sync = %+v, want bounce", res)
+	}
+	bounce := alpha.delivered[0]
+	if bounce.To != "mayor/" || bounce.From != "@beta/postmaster" || !strings.HasPrefix(bounce.Subject, "Undeliverable: Hello?") {
+		t.Errorf("bounce = %+v", bounce)
+	}
+}
+
+func TestFederationRejectsBadSignature(t *testing.T) {
+	alpha, beta := setupFederatedTowns(t)
+
+	msg := NewMessage("mayor/", "@beta/mayor/", "Forged", "x")
+	if _, err := alpha.fed.Enqueue("beta", "mayor/", msg); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(beta.fed.SpoolDir(), "msg-alpha-"+msg.ID+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := strings.Replace(string(data), "Forged", "Tampered", 1)
+	if err := os.WriteFile(path, []byte(tampered), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	res, _ := beta.fed.Sync(beta.delivery())
+	if len(beta.delivered) != 0 || len(res.Errors) != 1 || !strings.Contains(res.Errors[0], "bad signature") {
+		t.Errorf("tampered envelope: delivered=%d result=%+v", len(beta.delivered), res)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("rejected envelope left in the spool")
+	}
+}
+
+func TestFederationRefusesMismatchedAttachment(t *testing.T) {
+	alpha, beta := setupFederatedTowns(t)
+
+	att, err := StoreAttachment(alpha.fed.townRoot, "notes.txt", strings.NewReader("original"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := NewMessage("mayor/", "@beta/mayor/", "Swapped", "x")
+	msg.Attachments = []Attachment{att}
+	if _, err := alpha.fed.Enqueue("beta", "mayor/", msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-sign the envelope with different content under the same digest, as
+	// a peer holding the secret could.
+	path := filepath.Join(beta.fed.SpoolDir(), "msg-alpha-"+msg.ID+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file spoolFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatal(err)
+	}
+	var env federationEnvelope
+	if err := json.Unmarshal(file.Body, &env); err != nil {
+		t.Fatal(err)
+	}
+	env.Blobs[att.Digest] = []byte("swapped")
+	if file.Body, err = json.Marshal(env); err != nil {
+		t.Fatal(err)
+	}
+	peer, err := beta.fed.peer("alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.sign(peer.Secret)
+	if err := writeSpoolFile(beta.fed.SpoolDir(), &file); err != nil {
+		t.Fatal(err)
+	}
+
+	res, _ := beta.fed.Sync(beta.delivery())
+	if len(res.Refused) != 1 || len(beta.delivered) != 0 {
+		t.Fatalf("mismatched attachment: delivered=%d result=%+v", len(beta.delivered), res)
+	}
+	if _, err := os.Stat(BlobDir(beta.fed.townRoot)); !os.IsNotExist(err) {
+		t.Error("refused attachment was written to the blob store")
+	}
+}
+
+func TestFederationUnknownPeer(t *testing.T) {
+	alpha, _ := setupFederatedTowns(t)
+	msg := NewMessage("mayor/", "@gamma/mayor/", "Hi", "x")
+	if _, err := alpha.fed.Enqueue("gamma", "mayor/", msg); !errors.Is(err, ErrUnknownPeer) {
+		t.Errorf("Enqueue to unknown peer: err = %v, want ErrUnknownPeer", err)
+	}
+}
+
+func TestParseRemoteAddress(t *testing.T) {
+	tests := []struct {
+		addr     string
+		town     string
+		identity string
+		ok       bool
+	}{
+		{"@othertown/mayor/", "othertown", "mayor/", true},
+		{"@beta/gastown/witness", "beta", "gastown/witness", true},
+		{"@town", "", "", false},
+		{"@beta/", "", "", false},
+		{"mayor/", "", "", false},
+	}
+	for _, tt := range tests {
+		town, identity, ok := ParseRemoteAddress(tt.addr)
+		if town != tt.town || identity != tt.identity || ok != tt.ok {
+			t.Errorf("ParseRemoteAddress(%q) = %q, %q, %v", tt.addr, town, identity, ok)
+		}
+	}
+}
+
+func TestFederationConfigValidate(t *testing.T) {
+	secret, _ := GenerateFederationSecret()
+	bad := []*FederationConfig{
+		{Town: "rig"},
+		{Town: "has space"},
+		{Town: "alpha", Peers: map[string]*FederationPeer{"alpha": {Spool: "/s", Secret: secret}}},
+		{Town: "alpha", Peers: map[string]*FederationPeer{"beta": {Spool: "", Secret: secret}}},
+		{Town: "alpha", Peers: map[string]*FederationPeer{"beta": {Spool: "/s", Secret: "short"}}},
+	}
+	for i, cfg := range bad {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("config %d: Validate() = nil, want error", i)
+		}
+	}
+	good := &FederationConfig{Town: "alpha", Peers: map[string]*FederationPeer{"beta": {Spool: "/s", Secret: secret}}}
+	if err := good.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
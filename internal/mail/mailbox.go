@@ -97,12 +97,20 @@ func (m *Mailbox) lockLegacy() (*flock.Flock, error) {
 	return fl, nil
 }
 
-// List returns all open messages in the mailbox.
+// List returns all open messages in the mailbox. Scheduled messages that are
+// not yet due and expired messages are left out.
 func (m *Mailbox) List() ([]*Message, error) {
+	var messages []*Message
+	var err error
 	if m.legacy {
-		return m.listLegacy()
+		messages, err = m.listLegacy()
+	} else {
+		messages, err = m.listBeads()
+	}
+	if err != nil {
+		return nil, err
 	}
-	return m.listBeads()
+	return visibleMessages(messages, timeNow()), nil
 }
 
 func (m *Mailbox) listBeads() ([]*Message, error) {
@@ -338,7 +346,7 @@ func (m *Mailbox) getFromDir(id, beadsDir string) (*Message, error) {
 }
 
 func (m *Mailbox) getLegacy(id string) (*Message, error) {
-	messages, err := m.List()
+	messages, err := m.listLegacy()
 	if err != nil {
 		return nil, err
 	}
@@ -359,10 +367,28 @@ func (m *Mailbox) MarkRead(id string) error {
 }
 
 func (m *Mailbox) markReadBeads(id string) error {
+	m.recordReadReceipt(id)
 	// Single DB - wisps and persistent messages in same store
 	return m.closeInDir(id, m.beadsDir)
 }
 
+// recordReadReceipt writes read-by/read-at labels so senders waiting on a
+// receipt see the message was read. Best-effort: a missing receipt must not
+// stop the read itself.
+func (m *Mailbox) recordReadReceipt(id string) {
+	if m.identity == "" {
+		return
+	}
+	for _, label := range ReadReceiptLabels(m.identity, timeNow()) {
+		ctx, cancel := bdWriteCtx()
+		_, err := runBdCommand(ctx, []string{"label", "add", id, label}, m.workDir, m.beadsDir)
+		cancel()
+		if err != nil {
+			return
+		}
+	}
+}
+
 // closeInDir closes a message in a specific beads directory.
 func (m *Mailbox) closeInDir(id, beadsDir string) error {
 	args := []string{"close", id}
@@ -391,7 +417,7 @@ func (m *Mailbox) markReadLegacy(id string) error {
 	}
 	defer func() { _ = fl.Unlock() }()
 
-	messages, err := m.List()
+	messages, err := m.listLegacy()
 	if err != nil {
 		return err
 	}
@@ -436,6 +462,7 @@ func (m *Mailbox) markReadOnlyBeads(id string) error {
 		return err
 	}
 
+	m.recordReadReceipt(id)
 	return nil
 }
 
@@ -501,7 +528,7 @@ func (m *Mailbox) markUnreadLegacy(id string) error {
 	}
 	defer func() { _ = fl.Unlock() }()
 
-	messages, err := m.List()
+	messages, err := m.listLegacy()
 	if err != nil {
 		return err
 	}
@@ -536,7 +563,7 @@ func (m *Mailbox) deleteLegacy(id string) error {
 	}
 	defer func() { _ = fl.Unlock() }()
 
-	messages, err := m.List()
+	messages, err := m.listLegacy()
 	if err != nil {
 		return err
 	}
@@ -558,6 +585,59 @@ func (m *Mailbox) deleteLegacy(id string) error {
 	return m.rewriteLegacy(filtered)
 }
 
+// SetPinned pins or unpins a message. Pinned messages never expire.
+// For beads mode, this adds or removes the "pinned" label.
+// For legacy mode, this sets the Pinned field.
+func (m *Mailbox) SetPinned(id string, pinned bool) error {
+	if m.legacy {
+		return m.setPinnedLegacy(id, pinned)
+	}
+
+	op := "add"
+	if !pinned {
+		op = "remove"
+	}
+	ctx, cancel := bdWriteCtx()
+	defer cancel()
+	_, err := runBdCommand(ctx, []string{"label", op, id, LabelPinned}, m.workDir, m.beadsDir)
+	if err != nil {
+		if bdErr, ok := err.(*bdError); ok && bdErr.ContainsError("not found") {
+			return ErrMessageNotFound
+		}
+		if bdErr, ok := err.(*bdError); ok && !pinned && bdErr.ContainsError("does not have label") {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (m *Mailbox) setPinnedLegacy(id string, pinned bool) error {
+	fl, err := m.lockLegacy()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	messages, err := m.listLegacy()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, msg := range messages {
+		if msg.ID == id {
+			msg.Pinned = pinned
+			found = true
+		}
+	}
+	if !found {
+		return ErrMessageNotFound
+	}
+
+	return m.rewriteLegacy(messages)
+}
+
 // Archive moves a message to the archive file and removes it from inbox.
 func (m *Mailbox) Archive(id string) error {
 	if m.legacy {
@@ -1039,7 +1119,7 @@ func (m *Mailbox) listByThreadBeads(threadID string) ([]*Message, error) {
 }
 
 func (m *Mailbox) listByThreadLegacy(threadID string) ([]*Message, error) {
-	messages, err := m.List()
+	messages, err := m.listLegacy()
 	if err != nil {
 		return nil, err
 	}
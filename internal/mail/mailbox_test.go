@@ -611,6 +611,34 @@ func TestMailboxLegacyArchiveCorruptionDetection(t *testing.T) {
 	}
 }
 
+func TestMailboxLegacySetPinned(t *testing.T) {
+	tmpDir := t.TempDir()
+	m := NewMailbox(tmpDir)
+
+	if err := m.Append(&Message{ID: "msg-001", From: "mayor/", To: "overseer", Subject: "Pin me", Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Append error: %v", err)
+	}
+	if err := m.SetPinned("msg-001", true); err != nil {
+		t.Fatalf("SetPinned error: %v", err)
+	}
+	msg, err := m.Get("msg-001")
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if !msg.Pinned {
+		t.Error("message should be pinned")
+	}
+	if err := m.SetPinned("msg-001", false); err != nil {
+		t.Fatalf("SetPinned error: %v", err)
+	}
+	if msg, _ := m.Get("msg-001"); msg.Pinned {
+		t.Error("message should be unpinned")
+	}
+	if err := m.SetPinned("missing", true); err != ErrMessageNotFound {
+		t.Errorf("SetPinned(missing) = %v, want ErrMessageNotFound", err)
+	}
+}
+
 func TestMailboxLegacyConcurrentMarkRead(t *testing.T) {
 	tmpDir := t.TempDir()
 	m := NewMailbox(tmpDir)
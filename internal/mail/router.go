@@ -12,6 +12,7 @@ import (
 	"github.com/steveyegge/gastown/internal/beads"
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/nudge"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
 	"github.com/steveyegge/gastown/internal/workspace"
@@ -69,6 +70,11 @@ func NewRouterWithTownRoot(workDir, townRoot string) *Router {
 	}
 }
 
+// TownRoot returns the town root the router delivers into ("" if unknown).
+func (r *Router) TownRoot() string {
+	return r.townRoot
+}
+
 // WaitPendingNotifications blocks until all in-flight async notifications
 // have completed. CLI commands should call this before exiting to avoid
 // losing notifications that are still being delivered.
@@ -812,7 +818,14 @@ func (r *Router) shouldBeWisp(msg *Message) bool {
 // Supports single-copy delivery for:
 // - Queues (queue:name) - stores single message for worker claiming
 // - Announces (announce:name) - bulletin board, no claiming, retention-limited
+// - Remote towns (@town/identity) - queued for a federation peer
 func (r *Router) Send(msg *Message) error {
+	// Rig secret values never leave a session in mail.
+	if red := secrets.ForTown(r.townRoot); !red.Empty() {
+		msg.Subject = red.Redact(msg.Subject)
+		msg.Body = red.Redact(msg.Body)
+	}
+
 	// Check for mailing list address
 	if isListAddress(msg.To) {
 		return r.sendToList(msg)
@@ -833,6 +846,11 @@ func (r *Router) Send(msg *Message) error {
 		return r.sendToChannel(msg)
 	}
 
+	// Check for @town/identity address in a peer town - federated delivery
+	if town, identity, ok := ParseRemoteAddress(msg.To); ok && parseGroupAddress(msg.To) == nil {
+		return r.sendToRemote(msg, town, identity)
+	}
+
 	// Check for @group address - resolve and fan-out
 	if isGroupAddress(msg.To) {
 		return r.sendToGroup(msg)
@@ -943,11 +961,27 @@ func (r *Router) sendToSingle(msg *Message) error {
 		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
 	}
 
+	// Apply the recipient's mail rules (messaging config "rules").
+	rules := r.applyRules(msg)
+	if rules.Priority != "" {
+		msg.Priority = rules.Priority
+	}
+	if rules.NudgeRule != "" && msg.To != "overseer" && !msg.IsScheduled(timeNow()) {
+		// Without a live session the nudge would be lost, so fall back to mail.
+		if delivered, err := r.nudgeRecipient(msg, ruleNudgeText(msg, rules.NudgeRule)); err == nil && delivered {
+			r.forwardCopies(msg, rules.Forward)
+			return nil
+		}
+	}
+
 	// Build labels for type, from/thread/reply-to/cc
 	var labels []string
 	labels = append(labels, "gt:message")
 	labels = append(labels, "from:"+msg.From)
 	labels = append(labels, DeliverySendLabels()...)
+	if msg.Pinned {
+		labels = append(labels, LabelPinned)
+	}
 	if msg.ThreadID != "" {
 		labels = append(labels, "thread:"+msg.ThreadID)
 	}
@@ -959,13 +993,22 @@ func (r *Router) sendToSingle(msg *Message) error {
 		ccIdentity := AddressToIdentity(cc)
 		labels = append(labels, "cc:"+ccIdentity)
 	}
+	labels = append(labels, ScheduleLabels(msg)...)
+	labels = append(labels, PayloadLabels(msg)...)
+	for _, name := range rules.Matched {
+		labels = append(labels, LabelRulePrefix+name)
+	}
+	labels = append(labels, rules.Labels...)
+	if msg.forwardedFrom != "" {
+		labels = append(labels, LabelForwardedFromPrefix+msg.forwardedFrom)
+	}
 
 	// Build command: bd create --assignee=<recipient> -d <body> --labels=gt:message,... -- <subject>
 	// Flags go first, then -- to end flag parsing, then the positional subject.
 	// This prevents subjects like "--help" from being parsed as flags (see web/api.go).
-	args := []string{"create",
+	args := []string{"create", "--json",
 		"--assignee", toIdentity,
-		"-d", msg.Body,
+		"-d", EncodeDescription(msg),
 	}
 
 	// Add priority flag
@@ -995,29 +1038,53 @@ func (r *Router) sendToSingle(msg *Message) error {
 	}
 	ctx, cancel := bdWriteCtx()
 	defer cancel()
-	_, err := runBdCommand(ctx, args, filepath.Dir(beadsDir), beadsDir)
+	stdout, err := runBdCommand(ctx, args, filepath.Dir(beadsDir), beadsDir)
 	if err != nil {
 		return fmt.Errorf("sending message: %w", err)
 	}
+	// Report the bead ID so callers can wait on receipts. Older bd versions
+	// print nothing useful; the generated ID is kept in that case.
+	var created struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(stdout, &created) == nil && created.ID != "" {
+		msg.ID = created.ID
+	}
+
+	r.forwardCopies(msg, rules.Forward)
+	if rules.Archive {
+		// Archived by rule: the message is kept for the record but never
+		// reaches the inbox, so there is nothing to notify about.
+		if created.ID == "" {
+			return fmt.Errorf("archiving message by rule: bd create returned no ID")
+		}
+		return NewMailboxWithBeadsDir(msg.To, filepath.Dir(beadsDir), beadsDir).Archive(created.ID)
+	}
 
 	// Notify recipient if they have an active session (best-effort notification).
 	// Skip when the caller explicitly suppressed notification (--no-notify)
 	// or for self-mail (handoffs to future-self don't need present-self notified).
-	// Notification is async: the durable write is complete, so the caller
-	// doesn't block on idle probing (up to 1s per recipient in fan-out).
+	// Scheduled messages are notified by Sweep once they are due.
 	// Callers that exit soon after Send should call WaitPendingNotifications.
-	if !msg.SuppressNotify && !isSelfMail(msg.From, msg.To) {
-		msgCopy := *msg // copy to avoid data race if caller mutates msg
-		r.notifyWg.Add(1)
-		go func() {
-			defer r.notifyWg.Done()
-			r.notifyRecipient(&msgCopy) //nolint:errcheck
-		}()
+	if !msg.SuppressNotify && !isSelfMail(msg.From, msg.To) && !msg.IsScheduled(timeNow()) {
+		r.notifyAsync(msg)
 	}
 
 	return nil
 }
 
+// notifyAsync notifies msg's recipient in the background. The durable write
+// is already complete, so the caller doesn't block on idle probing (up to 1s
+// per recipient in fan-out).
+func (r *Router) notifyAsync(msg *Message) {
+	msgCopy := *msg // copy to avoid data race if caller mutates msg
+	r.notifyWg.Add(1)
+	go func() {
+		defer r.notifyWg.Done()
+		r.notifyRecipient(&msgCopy) //nolint:errcheck
+	}()
+}
+
 // sendToList expands a mailing list and sends individual copies to each recipient.
 // Each recipient gets their own message copy with the same content.
 // Collects all delivery errors and reports partial failures.
@@ -1077,6 +1144,8 @@ func (r *Router) sendToQueue(msg *Message) error {
 	labels = append(labels, "from:"+msg.From)
 	labels = append(labels, "queue:"+queueName)
 	labels = append(labels, DeliverySendLabels()...)
+	labels = append(labels, ScheduleLabels(msg)...)
+	labels = append(labels, PayloadLabels(msg)...)
 	if msg.ThreadID != "" {
 		labels = append(labels, "thread:"+msg.ThreadID)
 	}
@@ -1094,7 +1163,7 @@ func (r *Router) sendToQueue(msg *Message) error {
 	// Use queue:<name> as assignee so inbox queries can filter by queue
 	args := []string{"create",
 		"--assignee", msg.To, // queue:name
-		"-d", msg.Body,
+		"-d", EncodeDescription(msg),
 	}
 
 	// Add priority flag
@@ -1161,6 +1230,7 @@ func (r *Router) sendToAnnounce(msg *Message) error {
 	labels = append(labels, "gt:message")
 	labels = append(labels, "from:"+msg.From)
 	labels = append(labels, "announce:"+announceName)
+	labels = append(labels, PayloadLabels(msg)...)
 	if msg.ThreadID != "" {
 		labels = append(labels, "thread:"+msg.ThreadID)
 	}
@@ -1178,7 +1248,7 @@ func (r *Router) sendToAnnounce(msg *Message) error {
 	// Use announce:<name> as assignee so queries can filter by channel
 	args := []string{"create",
 		"--assignee", msg.To, // announce:name
-		"-d", msg.Body,
+		"-d", EncodeDescription(msg),
 	}
 
 	// Add priority flag
@@ -1247,6 +1317,7 @@ func (r *Router) sendToChannel(msg *Message) error {
 	labels = append(labels, "gt:message")
 	labels = append(labels, "from:"+msg.From)
 	labels = append(labels, "channel:"+channelName)
+	labels = append(labels, PayloadLabels(msg)...)
 	if msg.ThreadID != "" {
 		labels = append(labels, "thread:"+msg.ThreadID)
 	}
@@ -1264,7 +1335,7 @@ func (r *Router) sendToChannel(msg *Message) error {
 	// Use channel:<name> as assignee so queries can filter by channel
 	args := []string{"create",
 		"--assignee", msg.To, // channel:name
-		"-d", msg.Body,
+		"-d", EncodeDescription(msg),
 	}
 
 	// Add priority flag
@@ -1409,16 +1480,25 @@ func (r *Router) GetMailbox(address string) (*Mailbox, error) {
 // Supports mayor/, deacon/, rig/crew/name, rig/polecats/name, and rig/name addresses.
 // Respects agent DND/muted state - skips notification if recipient has DND enabled.
 func (r *Router) notifyRecipient(msg *Message) error {
+	notification := fmt.Sprintf("📬 You have new mail from %s. Subject: %s. Run 'gt mail inbox' to read.", msg.From, msg.Subject)
+	_, err := r.nudgeRecipient(msg, notification)
+	return err
+}
+
+// nudgeRecipient delivers text to msg's recipient using the idle-aware
+// strategy described on notifyRecipient. delivered is false when the
+// recipient is muted or has no live session, so callers can fall back.
+func (r *Router) nudgeRecipient(msg *Message, notification string) (delivered bool, err error) {
 	// Check DND status before attempting notification
 	if r.townRoot != "" {
 		if r.isRecipientMuted(msg.To) {
-			return nil // Recipient has DND enabled, skip notification
+			return false, nil // Recipient has DND enabled, skip notification
 		}
 	}
 
 	sessionIDs := AddressToSessionIDs(msg.To)
 	if len(sessionIDs) == 0 {
-		return nil // Unable to determine session ID
+		return false, nil // Unable to determine session ID
 	}
 
 	timeout := r.IdleNotifyTimeout
@@ -1438,27 +1518,25 @@ func (r *Router) notifyRecipient(msg *Message) error {
 		// Overseer is a human operator - use a visible banner instead of NudgeSession
 		// (which types into Claude's input and would disrupt the human's terminal).
 		if msg.To == "overseer" {
-			return r.tmux.SendNotificationBanner(sessionID, msg.From, msg.Subject)
+			return true, r.tmux.SendNotificationBanner(sessionID, msg.From, msg.Subject)
 		}
 
-		notification := fmt.Sprintf("📬 You have new mail from %s. Subject: %s. Run 'gt mail inbox' to read.", msg.From, msg.Subject)
-
 		// Idle-aware notification: try immediate nudge first, fall back to queue.
 		waitErr := r.tmux.WaitForIdle(sessionID, timeout)
 		if waitErr == nil {
 			// Session is idle → send immediate nudge
 			if err := r.tmux.NudgeSession(sessionID, notification); err == nil {
-				return nil
+				return true, nil
 			} else if errors.Is(err, tmux.ErrSessionNotFound) {
 				// Session disappeared between idle check and nudge — try next candidate
 				continue
 			} else if errors.Is(err, tmux.ErrNoServer) {
-				return nil
+				return false, nil
 			}
 			// NudgeSession failed for non-terminal reason — fall through to queue
 		} else if errors.Is(waitErr, tmux.ErrNoServer) {
 			// No tmux server — no point trying other candidates
-			return nil
+			return false, nil
 		} else if errors.Is(waitErr, tmux.ErrSessionNotFound) {
 			// Session disappeared — try next candidate
 			continue
@@ -1467,16 +1545,18 @@ func (r *Router) notifyRecipient(msg *Message) error {
 		// Busy or nudge failed → enqueue for cooperative delivery at the
 		// agent's next turn boundary.
 		if r.townRoot != "" {
-			return nudge.Enqueue(r.townRoot, sessionID, nudge.QueuedNudge{
+			err := nudge.Enqueue(r.townRoot, sessionID, nudge.QueuedNudge{
 				Sender:  msg.From,
 				Message: notification,
 			})
+			return err == nil, err
 		}
 		// Fallback to direct nudge if town root unavailable
-		return r.tmux.NudgeSession(sessionID, notification)
+		err = r.tmux.NudgeSession(sessionID, notification)
+		return err == nil, err
 	}
 
-	return nil // No active session found
+	return false, nil // No active session found
 }
 
 // IsRecipientMuted checks if a mail recipient has DND/muted notifications enabled.
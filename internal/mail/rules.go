@@ -0,0 +1,205 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// LabelRulePrefix records each mail rule that matched a delivered message, so
+// the recipient can see why a message was filed or relabeled.
+const LabelRulePrefix = "mail-rule:"
+
+// LabelForwardedFromPrefix marks a copy delivered by a forward rule. Forwarded
+// copies are not run through rules again, which prevents forwarding loops.
+const LabelForwardedFromPrefix = "forwarded-from:"
+
+// RuleOutcome is the combined effect of the mail rules that matched a message.
+type RuleOutcome struct {
+	Matched   []string // Names of the matching rules, in evaluation order
+	Archive   bool     // Store the message archived, without notification
+	NudgeRule string   // Rule that converts the message to a nudge ("" = none)
+	Labels    []string // Extra labels to add
+	Forward   []string // Addresses that receive a copy
+	Priority  Priority // Escalated priority ("" = unchanged)
+}
+
+// EvaluateRules runs rules against msg in order and returns what they do.
+// Archive and nudge end processing, as does any rule with Stop set.
+func EvaluateRules(rules []config.MailRule, msg *Message) RuleOutcome {
+	var out RuleOutcome
+	for _, rule := range rules {
+		if !RuleMatches(rule.Match, msg) {
+			continue
+		}
+		out.Matched = append(out.Matched, rule.Name)
+
+		switch rule.Action {
+		case config.MailRuleArchive:
+			out.Archive = true
+			return out
+		case config.MailRuleNudge:
+			out.NudgeRule = rule.Name
+			return out
+		case config.MailRuleLabel:
+			out.Labels = append(out.Labels, rule.Label)
+		case config.MailRuleForward:
+			out.Forward = append(out.Forward, rule.ForwardTo)
+		case config.MailRuleEscalate:
+			target := PriorityHigh
+			if rule.Priority != "" {
+				target = ParsePriority(rule.Priority)
+			}
+			current := msg.Priority
+			if out.Priority != "" {
+				current = out.Priority
+			}
+			if PriorityToBeads(target) < PriorityToBeads(current) {
+				out.Priority = target
+			}
+		}
+		if rule.Stop {
+			return out
+		}
+	}
+	return out
+}
+
+// RuleMatches reports whether every condition set in match holds for msg.
+func RuleMatches(match config.MailRuleMatch, msg *Message) bool {
+	if match.IsEmpty() {
+		return false
+	}
+	if match.From != "" && !globMatch(match.From, msg.From) && !globMatch(match.From, AddressToIdentity(msg.From)) {
+		return false
+	}
+	if match.Subject != "" && !globMatch(strings.ToLower(match.Subject), strings.ToLower(msg.Subject)) {
+		return false
+	}
+	if match.Type != "" && MessageType(match.Type) != msg.Type {
+		return false
+	}
+	if match.Priority != "" && Priority(match.Priority) != msg.Priority {
+		return false
+	}
+	if match.Thread != "" && match.Thread != msg.ThreadID {
+		return false
+	}
+	return true
+}
+
+// RulesFor returns the mail rules for a recipient address from the town's
+// messaging config. Rules for "*" come first, then pattern keys (sorted),
+// then the exact mailbox; rules under one key keep their order. A missing
+// config means no rules.
+func (r *Router) RulesFor(address string) ([]config.MailRule, error) {
+	if r.townRoot == "" {
+		return nil, nil
+	}
+	cfg, err := config.LoadOrCreateMessagingConfig(config.MessagingConfigPath(r.townRoot))
+	if err != nil {
+		return nil, fmt.Errorf("loading messaging config: %w", err)
+	}
+	return rulesForAddress(cfg.Rules, address), nil
+}
+
+// rulesForAddress selects the rule lists whose key matches address, either as
+// sent or in canonical form.
+func rulesForAddress(all map[string][]config.MailRule, address string) []config.MailRule {
+	if len(all) == 0 {
+		return nil
+	}
+	canonical := identityToAddress(AddressToIdentity(address))
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var wildcard, patterns, exact []config.MailRule
+	for _, key := range keys {
+		rules := all[key]
+		switch {
+		case key == "*":
+			wildcard = append(wildcard, rules...)
+		case strings.Contains(key, "*"):
+			if matchPattern(strings.TrimSuffix(key, "/"), strings.TrimSuffix(address, "/")) ||
+				matchPattern(strings.TrimSuffix(key, "/"), strings.TrimSuffix(canonical, "/")) {
+				patterns = append(patterns, rules...)
+			}
+		case identityToAddress(AddressToIdentity(key)) == canonical:
+			exact = append(exact, rules...)
+		}
+	}
+	out := append(wildcard, patterns...)
+	return append(out, exact...)
+}
+
+// applyRules evaluates the recipient's rules for msg. Rule loading failures
+// are reported and ignored: a bad config must not stop mail delivery.
+func (r *Router) applyRules(msg *Message) RuleOutcome {
+	if msg.forwardedFrom != "" {
+		return RuleOutcome{}
+	}
+	rules, err := r.RulesFor(msg.To)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mail rules: %v (delivering without rules)\n", err)
+		return RuleOutcome{}
+	}
+	return EvaluateRules(rules, msg)
+}
+
+// forwardCopies delivers a copy of msg to each forward address. Failures are
+// reported but do not fail the original delivery.
+func (r *Router) forwardCopies(msg *Message, addresses []string) {
+	for _, addr := range addresses {
+		fwd := *msg
+		fwd.ID = ""
+		fwd.To = addr
+		fwd.CC = nil
+		fwd.forwardedFrom = AddressToIdentity(msg.To)
+		if err := r.Send(&fwd); err != nil {
+			fmt.Fprintf(os.Stderr, "mail rules: forwarding %q to %s: %v\n", msg.Subject, addr, err)
+		}
+	}
+}
+
+// ruleNudgeText is the nudge sent in place of a message converted by a rule.
+func ruleNudgeText(msg *Message, rule string) string {
+	text := fmt.Sprintf("📨 Mail from %s (as nudge, rule %q): %s", msg.From, rule, msg.Subject)
+	if body := strings.TrimSpace(msg.Body); body != "" {
+		const maxBody = 200
+		if r := []rune(body); len(r) > maxBody {
+			body = string(r[:maxBody-1]) + "…"
+		}
+		text += " — " + strings.Join(strings.Fields(body), " ")
+	}
+	return text
+}
+
+// globMatch matches s against pattern, where '*' matches any run of
+// characters (including '/').
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == '*' {
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if s == "" || s[0] != pattern[0] {
+			return false
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
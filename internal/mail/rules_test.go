@@ -0,0 +1,117 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"convoy progress*", "convoy progress: 3/5", true},
+		{"*/refinery", "gastown/refinery", true},
+		{"*/refinery", "gastown/witness", false},
+		{"*patrol*", "deacon patrol receipt", true},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+		{"*", "", true},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateRules(t *testing.T) {
+	rules := []config.MailRule{
+		{Name: "ci", Match: config.MailRuleMatch{From: "*/refinery"}, Action: config.MailRuleLabel, Label: "ci"},
+		{Name: "copy", Match: config.MailRuleMatch{From: "*/refinery"}, Action: config.MailRuleForward, ForwardTo: "overseer"},
+		{Name: "urgent-tasks", Match: config.MailRuleMatch{Type: "task"}, Action: config.MailRuleEscalate, Priority: "urgent"},
+		{Name: "quiet", Match: config.MailRuleMatch{Subject: "MERGED*"}, Action: config.MailRuleArchive},
+		{Name: "never", Match: config.MailRuleMatch{Subject: "MERGED*"}, Action: config.MailRuleLabel, Label: "unreachable"},
+	}
+
+	msg := &Message{From: "gastown/refinery", Subject: "Merged gt-abc", Type: TypeNotification, Priority: PriorityNormal}
+	got := EvaluateRules(rules, msg)
+	want := RuleOutcome{
+		Matched: []string{"ci", "copy", "quiet"},
+		Archive: true,
+		Labels:  []string{"ci"},
+		Forward: []string{"overseer"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("EvaluateRules = %+v, want %+v", got, want)
+	}
+
+	task := &Message{From: "mayor/", Subject: "Fix it", Type: TypeTask, Priority: PriorityHigh}
+	if got := EvaluateRules(rules, task); got.Priority != PriorityUrgent || got.Archive {
+		t.Errorf("task outcome = %+v, want escalation to urgent", got)
+	}
+
+	// Escalation never lowers priority.
+	urgent := &Message{From: "mayor/", Subject: "x", Type: TypeTask, Priority: PriorityUrgent}
+	lower := []config.MailRule{{Name: "high", Match: config.MailRuleMatch{Type: "task"}, Action: config.MailRuleEscalate}}
+	if got := EvaluateRules(lower, urgent); got.Priority != "" {
+		t.Errorf("escalate lowered priority to %q", got.Priority)
+	}
+
+	// Stop ends processing.
+	stop := []config.MailRule{
+		{Name: "a", Match: config.MailRuleMatch{Type: "task"}, Action: config.MailRuleLabel, Label: "a", Stop: true},
+		{Name: "b", Match: config.MailRuleMatch{Type: "task"}, Action: config.MailRuleLabel, Label: "b"},
+	}
+	if got := EvaluateRules(stop, task); !reflect.DeepEqual(got.Labels, []string{"a"}) {
+		t.Errorf("labels = %v, want only a", got.Labels)
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	town := t.TempDir()
+	cfg := `{"type":"messaging","version":1,"rules":{
+		"mayor/": [{"name":"exact","match":{"type":"task"},"action":"label","label":"x"}],
+		"*": [{"name":"everyone","match":{"type":"task"},"action":"label","label":"y"}],
+		"gastown/*": [{"name":"rig","match":{"type":"task"},"action":"label","label":"z"}]
+	}}`
+	if err := os.MkdirAll(filepath.Join(town, "config"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(config.MessagingConfigPath(town), []byte(cfg), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouterWithTownRoot(town, town)
+
+	names := func(address string) []string {
+		rules, err := r.RulesFor(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, rule := range rules {
+			out = append(out, rule.Name)
+		}
+		return out
+	}
+	if got := names("mayor"); !reflect.DeepEqual(got, []string{"everyone", "exact"}) {
+		t.Errorf("mayor rules = %v", got)
+	}
+	if got := names("gastown/witness"); !reflect.DeepEqual(got, []string{"everyone", "rig"}) {
+		t.Errorf("witness rules = %v", got)
+	}
+	if got := names("beads/witness"); !reflect.DeepEqual(got, []string{"everyone"}) {
+		t.Errorf("beads witness rules = %v", got)
+	}
+}
+
+func TestRuleNudgeText(t *testing.T) {
+	msg := &Message{From: "deacon/", Subject: "Patrol done", Body: "all\n  clear"}
+	if got := ruleNudgeText(msg, "pings"); got != `📨 Mail from deacon/ (as nudge, rule "pings"): Patrol done — all clear` {
+		t.Errorf("ruleNudgeText = %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Label keys for scheduled delivery, expiry and read receipts. Like the
+// delivery labels, they are append-only so concurrent writers never race on
+// a rewrite.
+const (
+	LabelNotBeforePrefix = "not-before:"
+	LabelExpiresAtPrefix = "expires-at:"
+	LabelReadByPrefix    = "read-by:"
+	LabelReadAtPrefix    = "read-at:"
+
+	// LabelReleased marks a scheduled message whose recipient has been
+	// notified by Router.Sweep, so later sweeps do not notify again.
+	LabelReleased = "schedule:released"
+)
+
+// Receipt kinds accepted by Router.WaitForReceipt.
+const (
+	// ReceiptAck is satisfied by a delivery ack or a read receipt.
+	ReceiptAck = "ack"
+	// ReceiptRead is satisfied only by a read receipt.
+	ReceiptRead = "read"
+)
+
+// ErrReceiptTimeout is returned by WaitForReceipt when no receipt arrives in time.
+var ErrReceiptTimeout = errors.New("timed out waiting for receipt")
+
+// receiptPollInterval is how often WaitForReceipt re-reads the message.
+var receiptPollInterval = 2 * time.Second
+
+// ScheduleLabels returns the not-before and expires-at labels for msg.
+func ScheduleLabels(msg *Message) []string {
+	var labels []string
+	if msg.NotBefore != nil {
+		labels = append(labels, LabelNotBeforePrefix+msg.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if msg.ExpiresAt != nil {
+		labels = append(labels, LabelExpiresAtPrefix+msg.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return labels
+}
+
+// ReadReceiptLabels returns the labels recording that identity read a message.
+// read-at is written last so a reader never sees a timestamp without a reader.
+func ReadReceiptLabels(identity string, at time.Time) []string {
+	return []string{
+		LabelReadByPrefix + identity,
+		LabelReadAtPrefix + at.UTC().Format(time.RFC3339),
+	}
+}
+
+// ParseScheduleLabels extracts the schedule, expiry and read receipt metadata
+// from labels. Timestamps use last-wins, which for RFC3339 in lexicographic
+// label order is also the latest.
+func ParseScheduleLabels(labels []string) (notBefore, expiresAt *time.Time, readBy string, readAt *time.Time) {
+	parse := func(s string) *time.Time {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return &t
+		}
+		return nil
+	}
+	for _, label := range labels {
+		switch {
+		case strings.HasPrefix(label, LabelNotBeforePrefix):
+			if t := parse(strings.TrimPrefix(label, LabelNotBeforePrefix)); t != nil {
+				notBefore = t
+			}
+		case strings.HasPrefix(label, LabelExpiresAtPrefix):
+			if t := parse(strings.TrimPrefix(label, LabelExpiresAtPrefix)); t != nil {
+				expiresAt = t
+			}
+		case strings.HasPrefix(label, LabelReadByPrefix):
+			readBy = strings.TrimPrefix(label, LabelReadByPrefix)
+		case strings.HasPrefix(label, LabelReadAtPrefix):
+			if t := parse(strings.TrimPrefix(label, LabelReadAtPrefix)); t != nil {
+				readAt = t
+			}
+		}
+	}
+	return notBefore, expiresAt, readBy, readAt
+}
+
+// IsScheduled reports whether the message is held until a later time.
+func (m *Message) IsScheduled(now time.Time) bool {
+	return m.NotBefore != nil && now.Before(*m.NotBefore)
+}
+
+// IsExpired reports whether the message's TTL has passed. Pinned messages
+// never expire.
+func (m *Message) IsExpired(now time.Time) bool {
+	return m.ExpiresAt != nil && !m.Pinned && !now.Before(*m.ExpiresAt)
+}
+
+// HasReceipt reports whether the message carries a receipt of the given kind.
+func (m *Message) HasReceipt(kind string) bool {
+	if m.ReadAt != nil {
+		return true
+	}
+	return kind == ReceiptAck && m.DeliveryState == DeliveryStateAcked
+}
+
+// visibleMessages drops messages that are not yet due or already expired.
+func visibleMessages(messages []*Message, now time.Time) []*Message {
+	visible := messages[:0]
+	for _, msg := range messages {
+		if msg.IsScheduled(now) || msg.IsExpired(now) {
+			continue
+		}
+		visible = append(visible, msg)
+	}
+	return visible
+}
+
+// SweepResult reports what Router.Sweep did.
+type SweepResult struct {
+	Released []*Message `json:"released"` // Scheduled messages that became due
+	Expired  []*Message `json:"expired"`  // Messages archived after their TTL
+	Errors   []string   `json:"errors,omitempty"`
+}
+
+// Sweep releases scheduled messages that have become due (notifying their
+// recipients) and archives open messages whose TTL has passed. It is safe to
+// run concurrently with senders and readers: release is recorded with an
+// append-only label and archiving reuses Mailbox.Archive. With dryRun it only
+// reports what it would do.
+func (r *Router) Sweep(now time.Time, dryRun bool) (*SweepResult, error) {
+	beadsDir := r.resolveBeadsDir()
+	workDir := filepath.Dir(beadsDir)
+
+	args := []string{"list", "--label", "gt:message", "--json", "--limit", "0"}
+	ctx, cancel := bdReadCtx()
+	stdout, err := runBdCommand(ctx, args, workDir, beadsDir)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("listing messages: %w", err)
+	}
+	var bms []BeadsMessage
+	if err := json.Unmarshal(stdout, &bms); err != nil && len(stdout) > 0 && string(stdout) != "null" {
+		return nil, fmt.Errorf("parsing messages: %w", err)
+	}
+
+	result := &SweepResult{}
+	for i := range bms {
+		bm := &bms[i]
+		if bm.Status != "open" && bm.Status != "hooked" {
+			continue
+		}
+		msg := bm.ToMessage()
+
+		switch {
+		case msg.IsExpired(now):
+			if !dryRun {
+				if err := NewMailboxWithBeadsDir(msg.To, workDir, beadsDir).Archive(msg.ID); err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("%s: archive: %v", msg.ID, err))
+					continue
+				}
+			}
+			result.Expired = append(result.Expired, msg)
+
+		case msg.NotBefore != nil && !msg.IsScheduled(now) && !bm.HasLabel(LabelReleased):
+			if !dryRun {
+				ctx, cancel := bdWriteCtx()
+				_, err := runBdCommand(ctx, []string{"label", "add", msg.ID, LabelReleased}, workDir, beadsDir)
+				cancel()
+				if err != nil {
+					result.Errors = append(result.Errors, fmt.Sprintf("%s: release: %v", msg.ID, err))
+					continue
+				}
+				if msg.To != "" && !isSelfMail(msg.From, msg.To) {
+					r.notifyAsync(msg)
+				}
+			}
+			result.Released = append(result.Released, msg)
+		}
+	}
+	return result, nil
+}
+
+// WaitForReceipt polls message id until it carries a receipt of the given
+// kind (ReceiptAck or ReceiptRead) and returns the updated message. It
+// returns ErrReceiptTimeout if none arrives within timeout.
+func (r *Router) WaitForReceipt(id, kind string, timeout time.Duration) (*Message, error) {
+	beadsDir := r.resolveBeadsDir()
+	mailbox := NewMailboxWithBeadsDir("", filepath.Dir(beadsDir), beadsDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		msg, err := mailbox.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if msg.HasReceipt(kind) {
+			return msg, nil
+		}
+		select {
+		case <-ctx.Done():
+			return msg, ErrReceiptTimeout
+		case <-ticker.C:
+		}
+	}
+}
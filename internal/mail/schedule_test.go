@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleLabelsRoundTrip(t *testing.T) {
+	notBefore := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	expires := notBefore.Add(4 * time.Hour)
+	msg := &Message{NotBefore: &notBefore, ExpiresAt: &expires}
+
+	labels := append(ScheduleLabels(msg), ReadReceiptLabels("gastown/witness", notBefore.Add(time.Hour))...)
+	want := []string{
+		"not-before:2026-03-01T09:00:00Z",
+		"expires-at:2026-03-01T13:00:00Z",
+		"read-by:gastown/witness",
+		"read-at:2026-03-01T10:00:00Z",
+	}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %v, want %v", labels, want)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("labels[%d] = %q, want %q", i, labels[i], want[i])
+		}
+	}
+
+	bm := &BeadsMessage{ID: "hq-1", Assignee: "gastown/witness", Status: "open", Labels: append(labels, "from:mayor/")}
+	got := bm.ToMessage()
+	if got.NotBefore == nil || !got.NotBefore.Equal(notBefore) {
+		t.Errorf("NotBefore = %v, want %v", got.NotBefore, notBefore)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(expires) {
+		t.Errorf("ExpiresAt = %v, want %v", got.ExpiresAt, expires)
+	}
+	if got.ReadBy != "gastown/witness" || got.ReadAt == nil {
+		t.Errorf("read receipt = %q %v", got.ReadBy, got.ReadAt)
+	}
+}
+
+func TestParseScheduleLabels_IgnoresMalformed(t *testing.T) {
+	notBefore, expiresAt, readBy, readAt := ParseScheduleLabels([]string{"not-before:tomorrow", "expires-at:", "gt:message"})
+	if notBefore != nil || expiresAt != nil || readBy != "" || readAt != nil {
+		t.Errorf("got %v %v %q %v, want all empty", notBefore, expiresAt, readBy, readAt)
+	}
+}
+
+func TestVisibleMessages(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	messages := []*Message{
+		{ID: "plain"},
+		{ID: "due", NotBefore: &past},
+		{ID: "scheduled", NotBefore: &future},
+		{ID: "fresh", ExpiresAt: &future},
+		{ID: "expired", ExpiresAt: &past},
+		{ID: "pinned", ExpiresAt: &past, Pinned: true},
+	}
+	var got []string
+	for _, msg := range visibleMessages(messages, now) {
+		got = append(got, msg.ID)
+	}
+	want := []string{"plain", "due", "fresh", "pinned"}
+	if len(got) != len(want) {
+		t.Fatalf("visible = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("visible = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestHasReceipt(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		msg       Message
+		ack, read bool
+	}{
+		{"none", Message{DeliveryState: DeliveryStatePending}, false, false},
+		{"acked", Message{DeliveryState: DeliveryStateAcked}, true, false},
+		{"read", Message{ReadAt: &now}, true, true},
+	}
+	for _, tt := range tests {
+		if got := tt.msg.HasReceipt(ReceiptAck); got != tt.ack {
+			t.Errorf("%s: HasReceipt(ack) = %v, want %v", tt.name, got, tt.ack)
+		}
+		if got := tt.msg.HasReceipt(ReceiptRead); got != tt.read {
+			t.Errorf("%s: HasReceipt(read) = %v, want %v", tt.name, got, tt.read)
+		}
+	}
+}
+
+func TestMailboxListHidesScheduledLegacy(t *testing.T) {
+	orig := timeNow
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = orig }()
+
+	m := NewMailbox(t.TempDir() + "/inbox.jsonl")
+	later := now.Add(time.Hour)
+	for _, msg := range []*Message{
+		{ID: "now", From: "mayor/", To: "gastown/Toast", Subject: "a", Timestamp: now},
+		{ID: "later", From: "mayor/", To: "gastown/Toast", Subject: "b", Timestamp: now, NotBefore: &later},
+	} {
+		if err := m.Append(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	msgs, err := m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "now" {
+		t.Fatalf("List() = %d messages, want only the due one", len(msgs))
+	}
+
+	// Rewriting the inbox must keep the hidden message.
+	if err := m.MarkRead("now"); err != nil {
+		t.Fatal(err)
+	}
+	timeNow = func() time.Time { return later }
+	msgs, err = m.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("List() after schedule = %d messages, want 2", len(msgs))
+	}
+}
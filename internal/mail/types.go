@@ -56,6 +56,10 @@ const (
 	DeliveryInterrupt Delivery = "interrupt"
 )
 
+// LabelPinned marks a pinned message in beads mode. Pinned messages are never
+// expired or auto-archived.
+const LabelPinned = "pinned"
+
 // Message represents a mail message between agents.
 // This is the GGT-side representation; it gets translated to/from beads messages.
 type Message struct {
@@ -130,10 +134,30 @@ type Message struct {
 	// DeliveryAckedAt is when receipt was acknowledged.
 	DeliveryAckedAt *time.Time `json:"delivery_acked_at,omitempty"`
 
+	// NotBefore holds the message out of the recipient's inbox until this time.
+	// Router.Sweep notifies the recipient once it is due.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+	// ExpiresAt is when the message goes stale. Expired messages are hidden
+	// from the inbox and archived by Router.Sweep unless pinned.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ReadBy is the identity that read the message (read receipt).
+	ReadBy string `json:"read_by,omitempty"`
+	// ReadAt is when the message was read.
+	ReadAt *time.Time `json:"read_at,omitempty"`
+
+	// Payload is an optional typed, machine-readable body alongside Body.
+	Payload *Payload `json:"payload,omitempty"`
+	// Attachments reference blobs in the town's content-addressed blob store.
+	Attachments []Attachment `json:"attachments,omitempty"`
+
 	// SuppressNotify tells the router to skip all recipient notification
 	// (no nudge, no banner). Set by the CLI when --no-notify is passed.
 	// In-memory only — not serialized.
 	SuppressNotify bool `json:"-"`
+
+	// forwardedFrom is the original recipient identity when this is a copy
+	// delivered by a forward rule. Forwarded copies skip rule evaluation.
+	forwardedFrom string
 }
 
 // NewMessage creates a new message with a generated ID and thread ID.
@@ -264,6 +288,17 @@ func (m *Message) Validate() error {
 		return fmt.Errorf("claimed_at is only valid for queue messages")
 	}
 
+	if m.Payload != nil {
+		if err := m.Payload.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, a := range m.Attachments {
+		if err := a.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -299,7 +334,7 @@ type BeadsMessage struct {
 	Priority    int       `json:"priority"`    // 0=urgent, 1=high, 2=normal, 3=low
 	Status      string    `json:"status"`      // open=unread, closed=read
 	CreatedAt   time.Time `json:"created_at"`
-	Labels      []string  `json:"labels"` // Metadata labels (from:X, thread:X, reply-to:X, msg-type:X, cc:X, queue:X, channel:X, claimed-by:X, claimed-at:X)
+	Labels      []string  `json:"labels"` // Metadata labels (from:X, thread:X, reply-to:X, msg-type:X, cc:X, queue:X, channel:X, claimed-by:X, claimed-at:X, not-before:X, expires-at:X, read-by:X, read-at:X)
 	Pinned      bool      `json:"pinned,omitempty"`
 	Wisp        bool      `json:"wisp,omitempty"` // Ephemeral message (not synced to git)
 
@@ -317,6 +352,11 @@ type BeadsMessage struct {
 	deliveryState   string
 	deliveryAckedBy string
 	deliveryAckedAt *time.Time
+	// Scheduling and read receipt metadata
+	notBefore *time.Time
+	expiresAt *time.Time
+	readBy    string
+	readAt    *time.Time
 }
 
 // ParseLabels extracts metadata from the labels array.
@@ -361,6 +401,7 @@ func (bm *BeadsMessage) ParseLabels() {
 	}
 
 	bm.deliveryState, bm.deliveryAckedBy, bm.deliveryAckedAt = ParseDeliveryLabels(bm.Labels)
+	bm.notBefore, bm.expiresAt, bm.readBy, bm.readAt = ParseScheduleLabels(bm.Labels)
 }
 
 // GetCC returns the parsed CC recipients.
@@ -403,6 +444,8 @@ func (bm *BeadsMessage) ToMessage() *Message {
 		msgType = MessageType(bm.msgType)
 	}
 
+	body, payload, attachments := DecodeDescription(bm.Description)
+
 	// Convert CC identities to addresses
 	var ccAddrs []string
 	for _, cc := range bm.cc {
@@ -414,13 +457,14 @@ func (bm *BeadsMessage) ToMessage() *Message {
 		From:            identityToAddress(bm.sender),
 		To:              identityToAddress(bm.Assignee),
 		Subject:         bm.Title,
-		Body:            bm.Description,
+		Body:            body,
 		Timestamp:       bm.CreatedAt,
 		Read:            bm.Status == "closed" || bm.HasLabel("read"),
 		Priority:        priority,
 		Type:            msgType,
 		ThreadID:        bm.threadID,
 		ReplyTo:         bm.replyTo,
+		Pinned:          bm.Pinned || bm.HasLabel(LabelPinned),
 		Wisp:            bm.Wisp,
 		CC:              ccAddrs,
 		Queue:           bm.queue,
@@ -430,6 +474,12 @@ func (bm *BeadsMessage) ToMessage() *Message {
 		DeliveryState:   bm.deliveryState,
 		DeliveryAckedBy: bm.deliveryAckedBy,
 		DeliveryAckedAt: bm.deliveryAckedAt,
+		NotBefore:       bm.notBefore,
+		ExpiresAt:       bm.expiresAt,
+		ReadBy:          identityToAddress(bm.readBy),
+		ReadAt:          bm.readAt,
+		Payload:         payload,
+		Attachments:     attachments,
 	}
 }
 
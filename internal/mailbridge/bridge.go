@@ -0,0 +1,364 @@
+// Package mailbridge lets humans use an ordinary mail client with Gas Town
+// mail. It serves each identity's mailbox over IMAP and accepts SMTP
+// submissions, which are delivered through mail.Router like `gt mail send`.
+//
+// Identities map to addresses of the form rig/name@town.local (mayor/ is
+// mayor@town.local, the overseer is overseer@town.local). The bridge only
+// listens on loopback addresses and every session must authenticate with a
+// per-identity password from the bridge user file.
+package mailbridge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// Defaults for the bridge listeners and address domain.
+const (
+	DefaultDomain   = "town.local"
+	DefaultIMAPAddr = "127.0.0.1:1143"
+	DefaultSMTPAddr = "127.0.0.1:1025"
+)
+
+// Backend is the mail store the bridge serves. RouterBackend is the
+// production implementation; tests substitute an in-memory one.
+type Backend interface {
+	// List returns the inbox of identity, oldest first.
+	List(identity string) ([]*mail.Message, error)
+	// SetSeen marks a message read or unread.
+	SetSeen(identity, id string, seen bool) error
+	// SetPinned pins or unpins a message.
+	SetPinned(identity, id string, pinned bool) error
+	// Archive removes a message from the inbox.
+	Archive(identity, id string) error
+	// Send delivers a message.
+	Send(msg *mail.Message) error
+	// OpenAttachment reads an attachment blob.
+	OpenAttachment(att mail.Attachment) (io.ReadCloser, error)
+	// StoreAttachment stores an attachment blob.
+	StoreAttachment(name string, r io.Reader) (mail.Attachment, error)
+}
+
+// RouterBackend serves town mail through mail.Router.
+type RouterBackend struct {
+	router *mail.Router
+}
+
+// NewRouterBackend creates a backend for the town containing workDir.
+func NewRouterBackend(workDir string) *RouterBackend {
+	return &RouterBackend{router: mail.NewRouter(workDir)}
+}
+
+func (b *RouterBackend) mailbox(identity string) (*mail.Mailbox, error) {
+	return b.router.GetMailbox(identity)
+}
+
+// List implements Backend.
+func (b *RouterBackend) List(identity string) ([]*mail.Message, error) {
+	mb, err := b.mailbox(identity)
+	if err != nil {
+		return nil, err
+	}
+	return mb.List()
+}
+
+// SetSeen implements Backend. Unlike `gt mail archive`, marking a message
+// seen only adds the read label, so it stays in the inbox.
+func (b *RouterBackend) SetSeen(identity, id string, seen bool) error {
+	mb, err := b.mailbox(identity)
+	if err != nil {
+		return err
+	}
+	if seen {
+		return mb.MarkReadOnly(id)
+	}
+	return mb.MarkUnreadOnly(id)
+}
+
+// SetPinned implements Backend.
+func (b *RouterBackend) SetPinned(identity, id string, pinned bool) error {
+	mb, err := b.mailbox(identity)
+	if err != nil {
+		return err
+	}
+	return mb.SetPinned(id, pinned)
+}
+
+// Archive implements Backend.
+func (b *RouterBackend) Archive(identity, id string) error {
+	mb, err := b.mailbox(identity)
+	if err != nil {
+		return err
+	}
+	return mb.Archive(id)
+}
+
+// Send implements Backend.
+func (b *RouterBackend) Send(msg *mail.Message) error {
+	return b.router.Send(msg)
+}
+
+// OpenAttachment implements Backend.
+func (b *RouterBackend) OpenAttachment(att mail.Attachment) (io.ReadCloser, error) {
+	return mail.OpenAttachment(b.router.TownRoot(), att)
+}
+
+// StoreAttachment implements Backend.
+func (b *RouterBackend) StoreAttachment(name string, r io.Reader) (mail.Attachment, error) {
+	return mail.StoreAttachment(b.router.TownRoot(), name, r)
+}
+
+// WaitPendingNotifications waits for notifications of submitted mail.
+func (b *RouterBackend) WaitPendingNotifications() {
+	b.router.WaitPendingNotifications()
+}
+
+// EmailAddress returns the mail client address of a Gas Town identity.
+func EmailAddress(identity, domain string) string {
+	local := strings.TrimSuffix(mail.AddressToIdentity(identity), "/")
+	return local + "@" + domain
+}
+
+// IdentityFromEmail maps a mail client address (or a bare identity) back to
+// a Gas Town address. Addresses in other domains are rejected.
+func IdentityFromEmail(addr, domain string) (string, error) {
+	addr = strings.TrimSpace(strings.Trim(strings.TrimSpace(addr), "<>"))
+	local := addr
+	if at := strings.LastIndex(addr, "@"); at >= 0 {
+		if !strings.EqualFold(addr[at+1:], domain) {
+			return "", fmt.Errorf("address %q is not in domain %s", addr, domain)
+		}
+		local = addr[:at]
+	}
+	if local == "" || strings.ContainsAny(local, " \t\"<>,") {
+		return "", fmt.Errorf("invalid address %q", addr)
+	}
+	return mail.AddressToIdentity(local), nil
+}
+
+// Config configures a Server.
+type Config struct {
+	IMAPAddr string // IMAP listen address ("" = no IMAP)
+	SMTPAddr string // SMTP listen address ("" = no SMTP)
+	Domain   string // Address domain (default DefaultDomain)
+	Backend  Backend
+	Users    *Users
+	Logger   *log.Logger // Optional connection log
+}
+
+// Server runs the IMAP and SMTP listeners.
+type Server struct {
+	cfg   Config
+	uids  *uidRegistry
+	imap  net.Listener
+	smtp  net.Listener
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// NewServer validates cfg and returns a server ready to Start.
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.Backend == nil {
+		return nil, errors.New("mail bridge: no backend")
+	}
+	if cfg.Users == nil {
+		return nil, errors.New("mail bridge: no users configured")
+	}
+	if cfg.IMAPAddr == "" && cfg.SMTPAddr == "" {
+		return nil, errors.New("mail bridge: neither IMAP nor SMTP enabled")
+	}
+	for _, addr := range []string{cfg.IMAPAddr, cfg.SMTPAddr} {
+		if addr != "" {
+			if err := checkLoopback(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cfg.Domain == "" {
+		cfg.Domain = DefaultDomain
+	}
+	return &Server{cfg: cfg, uids: newUIDRegistry(), conns: make(map[net.Conn]struct{})}, nil
+}
+
+// checkLoopback rejects listen addresses that are not loopback-only. The
+// bridge speaks plaintext, so it must never be reachable off-host.
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("mail bridge: invalid listen address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("mail bridge: listen address %q is not loopback (the bridge is localhost-only)", addr)
+}
+
+// Start opens the listeners and serves connections in the background.
+func (s *Server) Start() error {
+	listen := func(addr string, serve func(net.Conn)) (net.Listener, error) {
+		if addr == "" {
+			return nil, nil
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("mail bridge: %w", err)
+		}
+		s.wg.Add(1)
+		go s.acceptLoop(ln, serve)
+		return ln, nil
+	}
+	var err error
+	if s.imap, err = listen(s.cfg.IMAPAddr, s.serveIMAP); err != nil {
+		return err
+	}
+	if s.smtp, err = listen(s.cfg.SMTPAddr, s.serveSMTP); err != nil {
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+// IMAPAddr returns the bound IMAP address ("" when IMAP is disabled).
+func (s *Server) IMAPAddr() string { return listenerAddr(s.imap) }
+
+// SMTPAddr returns the bound SMTP address ("" when SMTP is disabled).
+func (s *Server) SMTPAddr() string { return listenerAddr(s.smtp) }
+
+func listenerAddr(ln net.Listener) string {
+	if ln == nil {
+		return ""
+	}
+	return ln.Addr().String()
+}
+
+func (s *Server) acceptLoop(ln net.Listener, serve func(net.Conn)) {
+	defer s.wg.Done()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			serve(conn)
+		}()
+	}
+}
+
+// Close stops the listeners, closes open sessions and waits for them.
+func (s *Server) Close() error {
+	for _, ln := range []net.Listener{s.imap, s.smtp} {
+		if ln != nil {
+			ln.Close()
+		}
+	}
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	if s.cfg.Logger != nil {
+		s.cfg.Logger.Printf(format, args...)
+	}
+}
+
+// authenticate checks a login name (address or identity) and password and
+// returns the identity on success.
+func (s *Server) authenticate(username, password string) (string, bool) {
+	identity, err := IdentityFromEmail(username, s.cfg.Domain)
+	if err != nil {
+		return "", false
+	}
+	if !s.cfg.Users.Verify(identity, password) {
+		return "", false
+	}
+	return identity, true
+}
+
+// uidRegistry assigns IMAP UIDs to message IDs, per identity. UIDs are kept
+// in memory, so UIDVALIDITY changes on every bridge start and clients
+// resynchronize rather than trusting stale UIDs.
+type uidRegistry struct {
+	mu       sync.Mutex
+	validity uint32
+	boxes    map[string]*uidTable
+}
+
+type uidTable struct {
+	next uint32
+	byID map[string]uint32
+}
+
+func newUIDRegistry() *uidRegistry {
+	return &uidRegistry{validity: uint32(time.Now().Unix()), boxes: make(map[string]*uidTable)}
+}
+
+// assign returns the UID of each message, allocating new UIDs in order.
+// msgs is the whole inbox: IDs no longer listed are forgotten, so a message
+// that leaves and comes back gets a fresh, higher UID.
+func (r *uidRegistry) assign(identity string, msgs []*mail.Message) []uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.boxes[identity]
+	if t == nil {
+		t = &uidTable{next: 1, byID: make(map[string]uint32)}
+		r.boxes[identity] = t
+	}
+	present := make(map[string]bool, len(msgs))
+	for _, msg := range msgs {
+		present[msg.ID] = true
+	}
+	for id := range t.byID {
+		if !present[id] {
+			delete(t.byID, id)
+		}
+	}
+	uids := make([]uint32, len(msgs))
+	for i, msg := range msgs {
+		uid, ok := t.byID[msg.ID]
+		if !ok {
+			uid = t.next
+			t.next++
+			t.byID[msg.ID] = uid
+		}
+		uids[i] = uid
+	}
+	return uids
+}
+
+// uidNext returns the next UID that will be assigned for identity.
+func (r *uidRegistry) uidNext(identity string) uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t := r.boxes[identity]; t != nil {
+		return t.next
+	}
+	return 1
+}
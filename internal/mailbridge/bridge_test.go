@@ -0,0 +1,561 @@
+package mailbridge
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// memBackend is an in-memory Backend for tests.
+type memBackend struct {
+	mu       sync.Mutex
+	inboxes  map[string][]*mail.Message
+	archived []string
+	blobs    map[string][]byte
+	nextID   int
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{inboxes: make(map[string][]*mail.Message), blobs: make(map[string][]byte)}
+}
+
+func (b *memBackend) add(msg *mail.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if msg.ID == "" {
+		b.nextID++
+		msg.ID = fmt.Sprintf("msg-%d", b.nextID)
+	}
+	to := mail.AddressToIdentity(msg.To)
+	b.inboxes[to] = append(b.inboxes[to], msg)
+}
+
+func (b *memBackend) find(identity, id string) *mail.Message {
+	for _, m := range b.inboxes[identity] {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+func (b *memBackend) List(identity string) ([]*mail.Message, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []*mail.Message
+	for _, m := range b.inboxes[identity] {
+		c := *m
+		out = append(out, &c)
+	}
+	return out, nil
+}
+
+func (b *memBackend) SetSeen(identity, id string, seen bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := b.find(identity, id)
+	if m == nil {
+		return mail.ErrMessageNotFound
+	}
+	m.Read = seen
+	return nil
+}
+
+func (b *memBackend) SetPinned(identity, id string, pinned bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := b.find(identity, id)
+	if m == nil {
+		return mail.ErrMessageNotFound
+	}
+	m.Pinned = pinned
+	return nil
+}
+
+func (b *memBackend) Archive(identity, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	msgs := b.inboxes[identity]
+	for i, m := range msgs {
+		if m.ID == id {
+			b.inboxes[identity] = append(msgs[:i], msgs[i+1:]...)
+			b.archived = append(b.archived, id)
+			return nil
+		}
+	}
+	return mail.ErrMessageNotFound
+}
+
+func (b *memBackend) Send(msg *mail.Message) error {
+	c := *msg
+	b.add(&c)
+	return nil
+}
+
+func (b *memBackend) OpenAttachment(att mail.Attachment) (io.ReadCloser, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data, ok := b.blobs[att.Digest]
+	if !ok {
+		return nil, fmt.Errorf("attachment %s not found", att.Digest)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (b *memBackend) StoreAttachment(name string, r io.Reader) (mail.Attachment, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return mail.Attachment{}, err
+	}
+	sum := sha256.Sum256(data)
+	att := mail.Attachment{
+		Name:      name,
+		MediaType: "application/octet-stream",
+		Size:      int64(len(data)),
+		Digest:    "sha256:" + hex.EncodeToString(sum[:]),
+	}
+	b.mu.Lock()
+	b.blobs[att.Digest] = data
+	b.mu.Unlock()
+	return att, nil
+}
+
+// startTestServer starts a bridge with one user, overseer, and returns its
+// password.
+func startTestServer(t *testing.T, backend Backend) (*Server, string) {
+	t.Helper()
+	users, err := LoadUsers(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	password, err := users.Reset("overseer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(Config{
+		IMAPAddr: "127.0.0.1:0",
+		SMTPAddr: "127.0.0.1:0",
+		Backend:  backend,
+		Users:    users,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, password
+}
+
+// imapClient is a minimal IMAP client: it sends tagged commands and
+// collects the response lines, inlining literals.
+type imapClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	n    int
+}
+
+func dialIMAP(t *testing.T, addr string) *imapClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	c := &imapClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	if greeting := c.line(); !strings.HasPrefix(greeting, "* OK") {
+		t.Fatalf("greeting = %q", greeting)
+	}
+	return c
+}
+
+// line reads one response line, appending any literal it announces.
+var literalRE = regexp.MustCompile(`\{(\d+)\}$`)
+
+func (c *imapClient) line() string {
+	c.t.Helper()
+	var sb strings.Builder
+	for {
+		l, err := c.r.ReadString('\n')
+		if err != nil {
+			c.t.Fatalf("reading response: %v", err)
+		}
+		l = strings.TrimRight(l, "\r\n")
+		sb.WriteString(l)
+		m := literalRE.FindStringSubmatch(l)
+		if m == nil {
+			return sb.String()
+		}
+		n, _ := strconv.Atoi(m[1])
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			c.t.Fatalf("reading literal: %v", err)
+		}
+		sb.WriteString("\n")
+		sb.Write(buf)
+	}
+}
+
+// cmd runs a command and returns the untagged lines and the tagged status.
+func (c *imapClient) cmd(format string, args ...interface{}) ([]string, string) {
+	c.t.Helper()
+	c.n++
+	tag := fmt.Sprintf("a%d", c.n)
+	fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...))
+	var untagged []string
+	for {
+		l := c.line()
+		if strings.HasPrefix(l, tag+" ") {
+			return untagged, strings.TrimPrefix(l, tag+" ")
+		}
+		untagged = append(untagged, l)
+	}
+}
+
+// ok runs a command that must succeed.
+func (c *imapClient) ok(format string, args ...interface{}) []string {
+	c.t.Helper()
+	lines, status := c.cmd(format, args...)
+	if !strings.HasPrefix(status, "OK") {
+		c.t.Fatalf("%s: %s", fmt.Sprintf(format, args...), status)
+	}
+	return lines
+}
+
+func TestIMAPLoginRequired(t *testing.T) {
+	s, password := startTestServer(t, newMemBackend())
+	c := dialIMAP(t, s.IMAPAddr())
+
+	if _, status := c.cmd("SELECT INBOX"); !strings.HasPrefix(status, "NO") {
+		t.Errorf("SELECT before LOGIN = %q, want NO", status)
+	}
+	if _, status := c.cmd(`LOGIN overseer@town.local "wrong"`); !strings.HasPrefix(status, "NO") {
+		t.Errorf("LOGIN with bad password = %q, want NO", status)
+	}
+	c.ok(`LOGIN overseer@town.local %q`, password)
+}
+
+func TestIMAPReadAndFlags(t *testing.T) {
+	backend := newMemBackend()
+	backend.add(&mail.Message{From: "mayor/", To: "overseer", Subject: "First", Body: "hello\nworld", Timestamp: time.Now().Add(-time.Hour)})
+	backend.add(&mail.Message{From: "gastown/witness", To: "overseer", Subject: "Second", Body: "ping", Timestamp: time.Now()})
+	s, password := startTestServer(t, backend)
+	c := dialIMAP(t, s.IMAPAddr())
+	c.ok(`LOGIN overseer %q`, password)
+
+	lines := c.ok("SELECT INBOX")
+	if !containsLine(lines, "* 2 EXISTS") {
+		t.Fatalf("SELECT = %q, want 2 EXISTS", lines)
+	}
+
+	lines = c.ok("FETCH 1:* (UID FLAGS ENVELOPE)")
+	if len(lines) != 2 {
+		t.Fatalf("FETCH returned %d lines: %q", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], `"First"`) || !strings.Contains(lines[0], `((NIL NIL "mayor" "town.local"))`) {
+		t.Errorf("envelope = %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"gastown/witness" "town.local"`) {
+		t.Errorf("envelope = %q", lines[1])
+	}
+
+	// PEEK leaves the message unread; BODY[] marks it read.
+	c.ok("FETCH 1 BODY.PEEK[TEXT]")
+	if m, _ := backend.List("overseer"); m[0].Read {
+		t.Fatal("BODY.PEEK marked the message read")
+	}
+	lines = c.ok("FETCH 1 BODY[TEXT]")
+	if !strings.Contains(lines[0], "hello\r\nworld") || !strings.Contains(lines[0], `\Seen`) {
+		t.Errorf("BODY[TEXT] = %q", lines[0])
+	}
+	if m, _ := backend.List("overseer"); !m[0].Read {
+		t.Error("BODY[] did not mark the message read")
+	}
+
+	// \Flagged maps to pinned, -\Seen to unread.
+	c.ok(`STORE 2 +FLAGS (\Flagged)`)
+	c.ok(`STORE 1 -FLAGS.SILENT (\Seen)`)
+	msgs, _ := backend.List("overseer")
+	if msgs[0].Read || !msgs[1].Pinned {
+		t.Errorf("after STORE: read=%v pinned=%v", msgs[0].Read, msgs[1].Pinned)
+	}
+
+	lines = c.ok("SEARCH UNSEEN")
+	if !containsLine(lines, "* SEARCH 1 2") {
+		t.Errorf("SEARCH UNSEEN = %q", lines)
+	}
+	lines = c.ok("UID SEARCH FLAGGED")
+	if !containsLine(lines, "* SEARCH 2") {
+		t.Errorf("UID SEARCH FLAGGED = %q", lines)
+	}
+
+	// Expunging a \Deleted message archives it.
+	c.ok(`STORE 1 +FLAGS (\Deleted)`)
+	lines = c.ok("EXPUNGE")
+	if !containsLine(lines, "* 1 EXPUNGE") {
+		t.Errorf("EXPUNGE = %q", lines)
+	}
+	if len(backend.archived) != 1 || backend.archived[0] != "msg-1" {
+		t.Errorf("archived = %v, want [msg-1]", backend.archived)
+	}
+
+	// New mail shows up on NOOP.
+	backend.add(&mail.Message{From: "mayor/", To: "overseer", Subject: "Third", Timestamp: time.Now()})
+	lines = c.ok("NOOP")
+	if !containsLine(lines, "* 2 EXISTS") {
+		t.Errorf("NOOP = %q, want 2 EXISTS", lines)
+	}
+	lines = c.ok("UID FETCH 1:* (UID)")
+	if !containsLine(lines, "* 2 FETCH (UID 3)") {
+		t.Errorf("UID FETCH = %q", lines)
+	}
+	c.ok("LOGOUT")
+}
+
+func TestIMAPOnlyInbox(t *testing.T) {
+	s, password := startTestServer(t, newMemBackend())
+	c := dialIMAP(t, s.IMAPAddr())
+	c.ok(`LOGIN overseer %q`, password)
+
+	lines := c.ok(`LIST "" "*"`)
+	if len(lines) != 1 || !strings.HasSuffix(lines[0], `"/" INBOX`) {
+		t.Errorf("LIST = %q", lines)
+	}
+	if _, status := c.cmd("SELECT Sent"); !strings.HasPrefix(status, "NO") {
+		t.Errorf("SELECT Sent = %q, want NO", status)
+	}
+}
+
+func TestIMAPMultipartStructure(t *testing.T) {
+	backend := newMemBackend()
+	att, _ := backend.StoreAttachment("trace.txt", strings.NewReader("stack trace"))
+	payload, err := mail.NewPayload("test/v1", map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.add(&mail.Message{
+		From: "mayor/", To: "overseer", Subject: "With parts", Body: "see attached",
+		Timestamp: time.Now(), Payload: payload, Attachments: []mail.Attachment{att},
+	})
+	s, password := startTestServer(t, backend)
+	c := dialIMAP(t, s.IMAPAddr())
+	c.ok(`LOGIN overseer %q`, password)
+	c.ok("EXAMINE INBOX")
+
+	lines := c.ok("FETCH 1 BODYSTRUCTURE")
+	for _, want := range []string{`"MIXED"`, `"FILENAME" "payload.json"`, `"FILENAME" "trace.txt"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("BODYSTRUCTURE missing %s: %q", want, lines[0])
+		}
+	}
+	lines = c.ok("FETCH 1 BODY[3]")
+	if !strings.Contains(lines[0], "c3RhY2sgdHJhY2U=") {
+		t.Errorf("BODY[3] = %q, want base64 attachment", lines[0])
+	}
+	// EXAMINE is read-only: BODY[] does not set \Seen.
+	if m, _ := backend.List("overseer"); m[0].Read {
+		t.Error("fetch in EXAMINE marked the message read")
+	}
+}
+
+func TestSMTPSubmission(t *testing.T) {
+	backend := newMemBackend()
+	backend.add(&mail.Message{ID: "hq-orig", From: "mayor/", To: "overseer", Subject: "Question", ThreadID: "thread-1", Timestamp: time.Now()})
+	s, password := startTestServer(t, backend)
+
+	auth := smtp.PlainAuth("", "overseer@town.local", password, "127.0.0.1")
+	body := "From: overseer@town.local\r\n" +
+		"To: mayor@town.local\r\n" +
+		"Subject: Re: Question\r\n" +
+		"In-Reply-To: <hq-orig@town.local>\r\n" +
+		"X-Priority: 1\r\n" +
+		"\r\n" +
+		"Answer.\r\n"
+	err := smtp.SendMail(s.SMTPAddr(), auth, "overseer@town.local",
+		[]string{"mayor@town.local", "gastown/witness@town.local"}, []byte(body))
+	if err != nil {
+		t.Fatalf("SendMail: %v", err)
+	}
+
+	for _, to := range []string{"mayor/", "gastown/witness"} {
+		msgs, _ := backend.List(to)
+		if len(msgs) != 1 {
+			t.Fatalf("%s has %d messages, want 1", to, len(msgs))
+		}
+		m := msgs[0]
+		if m.From != "overseer" || m.Subject != "Re: Question" || m.Body != "Answer." {
+			t.Errorf("%s got %+v", to, m)
+		}
+		if m.ReplyTo != "hq-orig" || m.ThreadID != "thread-1" || m.Priority != mail.PriorityUrgent {
+			t.Errorf("%s reply fields: reply_to=%q thread=%q priority=%q", to, m.ReplyTo, m.ThreadID, m.Priority)
+		}
+	}
+}
+
+func TestSMTPRejectsSpoofedSender(t *testing.T) {
+	backend := newMemBackend()
+	s, password := startTestServer(t, backend)
+
+	auth := smtp.PlainAuth("", "overseer", password, "127.0.0.1")
+	err := smtp.SendMail(s.SMTPAddr(), auth, "mayor@town.local",
+		[]string{"gastown/witness@town.local"}, []byte("Subject: hi\r\n\r\nx\r\n"))
+	if err == nil || !strings.Contains(err.Error(), "553") {
+		t.Fatalf("SendMail as another identity: err = %v, want 553", err)
+	}
+	if msgs, _ := backend.List("gastown/witness"); len(msgs) != 0 {
+		t.Errorf("spoofed message delivered: %+v", msgs)
+	}
+}
+
+func TestSMTPRequiresAuth(t *testing.T) {
+	s, _ := startTestServer(t, newMemBackend())
+	c, err := smtp.Dial(s.SMTPAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.Mail("overseer@town.local"); err == nil || !strings.Contains(err.Error(), "530") {
+		t.Errorf("MAIL without AUTH: err = %v, want 530", err)
+	}
+}
+
+func TestParseSubmissionMultipart(t *testing.T) {
+	backend := newMemBackend()
+	raw := "Subject: =?utf-8?q?Build_r=C3=A9sum=C3=A9?=\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=XX\r\n" +
+		"\r\n" +
+		"--XX\r\n" +
+		"Content-Type: multipart/alternative; boundary=YY\r\n" +
+		"\r\n" +
+		"--YY\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"plain body\r\n" +
+		"--YY\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>html body</p>\r\n" +
+		"--YY--\r\n" +
+		"--XX\r\n" +
+		"Content-Type: application/octet-stream\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"Content-Disposition: attachment; filename=\"build.log\"\r\n" +
+		"\r\n" +
+		"YnVpbGQgb2s=\r\n" +
+		"--XX--\r\n"
+	sub, err := parseSubmission([]byte(raw), DefaultDomain, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Subject != "Build résumé" {
+		t.Errorf("Subject = %q", sub.Subject)
+	}
+	if sub.Body != "plain body" {
+		t.Errorf("Body = %q", sub.Body)
+	}
+	if len(sub.Attachments) != 1 || sub.Attachments[0].Name != "build.log" {
+		t.Fatalf("Attachments = %+v", sub.Attachments)
+	}
+	if got := string(backend.blobs[sub.Attachments[0].Digest]); got != "build ok" {
+		t.Errorf("attachment content = %q", got)
+	}
+}
+
+func TestAddressMapping(t *testing.T) {
+	tests := []struct {
+		identity string
+		email    string
+	}{
+		{"mayor/", "mayor@town.local"},
+		{"overseer", "overseer@town.local"},
+		{"gastown/witness", "gastown/witness@town.local"},
+		{"gastown/Toast", "gastown/Toast@town.local"},
+	}
+	for _, tt := range tests {
+		if got := EmailAddress(tt.identity, DefaultDomain); got != tt.email {
+			t.Errorf("EmailAddress(%q) = %q, want %q", tt.identity, got, tt.email)
+		}
+		got, err := IdentityFromEmail("<"+tt.email+">", DefaultDomain)
+		if err != nil || got != tt.identity {
+			t.Errorf("IdentityFromEmail(%q) = %q, %v; want %q", tt.email, got, err, tt.identity)
+		}
+	}
+	// Addresses are normalized like everywhere else in mail.
+	if got := EmailAddress("gastown/polecats/Toast", DefaultDomain); got != "gastown/Toast@town.local" {
+		t.Errorf("EmailAddress(polecat path) = %q", got)
+	}
+	if _, err := IdentityFromEmail("mayor@example.com", DefaultDomain); err == nil {
+		t.Error("IdentityFromEmail accepted a foreign domain")
+	}
+}
+
+func TestNewServerRequiresLoopback(t *testing.T) {
+	users, _ := LoadUsers(filepath.Join(t.TempDir(), "users.json"))
+	for _, addr := range []string{"0.0.0.0:1143", ":1143", "10.0.0.5:1143"} {
+		if _, err := NewServer(Config{IMAPAddr: addr, Backend: newMemBackend(), Users: users}); err == nil {
+			t.Errorf("NewServer accepted non-loopback address %q", addr)
+		}
+	}
+	for _, addr := range []string{"127.0.0.1:1143", "[::1]:1143", "localhost:1143"} {
+		if _, err := NewServer(Config{IMAPAddr: addr, Backend: newMemBackend(), Users: users}); err != nil {
+			t.Errorf("NewServer(%q): %v", addr, err)
+		}
+	}
+}
+
+func TestUsersResetAndVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	users, err := LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := users.Reset("mayor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !users.Verify("mayor/", first) {
+		t.Error("Verify rejected the generated password")
+	}
+	second, _ := users.Reset("mayor/")
+	if users.Verify("mayor/", first) {
+		t.Error("old password still valid after reset")
+	}
+
+	reloaded, err := LoadUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reloaded.Verify("mayor/", second) || reloaded.Verify("mayor/", "") {
+		t.Error("reloaded users do not verify correctly")
+	}
+	if removed, _ := reloaded.Remove("mayor/"); !removed || len(reloaded.Identities()) != 0 {
+		t.Errorf("Remove: removed=%v identities=%v", removed, reloaded.Identities())
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, l := range lines {
+		if l == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,977 @@
+package mailbridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// IMAP limits and timings.
+const (
+	imapInbox         = "INBOX"
+	imapIdleTimeout   = 30 * time.Minute
+	maxIMAPLine       = 64 << 10
+	maxIMAPLiteral    = 64 << 10
+	imapCapabilities  = "IMAP4rev1 LITERAL+ IDLE UNSELECT ID AUTH=PLAIN"
+	imapFlagSeen      = `\Seen`
+	imapFlagFlagged   = `\Flagged`
+	imapFlagDeleted   = `\Deleted`
+	imapInternalDate  = "02-Jan-2006 15:04:05 -0700"
+	imapSearchDateFmt = "2-Jan-2006"
+)
+
+// idlePollInterval is how often an IDLE session checks for new mail.
+var idlePollInterval = 10 * time.Second
+
+// errLogout ends a session after LOGOUT.
+var errLogout = errors.New("logout")
+
+// imapSession is the state of one IMAP connection.
+type imapSession struct {
+	s        *Server
+	conn     net.Conn
+	r        *bufio.Reader
+	w        *bufio.Writer
+	identity string       // Authenticated identity
+	sel      *imapMailbox // Selected mailbox (nil = none)
+}
+
+// imapMailbox is a session's view of the selected INBOX. Sequence numbers
+// are indexes into entries plus one; entries stay sorted by UID.
+type imapMailbox struct {
+	readOnly bool
+	entries  []*imapEntry
+}
+
+// imapEntry is one message in the selected mailbox. \Deleted is session
+// state until EXPUNGE archives the message.
+type imapEntry struct {
+	uid      uint32
+	msg      *mail.Message
+	deleted  bool
+	rendered *renderedMessage
+}
+
+// serveIMAP handles one IMAP connection.
+func (s *Server) serveIMAP(conn net.Conn) {
+	c := &imapSession{
+		s:    s,
+		conn: conn,
+		r:    bufio.NewReaderSize(conn, maxIMAPLine),
+		w:    bufio.NewWriter(conn),
+	}
+	c.untagged("OK [CAPABILITY %s] Gas Town mail bridge ready", imapCapabilities)
+	c.flush()
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(imapIdleTimeout))
+		line, err := c.readCommand()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				c.untagged("BYE %v", err)
+				c.flush()
+			}
+			return
+		}
+		err = c.dispatch(line)
+		c.flush()
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads one command line, including any literals it contains.
+// Literals stay inline as "{n}\r\n<bytes>" for the parser.
+func (c *imapSession) readCommand() ([]byte, error) {
+	var buf []byte
+	for {
+		line, err := c.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			return nil, errors.New("command line too long")
+		}
+		if err != nil {
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		buf = append(buf, line...)
+
+		n, sync, ok := literalSuffix(line)
+		if !ok {
+			return buf, nil
+		}
+		if n > maxIMAPLiteral {
+			return nil, errors.New("literal too large")
+		}
+		if sync {
+			c.w.WriteString("+ Ready\r\n")
+			c.flush()
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return nil, err
+		}
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, data...)
+	}
+}
+
+// literalSuffix reports whether line ends with a literal announcement
+// "{n}" (synchronizing) or "{n+}" (LITERAL+).
+func literalSuffix(line []byte) (n int, sync bool, ok bool) {
+	if !bytes.HasSuffix(line, []byte("}")) {
+		return 0, false, false
+	}
+	open := bytes.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false, false
+	}
+	spec := string(line[open+1 : len(line)-1])
+	sync = !strings.HasSuffix(spec, "+")
+	n, err := strconv.Atoi(strings.TrimSuffix(spec, "+"))
+	if err != nil || n < 0 {
+		return 0, false, false
+	}
+	return n, sync, true
+}
+
+func (c *imapSession) untagged(format string, args ...interface{}) {
+	c.w.WriteString("* ")
+	fmt.Fprintf(c.w, format, args...)
+	c.w.WriteString("\r\n")
+}
+
+func (c *imapSession) tagged(tag, status, format string, args ...interface{}) {
+	fmt.Fprintf(c.w, "%s %s ", tag, status)
+	fmt.Fprintf(c.w, format, args...)
+	c.w.WriteString("\r\n")
+}
+
+func (c *imapSession) flush() { _ = c.w.Flush() }
+
+// dispatch parses and runs one command.
+func (c *imapSession) dispatch(line []byte) error {
+	p := &imapParser{b: line}
+	tagArg, err := p.next()
+	if err != nil || tagArg.isList || tagArg.s == "" {
+		c.untagged("BAD Missing tag")
+		return nil
+	}
+	tag := tagArg.s
+	cmdArg, err := p.next()
+	if err != nil || cmdArg.isList {
+		c.tagged(tag, "BAD", "Missing command")
+		return nil
+	}
+	args, err := p.rest()
+	if err != nil {
+		c.tagged(tag, "BAD", "%v", err)
+		return nil
+	}
+
+	cmd := strings.ToUpper(cmdArg.s)
+	uid := false
+	if cmd == "UID" {
+		if len(args) == 0 || args[0].isList {
+			c.tagged(tag, "BAD", "UID requires a command")
+			return nil
+		}
+		uid = true
+		cmd = strings.ToUpper(args[0].s)
+		args = args[1:]
+	}
+
+	switch cmd {
+	case "CAPABILITY":
+		c.untagged("CAPABILITY %s", imapCapabilities)
+		c.tagged(tag, "OK", "CAPABILITY completed")
+		return nil
+	case "NOOP", "CHECK":
+		if c.sel != nil {
+			if err := c.refresh(); err != nil {
+				c.tagged(tag, "NO", "%v", err)
+				return nil
+			}
+		}
+		c.tagged(tag, "OK", "%s completed", cmd)
+		return nil
+	case "LOGOUT":
+		c.untagged("BYE Logging out")
+		c.tagged(tag, "OK", "LOGOUT completed")
+		return errLogout
+	case "ID":
+		c.untagged("ID NIL")
+		c.tagged(tag, "OK", "ID completed")
+		return nil
+	case "STARTTLS":
+		c.tagged(tag, "NO", "TLS is not available on the localhost bridge")
+		return nil
+	case "LOGIN":
+		c.login(tag, args)
+		return nil
+	case "AUTHENTICATE":
+		return c.authenticate(tag, args)
+	}
+
+	if c.identity == "" {
+		c.tagged(tag, "NO", "Not authenticated")
+		return nil
+	}
+
+	switch cmd {
+	case "SELECT", "EXAMINE":
+		c.selectInbox(tag, cmd, args)
+	case "LIST", "LSUB":
+		c.list(tag, cmd, args)
+	case "STATUS":
+		c.status(tag, args)
+	case "SUBSCRIBE", "UNSUBSCRIBE":
+		c.tagged(tag, "OK", "%s completed", cmd)
+	case "CREATE", "DELETE", "RENAME", "APPEND":
+		c.tagged(tag, "NO", "Only INBOX is available; send mail over SMTP")
+	case "IDLE":
+		return c.idle(tag)
+	default:
+		if c.sel == nil {
+			c.tagged(tag, "BAD", "Unknown command or no mailbox selected")
+			return nil
+		}
+		c.selectedCommand(tag, cmd, uid, args)
+	}
+	return nil
+}
+
+// selectedCommand runs commands valid only in the selected state.
+func (c *imapSession) selectedCommand(tag, cmd string, uid bool, args []imapArg) {
+	switch cmd {
+	case "CLOSE":
+		if !c.sel.readOnly {
+			_ = c.expunge(false)
+		}
+		c.sel = nil
+		c.tagged(tag, "OK", "CLOSE completed")
+	case "UNSELECT":
+		c.sel = nil
+		c.tagged(tag, "OK", "UNSELECT completed")
+	case "EXPUNGE":
+		if uid {
+			c.tagged(tag, "BAD", "UID EXPUNGE is not supported")
+			return
+		}
+		if c.sel.readOnly {
+			c.tagged(tag, "NO", "Mailbox is read-only")
+			return
+		}
+		if err := c.expunge(true); err != nil {
+			c.tagged(tag, "NO", "%v", err)
+			return
+		}
+		c.tagged(tag, "OK", "EXPUNGE completed")
+	case "SEARCH":
+		c.search(tag, uid, args)
+	case "FETCH":
+		c.fetch(tag, uid, args)
+	case "STORE":
+		c.store(tag, uid, args)
+	case "COPY", "MOVE":
+		c.tagged(tag, "NO", "Only INBOX is available")
+	default:
+		c.tagged(tag, "BAD", "Unknown command %s", cmd)
+	}
+}
+
+func (c *imapSession) login(tag string, args []imapArg) {
+	if c.identity != "" {
+		c.tagged(tag, "BAD", "Already authenticated")
+		return
+	}
+	if len(args) != 2 || args[0].isList || args[1].isList {
+		c.tagged(tag, "BAD", "LOGIN requires user and password")
+		return
+	}
+	c.finishAuth(tag, args[0].s, args[1].s)
+}
+
+func (c *imapSession) authenticate(tag string, args []imapArg) error {
+	if c.identity != "" {
+		c.tagged(tag, "BAD", "Already authenticated")
+		return nil
+	}
+	if len(args) == 0 || !strings.EqualFold(args[0].s, "PLAIN") {
+		c.tagged(tag, "NO", "Unsupported authentication mechanism")
+		return nil
+	}
+	var resp string
+	if len(args) > 1 {
+		resp = args[1].s
+	} else {
+		c.w.WriteString("+ \r\n")
+		c.flush()
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		resp = strings.TrimRight(line, "\r\n")
+	}
+	if resp == "*" {
+		c.tagged(tag, "BAD", "Authentication cancelled")
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(resp)
+	fields := bytes.Split(decoded, []byte{0})
+	if err != nil || len(fields) != 3 {
+		c.tagged(tag, "BAD", "Malformed AUTHENTICATE PLAIN response")
+		return nil
+	}
+	c.finishAuth(tag, string(fields[1]), string(fields[2]))
+	return nil
+}
+
+func (c *imapSession) finishAuth(tag, user, password string) {
+	identity, ok := c.s.authenticate(user, password)
+	if !ok {
+		c.s.logf("imap: failed login for %q from %s", user, c.conn.RemoteAddr())
+		c.tagged(tag, "NO", "[AUTHENTICATIONFAILED] Invalid credentials")
+		return
+	}
+	c.identity = identity
+	c.s.logf("imap: %s logged in", identity)
+	c.tagged(tag, "OK", "[CAPABILITY %s] Logged in as %s", imapCapabilities, EmailAddress(identity, c.s.cfg.Domain))
+}
+
+// loadInbox lists the inbox in UID order.
+func (c *imapSession) loadInbox() ([]*imapEntry, error) {
+	msgs, err := c.s.cfg.Backend.List(c.identity)
+	if err != nil {
+		return nil, fmt.Errorf("listing inbox: %w", err)
+	}
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp.Before(msgs[j].Timestamp) })
+	uids := c.s.uids.assign(c.identity, msgs)
+	entries := make([]*imapEntry, len(msgs))
+	for i, msg := range msgs {
+		entries[i] = &imapEntry{uid: uids[i], msg: msg}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].uid < entries[j].uid })
+	return entries, nil
+}
+
+func (c *imapSession) selectInbox(tag, cmd string, args []imapArg) {
+	c.sel = nil
+	if len(args) != 1 || !strings.EqualFold(args[0].s, imapInbox) {
+		c.tagged(tag, "NO", "Mailbox does not exist (only INBOX is available)")
+		return
+	}
+	entries, err := c.loadInbox()
+	if err != nil {
+		c.tagged(tag, "NO", "%v", err)
+		return
+	}
+	c.sel = &imapMailbox{readOnly: cmd == "EXAMINE", entries: entries}
+
+	c.untagged(`FLAGS (\Seen \Flagged \Deleted)`)
+	c.untagged(`OK [PERMANENTFLAGS (\Seen \Flagged \Deleted)] Flags permitted`)
+	c.untagged("%d EXISTS", len(entries))
+	c.untagged("0 RECENT")
+	for i, e := range entries {
+		if !e.msg.Read {
+			c.untagged("OK [UNSEEN %d] First unseen", i+1)
+			break
+		}
+	}
+	c.untagged("OK [UIDVALIDITY %d] UIDs valid", c.s.uids.validity)
+	c.untagged("OK [UIDNEXT %d] Predicted next UID", c.s.uids.uidNext(c.identity))
+	mode := "READ-WRITE"
+	if c.sel.readOnly {
+		mode = "READ-ONLY"
+	}
+	c.tagged(tag, "OK", "[%s] %s completed", mode, cmd)
+}
+
+func (c *imapSession) list(tag, cmd string, args []imapArg) {
+	if len(args) != 2 {
+		c.tagged(tag, "BAD", "%s requires reference and pattern", cmd)
+		return
+	}
+	pattern := args[1].s
+	if pattern == "" {
+		c.untagged(`%s (\Noselect) "/" ""`, cmd)
+	} else if listMatch(strings.ToUpper(pattern), imapInbox) {
+		c.untagged(`%s (\HasNoChildren) "/" INBOX`, cmd)
+	}
+	c.tagged(tag, "OK", "%s completed", cmd)
+}
+
+// listMatch matches a LIST pattern, where '*' and '%' match any characters.
+func listMatch(pattern, name string) bool {
+	return globMatch(strings.ReplaceAll(pattern, "%", "*"), name)
+}
+
+// globMatch matches s against pattern, where '*' matches any run of characters.
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == '*' {
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if s == "" || s[0] != pattern[0] {
+			return false
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
+
+func (c *imapSession) status(tag string, args []imapArg) {
+	if len(args) != 2 || !args[1].isList {
+		c.tagged(tag, "BAD", "STATUS requires mailbox and item list")
+		return
+	}
+	if !strings.EqualFold(args[0].s, imapInbox) {
+		c.tagged(tag, "NO", "Mailbox does not exist")
+		return
+	}
+	entries, err := c.loadInbox()
+	if err != nil {
+		c.tagged(tag, "NO", "%v", err)
+		return
+	}
+	var items []string
+	for _, item := range args[1].list {
+		name := strings.ToUpper(item.s)
+		switch name {
+		case "MESSAGES":
+			items = append(items, fmt.Sprintf("MESSAGES %d", len(entries)))
+		case "RECENT":
+			items = append(items, "RECENT 0")
+		case "UIDNEXT":
+			items = append(items, fmt.Sprintf("UIDNEXT %d", c.s.uids.uidNext(c.identity)))
+		case "UIDVALIDITY":
+			items = append(items, fmt.Sprintf("UIDVALIDITY %d", c.s.uids.validity))
+		case "UNSEEN":
+			unseen := 0
+			for _, e := range entries {
+				if !e.msg.Read {
+					unseen++
+				}
+			}
+			items = append(items, fmt.Sprintf("UNSEEN %d", unseen))
+		default:
+			c.tagged(tag, "BAD", "Unknown STATUS item %s", item.s)
+			return
+		}
+	}
+	c.untagged("STATUS INBOX (%s)", strings.Join(items, " "))
+	c.tagged(tag, "OK", "STATUS completed")
+}
+
+// refresh reloads the inbox and reports expunged, changed and new messages.
+func (c *imapSession) refresh() error {
+	fresh, err := c.loadInbox()
+	if err != nil {
+		return err
+	}
+	byUID := make(map[uint32]*mail.Message, len(fresh))
+	for _, e := range fresh {
+		byUID[e.uid] = e.msg
+	}
+
+	// Expunge from the highest sequence number down so numbers stay valid.
+	for i := len(c.sel.entries) - 1; i >= 0; i-- {
+		if _, ok := byUID[c.sel.entries[i].uid]; !ok {
+			c.untagged("%d EXPUNGE", i+1)
+			c.sel.entries = append(c.sel.entries[:i], c.sel.entries[i+1:]...)
+		}
+	}
+
+	known := make(map[uint32]bool, len(c.sel.entries))
+	for i, e := range c.sel.entries {
+		known[e.uid] = true
+		msg := byUID[e.uid]
+		before := e.flags()
+		e.msg = msg
+		e.rendered = nil
+		if after := e.flags(); after != before {
+			c.untagged("%d FETCH (FLAGS (%s))", i+1, after)
+		}
+	}
+
+	added := false
+	for _, e := range fresh {
+		if !known[e.uid] {
+			c.sel.entries = append(c.sel.entries, e)
+			added = true
+		}
+	}
+	if added {
+		c.untagged("%d EXISTS", len(c.sel.entries))
+	}
+	return nil
+}
+
+// flags renders the entry's IMAP flags.
+func (e *imapEntry) flags() string {
+	var flags []string
+	if e.msg.Read {
+		flags = append(flags, imapFlagSeen)
+	}
+	if e.msg.Pinned {
+		flags = append(flags, imapFlagFlagged)
+	}
+	if e.deleted {
+		flags = append(flags, imapFlagDeleted)
+	}
+	return strings.Join(flags, " ")
+}
+
+// expunge archives every \Deleted message. With report, it sends EXPUNGE
+// responses (CLOSE expunges silently).
+func (c *imapSession) expunge(report bool) error {
+	for i := len(c.sel.entries) - 1; i >= 0; i-- {
+		e := c.sel.entries[i]
+		if !e.deleted {
+			continue
+		}
+		if err := c.s.cfg.Backend.Archive(c.identity, e.msg.ID); err != nil {
+			return fmt.Errorf("archiving %s: %w", e.msg.ID, err)
+		}
+		c.sel.entries = append(c.sel.entries[:i], c.sel.entries[i+1:]...)
+		if report {
+			c.untagged("%d EXPUNGE", i+1)
+		}
+	}
+	return nil
+}
+
+// resolveSet returns the sequence indexes (0-based) matched by a sequence
+// set, interpreted as UIDs when uid is set.
+func (c *imapSession) resolveSet(set string, uid bool) ([]int, error) {
+	ranges, err := parseSeqSet(set)
+	if err != nil {
+		return nil, err
+	}
+	entries := c.sel.entries
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	var max uint32 = uint32(len(entries))
+	if uid {
+		max = entries[len(entries)-1].uid
+	}
+	var out []int
+	for i, e := range entries {
+		n := uint32(i + 1)
+		if uid {
+			n = e.uid
+		}
+		for _, r := range ranges {
+			if r.contains(n, max) {
+				out = append(out, i)
+				break
+			}
+		}
+	}
+	if !uid {
+		for _, r := range ranges {
+			if r.lo > max && r.lo != 0 || r.hi > max && r.hi != 0 {
+				return nil, fmt.Errorf("invalid message sequence number")
+			}
+		}
+	}
+	return out, nil
+}
+
+// seqRange is an inclusive range in a sequence set; 0 stands for '*'.
+type seqRange struct{ lo, hi uint32 }
+
+func (r seqRange) contains(n, max uint32) bool {
+	lo, hi := r.lo, r.hi
+	if lo == 0 {
+		lo = max
+	}
+	if hi == 0 {
+		hi = max
+	}
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return n >= lo && n <= hi
+}
+
+// parseSeqSet parses an IMAP sequence set such as "1,3:5,7:*".
+func parseSeqSet(set string) ([]seqRange, error) {
+	if set == "" {
+		return nil, errors.New("empty sequence set")
+	}
+	parseNum := func(s string) (uint32, error) {
+		if s == "*" {
+			return 0, nil
+		}
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("invalid sequence number %q", s)
+		}
+		return uint32(n), nil
+	}
+	var out []seqRange
+	for _, part := range strings.Split(set, ",") {
+		loStr, hiStr, isRange := strings.Cut(part, ":")
+		lo, err := parseNum(loStr)
+		if err != nil {
+			return nil, err
+		}
+		hi := lo
+		if isRange {
+			if hi, err = parseNum(hiStr); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, seqRange{lo, hi})
+	}
+	return out, nil
+}
+
+func (c *imapSession) store(tag string, uid bool, args []imapArg) {
+	if c.sel.readOnly {
+		c.tagged(tag, "NO", "Mailbox is read-only")
+		return
+	}
+	if len(args) < 3 {
+		c.tagged(tag, "BAD", "STORE requires set, item and flags")
+		return
+	}
+	idx, err := c.resolveSet(args[0].s, uid)
+	if err != nil {
+		c.tagged(tag, "BAD", "%v", err)
+		return
+	}
+	item := strings.ToUpper(args[1].s)
+	silent := strings.HasSuffix(item, ".SILENT")
+	item = strings.TrimSuffix(item, ".SILENT")
+	if item != "FLAGS" && item != "+FLAGS" && item != "-FLAGS" {
+		c.tagged(tag, "BAD", "Unknown STORE item %s", args[1].s)
+		return
+	}
+	var flags []string
+	for _, a := range args[2:] {
+		if a.isList {
+			for _, f := range a.list {
+				flags = append(flags, f.s)
+			}
+		} else {
+			flags = append(flags, a.s)
+		}
+	}
+	has := func(name string) bool {
+		for _, f := range flags {
+			if strings.EqualFold(f, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	backend := c.s.cfg.Backend
+	for _, i := range idx {
+		e := c.sel.entries[i]
+		seen, flagged, deleted := e.msg.Read, e.msg.Pinned, e.deleted
+		switch item {
+		case "FLAGS":
+			seen, flagged, deleted = has(imapFlagSeen), has(imapFlagFlagged), has(imapFlagDeleted)
+		case "+FLAGS":
+			seen, flagged, deleted = seen || has(imapFlagSeen), flagged || has(imapFlagFlagged), deleted || has(imapFlagDeleted)
+		case "-FLAGS":
+			seen, flagged, deleted = seen && !has(imapFlagSeen), flagged && !has(imapFlagFlagged), deleted && !has(imapFlagDeleted)
+		}
+		if seen != e.msg.Read {
+			if err := backend.SetSeen(c.identity, e.msg.ID, seen); err != nil {
+				c.tagged(tag, "NO", "Updating %s: %v", e.msg.ID, err)
+				return
+			}
+			e.msg.Read = seen
+		}
+		if flagged != e.msg.Pinned {
+			if err := backend.SetPinned(c.identity, e.msg.ID, flagged); err != nil {
+				c.tagged(tag, "NO", "Updating %s: %v", e.msg.ID, err)
+				return
+			}
+			e.msg.Pinned = flagged
+		}
+		e.deleted = deleted
+		if !silent {
+			if uid {
+				c.untagged("%d FETCH (FLAGS (%s) UID %d)", i+1, e.flags(), e.uid)
+			} else {
+				c.untagged("%d FETCH (FLAGS (%s))", i+1, e.flags())
+			}
+		}
+	}
+	c.tagged(tag, "OK", "STORE completed")
+}
+
+func (c *imapSession) search(tag string, uid bool, args []imapArg) {
+	if len(args) >= 2 && strings.EqualFold(args[0].s, "CHARSET") {
+		args = args[2:]
+	}
+	if len(args) == 0 {
+		c.tagged(tag, "BAD", "SEARCH requires criteria")
+		return
+	}
+	var hits []string
+	for i, e := range c.sel.entries {
+		ok, err := c.matchAll(args, i, e)
+		if err != nil {
+			c.tagged(tag, "BAD", "%v", err)
+			return
+		}
+		if ok {
+			if uid {
+				hits = append(hits, strconv.FormatUint(uint64(e.uid), 10))
+			} else {
+				hits = append(hits, strconv.Itoa(i+1))
+			}
+		}
+	}
+	if len(hits) == 0 {
+		c.untagged("SEARCH")
+	} else {
+		c.untagged("SEARCH %s", strings.Join(hits, " "))
+	}
+	c.tagged(tag, "OK", "SEARCH completed")
+}
+
+// matchAll reports whether entry i satisfies every criterion in keys.
+func (c *imapSession) matchAll(keys []imapArg, i int, e *imapEntry) (bool, error) {
+	for len(keys) > 0 {
+		ok, rest, err := c.matchKey(keys, i, e)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+		keys = rest
+	}
+	return true, nil
+}
+
+// matchKey evaluates the first search key and returns the unconsumed keys.
+func (c *imapSession) matchKey(keys []imapArg, i int, e *imapEntry) (bool, []imapArg, error) {
+	key := keys[0]
+	keys = keys[1:]
+	if key.isList {
+		ok, err := c.matchAll(key.list, i, e)
+		return ok, keys, err
+	}
+	arg := func() (string, error) {
+		if len(keys) == 0 {
+			return "", fmt.Errorf("search key %s requires an argument", key.s)
+		}
+		v := keys[0].s
+		keys = keys[1:]
+		return v, nil
+	}
+	contains := func(haystack string) (bool, error) {
+		needle, err := arg()
+		return strings.Contains(strings.ToLower(haystack), strings.ToLower(needle)), err
+	}
+	date := func() (time.Time, error) {
+		v, err := arg()
+		if err != nil {
+			return time.Time{}, err
+		}
+		t, err := time.Parse(imapSearchDateFmt, strings.Trim(v, `"`))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid search date %q", v)
+		}
+		return t, nil
+	}
+	msg := e.msg
+	day := func() time.Time {
+		y, m, d := msg.Timestamp.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	switch name := strings.ToUpper(key.s); name {
+	case "ALL", "OLD", "UNANSWERED", "UNDRAFT":
+		return true, keys, nil
+	case "NEW", "RECENT", "ANSWERED", "DRAFT":
+		return false, keys, nil
+	case "SEEN":
+		return msg.Read, keys, nil
+	case "UNSEEN":
+		return !msg.Read, keys, nil
+	case "FLAGGED":
+		return msg.Pinned, keys, nil
+	case "UNFLAGGED":
+		return !msg.Pinned, keys, nil
+	case "DELETED":
+		return e.deleted, keys, nil
+	case "UNDELETED":
+		return !e.deleted, keys, nil
+	case "KEYWORD":
+		_, err := arg()
+		return false, keys, err
+	case "UNKEYWORD":
+		_, err := arg()
+		return true, keys, err
+	case "FROM":
+		ok, err := contains(msg.From + " " + EmailAddress(msg.From, c.s.cfg.Domain))
+		return ok, keys, err
+	case "TO":
+		ok, err := contains(msg.To + " " + EmailAddress(msg.To, c.s.cfg.Domain))
+		return ok, keys, err
+	case "CC":
+		ok, err := contains(strings.Join(msg.CC, " "))
+		return ok, keys, err
+	case "SUBJECT":
+		ok, err := contains(msg.Subject)
+		return ok, keys, err
+	case "BODY":
+		ok, err := contains(msg.Body)
+		return ok, keys, err
+	case "TEXT":
+		ok, err := contains(msg.Subject + "\n" + msg.From + "\n" + msg.Body)
+		return ok, keys, err
+	case "HEADER":
+		if _, err := arg(); err != nil {
+			return false, keys, err
+		}
+		r, err := c.render(e)
+		if err != nil {
+			return false, keys, err
+		}
+		ok, err := contains(string(r.header))
+		return ok, keys, err
+	case "BEFORE", "SENTBEFORE":
+		t, err := date()
+		return day().Before(t), keys, err
+	case "ON", "SENTON":
+		t, err := date()
+		return day().Equal(t), keys, err
+	case "SINCE", "SENTSINCE":
+		t, err := date()
+		return !day().Before(t), keys, err
+	case "LARGER", "SMALLER":
+		v, err := arg()
+		if err != nil {
+			return false, keys, err
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false, keys, fmt.Errorf("invalid size %q", v)
+		}
+		r, err := c.render(e)
+		if err != nil {
+			return false, keys, err
+		}
+		size := len(r.header) + len(r.body)
+		if name == "LARGER" {
+			return size > n, keys, nil
+		}
+		return size < n, keys, nil
+	case "UID":
+		v, err := arg()
+		if err != nil {
+			return false, keys, err
+		}
+		return c.inSet(v, true, i), keys, nil
+	case "NOT":
+		if len(keys) == 0 {
+			return false, keys, errors.New("NOT requires a search key")
+		}
+		ok, rest, err := c.matchKey(keys, i, e)
+		return !ok, rest, err
+	case "OR":
+		if len(keys) < 2 {
+			return false, keys, errors.New("OR requires two search keys")
+		}
+		a, rest, err := c.matchKey(keys, i, e)
+		if err != nil || len(rest) == 0 {
+			return false, rest, errors.New("OR requires two search keys")
+		}
+		b, rest, err := c.matchKey(rest, i, e)
+		return a || b, rest, err
+	default:
+		if _, err := parseSeqSet(key.s); err == nil {
+			return c.inSet(key.s, false, i), keys, nil
+		}
+		return false, keys, fmt.Errorf("unknown search key %s", key.s)
+	}
+}
+
+// inSet reports whether entry i is in a sequence (or UID) set.
+func (c *imapSession) inSet(set string, uid bool, i int) bool {
+	idx, err := c.resolveSet(set, uid)
+	if err != nil {
+		return false
+	}
+	for _, j := range idx {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
+
+// render returns the cached RFC 5322 form of an entry.
+func (c *imapSession) render(e *imapEntry) (*renderedMessage, error) {
+	if e.rendered == nil {
+		r, err := renderMessage(e.msg, c.s.cfg.Domain, c.s.cfg.Backend)
+		if err != nil {
+			return nil, err
+		}
+		e.rendered = r
+	}
+	return e.rendered, nil
+}
+
+func (c *imapSession) idle(tag string) error {
+	c.w.WriteString("+ idling\r\n")
+	c.flush()
+
+	done := make(chan error, 1)
+	go func() {
+		line, err := c.r.ReadString('\n')
+		if err == nil && !strings.EqualFold(strings.TrimSpace(line), "DONE") {
+			err = fmt.Errorf("expected DONE, got %q", strings.TrimSpace(line))
+		}
+		done <- err
+	}()
+
+	ticker := time.NewTicker(idlePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				return err
+			}
+			c.tagged(tag, "OK", "IDLE terminated")
+			return nil
+		case <-ticker.C:
+			if c.sel != nil {
+				if err := c.refresh(); err != nil {
+					c.s.logf("imap: idle refresh for %s: %v", c.identity, err)
+				}
+				c.flush()
+			}
+		}
+	}
+}
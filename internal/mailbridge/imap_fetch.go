@@ -0,0 +1,382 @@
+package mailbridge
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// fetchItem is one parsed FETCH data item.
+type fetchItem struct {
+	name    string // Upper-cased item name, e.g. "FLAGS" or "BODY"
+	section string // Section spec for BODY[...] items
+	hasBody bool   // BODY[...] rather than BODY (the structure)
+	peek    bool   // BODY.PEEK: do not set \Seen
+	partial bool
+	offset  int
+	length  int // -1 = to the end
+}
+
+// fetchMacros expands the FETCH shorthand items.
+var fetchMacros = map[string][]string{
+	"ALL":  {"FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE"},
+	"FAST": {"FLAGS", "INTERNALDATE", "RFC822.SIZE"},
+	"FULL": {"FLAGS", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE", "BODY"},
+}
+
+// parseFetchItems parses the item argument of FETCH.
+func parseFetchItems(arg imapArg) ([]fetchItem, error) {
+	var names []string
+	if arg.isList {
+		for _, a := range arg.list {
+			names = append(names, a.s)
+		}
+	} else if expanded, ok := fetchMacros[strings.ToUpper(arg.s)]; ok {
+		names = expanded
+	} else {
+		names = []string{arg.s}
+	}
+
+	items := make([]fetchItem, 0, len(names))
+	for _, name := range names {
+		item, err := parseFetchItem(name)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func parseFetchItem(name string) (fetchItem, error) {
+	upper := strings.ToUpper(name)
+	open := strings.IndexByte(upper, '[')
+	if open < 0 {
+		switch upper {
+		case "FLAGS", "UID", "INTERNALDATE", "RFC822.SIZE", "ENVELOPE",
+			"BODYSTRUCTURE", "BODY", "RFC822", "RFC822.HEADER", "RFC822.TEXT":
+			return fetchItem{name: upper}, nil
+		}
+		return fetchItem{}, fmt.Errorf("unknown FETCH item %s", name)
+	}
+
+	item := fetchItem{name: "BODY", hasBody: true, length: -1}
+	switch upper[:open] {
+	case "BODY":
+	case "BODY.PEEK":
+		item.peek = true
+	default:
+		return fetchItem{}, fmt.Errorf("unknown FETCH item %s", name)
+	}
+	closing := strings.LastIndexByte(upper, ']')
+	if closing < open {
+		return fetchItem{}, fmt.Errorf("malformed section in %s", name)
+	}
+	item.section = strings.TrimSpace(upper[open+1 : closing])
+
+	if partial := upper[closing+1:]; partial != "" {
+		if !strings.HasPrefix(partial, "<") || !strings.HasSuffix(partial, ">") {
+			return fetchItem{}, fmt.Errorf("malformed partial in %s", name)
+		}
+		offStr, lenStr, hasLen := strings.Cut(partial[1:len(partial)-1], ".")
+		off, err := strconv.Atoi(offStr)
+		if err != nil || off < 0 {
+			return fetchItem{}, fmt.Errorf("malformed partial in %s", name)
+		}
+		item.partial, item.offset = true, off
+		if hasLen {
+			n, err := strconv.Atoi(lenStr)
+			if err != nil || n < 0 {
+				return fetchItem{}, fmt.Errorf("malformed partial in %s", name)
+			}
+			item.length = n
+		}
+	}
+	return item, nil
+}
+
+// setsSeen reports whether fetching the item implicitly sets \Seen.
+func (f fetchItem) setsSeen() bool {
+	switch f.name {
+	case "RFC822", "RFC822.TEXT":
+		return true
+	case "BODY":
+		return f.hasBody && !f.peek
+	}
+	return false
+}
+
+func (c *imapSession) fetch(tag string, uid bool, args []imapArg) {
+	if len(args) != 2 {
+		c.tagged(tag, "BAD", "FETCH requires a set and items")
+		return
+	}
+	idx, err := c.resolveSet(args[0].s, uid)
+	if err != nil {
+		c.tagged(tag, "BAD", "%v", err)
+		return
+	}
+	items, err := parseFetchItems(args[1])
+	if err != nil {
+		c.tagged(tag, "BAD", "%v", err)
+		return
+	}
+
+	for _, i := range idx {
+		e := c.sel.entries[i]
+		resp, err := c.fetchEntry(e, items, uid)
+		if err != nil {
+			c.tagged(tag, "NO", "Fetching %s: %v", e.msg.ID, err)
+			return
+		}
+		c.untagged("%d FETCH (%s)", i+1, strings.Join(resp, " "))
+	}
+	c.tagged(tag, "OK", "FETCH completed")
+}
+
+// fetchEntry renders the FETCH response items for one message.
+func (c *imapSession) fetchEntry(e *imapEntry, items []fetchItem, uid bool) ([]string, error) {
+	hasFlags, hasUID, markSeen := false, false, false
+	for _, item := range items {
+		hasFlags = hasFlags || item.name == "FLAGS"
+		hasUID = hasUID || item.name == "UID"
+		markSeen = markSeen || item.setsSeen()
+	}
+	if markSeen && !e.msg.Read && !c.sel.readOnly {
+		if err := c.s.cfg.Backend.SetSeen(c.identity, e.msg.ID, true); err != nil {
+			return nil, err
+		}
+		e.msg.Read = true
+		if !hasFlags {
+			// Unsolicited flag change, reported with the fetch.
+			items = append(items, fetchItem{name: "FLAGS"})
+		}
+	}
+
+	var resp []string
+	if uid && !hasUID {
+		resp = append(resp, fmt.Sprintf("UID %d", e.uid))
+	}
+	for _, item := range items {
+		switch item.name {
+		case "FLAGS":
+			resp = append(resp, fmt.Sprintf("FLAGS (%s)", e.flags()))
+		case "UID":
+			resp = append(resp, fmt.Sprintf("UID %d", e.uid))
+		case "INTERNALDATE":
+			resp = append(resp, "INTERNALDATE "+imapQuote(e.msg.Timestamp.Format(imapInternalDate)))
+		case "RFC822.SIZE":
+			r, err := c.render(e)
+			if err != nil {
+				return nil, err
+			}
+			resp = append(resp, fmt.Sprintf("RFC822.SIZE %d", len(r.header)+len(r.body)))
+		case "ENVELOPE":
+			resp = append(resp, "ENVELOPE "+envelope(e.msg, c.s.cfg.Domain))
+		case "BODYSTRUCTURE", "BODY":
+			r, err := c.render(e)
+			if err != nil {
+				return nil, err
+			}
+			if item.hasBody {
+				resp = append(resp, bodySectionResponse(r, item))
+			} else {
+				resp = append(resp, item.name+" "+bodyStructure(r, item.name == "BODYSTRUCTURE"))
+			}
+		case "RFC822", "RFC822.HEADER", "RFC822.TEXT":
+			r, err := c.render(e)
+			if err != nil {
+				return nil, err
+			}
+			data := r.full()
+			if item.name == "RFC822.HEADER" {
+				data = r.header
+			} else if item.name == "RFC822.TEXT" {
+				data = r.body
+			}
+			resp = append(resp, item.name+" "+literal(data))
+		}
+	}
+	return resp, nil
+}
+
+// literal renders data as an IMAP literal.
+func literal(data []byte) string {
+	return fmt.Sprintf("{%d}\r\n%s", len(data), data)
+}
+
+// bodySectionResponse renders a BODY[section]<partial> response item.
+func bodySectionResponse(r *renderedMessage, item fetchItem) string {
+	data := sectionData(r, item.section)
+	name := "BODY[" + item.section + "]"
+	if item.partial {
+		name += fmt.Sprintf("<%d>", item.offset)
+		if item.offset > len(data) {
+			data = nil
+		} else {
+			data = data[item.offset:]
+		}
+		if item.length >= 0 && item.length < len(data) {
+			data = data[:item.length]
+		}
+	}
+	return name + " " + literal(data)
+}
+
+// sectionData returns the content of a body section. Unknown sections are
+// empty, as RFC 3501 allows.
+func sectionData(r *renderedMessage, section string) []byte {
+	switch {
+	case section == "":
+		return r.full()
+	case section == "HEADER":
+		return r.header
+	case section == "TEXT":
+		return r.body
+	case strings.HasPrefix(section, "HEADER.FIELDS"):
+		not := strings.HasPrefix(section, "HEADER.FIELDS.NOT")
+		open, closing := strings.IndexByte(section, '('), strings.LastIndexByte(section, ')')
+		if open < 0 || closing < open {
+			return nil
+		}
+		return filterHeader(r.header, strings.Fields(section[open+1:closing]), not)
+	}
+
+	numStr, sub, _ := strings.Cut(section, ".")
+	n, err := strconv.Atoi(numStr)
+	if err != nil || n < 1 || n > len(r.parts) {
+		return nil
+	}
+	part := r.parts[n-1]
+	switch sub {
+	case "":
+		return part.body
+	case "MIME":
+		if r.boundary == "" {
+			return filterHeader(r.header, []string{"Content-Type", "Content-Transfer-Encoding"}, false)
+		}
+		return part.header
+	}
+	return nil
+}
+
+// filterHeader keeps (or, with not, drops) the named header fields.
+func filterHeader(header []byte, names []string, not bool) []byte {
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[strings.ToLower(strings.Trim(n, `"`))] = true
+	}
+	var out bytes.Buffer
+	keep := false
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "\r\n" || line == "" {
+			break
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			name, _, _ := strings.Cut(line, ":")
+			keep = want[strings.ToLower(strings.TrimSpace(name))] != not
+		}
+		if keep {
+			out.WriteString(line)
+		}
+	}
+	out.WriteString("\r\n")
+	return out.Bytes()
+}
+
+// envelope renders the ENVELOPE structure of a message.
+func envelope(msg *mail.Message, domain string) string {
+	addrList := func(addrs ...string) string {
+		var parts []string
+		for _, a := range addrs {
+			if a == "" {
+				continue
+			}
+			local, host, _ := strings.Cut(EmailAddress(a, domain), "@")
+			parts = append(parts, fmt.Sprintf("(NIL NIL %s %s)", imapQuote(local), imapQuote(host)))
+		}
+		if len(parts) == 0 {
+			return "NIL"
+		}
+		return "(" + strings.Join(parts, "") + ")"
+	}
+	from := addrList(msg.From)
+	inReplyTo := ""
+	if msg.ReplyTo != "" {
+		inReplyTo = messageID(msg.ReplyTo, domain)
+	}
+	return "(" + strings.Join([]string{
+		imapQuote(msg.Timestamp.Format("Mon, 02 Jan 2006 15:04:05 -0700")),
+		imapNString(msg.Subject),
+		from, from, from,
+		addrList(msg.To),
+		addrList(msg.CC...),
+		"NIL",
+		imapNString(inReplyTo),
+		imapNString(messageID(msg.ID, domain)),
+	}, " ") + ")"
+}
+
+// bodyStructure renders BODY or, with ext, BODYSTRUCTURE.
+func bodyStructure(r *renderedMessage, ext bool) string {
+	if r.boundary == "" {
+		return partStructure(r.parts[0], ext)
+	}
+	var sb strings.Builder
+	sb.WriteString("(")
+	for _, p := range r.parts {
+		sb.WriteString(partStructure(p, ext))
+	}
+	sb.WriteString(` "MIXED"`)
+	if ext {
+		sb.WriteString(` ("BOUNDARY" ` + imapQuote(r.boundary) + `) NIL NIL`)
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// partStructure renders the structure of one non-multipart body part.
+func partStructure(p renderedPart, ext bool) string {
+	typ, subtype, _ := strings.Cut(p.mediaType, "/")
+	fields := []string{
+		imapQuote(strings.ToUpper(typ)),
+		imapQuote(strings.ToUpper(subtype)),
+		paramList(p.params),
+		"NIL",
+		"NIL",
+		imapQuote(strings.ToUpper(p.encoding)),
+		strconv.Itoa(len(p.body)),
+	}
+	if strings.EqualFold(typ, "text") {
+		fields = append(fields, strconv.Itoa(bytes.Count(p.body, []byte("\r\n"))))
+	}
+	if ext {
+		disposition := "NIL"
+		if p.filename != "" {
+			disposition = `("ATTACHMENT" ("FILENAME" ` + imapQuote(p.filename) + `))`
+		}
+		fields = append(fields, "NIL", disposition, "NIL")
+	}
+	return "(" + strings.Join(fields, " ") + ")"
+}
+
+// paramList renders MIME parameters as an IMAP parameter list.
+func paramList(params map[string]string) string {
+	if len(params) == 0 {
+		return "NIL"
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, imapQuote(strings.ToUpper(k))+" "+imapQuote(params[k]))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
@@ -0,0 +1,162 @@
+package mailbridge
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// imapArg is one parsed IMAP command argument: an atom, a string (quoted or
+// literal) or a parenthesized list.
+type imapArg struct {
+	s        string
+	list     []imapArg
+	isList   bool
+	isString bool
+}
+
+// imapParser tokenizes a command line read by readCommand.
+type imapParser struct {
+	b []byte
+	i int
+}
+
+func (p *imapParser) skipSpace() {
+	for p.i < len(p.b) && p.b[p.i] == ' ' {
+		p.i++
+	}
+}
+
+// rest parses all remaining arguments.
+func (p *imapParser) rest() ([]imapArg, error) {
+	var args []imapArg
+	for {
+		p.skipSpace()
+		if p.i >= len(p.b) {
+			return args, nil
+		}
+		a, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, a)
+	}
+}
+
+// next parses one argument.
+func (p *imapParser) next() (imapArg, error) {
+	p.skipSpace()
+	if p.i >= len(p.b) {
+		return imapArg{}, errors.New("unexpected end of command")
+	}
+	switch p.b[p.i] {
+	case '(':
+		p.i++
+		var list []imapArg
+		for {
+			p.skipSpace()
+			if p.i >= len(p.b) {
+				return imapArg{}, errors.New("unterminated list")
+			}
+			if p.b[p.i] == ')' {
+				p.i++
+				return imapArg{list: list, isList: true}, nil
+			}
+			a, err := p.next()
+			if err != nil {
+				return imapArg{}, err
+			}
+			list = append(list, a)
+		}
+	case '"':
+		return p.quoted()
+	case '{':
+		return p.literal()
+	case ')':
+		return imapArg{}, errors.New("unexpected ')'")
+	}
+	return p.atom(), nil
+}
+
+func (p *imapParser) quoted() (imapArg, error) {
+	p.i++ // opening quote
+	var sb strings.Builder
+	for p.i < len(p.b) {
+		ch := p.b[p.i]
+		p.i++
+		switch ch {
+		case '\\':
+			if p.i >= len(p.b) {
+				return imapArg{}, errors.New("unterminated quoted string")
+			}
+			sb.WriteByte(p.b[p.i])
+			p.i++
+		case '"':
+			return imapArg{s: sb.String(), isString: true}, nil
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return imapArg{}, errors.New("unterminated quoted string")
+}
+
+func (p *imapParser) literal() (imapArg, error) {
+	end := strings.IndexByte(string(p.b[p.i:]), '}')
+	if end < 0 {
+		return imapArg{}, errors.New("malformed literal")
+	}
+	spec := strings.TrimSuffix(string(p.b[p.i+1:p.i+end]), "+")
+	n, err := strconv.Atoi(spec)
+	if err != nil || n < 0 {
+		return imapArg{}, fmt.Errorf("malformed literal size %q", spec)
+	}
+	start := p.i + end + 1 + 2 // skip "}\r\n"
+	if start+n > len(p.b) {
+		return imapArg{}, errors.New("truncated literal")
+	}
+	p.i = start + n
+	return imapArg{s: string(p.b[start : start+n]), isString: true}, nil
+}
+
+// atom reads up to the next space or ')'. Brackets are kept whole so that
+// fetch items like BODY.PEEK[HEADER.FIELDS (FROM TO)]<0.100> are one atom.
+func (p *imapParser) atom() imapArg {
+	start := p.i
+	depth := 0
+	for p.i < len(p.b) {
+		ch := p.b[p.i]
+		if depth == 0 && (ch == ' ' || ch == ')' || ch == '(') {
+			break
+		}
+		switch ch {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		}
+		p.i++
+	}
+	return imapArg{s: string(p.b[start:p.i])}
+}
+
+// imapQuote renders s as an IMAP string: quoted when safe, otherwise a
+// literal.
+func imapQuote(s string) string {
+	for i := 0; i < len(s); i++ {
+		if ch := s[i]; ch == '\r' || ch == '\n' || ch >= 0x80 || ch == 0 {
+			return fmt.Sprintf("{%d}\r\n%s", len(s), s)
+		}
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// imapNString renders s as an IMAP nstring, using NIL for empty values.
+func imapNString(s string) string {
+	if s == "" {
+		return "NIL"
+	}
+	return imapQuote(s)
+}
@@ -0,0 +1,358 @@
+package mailbridge
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// Gas Town headers carried on rendered and submitted messages.
+const (
+	headerPriority      = "X-Gt-Priority"
+	headerType          = "X-Gt-Type"
+	headerThread        = "X-Gt-Thread"
+	headerPayloadSchema = "X-Gt-Payload-Schema"
+)
+
+// maxMIMEDepth bounds nested multiparts in submitted mail.
+const maxMIMEDepth = 5
+
+// renderedMessage is a Gas Town message rendered as RFC 5322 text, split so
+// IMAP can serve header, text and individual MIME parts.
+type renderedMessage struct {
+	header   []byte         // Top-level header, including the blank line
+	body     []byte         // Everything after the header
+	parts    []renderedPart // MIME parts; a single-part message has one
+	boundary string         // multipart/mixed boundary ("" = single part)
+}
+
+// renderedPart is one part of a multipart/mixed message.
+type renderedPart struct {
+	header    []byte // Part header, including the blank line
+	body      []byte // Encoded part content
+	mediaType string // e.g. "text/plain"
+	params    map[string]string
+	encoding  string // Content-Transfer-Encoding
+	filename  string
+}
+
+// full returns the complete message.
+func (r *renderedMessage) full() []byte {
+	return append(append([]byte{}, r.header...), r.body...)
+}
+
+// renderMessage renders msg for a mail client. Messages with a payload or
+// attachments become multipart/mixed: the body, then the payload as
+// payload.json, then each attachment.
+func renderMessage(msg *mail.Message, domain string, backend Backend) (*renderedMessage, error) {
+	h := newHeaderWriter()
+	h.add("Date", msg.Timestamp.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	h.add("From", formatAddress(msg.From, domain))
+	h.add("To", formatAddress(msg.To, domain))
+	if len(msg.CC) > 0 {
+		cc := make([]string, len(msg.CC))
+		for i, addr := range msg.CC {
+			cc[i] = formatAddress(addr, domain)
+		}
+		h.add("Cc", strings.Join(cc, ", "))
+	}
+	h.add("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	h.add("Message-ID", messageID(msg.ID, domain))
+	if msg.ReplyTo != "" {
+		h.add("In-Reply-To", messageID(msg.ReplyTo, domain))
+	}
+	if msg.Priority != "" {
+		h.add(headerPriority, string(msg.Priority))
+		switch msg.Priority {
+		case mail.PriorityUrgent:
+			h.add("X-Priority", "1 (Highest)")
+		case mail.PriorityHigh:
+			h.add("X-Priority", "2 (High)")
+		}
+	}
+	if msg.Type != "" {
+		h.add(headerType, string(msg.Type))
+	}
+	if msg.ThreadID != "" {
+		h.add(headerThread, msg.ThreadID)
+	}
+	if msg.Payload != nil {
+		h.add(headerPayloadSchema, msg.Payload.Schema)
+	}
+	h.add("MIME-Version", "1.0")
+
+	text := renderedPart{
+		body:      []byte(toCRLF(msg.Body)),
+		mediaType: "text/plain",
+		params:    map[string]string{"charset": "utf-8"},
+		encoding:  "8bit",
+	}
+	if msg.Payload == nil && len(msg.Attachments) == 0 {
+		h.add("Content-Type", mime.FormatMediaType(text.mediaType, text.params))
+		h.add("Content-Transfer-Encoding", text.encoding)
+		return &renderedMessage{header: h.bytes(), body: text.body, parts: []renderedPart{text}}, nil
+	}
+
+	parts := []renderedPart{text}
+	if msg.Payload != nil {
+		parts = append(parts, renderedPart{
+			body:      []byte(toCRLF(string(msg.Payload.Data))),
+			mediaType: "application/json",
+			params:    map[string]string{"charset": "utf-8"},
+			encoding:  "8bit",
+			filename:  "payload.json",
+		})
+	}
+	for _, att := range msg.Attachments {
+		blob, err := backend.OpenAttachment(att)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(blob)
+		blob.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading attachment %q: %w", att.Name, err)
+		}
+		mediaType := att.MediaType
+		params := map[string]string{}
+		if mt, p, err := mime.ParseMediaType(mediaType); err == nil {
+			mediaType, params = mt, p
+		} else {
+			mediaType = "application/octet-stream"
+		}
+		params["name"] = att.Name
+		parts = append(parts, renderedPart{
+			body:      base64Lines(data),
+			mediaType: mediaType,
+			params:    params,
+			encoding:  "base64",
+			filename:  att.Name,
+		})
+	}
+
+	sum := sha256.Sum256([]byte(msg.ID))
+	boundary := "gt-" + hex.EncodeToString(sum[:12])
+	h.add("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": boundary}))
+
+	var body bytes.Buffer
+	for i := range parts {
+		p := &parts[i]
+		ph := newHeaderWriter()
+		ph.add("Content-Type", mime.FormatMediaType(p.mediaType, p.params))
+		ph.add("Content-Transfer-Encoding", p.encoding)
+		if p.filename != "" {
+			ph.add("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": p.filename}))
+		}
+		p.header = ph.bytes()
+		body.WriteString("--" + boundary + "\r\n")
+		body.Write(p.header)
+		body.Write(p.body)
+		body.WriteString("\r\n")
+	}
+	body.WriteString("--" + boundary + "--\r\n")
+	return &renderedMessage{header: h.bytes(), body: body.Bytes(), parts: parts, boundary: boundary}, nil
+}
+
+// headerWriter accumulates header fields in order.
+type headerWriter struct {
+	buf bytes.Buffer
+}
+
+func newHeaderWriter() *headerWriter { return &headerWriter{} }
+
+func (h *headerWriter) add(key, value string) {
+	h.buf.WriteString(key + ": " + value + "\r\n")
+}
+
+// bytes returns the header with its terminating blank line.
+func (h *headerWriter) bytes() []byte {
+	return append(h.buf.Bytes(), '\r', '\n')
+}
+
+// formatAddress renders a Gas Town address as a mail address.
+func formatAddress(addr, domain string) string {
+	return (&netmail.Address{Address: EmailAddress(addr, domain)}).String()
+}
+
+// messageID renders a Gas Town message ID as a Message-ID header value.
+func messageID(id, domain string) string {
+	return "<" + id + "@" + domain + ">"
+}
+
+// parseMessageID extracts a Gas Town message ID from a Message-ID or
+// In-Reply-To header value. IDs from other domains are rejected.
+func parseMessageID(value, domain string) string {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+	id := strings.Trim(fields[0], "<>")
+	suffix := "@" + domain
+	if !strings.HasSuffix(strings.ToLower(id), strings.ToLower(suffix)) {
+		return ""
+	}
+	return id[:len(id)-len(suffix)]
+}
+
+// toCRLF converts bare LF line endings to CRLF.
+func toCRLF(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.ReplaceAll(s, "\n", "\r\n")
+}
+
+// base64Lines encodes data as base64 wrapped at 76 columns.
+func base64Lines(data []byte) []byte {
+	enc := base64.StdEncoding.EncodeToString(data)
+	var out bytes.Buffer
+	for len(enc) > 76 {
+		out.WriteString(enc[:76] + "\r\n")
+		enc = enc[76:]
+	}
+	if enc != "" {
+		out.WriteString(enc + "\r\n")
+	}
+	return out.Bytes()
+}
+
+// submission is the content of a message received over SMTP.
+type submission struct {
+	Subject     string
+	Body        string
+	ReplyTo     string // Gas Town message ID from In-Reply-To
+	Priority    mail.Priority
+	Type        mail.MessageType
+	Attachments []mail.Attachment
+}
+
+// parseSubmission parses an RFC 5322 message. The first text/plain part is
+// the body; every other leaf part is stored as an attachment.
+func parseSubmission(data []byte, domain string, backend Backend) (*submission, error) {
+	m, err := netmail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing message: %w", err)
+	}
+	dec := new(mime.WordDecoder)
+	sub := &submission{}
+	if sub.Subject, err = dec.DecodeHeader(m.Header.Get("Subject")); err != nil {
+		sub.Subject = m.Header.Get("Subject")
+	}
+	sub.ReplyTo = parseMessageID(m.Header.Get("In-Reply-To"), domain)
+	sub.Priority = submissionPriority(m.Header)
+	if t := m.Header.Get(headerType); t != "" {
+		sub.Type = mail.ParseMessageType(t)
+	}
+
+	var body *string
+	if err := sub.walk(textproto.MIMEHeader(m.Header), m.Body, backend, &body, 0); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		sub.Body = strings.TrimRight(strings.ReplaceAll(*body, "\r\n", "\n"), "\n")
+	}
+	return sub, nil
+}
+
+// walk visits a MIME entity, recording the body text and attachments.
+func (sub *submission) walk(header textproto.MIMEHeader, r io.Reader, backend Backend, body **string, depth int) error {
+	if depth > maxMIMEDepth {
+		return fmt.Errorf("message nests MIME parts too deeply")
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	disposition, dparams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("reading MIME part: %w", err)
+			}
+			// In multipart/alternative only the plain text version is kept.
+			if mediaType == "multipart/alternative" && *body != nil {
+				continue
+			}
+			if err := sub.walk(part.Header, part, backend, body, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	content := decodeTransfer(header.Get("Content-Transfer-Encoding"), r)
+	if mediaType == "text/plain" && disposition != "attachment" && *body == nil {
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("reading body: %w", err)
+		}
+		text := string(data)
+		*body = &text
+		return nil
+	}
+
+	name := dparams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if name == "" {
+		name = "part-" + fmt.Sprint(len(sub.Attachments)+1)
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	att, err := backend.StoreAttachment(name, content)
+	if err != nil {
+		return fmt.Errorf("storing attachment %q: %w", name, err)
+	}
+	sub.Attachments = append(sub.Attachments, att)
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding.
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// submissionPriority reads the priority of a submitted message from the Gas
+// Town header or the common X-Priority / Importance headers.
+func submissionPriority(h netmail.Header) mail.Priority {
+	if p := h.Get(headerPriority); p != "" {
+		return mail.ParsePriority(p)
+	}
+	switch xp := strings.TrimSpace(h.Get("X-Priority")); {
+	case strings.HasPrefix(xp, "1"):
+		return mail.PriorityUrgent
+	case strings.HasPrefix(xp, "2"):
+		return mail.PriorityHigh
+	case strings.HasPrefix(xp, "4"), strings.HasPrefix(xp, "5"):
+		return mail.PriorityLow
+	}
+	switch strings.ToLower(h.Get("Importance")) {
+	case "high":
+		return mail.PriorityHigh
+	case "low":
+		return mail.PriorityLow
+	}
+	return ""
+}
@@ -0,0 +1,318 @@
+package mailbridge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// SMTP limits.
+const (
+	maxSubmissionSize = mail.MaxAttachmentSize + 5<<20 // One full attachment plus encoding overhead
+	maxRecipients     = 100
+	smtpIdleTimeout   = 5 * time.Minute
+)
+
+// smtpSession is the state of one SMTP connection.
+type smtpSession struct {
+	s        *Server
+	conn     net.Conn
+	text     *textproto.Conn
+	identity string // Authenticated identity
+	from     bool   // MAIL FROM accepted
+	rcpts    []string
+}
+
+// serveSMTP handles one SMTP submission connection. Every transaction must
+// be authenticated, and the envelope sender must be the authenticated
+// identity, so a client cannot send as another agent.
+func (s *Server) serveSMTP(conn net.Conn) {
+	sess := &smtpSession{s: s, conn: conn, text: textproto.NewConn(conn)}
+	sess.reply(220, "%s Gas Town mail bridge ESMTP", s.cfg.Domain)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(smtpIdleTimeout))
+		line, err := sess.text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		if !sess.handle(strings.ToUpper(verb), strings.TrimSpace(arg)) {
+			return
+		}
+	}
+}
+
+func (c *smtpSession) reply(code int, format string, args ...interface{}) {
+	_ = c.text.PrintfLine("%d %s", code, fmt.Sprintf(format, args...))
+}
+
+// handle runs one command and reports whether the session continues.
+func (c *smtpSession) handle(verb, arg string) bool {
+	switch verb {
+	case "EHLO":
+		c.reset()
+		_ = c.text.PrintfLine("250-%s", c.s.cfg.Domain)
+		_ = c.text.PrintfLine("250-SIZE %d", maxSubmissionSize)
+		_ = c.text.PrintfLine("250-8BITMIME")
+		_ = c.text.PrintfLine("250 AUTH PLAIN LOGIN")
+	case "HELO":
+		c.reset()
+		c.reply(250, "%s", c.s.cfg.Domain)
+	case "AUTH":
+		c.auth(arg)
+	case "MAIL":
+		c.mailFrom(arg)
+	case "RCPT":
+		c.rcptTo(arg)
+	case "DATA":
+		c.data()
+	case "RSET":
+		c.reset()
+		c.reply(250, "OK")
+	case "NOOP":
+		c.reply(250, "OK")
+	case "VRFY":
+		c.reply(252, "Cannot verify, send and see")
+	case "QUIT":
+		c.reply(221, "Bye")
+		return false
+	default:
+		c.reply(502, "Command not implemented")
+	}
+	return true
+}
+
+func (c *smtpSession) reset() {
+	c.from = false
+	c.rcpts = nil
+}
+
+func (c *smtpSession) auth(arg string) {
+	if c.identity != "" {
+		c.reply(503, "Already authenticated")
+		return
+	}
+	mech, initial, _ := strings.Cut(arg, " ")
+	var user, pass string
+	switch strings.ToUpper(mech) {
+	case "PLAIN":
+		resp := initial
+		if resp == "" {
+			var err error
+			if resp, err = c.challenge(""); err != nil {
+				return
+			}
+		}
+		decoded, err := base64.StdEncoding.DecodeString(resp)
+		if err != nil {
+			c.reply(501, "Malformed AUTH response")
+			return
+		}
+		// authzid \0 authcid \0 password
+		fields := bytes.Split(decoded, []byte{0})
+		if len(fields) != 3 {
+			c.reply(501, "Malformed AUTH PLAIN response")
+			return
+		}
+		user, pass = string(fields[1]), string(fields[2])
+	case "LOGIN":
+		u, err := c.challengeDecoded("Username:")
+		if err != nil {
+			return
+		}
+		p, err := c.challengeDecoded("Password:")
+		if err != nil {
+			return
+		}
+		user, pass = u, p
+	default:
+		c.reply(504, "Unrecognized authentication type")
+		return
+	}
+
+	identity, ok := c.s.authenticate(user, pass)
+	if !ok {
+		c.s.logf("smtp: failed login for %q from %s", user, c.conn.RemoteAddr())
+		c.reply(535, "Authentication credentials invalid")
+		return
+	}
+	c.identity = identity
+	c.reply(235, "Authentication successful")
+}
+
+// challenge sends a 334 continuation and returns the client's response.
+func (c *smtpSession) challenge(prompt string) (string, error) {
+	_ = c.text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+	line, err := c.text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	if line == "*" {
+		c.reply(501, "Authentication cancelled")
+		return "", errors.New("cancelled")
+	}
+	return line, nil
+}
+
+func (c *smtpSession) challengeDecoded(prompt string) (string, error) {
+	line, err := c.challenge(prompt)
+	if err != nil {
+		return "", err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(line)
+	if err != nil {
+		c.reply(501, "Malformed AUTH response")
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+func (c *smtpSession) mailFrom(arg string) {
+	if c.identity == "" {
+		c.reply(530, "Authentication required")
+		return
+	}
+	addr, ok := pathArg(arg, "FROM:")
+	if !ok {
+		c.reply(501, "Syntax: MAIL FROM:<address>")
+		return
+	}
+	identity, err := IdentityFromEmail(addr, c.s.cfg.Domain)
+	if err != nil || identity != c.identity {
+		c.reply(553, "Sender must be %s", EmailAddress(c.identity, c.s.cfg.Domain))
+		return
+	}
+	c.reset()
+	c.from = true
+	c.reply(250, "OK")
+}
+
+func (c *smtpSession) rcptTo(arg string) {
+	if !c.from {
+		c.reply(503, "Need MAIL command")
+		return
+	}
+	addr, ok := pathArg(arg, "TO:")
+	if !ok {
+		c.reply(501, "Syntax: RCPT TO:<address>")
+		return
+	}
+	identity, err := IdentityFromEmail(addr, c.s.cfg.Domain)
+	if err != nil {
+		c.reply(550, "%v", err)
+		return
+	}
+	if len(c.rcpts) >= maxRecipients {
+		c.reply(452, "Too many recipients")
+		return
+	}
+	c.rcpts = append(c.rcpts, identity)
+	c.reply(250, "OK")
+}
+
+// pathArg extracts the address from "FROM:<addr> [params]".
+func pathArg(arg, prefix string) (string, bool) {
+	if len(arg) < len(prefix) || !strings.EqualFold(arg[:len(prefix)], prefix) {
+		return "", false
+	}
+	path := strings.TrimSpace(arg[len(prefix):])
+	if i := strings.Index(path, ">"); strings.HasPrefix(path, "<") && i > 0 {
+		return path[1:i], true
+	}
+	if fields := strings.Fields(path); len(fields) > 0 {
+		return fields[0], true
+	}
+	return "", false
+}
+
+func (c *smtpSession) data() {
+	if !c.from || len(c.rcpts) == 0 {
+		c.reply(503, "Need RCPT command")
+		return
+	}
+	c.reply(354, "End data with <CR><LF>.<CR><LF>")
+
+	dr := c.text.DotReader()
+	raw, err := io.ReadAll(io.LimitReader(dr, maxSubmissionSize+1))
+	if err != nil {
+		c.reply(451, "Error reading message")
+		return
+	}
+	if len(raw) > maxSubmissionSize {
+		// Drain the rest of the data so the session stays in sync.
+		_, _ = io.Copy(io.Discard, dr)
+		c.reset()
+		c.reply(552, "Message exceeds fixed maximum message size")
+		return
+	}
+
+	err = c.s.deliver(c.identity, c.rcpts, raw)
+	c.reset()
+	if err != nil {
+		c.s.logf("smtp: delivery from %s failed: %v", c.identity, err)
+		c.reply(554, "Delivery failed: %v", err)
+		return
+	}
+	c.reply(250, "OK: queued")
+}
+
+// deliver routes a submitted message to each recipient through the
+// backend. Each recipient gets its own copy, so Bcc stays private.
+func (s *Server) deliver(from string, rcpts []string, raw []byte) error {
+	sub, err := parseSubmission(raw, s.cfg.Domain, s.cfg.Backend)
+	if err != nil {
+		return err
+	}
+	if sub.Subject == "" {
+		sub.Subject = "(no subject)"
+	}
+
+	msg := mail.NewMessage(from, "", sub.Subject, sub.Body)
+	msg.Attachments = sub.Attachments
+	if sub.Priority != "" {
+		msg.Priority = sub.Priority
+	}
+	if sub.Type != "" {
+		msg.Type = sub.Type
+	}
+	if sub.ReplyTo != "" {
+		msg.ReplyTo = sub.ReplyTo
+		if msg.Type == mail.TypeNotification {
+			msg.Type = mail.TypeReply
+		}
+		// Thread the reply with the original, which is in the sender's inbox.
+		if inbox, err := s.cfg.Backend.List(from); err == nil {
+			for _, orig := range inbox {
+				if orig.ID == sub.ReplyTo && orig.ThreadID != "" {
+					msg.ThreadID = orig.ThreadID
+				}
+			}
+		}
+	}
+
+	var failed []string
+	for _, to := range rcpts {
+		m := *msg
+		m.ID = ""
+		m.To = to
+		if err := s.cfg.Backend.Send(&m); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", to, err))
+		}
+	}
+	if len(failed) == len(rcpts) {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	for _, f := range failed {
+		s.logf("smtp: partial delivery failure: %s", f)
+	}
+	s.logf("smtp: %s sent %q to %s", from, msg.Subject, strings.Join(rcpts, ", "))
+	return nil
+}
@@ -0,0 +1,139 @@
+package mailbridge
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// UsersPath returns the bridge credential file for a town.
+func UsersPath(townRoot string) string {
+	return filepath.Join(townRoot, "mail", "bridge-users.json")
+}
+
+// Users holds the per-identity bridge passwords. Passwords are generated,
+// high-entropy tokens, so a salted SHA-256 is sufficient; they are never
+// stored in the clear.
+type Users struct {
+	path string
+	mu   sync.RWMutex
+	data usersFile
+}
+
+type usersFile struct {
+	Users map[string]userEntry `json:"users"`
+}
+
+type userEntry struct {
+	Salt string `json:"salt"`
+	Hash string `json:"hash"`
+}
+
+// LoadUsers reads the credential file at path. A missing file yields an
+// empty user set.
+func LoadUsers(path string) (*Users, error) {
+	u := &Users{path: path, data: usersFile{Users: make(map[string]userEntry)}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return u, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading bridge users: %w", err)
+	}
+	if err := json.Unmarshal(data, &u.data); err != nil {
+		return nil, fmt.Errorf("parsing bridge users %s: %w", path, err)
+	}
+	if u.data.Users == nil {
+		u.data.Users = make(map[string]userEntry)
+	}
+	return u, nil
+}
+
+// Identities returns the identities that have a password, sorted.
+func (u *Users) Identities() []string {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	ids := make([]string, 0, len(u.data.Users))
+	for id := range u.data.Users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Reset generates a new password for identity, saves the file and returns
+// the password. Any previous password stops working.
+func (u *Users) Reset(identity string) (string, error) {
+	identity = mail.AddressToIdentity(identity)
+	password, err := randomToken(18)
+	if err != nil {
+		return "", err
+	}
+	salt, err := randomToken(12)
+	if err != nil {
+		return "", err
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.data.Users[identity] = userEntry{Salt: salt, Hash: hashPassword(salt, password)}
+	if err := u.save(); err != nil {
+		return "", err
+	}
+	return password, nil
+}
+
+// Remove deletes identity's password. It reports whether one existed.
+func (u *Users) Remove(identity string) (bool, error) {
+	identity = mail.AddressToIdentity(identity)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.data.Users[identity]; !ok {
+		return false, nil
+	}
+	delete(u.data.Users, identity)
+	return true, u.save()
+}
+
+// Verify reports whether password is valid for identity.
+func (u *Users) Verify(identity, password string) bool {
+	u.mu.RLock()
+	entry, ok := u.data.Users[mail.AddressToIdentity(identity)]
+	u.mu.RUnlock()
+	if !ok || password == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashPassword(entry.Salt, password)), []byte(entry.Hash)) == 1
+}
+
+func (u *Users) save() error {
+	if err := os.MkdirAll(filepath.Dir(u.path), 0755); err != nil {
+		return err
+	}
+	return util.AtomicWriteJSONWithPerm(u.path, u.data, 0600)
+}
+
+func hashPassword(salt, password string) string {
+	sum := sha256.Sum256([]byte(salt + "\x00" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,234 @@
+package nudge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/events"
+)
+
+// Delivery states recorded in a session's nudge history.
+//
+// A nudge is queued by Enqueue and injected when Drain hands it to the
+// agent's hook. It is acknowledged at the session's next Drain: the hook runs
+// on every prompt, so a later Drain means the agent finished the turn that
+// carried the nudge. Nudges that outlive their TTL in the queue are expired;
+// nudges refused by Enqueue (full queue, sender rate limit) are dropped.
+const (
+	StateQueued       = "queued"
+	StateInjected     = "injected"
+	StateAcknowledged = "acknowledged"
+	StateExpired      = "expired"
+	StateDropped      = "dropped"
+)
+
+const (
+	// historyMaxBytes is the size at which a session's history file is
+	// compacted down to its most recent historyKeepEvents records.
+	historyMaxBytes   = 256 * 1024
+	historyKeepEvents = 500
+
+	// historyMessageMax caps the message text stored per history record.
+	historyMessageMax = 200
+)
+
+// DeliveryEvent is one state transition of a queued nudge.
+type DeliveryEvent struct {
+	ID       string    `json:"id"`
+	State    string    `json:"state"`
+	Time     time.Time `json:"ts"`
+	Sender   string    `json:"sender,omitempty"`
+	Priority string    `json:"priority,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+	// DeadLetter is set when a dropped or expired urgent nudge was
+	// handed to the dead-letter handler (converted to mail).
+	DeadLetter bool `json:"dead_letter,omitempty"`
+}
+
+// NudgeStatus is the latest known state of one nudge, folded from history.
+type NudgeStatus struct {
+	DeliveryEvent
+	QueuedAt time.Time `json:"queued_at,omitempty"`
+}
+
+// historyMu serializes history writes within this process; flock only
+// excludes other processes.
+var historyMu sync.Mutex
+
+// historyPath returns the delivery history file for a session.
+// Path: <townRoot>/.runtime/nudge_history/<session>.jsonl
+func historyPath(townRoot, session string) string {
+	safe := strings.ReplaceAll(session, "/", "_")
+	return filepath.Join(townRoot, constants.DirRuntime, "nudge_history", safe+".jsonl")
+}
+
+// newDeliveryEvent builds a history record for a nudge.
+func newDeliveryEvent(n QueuedNudge, state, reason string, at time.Time) DeliveryEvent {
+	msg := n.Message
+	if len(msg) > historyMessageMax {
+		msg = msg[:historyMessageMax] + "..."
+	}
+	return DeliveryEvent{
+		ID:       n.ID,
+		State:    state,
+		Time:     at,
+		Sender:   n.Sender,
+		Priority: n.Priority,
+		Message:  msg,
+		Reason:   reason,
+	}
+}
+
+// record appends delivery events to the session's history and mirrors them
+// to the town audit log. History is best-effort: a failed write never blocks
+// delivery.
+func record(townRoot, session string, evs ...DeliveryEvent) {
+	if len(evs) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	for _, ev := range evs {
+		data, err := json.Marshal(ev)
+		if err != nil {
+			continue
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	path := historyPath(townRoot, session)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		historyMu.Lock()
+		fl := flock.New(path + ".lock")
+		if fl.Lock() == nil {
+			appendHistory(path, buf.Bytes())
+			_ = fl.Unlock()
+		}
+		historyMu.Unlock()
+	}
+
+	for _, ev := range evs {
+		_ = events.LogAudit(events.TypeNudgeState, ev.Sender, events.NudgeStatePayload(session, ev.ID, ev.State, ev.Reason))
+	}
+}
+
+// appendHistory appends data to the history file, compacting it first when
+// it has grown past historyMaxBytes. Caller holds the history lock.
+func appendHistory(path string, data []byte) {
+	if info, err := os.Stat(path); err == nil && info.Size() > historyMaxBytes {
+		if evs, err := readHistory(path); err == nil && len(evs) > historyKeepEvents {
+			var kept bytes.Buffer
+			for _, ev := range evs[len(evs)-historyKeepEvents:] {
+				if line, err := json.Marshal(ev); err == nil {
+					kept.Write(line)
+					kept.WriteByte('\n')
+				}
+			}
+			tmp := path + ".tmp"
+			if os.WriteFile(tmp, kept.Bytes(), 0644) == nil {
+				_ = os.Rename(tmp, path)
+			}
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: nudge history is non-sensitive operational data
+	if err != nil {
+		return
+	}
+	_, _ = f.Write(data)
+	_ = f.Close()
+}
+
+// readHistory parses a history file, skipping malformed lines.
+func readHistory(path string) ([]DeliveryEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var evs []DeliveryEvent
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var ev DeliveryEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || ev.ID == "" {
+			continue
+		}
+		evs = append(evs, ev)
+	}
+	return evs, scanner.Err()
+}
+
+// History returns the recorded delivery events for a session, oldest first.
+// A session that never received a queued nudge has no history.
+func History(townRoot, session string) ([]DeliveryEvent, error) {
+	evs, err := readHistory(historyPath(townRoot, session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return evs, err
+}
+
+// Statuses folds a session's history into the latest state of each nudge,
+// ordered by when the nudge was first seen.
+func Statuses(townRoot, session string) ([]NudgeStatus, error) {
+	evs, err := History(townRoot, session)
+	if err != nil {
+		return nil, err
+	}
+	return foldHistory(evs), nil
+}
+
+// foldHistory reduces delivery events to one status per nudge ID.
+func foldHistory(evs []DeliveryEvent) []NudgeStatus {
+	index := make(map[string]int)
+	var out []NudgeStatus
+	for _, ev := range evs {
+		i, ok := index[ev.ID]
+		if !ok {
+			index[ev.ID] = len(out)
+			out = append(out, NudgeStatus{DeliveryEvent: ev, QueuedAt: ev.Time})
+			continue
+		}
+		prev := out[i].DeliveryEvent
+		// Later records may omit the nudge's descriptive fields.
+		if ev.Sender == "" {
+			ev.Sender = prev.Sender
+		}
+		if ev.Priority == "" {
+			ev.Priority = prev.Priority
+		}
+		if ev.Message == "" {
+			ev.Message = prev.Message
+		}
+		ev.DeadLetter = ev.DeadLetter || prev.DeadLetter
+		out[i].DeliveryEvent = ev
+	}
+	return out
+}
+
+// acknowledgeInjected marks every nudge whose latest state is injected as
+// acknowledged. Called at the start of Drain, i.e. at the agent's next turn.
+func acknowledgeInjected(townRoot, session string, at time.Time) {
+	statuses, err := Statuses(townRoot, session)
+	if err != nil {
+		return
+	}
+	var acks []DeliveryEvent
+	for _, s := range statuses {
+		if s.State == StateInjected {
+			acks = append(acks, DeliveryEvent{ID: s.ID, State: StateAcknowledged, Time: at, Sender: s.Sender})
+		}
+	}
+	record(townRoot, session, acks...)
+}
@@ -0,0 +1,175 @@
+package nudge
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// deadLetters installs a recording dead-letter handler for the test.
+func deadLetters(t *testing.T) *[]string {
+	t.Helper()
+	var got []string
+	SetDeadLetterHandler(func(townRoot, session string, n QueuedNudge, reason string) error {
+		got = append(got, n.Message+": "+reason)
+		return nil
+	})
+	t.Cleanup(func() { SetDeadLetterHandler(nil) })
+	return &got
+}
+
+func statesByMessage(t *testing.T, townRoot, session string) map[string]NudgeStatus {
+	t.Helper()
+	statuses, err := Statuses(townRoot, session)
+	if err != nil {
+		t.Fatalf("Statuses: %v", err)
+	}
+	out := make(map[string]NudgeStatus)
+	for _, s := range statuses {
+		out[s.Message] = s
+	}
+	return out
+}
+
+func TestDeliveryLifecycle(t *testing.T) {
+	townRoot := t.TempDir()
+	session := "gt-gastown-crew-max"
+
+	if err := Enqueue(townRoot, session, QueuedNudge{Sender: "mayor", Message: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := statesByMessage(t, townRoot, session)["first"]; got.State != StateQueued || got.ID == "" {
+		t.Fatalf("after Enqueue: %+v, want queued with an ID", got)
+	}
+
+	nudges, err := Drain(townRoot, session)
+	if err != nil || len(nudges) != 1 {
+		t.Fatalf("Drain = %v, %v", nudges, err)
+	}
+	got := statesByMessage(t, townRoot, session)["first"]
+	if got.State != StateInjected || got.ID != nudges[0].ID {
+		t.Fatalf("after Drain: %+v, want injected %s", got, nudges[0].ID)
+	}
+
+	// The next turn boundary acknowledges what the previous one injected.
+	if err := Enqueue(townRoot, session, QueuedNudge{Sender: "deacon", Message: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Drain(townRoot, session); err != nil {
+		t.Fatal(err)
+	}
+	states := statesByMessage(t, townRoot, session)
+	if states["first"].State != StateAcknowledged || states["first"].Sender != "mayor" {
+		t.Errorf("first = %+v, want acknowledged", states["first"])
+	}
+	if states["second"].State != StateInjected {
+		t.Errorf("second = %+v, want injected", states["second"])
+	}
+
+	history, _ := History(townRoot, session)
+	if len(history) != 5 {
+		t.Errorf("history has %d events, want 5 (2 queued, 2 injected, 1 acknowledged)", len(history))
+	}
+}
+
+func TestExpiredUrgentNudgeIsDeadLettered(t *testing.T) {
+	townRoot := t.TempDir()
+	session := "gt-test-expiry"
+	mailed := deadLetters(t)
+
+	past := time.Now().Add(-time.Hour)
+	for _, n := range []QueuedNudge{
+		{Sender: "witness", Message: "urgent", Priority: PriorityUrgent, Timestamp: past, ExpiresAt: past.Add(time.Minute)},
+		{Sender: "witness", Message: "normal", Timestamp: past, ExpiresAt: past.Add(time.Minute)},
+	} {
+		if err := Enqueue(townRoot, session, n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if nudges, _ := Drain(townRoot, session); len(nudges) != 0 {
+		t.Fatalf("Drain returned %d expired nudges", len(nudges))
+	}
+
+	states := statesByMessage(t, townRoot, session)
+	if s := states["urgent"]; s.State != StateExpired || !s.DeadLetter {
+		t.Errorf("urgent = %+v, want expired and dead-lettered", s)
+	}
+	if s := states["normal"]; s.State != StateExpired || s.DeadLetter {
+		t.Errorf("normal = %+v, want expired without dead letter", s)
+	}
+	if len(*mailed) != 1 || (*mailed)[0] != "urgent: ttl elapsed before delivery" {
+		t.Errorf("dead letters = %v", *mailed)
+	}
+}
+
+func TestDroppedNudgeIsRecorded(t *testing.T) {
+	townRoot := t.TempDir()
+	session := "gt-test-full"
+	mailed := deadLetters(t)
+
+	for i := 0; i < MaxQueueDepth; i++ {
+		if err := Enqueue(townRoot, session, QueuedNudge{Sender: "sender", Message: "fill"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := Enqueue(townRoot, session, QueuedNudge{Sender: "sender", Message: "overflow", Priority: PriorityUrgent})
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue on full queue: err = %v, want ErrQueueFull", err)
+	}
+	if s := statesByMessage(t, townRoot, session)["overflow"]; s.State != StateDropped || s.Reason != "queue full" || !s.DeadLetter {
+		t.Errorf("overflow = %+v, want dropped and dead-lettered", s)
+	}
+	if len(*mailed) != 1 {
+		t.Errorf("dead letters = %v, want 1", *mailed)
+	}
+}
+
+func TestSenderRateLimit(t *testing.T) {
+	townRoot := t.TempDir()
+
+	// The limit spans sessions.
+	for i := 0; i < SenderRateLimit; i++ {
+		session := "gt-test-a"
+		if i%2 == 1 {
+			session = "gt-test-b"
+		}
+		if err := CheckSenderRate(townRoot, session, "gastown/runaway", QueuedNudge{Sender: "gastown/runaway", Message: "spam"}); err != nil {
+			t.Fatalf("CheckSenderRate %d: %v", i, err)
+		}
+	}
+	err := CheckSenderRate(townRoot, "gt-test-c", "gastown/runaway", QueuedNudge{Sender: "gastown/runaway", Message: "one too many"})
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("CheckSenderRate over limit: err = %v, want ErrRateLimited", err)
+	}
+	if s := statesByMessage(t, townRoot, "gt-test-c")["one too many"]; s.State != StateDropped {
+		t.Errorf("rate-limited nudge = %+v, want dropped", s)
+	}
+
+	// Other senders, unidentified callers and system notifications queued
+	// directly are unaffected.
+	if err := CheckSenderRate(townRoot, "gt-test-c", "mayor", QueuedNudge{Sender: "mayor", Message: "hi"}); err != nil {
+		t.Errorf("CheckSenderRate from another sender: %v", err)
+	}
+	if err := CheckSenderRate(townRoot, "gt-test-c", "", QueuedNudge{Message: "anon"}); err != nil {
+		t.Errorf("CheckSenderRate without a key: %v", err)
+	}
+	if err := Enqueue(townRoot, "gt-test-c", QueuedNudge{Sender: "gastown/runaway", Message: "you have mail"}); err != nil {
+		t.Errorf("Enqueue from a rate-limited sender: %v", err)
+	}
+}
+
+func TestFoldHistoryKeepsNudgeDetails(t *testing.T) {
+	now := time.Now()
+	statuses := foldHistory([]DeliveryEvent{
+		{ID: "a", State: StateQueued, Time: now, Sender: "mayor", Priority: PriorityUrgent, Message: "hello"},
+		{ID: "b", State: StateQueued, Time: now, Sender: "deacon", Message: "other"},
+		{ID: "a", State: StateExpired, Time: now.Add(time.Hour), DeadLetter: true},
+	})
+	if len(statuses) != 2 {
+		t.Fatalf("got %d statuses, want 2", len(statuses))
+	}
+	a := statuses[0]
+	if a.State != StateExpired || a.Sender != "mayor" || a.Message != "hello" || a.Priority != PriorityUrgent || !a.DeadLetter || !a.QueuedAt.Equal(now) {
+		t.Errorf("folded = %+v", a)
+	}
+}
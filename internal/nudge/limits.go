@@ -0,0 +1,151 @@
+package nudge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/steveyegge/gastown/internal/constants"
+)
+
+// Sender rate limiting. MaxQueueDepth protects a single session; the sender
+// limit stops one runaway agent from flooding the town with gt nudge. It is
+// applied by the gt nudge command (CheckSenderRate), not by Enqueue, so
+// system notifications such as new-mail nudges are never refused.
+const (
+	// SenderRateLimit is the number of nudges one sender may queue, across
+	// all sessions, within SenderRateWindow.
+	SenderRateLimit = 60
+
+	// SenderRateWindow is the sliding window for SenderRateLimit.
+	SenderRateWindow = 5 * time.Minute
+)
+
+var (
+	// ErrQueueFull is returned by Enqueue when the session queue is at MaxQueueDepth.
+	ErrQueueFull = errors.New("queue full")
+
+	// ErrRateLimited is returned by CheckSenderRate when the sender exceeded SenderRateLimit.
+	ErrRateLimited = errors.New("sender rate limit exceeded")
+)
+
+// DeadLetterHandler receives urgent nudges that will never reach the agent:
+// dropped by Enqueue or expired in the queue. reason is the drop or expiry
+// reason recorded in history.
+type DeadLetterHandler func(townRoot, session string, n QueuedNudge, reason string) error
+
+var (
+	deadLetterMu sync.RWMutex
+	deadLetter   DeadLetterHandler
+)
+
+// SetDeadLetterHandler installs the handler for undeliverable urgent nudges.
+// The nudge package cannot send mail itself (mail depends on nudge), so the
+// command layer installs a handler that converts them into mail.
+func SetDeadLetterHandler(h DeadLetterHandler) {
+	deadLetterMu.Lock()
+	deadLetter = h
+	deadLetterMu.Unlock()
+}
+
+// deadLetterEvent records an undeliverable nudge, passing urgent ones to the
+// dead-letter handler.
+func deadLetterEvent(townRoot, session string, n QueuedNudge, state, reason string, at time.Time) DeliveryEvent {
+	ev := newDeliveryEvent(n, state, reason, at)
+	if n.Priority != PriorityUrgent {
+		return ev
+	}
+	deadLetterMu.RLock()
+	h := deadLetter
+	deadLetterMu.RUnlock()
+	if h == nil {
+		return ev
+	}
+	if err := h(townRoot, session, n, reason); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: dead-lettering urgent nudge %s failed: %v\n", n.ID, err)
+		return ev
+	}
+	ev.DeadLetter = true
+	return ev
+}
+
+// rateMu serializes rate-limit updates within this process; flock only
+// excludes other processes.
+var rateMu sync.Mutex
+
+// CheckSenderRate takes a rate-limit token for a nudge about to be sent to
+// session by the caller identified by key, failing with ErrRateLimited once
+// the caller used SenderRateLimit within SenderRateWindow. An empty key (a
+// caller that cannot be identified) is exempt rather than sharing one bucket
+// with every other unidentified caller. A refused nudge is recorded as
+// dropped; an urgent one goes to the dead-letter handler.
+func CheckSenderRate(townRoot, session, key string, n QueuedNudge) error {
+	if key == "" {
+		return nil
+	}
+	now := time.Now()
+	err := takeSenderToken(townRoot, key, now)
+	if err == nil {
+		return nil
+	}
+	if n.Timestamp.IsZero() {
+		n.Timestamp = now
+	}
+	if n.Priority == "" {
+		n.Priority = PriorityNormal
+	}
+	n.ID = fmt.Sprintf("%d-%s", n.Timestamp.UnixNano(), randomSuffix())
+	record(townRoot, session, deadLetterEvent(townRoot, session, n, StateDropped, "sender rate limit", now))
+	return err
+}
+
+// rateLimitPath returns the file holding a sender's recent send times.
+// Path: <townRoot>/.runtime/nudge_rate/<sender>.json
+func rateLimitPath(townRoot, sender string) string {
+	safe := strings.NewReplacer("/", "_", "\\", "_").Replace(sender)
+	return filepath.Join(townRoot, constants.DirRuntime, "nudge_rate", safe+".json")
+}
+
+// takeSenderToken records a send by sender at now, failing with
+// ErrRateLimited if the sender already used SenderRateLimit sends within
+// SenderRateWindow. Bookkeeping failures fail open.
+func takeSenderToken(townRoot, sender string, now time.Time) error {
+	path := rateLimitPath(townRoot, sender)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil
+	}
+
+	rateMu.Lock()
+	defer rateMu.Unlock()
+	fl := flock.New(path + ".lock")
+	if err := fl.Lock(); err != nil {
+		return nil
+	}
+	defer fl.Unlock() //nolint:errcheck // best-effort unlock
+
+	var sends []time.Time
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &sends)
+	}
+	cutoff := now.Add(-SenderRateWindow)
+	recent := sends[:0]
+	for _, t := range sends {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= SenderRateLimit {
+		return fmt.Errorf("%s sent %d nudges in the last %s: %w", sender, len(recent), SenderRateWindow, ErrRateLimited)
+	}
+	recent = append(recent, now)
+	if data, err := json.Marshal(recent); err == nil {
+		_ = os.WriteFile(path, data, 0644)
+	}
+	return nil
+}
@@ -7,6 +7,10 @@
 //
 // Queue location: <townRoot>/.runtime/nudge_queue/<session>/
 // Each nudge is a JSON file named by timestamp for FIFO ordering.
+//
+// Every state change (queued, injected, acknowledged, expired, dropped) is
+// appended to the session's delivery history (see history.go), so senders can
+// check with 'gt nudge status' whether a nudge was ever seen.
 package nudge
 
 import (
@@ -52,6 +56,8 @@ const (
 
 // QueuedNudge represents a nudge message stored in the queue.
 type QueuedNudge struct {
+	// ID identifies the nudge in delivery history. Assigned by Enqueue.
+	ID        string    `json:"id,omitempty"`
 	Sender    string    `json:"sender"`
 	Message   string    `json:"message"`
 	Priority  string    `json:"priority"`
@@ -77,21 +83,18 @@ func randomSuffix() string {
 
 // Enqueue writes a nudge to the queue for the given session.
 // The nudge will be picked up by the agent's hook at the next turn boundary.
-// Returns an error if the queue is full (MaxQueueDepth reached).
+// Returns an error wrapping ErrQueueFull if the queue is full (MaxQueueDepth
+// reached). Refused nudges are recorded as dropped; urgent ones go to the
+// dead-letter handler.
 func Enqueue(townRoot, session string, nudge QueuedNudge) error {
 	dir := queueDir(townRoot, session)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("creating nudge queue dir: %w", err)
 	}
 
-	// Check queue depth before writing to prevent runaway senders.
-	pending, _ := Pending(townRoot, session)
-	if pending >= MaxQueueDepth {
-		return fmt.Errorf("nudge queue for %s is full (%d/%d pending)", session, pending, MaxQueueDepth)
-	}
-
+	now := time.Now()
 	if nudge.Timestamp.IsZero() {
-		nudge.Timestamp = time.Now()
+		nudge.Timestamp = now
 	}
 	if nudge.Priority == "" {
 		nudge.Priority = PriorityNormal
@@ -107,21 +110,32 @@ func Enqueue(townRoot, session string, nudge QueuedNudge) error {
 		}
 	}
 
+	// Use nanosecond timestamp + random suffix for unique, ordered filenames.
+	// The random suffix prevents collisions when multiple agents enqueue
+	// nudges for the same session within the same nanosecond.
+	// The file name doubles as the nudge ID in delivery history.
+	nudge.ID = fmt.Sprintf("%d-%s", nudge.Timestamp.UnixNano(), randomSuffix())
+
+	// Check queue depth before writing to prevent runaway senders.
+	pending, _ := Pending(townRoot, session)
+	if pending >= MaxQueueDepth {
+		err := fmt.Errorf("nudge queue for %s is full (%d/%d pending): %w", session, pending, MaxQueueDepth, ErrQueueFull)
+		record(townRoot, session, deadLetterEvent(townRoot, session, nudge, StateDropped, "queue full", now))
+		return err
+	}
+
 	data, err := json.MarshalIndent(nudge, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling nudge: %w", err)
 	}
 
-	// Use nanosecond timestamp + random suffix for unique, ordered filenames.
-	// The random suffix prevents collisions when multiple agents enqueue
-	// nudges for the same session within the same nanosecond.
-	filename := fmt.Sprintf("%d-%s.json", nudge.Timestamp.UnixNano(), randomSuffix())
-	path := filepath.Join(dir, filename)
+	path := filepath.Join(dir, nudge.ID+".json")
 
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		return fmt.Errorf("writing nudge to queue: %w", err)
 	}
 
+	record(townRoot, session, newDeliveryEvent(nudge, StateQueued, "", now))
 	return nil
 }
 
@@ -132,11 +146,18 @@ func Enqueue(townRoot, session string, nudge QueuedNudge) error {
 // the same nudge twice: each file is atomically renamed to a .claimed suffix
 // before reading, so only one caller can claim each nudge.
 //
-// Expired nudges (past ExpiresAt) are silently discarded during drain.
+// Expired nudges (past ExpiresAt) are discarded during drain and recorded as
+// expired; urgent ones go to the dead-letter handler. Returned nudges are
+// recorded as injected, and nudges injected by an earlier Drain are recorded
+// as acknowledged.
 // Orphaned .claimed files from crashed drainers are swept if older than 5 minutes.
 func Drain(townRoot, session string) ([]QueuedNudge, error) {
 	dir := queueDir(townRoot, session)
 
+	// This Drain is the agent's next turn boundary: whatever the previous
+	// Drain injected has been seen.
+	acknowledgeInjected(townRoot, session, time.Now())
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -180,6 +201,7 @@ func Drain(townRoot, session string) ([]QueuedNudge, error) {
 	})
 
 	var nudges []QueuedNudge
+	var history []DeliveryEvent
 	for _, entry := range entries {
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
 			continue
@@ -224,8 +246,14 @@ func Drain(townRoot, session string) ([]QueuedNudge, error) {
 			continue
 		}
 
+		// Nudges queued before delivery tracking have no ID; use the file name.
+		if n.ID == "" {
+			n.ID = strings.TrimSuffix(entry.Name(), ".json")
+		}
+
 		// Skip expired nudges — stale messages create noise, not value.
 		if !n.ExpiresAt.IsZero() && now.After(n.ExpiresAt) {
+			history = append(history, deadLetterEvent(townRoot, session, n, StateExpired, "ttl elapsed before delivery", now))
 			if rmErr := os.Remove(claimPath); rmErr != nil {
 				fmt.Fprintf(os.Stderr, "Warning: failed to remove expired nudge %s: %v\n", entry.Name(), rmErr)
 			}
@@ -233,6 +261,7 @@ func Drain(townRoot, session string) ([]QueuedNudge, error) {
 		}
 
 		nudges = append(nudges, n)
+		history = append(history, newDeliveryEvent(n, StateInjected, "", now))
 
 		// Remove the claimed file after successful processing
 		if rmErr := os.Remove(claimPath); rmErr != nil {
@@ -240,6 +269,7 @@ func Drain(townRoot, session string) ([]QueuedNudge, error) {
 		}
 	}
 
+	record(townRoot, session, history...)
 	return nudges, nil
 }
 
@@ -703,12 +703,20 @@ func (m *Manager) AddWithOptions(name string, opts AddOptions) (_ *Polecat, retE
 			startPoint, m.rig.Path, filepath.Join(m.rig.Path, ".repo.git"))
 	}
 
-	// Always create fresh branch - unique name guarantees no collision
-	// git worktree add -b polecat/<name>-<timestamp> <path> <startpoint>
-	// Worktree goes in polecats/<name>/<rigname>/ for LLM ergonomics
-	if err := repoGit.WorktreeAddFromRef(clonePath, branchName, startPoint); err != nil {
-		cleanupOnError()
-		return nil, fmt.Errorf("creating worktree from %s: %w", startPoint, err)
+	// Claim a pre-provisioned worktree from the warm pool if one is ready.
+	// It already has overlay files and setup hooks applied.
+	warm := m.claimWarmSlot(repoGit, clonePath, branchName, startPoint)
+	if warm {
+		// Top the pool back up without making this spawn wait for it.
+		m.refillWarmPoolAsync()
+	} else {
+		// Always create fresh branch - unique name guarantees no collision
+		// git worktree add -b polecat/<name>-<timestamp> <path> <startpoint>
+		// Worktree goes in polecats/<name>/<rigname>/ for LLM ergonomics
+		if err := repoGit.WorktreeAddFromRef(clonePath, branchName, startPoint); err != nil {
+			cleanupOnError()
+			return nil, fmt.Errorf("creating worktree from %s: %w", startPoint, err)
+		}
 	}
 	worktreeCreated = true
 
@@ -734,9 +742,12 @@ func (m *Manager) AddWithOptions(name string, opts AddOptions) (_ *Polecat, retE
 
 	// Copy overlay files from .runtime/overlay/ to polecat root.
 	// This allows services to have .env and other config files at their root.
-	if err := rig.CopyOverlay(m.rig.Path, clonePath); err != nil {
-		// Non-fatal - log warning but continue
-		style.PrintWarning("could not copy overlay files: %v", err)
+	// Warm worktrees got theirs when provisioned.
+	if !warm {
+		if err := rig.CopyOverlay(m.rig.Path, clonePath); err != nil {
+			// Non-fatal - log warning but continue
+			style.PrintWarning("could not copy overlay files: %v", err)
+		}
 	}
 
 	// Ensure .gitignore has required Gas Town patterns
@@ -756,9 +767,12 @@ func (m *Manager) AddWithOptions(name string, opts AddOptions) (_ *Polecat, retE
 
 	// Run setup hooks from .runtime/setup-hooks/.
 	// These hooks can inject local git config, copy secrets, or perform other setup tasks.
-	if err := rig.RunSetupHooks(m.rig.Path, clonePath); err != nil {
-		// Non-fatal - log warning but continue
-		style.PrintWarning("could not run setup hooks: %v", err)
+	// Warm worktrees already ran them.
+	if !warm {
+		if err := rig.RunSetupHooksWithEnv(m.rig.Path, clonePath, SharedCacheEnv(m.rig.Path)); err != nil {
+			// Non-fatal - log warning but continue
+			style.PrintWarning("could not run setup hooks: %v", err)
+		}
 	}
 
 	// NOTE: Slash commands (.claude/commands/) are provisioned at town level by gt install.
@@ -1281,6 +1295,9 @@ func (m *Manager) ReconcilePool() {
 	defer func() { _ = fl.Unlock() }()
 
 	m.reconcilePoolInternal()
+
+	// Maintain the warm worktree pool in the background.
+	m.refillWarmPoolAsync()
 }
 
 // reconcilePoolInternal performs pool reconciliation without acquiring the pool lock.
@@ -0,0 +1,16 @@
+//go:build unix
+
+package polecat
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts cmd in its own process group so it outlives the
+// gt command that launched it.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+}
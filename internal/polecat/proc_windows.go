@@ -0,0 +1,8 @@
+//go:build windows
+
+package polecat
+
+import "os/exec"
+
+// detachProcess is a no-op on Windows; the child runs independently.
+func detachProcess(cmd *exec.Cmd) {}
@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,6 +20,7 @@ import (
 	"github.com/steveyegge/gastown/internal/git"
 	"github.com/steveyegge/gastown/internal/rig"
 	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/style"
 	"github.com/steveyegge/gastown/internal/session"
 	"github.com/steveyegge/gastown/internal/tmux"
@@ -314,11 +317,43 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	if polecatGitBranch != "" {
 		envVarsToInject["GT_BRANCH"] = polecatGitBranch
 	}
+	// Shared dependency caches (worktree_pool.shared_caches), so builds in
+	// the session reuse what setup hooks and other polecats downloaded.
+	cacheEnv := SharedCacheEnv(m.rig.Path)
+	for _, kv := range cacheEnv {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			envVarsToInject[k] = v
+		}
+	}
 	command = config.PrependEnv(command, envVarsToInject)
 
+	// Rig secrets (gt rig secrets) are handed over as environment only:
+	// staged in a 0600 file the session sources and deletes, so they never
+	// touch the worktree or appear in the command line.
+	secretEnv, err := secrets.SessionEnv(m.rig.Path)
+	if err != nil {
+		style.PrintWarning("rig secrets not loaded for %s: %v", sessionID, err)
+		secretEnv = nil
+	} else {
+		for k := range envVarsToInject {
+			delete(secretEnv, k)
+		}
+		if command, err = secrets.WrapCommand(m.rig.Path, sessionID, command, secretEnv); err != nil {
+			return fmt.Errorf("staging rig secrets: %w", err)
+		}
+	}
+
+	// Run inside the rig's sandbox when isolation is enabled, so the polecat
+	// cannot touch sibling worktrees, other rigs or the rest of $HOME.
+	command, err = session.IsolateCommand(townRoot, m.rig.Path, workDir, opts.RuntimeConfigDir, command)
+	if err != nil {
+		return fmt.Errorf("isolating session: %w", err)
+	}
+
 	// Create session with command directly to avoid send-keys race condition.
 	// See: https://github.com/anthropics/gastown/issues/280
 	if err := m.tmux.NewSessionWithCommand(sessionID, workDir, command); err != nil {
+		secrets.RemoveEnvFile(m.rig.Path, sessionID)
 		return fmt.Errorf("creating session: %w", err)
 	}
 
@@ -355,6 +390,18 @@ func (m *SessionManager) Start(polecat string, opts SessionStartOptions) error {
 	}
 	debugSession("SetEnvironment GT_POLECAT_PATH", m.tmux.SetEnvironment(sessionID, "GT_POLECAT_PATH", workDir))
 	debugSession("SetEnvironment GT_TOWN_ROOT", m.tmux.SetEnvironment(sessionID, "GT_TOWN_ROOT", townRoot))
+	for _, kv := range cacheEnv {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			debugSession("SetEnvironment "+k, m.tmux.SetEnvironment(sessionID, k, v))
+		}
+	}
+
+	// Keep secrets in the session environment so a respawned pane (handoff,
+	// respawn-pane), which finds the env file already deleted, still
+	// inherits them.
+	for _, k := range slices.Sorted(maps.Keys(secretEnv)) {
+		debugSession("SetEnvironment "+k, m.tmux.SetEnvironment(sessionID, k, secretEnv[k]))
+	}
 
 	// Disable Dolt auto-commit in tmux session environment (gt-5cc2p).
 	// This ensures respawned processes also inherit the setting.
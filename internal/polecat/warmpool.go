@@ -0,0 +1,496 @@
+package polecat
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+	"github.com/steveyegge/gastown/internal/style"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Warm worktree pool.
+//
+// Creating a polecat worktree means a checkout, overlay copy and setup hooks
+// (npm ci, go mod download, ...) before the agent can start. With a
+// worktree_pool configured in the rig settings, idle worktrees are
+// provisioned ahead of time under polecats/.warm/<slot>/<rig>/ on a detached
+// HEAD at the base branch, and AddWithOptions claims one by moving it into
+// place and creating the polecat branch. ReconcileWarmPool keeps the pool at
+// size and refreshes slots whose base branch has moved.
+//
+// Slots mirror the polecats/<name>/<rig>/ depth so relative paths written by
+// setup hooks survive the move; absolute paths (e.g. Python venvs) do not,
+// so hooks in a pooled rig must be path-independent. Hooks that print the
+// slot path are warned about.
+
+// warmProvisionTimeout is how long a slot may stay unready before it is
+// treated as an abandoned provisioning attempt and removed.
+const warmProvisionTimeout = time.Hour
+
+// WarmSlot is an idle pre-provisioned worktree.
+type WarmSlot struct {
+	ID          string    `json:"id"`
+	BaseRef     string    `json:"base_ref"`    // e.g. origin/main
+	BaseCommit  string    `json:"base_commit"` // commit the slot was provisioned at
+	CreatedAt   time.Time `json:"created_at"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	Ready       bool      `json:"ready"` // false while provisioning or refreshing
+
+	// Path is the slot's worktree.
+	Path string `json:"path"`
+}
+
+// WarmPoolResult reports what a ReconcileWarmPool pass did.
+type WarmPoolResult struct {
+	Provisioned int `json:"provisioned"`
+	Refreshed   int `json:"refreshed"`
+	Removed     int `json:"removed"`
+	Ready       int `json:"ready"`
+	Size        int `json:"size"`
+}
+
+// warmPoolConfig returns the rig's worktree pool settings, or nil.
+func (m *Manager) warmPoolConfig() *config.WorktreePoolConfig {
+	return loadWorktreePoolConfig(m.rig.Path)
+}
+
+func loadWorktreePoolConfig(rigPath string) *config.WorktreePoolConfig {
+	settings, err := config.LoadRigSettings(filepath.Join(rigPath, "settings", "config.json"))
+	if err != nil || settings.WorktreePool == nil {
+		return nil
+	}
+	return settings.WorktreePool
+}
+
+// warmDir is the parent directory of all warm slots.
+func (m *Manager) warmDir() string {
+	return filepath.Join(m.rig.Path, "polecats", ".warm")
+}
+
+func (m *Manager) warmSlotDir(id string) string {
+	return filepath.Join(m.warmDir(), id)
+}
+
+func (m *Manager) warmSlotMetaPath(id string) string {
+	return filepath.Join(m.warmSlotDir(id), "slot.json")
+}
+
+// lockWarmPool acquires the lock guarding slot claims and state changes.
+// Held briefly; provisioning itself runs outside it.
+// Caller must defer fl.Unlock().
+func (m *Manager) lockWarmPool() (*flock.Flock, error) {
+	lockDir := filepath.Join(m.rig.Path, ".runtime", "locks")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	fl := flock.New(filepath.Join(lockDir, "polecat-warm.lock"))
+	if err := fl.Lock(); err != nil {
+		return nil, fmt.Errorf("acquiring warm pool lock: %w", err)
+	}
+	return fl, nil
+}
+
+// reconcileLock returns the lock that allows a single ReconcileWarmPool at a time.
+func (m *Manager) reconcileLock() (*flock.Flock, error) {
+	lockDir := filepath.Join(m.rig.Path, ".runtime", "locks")
+	if err := os.MkdirAll(lockDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating lock dir: %w", err)
+	}
+	return flock.New(filepath.Join(lockDir, "polecat-warm-reconcile.lock")), nil
+}
+
+// WarmSlots returns the pool's slots, oldest first.
+func (m *Manager) WarmSlots() ([]*WarmSlot, error) {
+	entries, err := os.ReadDir(m.warmDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading warm pool: %w", err)
+	}
+	var slots []*WarmSlot
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		slot, err := m.loadWarmSlot(e.Name())
+		if err != nil {
+			// No metadata: a crashed provision. Report it unready so
+			// reconcile removes it once it is old enough.
+			info, _ := e.Info()
+			created := time.Time{}
+			if info != nil {
+				created = info.ModTime()
+			}
+			slot = &WarmSlot{ID: e.Name(), CreatedAt: created, RefreshedAt: created,
+				Path: filepath.Join(m.warmSlotDir(e.Name()), m.rig.Name)}
+		}
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].CreatedAt.Before(slots[j].CreatedAt) })
+	return slots, nil
+}
+
+func (m *Manager) loadWarmSlot(id string) (*WarmSlot, error) {
+	data, err := os.ReadFile(m.warmSlotMetaPath(id))
+	if err != nil {
+		return nil, err
+	}
+	var slot WarmSlot
+	if err := json.Unmarshal(data, &slot); err != nil {
+		return nil, err
+	}
+	slot.ID = id
+	slot.Path = filepath.Join(m.warmSlotDir(id), m.rig.Name)
+	return &slot, nil
+}
+
+func (m *Manager) saveWarmSlot(slot *WarmSlot) error {
+	return util.AtomicWriteJSON(m.warmSlotMetaPath(slot.ID), slot)
+}
+
+// setWarmSlotReady flips a slot's Ready flag under the pool lock. Returns
+// false if the slot was claimed (or removed) meanwhile.
+func (m *Manager) setWarmSlotReady(id string, ready bool, update func(*WarmSlot)) bool {
+	fl, err := m.lockWarmPool()
+	if err != nil {
+		return false
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	slot, err := m.loadWarmSlot(id)
+	if err != nil {
+		return false
+	}
+	if !ready && !slot.Ready {
+		return false // someone else is already refreshing it
+	}
+	slot.Ready = ready
+	if update != nil {
+		update(slot)
+	}
+	return m.saveWarmSlot(slot) == nil
+}
+
+// defaultStartPoint returns the ref new polecat worktrees start from.
+func (m *Manager) defaultStartPoint() string {
+	defaultBranch := "main"
+	if rigCfg, err := rig.LoadRigConfig(m.rig.Path); err == nil && rigCfg.DefaultBranch != "" {
+		defaultBranch = rigCfg.DefaultBranch
+	}
+	return fmt.Sprintf("origin/%s", defaultBranch)
+}
+
+// claimWarmSlot moves a ready slot for startPoint to clonePath and checks out
+// a new branch there. Returns false when no slot could be used; the caller
+// then creates the worktree from scratch.
+func (m *Manager) claimWarmSlot(repoGit *git.Git, clonePath, branchName, startPoint string) bool {
+	if cfg := m.warmPoolConfig(); cfg == nil || cfg.Size <= 0 {
+		return false
+	}
+	fl, err := m.lockWarmPool()
+	if err != nil {
+		return false
+	}
+	defer func() { _ = fl.Unlock() }()
+
+	slots, err := m.WarmSlots()
+	if err != nil {
+		return false
+	}
+	// Prefer the most recently refreshed slot: fewest files to update.
+	sort.Slice(slots, func(i, j int) bool { return slots[i].RefreshedAt.After(slots[j].RefreshedAt) })
+	for _, slot := range slots {
+		if !slot.Ready || slot.BaseRef != startPoint {
+			continue
+		}
+		if err := repoGit.WorktreeMove(slot.Path, clonePath); err != nil {
+			m.removeWarmSlot(repoGit, slot)
+			continue
+		}
+		_ = os.RemoveAll(m.warmSlotDir(slot.ID))
+
+		// The base may have moved since the slot was provisioned; checkout
+		// only touches the files that changed.
+		if err := git.NewGit(clonePath).CheckoutNewBranch(branchName, startPoint); err != nil {
+			_ = repoGit.WorktreeRemove(clonePath, true)
+			_ = os.RemoveAll(clonePath)
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// removeWarmSlot deletes a slot's worktree and directory.
+func (m *Manager) removeWarmSlot(repoGit *git.Git, slot *WarmSlot) {
+	if repoGit != nil {
+		_ = repoGit.WorktreeRemove(slot.Path, true)
+	}
+	_ = os.RemoveAll(m.warmSlotDir(slot.ID))
+}
+
+// provisionWarmSlot creates a new slot at startPoint and runs the overlay
+// copy and setup hooks in it. The slot is claimable once this returns nil.
+func (m *Manager) provisionWarmSlot(repoGit *git.Git, startPoint, commit string) error {
+	now := time.Now()
+	slot := &WarmSlot{
+		ID:          strconv.FormatInt(now.UnixNano(), 36),
+		BaseRef:     startPoint,
+		BaseCommit:  commit,
+		CreatedAt:   now,
+		RefreshedAt: now,
+	}
+	slot.Path = filepath.Join(m.warmSlotDir(slot.ID), m.rig.Name)
+	if err := os.MkdirAll(m.warmSlotDir(slot.ID), 0755); err != nil {
+		return fmt.Errorf("creating warm slot dir: %w", err)
+	}
+	if err := m.saveWarmSlot(slot); err != nil {
+		return fmt.Errorf("writing warm slot: %w", err)
+	}
+	if err := repoGit.WorktreeAddDetached(slot.Path, commit); err != nil {
+		m.removeWarmSlot(repoGit, slot)
+		return fmt.Errorf("creating warm worktree: %w", err)
+	}
+	if err := m.prepareWorktree(slot.Path); err != nil {
+		m.removeWarmSlot(repoGit, slot)
+		return fmt.Errorf("provisioning warm slot %s: %w", slot.ID, err)
+	}
+
+	if !m.setWarmSlotReady(slot.ID, true, nil) {
+		m.removeWarmSlot(repoGit, slot)
+		return fmt.Errorf("warm slot %s vanished while provisioning", slot.ID)
+	}
+	return nil
+}
+
+// refreshWarmSlot moves a ready slot to commit and re-runs setup hooks so
+// installed dependencies follow lockfile changes.
+func (m *Manager) refreshWarmSlot(repoGit *git.Git, slot *WarmSlot, commit string) error {
+	if !m.setWarmSlotReady(slot.ID, false, nil) {
+		return nil // claimed or already refreshing
+	}
+	if err := git.NewGit(slot.Path).CheckoutDetached(commit); err != nil {
+		m.removeWarmSlot(repoGit, slot)
+		return fmt.Errorf("refreshing warm slot %s: %w", slot.ID, err)
+	}
+	if err := m.prepareWorktree(slot.Path); err != nil {
+		m.removeWarmSlot(repoGit, slot)
+		return fmt.Errorf("refreshing warm slot %s: %w", slot.ID, err)
+	}
+	m.setWarmSlotReady(slot.ID, true, func(s *WarmSlot) {
+		s.BaseCommit = commit
+		s.RefreshedAt = time.Now()
+	})
+	return nil
+}
+
+// prepareWorktree runs the path-independent, slow part of polecat setup in a
+// warm slot: overlay files and setup hooks, with shared caches when enabled
+// and the pool's hook timeout. A failed hook fails the slot, which is then
+// never claimed half-provisioned.
+func (m *Manager) prepareWorktree(clonePath string) error {
+	if err := rig.CopyOverlay(m.rig.Path, clonePath); err != nil {
+		style.PrintWarning("could not copy overlay files: %v", err)
+	}
+	timeout := config.DefaultWarmHookTimeout
+	if cfg := m.warmPoolConfig(); cfg != nil {
+		timeout = cfg.GetHookTimeout()
+	}
+	return rig.RunSetupHooksWithOptions(m.rig.Path, clonePath, rig.SetupHookOptions{
+		Env:      SharedCacheEnv(m.rig.Path),
+		Timeout:  timeout,
+		WarnPath: clonePath,
+	})
+}
+
+// ReconcileWarmPool brings the warm pool to its configured size: removes
+// broken, abandoned and surplus slots, refreshes slots whose base moved
+// longer than refresh_interval ago, and provisions new ones. Only one
+// reconcile runs per rig at a time; concurrent calls return immediately.
+func (m *Manager) ReconcileWarmPool() (*WarmPoolResult, error) {
+	result := &WarmPoolResult{}
+	lock, err := m.reconcileLock()
+	if err != nil {
+		return result, err
+	}
+	locked, err := lock.TryLock()
+	if err != nil || !locked {
+		return result, err
+	}
+	defer func() { _ = lock.Unlock() }()
+
+	repoGit, err := m.repoBase()
+	if err != nil {
+		return result, err
+	}
+	cfg := m.warmPoolConfig()
+	size := 0
+	if cfg != nil && cfg.Size > 0 {
+		size = cfg.Size
+	}
+	result.Size = size
+
+	slots, err := m.WarmSlots()
+	if err != nil {
+		return result, err
+	}
+	if size == 0 {
+		for _, slot := range slots {
+			m.removeWarmSlot(repoGit, slot)
+			result.Removed++
+		}
+		return result, nil
+	}
+
+	if err := repoGit.Fetch("origin"); err != nil {
+		style.PrintWarning("could not fetch origin: %v", err)
+	}
+	startPoint := m.defaultStartPoint()
+	commit, err := repoGit.Rev(startPoint)
+	if err != nil {
+		return result, fmt.Errorf("resolving %s: %w", startPoint, err)
+	}
+
+	now := time.Now()
+	keep, stale := planWarmPool(slots, startPoint, commit, size, cfg.GetRefreshInterval(), now)
+	for _, slot := range slots {
+		if !keep[slot.ID] {
+			m.removeWarmSlot(repoGit, slot)
+			result.Removed++
+		}
+	}
+	for _, slot := range stale {
+		if err := m.refreshWarmSlot(repoGit, slot, commit); err != nil {
+			style.PrintWarning("%v", err)
+			continue
+		}
+		result.Refreshed++
+	}
+
+	for n := len(keep); n < size; n++ {
+		if err := m.provisionWarmSlot(repoGit, startPoint, commit); err != nil {
+			return result, err
+		}
+		result.Provisioned++
+	}
+
+	if slots, err := m.WarmSlots(); err == nil {
+		for _, s := range slots {
+			if s.Ready {
+				result.Ready++
+			}
+		}
+	}
+	return result, nil
+}
+
+// planWarmPool decides which slots to keep and which of those to refresh.
+// Slots for another base ref, slots without a worktree, and provisions
+// abandoned for longer than warmProvisionTimeout are dropped, as are the
+// oldest slots beyond size. A ready slot behind commit is refreshed once its
+// last refresh is older than refreshInterval.
+func planWarmPool(slots []*WarmSlot, startPoint, commit string, size int, refreshInterval time.Duration, now time.Time) (keep map[string]bool, stale []*WarmSlot) {
+	keep = make(map[string]bool)
+	var candidates []*WarmSlot
+	for _, slot := range slots {
+		if slot.BaseRef != startPoint {
+			continue
+		}
+		if !slot.Ready && now.Sub(slot.RefreshedAt) > warmProvisionTimeout {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(slot.Path, ".git")); err != nil {
+			if slot.Ready || now.Sub(slot.RefreshedAt) > warmProvisionTimeout {
+				continue
+			}
+		}
+		candidates = append(candidates, slot)
+	}
+	// Newest first, so the surplus trimmed is the oldest.
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].CreatedAt.After(candidates[j].CreatedAt) })
+	if len(candidates) > size {
+		candidates = candidates[:size]
+	}
+	for _, slot := range candidates {
+		keep[slot.ID] = true
+		if slot.Ready && slot.BaseCommit != commit && now.Sub(slot.RefreshedAt) >= refreshInterval {
+			stale = append(stale, slot)
+		}
+	}
+	return keep, stale
+}
+
+// refillWarmPoolAsync starts `gt polecat pool reconcile <rig>` in the
+// background when the rig has a pool, so spawns never wait on provisioning.
+// Skipped when a reconcile is already running.
+func (m *Manager) refillWarmPoolAsync() {
+	if cfg := m.warmPoolConfig(); cfg == nil || cfg.Size <= 0 {
+		return
+	}
+	lock, err := m.reconcileLock()
+	if err != nil {
+		return
+	}
+	if locked, err := lock.TryLock(); err != nil || !locked {
+		return
+	}
+	_ = lock.Unlock()
+
+	cmd := exec.Command("gt", "polecat", "pool", "reconcile", m.rig.Name) //nolint:gosec // G204: args are constructed internally
+	cmd.Dir = filepath.Dir(m.rig.Path)
+	cmd.Env = append(os.Environ(), "BD_DOLT_AUTO_COMMIT=off")
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	go func() { _ = cmd.Wait() }()
+}
+
+// sharedCacheVars maps cache environment variables to their directory under
+// <town>/.cache/.
+var sharedCacheVars = []struct{ env, dir string }{
+	{"GOMODCACHE", "go-mod"},
+	{"GOCACHE", "go-build"},
+	{"npm_config_cache", "npm"},
+	{"YARN_CACHE_FOLDER", "yarn"},
+	{"PIP_CACHE_DIR", "pip"},
+}
+
+// SharedCacheEnv returns KEY=VALUE entries pointing dependency caches at
+// shared directories under <town>/.cache/, or nil when the rig has no
+// worktree pool or disabled shared caches. Variables already set in the
+// environment are left alone.
+func SharedCacheEnv(rigPath string) []string {
+	cfg := loadWorktreePoolConfig(rigPath)
+	if cfg == nil || !cfg.IsSharedCachesEnabled() {
+		return nil
+	}
+	return sharedCacheEnv(filepath.Dir(rigPath), os.Getenv)
+}
+
+func sharedCacheEnv(townRoot string, getenv func(string) string) []string {
+	var env []string
+	for _, c := range sharedCacheVars {
+		if getenv(c.env) != "" {
+			continue
+		}
+		dir := filepath.Join(townRoot, ".cache", c.dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			continue
+		}
+		env = append(env, c.env+"="+dir)
+	}
+	return env
+}
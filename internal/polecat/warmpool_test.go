@@ -0,0 +1,130 @@
+package polecat
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestPlanWarmPool(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	slot := func(id string, ready bool, base, commit string, created, refreshed time.Duration, withGit bool) *WarmSlot {
+		path := filepath.Join(dir, id, "gastown")
+		if withGit {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(path, ".git"), []byte("gitdir: x"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return &WarmSlot{ID: id, Ready: ready, BaseRef: base, BaseCommit: commit,
+			CreatedAt: now.Add(-created), RefreshedAt: now.Add(-refreshed), Path: path}
+	}
+
+	slots := []*WarmSlot{
+		slot("current", true, "origin/main", "c2", 3*time.Hour, time.Hour, true),
+		slot("behind-recent", true, "origin/main", "c1", 2*time.Hour, 10*time.Minute, true),
+		slot("behind-old", true, "origin/main", "c1", 4*time.Hour, 2*time.Hour, true),
+		slot("other-base", true, "origin/develop", "c2", time.Hour, time.Hour, true),
+		slot("provisioning", false, "origin/main", "c2", 5*time.Minute, 5*time.Minute, false),
+		slot("abandoned", false, "origin/main", "c2", 3*time.Hour, 3*time.Hour, true),
+		slot("no-worktree", true, "origin/main", "c2", time.Hour, time.Hour, false),
+	}
+
+	keep, stale := planWarmPool(slots, "origin/main", "c2", 3, 30*time.Minute, now)
+	for _, id := range []string{"provisioning", "behind-recent", "current"} {
+		if !keep[id] {
+			t.Errorf("expected to keep %s, keep = %v", id, keep)
+		}
+	}
+	if len(keep) != 3 {
+		t.Errorf("keep = %v, want the 3 newest usable slots", keep)
+	}
+	// behind-recent was refreshed 10m ago: under the 30m interval.
+	if len(stale) != 0 {
+		t.Errorf("stale = %v, want none", stale)
+	}
+
+	keep, stale = planWarmPool(slots, "origin/main", "c2", 5, 30*time.Minute, now)
+	if !keep["behind-old"] || keep["abandoned"] || keep["other-base"] || keep["no-worktree"] {
+		t.Errorf("keep = %v", keep)
+	}
+	if len(stale) != 1 || stale[0].ID != "behind-old" {
+		t.Errorf("stale = %v, want behind-old", stale)
+	}
+}
+
+func TestWarmSlotsListing(t *testing.T) {
+	rigPath := filepath.Join(t.TempDir(), "gastown")
+	m := &Manager{rig: &rig.Rig{Name: "gastown", Path: rigPath}}
+
+	if slots, err := m.WarmSlots(); err != nil || slots != nil {
+		t.Fatalf("empty pool = %v, %v", slots, err)
+	}
+
+	ready := &WarmSlot{ID: "a", BaseRef: "origin/main", Ready: true, CreatedAt: time.Now()}
+	if err := os.MkdirAll(m.warmSlotDir("a"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.saveWarmSlot(ready); err != nil {
+		t.Fatal(err)
+	}
+	// A slot dir without metadata is a crashed provision.
+	if err := os.MkdirAll(m.warmSlotDir("b"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	slots, err := m.WarmSlots()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slots) != 2 {
+		t.Fatalf("got %d slots, want 2", len(slots))
+	}
+	byID := map[string]*WarmSlot{slots[0].ID: slots[0], slots[1].ID: slots[1]}
+	if !byID["a"].Ready || byID["a"].Path != filepath.Join(rigPath, "polecats", ".warm", "a", "gastown") {
+		t.Errorf("slot a = %+v", byID["a"])
+	}
+	if byID["b"].Ready {
+		t.Error("slot without metadata must not be ready")
+	}
+
+	// Warm slots are not polecats.
+	if polecats, _ := m.List(); len(polecats) != 0 {
+		t.Errorf("List() = %v, want no polecats", polecats)
+	}
+
+	if m.setWarmSlotReady("a", false, nil) != true {
+		t.Error("marking a ready slot unready should succeed")
+	}
+	if m.setWarmSlotReady("a", false, nil) != false {
+		t.Error("a slot already being refreshed must not be taken twice")
+	}
+}
+
+func TestSharedCacheEnv(t *testing.T) {
+	townRoot := t.TempDir()
+	env := sharedCacheEnv(townRoot, func(k string) string {
+		if k == "GOMODCACHE" {
+			return "/home/me/go/pkg/mod"
+		}
+		return ""
+	})
+	joined := strings.Join(env, "\n")
+	if strings.Contains(joined, "GOMODCACHE=") {
+		t.Errorf("an explicitly set GOMODCACHE must be left alone: %v", env)
+	}
+	want := "npm_config_cache=" + filepath.Join(townRoot, ".cache", "npm")
+	if !strings.Contains(joined, want) {
+		t.Errorf("env = %v, want %s", env, want)
+	}
+	if _, err := os.Stat(filepath.Join(townRoot, ".cache", "go-build")); err != nil {
+		t.Errorf("cache dir not created: %v", err)
+	}
+}
@@ -31,6 +31,8 @@ func NewMergeReadyMessage(rig, polecat, branch, issue string) *mail.Message {
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
 
+	setPayload(msg, SchemaMergeReady, payload)
+
 	return msg
 }
 
@@ -71,6 +73,8 @@ func NewMergedMessage(rig, polecat, branch, issue, targetBranch, mergeCommit str
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeNotification
 
+	setPayload(msg, SchemaMerged, payload)
+
 	return msg
 }
 
@@ -114,6 +118,8 @@ func NewMergeFailedMessage(rig, polecat, branch, issue, targetBranch, failureTyp
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
 
+	setPayload(msg, SchemaMergeFailed, payload)
+
 	return msg
 }
 
@@ -156,6 +162,8 @@ func NewReworkRequestMessage(rig, polecat, branch, issue, targetBranch string, c
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
 
+	setPayload(msg, SchemaReworkRequest, payload)
+
 	return msg
 }
 
@@ -213,6 +221,8 @@ func NewConvoyNeedsFeedingMessage(rig, convoyID, sourceIssue string) *mail.Messa
 	msg.Priority = mail.PriorityHigh
 	msg.Type = mail.TypeTask
 
+	setPayload(msg, SchemaConvoyNeedsFeeding, payload)
+
 	return msg
 }
 
@@ -419,6 +429,115 @@ func ParsePolecatDonePayload(polecatName, body string) *PolecatDonePayload {
 	return payload
 }
 
+// setPayload attaches p to msg as a typed payload. Protocol payloads are
+// plain structs, so encoding cannot fail in practice; if it does, receivers
+// fall back to the text body.
+func setPayload(msg *mail.Message, schema string, p interface{}) {
+	if payload, err := mail.NewPayload(schema, p); err == nil {
+		msg.Payload = payload
+	}
+}
+
+// decodePayload decodes msg's typed payload into v when it has the given
+// schema. It reports false when the message has no payload of that schema
+// (e.g. mail from an older sender), in which case the caller parses the body.
+func decodePayload(msg *mail.Message, schema string, v interface{}) (bool, error) {
+	if msg.Payload == nil || msg.Payload.Schema != schema {
+		return false, nil
+	}
+	return true, msg.Payload.Decode(schema, v)
+}
+
+// requireFields returns an error naming the empty fields among name/value pairs.
+func requireFields(kind string, pairs ...string) error {
+	var missing []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			missing = append(missing, pairs[i])
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("invalid %s payload: missing required fields: %s", kind, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// DecodeMergeReadyPayload returns the payload of a MERGE_READY message,
+// preferring the typed payload over parsing the text body.
+func DecodeMergeReadyPayload(msg *mail.Message) (*MergeReadyPayload, error) {
+	var p MergeReadyPayload
+	if ok, err := decodePayload(msg, SchemaMergeReady, &p); !ok {
+		return ParseMergeReadyPayload(msg.Body)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireFields("MERGE_READY", "Branch", p.Branch, "Polecat", p.Polecat, "Rig", p.Rig); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DecodeMergedPayload returns the payload of a MERGED message, preferring
+// the typed payload over parsing the text body.
+func DecodeMergedPayload(msg *mail.Message) (*MergedPayload, error) {
+	var p MergedPayload
+	if ok, err := decodePayload(msg, SchemaMerged, &p); !ok {
+		return ParseMergedPayload(msg.Body)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireFields("MERGED", "Branch", p.Branch, "Polecat", p.Polecat, "Rig", p.Rig); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DecodeMergeFailedPayload returns the payload of a MERGE_FAILED message,
+// preferring the typed payload over parsing the text body.
+func DecodeMergeFailedPayload(msg *mail.Message) (*MergeFailedPayload, error) {
+	var p MergeFailedPayload
+	if ok, err := decodePayload(msg, SchemaMergeFailed, &p); !ok {
+		return ParseMergeFailedPayload(msg.Body)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireFields("MERGE_FAILED", "Branch", p.Branch, "Polecat", p.Polecat, "Rig", p.Rig); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DecodeReworkRequestPayload returns the payload of a REWORK_REQUEST message,
+// preferring the typed payload over parsing the text body.
+func DecodeReworkRequestPayload(msg *mail.Message) (*ReworkRequestPayload, error) {
+	var p ReworkRequestPayload
+	if ok, err := decodePayload(msg, SchemaReworkRequest, &p); !ok {
+		return ParseReworkRequestPayload(msg.Body)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireFields("REWORK_REQUEST", "Branch", p.Branch, "Polecat", p.Polecat, "Rig", p.Rig); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DecodeConvoyNeedsFeedingPayload returns the payload of a
+// CONVOY_NEEDS_FEEDING message, preferring the typed payload over parsing
+// the text body.
+func DecodeConvoyNeedsFeedingPayload(msg *mail.Message) (*ConvoyNeedsFeedingPayload, error) {
+	var p ConvoyNeedsFeedingPayload
+	if ok, err := decodePayload(msg, SchemaConvoyNeedsFeeding, &p); !ok {
+		return ParseConvoyNeedsFeedingPayload(msg.Body)
+	} else if err != nil {
+		return nil, err
+	}
+	if err := requireFields("CONVOY_NEEDS_FEEDING", "ConvoyID", p.ConvoyID, "Rig", p.Rig); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
 // parseField extracts a field value from a key-value body format.
 // Format: "Key: value"
 func parseField(body, key string) string {
@@ -585,6 +585,62 @@ func TestWrapRefineryHandlers_InvalidPayload(t *testing.T) {
 	}
 }
 
+func TestNewMessages_CarryTypedPayload(t *testing.T) {
+	msg := NewReworkRequestMessage("gastown", "nux", "polecat/nux", "gt-abc", "main", []string{"a.go", "b, c.go"})
+	if msg.Payload == nil || msg.Payload.Schema != SchemaReworkRequest {
+		t.Fatalf("Payload = %+v, want schema %s", msg.Payload, SchemaReworkRequest)
+	}
+
+	// The typed payload keeps values the text body cannot round-trip, such
+	// as a file name containing ", ".
+	payload, err := DecodeReworkRequestPayload(msg)
+	if err != nil {
+		t.Fatalf("DecodeReworkRequestPayload: %v", err)
+	}
+	if len(payload.ConflictFiles) != 2 || payload.ConflictFiles[1] != "b, c.go" {
+		t.Errorf("ConflictFiles = %q", payload.ConflictFiles)
+	}
+	if payload.TargetBranch != "main" || payload.RequestedAt.IsZero() {
+		t.Errorf("payload = %+v", payload)
+	}
+}
+
+func TestDecodePayload_FallsBackToBody(t *testing.T) {
+	msg := &mail.Message{
+		Subject: "MERGE_READY nux",
+		Body:    "Branch: polecat/nux\nIssue: gt-abc\nPolecat: nux\nRig: gastown",
+	}
+	payload, err := DecodeMergeReadyPayload(msg)
+	if err != nil {
+		t.Fatalf("DecodeMergeReadyPayload: %v", err)
+	}
+	if payload.Branch != "polecat/nux" || payload.Rig != "gastown" {
+		t.Errorf("payload = %+v", payload)
+	}
+
+	// A payload with a different schema is ignored in favour of the body.
+	msg.Payload = &mail.Payload{Schema: "other/v1", Data: []byte(`{}`)}
+	if _, err := DecodeMergeReadyPayload(msg); err != nil {
+		t.Errorf("foreign schema: %v", err)
+	}
+}
+
+func TestDecodePayload_TypedMissingFields(t *testing.T) {
+	msg := &mail.Message{
+		Subject: "MERGED nux",
+		Payload: &mail.Payload{Schema: SchemaMerged, Data: []byte(`{"branch":"polecat/nux"}`)},
+	}
+	_, err := DecodeMergedPayload(msg)
+	if err == nil || !strings.Contains(err.Error(), "Polecat, Rig") {
+		t.Errorf("err = %v, want missing Polecat, Rig", err)
+	}
+
+	msg.Payload.Data = []byte(`{"branch": 1}`)
+	if _, err := DecodeMergedPayload(msg); err == nil {
+		t.Error("expected decode error for mistyped payload")
+	}
+}
+
 func TestDefaultWitnessHandler(t *testing.T) {
 	tmpDir := t.TempDir()
 	handler := NewWitnessHandler("gastown", tmpDir)
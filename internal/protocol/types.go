@@ -46,6 +46,17 @@ const (
 	TypeConvoyNeedsFeeding MessageType = "CONVOY_NEEDS_FEEDING"
 )
 
+// Payload schemas for the typed payloads carried by protocol messages. The
+// text body is still written for humans and for older receivers; handlers
+// prefer the typed payload when present.
+const (
+	SchemaMergeReady         = "protocol.merge_ready/v1"
+	SchemaMerged             = "protocol.merged/v1"
+	SchemaMergeFailed        = "protocol.merge_failed/v1"
+	SchemaReworkRequest      = "protocol.rework_request/v1"
+	SchemaConvoyNeedsFeeding = "protocol.convoy_needs_feeding/v1"
+)
+
 // ParseMessageType extracts the protocol message type from a mail subject.
 // Returns empty string if subject doesn't match a known protocol type.
 func ParseMessageType(subject string) MessageType {
@@ -150,16 +150,18 @@ func (r *Rotator) executeOne(state *config.QuotaState, mu *sync.Mutex, session,
 
 	// --- Validation phase: read-only, no side effects ---
 
-	// 1. Resolve old account from tmux session environment.
-	oldConfigDir, err := r.tmuxClient.GetEnvironment(session, "CLAUDE_CONFIG_DIR")
-	if err == nil {
-		for handle, acct := range r.accounts.Accounts {
-			if acct.ConfigDir == oldConfigDir || util.ExpandHome(acct.ConfigDir) == oldConfigDir {
-				result.OldAccount = handle
-				break
-			}
-		}
+	// 1. Resolve the session's agent and old account from its tmux environment.
+	agentName := r.agentName
+	provider := SessionProvider(r.tmuxClient, session)
+	if provider != string(config.AgentClaude) {
+		agentName = provider
+	}
+	configDirEnv := ConfigDirEnv(provider)
+	if configDirEnv == "" {
+		result.Error = fmt.Sprintf("agent %q has no config dir env var; cannot switch accounts", provider)
+		return result
 	}
+	result.OldAccount = matchAccount(r.tmuxClient, r.accounts, session, provider)
 
 	// 2. Resolve new account config dir.
 	newAcct, ok := r.accounts.Accounts[newAccount]
@@ -167,11 +169,15 @@ func (r *Rotator) executeOne(state *config.QuotaState, mu *sync.Mutex, session,
 		result.Error = fmt.Sprintf("account %q not found in config", newAccount)
 		return result
 	}
+	if newAcct.ProviderName() != provider {
+		result.Error = fmt.Sprintf("account %q is for %s, session runs %s", newAccount, newAcct.ProviderName(), provider)
+		return result
+	}
 	newConfigDir := util.ExpandHome(newAcct.ConfigDir)
 
-	// 3. Read CLAUDE_SESSION_ID from tmux session environment for resume support.
+	// 3. Read the agent's session ID from tmux session environment for resume support.
 	var sessionID string
-	sessionIDEnv := config.GetSessionIDEnvVar(r.agentName)
+	sessionIDEnv := config.GetSessionIDEnvVar(agentName)
 	if sessionIDEnv != "" {
 		sessionID, _ = r.tmuxClient.GetEnvironment(session, sessionIDEnv)
 	}
@@ -184,12 +190,14 @@ func (r *Rotator) executeOne(state *config.QuotaState, mu *sync.Mutex, session,
 	}
 
 	// 5. If session ID found + linker available, attempt resume command.
-	if sessionID != "" && r.sessionLinker != nil {
+	// The linker moves Claude session files between config dirs; other agents
+	// keep sessions elsewhere and restart fresh.
+	if sessionID != "" && r.sessionLinker != nil && provider == string(config.AgentClaude) {
 		cleanup, linkErr := r.sessionLinker(r.townRoot, sessionID, newConfigDir)
 		if linkErr != nil {
 			r.log.Warn("could not symlink session for resume in %s: %v (falling back to fresh start)", session, linkErr)
 		} else {
-			resumeCmd := config.BuildResumeCommand(r.agentName, sessionID)
+			resumeCmd := config.BuildResumeCommand(agentName, sessionID)
 			if resumeCmd != "" {
 				respawnCmd = resumeCmd
 				result.ResumedSession = sessionID
@@ -200,8 +208,8 @@ func (r *Rotator) executeOne(state *config.QuotaState, mu *sync.Mutex, session,
 		}
 	}
 
-	// 6. Prepend CLAUDE_CONFIG_DIR export.
-	respawnCmd = fmt.Sprintf("export CLAUDE_CONFIG_DIR=%q && %s", newConfigDir, respawnCmd)
+	// 6. Prepend config dir export (CLAUDE_CONFIG_DIR for Claude).
+	respawnCmd = fmt.Sprintf("export %s=%q && %s", configDirEnv, newConfigDir, respawnCmd)
 
 	// 7. Validate target pane exists.
 	pane, err := r.tmuxExec.GetPaneID(session)
@@ -212,9 +220,9 @@ func (r *Rotator) executeOne(state *config.QuotaState, mu *sync.Mutex, session,
 
 	// --- Mutation phase: all validation passed ---
 
-	// 8. Set new CLAUDE_CONFIG_DIR in tmux session environment.
-	if err := r.tmuxExec.SetEnvironment(session, "CLAUDE_CONFIG_DIR", newConfigDir); err != nil {
-		result.Error = fmt.Sprintf("setting CLAUDE_CONFIG_DIR: %v", err)
+	// 8. Set new config dir in tmux session environment.
+	if err := r.tmuxExec.SetEnvironment(session, configDirEnv, newConfigDir); err != nil {
+		result.Error = fmt.Sprintf("setting %s: %v", configDirEnv, err)
 		return result
 	}
 
@@ -0,0 +1,203 @@
+package quota
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resetClockPattern matches the clock part of a provider reset string:
+// "7pm", "7:30 pm", "3:00 AM", "19:00".
+var resetClockPattern = regexp.MustCompile(`(?i)\b(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\b`)
+
+// resetDatePattern matches an optional leading date such as "Jan 5," or "Jan 5 at".
+var resetDatePattern = regexp.MustCompile(`(?i)^([A-Za-z]{3,9})\s+(\d{1,2})(?:,|\s+at)?\s+`)
+
+// resetDurationPattern matches one component of a relative reset time:
+// "2 days", "3 hours", "15m", "45.2s".
+var resetDurationPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s)\b`)
+
+var compactDurationPattern = regexp.MustCompile(`([A-Za-z])(\d)`)
+
+// resetZonePattern matches an IANA zone in parentheses: "(America/Los_Angeles)".
+var resetZonePattern = regexp.MustCompile(`\(([A-Za-z_]+(?:/[A-Za-z_+\-0-9]+)*)\)`)
+
+// zoneAbbreviations maps common abbreviations seen in reset messages to
+// IANA zones. Abbreviations are ambiguous in general; these are the ones
+// the providers actually emit.
+var zoneAbbreviations = map[string]string{
+	"UTC": "UTC",
+	"GMT": "UTC",
+	"PST": "America/Los_Angeles",
+	"PDT": "America/Los_Angeles",
+	"PT":  "America/Los_Angeles",
+	"MST": "America/Denver",
+	"MDT": "America/Denver",
+	"CST": "America/Chicago",
+	"CDT": "America/Chicago",
+	"EST": "America/New_York",
+	"EDT": "America/New_York",
+	"ET":  "America/New_York",
+}
+
+// ParseResetTime converts a provider reset string (as captured by the
+// scanner) into an absolute time. Clock times such as
+// "7pm (America/Los_Angeles)" or "3:00 AM PST" resolve to the first matching
+// instant at or after ref, which should be when the limit was observed;
+// strings without a zone are interpreted in ref's location. Relative times
+// such as "2 hours 15 minutes" or "45.2s" are added to ref.
+func ParseResetTime(s string, ref time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty reset time")
+	}
+	if d, ok := parseResetDuration(s); ok {
+		return ref.Add(d), nil
+	}
+
+	loc := ref.Location()
+	if m := resetZonePattern.FindStringSubmatch(s); m != nil {
+		l, err := time.LoadLocation(m[1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown time zone %q: %w", m[1], err)
+		}
+		loc = l
+		s = strings.TrimSpace(resetZonePattern.ReplaceAllString(s, ""))
+	} else {
+		for _, field := range strings.Fields(s) {
+			if name, ok := zoneAbbreviations[strings.ToUpper(strings.Trim(field, ".,"))]; ok {
+				if l, err := time.LoadLocation(name); err == nil {
+					loc = l
+				}
+				break
+			}
+		}
+	}
+
+	var month time.Month
+	var day int
+	if m := resetDatePattern.FindStringSubmatch(s); m != nil {
+		month = parseMonth(m[1])
+		day, _ = strconv.Atoi(m[2])
+		if month != 0 {
+			s = s[len(m[0]):]
+		}
+	}
+
+	m := resetClockPattern.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("no clock time in %q", s)
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch strings.ToLower(m[3]) {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour != 12 {
+			hour += 12
+		}
+	default:
+		// A bare number without minutes or am/pm is not a clock time.
+		if m[2] == "" {
+			return time.Time{}, fmt.Errorf("no clock time in %q", s)
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid clock time in %q", s)
+	}
+
+	local := ref.In(loc)
+	if month != 0 {
+		t := time.Date(local.Year(), month, day, hour, minute, 0, 0, loc)
+		// A date that already passed by more than a day belongs to next year
+		// (e.g. "Jan 2" seen on Dec 30).
+		if t.Before(local.Add(-24 * time.Hour)) {
+			t = t.AddDate(1, 0, 0)
+		}
+		return t, nil
+	}
+
+	t := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if t.Before(local) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// parseResetDuration sums the duration components of a relative reset time.
+// Strings that contain a clock time ("7pm", "3:00") are not durations.
+func parseResetDuration(s string) (time.Duration, bool) {
+	if m := resetClockPattern.FindStringSubmatch(s); m != nil && (m[2] != "" || m[3] != "") {
+		return 0, false
+	}
+	// Split compact forms like "1h30m" so each unit ends on a word boundary.
+	s = compactDurationPattern.ReplaceAllString(s, "$1 $2")
+	matches := resetDurationPattern.FindAllStringSubmatch(s, -1)
+	if len(matches) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, m := range matches {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		var unit time.Duration
+		switch u := strings.ToLower(m[2]); {
+		case strings.HasPrefix(u, "d"):
+			unit = 24 * time.Hour
+		case strings.HasPrefix(u, "h"):
+			unit = time.Hour
+		case strings.HasPrefix(u, "m"):
+			unit = time.Minute
+		default:
+			unit = time.Second
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	return total, true
+}
+
+func parseMonth(s string) time.Month {
+	s = strings.ToLower(s)
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if s == name || (len(s) >= 3 && strings.HasPrefix(name, s)) {
+			return m
+		}
+	}
+	return 0
+}
+
+// resetTimeRFC3339 parses resetsAt relative to ref and formats it as RFC3339
+// UTC, or returns "" if it cannot be parsed.
+func resetTimeRFC3339(resetsAt string, ref time.Time) string {
+	if resetsAt == "" {
+		return ""
+	}
+	t, err := ParseResetTime(resetsAt, ref)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// hasReset reports whether a limited account's parsed reset time has passed.
+func hasReset(resetTime string, now time.Time) bool {
+	if resetTime == "" {
+		return false
+	}
+	t, err := time.Parse(time.RFC3339, resetTime)
+	if err != nil {
+		return false
+	}
+	return !now.Before(t)
+}
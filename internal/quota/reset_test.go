@@ -0,0 +1,76 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResetTime_Absolute(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	// 2026-01-15 10:00 in Los Angeles.
+	ref := time.Date(2026, 1, 15, 10, 0, 0, 0, la)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"7pm (America/Los_Angeles)", time.Date(2026, 1, 15, 19, 0, 0, 0, la)},
+		{"9am (America/Los_Angeles)", time.Date(2026, 1, 16, 9, 0, 0, 0, la)},
+		{"3:30 PM PST", time.Date(2026, 1, 15, 15, 30, 0, 0, la)},
+		{"12am (America/Los_Angeles)", time.Date(2026, 1, 16, 0, 0, 0, 0, la)},
+		{"Jan 20, 7pm (America/Los_Angeles)", time.Date(2026, 1, 20, 19, 0, 0, 0, la)},
+		{"18:45 UTC", time.Date(2026, 1, 15, 18, 45, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseResetTime(tt.in, ref)
+			if err != nil {
+				t.Fatalf("ParseResetTime(%q): %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseResetTime(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "soon", "7pm (Not/AZone)"} {
+		if _, err := ParseResetTime(bad, ref); err == nil {
+			t.Errorf("ParseResetTime(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestParseResetTime_DateRollsToNextYear(t *testing.T) {
+	ref := time.Date(2026, 12, 30, 12, 0, 0, 0, time.UTC)
+	got, err := ParseResetTime("Jan 2, 7pm UTC", ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Year() != 2027 {
+		t.Errorf("year = %d, want 2027", got.Year())
+	}
+}
+
+func TestParseResetTime_Relative(t *testing.T) {
+	ref := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"45.5s":                   45500 * time.Millisecond,
+		"2 hours 15 minutes":      2*time.Hour + 15*time.Minute,
+		"1 day 3 hours 5 minutes": 27*time.Hour + 5*time.Minute,
+		"in 5 hours":              5 * time.Hour,
+		"1h30m":                   90 * time.Minute,
+	}
+	for in, want := range tests {
+		got, err := ParseResetTime(in, ref)
+		if err != nil {
+			t.Errorf("ParseResetTime(%q): %v", in, err)
+			continue
+		}
+		if d := got.Sub(ref); d != want {
+			t.Errorf("ParseResetTime(%q) = ref+%v, want ref+%v", in, d, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package quota
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/steveyegge/gastown/internal/config"
 )
@@ -26,6 +27,10 @@ type RotatePlan struct {
 
 	// Assignments maps session -> new account handle.
 	Assignments map[string]string
+
+	// Unrotatable maps limited sessions that cannot be assigned an account
+	// to the reason why.
+	Unrotatable map[string]string
 }
 
 // PlanRotation scans for limited sessions and plans account assignments.
@@ -53,13 +58,17 @@ func PlanRotation(scanner *Scanner, mgr *Manager, acctCfg *config.AccountsConfig
 	}
 
 	// Update state: mark detected limited accounts
+	now := time.Now()
 	for _, r := range limitedSessions {
 		if r.AccountHandle != "" {
-			state.Accounts[r.AccountHandle] = config.AccountQuotaState{
-				Status:    config.QuotaStatusLimited,
-				LimitedAt: state.Accounts[r.AccountHandle].LimitedAt,
-				ResetsAt:  r.ResetsAt,
-				LastUsed:  state.Accounts[r.AccountHandle].LastUsed,
+			existing := state.Accounts[r.AccountHandle]
+			limited := LimitedState(existing, r.ResetsAt, now)
+			if existing.LimitedAt != "" {
+				limited.LimitedAt = existing.LimitedAt
+			}
+			state.Accounts[r.AccountHandle] = limited
+			if existing.Status != config.QuotaStatusLimited {
+				RecordLimitUsage(state, r.AccountHandle, acctCfg.Accounts[r.AccountHandle], now)
 			}
 		}
 	}
@@ -68,25 +77,42 @@ func PlanRotation(scanner *Scanner, mgr *Manager, acctCfg *config.AccountsConfig
 	available := mgr.AvailableAccounts(state)
 
 	// Plan assignments: assign all limited sessions to the best available account.
-	// Strategy: pick the first available account (LRU) that isn't already the
-	// session's current account. All sessions rotate to the same account so the
-	// operator can drain one account at a time, then move on.
+	// Strategy: pick the first available account (LRU) of the session's
+	// provider that isn't already the session's current account. All sessions
+	// of a provider rotate to the same account so the operator can drain one
+	// account at a time, then move on. Providers without a config dir env var
+	// cannot switch accounts; their sessions are reported as unrotatable.
 	assignments := make(map[string]string)
-	if len(available) > 0 {
-		for _, r := range limitedSessions {
-			// Find the first available account that differs from current
-			for _, candidate := range available {
-				if candidate != r.AccountHandle {
-					assignments[r.Session] = candidate
-					break
-				}
+	unrotatable := make(map[string]string)
+	for _, r := range limitedSessions {
+		provider := r.Provider
+		if provider == "" {
+			provider = string(config.AgentClaude)
+		}
+		if ConfigDirEnv(provider) == "" {
+			unrotatable[r.Session] = fmt.Sprintf("provider %s does not support account switching", provider)
+			continue
+		}
+		// Find the first available account that differs from current
+		for _, candidate := range available {
+			acct, ok := acctCfg.Accounts[candidate]
+			if !ok || acct.ProviderName() != provider {
+				continue
+			}
+			if candidate != r.AccountHandle {
+				assignments[r.Session] = candidate
+				break
 			}
 		}
+		if _, ok := assignments[r.Session]; !ok {
+			unrotatable[r.Session] = fmt.Sprintf("no available %s account", provider)
+		}
 	}
 
 	return &RotatePlan{
 		LimitedSessions:   limitedSessions,
 		AvailableAccounts: available,
 		Assignments:       assignments,
+		Unrotatable:       unrotatable,
 	}, nil
 }
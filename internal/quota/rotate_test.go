@@ -1,6 +1,7 @@
 package quota
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/steveyegge/gastown/internal/config"
@@ -287,3 +288,62 @@ func TestPlanRotation_MultipleLimitedSessions(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanRotation_ProviderPools(t *testing.T) {
+	setupTestRegistry(t)
+	config.RegisterAgentForTesting("acme", config.AgentPresetInfo{
+		Name:              "acme",
+		Command:           "acme",
+		ConfigDirEnv:      "ACME_HOME",
+		RateLimitPatterns: []string{`acme quota exhausted`},
+	})
+	t.Cleanup(config.ResetRegistryForTesting)
+
+	tmux := &mockTmux{
+		sessions: []string{"gt-crew-bear", "gt-crew-fox", "gt-crew-gem"},
+		paneContent: map[string]string{
+			"gt-crew-bear": "You've hit your limit · resets 7pm (America/Los_Angeles)",
+			"gt-crew-fox":  "acme quota exhausted",
+			"gt-crew-gem":  "RESOURCE_EXHAUSTED",
+		},
+		envVars: map[string]map[string]string{
+			"gt-crew-bear": {"CLAUDE_CONFIG_DIR": "/accts/claude-a"},
+			"gt-crew-fox":  {"GT_AGENT": "acme", "ACME_HOME": "/accts/acme-a"},
+			"gt-crew-gem":  {"GT_AGENT": "gemini"},
+		},
+	}
+	accounts := &config.AccountsConfig{
+		Accounts: map[string]config.Account{
+			"claude-a": {ConfigDir: "/accts/claude-a"},
+			"claude-b": {ConfigDir: "/accts/claude-b"},
+			"acme-a":   {ConfigDir: "/accts/acme-a", Provider: "acme"},
+			"acme-b":   {ConfigDir: "/accts/acme-b", Provider: "acme"},
+		},
+	}
+
+	scanner, err := NewScanner(tmux, nil, accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := PlanRotation(scanner, NewManager(setupTestTown(t)), accounts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(plan.LimitedSessions) != 3 {
+		t.Fatalf("expected 3 limited sessions, got %d", len(plan.LimitedSessions))
+	}
+	if got := plan.Assignments["gt-crew-bear"]; got != "claude-b" {
+		t.Errorf("claude session assigned %q, want claude-b", got)
+	}
+	if got := plan.Assignments["gt-crew-fox"]; got != "acme-b" {
+		t.Errorf("acme session assigned %q, want acme-b", got)
+	}
+	// Gemini has no config dir env var, so it cannot be rotated.
+	if got, ok := plan.Assignments["gt-crew-gem"]; ok {
+		t.Errorf("gemini session assigned %q, want no assignment", got)
+	}
+	if reason := plan.Unrotatable["gt-crew-gem"]; !strings.Contains(reason, "gemini") {
+		t.Errorf("gemini session unrotatable reason = %q, want it to name the provider", reason)
+	}
+}
@@ -14,6 +14,7 @@ import (
 // ScanResult holds the result of scanning a single tmux session.
 type ScanResult struct {
 	Session       string `json:"session"`                  // tmux session name
+	Provider      string `json:"provider,omitempty"`       // agent preset running in the session (GT_AGENT)
 	AccountHandle string `json:"account_handle,omitempty"` // resolved account handle
 	RateLimited   bool   `json:"rate_limited"`             // whether rate-limit was detected
 	MatchedLine   string `json:"matched_line,omitempty"`   // the line that matched
@@ -31,17 +32,46 @@ type TmuxClient interface {
 // Scanner detects rate-limited sessions by examining tmux pane content.
 type Scanner struct {
 	tmux     TmuxClient
-	patterns []*regexp.Regexp
+	patterns []*regexp.Regexp // explicit override for every session; nil = per-provider
 	accounts *config.AccountsConfig
+
+	// byProvider caches compiled preset signatures, keyed by provider.
+	byProvider map[string]*providerSignatures
+}
+
+// providerSignatures are an agent preset's compiled rate-limit signatures.
+type providerSignatures struct {
+	limit []*regexp.Regexp
+	reset *regexp.Regexp // nil = Claude's "resets <time>" extraction
 }
 
 // NewScanner creates a scanner with the given tmux client and rate-limit patterns.
-// If patterns is nil, DefaultRateLimitPatterns are used.
+// If patterns is nil, each session is matched against the rate-limit
+// signatures of its agent preset (GT_AGENT), falling back to
+// DefaultRateLimitPatterns for presets that define none.
 func NewScanner(tmux TmuxClient, patterns []string, accounts *config.AccountsConfig) (*Scanner, error) {
-	if len(patterns) == 0 {
-		patterns = constants.DefaultRateLimitPatterns
+	s := &Scanner{
+		tmux:       tmux,
+		accounts:   accounts,
+		byProvider: make(map[string]*providerSignatures),
 	}
+	if len(patterns) > 0 {
+		compiled, err := compilePatterns(patterns)
+		if err != nil {
+			return nil, err
+		}
+		s.patterns = compiled
+		return s, nil
+	}
+
+	// Validate the default patterns up front so a bad constant fails fast.
+	if _, err := compilePatterns(constants.DefaultRateLimitPatterns); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
 
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
 	compiled := make([]*regexp.Regexp, 0, len(patterns))
 	for _, p := range patterns {
 		re, err := regexp.Compile("(?i)" + p)
@@ -50,12 +80,32 @@ func NewScanner(tmux TmuxClient, patterns []string, accounts *config.AccountsCon
 		}
 		compiled = append(compiled, re)
 	}
+	return compiled, nil
+}
+
+// signatures returns the compiled rate-limit signatures for provider.
+// Presets with invalid or missing patterns fall back to the defaults.
+func (s *Scanner) signatures(provider string) *providerSignatures {
+	if sig, ok := s.byProvider[provider]; ok {
+		return sig
+	}
 
-	return &Scanner{
-		tmux:     tmux,
-		patterns: compiled,
-		accounts: accounts,
-	}, nil
+	sig := &providerSignatures{}
+	if preset := config.GetAgentPresetByName(provider); preset != nil {
+		if limit, err := compilePatterns(preset.RateLimitPatterns); err == nil {
+			sig.limit = limit
+		}
+		if preset.ResetTimePattern != "" {
+			if re, err := regexp.Compile("(?i)" + preset.ResetTimePattern); err == nil {
+				sig.reset = re
+			}
+		}
+	}
+	if len(sig.limit) == 0 {
+		sig.limit, _ = compilePatterns(constants.DefaultRateLimitPatterns)
+	}
+	s.byProvider[provider] = sig
+	return sig
 }
 
 // scanLines is the number of pane lines to capture for rate-limit detection.
@@ -88,9 +138,17 @@ func (s *Scanner) ScanAll() ([]ScanResult, error) {
 // scanSession examines a single tmux session for rate-limit indicators.
 func (s *Scanner) scanSession(session string) ScanResult {
 	result := ScanResult{Session: session}
+	result.Provider = SessionProvider(s.tmux, session)
 
-	// Derive account from CLAUDE_CONFIG_DIR
-	result.AccountHandle = s.resolveAccountHandle(session)
+	// Derive account from the provider's config dir env var
+	result.AccountHandle = s.resolveAccountHandle(session, result.Provider)
+
+	patterns := s.patterns
+	var resetRe *regexp.Regexp
+	if patterns == nil {
+		sig := s.signatures(result.Provider)
+		patterns, resetRe = sig.limit, sig.reset
+	}
 
 	// Capture pane content
 	content, err := s.tmux.CapturePane(session, scanLines)
@@ -105,11 +163,11 @@ func (s *Scanner) scanSession(session string) ScanResult {
 		if line == "" {
 			continue
 		}
-		for _, re := range s.patterns {
+		for _, re := range patterns {
 			if re.MatchString(line) {
 				result.RateLimited = true
 				result.MatchedLine = line
-				result.ResetsAt = parseResetTime(line)
+				result.ResetsAt = extractResetTime(resetRe, line)
 				return result
 			}
 		}
@@ -118,26 +176,61 @@ func (s *Scanner) scanSession(session string) ScanResult {
 	return result
 }
 
-// resolveAccountHandle maps a session's CLAUDE_CONFIG_DIR back to an account handle.
-func (s *Scanner) resolveAccountHandle(session string) string {
+// resolveAccountHandle maps a session's config dir env var (CLAUDE_CONFIG_DIR
+// for Claude) back to an account handle of the same provider.
+func (s *Scanner) resolveAccountHandle(session, provider string) string {
 	if s.accounts == nil {
 		return ""
 	}
+	return matchAccount(s.tmux, s.accounts, session, provider)
+}
 
-	configDir, err := s.tmux.GetEnvironment(session, "CLAUDE_CONFIG_DIR")
+// matchAccount finds the account of provider whose config dir is set in the
+// session's environment. Returns "" if the provider has no config dir env var
+// or the directory matches no registered account.
+func matchAccount(tmux TmuxClient, accounts *config.AccountsConfig, session, provider string) string {
+	envVar := ConfigDirEnv(provider)
+	if envVar == "" {
+		return ""
+	}
+	configDir, err := tmux.GetEnvironment(session, envVar)
 	if err != nil {
-		return "" // No CLAUDE_CONFIG_DIR = using default config
+		return "" // Not set = using default config
 	}
 
 	configDir = strings.TrimSpace(configDir)
-	for handle, acct := range s.accounts.Accounts {
+	for handle, acct := range accounts.Accounts {
+		if acct.ProviderName() != provider {
+			continue
+		}
 		// Compare normalized paths (accounts may use ~/... while tmux has expanded)
 		if acct.ConfigDir == configDir || util.ExpandHome(acct.ConfigDir) == configDir {
 			return handle
 		}
 	}
 
-	return "" // CLAUDE_CONFIG_DIR doesn't match any registered account
+	return "" // config dir doesn't match any registered account
+}
+
+// SessionProvider returns the agent preset running in a session, read from
+// its GT_AGENT environment variable. Sessions without GT_AGENT, or running an
+// agent not in the preset registry (e.g. a custom Claude wrapper from town
+// settings), are treated as Claude.
+func SessionProvider(tmux TmuxClient, session string) string {
+	agent, err := tmux.GetEnvironment(session, "GT_AGENT")
+	if agent = strings.TrimSpace(agent); err != nil || agent == "" || config.GetAgentPresetByName(agent) == nil {
+		return string(config.AgentClaude)
+	}
+	return agent
+}
+
+// ConfigDirEnv returns the environment variable that selects provider's
+// account config dir, or "" if the provider cannot switch accounts that way.
+func ConfigDirEnv(provider string) string {
+	if preset := config.GetAgentPresetByName(provider); preset != nil {
+		return preset.ConfigDirEnv
+	}
+	return ""
 }
 
 // isGasTownSession returns true if the session name belongs to Gas Town.
@@ -155,7 +248,16 @@ func isGasTownSession(sess string) bool {
 var resetTimePattern = regexp.MustCompile(`(?i)\bresets\s+(.+)`)
 
 func parseResetTime(line string) string {
-	m := resetTimePattern.FindStringSubmatch(line)
+	return extractResetTime(resetTimePattern, line)
+}
+
+// extractResetTime returns the first capture group of re in line, using the
+// Claude "resets <time>" pattern when re is nil.
+func extractResetTime(re *regexp.Regexp, line string) string {
+	if re == nil {
+		re = resetTimePattern
+	}
+	m := re.FindStringSubmatch(line)
 	if len(m) < 2 {
 		return ""
 	}
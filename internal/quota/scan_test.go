@@ -416,3 +416,61 @@ func TestScanAll_ListSessionsError(t *testing.T) {
 		t.Error("expected error when ListSessions fails")
 	}
 }
+
+func TestScanAll_PerProviderSignatures(t *testing.T) {
+	setupTestRegistry(t)
+
+	tmux := &mockTmux{
+		sessions: []string{"gt-crew-gem", "gt-crew-codex", "gt-crew-claude"},
+		paneContent: map[string]string{
+			"gt-crew-gem":   "Error: RESOURCE_EXHAUSTED. Please retry in 41.5s.",
+			"gt-crew-codex": "■ You've hit your usage limit. Upgrade to Pro or try again in 2 hours 5 minutes.",
+			// Gemini's signature in a Claude session is not a Claude rate limit.
+			"gt-crew-claude": "tool output: RESOURCE_EXHAUSTED",
+		},
+		envVars: map[string]map[string]string{
+			"gt-crew-gem":    {"GT_AGENT": "gemini"},
+			"gt-crew-codex":  {"GT_AGENT": "codex"},
+			"gt-crew-claude": {},
+		},
+	}
+
+	scanner, err := NewScanner(tmux, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := scanner.ScanAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]ScanResult)
+	for _, r := range results {
+		byName[r.Session] = r
+	}
+
+	if gem := byName["gt-crew-gem"]; !gem.RateLimited || gem.Provider != "gemini" || gem.ResetsAt != "41.5s" {
+		t.Errorf("gemini result = %+v", gem)
+	}
+	if codex := byName["gt-crew-codex"]; !codex.RateLimited || codex.ResetsAt != "2 hours 5 minutes" {
+		t.Errorf("codex result = %+v", codex)
+	}
+	if claude := byName["gt-crew-claude"]; claude.RateLimited || claude.Provider != "claude" {
+		t.Errorf("claude result = %+v", claude)
+	}
+}
+
+func TestSessionProvider_UnknownAgentIsClaude(t *testing.T) {
+	tmux := &mockTmux{envVars: map[string]map[string]string{
+		"a": {"GT_AGENT": "my-claude-wrapper"},
+		"b": {"GT_AGENT": "gemini"},
+	}}
+	if got := SessionProvider(tmux, "a"); got != "claude" {
+		t.Errorf("SessionProvider(custom) = %q, want claude", got)
+	}
+	if got := SessionProvider(tmux, "b"); got != "gemini" {
+		t.Errorf("SessionProvider(gemini) = %q, want gemini", got)
+	}
+	if got := SessionProvider(tmux, "missing"); got != "claude" {
+		t.Errorf("SessionProvider(no env) = %q, want claude", got)
+	}
+}
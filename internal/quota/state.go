@@ -121,17 +121,25 @@ func (m *Manager) MarkLimited(handle string, resetsAt string) error {
 		return err
 	}
 
-	now := time.Now().UTC().Format(time.RFC3339)
-	state.Accounts[handle] = config.AccountQuotaState{
-		Status:    config.QuotaStatusLimited,
-		LimitedAt: now,
-		ResetsAt:  resetsAt,
-		LastUsed:  state.Accounts[handle].LastUsed,
-	}
+	state.Accounts[handle] = LimitedState(state.Accounts[handle], resetsAt, time.Now())
 
 	return util.EnsureDirAndWriteJSON(m.statePath(), state)
 }
 
+// LimitedState returns existing marked as rate-limited at now, with resetsAt
+// parsed into an absolute ResetTime when possible. LastUsed and the
+// observed LimitTokens carry over.
+func LimitedState(existing config.AccountQuotaState, resetsAt string, now time.Time) config.AccountQuotaState {
+	return config.AccountQuotaState{
+		Status:      config.QuotaStatusLimited,
+		LimitedAt:   now.UTC().Format(time.RFC3339),
+		ResetsAt:    resetsAt,
+		ResetTime:   resetTimeRFC3339(resetsAt, now),
+		LastUsed:    existing.LastUsed,
+		LimitTokens: existing.LimitTokens,
+	}
+}
+
 // MarkAvailable marks an account as available (not rate-limited).
 func (m *Manager) MarkAvailable(handle string) error {
 	unlock, err := m.lock()
@@ -147,19 +155,22 @@ func (m *Manager) MarkAvailable(handle string) error {
 
 	existing := state.Accounts[handle]
 	state.Accounts[handle] = config.AccountQuotaState{
-		Status:   config.QuotaStatusAvailable,
-		LastUsed: existing.LastUsed,
+		Status:      config.QuotaStatusAvailable,
+		LastUsed:    existing.LastUsed,
+		LimitTokens: existing.LimitTokens,
 	}
 
 	return util.EnsureDirAndWriteJSON(m.statePath(), state)
 }
 
 // AvailableAccounts returns account handles that are not rate-limited,
-// sorted by least-recently-used first.
+// sorted by least-recently-used first. Limited accounts whose parsed reset
+// time has passed count as available.
 func (m *Manager) AvailableAccounts(state *config.QuotaState) []string {
+	now := time.Now()
 	var available []string
 	for handle, acctState := range state.Accounts {
-		if acctState.Status == config.QuotaStatusAvailable || acctState.Status == "" {
+		if IsAvailable(acctState, now) {
 			available = append(available, handle)
 		}
 	}
@@ -168,11 +179,42 @@ func (m *Manager) AvailableAccounts(state *config.QuotaState) []string {
 	return available
 }
 
+// IsAvailable reports whether an account can take new work at now: it is
+// not rate-limited, or its limit's parsed reset time has passed.
+func IsAvailable(acctState config.AccountQuotaState, now time.Time) bool {
+	switch acctState.Status {
+	case config.QuotaStatusAvailable, "":
+		return true
+	case config.QuotaStatusLimited:
+		return hasReset(acctState.ResetTime, now)
+	default:
+		return false
+	}
+}
+
+// MarkUsed records that handle was just assigned to a session.
+func (m *Manager) MarkUsed(handle string) error {
+	return m.WithLock(func() error {
+		state, err := m.Load()
+		if err != nil {
+			return err
+		}
+		existing := state.Accounts[handle]
+		if existing.Status == "" {
+			existing.Status = config.QuotaStatusAvailable
+		}
+		existing.LastUsed = time.Now().UTC().Format(time.RFC3339)
+		state.Accounts[handle] = existing
+		return m.SaveUnlocked(state)
+	})
+}
+
 // LimitedAccounts returns account handles that are currently rate-limited.
 func (m *Manager) LimitedAccounts(state *config.QuotaState) []string {
+	now := time.Now()
 	var limited []string
 	for handle, acctState := range state.Accounts {
-		if acctState.Status == config.QuotaStatusLimited {
+		if acctState.Status == config.QuotaStatusLimited && !hasReset(acctState.ResetTime, now) {
 			limited = append(limited, handle)
 		}
 	}
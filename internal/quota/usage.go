@@ -0,0 +1,256 @@
+package quota
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// UsageWindow is the rolling window over which provider session limits are
+// enforced. Consumption older than this no longer counts against an account.
+const UsageWindow = 5 * time.Hour
+
+// AccountUsage is an account's token consumption within the usage window,
+// read from the transcripts under its CLAUDE_CONFIG_DIR.
+type AccountUsage struct {
+	WindowTokens int64     `json:"window_tokens"`           // input + output + cache-creation tokens
+	Messages     int       `json:"messages"`                // assistant messages counted
+	LastActivity time.Time `json:"last_activity,omitempty"` // newest counted message
+}
+
+// usageLine is the subset of a transcript line needed for usage accounting.
+type usageLine struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Message   *struct {
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ScanUsage sums assistant token usage since the given time across all
+// transcripts under configDir/projects. Cache reads are excluded: they are
+// billed at a small fraction of input and would otherwise dominate the count.
+// A missing projects directory yields zero usage.
+func ScanUsage(configDir string, since time.Time) (AccountUsage, error) {
+	var usage AccountUsage
+	root := filepath.Join(util.ExpandHome(configDir), "projects")
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return nil // Skip unreadable entries
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".jsonl") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.ModTime().Before(since) {
+			return nil
+		}
+		scanTranscriptUsage(path, since, &usage)
+		return nil
+	})
+	return usage, err
+}
+
+func scanTranscriptUsage(path string, since time.Time, usage *AccountUsage) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is from walking the account config dir
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 256*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Cheap pre-filter: most lines are not assistant messages with usage.
+		if !strings.Contains(string(line), `"usage"`) {
+			continue
+		}
+		var msg usageLine
+		if err := json.Unmarshal(line, &msg); err != nil {
+			continue
+		}
+		if msg.Type != "assistant" || msg.Message == nil || msg.Message.Usage == nil {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, msg.Timestamp)
+		if err != nil || ts.Before(since) {
+			continue
+		}
+		u := msg.Message.Usage
+		usage.WindowTokens += u.InputTokens + u.CacheCreationInputTokens + u.OutputTokens
+		usage.Messages++
+		if ts.After(usage.LastActivity) {
+			usage.LastActivity = ts
+		}
+	}
+}
+
+// usageWindowStart is where an account's current usage window begins: the
+// rolling UsageWindow, or its last reset if that is more recent.
+func usageWindowStart(acctState config.AccountQuotaState, now time.Time) time.Time {
+	start := now.Add(-UsageWindow)
+	if acctState.ResetTime != "" {
+		if t, err := time.Parse(time.RFC3339, acctState.ResetTime); err == nil && t.After(start) && !t.After(now) {
+			start = t
+		}
+	}
+	return start
+}
+
+// AccountHeadroom is an account's estimated remaining capacity.
+type AccountHeadroom struct {
+	Handle       string `json:"handle"`
+	Available    bool   `json:"available"`
+	WindowTokens int64  `json:"window_tokens"`
+	// LimitTokens is the estimated window budget: the account's own observed
+	// limit, else the largest limit observed on any account, else 0 (unknown).
+	LimitTokens int64 `json:"limit_tokens,omitempty"`
+	// Headroom is the fraction of LimitTokens remaining, or -1 if unknown.
+	Headroom float64 `json:"headroom"`
+	LastUsed string  `json:"last_used,omitempty"`
+}
+
+// RankAccounts estimates headroom for each handle and orders them most
+// likely to last first: available before limited, then by remaining tokens
+// (or, with no observed limits, by least consumption), then least recently
+// used.
+func RankAccounts(handles []string, state *config.QuotaState, usage map[string]AccountUsage, now time.Time) []AccountHeadroom {
+	var fallbackLimit int64
+	for _, h := range handles {
+		if l := state.Accounts[h].LimitTokens; l > fallbackLimit {
+			fallbackLimit = l
+		}
+	}
+
+	ranked := make([]AccountHeadroom, 0, len(handles))
+	for _, h := range handles {
+		st := state.Accounts[h]
+		r := AccountHeadroom{
+			Handle:       h,
+			Available:    IsAvailable(st, now),
+			WindowTokens: usage[h].WindowTokens,
+			LimitTokens:  st.LimitTokens,
+			Headroom:     -1,
+			LastUsed:     st.LastUsed,
+		}
+		if r.LimitTokens == 0 {
+			r.LimitTokens = fallbackLimit
+		}
+		if r.LimitTokens > 0 {
+			r.Headroom = 1 - float64(r.WindowTokens)/float64(r.LimitTokens)
+			if r.Headroom < 0 {
+				r.Headroom = 0
+			}
+		}
+		ranked = append(ranked, r)
+	}
+
+	remaining := func(r AccountHeadroom) int64 {
+		if r.LimitTokens > 0 {
+			return r.LimitTokens - r.WindowTokens
+		}
+		return -r.WindowTokens
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Available != b.Available {
+			return a.Available
+		}
+		if ra, rb := remaining(a), remaining(b); ra != rb {
+			return ra > rb
+		}
+		if a.LastUsed != b.LastUsed {
+			return a.LastUsed < b.LastUsed
+		}
+		return a.Handle < b.Handle
+	})
+	return ranked
+}
+
+// AccountUsages scans window usage for every Claude account in acctCfg.
+// Accounts whose transcripts cannot be read report zero usage. Other
+// providers' transcripts are not in Claude's format, so their accounts are
+// omitted and RankAccounts orders them by least recent use.
+func AccountUsages(acctCfg *config.AccountsConfig, state *config.QuotaState, now time.Time) map[string]AccountUsage {
+	usage := make(map[string]AccountUsage, len(acctCfg.Accounts))
+	for handle, acct := range acctCfg.Accounts {
+		if acct.ConfigDir == "" || acct.ProviderName() != string(config.AgentClaude) {
+			continue
+		}
+		u, _ := ScanUsage(acct.ConfigDir, usageWindowStart(state.Accounts[handle], now))
+		usage[handle] = u
+	}
+	return usage
+}
+
+// SelectAccount picks the account of provider most likely to last for a new
+// session and records it as used. Returns "" (and no error) when no account is
+// available, leaving the caller to fall back to the default account. The
+// quota lock is held from load to save, as in Rotator.Execute, so concurrent
+// spawns see each other's picks and spread across accounts.
+func SelectAccount(townRoot string, acctCfg *config.AccountsConfig, provider string) (string, error) {
+	mgr := NewManager(townRoot)
+	var handle string
+	err := mgr.WithLock(func() error {
+		state, err := mgr.Load()
+		if err != nil {
+			return err
+		}
+		mgr.EnsureAccountsTracked(state, acctCfg.Accounts)
+
+		now := time.Now()
+		handles := make([]string, 0, len(acctCfg.Accounts))
+		for h, acct := range acctCfg.Accounts {
+			if acct.ProviderName() == provider {
+				handles = append(handles, h)
+			}
+		}
+		ranked := RankAccounts(handles, state, AccountUsages(acctCfg, state, now), now)
+		if len(ranked) == 0 || !ranked[0].Available {
+			return nil
+		}
+
+		handle = ranked[0].Handle
+		st := state.Accounts[handle]
+		st.LastUsed = now.UTC().Format(time.RFC3339)
+		state.Accounts[handle] = st
+		return mgr.SaveUnlocked(state)
+	})
+	if err != nil {
+		return "", err
+	}
+	return handle, nil
+}
+
+// RecordLimitUsage stores the window consumption observed when handle hit its
+// limit, so later estimates know roughly how much the account can take.
+// Call after marking the account limited.
+func RecordLimitUsage(state *config.QuotaState, handle string, acct config.Account, now time.Time) {
+	if acct.ConfigDir == "" || acct.ProviderName() != string(config.AgentClaude) {
+		return
+	}
+	u, err := ScanUsage(acct.ConfigDir, now.Add(-UsageWindow))
+	if err != nil || u.WindowTokens == 0 {
+		return
+	}
+	st := state.Accounts[handle]
+	st.LimitTokens = u.WindowTokens
+	state.Accounts[handle] = st
+}
@@ -0,0 +1,185 @@
+package quota
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+func writeTranscript(t *testing.T, configDir string, lines ...string) {
+	t.Helper()
+	dir := filepath.Join(configDir, "projects", "-town-rig")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanUsage(t *testing.T) {
+	configDir := t.TempDir()
+	now := time.Now().UTC()
+	recent := now.Add(-time.Hour).Format(time.RFC3339)
+	old := now.Add(-10 * time.Hour).Format(time.RFC3339)
+
+	writeTranscript(t, configDir,
+		`{"type":"user","timestamp":"`+recent+`","message":{"role":"user"}}`,
+		`{"type":"assistant","timestamp":"`+recent+`","message":{"usage":{"input_tokens":100,"cache_creation_input_tokens":50,"cache_read_input_tokens":9999,"output_tokens":25}}}`,
+		`{"type":"assistant","timestamp":"`+old+`","message":{"usage":{"input_tokens":1000,"output_tokens":1000}}}`,
+		`not json "usage"`,
+	)
+
+	u, err := ScanUsage(configDir, now.Add(-UsageWindow))
+	if err != nil {
+		t.Fatalf("ScanUsage: %v", err)
+	}
+	if u.WindowTokens != 175 {
+		t.Errorf("WindowTokens = %d, want 175 (cache reads and old messages excluded)", u.WindowTokens)
+	}
+	if u.Messages != 1 {
+		t.Errorf("Messages = %d, want 1", u.Messages)
+	}
+
+	// Missing projects dir is zero usage, not an error.
+	if u, err := ScanUsage(t.TempDir(), now.Add(-UsageWindow)); err != nil || u.WindowTokens != 0 {
+		t.Errorf("empty config dir: usage=%+v err=%v", u, err)
+	}
+}
+
+func TestRankAccounts(t *testing.T) {
+	now := time.Date(2026, 1, 15, 12, 0, 0, 0, time.UTC)
+	state := &config.QuotaState{Accounts: map[string]config.AccountQuotaState{
+		"busy":    {Status: config.QuotaStatusAvailable, LimitTokens: 1000},
+		"idle":    {Status: config.QuotaStatusAvailable},
+		"limited": {Status: config.QuotaStatusLimited, ResetTime: now.Add(time.Hour).Format(time.RFC3339)},
+		"reset":   {Status: config.QuotaStatusLimited, ResetTime: now.Add(-time.Hour).Format(time.RFC3339), LimitTokens: 1000},
+	}}
+	usage := map[string]AccountUsage{
+		"busy":  {WindowTokens: 900},
+		"idle":  {WindowTokens: 200},
+		"reset": {WindowTokens: 0},
+	}
+
+	ranked := RankAccounts([]string{"busy", "idle", "limited", "reset"}, state, usage, now)
+	var order []string
+	for _, r := range ranked {
+		order = append(order, r.Handle)
+	}
+	want := []string{"reset", "idle", "busy", "limited"}
+	if strings.Join(order, ",") != strings.Join(want, ",") {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if ranked[len(ranked)-1].Available {
+		t.Error("limited account before reset should be unavailable")
+	}
+	// idle has no observed limit of its own and borrows the largest one.
+	for _, r := range ranked {
+		if r.Handle == "idle" && (r.LimitTokens != 1000 || r.Headroom != 0.8) {
+			t.Errorf("idle headroom = %+v", r)
+		}
+	}
+}
+
+func TestRankAccounts_NoLimitsPrefersLeastUsed(t *testing.T) {
+	now := time.Now()
+	state := &config.QuotaState{Accounts: map[string]config.AccountQuotaState{
+		"a": {LastUsed: "2026-01-01T00:00:00Z"},
+		"b": {LastUsed: "2026-01-02T00:00:00Z"},
+		"c": {},
+	}}
+	usage := map[string]AccountUsage{"a": {WindowTokens: 500}, "b": {WindowTokens: 100}}
+
+	ranked := RankAccounts([]string{"a", "b", "c"}, state, usage, now)
+	if ranked[0].Handle != "c" || ranked[1].Handle != "b" {
+		t.Errorf("ranked = %+v", ranked)
+	}
+	if ranked[0].Headroom != -1 {
+		t.Errorf("headroom without limits = %v, want -1", ranked[0].Headroom)
+	}
+}
+
+func TestSelectAccount(t *testing.T) {
+	townRoot := setupTestTown(t)
+	heavy, light := t.TempDir(), t.TempDir()
+	ts := time.Now().UTC().Add(-time.Minute).Format(time.RFC3339)
+	writeTranscript(t, heavy, `{"type":"assistant","timestamp":"`+ts+`","message":{"usage":{"input_tokens":5000,"output_tokens":5000}}}`)
+	writeTranscript(t, light, `{"type":"assistant","timestamp":"`+ts+`","message":{"usage":{"input_tokens":10,"output_tokens":10}}}`)
+
+	acctCfg := &config.AccountsConfig{
+		Accounts: map[string]config.Account{
+			"heavy": {ConfigDir: heavy},
+			"light": {ConfigDir: light},
+		},
+		Default:   "heavy",
+		Selection: config.AccountSelectionHeadroom,
+	}
+	got, err := SelectAccount(townRoot, acctCfg, "claude")
+	if err != nil {
+		t.Fatalf("SelectAccount: %v", err)
+	}
+	if got != "light" {
+		t.Errorf("SelectAccount = %q, want light", got)
+	}
+
+	state, err := NewManager(townRoot).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Accounts["light"].LastUsed == "" {
+		t.Error("selected account not marked used")
+	}
+
+	// No gemini accounts: nothing to pre-select.
+	if got, err := SelectAccount(townRoot, acctCfg, "gemini"); err != nil || got != "" {
+		t.Errorf("SelectAccount(gemini) = %q, %v; want empty", got, err)
+	}
+}
+
+func TestSelectAccount_ConcurrentSpawnsSpread(t *testing.T) {
+	townRoot := setupTestTown(t)
+	acctCfg := &config.AccountsConfig{
+		Accounts: map[string]config.Account{
+			"a": {ConfigDir: t.TempDir()},
+			"b": {ConfigDir: t.TempDir()},
+		},
+		Selection: config.AccountSelectionHeadroom,
+	}
+
+	picks := make([]string, 2)
+	var wg sync.WaitGroup
+	for i := range picks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			picks[i], _ = SelectAccount(townRoot, acctCfg, "claude")
+		}(i)
+	}
+	wg.Wait()
+	if picks[0] == "" || picks[0] == picks[1] {
+		t.Errorf("concurrent picks = %v, want two different accounts", picks)
+	}
+}
+
+func TestIsAvailable_ResetElapsed(t *testing.T) {
+	now := time.Now()
+	st := LimitedState(config.AccountQuotaState{LimitTokens: 42}, "7pm (America/Los_Angeles)", now)
+	if st.ResetTime == "" {
+		t.Skip("tzdata unavailable")
+	}
+	if st.LimitTokens != 42 {
+		t.Errorf("LimitTokens not carried over: %d", st.LimitTokens)
+	}
+	if IsAvailable(st, now) {
+		t.Error("freshly limited account reported available")
+	}
+	if !IsAvailable(st, now.Add(25*time.Hour)) {
+		t.Error("account still limited after reset time passed")
+	}
+}
@@ -0,0 +1,445 @@
+package refinery
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// ConflictResolution records how the refinery resolved one conflicted file.
+type ConflictResolution struct {
+	Path     string
+	Strategy string
+}
+
+func (r ConflictResolution) String() string {
+	return fmt.Sprintf("%s (%s)", r.Path, r.Strategy)
+}
+
+// formatResolutions renders resolutions for MR fields and task descriptions.
+func formatResolutions(rs []ConflictResolution) string {
+	parts := make([]string, len(rs))
+	for i, r := range rs {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lockfileCommands regenerates a lockfile after the target's copy has been
+// taken, so the branch's manifest changes are folded back in. Each only
+// rewrites the lockfile: nothing is installed into the refinery worktree.
+// yarn.lock needs Yarn 2+; Yarn 1 rejects --mode and the conflict is left
+// for a resolution task.
+var lockfileCommands = map[string][]string{
+	"package-lock.json": {"npm", "install", "--package-lock-only", "--ignore-scripts"},
+	"pnpm-lock.yaml":    {"pnpm", "install", "--lockfile-only", "--ignore-scripts"},
+	"yarn.lock":         {"yarn", "install", "--mode=update-lockfile"},
+	"Cargo.lock":        {"cargo", "update", "--workspace"},
+	"Gemfile.lock":      {"bundle", "lock"},
+	"poetry.lock":       {"poetry", "lock", "--no-update"},
+	"uv.lock":           {"uv", "lock"},
+}
+
+// changelogNames are base names (without extension, upper-cased) whose
+// conflicts are resolved by keeping both sides.
+var changelogNames = []string{"CHANGELOG", "CHANGES", "HISTORY", "NEWS"}
+
+var (
+	goImportLine = regexp.MustCompile(`^\s*(import\s+)?([A-Za-z_.][A-Za-z0-9_]*\s+)?"[^"]+"\s*(//.*)?$`)
+	pyImportLine = regexp.MustCompile(`^\s*(import\s+[A-Za-z_][\w.]*(\s+as\s+\w+)?(\s*,\s*[A-Za-z_][\w.]*(\s+as\s+\w+)?)*|from\s+\.*[\w.]*\s+import\s+[^()\\]+)\s*(#.*)?$`)
+	jsImportLine = regexp.MustCompile(`^\s*(import\s+([^;]+\s+from\s+)?|export\s+[^;]+\s+from\s+)['"][^'"]+['"];?\s*(//.*)?$`)
+)
+
+// importLinePattern returns the import-line pattern for a file's language,
+// or nil if imports in that language are not auto-resolved.
+func importLinePattern(path string) *regexp.Regexp {
+	switch filepath.Ext(path) {
+	case ".go":
+		return goImportLine
+	case ".py":
+		return pyImportLine
+	case ".js", ".jsx", ".mjs", ".cjs", ".ts", ".tsx":
+		return jsImportLine
+	}
+	return nil
+}
+
+func isChangelog(path string) bool {
+	base := filepath.Base(path)
+	name := strings.ToUpper(strings.TrimSuffix(base, filepath.Ext(base)))
+	return slices.Contains(changelogNames, name)
+}
+
+func isGoSum(path string) bool {
+	base := filepath.Base(path)
+	return base == "go.sum" || base == "go.work.sum"
+}
+
+// conflictStrategies returns the configured strategies, defaulting to all.
+func (e *Engineer) conflictStrategies() []string {
+	if len(e.config.ConflictStrategies) > 0 {
+		return e.config.ConflictStrategies
+	}
+	return config.ConflictStrategyNames
+}
+
+// autoResolveMerge squash-merges branch into the checked-out target,
+// resolving conflicts with the configured strategies, then re-runs gates and
+// commits. Resolved conflicts are never committed unverified: with no gates
+// or test command configured the merge falls back like a failed gate. On any failure the worktree is reset and a Conflict result is
+// returned listing what was resolved and what was not, so the caller can
+// fall back to a conflict-resolution task.
+//
+// rerere is enabled in the rig's shared repository config, so resolutions
+// recorded by polecats resolving earlier conflict tasks (and by the refinery
+// itself) are replayed here.
+func (e *Engineer) autoResolveMerge(ctx context.Context, branch, message string) ProcessResult {
+	strategies := e.conflictStrategies()
+	useRerere := slices.Contains(strategies, config.ConflictStrategyRerere)
+	if useRerere {
+		if err := e.git.ConfigSet("rerere.enabled", "true"); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not enable rerere: %v\n", err)
+			useRerere = false
+		}
+	}
+
+	fail := func(result ProcessResult) ProcessResult {
+		if err := e.git.ResetHard("HEAD"); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to reset after auto-resolve: %v\n", err)
+		}
+		return result
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Attempting automatic conflict resolution (%s)...\n", strings.Join(strategies, ", "))
+	var conflicts []string
+	if mergeErr := e.git.MergeSquashNoCommit(branch); mergeErr != nil {
+		var err error
+		conflicts, err = e.git.GetConflictingFiles()
+		if err != nil || len(conflicts) == 0 {
+			return fail(ProcessResult{
+				Success: false,
+				Error:   fmt.Sprintf("merge failed: %v", mergeErr),
+			})
+		}
+	}
+
+	var resolutions []ConflictResolution
+	var unresolved []string
+	rerereRemaining := conflicts
+	if useRerere && len(conflicts) > 0 {
+		if remaining, err := e.git.RerereRemaining(); err == nil {
+			rerereRemaining = remaining
+		}
+	}
+	for _, path := range conflicts {
+		strategy := ""
+		if useRerere && !slices.Contains(rerereRemaining, path) {
+			strategy = config.ConflictStrategyRerere
+		} else {
+			for _, s := range strategies {
+				if e.resolveConflict(ctx, s, path) {
+					strategy = s
+					break
+				}
+			}
+		}
+		if strategy == "" {
+			unresolved = append(unresolved, path)
+			continue
+		}
+		if err := e.git.Add(path); err != nil {
+			unresolved = append(unresolved, path)
+			continue
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Resolved %s (%s)\n", path, strategy)
+		resolutions = append(resolutions, ConflictResolution{Path: path, Strategy: strategy})
+	}
+
+	if len(unresolved) > 0 {
+		return fail(ProcessResult{
+			Success:     false,
+			Conflict:    true,
+			Error:       fmt.Sprintf("merge conflicts in: %v (auto-resolved %d of %d)", unresolved, len(resolutions), len(conflicts)),
+			Resolutions: resolutions,
+			Unresolved:  unresolved,
+		})
+	}
+
+	// A resolved tree must be verified before it lands; without gates or a
+	// test command there is nothing to verify it with.
+	if len(resolutions) > 0 && !e.hasQualityChecks() {
+		return fail(ProcessResult{
+			Success:     false,
+			Conflict:    true,
+			Error:       "auto-resolved conflicts cannot be verified: no gates or test command configured",
+			Resolutions: resolutions,
+		})
+	}
+
+	if check := e.runQualityChecks(ctx); !check.Success {
+		return fail(ProcessResult{
+			Success:     false,
+			Conflict:    len(resolutions) > 0,
+			TestsFailed: len(resolutions) == 0,
+			Error:       fmt.Sprintf("auto-resolved merge failed checks: %s", check.Error),
+			Resolutions: resolutions,
+		})
+	}
+
+	if len(resolutions) > 0 {
+		message = strings.TrimRight(message, "\n") + "\n\n"
+		for _, r := range resolutions {
+			message += "Conflict-Resolved: " + r.String() + "\n"
+		}
+	}
+	if err := e.git.Commit(message); err != nil {
+		return fail(ProcessResult{
+			Success: false,
+			Error:   fmt.Sprintf("commit after auto-resolve failed: %v", err),
+		})
+	}
+	return ProcessResult{Success: true, Resolutions: resolutions}
+}
+
+// resolveConflict applies one strategy to one conflicted file in the
+// worktree. The file is left unmodified-or-resolved; staging is the caller's.
+func (e *Engineer) resolveConflict(ctx context.Context, strategy, path string) bool {
+	switch strategy {
+	case config.ConflictStrategyGoSum:
+		if !isGoSum(path) {
+			return false
+		}
+		ours, err := e.git.ShowStage(2, path)
+		if err != nil {
+			return false
+		}
+		theirs, err := e.git.ShowStage(3, path)
+		if err != nil {
+			return false
+		}
+		return e.writeResolved(path, mergeGoSum(ours, theirs))
+
+	case config.ConflictStrategyLockfile:
+		regen, ok := lockfileCommands[filepath.Base(path)]
+		if !ok {
+			return false
+		}
+		if _, err := exec.LookPath(regen[0]); err != nil {
+			return false
+		}
+		if err := e.git.CheckoutStage("--ours", path); err != nil {
+			return false
+		}
+		cmd := exec.CommandContext(ctx, regen[0], regen[1:]...) //nolint:gosec // G204: fixed lockfile tool commands
+		cmd.Dir = filepath.Join(e.workDir, filepath.Dir(path))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Lockfile regeneration for %s failed: %v: %s\n",
+				path, err, strings.TrimSpace(string(out)))
+			return false
+		}
+		return true
+
+	case config.ConflictStrategyImports:
+		pattern := importLinePattern(path)
+		if pattern == nil {
+			return false
+		}
+		sortLines := filepath.Ext(path) == ".go"
+		return e.resolveHunksInFile(path, func(h conflictHunk) ([]string, bool) {
+			return mergeImportHunk(h, pattern, sortLines)
+		})
+
+	case config.ConflictStrategyChangelog:
+		if !isChangelog(path) {
+			return false
+		}
+		return e.resolveHunksInFile(path, func(h conflictHunk) ([]string, bool) {
+			return unionLines(h.Ours, h.Theirs), true
+		})
+	}
+	return false
+}
+
+func (e *Engineer) resolveHunksInFile(path string, resolve func(conflictHunk) ([]string, bool)) bool {
+	data, err := os.ReadFile(filepath.Join(e.workDir, path)) //nolint:gosec // G304: path is a conflicted file in the refinery worktree
+	if err != nil {
+		return false
+	}
+	resolved, ok := resolveConflictHunks(string(data), resolve)
+	if !ok {
+		return false
+	}
+	return e.writeResolved(path, resolved)
+}
+
+func (e *Engineer) writeResolved(path, content string) bool {
+	full := filepath.Join(e.workDir, path)
+	info, err := os.Stat(full)
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(full, []byte(content), info.Mode().Perm()) == nil
+}
+
+// conflictHunk is one conflict region. Lines keep their line endings. Base
+// is set only when the file was written with diff3/zdiff3 conflict style.
+type conflictHunk struct {
+	Ours    []string
+	Base    []string
+	Theirs  []string
+	HasBase bool
+}
+
+// resolveConflictHunks replaces every conflict region in content with the
+// lines returned by resolve. It fails if content has no conflict regions,
+// if a region is malformed, or if resolve declines any region.
+func resolveConflictHunks(content string, resolve func(conflictHunk) ([]string, bool)) (string, bool) {
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+	var b strings.Builder
+	var h conflictHunk
+	state := outside
+	hunks := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, "<<<<<<< ") || strings.TrimRight(line, "\r\n") == "<<<<<<<":
+			if state != outside {
+				return "", false
+			}
+			h = conflictHunk{}
+			state = inOurs
+		case strings.HasPrefix(line, "||||||| ") || strings.TrimRight(line, "\r\n") == "|||||||":
+			if state != inOurs {
+				return "", false
+			}
+			h.HasBase = true
+			state = inBase
+		case strings.TrimRight(line, "\r\n") == "=======" && (state == inOurs || state == inBase):
+			state = inTheirs
+		case (strings.HasPrefix(line, ">>>>>>> ") || strings.TrimRight(line, "\r\n") == ">>>>>>>") && state == inTheirs:
+			lines, ok := resolve(h)
+			if !ok {
+				return "", false
+			}
+			for _, l := range lines {
+				b.WriteString(l)
+			}
+			hunks++
+			state = outside
+		default:
+			switch state {
+			case outside:
+				b.WriteString(line)
+			case inOurs:
+				h.Ours = append(h.Ours, line)
+			case inBase:
+				h.Base = append(h.Base, line)
+			case inTheirs:
+				h.Theirs = append(h.Theirs, line)
+			}
+		}
+	}
+	if state != outside || hunks == 0 {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// unionLines returns ours followed by the lines of theirs not already in ours.
+func unionLines(ours, theirs []string) []string {
+	out := append([]string(nil), ours...)
+	for _, l := range theirs {
+		if !slices.Contains(ours, l) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// mergeImportHunk resolves a hunk in which both sides only add or remove
+// import lines. With a base section, a line removed by either side stays
+// removed; without one, the two sides are unioned. Go imports are re-sorted
+// when the hunk lies within a single import group.
+func mergeImportHunk(h conflictHunk, pattern *regexp.Regexp, sortLines bool) ([]string, bool) {
+	blank := false
+	for _, side := range [][]string{h.Ours, h.Base, h.Theirs} {
+		for _, l := range side {
+			t := strings.TrimRight(l, "\r\n")
+			if strings.TrimSpace(t) == "" {
+				blank = true
+				continue
+			}
+			if !pattern.MatchString(t) {
+				return nil, false
+			}
+		}
+	}
+
+	var out []string
+	if h.HasBase {
+		keep := func(l string, other []string) bool {
+			return slices.Contains(other, l) || !slices.Contains(h.Base, l)
+		}
+		for _, l := range h.Ours {
+			if keep(l, h.Theirs) {
+				out = append(out, l)
+			}
+		}
+		for _, l := range h.Theirs {
+			if !slices.Contains(h.Ours, l) && keep(l, h.Ours) {
+				out = append(out, l)
+			}
+		}
+	} else {
+		out = unionLines(h.Ours, h.Theirs)
+	}
+	if sortLines && !blank {
+		sort.SliceStable(out, func(i, j int) bool {
+			return importSortKey(out[i]) < importSortKey(out[j])
+		})
+	}
+	return out, true
+}
+
+// importSortKey is the import path, which is what gofmt sorts by.
+func importSortKey(line string) string {
+	if i := strings.Index(line, `"`); i >= 0 {
+		return line[i:]
+	}
+	return line
+}
+
+// mergeGoSum returns the sorted union of two go.sum files. go.sum is a set
+// of independent checksum lines, so keeping both sides is always valid;
+// `go mod tidy` in a gate can prune lines no longer needed.
+func mergeGoSum(ours, theirs string) string {
+	seen := make(map[string]bool)
+	var lines []string
+	for _, content := range []string{ours, theirs} {
+		for _, l := range strings.Split(content, "\n") {
+			l = strings.TrimRight(l, "\r")
+			if l == "" || seen[l] {
+				continue
+			}
+			seen[l] = true
+			lines = append(lines, l)
+		}
+	}
+	sort.Strings(lines)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,289 @@
+package refinery
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestResolveConflictHunks(t *testing.T) {
+	content := "a\n<<<<<<< HEAD\nours\n||||||| base\nbase\n=======\ntheirs\n>>>>>>> branch\nz\n"
+	var got conflictHunk
+	out, ok := resolveConflictHunks(content, func(h conflictHunk) ([]string, bool) {
+		got = h
+		return []string{"merged\n"}, true
+	})
+	if !ok {
+		t.Fatal("expected resolution")
+	}
+	if out != "a\nmerged\nz\n" {
+		t.Errorf("resolved content = %q", out)
+	}
+	want := conflictHunk{Ours: []string{"ours\n"}, Base: []string{"base\n"}, Theirs: []string{"theirs\n"}, HasBase: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("hunk = %+v, want %+v", got, want)
+	}
+
+	for name, bad := range map[string]string{
+		"no hunks":     "a\nb\n",
+		"unterminated": "<<<<<<< HEAD\nours\n=======\ntheirs\n",
+	} {
+		if _, ok := resolveConflictHunks(bad, func(conflictHunk) ([]string, bool) { return nil, true }); ok {
+			t.Errorf("%s: expected failure", name)
+		}
+	}
+	if _, ok := resolveConflictHunks(content, func(conflictHunk) ([]string, bool) { return nil, false }); ok {
+		t.Error("declined hunk should fail the file")
+	}
+}
+
+func TestMergeImportHunk(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		hunk   conflictHunk
+		want   []string
+		wantOK bool
+	}{
+		{
+			name: "go union sorted",
+			path: "main.go",
+			hunk: conflictHunk{
+				Ours:   []string{"\t\"os\"\n", "\t\"strings\"\n"},
+				Theirs: []string{"\t\"fmt\"\n", "\t\"os\"\n"},
+			},
+			want:   []string{"\t\"fmt\"\n", "\t\"os\"\n", "\t\"strings\"\n"},
+			wantOK: true,
+		},
+		{
+			name: "go removal with base",
+			path: "main.go",
+			hunk: conflictHunk{
+				Ours:    []string{"\t\"os\"\n", "\t\"strings\"\n"},
+				Base:    []string{"\t\"io\"\n", "\t\"os\"\n"},
+				Theirs:  []string{"\t\"io\"\n", "\tlog \"github.com/x/log\"\n"},
+				HasBase: true,
+			},
+			want:   []string{"\tlog \"github.com/x/log\"\n", "\t\"strings\"\n"},
+			wantOK: true,
+		},
+		{
+			name: "python",
+			path: "app.py",
+			hunk: conflictHunk{
+				Ours:   []string{"import os\n"},
+				Theirs: []string{"from typing import Any\n"},
+			},
+			want:   []string{"import os\n", "from typing import Any\n"},
+			wantOK: true,
+		},
+		{
+			name: "typescript",
+			path: "app.ts",
+			hunk: conflictHunk{
+				Ours:   []string{"import { a } from './a';\n"},
+				Theirs: []string{"import b from \"./b\";\n"},
+			},
+			want:   []string{"import { a } from './a';\n", "import b from \"./b\";\n"},
+			wantOK: true,
+		},
+		{
+			name: "code line rejected",
+			path: "main.go",
+			hunk: conflictHunk{
+				Ours:   []string{"\t\"os\"\n"},
+				Theirs: []string{"\tx := 1\n"},
+			},
+			wantOK: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := importLinePattern(tt.path)
+			if pattern == nil {
+				t.Fatalf("no import pattern for %s", tt.path)
+			}
+			got, ok := mergeImportHunk(tt.hunk, pattern, filepath.Ext(tt.path) == ".go")
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeGoSum(t *testing.T) {
+	ours := "b v1.0.0 h1:b=\na v1.0.0 h1:a=\n"
+	theirs := "a v1.0.0 h1:a=\nc v1.0.0 h1:c=\n"
+	want := "a v1.0.0 h1:a=\nb v1.0.0 h1:b=\nc v1.0.0 h1:c=\n"
+	if got := mergeGoSum(ours, theirs); got != want {
+		t.Errorf("mergeGoSum = %q, want %q", got, want)
+	}
+}
+
+func TestIsChangelog(t *testing.T) {
+	for path, want := range map[string]bool{
+		"CHANGELOG.md":      true,
+		"docs/changes.rst":  true,
+		"NEWS":              true,
+		"README.md":         false,
+		"changelog_test.go": false,
+	} {
+		if got := isChangelog(path); got != want {
+			t.Errorf("isChangelog(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// conflictRepo creates a repo on main with a branch that conflicts with it.
+// files maps path -> {base, main, branch} contents.
+func conflictRepo(t *testing.T, files map[string][3]string) string {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(side int) {
+		t.Helper()
+		for path, contents := range files {
+			full := filepath.Join(dir, path)
+			if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(full, []byte(contents[side]), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "merge.conflictStyle", "diff3")
+	write(0)
+	run("add", ".")
+	run("commit", "-qm", "base")
+	run("checkout", "-qb", "polecat/test")
+	write(2)
+	run("commit", "-qam", "feat: branch change")
+	run("checkout", "-q", "main")
+	write(1)
+	run("commit", "-qam", "main change")
+	return dir
+}
+
+func newConflictEngineer(t *testing.T, dir string) *Engineer {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.git = git.NewGit(dir)
+	e.workDir = dir
+	e.output = io.Discard
+	e.config.OnConflict = config.OnConflictAutoResolve
+	return e
+}
+
+func TestAutoResolveMerge_ResolvesAndCommits(t *testing.T) {
+	dir := conflictRepo(t, map[string][3]string{
+		"go.sum":  {"a v1 h1:a=\n", "a v1 h1:a=\nb v1 h1:b=\n", "a v1 h1:a=\nc v1 h1:c=\n"},
+		"main.go": {"package main\n\nimport (\n\t\"os\"\n)\n", "package main\n\nimport (\n\t\"os\"\n\t\"strings\"\n)\n", "package main\n\nimport (\n\t\"os\"\n\t\"sort\"\n)\n"},
+	})
+	e := newConflictEngineer(t, dir)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "true"}}
+
+	result := e.autoResolveMerge(context.Background(), "polecat/test", "feat: branch change\n")
+	if !result.Success {
+		t.Fatalf("autoResolveMerge failed: %s", result.Error)
+	}
+	want := []ConflictResolution{{Path: "go.sum", Strategy: "go_sum"}, {Path: "main.go", Strategy: "imports"}}
+	if !reflect.DeepEqual(result.Resolutions, want) {
+		t.Errorf("Resolutions = %v, want %v", result.Resolutions, want)
+	}
+
+	msg, err := e.git.GetBranchCommitMessage("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(msg, "Conflict-Resolved: go.sum (go_sum)") || !strings.Contains(msg, "Conflict-Resolved: main.go (imports)") {
+		t.Errorf("commit message missing trailers:\n%s", msg)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "main.go"))
+	if !strings.Contains(string(data), "\t\"os\"\n\t\"sort\"\n\t\"strings\"\n") {
+		t.Errorf("main.go imports not merged:\n%s", data)
+	}
+}
+
+func TestAutoResolveMerge_FallsBackOnUnresolved(t *testing.T) {
+	dir := conflictRepo(t, map[string][3]string{
+		"go.sum":  {"a v1 h1:a=\n", "b v1 h1:b=\n", "c v1 h1:c=\n"},
+		"main.go": {"package main\n\nvar x = 0\n", "package main\n\nvar x = 1\n", "package main\n\nvar x = 2\n"},
+	})
+	e := newConflictEngineer(t, dir)
+	head, _ := e.git.Rev("HEAD")
+
+	result := e.autoResolveMerge(context.Background(), "polecat/test", "feat: branch change\n")
+	if result.Success || !result.Conflict {
+		t.Fatalf("expected conflict failure, got %+v", result)
+	}
+	if !reflect.DeepEqual(result.Unresolved, []string{"main.go"}) {
+		t.Errorf("Unresolved = %v", result.Unresolved)
+	}
+	if len(result.Resolutions) != 1 || result.Resolutions[0].Strategy != "go_sum" {
+		t.Errorf("Resolutions = %v", result.Resolutions)
+	}
+	if after, _ := e.git.Rev("HEAD"); after != head {
+		t.Errorf("HEAD moved from %s to %s", head, after)
+	}
+	if conflicts, _ := e.git.GetConflictingFiles(); len(conflicts) != 0 {
+		t.Errorf("worktree not reset, conflicts: %v", conflicts)
+	}
+}
+
+func TestAutoResolveMerge_GateFailureFallsBack(t *testing.T) {
+	dir := conflictRepo(t, map[string][3]string{
+		"go.sum": {"a v1 h1:a=\n", "b v1 h1:b=\n", "c v1 h1:c=\n"},
+	})
+	e := newConflictEngineer(t, dir)
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "exit 1"}}
+
+	result := e.autoResolveMerge(context.Background(), "polecat/test", "feat: branch change\n")
+	if result.Success || !result.Conflict {
+		t.Fatalf("expected conflict failure after gates, got %+v", result)
+	}
+	if len(result.Resolutions) != 1 {
+		t.Errorf("Resolutions = %v", result.Resolutions)
+	}
+}
+
+func TestAutoResolveMerge_NoChecksFallsBack(t *testing.T) {
+	dir := conflictRepo(t, map[string][3]string{
+		"go.sum": {"a v1 h1:a=\n", "b v1 h1:b=\n", "c v1 h1:c=\n"},
+	})
+	e := newConflictEngineer(t, dir)
+	head, _ := e.git.Rev("HEAD")
+
+	result := e.autoResolveMerge(context.Background(), "polecat/test", "feat: branch change\n")
+	if result.Success || !result.Conflict {
+		t.Fatalf("expected conflict failure without checks, got %+v", result)
+	}
+	if after, _ := e.git.Rev("HEAD"); after != head {
+		t.Errorf("unverified resolution committed: HEAD moved from %s to %s", head, after)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/crew"
 	"github.com/steveyegge/gastown/internal/events"
 	"github.com/steveyegge/gastown/internal/git"
@@ -75,9 +76,14 @@ type MergeQueueConfig struct {
 	// Enabled controls whether the merge queue is active.
 	Enabled bool `json:"enabled"`
 
-	// OnConflict is the strategy for handling conflicts: "assign_back" or "auto_rebase".
+	// OnConflict is the strategy for handling conflicts: "assign_back",
+	// "auto_rebase" or "auto_resolve" (see autoResolveMerge).
 	OnConflict string `json:"on_conflict"`
 
+	// ConflictStrategies lists the strategies auto_resolve tries, in order.
+	// Empty means config.ConflictStrategyNames.
+	ConflictStrategies []string `json:"conflict_strategies"`
+
 	// RunTests controls whether to run tests before merging.
 	RunTests bool `json:"run_tests"`
 
@@ -112,6 +118,14 @@ type MergeQueueConfig struct {
 	// GatesParallel controls whether gates run concurrently.
 	// When true, all gates start simultaneously; any failure = overall failure.
 	GatesParallel bool `json:"gates_parallel"`
+
+	// PostMergeVerify re-runs the gates on each merged commit after it lands
+	// (see VerifyMerge; the patrol runs it with gt mq verify).
+	PostMergeVerify bool `json:"post_merge_verify"`
+
+	// PhaseSLA is how long an MR may stay in each phase before it is
+	// escalated (see CheckSLA). Phases without an entry are never escalated.
+	PhaseSLA map[MRPhase]time.Duration `json:"phase_sla"`
 }
 
 // DefaultMergeQueueConfig returns sensible defaults for merge queue configuration.
@@ -126,6 +140,7 @@ func DefaultMergeQueueConfig() *MergeQueueConfig {
 		PollInterval:         30 * time.Second,
 		MaxConcurrent:        1,
 		StaleClaimTimeout:    DefaultStaleClaimTimeout,
+		PhaseSLA:             DefaultPhaseSLA,
 	}
 }
 
@@ -146,6 +161,9 @@ type MRInfo struct {
 	ConvoyCreatedAt *time.Time // Convoy creation time
 	CreatedAt       time.Time  // MR creation time
 	BlockedBy       string     // Task ID blocking this MR
+	ParentMR        string     // MR this one is stacked on (see stack.go)
+	ParentHead      string     // Parent branch commit this branch was built on
+	Reverts         string     // Commit range a revert MR undoes (see verify.go)
 
 	// Raw data for agent-side queue health analysis (ZFC: agent decides, Go transports)
 	UpdatedAt          time.Time // When the MR was last updated
@@ -269,6 +287,7 @@ func (e *Engineer) LoadConfig() error {
 	var mqRaw struct {
 		Enabled              *bool                      `json:"enabled"`
 		OnConflict           *string                    `json:"on_conflict"`
+		ConflictStrategies   []string                   `json:"conflict_strategies"`
 		RunTests             *bool                      `json:"run_tests"`
 		TestCommand          *string                    `json:"test_command"`
 		DeleteMergedBranches *bool                      `json:"delete_merged_branches"`
@@ -278,6 +297,7 @@ func (e *Engineer) LoadConfig() error {
 		StaleClaimTimeout    *string                    `json:"stale_claim_timeout"`
 		Gates                map[string]*gateConfigRaw  `json:"gates"`
 		GatesParallel        *bool                      `json:"gates_parallel"`
+		PostMergeVerify      *bool                      `json:"post_merge_verify"`
 	}
 
 	if err := json.Unmarshal(rawConfig.MergeQueue, &mqRaw); err != nil {
@@ -291,6 +311,9 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.OnConflict != nil {
 		e.config.OnConflict = *mqRaw.OnConflict
 	}
+	if mqRaw.ConflictStrategies != nil {
+		e.config.ConflictStrategies = mqRaw.ConflictStrategies
+	}
 	if mqRaw.RunTests != nil {
 		e.config.RunTests = *mqRaw.RunTests
 	}
@@ -345,6 +368,9 @@ func (e *Engineer) LoadConfig() error {
 	if mqRaw.GatesParallel != nil {
 		e.config.GatesParallel = *mqRaw.GatesParallel
 	}
+	if mqRaw.PostMergeVerify != nil {
+		e.config.PostMergeVerify = *mqRaw.PostMergeVerify
+	}
 
 	return nil
 }
@@ -369,6 +395,12 @@ type ProcessResult struct {
 	Conflict    bool
 	TestsFailed bool
 	SlotTimeout bool // Merge slot contention timeout (distinct from build/test failure)
+
+	// Resolutions lists conflicts resolved by auto_resolve, including on
+	// failure, when they record what was attempted before falling back.
+	Resolutions []ConflictResolution
+	// Unresolved lists conflicted files no auto_resolve strategy handled.
+	Unresolved []string
 }
 
 // doMerge performs the actual git merge operation.
@@ -414,7 +446,8 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 			Error:    fmt.Sprintf("conflict check failed: %v", err),
 		}
 	}
-	if len(conflicts) > 0 {
+	autoResolve := len(conflicts) > 0 && e.config.OnConflict == config.OnConflictAutoResolve
+	if len(conflicts) > 0 && !autoResolve {
 		return ProcessResult{
 			Success:  false,
 			Conflict: true,
@@ -452,56 +485,42 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 		_, _ = fmt.Fprintf(e.output, "[Engineer] Pushed %d submodule(s)\n", len(subChanges))
 	}
 
-	// Step 4: Run quality gates (or legacy tests) if configured
-	if len(e.config.Gates) > 0 {
-		// New gates system: run configured quality gates
-		gateResult := e.runGates(ctx)
-		if !gateResult.Success {
-			return gateResult
-		}
-	} else if e.config.RunTests && e.config.TestCommand != "" {
-		// Legacy test command path (backward compatible)
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
-		result := e.runTests(ctx)
+	var resolutions []ConflictResolution
+	if autoResolve {
+		// Steps 4-5 (auto_resolve): merge, resolve conflicts, run gates on the
+		// resolved tree, then commit. Falls back to a conflict task on failure.
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Conflicts in %v\n", conflicts)
+		result := e.autoResolveMerge(ctx, branch, e.squashMessage(branch, target, sourceIssue))
 		if !result.Success {
-			return ProcessResult{
-				Success:     false,
-				TestsFailed: true,
-				Error:       result.Error,
-			}
+			return result
 		}
-		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
-	}
-
-	// Step 5: Perform the actual merge using squash merge
-	// Get the original commit message from the polecat branch to preserve the
-	// conventional commit format (feat:/fix:) instead of creating redundant merge commits
-	originalMsg, err := e.git.GetBranchCommitMessage(branch)
-	if err != nil {
-		// Fallback to a descriptive message if we can't get the original
-		originalMsg = fmt.Sprintf("Squash merge %s into %s", branch, target)
-		if sourceIssue != "" {
-			originalMsg = fmt.Sprintf("Squash merge %s into %s (%s)", branch, target, sourceIssue)
-		}
-		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not get original commit message: %v\n", err)
-	}
-	_, _ = fmt.Fprintf(e.output, "[Engineer] Squash merging with message: %s\n", strings.TrimSpace(originalMsg))
-	if err := e.git.MergeSquash(branch, originalMsg); err != nil {
-		// ZFC: Use git's porcelain output to detect conflicts instead of parsing stderr.
-		// GetConflictingFiles() uses `git diff --diff-filter=U` which is proper.
-		conflicts, conflictErr := e.git.GetConflictingFiles()
-		if conflictErr == nil && len(conflicts) > 0 {
-			_ = e.git.AbortMerge()
+		resolutions = result.Resolutions
+	} else {
+		// Step 4: Run quality gates (or legacy tests) if configured
+		if result := e.runQualityChecks(ctx); !result.Success {
+			return result
+		}
+
+		// Step 5: Perform the actual merge using squash merge
+		originalMsg := e.squashMessage(branch, target, sourceIssue)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Squash merging with message: %s\n", strings.TrimSpace(originalMsg))
+		if err := e.git.MergeSquash(branch, originalMsg); err != nil {
+			// ZFC: Use git's porcelain output to detect conflicts instead of parsing stderr.
+			// GetConflictingFiles() uses `git diff --diff-filter=U` which is proper.
+			conflicts, conflictErr := e.git.GetConflictingFiles()
+			if conflictErr == nil && len(conflicts) > 0 {
+				_ = e.git.AbortMerge()
+				return ProcessResult{
+					Success:  false,
+					Conflict: true,
+					Error:    "merge conflict during actual merge",
+				}
+			}
 			return ProcessResult{
-				Success:  false,
-				Conflict: true,
-				Error:    "merge conflict during actual merge",
+				Success: false,
+				Error:   fmt.Sprintf("merge failed: %v", err),
 			}
 		}
-		return ProcessResult{
-			Success: false,
-			Error:   fmt.Sprintf("merge failed: %v", err),
-		}
 	}
 
 	// Step 6: Get the merge commit SHA
@@ -564,9 +583,48 @@ func (e *Engineer) doMerge(ctx context.Context, branch, target, sourceIssue stri
 	return ProcessResult{
 		Success:     true,
 		MergeCommit: mergeCommit,
+		Resolutions: resolutions,
 	}
 }
 
+// runQualityChecks runs configured quality gates, or the legacy test command.
+func (e *Engineer) runQualityChecks(ctx context.Context) ProcessResult {
+	if len(e.config.Gates) > 0 {
+		// New gates system: run configured quality gates
+		return e.runGates(ctx)
+	}
+	if e.config.RunTests && e.config.TestCommand != "" {
+		// Legacy test command path (backward compatible)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Running tests: %s\n", e.config.TestCommand)
+		result := e.runTests(ctx)
+		if !result.Success {
+			return ProcessResult{
+				Success:     false,
+				TestsFailed: true,
+				Error:       result.Error,
+			}
+		}
+		_, _ = fmt.Fprintln(e.output, "[Engineer] Tests passed")
+	}
+	return ProcessResult{Success: true}
+}
+
+// squashMessage returns the commit message for squash-merging branch.
+// The original commit message from the polecat branch is preserved to keep the
+// conventional commit format (feat:/fix:) instead of creating redundant merge commits.
+func (e *Engineer) squashMessage(branch, target, sourceIssue string) string {
+	originalMsg, err := e.git.GetBranchCommitMessage(branch)
+	if err != nil {
+		// Fallback to a descriptive message if we can't get the original
+		originalMsg = fmt.Sprintf("Squash merge %s into %s", branch, target)
+		if sourceIssue != "" {
+			originalMsg = fmt.Sprintf("Squash merge %s into %s (%s)", branch, target, sourceIssue)
+		}
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not get original commit message: %v\n", err)
+	}
+	return originalMsg
+}
+
 func (e *Engineer) acquireMainPushSlot(ctx context.Context) (string, error) {
 	slotID, err := e.mergeSlotEnsureExists()
 	if err != nil {
@@ -879,6 +937,9 @@ func (e *Engineer) HandleMRInfoSuccess(mr *MRInfo, result ProcessResult) {
 			}
 			mrFields.MergeCommit = result.MergeCommit
 			mrFields.CloseReason = "merged"
+			if len(result.Resolutions) > 0 {
+				mrFields.ConflictResolutions = formatResolutions(result.Resolutions)
+			}
 			newDesc := beads.SetMRFields(mrBead, mrFields)
 			if err := e.beads.Update(mr.ID, beads.UpdateOptions{Description: &newDesc}); err != nil {
 				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to update MR %s with merge commit: %v\n", mr.ID, err)
@@ -1005,7 +1066,7 @@ func (e *Engineer) HandleMRInfoFailure(mr *MRInfo, result ProcessResult) {
 // This serializes conflict resolution - only one polecat can resolve conflicts at a time.
 // If the slot is already held, we skip creating the task and let the MR stay in queue.
 // When the current resolution completes and merges, the slot is released.
-func (e *Engineer) createConflictResolutionTaskForMR(mr *MRInfo, _ ProcessResult) (string, error) { // result unused but kept for future merge diagnostics
+func (e *Engineer) createConflictResolutionTaskForMR(mr *MRInfo, result ProcessResult) (string, error) {
 	// === MERGE SLOT GATE: Serialize conflict resolution ===
 	// Ensure merge slot exists (idempotent)
 	slotID, err := e.mergeSlotEnsureExists()
@@ -1065,6 +1126,25 @@ func (e *Engineer) createConflictResolutionTaskForMR(mr *MRInfo, _ ProcessResult
 	// Increment retry count for tracking
 	retryCount := mr.RetryCount + 1
 
+	// Record what auto_resolve managed, so the resolver starts from there
+	autoResolveNote := ""
+	if len(result.Resolutions) > 0 || len(result.Unresolved) > 0 {
+		autoResolveNote = "\n## Auto-resolution attempted\n"
+		if len(result.Resolutions) > 0 {
+			autoResolveNote += "- Resolved: " + formatResolutions(result.Resolutions) + "\n"
+		}
+		if len(result.Unresolved) > 0 {
+			autoResolveNote += "- Unresolved: " + strings.Join(result.Unresolved, ", ") + "\n"
+		}
+		autoResolveNote += "- Result: " + result.Error + "\n"
+	}
+
+	// A stacked MR whose parent landed replays only its own commits
+	rebaseCmd := "git rebase origin/" + mr.Target
+	if mr.ParentHead != "" {
+		rebaseCmd = fmt.Sprintf("git rebase --onto origin/%s %s", mr.Target, mr.ParentHead)
+	}
+
 	// Build the task description with metadata
 	description := fmt.Sprintf(`Resolve merge conflicts for branch %s
 
@@ -1074,10 +1154,10 @@ func (e *Engineer) createConflictResolutionTaskForMR(mr *MRInfo, _ ProcessResult
 - Conflict with: %s@%s
 - Original issue: %s
 - Retry count: %d
-
+%s
 ## Instructions
 1. Check out the branch: git checkout %s
-2. Rebase onto target: git rebase origin/%s
+2. Rebase onto target: %s
 3. Resolve conflicts in your editor
 4. Complete the rebase: git add . && git rebase --continue
 5. Force-push the resolved branch: git push -f
@@ -1090,8 +1170,9 @@ The Refinery will automatically retry the merge after you force-push.`,
 		mr.Target, mainSHA[:8],
 		mr.SourceIssue,
 		retryCount,
+		autoResolveNote,
 		mr.Branch,
-		mr.Target,
+		rebaseCmd,
 	)
 
 	// Create the conflict resolution task
@@ -1163,6 +1244,9 @@ func issueToMRInfo(issue *beads.Issue, fields *beads.MRFields) *MRInfo {
 		Priority:        issue.Priority,
 		AgentBead:       fields.AgentBead,
 		RetryCount:      fields.RetryCount,
+		ParentMR:        fields.ParentMR,
+		ParentHead:      fields.ParentHead,
+		Reverts:         fields.Reverts,
 		ConvoyID:        fields.ConvoyID,
 		ConvoyCreatedAt: convoyCreatedAt,
 		CreatedAt:       createdAt,
@@ -1204,6 +1288,12 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 		return nil, fmt.Errorf("querying beads for merge-requests: %w", err)
 	}
 
+	// While the target is broken only revert MRs may merge (see verify.go)
+	pause, err := LoadQueuePause(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
 	// Convert beads issues to MRInfo
 	var mrs []*MRInfo
 	for _, issue := range issues {
@@ -1230,6 +1320,16 @@ func (e *Engineer) ListReadyMRs() ([]*MRInfo, error) {
 			continue // Skip issues without MR fields
 		}
 
+		// Skip stacked MRs: they become ready once Restack lands them on
+		// the target after their parent merges.
+		if fields.ParentMR != "" {
+			continue
+		}
+
+		if pause != nil && fields.Reverts == "" {
+			continue
+		}
+
 		// Skip if already assigned, unless claim is stale (allows re-claim after crash).
 		// NOTE: Only one refinery runs per rig (enforced by ErrAlreadyRunning in
 		// manager.go), so concurrent re-claim race conditions are not a concern.
@@ -1267,23 +1367,21 @@ func (e *Engineer) ListBlockedMRs() ([]*MRInfo, error) {
 		return nil, fmt.Errorf("querying beads for merge-requests: %w", err)
 	}
 
-	// Filter for blocked issues (those with open blockers)
+	// Filter for blocked issues (those with open blockers, or still stacked)
 	var mrs []*MRInfo
 	for _, issue := range issues {
-		// Skip if not blocked
-		if len(issue.BlockedBy) == 0 {
+		fields := beads.ParseMRFields(issue)
+		if fields == nil {
 			continue
 		}
 
 		// Check if any blocker is still open
 		blockedBy := e.firstOpenBlocker(issue)
 		if blockedBy == "" {
-			continue // All blockers are closed, not blocked
+			blockedBy = fields.ParentMR
 		}
-
-		fields := beads.ParseMRFields(issue)
-		if fields == nil {
-			continue
+		if blockedBy == "" {
+			continue // Not blocked
 		}
 
 		mr := issueToMRInfo(issue, fields)
@@ -1326,6 +1424,9 @@ func (e *Engineer) ListAllOpenMRs() ([]*MRInfo, error) {
 		mr.BranchExistsLocal, _ = e.git.BranchExists(fields.Branch)
 		mr.BranchExistsRemote, _ = e.git.RemoteTrackingBranchExists("origin", fields.Branch)
 		mr.BlockedBy = e.firstOpenBlocker(issue)
+		if mr.BlockedBy == "" {
+			mr.BlockedBy = fields.ParentMR
+		}
 
 		mrs = append(mrs, mr)
 	}
@@ -1417,18 +1518,26 @@ func detectQueueAnomalies(
 // This replaces mrqueue.Claim() for beads-based MRs.
 // The workerID is typically the refinery's identifier (e.g., "gastown/refinery").
 func (e *Engineer) ClaimMR(mrID, workerID string) error {
-	return e.beads.Update(mrID, beads.UpdateOptions{
+	if err := e.beads.Update(mrID, beads.UpdateOptions{
 		Assignee: &workerID,
-	})
+	}); err != nil {
+		return err
+	}
+	e.recordPhase(&MRInfo{ID: mrID}, MRPhaseClaimed, workerID)
+	return nil
 }
 
 // ReleaseMR releases a claimed MR back to the queue by clearing the assignee.
 // This replaces mrqueue.Release() for beads-based MRs.
 func (e *Engineer) ReleaseMR(mrID string) error {
 	empty := ""
-	return e.beads.Update(mrID, beads.UpdateOptions{
+	if err := e.beads.Update(mrID, beads.UpdateOptions{
 		Assignee: &empty,
-	})
+	}); err != nil {
+		return err
+	}
+	e.recordPhase(&MRInfo{ID: mrID}, MRPhaseReady, "released")
+	return nil
 }
 
 // postMergeConvoyCheck runs convoy completion checks after a successful merge.
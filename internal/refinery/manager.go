@@ -475,6 +475,11 @@ func (m *Manager) RejectMR(idOrBranch string, reason string, notify bool) (*Merg
 	}
 	mr.Error = reason
 
+	ev := PhaseEvent{MR: mr.ID, Phase: MRPhaseRejected, Worker: mr.Worker, Target: mr.TargetBranch, Reason: reason}
+	if err := RecordPhase(m.rig.Path, ev); err != nil {
+		_, _ = fmt.Fprintf(m.output, "Warning: recording %s phase %s: %v\n", mr.ID, MRPhaseRejected, err)
+	}
+
 	// Optionally notify worker
 	if notify {
 		m.notifyWorkerRejected(mr, reason)
@@ -0,0 +1,653 @@
+package refinery
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mail"
+	"github.com/steveyegge/gastown/internal/telemetry"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Merge queue phase tracking.
+//
+// Every MRPhase transition is appended to the rig's phase log, so the time an
+// MR spends in each phase outlives its bead (a wisp, cleaned up after merge).
+// QueueStats summarizes the log for `gt mq stats`; CheckSLA finds MRs stuck in
+// a phase longer than the rig allows so they can be escalated.
+
+// phaseLogFile is the append-only phase log under the rig's .runtime directory.
+const phaseLogFile = "mq-phases.jsonl"
+
+// phaseIndexFile holds each logged MR's latest event, so RecordPhase can
+// validate a transition without re-reading the whole log.
+const phaseIndexFile = "mq-phases-current.json"
+
+// PhaseLogRetention is how long a merged or rejected MR stays in the phase
+// log before PrunePhaseLog drops it.
+const PhaseLogRetention = 30 * 24 * time.Hour
+
+// slaStateFile remembers which breaches were already escalated.
+const slaStateFile = "mq-sla-escalated.json"
+
+// PhaseEvent is one MR phase transition.
+type PhaseEvent struct {
+	MR     string    `json:"mr"`
+	Phase  MRPhase   `json:"phase"`
+	At     time.Time `json:"at"`
+	Worker string    `json:"worker,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// AllPhases lists the MR phases in lifecycle order.
+var AllPhases = []MRPhase{
+	MRPhaseReady,
+	MRPhaseClaimed,
+	MRPhasePreparing,
+	MRPhasePrepared,
+	MRPhaseMerging,
+	MRPhaseMerged,
+	MRPhaseRejected,
+	MRPhaseFailed,
+}
+
+// DefaultPhaseSLA is how long an MR may stay in each non-terminal phase
+// before it is escalated.
+var DefaultPhaseSLA = map[MRPhase]time.Duration{
+	MRPhaseReady:     4 * time.Hour,
+	MRPhaseClaimed:   DefaultClaimTTLMinutes * time.Minute,
+	MRPhasePreparing: time.Hour,
+	MRPhasePrepared:  30 * time.Minute,
+	MRPhaseMerging:   15 * time.Minute,
+	MRPhaseFailed:    time.Hour,
+}
+
+func phaseLogPath(rigPath string) string {
+	return filepath.Join(constants.RigRuntimePath(rigPath), phaseLogFile)
+}
+
+func phaseIndexPath(rigPath string) string {
+	return filepath.Join(constants.RigRuntimePath(rigPath), phaseIndexFile)
+}
+
+// lockPhaseLog takes the phase log lock. Callers must defer the returned unlock.
+func lockPhaseLog(rigPath string) (func(), error) {
+	path := phaseLogPath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating runtime dir: %w", err)
+	}
+	fl := flock.New(path + ".lock")
+	if err := fl.Lock(); err != nil {
+		return nil, fmt.Errorf("acquiring phase log lock: %w", err)
+	}
+	return func() { _ = fl.Unlock() }, nil
+}
+
+// loadPhaseIndex returns each MR's latest event. A missing index is rebuilt
+// from the log, so logs written before the index existed keep working.
+// The caller must hold the phase log lock.
+func loadPhaseIndex(rigPath string) (map[string]PhaseEvent, error) {
+	data, err := os.ReadFile(phaseIndexPath(rigPath)) //nolint:gosec // G304: path from trusted rig path
+	if err == nil {
+		index := map[string]PhaseEvent{}
+		if err := json.Unmarshal(data, &index); err == nil {
+			return index, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("reading phase index: %w", err)
+	}
+	history, err := LoadPhaseEvents(rigPath)
+	if err != nil {
+		return nil, err
+	}
+	return indexPhaseEvents(history), nil
+}
+
+func indexPhaseEvents(history []PhaseEvent) map[string]PhaseEvent {
+	index := make(map[string]PhaseEvent)
+	for _, ev := range history {
+		index[ev.MR] = ev
+	}
+	return index
+}
+
+// savePhaseIndex atomically replaces the index. The caller must hold the
+// phase log lock.
+func savePhaseIndex(rigPath string, index map[string]PhaseEvent) error {
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	if err := util.AtomicWriteFile(phaseIndexPath(rigPath), data, 0644); err != nil {
+		return fmt.Errorf("writing phase index: %w", err)
+	}
+	return nil
+}
+
+// RecordPhase appends a transition of ev.MR to ev.Phase. The move from the
+// MR's last recorded phase must be valid (see ValidatePhaseTransition); an
+// MR's first event may be any phase, and repeating the current phase is a
+// no-op. Worker and target are carried over from earlier events when unset.
+// The last phase comes from the phase index, so the log is only appended to.
+func RecordPhase(rigPath string, ev PhaseEvent) error {
+	if ev.MR == "" {
+		return errors.New("recording phase: no MR")
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now().UTC()
+	}
+
+	unlock, err := lockPhaseLog(rigPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	index, err := loadPhaseIndex(rigPath)
+	if err != nil {
+		return err
+	}
+	var last *PhaseEvent
+	if prev, ok := index[ev.MR]; ok {
+		last = &prev
+	}
+	if last != nil {
+		if last.Phase == ev.Phase {
+			return nil
+		}
+		if err := ValidatePhaseTransition(last.Phase, ev.Phase); err != nil {
+			return fmt.Errorf("%s: %w", ev.MR, err)
+		}
+		if ev.Worker == "" {
+			ev.Worker = last.Worker
+		}
+		if ev.Target == "" {
+			ev.Target = last.Target
+		}
+	}
+
+	path := phaseLogPath(rigPath)
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644) //nolint:gosec // G302: phase log is non-sensitive operational data
+	if err != nil {
+		return fmt.Errorf("opening phase log: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing phase log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	index[ev.MR] = ev
+	if err := savePhaseIndex(rigPath, index); err != nil {
+		return err
+	}
+
+	from, inPhase := "", time.Duration(0)
+	if last != nil {
+		from, inPhase = string(last.Phase), ev.At.Sub(last.At)
+	}
+	rework := last != nil && isRework(last.Phase, ev.Phase)
+	telemetry.RecordMQPhase(context.Background(), filepath.Base(rigPath), ev.MR, from, string(ev.Phase), inPhase, rework)
+	return nil
+}
+
+// PrunePhaseLog drops every merged or rejected MR whose final event is older
+// than cutoff from the phase log, so the log does not grow without bound.
+// Returns the number of MRs dropped.
+func PrunePhaseLog(rigPath string, cutoff time.Time) (int, error) {
+	unlock, err := lockPhaseLog(rigPath)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	history, err := LoadPhaseEvents(rigPath)
+	if err != nil {
+		return 0, err
+	}
+	drop := make(map[string]bool)
+	for _, t := range BuildTimelines(history) {
+		cur := t.Current()
+		if (cur.Phase == MRPhaseMerged || cur.Phase == MRPhaseRejected) && cur.At.Before(cutoff) {
+			drop[t.MR] = true
+		}
+	}
+	if len(drop) == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	kept := history[:0]
+	for _, ev := range history {
+		if drop[ev.MR] {
+			continue
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return 0, err
+		}
+		buf.Write(append(data, '\n'))
+		kept = append(kept, ev)
+	}
+	if err := util.AtomicWriteFile(phaseLogPath(rigPath), buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("writing phase log: %w", err)
+	}
+	return len(drop), savePhaseIndex(rigPath, indexPhaseEvents(kept))
+}
+
+// isRework reports whether a transition sends an MR back to the queue after
+// it failed or was bounced during diagnosis.
+func isRework(from, to MRPhase) bool {
+	return to == MRPhaseReady && (from == MRPhaseFailed || from == MRPhasePrepared)
+}
+
+// LoadPhaseEvents returns the rig's phase log in order. Malformed lines are
+// skipped.
+func LoadPhaseEvents(rigPath string) ([]PhaseEvent, error) {
+	f, err := os.Open(phaseLogPath(rigPath)) //nolint:gosec // G304: path from trusted rig path
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading phase log: %w", err)
+	}
+	defer f.Close()
+
+	var history []PhaseEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var ev PhaseEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil || ev.MR == "" {
+			continue
+		}
+		history = append(history, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading phase log: %w", err)
+	}
+	return history, nil
+}
+
+// MRTimeline is the recorded phase history of one MR.
+type MRTimeline struct {
+	MR     string
+	Worker string
+	Target string
+	Events []PhaseEvent
+}
+
+// Current returns the MR's latest phase event.
+func (t *MRTimeline) Current() PhaseEvent {
+	return t.Events[len(t.Events)-1]
+}
+
+// BuildTimelines groups phase events by MR, ordered by each MR's first event.
+func BuildTimelines(history []PhaseEvent) []*MRTimeline {
+	byMR := make(map[string]*MRTimeline)
+	var timelines []*MRTimeline
+	for _, ev := range history {
+		t := byMR[ev.MR]
+		if t == nil {
+			t = &MRTimeline{MR: ev.MR}
+			byMR[ev.MR] = t
+			timelines = append(timelines, t)
+		}
+		t.Events = append(t.Events, ev)
+		if ev.Worker != "" {
+			t.Worker = ev.Worker
+		}
+		if ev.Target != "" {
+			t.Target = ev.Target
+		}
+	}
+	return timelines
+}
+
+// DurationStats summarizes a set of durations.
+type DurationStats struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+}
+
+// GroupStats aggregates the timelines of a rig or of one worker.
+type GroupStats struct {
+	MRs          int           `json:"mrs"`
+	Merged       int           `json:"merged"`
+	Rejected     int           `json:"rejected"`
+	Open         int           `json:"open"`
+	Wait         DurationStats `json:"wait"` // Per MR: total time ready before being claimed
+	Gate         DurationStats `json:"gate"` // Per gate run: time spent preparing
+	GateFailures int           `json:"gate_failures"`
+	ReworkLoops  int           `json:"rework_loops"` // Returns to ready after failing or being bounced
+
+	waits []time.Duration
+	gates []time.Duration
+}
+
+// QueueStats is the queue-time analytics for a rig.
+type QueueStats struct {
+	Since   *time.Time             `json:"since,omitempty"`
+	Total   *GroupStats            `json:"total"`
+	Workers map[string]*GroupStats `json:"workers"`
+}
+
+// ComputeQueueStats aggregates the timelines with activity at or after since
+// (all timelines when since is zero). Only completed phase spans count
+// toward the wait and gate percentiles.
+func ComputeQueueStats(timelines []*MRTimeline, since time.Time) *QueueStats {
+	stats := &QueueStats{
+		Total:   &GroupStats{},
+		Workers: make(map[string]*GroupStats),
+	}
+	if !since.IsZero() {
+		stats.Since = &since
+	}
+	for _, t := range timelines {
+		if t.Current().At.Before(since) {
+			continue
+		}
+		worker := t.Worker
+		if worker == "" {
+			worker = "unknown"
+		}
+		ws := stats.Workers[worker]
+		if ws == nil {
+			ws = &GroupStats{}
+			stats.Workers[worker] = ws
+		}
+		stats.Total.add(t)
+		ws.add(t)
+	}
+	stats.Total.finish()
+	for _, ws := range stats.Workers {
+		ws.finish()
+	}
+	return stats
+}
+
+func (g *GroupStats) add(t *MRTimeline) {
+	g.MRs++
+	switch t.Current().Phase {
+	case MRPhaseMerged:
+		g.Merged++
+	case MRPhaseRejected:
+		g.Rejected++
+	default:
+		g.Open++
+	}
+
+	var wait time.Duration
+	waited := false
+	for i := 0; i+1 < len(t.Events); i++ {
+		from, to := t.Events[i], t.Events[i+1]
+		span := to.At.Sub(from.At)
+		switch from.Phase {
+		case MRPhaseReady:
+			wait += span
+			waited = true
+		case MRPhasePreparing:
+			g.gates = append(g.gates, span)
+			if to.Phase == MRPhaseFailed {
+				g.GateFailures++
+			}
+		}
+		if isRework(from.Phase, to.Phase) {
+			g.ReworkLoops++
+		}
+	}
+	if waited {
+		g.waits = append(g.waits, wait)
+	}
+}
+
+func (g *GroupStats) finish() {
+	g.Wait = summarize(g.waits)
+	g.Gate = summarize(g.gates)
+}
+
+func summarize(ds []time.Duration) DurationStats {
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	return DurationStats{
+		Count: len(ds),
+		P50:   percentile(ds, 0.5),
+		P90:   percentile(ds, 0.9),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// ParsePhaseSLA applies per-phase overrides ("ready": "2h") to
+// DefaultPhaseSLA. "0" or "off" disables a phase's SLA.
+func ParsePhaseSLA(overrides map[string]string) (map[MRPhase]time.Duration, error) {
+	sla := make(map[MRPhase]time.Duration, len(DefaultPhaseSLA))
+	for phase, limit := range DefaultPhaseSLA {
+		sla[phase] = limit
+	}
+	for name, value := range overrides {
+		phase := MRPhase(strings.ToLower(strings.TrimSpace(name)))
+		if _, ok := DefaultPhaseSLA[phase]; !ok {
+			return nil, fmt.Errorf("phase_sla: %q is not a non-terminal MR phase", name)
+		}
+		value = strings.TrimSpace(value)
+		if value == "0" || value == "off" {
+			delete(sla, phase)
+			continue
+		}
+		limit, err := time.ParseDuration(value)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("phase_sla: invalid duration %q for %s", value, name)
+		}
+		sla[phase] = limit
+	}
+	return sla, nil
+}
+
+// SLABreach is an MR that has stayed in its current phase past the SLA.
+type SLABreach struct {
+	MR     string        `json:"mr"`
+	Worker string        `json:"worker,omitempty"`
+	Target string        `json:"target,omitempty"`
+	Phase  MRPhase       `json:"phase"`
+	Since  time.Time     `json:"since"`
+	Age    time.Duration `json:"age"`
+	Limit  time.Duration `json:"limit"`
+}
+
+// CheckSLA returns the MRs whose current phase is older than its SLA at now.
+func CheckSLA(timelines []*MRTimeline, sla map[MRPhase]time.Duration, now time.Time) []SLABreach {
+	var breaches []SLABreach
+	for _, t := range timelines {
+		cur := t.Current()
+		limit, ok := sla[cur.Phase]
+		if !ok {
+			continue
+		}
+		if age := now.Sub(cur.At); age > limit {
+			breaches = append(breaches, SLABreach{
+				MR:     t.MR,
+				Worker: t.Worker,
+				Target: t.Target,
+				Phase:  cur.Phase,
+				Since:  cur.At,
+				Age:    age,
+				Limit:  limit,
+			})
+		}
+	}
+	return breaches
+}
+
+func slaStatePath(rigPath string) string {
+	return filepath.Join(constants.RigRuntimePath(rigPath), slaStateFile)
+}
+
+// slaKey identifies one stay of an MR in a phase, so each stay escalates once.
+func slaKey(b SLABreach) string {
+	return string(b.Phase) + "@" + b.Since.UTC().Format(time.RFC3339Nano)
+}
+
+func loadSLAState(rigPath string) (map[string]string, error) {
+	data, err := os.ReadFile(slaStatePath(rigPath)) //nolint:gosec // G304: path from trusted rig path
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading SLA state: %w", err)
+	}
+	state := map[string]string{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing SLA state: %w", err)
+	}
+	return state, nil
+}
+
+func saveSLAState(rigPath string, state map[string]string) error {
+	path := slaStatePath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating runtime dir: %w", err)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644) //nolint:gosec // G306: SLA state is non-sensitive operational data
+}
+
+// recordPhase records an MR phase transition, warning on failure: phase
+// tracking never blocks the merge itself.
+func (e *Engineer) recordPhase(mr *MRInfo, phase MRPhase, reason string) {
+	if mr == nil || mr.ID == "" {
+		return
+	}
+	ev := PhaseEvent{MR: mr.ID, Phase: phase, Worker: mr.Worker, Target: mr.Target, Reason: reason}
+	if err := RecordPhase(e.rig.Path, ev); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: recording %s phase %s: %v\n", mr.ID, phase, err)
+	}
+}
+
+// QueueStats computes queue-time analytics from the rig's phase log.
+func (e *Engineer) QueueStats(since time.Time) (*QueueStats, error) {
+	history, err := LoadPhaseEvents(e.rig.Path)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeQueueStats(BuildTimelines(history), since), nil
+}
+
+// CheckSLA returns open MRs that have stayed in their current phase longer
+// than the configured SLA. MRs whose bead is no longer open are ignored, so a
+// bead closed outside the phase log does not breach forever.
+func (e *Engineer) CheckSLA(now time.Time) ([]SLABreach, error) {
+	history, err := LoadPhaseEvents(e.rig.Path)
+	if err != nil {
+		return nil, err
+	}
+	open, err := e.ListAllOpenMRs()
+	if err != nil {
+		return nil, err
+	}
+	openIDs := make(map[string]bool, len(open))
+	for _, mr := range open {
+		openIDs[mr.ID] = true
+	}
+	var timelines []*MRTimeline
+	for _, t := range BuildTimelines(history) {
+		if openIDs[t.MR] {
+			timelines = append(timelines, t)
+		}
+	}
+	return CheckSLA(timelines, e.config.PhaseSLA, now), nil
+}
+
+// EscalateSLABreaches mails the Deacon about each breach not escalated
+// before and records an mq_sla_breach event. Returns the breaches escalated
+// by this call.
+func (e *Engineer) EscalateSLABreaches(breaches []SLABreach) ([]SLABreach, error) {
+	state, err := loadSLAState(e.rig.Path)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]string, len(breaches))
+	var escalated []SLABreach
+	for _, b := range breaches {
+		key := slaKey(b)
+		current[b.MR] = key
+		if state[b.MR] == key {
+			continue
+		}
+		if err := e.notifySLABreach(b); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: escalating SLA breach of %s: %v\n", b.MR, err)
+			delete(current, b.MR)
+			continue
+		}
+		escalated = append(escalated, b)
+	}
+	// Only live breaches are kept, so a later stay in the same phase escalates again
+	if err := saveSLAState(e.rig.Path, current); err != nil {
+		return escalated, err
+	}
+	return escalated, nil
+}
+
+// notifySLABreach sends the escalation for one breach to the Deacon.
+func (e *Engineer) notifySLABreach(b SLABreach) error {
+	age, limit := b.Age.Round(time.Minute).String(), b.Limit.String()
+	actor := e.rig.Name + "/refinery"
+	msg := &mail.Message{
+		From:     actor,
+		To:       "deacon/",
+		Subject:  fmt.Sprintf("MQ_SLA_BREACH %s: %s for %s", b.MR, b.Phase, age),
+		Priority: mail.PriorityHigh,
+		Type:     mail.TypeTask,
+		Body: fmt.Sprintf(`MR: %s
+Rig: %s
+Worker: %s
+Target: %s
+Phase: %s
+Since: %s
+Age: %s
+SLA: %s
+
+Run 'gt mq status %s' to see where it is stuck.`,
+			b.MR, e.rig.Name, b.Worker, b.Target, b.Phase,
+			b.Since.Format(time.RFC3339), age, limit, b.MR),
+	}
+	if err := e.router.Send(msg); err != nil {
+		return err
+	}
+	_ = events.LogFeed(events.TypeMQSLABreach, actor,
+		events.SLABreachPayload(e.rig.Name, b.MR, b.Worker, string(b.Phase), age, limit))
+	telemetry.RecordMQSLABreach(context.Background(), e.rig.Name, b.MR, string(b.Phase), b.Age, b.Limit)
+	return nil
+}
@@ -0,0 +1,276 @@
+package refinery
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecordPhase(t *testing.T) {
+	rigPath := t.TempDir()
+	t0 := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	record := func(phase MRPhase, at time.Duration) error {
+		return RecordPhase(rigPath, PhaseEvent{MR: "gt-mr-1", Phase: phase, At: t0.Add(at)})
+	}
+	if err := RecordPhase(rigPath, PhaseEvent{MR: "gt-mr-1", Phase: MRPhaseReady, At: t0, Worker: "nux", Target: "main"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := record(MRPhaseClaimed, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// Repeating the current phase is a no-op
+	if err := record(MRPhaseClaimed, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := record(MRPhaseMerged, 3*time.Minute); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("claimed → merged: err = %v, want ErrInvalidTransition", err)
+	}
+
+	history, err := LoadPhaseEvents(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("recorded %d events, want 2: %+v", len(history), history)
+	}
+	claimed := history[1]
+	if claimed.Phase != MRPhaseClaimed || !claimed.At.Equal(t0.Add(time.Minute)) {
+		t.Errorf("second event = %+v", claimed)
+	}
+	if claimed.Worker != "nux" || claimed.Target != "main" {
+		t.Errorf("worker/target not carried over: %+v", claimed)
+	}
+}
+
+func TestRecordPhase_RebuildsMissingIndex(t *testing.T) {
+	rigPath := t.TempDir()
+	if err := RecordPhase(rigPath, PhaseEvent{MR: "gt-mr-1", Phase: MRPhaseReady, Worker: "nux"}); err != nil {
+		t.Fatal(err)
+	}
+	// A log written before the index existed still validates transitions.
+	if err := os.Remove(phaseIndexPath(rigPath)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordPhase(rigPath, PhaseEvent{MR: "gt-mr-1", Phase: MRPhaseMerged}); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("ready → merged without index: err = %v, want ErrInvalidTransition", err)
+	}
+	if err := RecordPhase(rigPath, PhaseEvent{MR: "gt-mr-1", Phase: MRPhaseClaimed}); err != nil {
+		t.Fatal(err)
+	}
+	history, _ := LoadPhaseEvents(rigPath)
+	if len(history) != 2 || history[1].Worker != "nux" {
+		t.Errorf("history = %+v, want claimed with carried-over worker", history)
+	}
+}
+
+func TestPrunePhaseLog(t *testing.T) {
+	rigPath := t.TempDir()
+	old := time.Now().Add(-40 * 24 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	for _, ev := range []PhaseEvent{
+		{MR: "gt-old-merged", Phase: MRPhaseMerging, At: old},
+		{MR: "gt-old-merged", Phase: MRPhaseMerged, At: old.Add(time.Minute)},
+		{MR: "gt-old-open", Phase: MRPhaseReady, At: old},
+		{MR: "gt-new-rejected", Phase: MRPhaseReady, At: recent},
+		{MR: "gt-new-rejected", Phase: MRPhaseRejected, At: recent.Add(time.Minute)},
+	} {
+		if err := RecordPhase(rigPath, ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err := PrunePhaseLog(rigPath, time.Now().Add(-PhaseLogRetention))
+	if err != nil || n != 1 {
+		t.Fatalf("PrunePhaseLog = %d, %v; want 1", n, err)
+	}
+	history, _ := LoadPhaseEvents(rigPath)
+	for _, ev := range history {
+		if ev.MR == "gt-old-merged" {
+			t.Errorf("pruned MR still logged: %+v", ev)
+		}
+	}
+	if len(history) != 3 {
+		t.Errorf("kept %d events, want 3 (open and recent MRs)", len(history))
+	}
+	// The pruned MR is gone from the index too, so it may be logged afresh.
+	if err := RecordPhase(rigPath, PhaseEvent{MR: "gt-old-merged", Phase: MRPhaseReady}); err != nil {
+		t.Errorf("re-recording a pruned MR: %v", err)
+	}
+}
+
+func TestLoadPhaseEvents_NoLog(t *testing.T) {
+	history, err := LoadPhaseEvents(t.TempDir())
+	if err != nil || history != nil {
+		t.Errorf("LoadPhaseEvents = %v, %v; want nil, nil", history, err)
+	}
+}
+
+// phaseRun builds phase events for one MR from (phase, minutes) pairs.
+func phaseRun(mr, worker string, t0 time.Time, steps ...interface{}) []PhaseEvent {
+	var history []PhaseEvent
+	for i := 0; i < len(steps); i += 2 {
+		history = append(history, PhaseEvent{
+			MR:     mr,
+			Worker: worker,
+			Phase:  steps[i].(MRPhase),
+			At:     t0.Add(time.Duration(steps[i+1].(int)) * time.Minute),
+		})
+	}
+	return history
+}
+
+func TestComputeQueueStats(t *testing.T) {
+	t0 := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	var history []PhaseEvent
+	// Merged first time: waits 10m, gates 5m
+	history = append(history, phaseRun("mr-1", "nux", t0,
+		MRPhaseReady, 0, MRPhaseClaimed, 10, MRPhasePreparing, 11,
+		MRPhasePrepared, 16, MRPhaseMerging, 17, MRPhaseMerged, 18)...)
+	// Fails its gates once, then merges: waits 20m + 30m, gates 8m and 4m
+	history = append(history, phaseRun("mr-2", "nux", t0,
+		MRPhaseReady, 0, MRPhaseClaimed, 20, MRPhasePreparing, 21,
+		MRPhaseFailed, 29, MRPhaseReady, 30, MRPhaseClaimed, 60,
+		MRPhasePreparing, 61, MRPhasePrepared, 65, MRPhaseMerging, 66, MRPhaseMerged, 67)...)
+	// Bounced after diagnosis, still waiting: 40m wait so far
+	history = append(history, phaseRun("mr-3", "slit", t0,
+		MRPhaseReady, 0, MRPhaseClaimed, 40, MRPhasePreparing, 41,
+		MRPhasePrepared, 43, MRPhaseReady, 44)...)
+	// Rejected long ago, outside the window
+	history = append(history, phaseRun("mr-old", "slit", t0.Add(-48*time.Hour),
+		MRPhaseReady, 0, MRPhaseClaimed, 1, MRPhasePreparing, 2,
+		MRPhasePrepared, 3, MRPhaseRejected, 4)...)
+
+	stats := ComputeQueueStats(BuildTimelines(history), t0.Add(-time.Hour))
+	total := stats.Total
+	if total.MRs != 3 || total.Merged != 2 || total.Open != 1 || total.Rejected != 0 {
+		t.Errorf("counts = %d MRs, %d merged, %d open, %d rejected", total.MRs, total.Merged, total.Open, total.Rejected)
+	}
+	if total.ReworkLoops != 2 || total.GateFailures != 1 {
+		t.Errorf("rework = %d, gate failures = %d; want 2, 1", total.ReworkLoops, total.GateFailures)
+	}
+	// Waits per MR: 10m, 50m, 40m
+	if total.Wait.Count != 3 || total.Wait.P50 != 40*time.Minute || total.Wait.P90 != 50*time.Minute {
+		t.Errorf("wait = %+v", total.Wait)
+	}
+	// Gate runs: 5m, 8m, 4m, 2m
+	if total.Gate.Count != 4 || total.Gate.P50 != 4*time.Minute || total.Gate.P90 != 8*time.Minute {
+		t.Errorf("gate = %+v", total.Gate)
+	}
+
+	nux, slit := stats.Workers["nux"], stats.Workers["slit"]
+	if nux == nil || slit == nil || len(stats.Workers) != 2 {
+		t.Fatalf("workers = %v", stats.Workers)
+	}
+	if nux.MRs != 2 || nux.ReworkLoops != 1 || nux.Wait.P90 != 50*time.Minute {
+		t.Errorf("nux = %+v", nux)
+	}
+	if slit.MRs != 1 || slit.ReworkLoops != 1 || slit.Open != 1 {
+		t.Errorf("slit = %+v", slit)
+	}
+
+	all := ComputeQueueStats(BuildTimelines(history), time.Time{})
+	if all.Total.MRs != 4 || all.Total.Rejected != 1 {
+		t.Errorf("unbounded stats = %+v", all.Total)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("percentile(nil) = %v", got)
+	}
+	ds := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := percentile(ds, 0.5); got != 5 {
+		t.Errorf("p50 = %v, want 5", got)
+	}
+	if got := percentile(ds, 0.9); got != 9 {
+		t.Errorf("p90 = %v, want 9", got)
+	}
+	if got := percentile(ds[:1], 0.9); got != 1 {
+		t.Errorf("p90 of one = %v, want 1", got)
+	}
+}
+
+func TestParsePhaseSLA(t *testing.T) {
+	sla, err := ParsePhaseSLA(map[string]string{"ready": "2h", "Merging": "off"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sla[MRPhaseReady] != 2*time.Hour {
+		t.Errorf("ready = %v, want 2h", sla[MRPhaseReady])
+	}
+	if _, ok := sla[MRPhaseMerging]; ok {
+		t.Error("merging SLA not disabled")
+	}
+	if sla[MRPhasePreparing] != DefaultPhaseSLA[MRPhasePreparing] {
+		t.Errorf("preparing = %v, want default", sla[MRPhasePreparing])
+	}
+	if DefaultPhaseSLA[MRPhaseReady] != 4*time.Hour {
+		t.Error("ParsePhaseSLA modified DefaultPhaseSLA")
+	}
+
+	for _, bad := range []map[string]string{
+		{"merged": "1h"},
+		{"ready": "soon"},
+		{"ready": "-1h"},
+	} {
+		if _, err := ParsePhaseSLA(bad); err == nil {
+			t.Errorf("ParsePhaseSLA(%v) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestCheckSLA(t *testing.T) {
+	t0 := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	var history []PhaseEvent
+	history = append(history, phaseRun("mr-waiting", "nux", t0, MRPhaseReady, 0)...)
+	history = append(history, phaseRun("mr-gating", "slit", t0,
+		MRPhaseReady, 0, MRPhaseClaimed, 1, MRPhasePreparing, 2)...)
+	history = append(history, phaseRun("mr-merged", "nux", t0,
+		MRPhaseReady, 0, MRPhaseClaimed, 1, MRPhasePreparing, 2,
+		MRPhasePrepared, 3, MRPhaseMerging, 4, MRPhaseMerged, 5)...)
+
+	sla := map[MRPhase]time.Duration{
+		MRPhaseReady:     3 * time.Hour,
+		MRPhasePreparing: time.Hour,
+	}
+	breaches := CheckSLA(BuildTimelines(history), sla, t0.Add(2*time.Hour))
+	if len(breaches) != 1 {
+		t.Fatalf("breaches = %+v, want only mr-gating", breaches)
+	}
+	b := breaches[0]
+	if b.MR != "mr-gating" || b.Phase != MRPhasePreparing || b.Worker != "slit" || b.Limit != time.Hour {
+		t.Errorf("breach = %+v", b)
+	}
+	if b.Age != 118*time.Minute {
+		t.Errorf("age = %v, want 1h58m", b.Age)
+	}
+
+	if got := CheckSLA(BuildTimelines(history), sla, t0.Add(4*time.Hour)); len(got) != 2 {
+		t.Errorf("later breaches = %+v, want 2", got)
+	}
+}
+
+func TestSLAStateRoundTrip(t *testing.T) {
+	rigPath := t.TempDir()
+	state, err := loadSLAState(rigPath)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("loadSLAState on fresh rig = %v, %v", state, err)
+	}
+	b := SLABreach{MR: "mr-1", Phase: MRPhaseReady, Since: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)}
+	if err := saveSLAState(rigPath, map[string]string{b.MR: slaKey(b)}); err != nil {
+		t.Fatal(err)
+	}
+	state, err = loadSLAState(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state["mr-1"] != slaKey(b) {
+		t.Errorf("state = %v", state)
+	}
+	b.Since = b.Since.Add(time.Hour)
+	if state["mr-1"] == slaKey(b) {
+		t.Error("a later stay in the same phase shares the escalation key")
+	}
+}
@@ -0,0 +1,317 @@
+package refinery
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+)
+
+// Stacked merge requests.
+//
+// A stacked MR declares a parent MR (parent_mr) and records the parent branch
+// commit it was built on (parent_head). The child bead depends on the parent
+// bead and is never ready while parent_mr is set, so a stack merges in order.
+// Restack keeps each child on top of its parent: when the parent branch moves,
+// the child's own commits (parent_head..child) are replayed onto the parent's
+// new head; when the parent lands, they are replayed onto the target and the
+// child becomes an ordinary MR.
+
+// Restack actions reported in StackResult.
+const (
+	StackCurrent  = "current"  // Child already sits on its parent's head
+	StackRebased  = "rebased"  // Parent moved; child replayed onto its new head
+	StackLanded   = "landed"   // Parent merged; child replayed onto the target and unstacked
+	StackOrphaned = "orphaned" // Parent closed without merging; needs re-targeting by hand
+	StackSkipped  = "skipped"  // Child branch is checked out in another worktree; retried next restack
+	StackFailed   = "failed"   // Rebase or bookkeeping failed (see Error)
+)
+
+// ErrBranchCheckedOut is returned by replayBranch when the branch is checked
+// out in another worktree, where moving it would pull the ref out from under
+// the worker using it.
+var ErrBranchCheckedOut = errors.New("branch is checked out in another worktree")
+
+// StackResult reports what Restack did with one stacked MR.
+type StackResult struct {
+	MR     string `json:"mr"`
+	Branch string `json:"branch"`
+	Parent string `json:"parent"`
+	Action string `json:"action"`
+	Head   string `json:"head,omitempty"` // New branch head after a rebase
+	Error  string `json:"error,omitempty"`
+}
+
+// Restack rebases every stacked MR whose parent branch moved or whose parent
+// landed.
+func (e *Engineer) Restack() ([]StackResult, error) {
+	return e.restack("")
+}
+
+// restack restacks the children of parentID, or every stacked MR when
+// parentID is empty.
+func (e *Engineer) restack(parentID string) ([]StackResult, error) {
+	issues, err := e.beads.List(beads.ListOptions{
+		Status:   "open",
+		Label:    "gt:merge-request",
+		Priority: -1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying beads for merge-requests: %w", err)
+	}
+
+	fetched := false
+	var results []StackResult
+	for _, issue := range issues {
+		if issue.Status != "open" {
+			continue
+		}
+		fields := beads.ParseMRFields(issue)
+		if fields == nil || fields.ParentMR == "" {
+			continue
+		}
+		if parentID != "" && fields.ParentMR != parentID {
+			continue
+		}
+		if !fetched {
+			if err := e.git.Fetch("origin"); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: fetch before restack: %v\n", err)
+			}
+			fetched = true
+		}
+		result := e.restackMR(issue, fields)
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Restack %s (%s on %s): %s\n", issue.ID, fields.Branch, fields.ParentMR, result.Action)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// restackMR brings one stacked MR up to date with its parent.
+func (e *Engineer) restackMR(issue *beads.Issue, fields *beads.MRFields) StackResult {
+	result := StackResult{MR: issue.ID, Branch: fields.Branch, Parent: fields.ParentMR}
+	fail := func(format string, args ...interface{}) StackResult {
+		result.Action = StackFailed
+		result.Error = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	parent, err := e.beads.Show(fields.ParentMR)
+	if err != nil {
+		return fail("fetching parent %s: %v", fields.ParentMR, err)
+	}
+	parentFields := beads.ParseMRFields(parent)
+	if parentFields == nil {
+		parentFields = &beads.MRFields{}
+	}
+
+	if parent.Status == "closed" {
+		if !parentMerged(parent, parentFields) {
+			result.Action = StackOrphaned
+			result.Error = fmt.Sprintf("parent %s closed without merging (%s)", parent.ID, parent.CloseReason)
+			return result
+		}
+		return e.landStackedMR(issue, fields, result)
+	}
+
+	if parentFields.Branch == "" {
+		return fail("parent %s has no branch", parent.ID)
+	}
+	parentHead, err := e.git.Rev(e.branchRef(parentFields.Branch))
+	if err != nil {
+		return fail("resolving parent branch %s: %v", parentFields.Branch, err)
+	}
+	if parentHead == fields.ParentHead {
+		result.Action = StackCurrent
+		return result
+	}
+	if fields.ParentHead == "" {
+		return fail("no parent_head recorded; cannot tell which commits are the child's")
+	}
+
+	head, err := e.replayBranch(fields.Branch, parentHead, fields.ParentHead)
+	if errors.Is(err, ErrBranchCheckedOut) {
+		result.Action = StackSkipped
+		result.Error = err.Error()
+		return result
+	}
+	if err != nil {
+		// Left stacked on the old parent head: the replay is retried when the
+		// parent moves again or lands.
+		return fail("%v", err)
+	}
+	fields.ParentHead = parentHead
+	if err := e.updateMRFields(issue, fields); err != nil {
+		return fail("recording new parent head: %v", err)
+	}
+	result.Action = StackRebased
+	result.Head = head
+	return result
+}
+
+// landStackedMR replays a child whose parent merged onto the target and
+// unstacks it. If the replay conflicts, a conflict-resolution task carrying
+// the exact rebase command is created and the MR is blocked on it.
+func (e *Engineer) landStackedMR(issue *beads.Issue, fields *beads.MRFields, result StackResult) StackResult {
+	target := fields.Target
+	if target == "" {
+		target = e.rig.DefaultBranch()
+	}
+	newBase := "origin/" + target
+	if exists, _ := e.git.RemoteTrackingBranchExists("origin", target); !exists {
+		newBase = target
+	}
+
+	oldParentHead := fields.ParentHead
+	head, replayErr := e.replayBranch(fields.Branch, newBase, oldParentHead)
+	if errors.Is(replayErr, ErrBranchCheckedOut) {
+		// Stay stacked so the next restack lands it once the worktree lets go.
+		result.Action = StackSkipped
+		result.Error = replayErr.Error()
+		return result
+	}
+
+	fields.ParentMR = ""
+	fields.ParentHead = ""
+	if err := e.updateMRFields(issue, fields); err != nil {
+		result.Action = StackFailed
+		result.Error = fmt.Sprintf("unstacking: %v", err)
+		return result
+	}
+
+	if replayErr != nil {
+		result.Action = StackFailed
+		result.Error = replayErr.Error()
+		mr := issueToMRInfo(issue, fields)
+		mr.Target = target
+		mr.ParentHead = oldParentHead
+		taskID, err := e.createConflictResolutionTaskForMR(mr, ProcessResult{
+			Conflict: true,
+			Error:    fmt.Sprintf("restack onto %s after parent landed: %v", target, replayErr),
+		})
+		if err != nil {
+			result.Error += fmt.Sprintf("; creating conflict task: %v", err)
+		} else if taskID != "" {
+			if err := e.beads.AddDependency(issue.ID, taskID); err != nil {
+				result.Error += fmt.Sprintf("; blocking on %s: %v", taskID, err)
+			}
+		}
+		return result
+	}
+
+	result.Action = StackLanded
+	result.Head = head
+	return result
+}
+
+// parentMerged reports whether a closed parent MR landed, as opposed to
+// being rejected or superseded. The Go merge path records close_reason:
+// merged; the patrol closes with a "Merged to <target>" reason.
+func parentMerged(parent *beads.Issue, fields *beads.MRFields) bool {
+	return fields.CloseReason == "merged" ||
+		strings.HasPrefix(strings.ToLower(parent.CloseReason), "merged")
+}
+
+// branchRef returns the ref for an MR branch: the local branch in the shared
+// repo when it exists, otherwise its remote-tracking ref.
+func (e *Engineer) branchRef(branch string) string {
+	if exists, _ := e.git.BranchExists(branch); exists {
+		return branch
+	}
+	return "origin/" + branch
+}
+
+// replayBranch rebases the commits of branch after oldBase onto newBase and
+// moves the local branch and origin to the result. The rebase runs on a
+// detached HEAD. A branch checked out in another worktree (a polecat still
+// on it) is left alone with ErrBranchCheckedOut. Returns the new head.
+func (e *Engineer) replayBranch(branch, newBase, oldBase string) (string, error) {
+	localSHA := ""
+	if exists, _ := e.git.BranchExists(branch); exists {
+		localSHA, _ = e.git.Rev("refs/heads/" + branch)
+	}
+	if path := e.checkedOutElsewhere(branch); path != "" {
+		return "", fmt.Errorf("%s at %s: %w", branch, path, ErrBranchCheckedOut)
+	}
+	remoteSHA := ""
+	if exists, _ := e.git.RemoteTrackingBranchExists("origin", branch); exists {
+		remoteSHA, _ = e.git.Rev("refs/remotes/origin/" + branch)
+	}
+	oldHead := localSHA
+	if oldHead == "" {
+		oldHead = remoteSHA
+	}
+	if oldHead == "" {
+		return "", fmt.Errorf("branch %s not found", branch)
+	}
+
+	prev, _ := e.git.CurrentBranch()
+	defer func() {
+		if prev != "" && prev != "HEAD" {
+			if err := e.git.Checkout(prev); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to return to %s after restack: %v\n", prev, err)
+			}
+		}
+	}()
+
+	if err := e.git.CheckoutDetached(oldHead); err != nil {
+		return "", fmt.Errorf("checking out %s: %w", branch, err)
+	}
+	if err := e.git.RebaseOnto(newBase, oldBase); err != nil {
+		_ = e.git.AbortRebase()
+		return "", fmt.Errorf("rebasing %s onto %s: %w", branch, newBase, err)
+	}
+	newHead, err := e.git.Rev("HEAD")
+	if err != nil {
+		return "", err
+	}
+	if newHead == oldHead {
+		return newHead, nil
+	}
+
+	if remoteSHA != "" {
+		if err := e.git.PushForceWithLease("origin", newHead, branch, remoteSHA); err != nil {
+			return "", fmt.Errorf("pushing restacked %s: %w", branch, err)
+		}
+	}
+	if localSHA != "" {
+		if err := e.git.UpdateRef("refs/heads/"+branch, newHead, localSHA); err != nil {
+			return "", fmt.Errorf("updating %s: %w", branch, err)
+		}
+	}
+	return newHead, nil
+}
+
+// checkedOutElsewhere returns the path of a worktree other than the
+// engineer's own that has branch checked out, or "".
+func (e *Engineer) checkedOutElsewhere(branch string) string {
+	worktrees, err := e.git.WorktreeList()
+	if err != nil {
+		return ""
+	}
+	own := canonicalPath(e.workDir)
+	for _, wt := range worktrees {
+		if wt.Branch == branch && canonicalPath(wt.Path) != own {
+			return wt.Path
+		}
+	}
+	return ""
+}
+
+func canonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// updateMRFields writes fields back into an MR bead's description.
+func (e *Engineer) updateMRFields(issue *beads.Issue, fields *beads.MRFields) error {
+	desc := beads.SetMRFields(issue, fields)
+	if err := e.beads.Update(issue.ID, beads.UpdateOptions{Description: &desc}); err != nil {
+		return err
+	}
+	issue.Description = desc
+	return nil
+}
@@ -0,0 +1,173 @@
+package refinery
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/git"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestParentMerged(t *testing.T) {
+	tests := []struct {
+		reason string
+		fields beads.MRFields
+		want   bool
+	}{
+		{reason: "Merged to main at abc123", want: true},
+		{fields: beads.MRFields{CloseReason: "merged"}, want: true},
+		{reason: "rejected: superseded", fields: beads.MRFields{CloseReason: "rejected"}, want: false},
+		{reason: "", want: false},
+	}
+	for _, tt := range tests {
+		parent := &beads.Issue{Status: "closed", CloseReason: tt.reason}
+		if got := parentMerged(parent, &tt.fields); got != tt.want {
+			t.Errorf("parentMerged(%q, %q) = %v, want %v", tt.reason, tt.fields.CloseReason, got, tt.want)
+		}
+	}
+}
+
+// stackRepo creates a repo with main, polecat/parent (one commit) and
+// polecat/child (one commit on top of parent). Returns the repo dir and the
+// parent head the child was cut from.
+func stackRepo(t *testing.T) (string, string) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	commit := func(file, content, msg string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitRun(t, dir, "add", file)
+		gitRun(t, dir, "commit", "-qm", msg)
+	}
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	commit("README", "base\n", "base")
+	gitRun(t, dir, "checkout", "-qb", "polecat/parent")
+	commit("parent.txt", "parent v1\n", "parent work")
+	parentHead := gitRun(t, dir, "rev-parse", "HEAD")
+	gitRun(t, dir, "checkout", "-qb", "polecat/child")
+	commit("child.txt", "child\n", "child work")
+	gitRun(t, dir, "checkout", "-q", "main")
+	return dir, parentHead
+}
+
+func gitRun(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func newStackEngineer(t *testing.T, dir string) *Engineer {
+	t.Helper()
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.git = git.NewGit(dir)
+	e.workDir = dir
+	e.output = io.Discard
+	return e
+}
+
+func TestReplayBranch_ParentMoved(t *testing.T) {
+	dir, oldParentHead := stackRepo(t)
+	e := newStackEngineer(t, dir)
+
+	// Parent gets review fixes after the child was cut
+	gitRun(t, dir, "checkout", "-q", "polecat/parent")
+	if err := os.WriteFile(filepath.Join(dir, "parent.txt"), []byte("parent v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "commit", "-qam", "parent fixup")
+	newParentHead := gitRun(t, dir, "rev-parse", "HEAD")
+	gitRun(t, dir, "checkout", "-q", "main")
+
+	head, err := e.replayBranch("polecat/child", "polecat/parent", oldParentHead)
+	if err != nil {
+		t.Fatalf("replayBranch: %v", err)
+	}
+	if got := gitRun(t, dir, "rev-parse", "polecat/child"); got != head {
+		t.Errorf("polecat/child = %s, want %s", got, head)
+	}
+	if got := gitRun(t, dir, "rev-parse", "polecat/child^"); got != newParentHead {
+		t.Errorf("child's parent commit = %s, want new parent head %s", got, newParentHead)
+	}
+	if got := gitRun(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != "main" {
+		t.Errorf("HEAD left on %s, want main", got)
+	}
+}
+
+func TestReplayBranch_ParentLanded(t *testing.T) {
+	dir, parentHead := stackRepo(t)
+	e := newStackEngineer(t, dir)
+
+	// Parent lands as a squash commit, so its commits are not on main
+	gitRun(t, dir, "merge", "-q", "--squash", "polecat/parent")
+	gitRun(t, dir, "commit", "-qm", "Merge parent (squashed)")
+	mainHead := gitRun(t, dir, "rev-parse", "HEAD")
+
+	head, err := e.replayBranch("polecat/child", "main", parentHead)
+	if err != nil {
+		t.Fatalf("replayBranch: %v", err)
+	}
+	if got := gitRun(t, dir, "rev-parse", head+"^"); got != mainHead {
+		t.Errorf("child's parent commit = %s, want main %s", got, mainHead)
+	}
+	if got := gitRun(t, dir, "rev-list", "--count", "main..polecat/child"); got != "1" {
+		t.Errorf("child has %s commits over main, want 1", got)
+	}
+}
+
+func TestReplayBranch_ConflictLeavesBranch(t *testing.T) {
+	dir, parentHead := stackRepo(t)
+	e := newStackEngineer(t, dir)
+	before := gitRun(t, dir, "rev-parse", "polecat/child")
+
+	if err := os.WriteFile(filepath.Join(dir, "child.txt"), []byte("main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gitRun(t, dir, "add", "child.txt")
+	gitRun(t, dir, "commit", "-qm", "conflicting main change")
+
+	if _, err := e.replayBranch("polecat/child", "main", parentHead); err == nil {
+		t.Fatal("expected rebase conflict")
+	}
+	if got := gitRun(t, dir, "rev-parse", "polecat/child"); got != before {
+		t.Errorf("polecat/child moved to %s after failed replay", got)
+	}
+	if got := gitRun(t, dir, "status", "--porcelain"); got != "" {
+		t.Errorf("worktree dirty after failed replay:\n%s", got)
+	}
+}
+
+func TestReplayBranch_SkipsCheckedOutBranch(t *testing.T) {
+	dir, parentHead := stackRepo(t)
+	e := newStackEngineer(t, dir)
+	before := gitRun(t, dir, "rev-parse", "polecat/child")
+
+	// A polecat still has the child checked out in its own worktree
+	gitRun(t, dir, "worktree", "add", "-q", filepath.Join(t.TempDir(), "polecat"), "polecat/child")
+	gitRun(t, dir, "commit", "-q", "--allow-empty", "-m", "main moves")
+
+	_, err := e.replayBranch("polecat/child", "main", parentHead)
+	if !errors.Is(err, ErrBranchCheckedOut) {
+		t.Fatalf("replayBranch err = %v, want ErrBranchCheckedOut", err)
+	}
+	if got := gitRun(t, dir, "rev-parse", "polecat/child"); got != before {
+		t.Errorf("polecat/child moved to %s while checked out elsewhere", got)
+	}
+}
@@ -90,13 +90,15 @@ const (
 )
 
 // ValidPhaseTransitions defines the allowed state transitions for MR phases.
+// Besides diagnosis after prepared, gt mq reject can reject an MR from any
+// phase before it starts merging.
 var ValidPhaseTransitions = map[MRPhase][]MRPhase{
-	MRPhaseReady:     {MRPhaseClaimed},
-	MRPhaseClaimed:   {MRPhasePreparing, MRPhaseReady},
-	MRPhasePreparing: {MRPhasePrepared, MRPhaseFailed},
+	MRPhaseReady:     {MRPhaseClaimed, MRPhaseRejected},
+	MRPhaseClaimed:   {MRPhasePreparing, MRPhaseReady, MRPhaseRejected},
+	MRPhasePreparing: {MRPhasePrepared, MRPhaseFailed, MRPhaseRejected},
 	MRPhasePrepared:  {MRPhaseMerging, MRPhaseRejected, MRPhaseReady},
 	MRPhaseMerging:   {MRPhaseMerged, MRPhaseFailed},
-	MRPhaseFailed:    {MRPhaseReady},
+	MRPhaseFailed:    {MRPhaseReady, MRPhaseRejected},
 	// Terminal states: MRPhaseMerged, MRPhaseRejected (no transitions out)
 }
 
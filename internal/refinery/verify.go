@@ -0,0 +1,401 @@
+package refinery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/events"
+	"github.com/steveyegge/gastown/internal/mail"
+)
+
+// Post-merge verification.
+//
+// Gates run before a merge, but races between merge paths and environment
+// drift can still break the target. With post_merge_verify enabled, the
+// refinery patrol runs `gt mq verify` after every merge, which re-runs the
+// gates on the merged commit through VerifyMergedMR. When they fail the
+// engineer opens a P0 revert MR, mails the author, records a main.broken
+// event and pauses the rig's queue: while paused only revert MRs are ready.
+// The pause clears when a later verification of the target passes.
+
+// RevertBranchPrefix prefixes branches created for revert MRs.
+const RevertBranchPrefix = "revert/"
+
+// queuePauseFile holds the pause state under the rig's .runtime directory.
+const queuePauseFile = "mq-paused.json"
+
+// QueuePause records why a rig's merge queue is paused.
+type QueuePause struct {
+	Target      string    `json:"target"`
+	Commit      string    `json:"commit"`       // Merged commit that failed verification
+	Base        string    `json:"base"`         // Target head before that merge
+	MR          string    `json:"mr,omitempty"` // MR that broke the target
+	SourceIssue string    `json:"source_issue,omitempty"`
+	Worker      string    `json:"worker,omitempty"`
+	RevertMR    string    `json:"revert_mr,omitempty"`
+	Reason      string    `json:"reason"`
+	PausedAt    time.Time `json:"paused_at"`
+}
+
+// VerifyResult reports the outcome of VerifyMerge.
+type VerifyResult struct {
+	Commit   string `json:"commit"`
+	Passed   bool   `json:"passed"`
+	Skipped  bool   `json:"skipped,omitempty"` // No gates or test command configured
+	Error    string `json:"error,omitempty"`
+	RevertMR string `json:"revert_mr,omitempty"`
+	Paused   bool   `json:"paused,omitempty"`  // Queue is paused after this run
+	Resumed  bool   `json:"resumed,omitempty"` // This run cleared an earlier pause
+}
+
+func queuePausePath(rigPath string) string {
+	return filepath.Join(constants.RigRuntimePath(rigPath), queuePauseFile)
+}
+
+// LoadQueuePause returns the rig's queue pause, or nil if the queue is running.
+func LoadQueuePause(rigPath string) (*QueuePause, error) {
+	data, err := os.ReadFile(queuePausePath(rigPath)) //nolint:gosec // G304: path from trusted rig path
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading queue pause: %w", err)
+	}
+	var pause QueuePause
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return nil, fmt.Errorf("parsing queue pause: %w", err)
+	}
+	return &pause, nil
+}
+
+func saveQueuePause(rigPath string, pause *QueuePause) error {
+	path := queuePausePath(rigPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating runtime dir: %w", err)
+	}
+	data, err := json.MarshalIndent(pause, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling queue pause: %w", err)
+	}
+	return os.WriteFile(path, data, 0644) //nolint:gosec // G306: non-sensitive runtime state
+}
+
+// ClearQueuePause resumes a paused queue. Reports whether it was paused.
+func ClearQueuePause(rigPath string) (bool, error) {
+	err := os.Remove(queuePausePath(rigPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("clearing queue pause: %w", err)
+	}
+	return true, nil
+}
+
+// ApplyRigSettings fills in post-merge verification, phase SLAs and the check
+// command from the rig's settings/config.json merge_queue section when the
+// engineer's own config leaves them unset. The check command chains the setup,
+// build, typecheck, lint and test commands the patrol formula runs before
+// merging.
+func (e *Engineer) ApplyRigSettings(mq *config.MergeQueueConfig) {
+	if mq == nil {
+		return
+	}
+	if mq.IsPostMergeVerifyEnabled() {
+		e.config.PostMergeVerify = true
+	}
+	if len(mq.PhaseSLA) > 0 {
+		if sla, err := ParsePhaseSLA(mq.PhaseSLA); err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: ignoring %v\n", err)
+		} else {
+			e.config.PhaseSLA = sla
+		}
+	}
+	if e.hasQualityChecks() || !mq.IsRunTestsEnabled() {
+		return
+	}
+	var cmds []string
+	for _, c := range []string{mq.SetupCommand, mq.BuildCommand, mq.TypecheckCommand, mq.LintCommand, mq.TestCommand} {
+		if c != "" {
+			cmds = append(cmds, c)
+		}
+	}
+	if len(cmds) > 0 {
+		e.config.RunTests = true
+		e.config.TestCommand = strings.Join(cmds, " && ")
+	}
+}
+
+// hasQualityChecks reports whether runQualityChecks would run anything.
+func (e *Engineer) hasQualityChecks() bool {
+	return len(e.config.Gates) > 0 || (e.config.RunTests && e.config.TestCommand != "")
+}
+
+// VerifyMerge runs the quality checks on commit, which merged mr into its
+// target on top of base (commit^ when empty). On failure it opens a revert
+// MR and pauses the queue; on success it clears an earlier pause.
+func (e *Engineer) VerifyMerge(ctx context.Context, mr *MRInfo, commit, base string) VerifyResult {
+	result := VerifyResult{Commit: commit}
+	if !e.hasQualityChecks() {
+		result.Passed = true
+		result.Skipped = true
+		return result
+	}
+
+	target := mr.Target
+	if target == "" {
+		target = e.rig.DefaultBranch()
+	}
+	if base == "" {
+		base = commit + "^"
+	}
+	if sha, err := e.git.Rev(base); err == nil {
+		base = sha
+	}
+
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Verifying merged commit %s on %s...\n", shortSHA(commit), target)
+	check := e.verifyCommit(ctx, commit)
+
+	pause, err := LoadQueuePause(e.rig.Path)
+	if err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+	}
+
+	if check.Success {
+		result.Passed = true
+		if pause != nil && pause.Target == target {
+			if _, err := ClearQueuePause(e.rig.Path); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: %v\n", err)
+				result.Paused = true
+				return result
+			}
+			result.Resumed = true
+			_, _ = fmt.Fprintf(e.output, "[Engineer] %s is green again at %s; merge queue resumed\n", target, shortSHA(commit))
+			_ = events.LogFeed(events.TypeMainGreen, e.rig.Name+"/refinery", map[string]interface{}{
+				"rig":    e.rig.Name,
+				"target": target,
+				"commit": commit,
+			})
+		}
+		return result
+	}
+
+	result.Error = check.Error
+	_, _ = fmt.Fprintf(e.output, "[Engineer] ✗ Post-merge verification failed on %s: %s\n", shortSHA(commit), check.Error)
+
+	if pause != nil {
+		// Already paused: either the revert itself is still red or work
+		// landed outside the queue. Don't stack reverts; a human decides.
+		result.Paused = true
+		result.RevertMR = pause.RevertMR
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Queue already paused since %s (revert %s); not opening another revert\n",
+			shortSHA(pause.Commit), pause.RevertMR)
+		return result
+	}
+
+	pause = &QueuePause{
+		Target:      target,
+		Commit:      commit,
+		Base:        base,
+		MR:          mr.ID,
+		SourceIssue: mr.SourceIssue,
+		Worker:      mr.Worker,
+		Reason:      check.Error,
+		PausedAt:    time.Now().UTC(),
+	}
+	if mr.Reverts == "" {
+		revertMR, err := e.createRevertMR(mr, target, base, commit)
+		if err != nil {
+			_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not open revert MR: %v\n", err)
+		} else {
+			pause.RevertMR = revertMR
+			result.RevertMR = revertMR
+		}
+	}
+	if err := saveQueuePause(e.rig.Path, pause); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: could not pause queue: %v\n", err)
+	} else {
+		result.Paused = true
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Merge queue paused until %s is green\n", target)
+	}
+
+	_ = events.LogFeed(events.TypeMainBroken, e.rig.Name+"/refinery",
+		events.MainBrokenPayload(e.rig.Name, target, commit, mr.ID, pause.RevertMR, check.Error))
+	e.notifyMainBroken(mr, pause)
+	return result
+}
+
+// VerifyMergedMR verifies the commit that merged MR mrID. commit defaults
+// to the MR's merge_commit field.
+func (e *Engineer) VerifyMergedMR(ctx context.Context, mrID, commit, base string) (VerifyResult, error) {
+	issue, err := e.beads.Show(mrID)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("fetching MR %s: %w", mrID, err)
+	}
+	fields := beads.ParseMRFields(issue)
+	if fields == nil {
+		return VerifyResult{}, fmt.Errorf("%s is not a merge request", mrID)
+	}
+	if commit == "" {
+		commit = fields.MergeCommit
+	}
+	if commit == "" {
+		return VerifyResult{}, fmt.Errorf("%s has no merge_commit; pass the merged commit explicitly", mrID)
+	}
+	sha, err := e.git.Rev(commit)
+	if err != nil {
+		return VerifyResult{}, fmt.Errorf("resolving %s: %w", commit, err)
+	}
+	return e.VerifyMerge(ctx, issueToMRInfo(issue, fields), sha, base), nil
+}
+
+// verifyCommit runs the quality checks with commit checked out, then returns
+// the worktree to the branch it was on.
+func (e *Engineer) verifyCommit(ctx context.Context, commit string) ProcessResult {
+	prev, _ := e.git.CurrentBranch()
+	if err := e.git.CheckoutDetached(commit); err != nil {
+		return ProcessResult{Error: fmt.Sprintf("checking out %s: %v", commit, err)}
+	}
+	defer func() {
+		if prev != "" && prev != "HEAD" {
+			if err := e.git.Checkout(prev); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to return to %s after verification: %v\n", prev, err)
+			}
+		}
+	}()
+	return e.runQualityChecks(ctx)
+}
+
+// createRevertMR undoes base..commit in a single commit on top of the
+// target's current head, pushes it as a revert branch and queues it as a P0
+// merge request. The broken MR is marked reverted_by the new one.
+func (e *Engineer) createRevertMR(mr *MRInfo, target, base, commit string) (string, error) {
+	branch := RevertBranchPrefix + shortSHA(commit)
+	hasRemote, _ := e.git.RemoteTrackingBranchExists("origin", target)
+	start := target
+	if hasRemote {
+		start = "origin/" + target
+	}
+
+	prev, _ := e.git.CurrentBranch()
+	defer func() {
+		if prev != "" && prev != "HEAD" {
+			_ = e.git.Checkout(prev)
+		}
+	}()
+	if err := e.git.CheckoutDetached(start); err != nil {
+		return "", fmt.Errorf("checking out %s: %w", start, err)
+	}
+	if err := e.git.RevertRangeNoCommit(base, commit); err != nil {
+		_ = e.git.AbortRevert()
+		return "", fmt.Errorf("reverting %s..%s: %w", shortSHA(base), shortSHA(commit), err)
+	}
+
+	label := mr.SourceIssue
+	if label == "" {
+		label = shortSHA(commit)
+	}
+	msg := fmt.Sprintf("Revert %s: post-merge verification failed\n\nReverts %s..%s", label, base, commit)
+	if mr.ID != "" {
+		msg += fmt.Sprintf(" (%s)", mr.ID)
+	}
+	msg += ".\n"
+	if err := e.git.Commit(msg); err != nil {
+		_ = e.git.ResetHard("HEAD")
+		return "", fmt.Errorf("committing revert: %w", err)
+	}
+	if err := e.git.CreateBranchFrom(branch, "HEAD"); err != nil {
+		return "", fmt.Errorf("creating %s: %w", branch, err)
+	}
+	if hasRemote {
+		if err := e.git.Push("origin", branch, false); err != nil {
+			return "", fmt.Errorf("pushing %s: %w", branch, err)
+		}
+	}
+
+	desc := beads.FormatMRFields(&beads.MRFields{
+		Branch:  branch,
+		Target:  target,
+		Rig:     e.rig.Name,
+		Reverts: base + ".." + commit,
+	})
+	revert, err := e.beads.Create(beads.CreateOptions{
+		Title:       "Revert: " + label,
+		Type:        "merge-request",
+		Priority:    0,
+		Description: desc,
+		Actor:       e.rig.Name + "/refinery",
+		Ephemeral:   true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating revert MR: %w", err)
+	}
+	_, _ = fmt.Fprintf(e.output, "[Engineer] Opened revert MR %s (%s)\n", revert.ID, branch)
+
+	if mr.ID != "" {
+		if issue, err := e.beads.Show(mr.ID); err == nil {
+			fields := beads.ParseMRFields(issue)
+			if fields == nil {
+				fields = &beads.MRFields{}
+			}
+			fields.RevertedBy = revert.ID
+			if err := e.updateMRFields(issue, fields); err != nil {
+				_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to mark %s reverted: %v\n", mr.ID, err)
+			}
+		}
+	}
+	return revert.ID, nil
+}
+
+// notifyMainBroken mails the author of the breaking merge.
+func (e *Engineer) notifyMainBroken(mr *MRInfo, pause *QueuePause) {
+	if mr.Worker == "" {
+		return
+	}
+	revert := pause.RevertMR
+	if revert == "" {
+		revert = "(none - revert by hand)"
+	}
+	msg := &mail.Message{
+		From:     e.rig.Name + "/refinery",
+		To:       fmt.Sprintf("%s/%s", e.rig.Name, mr.Worker),
+		Subject:  fmt.Sprintf("MAIN_BROKEN: %s broke %s", mr.SourceIssue, pause.Target),
+		Priority: mail.PriorityUrgent,
+		Type:     mail.TypeNotification,
+		Body: fmt.Sprintf(`Your merge broke %s and is being reverted.
+
+MR: %s
+Branch: %s
+Issue: %s
+Commit: %s
+Revert MR: %s
+
+Post-merge verification failed:
+%s
+
+The merge queue for %s is paused until %s is green. Fix the work on a new
+branch from %s once the revert lands, then resubmit.
+`, pause.Target, mr.ID, mr.Branch, mr.SourceIssue, pause.Commit, revert,
+			pause.Reason, e.rig.Name, pause.Target, pause.Target),
+	}
+	if err := e.router.Send(msg); err != nil {
+		_, _ = fmt.Fprintf(e.output, "[Engineer] Warning: failed to notify %s: %v\n", mr.Worker, err)
+	}
+}
+
+// shortSHA abbreviates a commit for messages and branch names.
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
@@ -0,0 +1,167 @@
+package refinery
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/config"
+	"github.com/steveyegge/gastown/internal/rig"
+)
+
+func TestQueuePauseRoundTrip(t *testing.T) {
+	rigPath := t.TempDir()
+
+	if pause, err := LoadQueuePause(rigPath); err != nil || pause != nil {
+		t.Fatalf("LoadQueuePause on fresh rig = %v, %v; want nil, nil", pause, err)
+	}
+
+	want := &QueuePause{Target: "main", Commit: "abc", MR: "gt-mr-1", RevertMR: "gt-mr-2", Reason: "tests failed", PausedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := saveQueuePause(rigPath, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadQueuePause(rigPath)
+	if err != nil || got == nil {
+		t.Fatalf("LoadQueuePause = %v, %v", got, err)
+	}
+	if *got != *want {
+		t.Errorf("LoadQueuePause = %+v, want %+v", got, want)
+	}
+
+	if cleared, err := ClearQueuePause(rigPath); err != nil || !cleared {
+		t.Errorf("ClearQueuePause = %v, %v; want true, nil", cleared, err)
+	}
+	if cleared, err := ClearQueuePause(rigPath); err != nil || cleared {
+		t.Errorf("second ClearQueuePause = %v, %v; want false, nil", cleared, err)
+	}
+}
+
+func TestApplyRigSettings(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.config.TestCommand = ""
+	verify := true
+	e.ApplyRigSettings(&config.MergeQueueConfig{
+		PostMergeVerify: &verify,
+		SetupCommand:    "pnpm install",
+		TestCommand:     "pnpm test",
+	})
+	if !e.config.PostMergeVerify {
+		t.Error("PostMergeVerify not applied")
+	}
+	if e.config.TestCommand != "pnpm install && pnpm test" {
+		t.Errorf("TestCommand = %q", e.config.TestCommand)
+	}
+	if e.config.PhaseSLA[MRPhaseReady] != DefaultPhaseSLA[MRPhaseReady] {
+		t.Errorf("PhaseSLA = %v, want defaults", e.config.PhaseSLA)
+	}
+
+	e.ApplyRigSettings(&config.MergeQueueConfig{PhaseSLA: map[string]string{"ready": "1h"}})
+	if e.config.PhaseSLA[MRPhaseReady] != time.Hour {
+		t.Errorf("PhaseSLA[ready] = %v, want 1h", e.config.PhaseSLA[MRPhaseReady])
+	}
+	e.ApplyRigSettings(&config.MergeQueueConfig{PhaseSLA: map[string]string{"ready": "soon"}})
+	if e.config.PhaseSLA[MRPhaseReady] != time.Hour {
+		t.Errorf("invalid phase_sla replaced PhaseSLA: %v", e.config.PhaseSLA)
+	}
+
+	// Gates from the engineer's own config take precedence
+	e = NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.config.Gates = map[string]*GateConfig{"test": {Cmd: "make test"}}
+	e.ApplyRigSettings(&config.MergeQueueConfig{TestCommand: "pnpm test"})
+	if e.config.TestCommand != "" || e.config.PostMergeVerify {
+		t.Errorf("config overridden: %+v", e.config)
+	}
+}
+
+// brokenMainRepo creates a repo whose main head adds a file named "broken".
+// Returns the dir and the breaking commit.
+func brokenMainRepo(t *testing.T) (string, string) {
+	t.Helper()
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	gitRun(t, dir, "init", "-q", "-b", "main")
+	for _, file := range []string{"README", "broken"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(file+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		gitRun(t, dir, "add", file)
+		gitRun(t, dir, "commit", "-qm", "add "+file)
+	}
+	return dir, gitRun(t, dir, "rev-parse", "HEAD")
+}
+
+func TestVerifyMerge_FailurePausesAndReverts(t *testing.T) {
+	dir, commit := brokenMainRepo(t)
+	e := newStackEngineer(t, dir)
+	e.config.Gates = map[string]*GateConfig{"healthy": {Cmd: "test ! -f broken"}}
+
+	result := e.VerifyMerge(context.Background(), &MRInfo{ID: "gt-mr-1", Target: "main"}, commit, "")
+	if result.Passed || !result.Paused {
+		t.Fatalf("VerifyMerge = %+v, want failed and paused", result)
+	}
+
+	pause, err := LoadQueuePause(e.rig.Path)
+	if err != nil || pause == nil {
+		t.Fatalf("queue not paused: %v", err)
+	}
+	if pause.Commit != commit || pause.MR != "gt-mr-1" || pause.Target != "main" {
+		t.Errorf("pause = %+v", pause)
+	}
+
+	// The revert branch undoes the breaking commit on top of main, even
+	// though the MR bead could not be created without bd.
+	branch := RevertBranchPrefix + shortSHA(commit)
+	if got := gitRun(t, dir, "rev-parse", branch+"^"); got != commit {
+		t.Errorf("%s parent = %s, want %s", branch, got, commit)
+	}
+	cmd := exec.Command("git", "cat-file", "-e", branch+":broken")
+	cmd.Dir = dir
+	if cmd.Run() == nil {
+		t.Errorf("%s still contains the breaking file", branch)
+	}
+	if msg := gitRun(t, dir, "log", "-1", "--format=%B", branch); !strings.Contains(msg, "post-merge verification failed") {
+		t.Errorf("revert message = %q", msg)
+	}
+	if got := gitRun(t, dir, "rev-parse", "--abbrev-ref", "HEAD"); got != "main" {
+		t.Errorf("HEAD left on %s, want main", got)
+	}
+
+	// A second failure while paused does not open another revert
+	again := e.VerifyMerge(context.Background(), &MRInfo{ID: "gt-mr-3", Target: "main"}, commit, "")
+	if again.Passed || !again.Paused {
+		t.Errorf("second VerifyMerge = %+v", again)
+	}
+}
+
+func TestVerifyMerge_GreenResumesQueue(t *testing.T) {
+	dir, commit := brokenMainRepo(t)
+	e := newStackEngineer(t, dir)
+	e.config.Gates = map[string]*GateConfig{"healthy": {Cmd: "test -f README"}}
+	if err := saveQueuePause(e.rig.Path, &QueuePause{Target: "main", Commit: "old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result := e.VerifyMerge(context.Background(), &MRInfo{ID: "gt-mr-rev", Target: "main"}, commit, "")
+	if !result.Passed || !result.Resumed {
+		t.Fatalf("VerifyMerge = %+v, want passed and resumed", result)
+	}
+	if pause, _ := LoadQueuePause(e.rig.Path); pause != nil {
+		t.Errorf("queue still paused: %+v", pause)
+	}
+}
+
+func TestVerifyMerge_SkippedWithoutChecks(t *testing.T) {
+	e := NewEngineer(&rig.Rig{Name: "test-rig", Path: t.TempDir()})
+	e.config.TestCommand = ""
+	if result := e.VerifyMerge(context.Background(), &MRInfo{}, "abc", ""); !result.Skipped || !result.Passed {
+		t.Errorf("VerifyMerge = %+v, want skipped", result)
+	}
+}
@@ -45,6 +45,11 @@ func CopyOverlay(rigPath, destPath string) error {
 			continue
 		}
 
+		if isEnvFile(entry.Name()) {
+			style.PrintWarning("overlay file %s copied into %s may hold secrets; move them to the encrypted store with 'gt rig secrets import %s'",
+				entry.Name(), destPath, filepath.Base(rigPath))
+		}
+
 		srcPath := filepath.Join(overlayDir, entry.Name())
 		dstPath := filepath.Join(destPath, entry.Name())
 
@@ -197,3 +202,20 @@ func copyFilePreserveMode(src, dst string) error {
 
 	return nil
 }
+
+// isEnvFile reports whether name is a dotenv file (.env, .env.local, ...),
+// not counting templates such as .env.example.
+func isEnvFile(name string) bool {
+	if name == ".env" {
+		return true
+	}
+	if !strings.HasPrefix(name, ".env.") {
+		return false
+	}
+	for _, suffix := range []string{".example", ".sample", ".template"} {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,12 +2,16 @@
 package rig
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/style"
@@ -39,6 +43,41 @@ import (
 // Returns nil if the setup-hooks directory doesn't exist (nothing to run).
 // Individual hook failures are logged as warnings but don't fail the overall operation.
 func RunSetupHooks(rigPath, worktreePath string) error {
+	return RunSetupHooksWithEnv(rigPath, worktreePath, nil)
+}
+
+// RunSetupHooksWithEnv is RunSetupHooks with extra KEY=VALUE environment
+// entries for each hook, such as shared dependency cache locations.
+func RunSetupHooksWithEnv(rigPath, worktreePath string, env []string) error {
+	err := RunSetupHooksWithOptions(rigPath, worktreePath, SetupHookOptions{Env: env})
+	if errors.Is(err, ErrSetupHookFailed) {
+		return nil // already warned per hook; spawn goes on
+	}
+	return err
+}
+
+// ErrSetupHookFailed is wrapped by RunSetupHooksWithOptions when one or more
+// hooks failed or timed out.
+var ErrSetupHookFailed = errors.New("setup hook failed")
+
+// SetupHookOptions adjusts how RunSetupHooksWithOptions runs each hook.
+type SetupHookOptions struct {
+	// Env holds extra KEY=VALUE entries for each hook.
+	Env []string
+
+	// Timeout bounds each hook (default 60s).
+	Timeout time.Duration
+
+	// WarnPath, when set, is a path the worktree will not keep (a warm
+	// slot that is moved on claim). A hook whose output mentions it is
+	// warned about, since whatever it wrote with that path breaks.
+	WarnPath string
+}
+
+// RunSetupHooksWithOptions is RunSetupHooks with options. Unlike
+// RunSetupHooks it reports hook failures: every hook still runs, and the
+// returned error wraps ErrSetupHookFailed and names the hooks that failed.
+func RunSetupHooksWithOptions(rigPath, worktreePath string, opts SetupHookOptions) error {
 	hooksDir := filepath.Join(rigPath, ".runtime", "setup-hooks")
 
 	// Check if setup-hooks directory exists
@@ -62,6 +101,7 @@ func RunSetupHooks(rigPath, worktreePath string) error {
 	})
 
 	// Execute each hook
+	var failed []string
 	for _, entry := range entries {
 		if entry.IsDir() {
 			// Skip subdirectories
@@ -84,47 +124,65 @@ func RunSetupHooks(rigPath, worktreePath string) error {
 		}
 
 		// Execute the hook
-		if err := runHook(hookPath, worktreePath); err != nil {
-			// Log warning but continue - don't fail spawn for hook failures
+		output, err := runHook(hookPath, worktreePath, opts)
+		if opts.WarnPath != "" && strings.Contains(output, opts.WarnPath) {
+			style.PrintWarning("setup hook %s printed %s, which moves when a polecat claims it; keep pooled hooks path-independent", entry.Name(), opts.WarnPath)
+		}
+		if err != nil {
+			// Log warning but continue with the remaining hooks
 			style.PrintWarning("setup hook %s failed: %v", entry.Name(), err)
+			failed = append(failed, entry.Name())
 			continue
 		}
 
 		fmt.Printf("Ran setup hook: %s\n", entry.Name())
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: %s", ErrSetupHookFailed, strings.Join(failed, ", "))
+	}
 	return nil
 }
 
-// runHook executes a single hook script in the context of the worktree.
+// hookTimeout is the default maximum time a setup hook is allowed to run.
+const hookTimeout = 60 * time.Second
+
+// runHook executes a single hook script in the context of the worktree and
+// returns what it printed (also passed through to stdout/stderr).
 // The hook is run with:
 // - Working directory set to worktreePath
 // - Environment variable GT_WORKTREE_PATH pointing to the worktree
 // - Environment variable GT_RIG_PATH pointing to the rig
-// hookTimeout is the maximum time a setup hook is allowed to run.
-const hookTimeout = 60 * time.Second
-
-func runHook(hookPath, worktreePath string) error {
+func runHook(hookPath, worktreePath string, opts SetupHookOptions) (string, error) {
 	// Get the rig path from the hook path (strip .runtime/setup-hooks/)
 	rigPath := filepath.Dir(filepath.Dir(filepath.Dir(hookPath)))
 
-	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = hookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	var output bytes.Buffer
 	cmd := exec.CommandContext(ctx, hookPath)
 	cmd.Dir = worktreePath
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = io.MultiWriter(os.Stdout, &output)
+	cmd.Stderr = io.MultiWriter(os.Stderr, &output)
+	// Output goes through a pipe now; don't wait on children that outlive
+	// a killed hook and keep it open.
+	cmd.WaitDelay = time.Second
 	cmd.Env = append(os.Environ(),
 		fmt.Sprintf("GT_WORKTREE_PATH=%s", worktreePath),
 		fmt.Sprintf("GT_RIG_PATH=%s", rigPath),
 	)
+	cmd.Env = append(cmd.Env, opts.Env...)
 
 	if err := cmd.Run(); err != nil {
 		if ctx.Err() == context.DeadlineExceeded {
-			return fmt.Errorf("timed out after %s", hookTimeout)
+			return output.String(), fmt.Errorf("timed out after %s", timeout)
 		}
-		return err
+		return output.String(), err
 	}
-	return nil
+	return output.String(), nil
 }
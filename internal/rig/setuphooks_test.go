@@ -0,0 +1,54 @@
+package rig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeHook(t *testing.T, rigDir, name, script string) {
+	t.Helper()
+	hooksDir := filepath.Join(rigDir, ".runtime", "setup-hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hooksDir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunSetupHooksWithOptions_ReportsFailedHooks(t *testing.T) {
+	rigDir := t.TempDir()
+	worktree := t.TempDir()
+	writeHook(t, rigDir, "01-fail.sh", "exit 3")
+	writeHook(t, rigDir, "02-ok.sh", "touch ran")
+
+	err := RunSetupHooksWithOptions(rigDir, worktree, SetupHookOptions{})
+	if !errors.Is(err, ErrSetupHookFailed) {
+		t.Fatalf("err = %v, want ErrSetupHookFailed", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktree, "ran")); err != nil {
+		t.Error("hooks after a failed one should still run")
+	}
+
+	// The spawn path keeps warning and going on.
+	if err := RunSetupHooksWithEnv(rigDir, worktree, nil); err != nil {
+		t.Errorf("RunSetupHooksWithEnv = %v, want nil", err)
+	}
+}
+
+func TestRunSetupHooksWithOptions_Timeout(t *testing.T) {
+	rigDir := t.TempDir()
+	writeHook(t, rigDir, "01-slow.sh", "sleep 5")
+
+	start := time.Now()
+	err := RunSetupHooksWithOptions(rigDir, t.TempDir(), SetupHookOptions{Timeout: 200 * time.Millisecond})
+	if !errors.Is(err, ErrSetupHookFailed) {
+		t.Fatalf("err = %v, want ErrSetupHookFailed", err)
+	}
+	if elapsed := time.Since(start); elapsed > 4*time.Second {
+		t.Errorf("hook ran %s, want it killed at the timeout", elapsed)
+	}
+}
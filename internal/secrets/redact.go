@@ -0,0 +1,206 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MinRedactLength is the shortest value that is redacted. Shorter values
+// ("yes", "1234") would mangle ordinary text.
+const MinRedactLength = 6
+
+// reloadInterval bounds how often a cached redactor re-checks the stores.
+const reloadInterval = 5 * time.Second
+
+type secretValue struct {
+	name  string
+	value string
+}
+
+// Redactor replaces secret values in text with [REDACTED:NAME].
+type Redactor struct {
+	values []secretValue // longest first, so overlapping values redact fully
+}
+
+// NewRedactor builds a redactor for the given name → value pairs.
+func NewRedactor(values map[string]string) *Redactor {
+	r := &Redactor{}
+	for name, value := range values {
+		if len(value) >= MinRedactLength {
+			r.values = append(r.values, secretValue{name: name, value: value})
+		}
+	}
+	sort.Slice(r.values, func(i, j int) bool {
+		if len(r.values[i].value) != len(r.values[j].value) {
+			return len(r.values[i].value) > len(r.values[j].value)
+		}
+		return r.values[i].name < r.values[j].name
+	})
+	return r
+}
+
+// Redact returns s with every secret value replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, v := range r.values {
+		if strings.Contains(s, v.value) {
+			s = strings.ReplaceAll(s, v.value, "[REDACTED:"+v.name+"]")
+		}
+	}
+	return s
+}
+
+// RedactValue redacts strings inside v, descending into the maps and slices
+// used for event payloads. Other values are returned unchanged.
+func (r *Redactor) RedactValue(v interface{}) interface{} {
+	if r == nil || len(r.values) == 0 {
+		return v
+	}
+	switch t := v.(type) {
+	case string:
+		return r.Redact(t)
+	case []string:
+		out := make([]string, len(t))
+		for i, s := range t {
+			out[i] = r.Redact(s)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(t))
+		for i, e := range t {
+			out[i] = r.RedactValue(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			out[k] = r.RedactValue(e)
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(t))
+		for k, e := range t {
+			out[k] = r.Redact(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Empty reports whether there is nothing to redact.
+func (r *Redactor) Empty() bool {
+	return r == nil || len(r.values) == 0
+}
+
+// storePaths returns the secret stores of every rig in a town.
+func storePaths(townRoot string) []string {
+	paths, _ := filepath.Glob(filepath.Join(townRoot, "*", "settings", StoreFile))
+	return paths
+}
+
+// LoadRedactor builds a redactor for every rig in a town. Stores are
+// decrypted when the key is available; otherwise values come from the
+// environment, which is where a session's own secrets live.
+func LoadRedactor(townRoot string) *Redactor {
+	values := make(map[string]string)
+	for _, path := range storePaths(townRoot) {
+		rigPath := filepath.Dir(filepath.Dir(path))
+		if s, err := Load(rigPath); err == nil {
+			for k, v := range s.values {
+				values[k] = v
+			}
+			continue
+		}
+		names, _ := Names(rigPath)
+		for _, name := range names {
+			if v := os.Getenv(name); v != "" {
+				values[name] = v
+			}
+		}
+	}
+	return NewRedactor(values)
+}
+
+// cachedRedactor is a town's redactor, rebuilt when a store changes.
+type cachedRedactor struct {
+	redactor  *Redactor
+	signature string
+	checked   time.Time
+}
+
+var (
+	cacheMu     sync.Mutex
+	cache       = make(map[string]*cachedRedactor)
+	defaultTown string
+)
+
+// storesSignature identifies the current contents of a town's stores.
+func storesSignature(townRoot string) string {
+	var b strings.Builder
+	for _, path := range storePaths(townRoot) {
+		if info, err := os.Stat(path); err == nil {
+			b.WriteString(path)
+			b.WriteString(info.ModTime().String())
+		}
+	}
+	return b.String()
+}
+
+// ForTown returns the redactor for a town, cached across calls.
+func ForTown(townRoot string) *Redactor {
+	if townRoot == "" {
+		return nil
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	c := cache[townRoot]
+	now := time.Now()
+	if c != nil && now.Sub(c.checked) < reloadInterval {
+		return c.redactor
+	}
+	sig := storesSignature(townRoot)
+	if c != nil && c.signature == sig {
+		c.checked = now
+		return c.redactor
+	}
+	c = &cachedRedactor{signature: sig, checked: now}
+	if sig != "" {
+		c.redactor = LoadRedactor(townRoot)
+	}
+	cache[townRoot] = c
+	return c.redactor
+}
+
+// SetTownRoot sets the town used by Redact. Without it, Redact falls back
+// to $GT_TOWN_ROOT, which every agent session has.
+func SetTownRoot(townRoot string) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	defaultTown = townRoot
+}
+
+func currentTown() string {
+	cacheMu.Lock()
+	town := defaultTown
+	cacheMu.Unlock()
+	if town == "" {
+		town = os.Getenv("GT_TOWN_ROOT")
+	}
+	return town
+}
+
+// Redact replaces secret values in s using the current town's secrets.
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	return ForTown(currentTown()).Redact(s)
+}
@@ -0,0 +1,75 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r := NewRedactor(map[string]string{
+		"TOKEN":  "abcdef123456",
+		"PREFIX": "abcdef",
+		"SHORT":  "abc",
+	})
+	got := r.Redact("token=abcdef123456 prefix=abcdef short=abc")
+	want := "token=[REDACTED:TOKEN] prefix=[REDACTED:PREFIX] short=abc"
+	if got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+
+	var nilRedactor *Redactor
+	if nilRedactor.Redact("abcdef123456") != "abcdef123456" || !nilRedactor.Empty() {
+		t.Error("nil redactor must pass text through")
+	}
+}
+
+func TestRedactValue(t *testing.T) {
+	r := NewRedactor(map[string]string{"TOKEN": "abcdef123456"})
+	payload := map[string]interface{}{
+		"subject": "leak abcdef123456",
+		"count":   3,
+		"nested":  map[string]interface{}{"list": []interface{}{"abcdef123456", 1}},
+		"tags":    []string{"abcdef123456"},
+	}
+	got := r.RedactValue(payload).(map[string]interface{})
+	if got["subject"] != "leak [REDACTED:TOKEN]" || got["count"] != 3 {
+		t.Errorf("payload = %v", got)
+	}
+	if got["nested"].(map[string]interface{})["list"].([]interface{})[0] != "[REDACTED:TOKEN]" {
+		t.Errorf("nested = %v", got["nested"])
+	}
+	if got["tags"].([]string)[0] != "[REDACTED:TOKEN]" {
+		t.Errorf("tags = %v", got["tags"])
+	}
+	if payload["subject"] != "leak abcdef123456" {
+		t.Error("RedactValue must not modify its input")
+	}
+}
+
+func TestLoadRedactor_EnvFallback(t *testing.T) {
+	keyPath := setupKey(t)
+	townRoot := t.TempDir()
+	rigPath := filepath.Join(townRoot, "gastown")
+	s, _ := Load(rigPath)
+	_ = s.Set("NPM_TOKEN", "npm_abcdef123456")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := LoadRedactor(townRoot).Redact("npm_abcdef123456"); got != "[REDACTED:NPM_TOKEN]" {
+		t.Errorf("with key: %q", got)
+	}
+
+	// Without the key (sandboxed polecat), the session's own env is used.
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadRedactor(townRoot).Redact("npm_abcdef123456"); got != "npm_abcdef123456" {
+		t.Errorf("without key or env: %q", got)
+	}
+	t.Setenv("NPM_TOKEN", "npm_abcdef123456")
+	if got := LoadRedactor(townRoot).Redact("npm_abcdef123456"); got != "[REDACTED:NPM_TOKEN]" {
+		t.Errorf("from env: %q", got)
+	}
+}
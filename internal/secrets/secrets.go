@@ -0,0 +1,366 @@
+// Package secrets keeps per-rig secrets encrypted at rest, hands them to
+// agent sessions as environment variables, and redacts their values from
+// text that leaves a session: pane captures, mail, events and telemetry.
+//
+// Each rig has one store, <rig>/settings/secrets.enc, encrypted with
+// AES-256-GCM under a key file kept outside the town
+// (~/.config/gastown/secrets.key, or $GT_SECRETS_KEY_FILE). Secret names
+// are stored in the clear so processes without the key (sandboxed polecats)
+// can still redact values they hold in their environment.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// StoreFile is the name of a rig's secret store, under <rig>/settings/.
+const StoreFile = "secrets.enc"
+
+// KeyFileEnv overrides the location of the key file.
+const KeyFileEnv = "GT_SECRETS_KEY_FILE"
+
+const (
+	storeVersion = 1
+	keySize      = 32
+)
+
+// ErrNoKey is returned when a store exists but the key file does not.
+var ErrNoKey = errors.New("secrets key not found")
+
+var namePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// storeFile is the on-disk form of a store.
+type storeFile struct {
+	Version int      `json:"version"`
+	Names   []string `json:"names"` // authenticated as additional data
+	Nonce   string   `json:"nonce"`
+	Data    string   `json:"data"`
+}
+
+// Store is a rig's decrypted secrets.
+type Store struct {
+	path   string
+	values map[string]string
+}
+
+// StorePath returns the secret store path for a rig.
+func StorePath(rigPath string) string {
+	return filepath.Join(rigPath, "settings", StoreFile)
+}
+
+// KeyPath returns the key file location.
+func KeyPath() (string, error) {
+	if p := os.Getenv(KeyFileEnv); p != "" {
+		return p, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("locating key file: %w", err)
+	}
+	return filepath.Join(dir, "gastown", "secrets.key"), nil
+}
+
+// loadKey reads the key file, creating it when create is set.
+func loadKey(create bool) ([]byte, error) {
+	path, err := KeyPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is the configured key file
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != keySize {
+			return nil, fmt.Errorf("invalid secrets key in %s", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading secrets key: %w", err)
+	}
+	if !create {
+		return nil, fmt.Errorf("%w at %s", ErrNoKey, path)
+	}
+
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("generating secrets key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating key directory: %w", err)
+	}
+	if err := util.AtomicWriteFile(path, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("writing secrets key: %w", err)
+	}
+	return key, nil
+}
+
+func readStoreFile(path string) (*storeFile, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is a rig store path
+	if err != nil {
+		return nil, err
+	}
+	var sf storeFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if sf.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported secrets store version %d in %s", sf.Version, path)
+	}
+	return &sf, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(names []string) []byte {
+	return []byte(strings.Join(names, "\n"))
+}
+
+// Load decrypts a rig's store. A rig without a store gets an empty one.
+// Returns ErrNoKey if the store exists but the key file does not.
+func Load(rigPath string) (*Store, error) {
+	s := &Store{path: StorePath(rigPath), values: make(map[string]string)}
+	sf, err := readStoreFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	key, err := loadKey(false)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(sf.Nonce)
+	if err != nil || len(nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("corrupt nonce in %s", s.path)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(sf.Data)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt data in %s", s.path)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData(sf.Names))
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s (wrong key or tampered file): %w", s.path, err)
+	}
+	if err := json.Unmarshal(plaintext, &s.values); err != nil {
+		return nil, fmt.Errorf("parsing decrypted %s: %w", s.path, err)
+	}
+	return s, nil
+}
+
+// Names returns the secret names in a rig's store without decrypting it.
+func Names(rigPath string) ([]string, error) {
+	sf, err := readStoreFile(StorePath(rigPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sf.Names, nil
+}
+
+// ValidateName checks that name can be used as an environment variable.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid secret name %q: must be a valid environment variable name", name)
+	}
+	if strings.HasPrefix(name, "GT_") || strings.HasPrefix(name, "BD_") {
+		return fmt.Errorf("invalid secret name %q: GT_ and BD_ are reserved", name)
+	}
+	return nil
+}
+
+// Get returns a secret's value.
+func (s *Store) Get(name string) (string, bool) {
+	v, ok := s.values[name]
+	return v, ok
+}
+
+// Set adds or replaces a secret. Call Save to persist.
+func (s *Store) Set(name, value string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if value == "" {
+		return fmt.Errorf("secret %s has an empty value", name)
+	}
+	s.values[name] = value
+	return nil
+}
+
+// Delete removes a secret and reports whether it existed. Call Save to persist.
+func (s *Store) Delete(name string) bool {
+	_, ok := s.values[name]
+	delete(s.values, name)
+	return ok
+}
+
+// Names returns the secret names, sorted.
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Env returns the secrets as environment variables.
+func (s *Store) Env() map[string]string {
+	env := make(map[string]string, len(s.values))
+	for k, v := range s.values {
+		env[k] = v
+	}
+	return env
+}
+
+// Save encrypts and writes the store, creating the key file on first use.
+// An empty store removes the file.
+func (s *Store) Save() error {
+	if len(s.values) == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	key, err := loadKey(true)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	names := s.Names()
+	sf := storeFile{
+		Version: storeVersion,
+		Names:   names,
+		Nonce:   base64.StdEncoding.EncodeToString(nonce),
+		Data:    base64.StdEncoding.EncodeToString(gcm.Seal(nil, nonce, plaintext, additionalData(names))),
+	}
+	return util.EnsureDirAndWriteJSONWithPerm(s.path, sf, 0600)
+}
+
+// SessionEnv returns a rig's secrets for injection into a session's
+// environment. Returns nil when the rig has no store.
+func SessionEnv(rigPath string) (map[string]string, error) {
+	if rigPath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(StorePath(rigPath)); os.IsNotExist(err) {
+		return nil, nil
+	}
+	s, err := Load(rigPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.Env(), nil
+}
+
+// EnvFilePath returns where a session's secrets are staged before it
+// starts: a 0600 file under the rig's runtime directory.
+func EnvFilePath(rigPath, sessionID string) string {
+	return filepath.Join(constants.RigRuntimePath(rigPath), "secrets", sessionID+".env")
+}
+
+// WrapCommand stages env in the session's env file and returns command
+// prefixed to source the file and delete it. The values never appear in
+// the command line, so they stay out of ps output, tmux's
+// pane_start_command and the sandbox's argv. A respawned pane finds the
+// file gone and runs command with the environment it inherits. Returns
+// command unchanged when env is empty.
+func WrapCommand(rigPath, sessionID, command string, env map[string]string) (string, error) {
+	if len(env) == 0 {
+		return command, nil
+	}
+	names := make([]string, 0, len(env))
+	for name := range env {
+		if err := ValidateName(name); err != nil {
+			return "", err
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "export %s=%s\n", name, shellQuote(env[name]))
+	}
+
+	path := EnvFilePath(rigPath, sessionID)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", fmt.Errorf("creating secrets directory: %w", err)
+	}
+	if err := util.AtomicWriteFile(path, []byte(b.String()), 0600); err != nil {
+		return "", fmt.Errorf("staging session secrets: %w", err)
+	}
+	q := shellQuote(path)
+	return fmt.Sprintf("[ -f %s ] && . %s; rm -f %s; %s", q, q, q, command), nil
+}
+
+// RemoveEnvFile deletes a session's staged env file, for sessions that
+// failed to start and so never sourced it.
+func RemoveEnvFile(rigPath, sessionID string) {
+	_ = os.Remove(EnvFilePath(rigPath, sessionID))
+}
+
+// shellQuote single-quotes s for POSIX sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ParseEnvFile parses KEY=VALUE lines as found in .env files. Blank lines,
+// comments and an "export " prefix are allowed; values may be quoted.
+func ParseEnvFile(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", i+1)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	return values, nil
+}
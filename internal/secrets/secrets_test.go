@@ -0,0 +1,183 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupKey(t *testing.T) string {
+	t.Helper()
+	keyPath := filepath.Join(t.TempDir(), "secrets.key")
+	t.Setenv(KeyFileEnv, keyPath)
+	return keyPath
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	keyPath := setupKey(t)
+	rigPath := t.TempDir()
+
+	s, err := Load(rigPath)
+	if err != nil {
+		t.Fatalf("Load(empty) = %v", err)
+	}
+	if err := s.Set("NPM_TOKEN", "npm_abcdef123456"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("API_KEY", "sk-live-999999"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("key file not created 0600: %v %v", info, err)
+	}
+	raw, err := os.ReadFile(StorePath(rigPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "npm_abcdef123456") {
+		t.Fatal("store holds a plaintext value")
+	}
+
+	names, err := Names(rigPath)
+	if err != nil || strings.Join(names, ",") != "API_KEY,NPM_TOKEN" {
+		t.Errorf("Names() = %v, %v", names, err)
+	}
+
+	loaded, err := Load(rigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := loaded.Get("NPM_TOKEN"); v != "npm_abcdef123456" {
+		t.Errorf("Get(NPM_TOKEN) = %q", v)
+	}
+
+	// Removing the last secret removes the store.
+	loaded.Delete("NPM_TOKEN")
+	loaded.Delete("API_KEY")
+	if err := loaded.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(StorePath(rigPath)); !os.IsNotExist(err) {
+		t.Errorf("empty store not removed: %v", err)
+	}
+}
+
+func TestLoadWithoutKey(t *testing.T) {
+	keyPath := setupKey(t)
+	rigPath := t.TempDir()
+	s, _ := Load(rigPath)
+	_ = s.Set("TOKEN", "value-123456")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(rigPath); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Load() without key = %v, want ErrNoKey", err)
+	}
+	// Names stay readable.
+	if names, err := Names(rigPath); err != nil || len(names) != 1 {
+		t.Errorf("Names() = %v, %v", names, err)
+	}
+}
+
+func TestLoadRejectsTamperedNames(t *testing.T) {
+	setupKey(t)
+	rigPath := t.TempDir()
+	s, _ := Load(rigPath)
+	_ = s.Set("TOKEN", "value-123456")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	path := StorePath(rigPath)
+	raw, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(raw), `"TOKEN"`, `"OTHER"`, 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(rigPath); err == nil {
+		t.Error("Load() accepted a store whose names were altered")
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"NPM_TOKEN", "_x", "a1"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "1X", "A-B", "GT_RIG", "BD_DOLT_AUTO_COMMIT"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) accepted", name)
+		}
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	values, err := ParseEnvFile([]byte(`# comment
+export NPM_TOKEN=abc
+DB_URL="postgres://u:p@h/db"
+SINGLE='x y'
+
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"NPM_TOKEN": "abc", "DB_URL": "postgres://u:p@h/db", "SINGLE": "x y"}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s = %q, want %q", k, values[k], v)
+		}
+	}
+	if _, err := ParseEnvFile([]byte("not a pair\n")); err == nil {
+		t.Error("expected error for a line without =")
+	}
+}
+
+func TestWrapCommand(t *testing.T) {
+	rigPath := t.TempDir()
+	secret := "s3cr'et $(touch pwned)"
+	env := map[string]string{"API_KEY": secret}
+
+	cmd, err := WrapCommand(rigPath, "gt-rig-Toast", `exec sh -c 'printf %s "$API_KEY"'`, env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(cmd, secret) || strings.Contains(cmd, "s3cr") {
+		t.Fatalf("secret value in command line: %s", cmd)
+	}
+	path := EnvFilePath(rigPath, "gt-rig-Toast")
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("env file not staged 0600: %v %v", info, err)
+	}
+
+	out, err := exec.Command("/bin/sh", "-c", cmd).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != secret {
+		t.Errorf("session saw API_KEY = %q, want %q", out, secret)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("env file not deleted after sourcing: %v", err)
+	}
+
+	// A respawn re-runs the command after the file is gone.
+	if _, err := exec.Command("/bin/sh", "-c", cmd).Output(); err != nil {
+		t.Errorf("re-running command without env file: %v", err)
+	}
+
+	if got, err := WrapCommand(rigPath, "gt-rig-Toast", "exec claude", nil); err != nil || got != "exec claude" {
+		t.Errorf("WrapCommand(no secrets) = %q, %v", got, err)
+	}
+	if _, err := WrapCommand(rigPath, "gt-rig-Toast", "exec claude", map[string]string{"BAD NAME": "x"}); err == nil {
+		t.Error("invalid name accepted")
+	}
+}
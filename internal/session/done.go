@@ -0,0 +1,71 @@
+package session
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/steveyegge/gastown/internal/util"
+)
+
+// Done markers.
+//
+// gt done ends the polecat's own tmux session ("done means idle"). Inside an
+// isolated sandbox it cannot: the tmux socket is not mounted and the PID
+// namespace hides the pane's processes. The polecat leaves a done marker in
+// the rig's .runtime/ (mounted read-write in the sandbox) instead, and the
+// daemon ends the session from the host.
+
+// SandboxEnv is set inside an isolated polecat sandbox.
+const SandboxEnv = "GT_SANDBOXED"
+
+// DoneMarker asks the host to end a finished polecat's session.
+type DoneMarker struct {
+	Polecat   string    `json:"polecat"`
+	Session   string    `json:"session"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// doneMarkerDir holds a rig's pending done markers, one file per polecat.
+func doneMarkerDir(rigPath string) string {
+	return filepath.Join(rigPath, ".runtime", "done-sessions")
+}
+
+// WriteDoneMarker records that polecat finished and its session can go.
+func WriteDoneMarker(rigPath, polecat, sessionName string) error {
+	marker := DoneMarker{Polecat: polecat, Session: sessionName, CreatedAt: time.Now()}
+	return util.EnsureDirAndWriteJSON(filepath.Join(doneMarkerDir(rigPath), polecat+".json"), marker)
+}
+
+// DoneMarkers returns a rig's pending done markers. The session is derived
+// from the file name rather than trusted from the contents, so a marker can
+// only name a polecat session of this rig.
+func DoneMarkers(rigPath, rigName string) []DoneMarker {
+	dir := doneMarkerDir(rigPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var markers []DoneMarker
+	for _, e := range entries {
+		polecat, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		var marker DoneMarker
+		if data, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil { //nolint:gosec // G304: path is under the rig's runtime dir
+			_ = json.Unmarshal(data, &marker)
+		}
+		marker.Polecat = polecat
+		marker.Session = PolecatSessionName(PrefixFor(rigName), polecat)
+		markers = append(markers, marker)
+	}
+	return markers
+}
+
+// RemoveDoneMarker deletes polecat's done marker once it has been handled.
+func RemoveDoneMarker(rigPath, polecat string) {
+	_ = os.Remove(filepath.Join(doneMarkerDir(rigPath), polecat+".json"))
+}
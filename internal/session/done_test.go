@@ -0,0 +1,31 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoneMarkers(t *testing.T) {
+	rigPath := t.TempDir()
+	if err := WriteDoneMarker(rigPath, "Toast", "spoofed-session"); err != nil {
+		t.Fatal(err)
+	}
+
+	markers := DoneMarkers(rigPath, "gastown")
+	if len(markers) != 1 {
+		t.Fatalf("markers = %+v, want 1", markers)
+	}
+	want := PolecatSessionName(PrefixFor("gastown"), "Toast")
+	if markers[0].Polecat != "Toast" || markers[0].Session != want {
+		t.Errorf("marker = %+v, want polecat Toast with session %s from the file name", markers[0], want)
+	}
+
+	RemoveDoneMarker(rigPath, "Toast")
+	if _, err := os.Stat(filepath.Join(doneMarkerDir(rigPath), "Toast.json")); !os.IsNotExist(err) {
+		t.Errorf("marker not removed: %v", err)
+	}
+	if markers := DoneMarkers(rigPath, "gastown"); len(markers) != 0 {
+		t.Errorf("markers after remove = %+v", markers)
+	}
+}
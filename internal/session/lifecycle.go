@@ -12,6 +12,7 @@ import (
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
 	"github.com/steveyegge/gastown/internal/runtime"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/telemetry"
 	"github.com/steveyegge/gastown/internal/tmux"
 )
@@ -124,7 +125,8 @@ type StartResult struct {
 //  1. Resolve runtime config for the role
 //  2. Ensure settings/plugins exist for the agent
 //  3. Build startup command (if not provided)
-//  4. Create tmux session with command
+//  4. Create tmux session with command (sandboxed for polecats in rigs
+//     with isolation enabled)
 //  5. Set environment variables (standard + extra)
 //  6. Apply theme (if configured)
 //  7. Optional post-start: wait for agent, accept bypass, ready delay,
@@ -180,8 +182,30 @@ func StartSession(t *tmux.Tmux, cfg SessionConfig) (_ *StartResult, retErr error
 		command = config.PrependEnv(command, cfg.ExtraEnv)
 	}
 
+	// Rig secrets are only ever handed to the session as environment, and
+	// never through the command line. Explicit ExtraEnv entries win.
+	secretEnv, err := secrets.SessionEnv(cfg.RigPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: rig secrets not loaded for %s: %v\n", cfg.SessionID, err)
+	}
+	for k := range cfg.ExtraEnv {
+		delete(secretEnv, k)
+	}
+	if command, err = secrets.WrapCommand(cfg.RigPath, cfg.SessionID, command, secretEnv); err != nil {
+		return nil, fmt.Errorf("staging rig secrets: %w", err)
+	}
+
+	// Run polecats inside the rig's sandbox when isolation is enabled.
+	if cfg.Role == "polecat" {
+		command, err = IsolateCommand(cfg.TownRoot, cfg.RigPath, cfg.WorkDir, cfg.RuntimeConfigDir, command)
+		if err != nil {
+			return nil, fmt.Errorf("isolating session: %w", err)
+		}
+	}
+
 	// 4. Create tmux session with command.
 	if err := t.NewSessionWithCommand(cfg.SessionID, cfg.WorkDir, command); err != nil {
+		secrets.RemoveEnvFile(cfg.RigPath, cfg.SessionID)
 		return nil, fmt.Errorf("creating session: %w", err)
 	}
 
@@ -206,6 +230,11 @@ func StartSession(t *tmux.Tmux, cfg SessionConfig) (_ *StartResult, retErr error
 	for _, k := range mapKeysSorted(cfg.ExtraEnv) {
 		_ = t.SetEnvironment(cfg.SessionID, k, cfg.ExtraEnv[k])
 	}
+	// Keep secrets in the session environment so an auto-respawned pane,
+	// which finds the env file already deleted, still inherits them.
+	for _, k := range mapKeysSorted(secretEnv) {
+		_ = t.SetEnvironment(cfg.SessionID, k, secretEnv[k])
+	}
 
 	// 7. Apply theme.
 	if cfg.Theme != nil {
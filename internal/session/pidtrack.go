@@ -75,6 +75,44 @@ func UntrackPID(townRoot, sessionID string) {
 	_ = os.Remove(pidFile(townRoot, sessionID))
 }
 
+// TrackedIncarnation identifies the session's current run by its tracked
+// PID record ("pid|start time"). Returns "" when no PID is tracked.
+func TrackedIncarnation(townRoot, sessionID string) string {
+	data, err := os.ReadFile(pidFile(townRoot, sessionID))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ReadTrackedPID returns the tracked PID for a session and whether that
+// process is still alive. Returns pid 0 when the session has no PID file.
+// A PID whose recorded start time no longer matches is reported as dead,
+// since the original process exited and the PID was reused.
+func ReadTrackedPID(townRoot, sessionID string) (pid int, alive bool, err error) {
+	data, err := os.ReadFile(pidFile(townRoot, sessionID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	record, err := parseTrackedPID(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, false, err
+	}
+	proc, err := os.FindProcess(record.PID)
+	if err != nil || proc.Signal(syscall.Signal(0)) != nil {
+		return record.PID, false, nil
+	}
+	if record.StartTime != "" {
+		if current, startErr := pidStartTimeFunc(record.PID); startErr == nil && current != record.StartTime {
+			return record.PID, false, nil
+		}
+	}
+	return record.PID, true, nil
+}
+
 // KillTrackedPIDs reads all PID files and kills any processes that are
 // still running. Returns the number of processes killed and any session
 // names that had errors.
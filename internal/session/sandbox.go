@@ -0,0 +1,397 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+
+	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// sandboxSystemPaths are mounted read-only into every sandbox so the agent,
+// shells and toolchains installed system-wide keep working.
+var sandboxSystemPaths = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64",
+	"/etc", "/opt", "/nix", "/run/systemd/resolve",
+}
+
+// agentHome lists what an agent may write inside its $HOME directory:
+// credentials and session history, never settings, hooks or plugins.
+type agentHome struct {
+	Dirs  []string
+	Files []string
+}
+
+// sandboxAgentHome are the $HOME directories agents keep their settings
+// and state in. They are mounted read-only, since hooks, MCP servers and
+// settings planted there would run unsandboxed in every other session;
+// only the listed entries stay writable.
+var sandboxAgentHome = map[string]agentHome{
+	".claude": {
+		Dirs: []string{"projects", "todos", "shell-snapshots", "statsig",
+			"session-env", "file-history", "plans", "ide", "debug"},
+		Files: []string{".credentials.json"},
+	},
+	".codex":           {Dirs: []string{"sessions", "log"}, Files: []string{"auth.json", "history.jsonl"}},
+	".gemini":          {Dirs: []string{"tmp", "history"}, Files: []string{"oauth_creds.json"}},
+	".config/opencode": {},
+}
+
+// sandboxSessionHome are $HOME files the agent rewrites while it runs.
+// Each sandboxed session gets its own copy, seeded from the host file when
+// the session starts, so its changes never reach other sessions.
+var sandboxSessionHome = []string{".claude.json"}
+
+// sandboxHomeReadOnly are $HOME entries git needs to commit and push.
+var sandboxHomeReadOnly = []string{
+	".gitconfig", ".config/git", ".ssh",
+}
+
+// SandboxSpec describes what a sandboxed session can see. Paths are mounted
+// at the same location inside the sandbox; missing paths are skipped.
+type SandboxSpec struct {
+	// WorkDir is the session's working directory.
+	WorkDir string
+
+	// ReadWrite and ReadOnly are the paths mounted into the sandbox.
+	ReadWrite []string
+	ReadOnly  []string
+
+	// Copies maps paths inside the sandbox to the session's own copy on
+	// the host, mounted read-write (see SeedSandboxCopies).
+	Copies map[string]string
+
+	// DenyNetwork unshares the network namespace.
+	DenyNetwork bool
+
+	// Bwrap is the bubblewrap binary.
+	Bwrap string
+}
+
+// NewSandboxSpec builds the sandbox for a polecat working in workDir: its
+// worktree, its own git metadata and the repository's objects and refs,
+// the beads databases, the town's runtime and cache directories and the
+// agent's credentials and session history read-write; system directories,
+// PATH, git hooks and config, agent settings and the town and rig
+// configuration read-only; plus the paths listed in iso. The tmux socket
+// is not mounted: a session that can reach the tmux server can run
+// commands in any other pane. The session therefore cannot end itself;
+// gt done leaves a done marker for the daemon (see done.go).
+func NewSandboxSpec(townRoot, rigPath, workDir, runtimeConfigDir string, iso *config.IsolationConfig) SandboxSpec {
+	home, _ := os.UserHomeDir()
+	spec := SandboxSpec{
+		WorkDir:     workDir,
+		DenyNetwork: iso.DenyNetwork,
+		Bwrap:       iso.Bwrap,
+	}
+	if spec.Bwrap == "" {
+		spec.Bwrap = "bwrap"
+	}
+
+	rw := []string{
+		workDir,
+		beads.ResolveBeadsDir(workDir),
+		filepath.Join(townRoot, ".beads"),
+		filepath.Join(townRoot, ".runtime"),
+		filepath.Join(townRoot, ".cache"),
+		filepath.Join(rigPath, ".runtime"),
+		runtimeConfigDir,
+	}
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		rw = append(rw, filepath.Dir(sock))
+	}
+	ro := append([]string{}, sandboxSystemPaths...)
+	ro = append(ro,
+		filepath.Join(townRoot, "mayor", "town.json"),
+		filepath.Join(townRoot, "mayor", "rigs.json"),
+		filepath.Join(townRoot, "settings"),
+		filepath.Join(rigPath, "config.json"),
+		filepath.Join(rigPath, "settings"),
+	)
+	ro = append(ro, sharedSettingsEntries(config.RoleSettingsDir("polecat", rigPath))...)
+	if exe, err := os.Executable(); err == nil {
+		ro = append(ro, filepath.Dir(exe))
+	}
+	ro = append(ro, filepath.SplitList(os.Getenv("PATH"))...)
+
+	gitRW, gitRO := gitMounts(workDir)
+	rw = append(rw, gitRW...)
+	ro = append(ro, gitRO...)
+
+	if home != "" {
+		for dir, state := range sandboxAgentHome {
+			ro = append(ro, filepath.Join(home, dir))
+			for _, p := range append(state.Dirs, state.Files...) {
+				rw = append(rw, filepath.Join(home, dir, p))
+			}
+		}
+		for _, p := range sandboxHomeReadOnly {
+			ro = append(ro, filepath.Join(home, p))
+		}
+		stateDir := sandboxStateDir(rigPath, workDir)
+		spec.Copies = make(map[string]string, len(sandboxSessionHome))
+		for _, p := range sandboxSessionHome {
+			spec.Copies[filepath.Join(home, p)] = filepath.Join(stateDir, filepath.Base(p))
+		}
+	}
+	for _, p := range iso.AllowPaths {
+		rw = append(rw, expandHome(p, home))
+	}
+	for _, p := range iso.ReadOnlyPaths {
+		ro = append(ro, expandHome(p, home))
+	}
+
+	spec.ReadWrite = rw
+	spec.ReadOnly = ro
+	return spec
+}
+
+// SandboxArgs returns the bubblewrap arguments for spec, up to and
+// including the "--" separator. Mounts are ordered by path so a parent is
+// mounted before anything beneath it; a path listed both read-write and
+// read-only is mounted read-write.
+func SandboxArgs(spec SandboxSpec) []string {
+	args := []string{
+		"--die-with-parent",
+		"--unshare-pid", "--unshare-ipc", "--unshare-uts",
+	}
+	if spec.DenyNetwork {
+		args = append(args, "--unshare-net")
+	}
+	args = append(args, "--proc", "/proc", "--dev", "/dev", "--tmpfs", "/tmp")
+	// gt done cannot reach tmux from here and leaves a done marker instead.
+	args = append(args, "--setenv", SandboxEnv, "1")
+
+	type mount struct {
+		src      string
+		writable bool
+	}
+	mounts := make(map[string]mount) // sandbox path -> host path
+	for _, p := range spec.ReadOnly {
+		if p = cleanMountPath(p); p != "" {
+			mounts[p] = mount{src: p}
+		}
+	}
+	for _, p := range spec.ReadWrite {
+		if p = cleanMountPath(p); p != "" {
+			mounts[p] = mount{src: p, writable: true}
+		}
+	}
+	for dst, src := range spec.Copies {
+		if dst, src = cleanMountPath(dst), cleanMountPath(src); dst != "" && src != "" {
+			mounts[dst] = mount{src: src, writable: true}
+		}
+	}
+	paths := make([]string, 0, len(mounts))
+	for p := range mounts {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if m := mounts[p]; m.writable {
+			args = append(args, "--bind-try", m.src, p)
+		} else {
+			args = append(args, "--ro-bind-try", m.src, p)
+		}
+	}
+
+	if spec.WorkDir != "" {
+		args = append(args, "--chdir", spec.WorkDir)
+	}
+	return append(args, "--")
+}
+
+// WrapSandbox returns command wrapped to run inside the sandbox. It fails
+// when the sandbox cannot be created, so an isolated session never starts
+// unisolated.
+func WrapSandbox(command string, spec SandboxSpec) (string, error) {
+	if goruntime.GOOS != "linux" {
+		return "", fmt.Errorf("polecat isolation requires Linux namespaces (running on %s)", goruntime.GOOS)
+	}
+	bwrap, err := exec.LookPath(spec.Bwrap)
+	if err != nil {
+		return "", fmt.Errorf("polecat isolation requires bubblewrap: %w", err)
+	}
+
+	parts := []string{"exec", config.ShellQuote(bwrap)}
+	for _, a := range SandboxArgs(spec) {
+		parts = append(parts, config.ShellQuote(a))
+	}
+	parts = append(parts, "/bin/sh", "-c", config.ShellQuote(command))
+	return strings.Join(parts, " "), nil
+}
+
+// IsolateCommand wraps a polecat startup command in the sandbox configured
+// by the rig's isolation settings. The command is returned unchanged when
+// isolation is not enabled for the rig.
+func IsolateCommand(townRoot, rigPath, workDir, runtimeConfigDir, command string) (string, error) {
+	if rigPath == "" {
+		return command, nil
+	}
+	settings, err := config.LoadRigSettings(config.RigSettingsPath(rigPath))
+	if err != nil || settings.Isolation == nil || !settings.Isolation.Enabled {
+		return command, nil
+	}
+	spec := NewSandboxSpec(townRoot, rigPath, workDir, runtimeConfigDir, settings.Isolation)
+	if err := SeedSandboxCopies(spec); err != nil {
+		return "", fmt.Errorf("preparing sandbox: %w", err)
+	}
+	return WrapSandbox(command, spec)
+}
+
+// SeedSandboxCopies refreshes the session's own copies of spec.Copies from
+// the host files, and creates the writable agent state directories so they
+// can be mounted.
+func SeedSandboxCopies(spec SandboxSpec) error {
+	for dst, src := range spec.Copies {
+		if err := os.MkdirAll(filepath.Dir(src), 0700); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(dst)
+		if os.IsNotExist(err) {
+			// Nothing to copy; the agent creates the file inside the sandbox.
+			if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(src, data, 0600); err != nil {
+			return err
+		}
+	}
+	home, _ := os.UserHomeDir()
+	if home == "" {
+		return nil
+	}
+	for dir, state := range sandboxAgentHome {
+		if _, err := os.Stat(filepath.Join(home, dir)); err != nil {
+			continue // agent not installed
+		}
+		for _, p := range state.Dirs {
+			if err := os.MkdirAll(filepath.Join(home, dir, p), 0700); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// sandboxStateDir is where a sandboxed session keeps its own copies of
+// $HOME files, keyed by the worktree it runs in.
+func sandboxStateDir(rigPath, workDir string) string {
+	key := filepath.Base(workDir)
+	if rel, err := filepath.Rel(rigPath, workDir); err == nil && !strings.HasPrefix(rel, "..") {
+		key = strings.ReplaceAll(rel, string(filepath.Separator), "-")
+	}
+	return filepath.Join(rigPath, ".runtime", "sandbox", key)
+}
+
+// gitMounts returns the git paths a session in workDir needs to commit and
+// push. A worktree gets its own gitdir plus the shared repository's objects,
+// refs and reflogs read-write; the rest of the shared repository, including
+// hooks/, config and sibling worktrees' gitdirs, is read-only, since git
+// runs those hooks and config outside the sandbox too. A regular checkout
+// is writable through workDir, except for its hooks and config.
+func gitMounts(workDir string) (rw, ro []string) {
+	dotGit := filepath.Join(workDir, ".git")
+	if info, err := os.Stat(dotGit); err == nil && info.IsDir() {
+		return nil, []string{filepath.Join(dotGit, "hooks"), filepath.Join(dotGit, "config")}
+	}
+	gitDir := worktreeGitDir(workDir)
+	if gitDir == "" {
+		return nil, nil
+	}
+	common := gitCommonDir(workDir)
+	rw = []string{
+		gitDir,
+		filepath.Join(common, "objects"),
+		filepath.Join(common, "refs"),
+		filepath.Join(common, "logs"),
+	}
+	ro = []string{common, filepath.Join(common, "hooks"), filepath.Join(common, "config")}
+	return rw, ro
+}
+
+// worktreeGitDir returns the gitdir a git worktree's .git file points at
+// (e.g. .repo.git/worktrees/<name>), or "" when workDir is not a worktree.
+func worktreeGitDir(workDir string) string {
+	data, err := os.ReadFile(filepath.Join(workDir, ".git"))
+	if err != nil {
+		return ""
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return ""
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(workDir, gitDir)
+	}
+	return filepath.Clean(gitDir)
+}
+
+// gitCommonDir returns the repository directory shared by a git worktree,
+// where commits are written. Returns "" when workDir is a regular checkout
+// (its .git directory is already inside workDir) or not a git worktree.
+func gitCommonDir(workDir string) string {
+	gitDir := worktreeGitDir(workDir)
+	if gitDir == "" {
+		return ""
+	}
+	common, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return gitDir
+	}
+	dir := strings.TrimSpace(string(common))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(gitDir, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// sharedSettingsEntries returns the agent settings shared by all polecats
+// (files and dot-directories such as .claude/ in polecats/), leaving out
+// the sibling worktrees and the warm pool that live alongside them.
+func sharedSettingsEntries(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() && (!strings.HasPrefix(name, ".") || name == ".warm") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths
+}
+
+func expandHome(p, home string) string {
+	if home != "" && (p == "~" || strings.HasPrefix(p, "~/")) {
+		return filepath.Join(home, strings.TrimPrefix(p, "~"))
+	}
+	return p
+}
+
+// cleanMountPath returns p cleaned, or "" if it cannot be mounted: relative
+// paths and the root directory (which would expose the whole filesystem).
+func cleanMountPath(p string) string {
+	if p == "" || !filepath.IsAbs(p) {
+		return ""
+	}
+	p = filepath.Clean(p)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
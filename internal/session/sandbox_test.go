@@ -0,0 +1,202 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steveyegge/gastown/internal/config"
+)
+
+// mountOf returns the bwrap mount flag used for path, or "".
+func mountOf(args []string, path string) string {
+	for i := 0; i+2 < len(args); i++ {
+		if (args[i] == "--bind-try" || args[i] == "--ro-bind-try") && args[i+1] == path {
+			return args[i]
+		}
+	}
+	return ""
+}
+
+func TestSandboxArgs(t *testing.T) {
+	args := SandboxArgs(SandboxSpec{
+		WorkDir:   "/town/rig/polecats/Toast/rig",
+		ReadWrite: []string{"/town/rig/polecats/Toast/rig", "/town/rig/.repo.git", "/usr/local", "relative", "/"},
+		ReadOnly:  []string{"/usr", "/usr/local", "/town/settings"},
+	})
+
+	if args[len(args)-1] != "--" {
+		t.Errorf("args must end with --: %v", args)
+	}
+	if slices.Contains(args, "--unshare-net") {
+		t.Error("network must be shared unless DenyNetwork is set")
+	}
+	if got := mountOf(args, "/usr"); got != "--ro-bind-try" {
+		t.Errorf("/usr mounted with %q", got)
+	}
+	if got := mountOf(args, "/usr/local"); got != "--bind-try" {
+		t.Errorf("path listed read-write and read-only mounted with %q", got)
+	}
+	if got := mountOf(args, "/town/rig/.repo.git"); got != "--bind-try" {
+		t.Errorf("git repo mounted with %q", got)
+	}
+	if mountOf(args, "relative") != "" || mountOf(args, "/") != "" {
+		t.Errorf("relative paths and / must not be mounted: %v", args)
+	}
+
+	// Parents are mounted before their children.
+	joined := strings.Join(args, " ")
+	if strings.Index(joined, " /usr /usr") > strings.Index(joined, " /usr/local /usr/local") {
+		t.Errorf("/usr must be mounted before /usr/local: %v", args)
+	}
+	if i := slices.Index(args, "--chdir"); i < 0 || args[i+1] != "/town/rig/polecats/Toast/rig" {
+		t.Errorf("missing --chdir to work dir: %v", args)
+	}
+
+	if i := slices.Index(args, "--setenv"); i < 0 || args[i+1] != SandboxEnv {
+		t.Errorf("missing --setenv %s: %v", SandboxEnv, args)
+	}
+
+	args = SandboxArgs(SandboxSpec{Copies: map[string]string{"/home/u/.claude.json": "/town/rig/.runtime/sandbox/Toast/.claude.json"}})
+	if !strings.Contains(strings.Join(args, " "), "--bind-try /town/rig/.runtime/sandbox/Toast/.claude.json /home/u/.claude.json") {
+		t.Errorf("session copy not bound over the home file: %v", args)
+	}
+
+	args = SandboxArgs(SandboxSpec{DenyNetwork: true})
+	if !slices.Contains(args, "--unshare-net") {
+		t.Errorf("DenyNetwork must unshare the network: %v", args)
+	}
+}
+
+func TestGitCommonDir(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, ".repo.git")
+	admin := filepath.Join(repo, "worktrees", "Toast")
+	work := filepath.Join(root, "polecats", "Toast", "rig")
+	for _, d := range []string{admin, work} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(admin, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(work, ".git"), []byte("gitdir: "+admin+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := gitCommonDir(work); got != repo {
+		t.Errorf("gitCommonDir() = %q, want %q", got, repo)
+	}
+
+	// Only the worktree's own gitdir and the object/ref stores are
+	// writable; hooks and config stay read-only.
+	rw, ro := gitMounts(work)
+	for _, p := range []string{admin, filepath.Join(repo, "objects"), filepath.Join(repo, "refs")} {
+		if !slices.Contains(rw, p) {
+			t.Errorf("%s not writable: %v", p, rw)
+		}
+	}
+	if slices.Contains(rw, repo) {
+		t.Errorf("shared repository mounted read-write: %v", rw)
+	}
+	for _, p := range []string{repo, filepath.Join(repo, "hooks"), filepath.Join(repo, "config")} {
+		if !slices.Contains(ro, p) {
+			t.Errorf("%s not read-only: %v", p, ro)
+		}
+	}
+
+	// A regular checkout needs nothing beyond the work dir itself.
+	checkout := filepath.Join(root, "checkout")
+	if err := os.MkdirAll(filepath.Join(checkout, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got := gitCommonDir(checkout); got != "" {
+		t.Errorf("gitCommonDir(checkout) = %q, want empty", got)
+	}
+	if rw, ro := gitMounts(checkout); len(rw) != 0 || !slices.Contains(ro, filepath.Join(checkout, ".git", "hooks")) {
+		t.Errorf("gitMounts(checkout) = %v, %v", rw, ro)
+	}
+}
+
+func TestNewSandboxSpec(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	townRoot := t.TempDir()
+	rigPath := filepath.Join(townRoot, "gastown")
+	workDir := filepath.Join(rigPath, "polecats", "Toast", "gastown")
+
+	polecatsDir := filepath.Join(rigPath, "polecats")
+	for _, d := range []string{".claude", ".warm", "Shadow"} {
+		if err := os.MkdirAll(filepath.Join(polecatsDir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spec := NewSandboxSpec(townRoot, rigPath, workDir, "", &config.IsolationConfig{
+		Enabled:       true,
+		AllowPaths:    []string{"~/scratch"},
+		ReadOnlyPaths: []string{"~/.nvm"},
+		DenyNetwork:   true,
+	})
+
+	if spec.Bwrap != "bwrap" || !spec.DenyNetwork {
+		t.Errorf("spec = %+v", spec)
+	}
+	if !slices.Contains(spec.ReadWrite, workDir) {
+		t.Errorf("work dir not writable: %v", spec.ReadWrite)
+	}
+	if !slices.Contains(spec.ReadWrite, filepath.Join(home, "scratch")) {
+		t.Errorf("allow_paths not expanded: %v", spec.ReadWrite)
+	}
+	if !slices.Contains(spec.ReadOnly, filepath.Join(home, ".nvm")) {
+		t.Errorf("read_only_paths not expanded: %v", spec.ReadOnly)
+	}
+	if !slices.Contains(spec.ReadOnly, filepath.Join(polecatsDir, ".claude")) {
+		t.Errorf("shared polecat settings not mounted: %v", spec.ReadOnly)
+	}
+	claudeDir := filepath.Join(home, ".claude")
+	if !slices.Contains(spec.ReadOnly, claudeDir) || slices.Contains(spec.ReadWrite, claudeDir) {
+		t.Errorf("~/.claude must be read-only: rw=%v", spec.ReadWrite)
+	}
+	if !slices.Contains(spec.ReadWrite, filepath.Join(claudeDir, "projects")) {
+		t.Errorf("session history not writable: %v", spec.ReadWrite)
+	}
+	claudeJSON := filepath.Join(home, ".claude.json")
+	copyPath := spec.Copies[claudeJSON]
+	if copyPath == "" || slices.Contains(spec.ReadWrite, claudeJSON) {
+		t.Errorf("~/.claude.json must be a per-session copy: copies=%v", spec.Copies)
+	}
+	for _, p := range spec.ReadWrite {
+		if strings.Contains(p, "tmux-") {
+			t.Errorf("tmux socket dir must not be mounted: %s", p)
+		}
+	}
+
+	if err := os.WriteFile(claudeJSON, []byte(`{"host":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := SeedSandboxCopies(spec); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(copyPath); err != nil || string(data) != `{"host":true}` {
+		t.Errorf("session copy = %q, %v", data, err)
+	}
+
+	for _, p := range append(spec.ReadWrite, spec.ReadOnly...) {
+		if p == home || p == rigPath || p == polecatsDir ||
+			p == filepath.Join(polecatsDir, "Shadow") || p == filepath.Join(polecatsDir, ".warm") {
+			t.Errorf("sandbox must not expose %s", p)
+		}
+	}
+}
+
+func TestIsolateCommand_Disabled(t *testing.T) {
+	rigPath := t.TempDir()
+	got, err := IsolateCommand(t.TempDir(), rigPath, rigPath, "", "exec claude")
+	if err != nil || got != "exec claude" {
+		t.Errorf("IsolateCommand() = %q, %v; want command unchanged", got, err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 	"unicode/utf8"
 
 	"go.opentelemetry.io/otel"
@@ -16,6 +17,8 @@ import (
 	otellog "go.opentelemetry.io/otel/log"
 	"go.opentelemetry.io/otel/log/global"
 	"go.opentelemetry.io/otel/metric"
+
+	"github.com/steveyegge/gastown/internal/secrets"
 )
 
 const (
@@ -43,9 +46,13 @@ type recorderInstruments struct {
 	daemonRestartTotal metric.Int64Counter
 	formulaTotal       metric.Int64Counter
 	convoyTotal        metric.Int64Counter
+	mqPhaseTotal       metric.Int64Counter
+	mqReworkTotal      metric.Int64Counter
+	mqSLABreachTotal   metric.Int64Counter
 
 	// Histograms
-	bdDurationHist metric.Float64Histogram
+	bdDurationHist      metric.Float64Histogram
+	mqPhaseDurationHist metric.Float64Histogram
 }
 
 var (
@@ -112,12 +119,25 @@ func initInstruments() {
 		inst.convoyTotal, _ = m.Int64Counter("gastown.convoy.creates.total",
 			metric.WithDescription("Total auto-convoy creations"),
 		)
+		inst.mqPhaseTotal, _ = m.Int64Counter("gastown.mq.phase_transitions.total",
+			metric.WithDescription("Total merge request phase transitions"),
+		)
+		inst.mqReworkTotal, _ = m.Int64Counter("gastown.mq.rework_loops.total",
+			metric.WithDescription("Total merge requests returned to the queue after failing or being bounced"),
+		)
+		inst.mqSLABreachTotal, _ = m.Int64Counter("gastown.mq.sla_breaches.total",
+			metric.WithDescription("Total merge requests escalated for exceeding a phase SLA"),
+		)
 
 		// Histograms
 		inst.bdDurationHist, _ = m.Float64Histogram("gastown.bd.duration_ms",
 			metric.WithDescription("bd CLI call round-trip latency in milliseconds"),
 			metric.WithUnit("ms"),
 		)
+		inst.mqPhaseDurationHist, _ = m.Float64Histogram("gastown.mq.phase.duration_s",
+			metric.WithDescription("Time a merge request spent in a phase before leaving it"),
+			metric.WithUnit("s"),
+		)
 	})
 }
 
@@ -130,11 +150,17 @@ func statusStr(err error) string {
 }
 
 // emit sends an OTel log event with the given body and key-value attributes.
+// Rig secret values are redacted from the body and string attributes.
 func emit(ctx context.Context, body string, sev otellog.Severity, attrs ...otellog.KeyValue) {
 	logger := global.GetLoggerProvider().Logger(loggerName)
 	var r otellog.Record
-	r.SetBody(otellog.StringValue(body))
+	r.SetBody(otellog.StringValue(secrets.Redact(body)))
 	r.SetSeverity(sev)
+	for i, kv := range attrs {
+		if kv.Value.Kind() == otellog.KindString {
+			attrs[i] = otellog.String(kv.Key, secrets.Redact(kv.Value.AsString()))
+		}
+	}
 	r.AddAttributes(attrs...)
 	logger.Emit(ctx, r)
 }
@@ -194,11 +220,13 @@ func RecordBDCall(ctx context.Context, args []string, durationMs float64, err er
 	attrs := metric.WithAttributes(
 		attribute.String("status", status),
 		attribute.String("subcommand", subcommand),
+		attribute.String("mode", "subprocess"),
 	)
 	inst.bdTotal.Add(ctx, 1, attrs)
 	inst.bdDurationHist.Record(ctx, durationMs, attrs)
 	kvs := []otellog.KeyValue{
 		otellog.String("subcommand", subcommand),
+		otellog.String("mode", "subprocess"),
 		otellog.String("args", strings.Join(args, " ")),
 		otellog.Float64("duration_ms", durationMs),
 		otellog.String("status", status),
@@ -214,6 +242,29 @@ func RecordBDCall(ctx context.Context, args []string, durationMs float64, err er
 	emit(ctx, "bd.call", severity(err), kvs...)
 }
 
+// RecordBDStoreCall records a beads operation served in-process by the beads
+// storage library instead of a bd subprocess. It shares the bd call metrics
+// with RecordBDCall, distinguished by mode="inprocess", so the number of
+// forked bd processes stays measurable as mode="subprocess".
+func RecordBDStoreCall(ctx context.Context, op string, durationMs float64, err error) {
+	initInstruments()
+	status := statusStr(err)
+	attrs := metric.WithAttributes(
+		attribute.String("status", status),
+		attribute.String("subcommand", op),
+		attribute.String("mode", "inprocess"),
+	)
+	inst.bdTotal.Add(ctx, 1, attrs)
+	inst.bdDurationHist.Record(ctx, durationMs, attrs)
+	emit(ctx, "bd.call", severity(err),
+		otellog.String("subcommand", op),
+		otellog.String("mode", "inprocess"),
+		otellog.Float64("duration_ms", durationMs),
+		otellog.String("status", status),
+		errKV(err),
+	)
+}
+
 // RecordSessionStart records an agent session start (metrics + log event).
 func RecordSessionStart(ctx context.Context, sessionID, role string, err error) {
 	initInstruments()
@@ -470,6 +521,58 @@ func RecordConvoyCreate(ctx context.Context, beadID string, err error) {
 	)
 }
 
+// RecordMQPhase records a merge request phase transition (metrics + log event).
+// from is the phase being left, empty for an MR's first recorded phase, and
+// inPhase is how long the MR spent in it. rework marks a return to the queue
+// after a failure or bounce.
+func RecordMQPhase(ctx context.Context, rig, mrID, from, to string, inPhase time.Duration, rework bool) {
+	initInstruments()
+	inst.mqPhaseTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("rig", rig),
+			attribute.String("phase", to),
+		),
+	)
+	if from != "" {
+		inst.mqPhaseDurationHist.Record(ctx, inPhase.Seconds(),
+			metric.WithAttributes(
+				attribute.String("rig", rig),
+				attribute.String("phase", from),
+			),
+		)
+	}
+	if rework {
+		inst.mqReworkTotal.Add(ctx, 1, metric.WithAttributes(attribute.String("rig", rig)))
+	}
+	emit(ctx, "mq.phase", otellog.SeverityInfo,
+		otellog.String("rig", rig),
+		otellog.String("mr", mrID),
+		otellog.String("from", from),
+		otellog.String("to", to),
+		otellog.Float64("duration_s", inPhase.Seconds()),
+		otellog.Bool("rework", rework),
+	)
+}
+
+// RecordMQSLABreach records a merge request escalated for staying in a phase
+// longer than its SLA (metrics + log event).
+func RecordMQSLABreach(ctx context.Context, rig, mrID, phase string, age, limit time.Duration) {
+	initInstruments()
+	inst.mqSLABreachTotal.Add(ctx, 1,
+		metric.WithAttributes(
+			attribute.String("rig", rig),
+			attribute.String("phase", phase),
+		),
+	)
+	emit(ctx, "mq.sla_breach", otellog.SeverityWarn,
+		otellog.String("rig", rig),
+		otellog.String("mr", mrID),
+		otellog.String("phase", phase),
+		otellog.Float64("age_s", age.Seconds()),
+		otellog.Float64("limit_s", limit.Seconds()),
+	)
+}
+
 const maxPaneOutputLog = 8192
 
 // RecordPaneOutput emits a chunk of raw pane output (ANSI already stripped) to VictoriaLogs.
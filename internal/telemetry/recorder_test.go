@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	otellog "go.opentelemetry.io/otel/log"
 )
@@ -90,6 +91,14 @@ func TestRecordBDCall(t *testing.T) {
 	RecordBDCall(ctx, nil, 0, nil, nil, "")
 }
 
+func TestRecordBDStoreCall(t *testing.T) {
+	resetInstruments(t)
+	ctx := context.Background()
+
+	RecordBDStoreCall(ctx, "show", 0.4, nil)
+	RecordBDStoreCall(ctx, "list", 1.2, errors.New("fail"))
+}
+
 func TestRecordBDCall_TruncatesLongOutput(t *testing.T) {
 	resetInstruments(t)
 	ctx := context.Background()
@@ -225,3 +234,19 @@ func TestRecordConvoyCreate(t *testing.T) {
 	RecordConvoyCreate(ctx, "bead-abc", nil)
 	RecordConvoyCreate(ctx, "bead-def", errors.New("convoy error"))
 }
+
+func TestRecordMQPhase(t *testing.T) {
+	resetInstruments(t)
+	ctx := context.Background()
+
+	RecordMQPhase(ctx, "gastown", "gt-mr-1", "", "ready", 0, false)
+	RecordMQPhase(ctx, "gastown", "gt-mr-1", "ready", "claimed", 5*time.Minute, false)
+	RecordMQPhase(ctx, "gastown", "gt-mr-1", "failed", "ready", time.Second, true)
+}
+
+func TestRecordMQSLABreach(t *testing.T) {
+	resetInstruments(t)
+	ctx := context.Background()
+
+	RecordMQSLABreach(ctx, "gastown", "gt-mr-1", "ready", 5*time.Hour, 4*time.Hour)
+}
@@ -18,6 +18,7 @@ import (
 
 	"github.com/steveyegge/gastown/internal/config"
 	"github.com/steveyegge/gastown/internal/constants"
+	"github.com/steveyegge/gastown/internal/secrets"
 	"github.com/steveyegge/gastown/internal/telemetry"
 )
 
@@ -1220,6 +1221,44 @@ func (t *Tmux) GetPanePID(target string) (string, error) {
 	return result, nil
 }
 
+// ServerStartTime returns when the tmux server was started. A start time
+// newer than existing sessions' expected lifetime means the server restarted
+// and every session it held was lost.
+func (t *Tmux) ServerStartTime() (time.Time, error) {
+	out, err := t.run("list-sessions", "-F", "#{start_time}")
+	if err != nil {
+		return time.Time{}, err
+	}
+	first := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+	secs, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parsing tmux start_time %q: %w", first, err)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// GetPaneDeadStatus reports whether a session's first pane has exited and,
+// if so, the exit status of its process. Only meaningful for sessions with
+// remain-on-exit enabled; otherwise the pane disappears when the process exits.
+// status is -1 when the pane is alive or tmux did not record a status.
+func (t *Tmux) GetPaneDeadStatus(session string) (dead bool, status int, err error) {
+	out, err := t.run("display-message", "-t", session+":0.0", "-p", "#{pane_dead} #{pane_dead_status}")
+	if err != nil {
+		return false, -1, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 || fields[0] != "1" {
+		return false, -1, nil
+	}
+	status = -1
+	if len(fields) > 1 {
+		if n, convErr := strconv.Atoi(fields[1]); convErr == nil {
+			status = n
+		}
+	}
+	return true, status, nil
+}
+
 // GetSessionActivity returns the last activity time for a session.
 // This is updated whenever there's any activity in the session (input/output).
 func (t *Tmux) GetSessionActivity(session string) (time.Time, error) {
@@ -1416,15 +1455,18 @@ func (t *Tmux) FindSessionByWorkDir(targetDir string, processNames []string) ([]
 }
 
 // CapturePane captures the visible content of a pane.
+// Rig secret values are redacted from the result.
 func (t *Tmux) CapturePane(session string, lines int) (string, error) {
 	content, err := t.run("capture-pane", "-p", "-t", session, "-S", fmt.Sprintf("-%d", lines))
 	telemetry.RecordPaneRead(context.Background(), session, lines, len(content), err)
-	return content, err
+	return secrets.Redact(content), err
 }
 
 // CapturePaneAll captures all scrollback history.
+// Rig secret values are redacted from the result.
 func (t *Tmux) CapturePaneAll(session string) (string, error) {
-	return t.run("capture-pane", "-p", "-t", session, "-S", "-")
+	content, err := t.run("capture-pane", "-p", "-t", session, "-S", "-")
+	return secrets.Redact(content), err
 }
 
 // CapturePaneLines captures the last N lines of a pane as a slice.
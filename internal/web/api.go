@@ -6,16 +6,21 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/steveyegge/gastown/internal/beads"
+	"github.com/steveyegge/gastown/internal/mail"
 	"github.com/steveyegge/gastown/internal/session"
+	"github.com/steveyegge/gastown/internal/workspace"
 )
 
 // CommandRequest is the JSON request body for /api/run.
@@ -104,6 +109,8 @@ func (h *APIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.handleMailThreads(w, r)
 	case path == "/mail/read" && r.Method == http.MethodGet:
 		h.handleMailRead(w, r)
+	case path == "/mail/attachment" && r.Method == http.MethodGet:
+		h.handleMailAttachment(w, r)
 	case path == "/mail/send" && r.Method == http.MethodPost:
 		h.handleMailSend(w, r)
 	case path == "/issues/show" && r.Method == http.MethodGet:
@@ -270,6 +277,9 @@ type MailMessage struct {
 	Priority  string `json:"priority,omitempty"`
 	ThreadID  string `json:"thread_id,omitempty"`
 	ReplyTo   string `json:"reply_to,omitempty"`
+	// Typed payload and attachment references, when the message has them.
+	Payload     *mail.Payload     `json:"payload,omitempty"`
+	Attachments []mail.Attachment `json:"attachments,omitempty"`
 }
 
 // MailInboxResponse is the response for /api/mail/inbox.
@@ -483,19 +493,63 @@ func (h *APIHandler) handleMailRead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "read", msgID})
+	output, err := h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "read", msgID, "--json"})
 	if err != nil {
 		h.sendError(w, "Failed to read message: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// Parse the message output
-	msg := parseMailReadOutput(output, msgID)
+	// Prefer the JSON form (which carries payloads and attachments); fall
+	// back to parsing the text output of older gt binaries.
+	msg, ok := parseMailReadJSON(output)
+	if !ok {
+		output, err = h.runGtCommand(r.Context(), 10*time.Second, []string{"mail", "read", msgID})
+		if err != nil {
+			h.sendError(w, "Failed to read message: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		msg = parseMailReadOutput(output, msgID)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(msg)
 }
 
+// handleMailAttachment serves a mail attachment from the town blob store.
+// Blobs are content-addressed, so the digest alone identifies the content;
+// name only sets the download file name.
+func (h *APIHandler) handleMailAttachment(w http.ResponseWriter, r *http.Request) {
+	att := mail.Attachment{
+		Name:   r.URL.Query().Get("name"),
+		Digest: r.URL.Query().Get("digest"),
+	}
+	if att.Name == "" {
+		att.Name = "attachment"
+	}
+	if err := att.Validate(); err != nil {
+		h.sendError(w, "Invalid attachment digest", http.StatusBadRequest)
+		return
+	}
+	townRoot, err := workspace.Find(h.workDir)
+	if err != nil || townRoot == "" {
+		h.sendError(w, "Not in a Gas Town workspace", http.StatusInternalServerError)
+		return
+	}
+
+	blob, err := mail.OpenAttachment(townRoot, att)
+	if err != nil {
+		h.sendError(w, "Attachment not found", http.StatusNotFound)
+		return
+	}
+	defer blob.Close()
+
+	// Always download rather than render: attachments are untrusted content.
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(att.Name)}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = io.Copy(w, blob)
+}
+
 // MailSendRequest is the request body for /api/mail/send.
 type MailSendRequest struct {
 	To      string `json:"to"`
@@ -617,6 +671,29 @@ func parseMailInboxText(output string) []MailMessage {
 	return messages
 }
 
+// parseMailReadJSON converts `gt mail read --json` output into a MailMessage.
+// Any stderr text appended after the JSON document is ignored.
+func parseMailReadJSON(output string) (MailMessage, bool) {
+	var m mail.Message
+	if err := json.NewDecoder(strings.NewReader(output)).Decode(&m); err != nil || m.ID == "" {
+		return MailMessage{}, false
+	}
+	return MailMessage{
+		ID:          m.ID,
+		From:        m.From,
+		To:          m.To,
+		Subject:     m.Subject,
+		Body:        m.Body,
+		Timestamp:   m.Timestamp.Format(time.RFC3339),
+		Read:        m.Read,
+		Priority:    string(m.Priority),
+		ThreadID:    m.ThreadID,
+		ReplyTo:     m.ReplyTo,
+		Payload:     m.Payload,
+		Attachments: m.Attachments,
+	}, true
+}
+
 // parseMailReadOutput parses the output from "gt mail read <id>".
 func parseMailReadOutput(output string, msgID string) MailMessage {
 	msg := MailMessage{ID: msgID}
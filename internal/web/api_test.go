@@ -1069,3 +1069,36 @@ func TestHandleSessionPreviewPrefixValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMailReadJSON(t *testing.T) {
+	output := `{"id":"hq-1","from":"mayor/","to":"gastown/Toast","subject":"Log","body":"see attached",` +
+		`"timestamp":"2026-03-01T09:00:00Z","priority":"high",` +
+		`"payload":{"schema":"stats/v1","data":{"n":1}},` +
+		`"attachments":[{"name":"build.log","size":3,"digest":"sha256:00"}]}` +
+		"\ngt mail read: delivery ack failed: boom\n"
+
+	msg, ok := parseMailReadJSON(output)
+	if !ok {
+		t.Fatal("parseMailReadJSON failed on JSON with trailing stderr")
+	}
+	if msg.ID != "hq-1" || msg.Body != "see attached" || msg.Priority != "high" {
+		t.Errorf("msg = %+v", msg)
+	}
+	if msg.Payload == nil || msg.Payload.Schema != "stats/v1" || len(msg.Attachments) != 1 {
+		t.Errorf("payload = %+v, attachments = %+v", msg.Payload, msg.Attachments)
+	}
+
+	if _, ok := parseMailReadJSON("Subject: hi\nFrom: mayor/\n"); ok {
+		t.Error("text output should not parse as JSON")
+	}
+}
+
+func TestHandleMailAttachment_RejectsBadDigest(t *testing.T) {
+	h := NewAPIHandler(time.Second, time.Second)
+	req := httptest.NewRequest(http.MethodGet, "/api/mail/attachment?digest=../../etc/passwd", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
@@ -1912,6 +1912,7 @@
         document.getElementById('mail-detail-from').textContent = from || '';
         document.getElementById('mail-detail-body').textContent = '';
         document.getElementById('mail-detail-time').textContent = '';
+        renderMailAttachments({});
 
         // Hide both list views and compose, show detail
         mailList.style.display = 'none';
@@ -1927,12 +1928,42 @@
                 document.getElementById('mail-detail-from').textContent = msg.from || from;
                 document.getElementById('mail-detail-body').textContent = msg.body || '(no content)';
                 document.getElementById('mail-detail-time').textContent = msg.timestamp || '';
+                renderMailAttachments(msg);
             })
             .catch(function(err) {
                 document.getElementById('mail-detail-body').textContent = 'Error loading message: ' + err.message;
             });
     }
 
+    // Render a message's typed payload schema and attachment download links
+    // below the body. Built with DOM nodes so names are never parsed as HTML.
+    function renderMailAttachments(msg) {
+        var body = document.getElementById('mail-detail-body');
+        var box = document.getElementById('mail-detail-attachments');
+        if (!box) {
+            box = document.createElement('div');
+            box.id = 'mail-detail-attachments';
+            box.className = 'mail-detail-attachments';
+            body.parentNode.insertBefore(box, body.nextSibling);
+        }
+        box.textContent = '';
+        if (msg.payload && msg.payload.schema) {
+            var schema = document.createElement('div');
+            schema.textContent = 'Payload: ' + msg.payload.schema;
+            box.appendChild(schema);
+        }
+        (msg.attachments || []).forEach(function(a) {
+            var link = document.createElement('a');
+            link.href = '/api/mail/attachment?digest=' + encodeURIComponent(a.digest) +
+                '&name=' + encodeURIComponent(a.name);
+            link.textContent = '📎 ' + a.name + ' (' + a.size + ' bytes)';
+            link.setAttribute('download', a.name);
+            var row = document.createElement('div');
+            row.appendChild(link);
+            box.appendChild(row);
+        });
+    }
+
     // Back button from detail view - return to correct tab
     document.getElementById('mail-back-btn').addEventListener('click', function() {
         mailDetail.style.display = 'none';